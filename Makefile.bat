@@ -1,4 +1,4 @@
-.PHONY: help setup run-api run-worker run-all stop test clean migrate db-connect rabbitmq-ui
+.PHONY: help setup docs run-api run-worker run-outbox-relay run-all stop test clean migrate db-connect rabbitmq-ui
 
 # Colors for output
 GREEN = \033[0;32m
@@ -10,8 +10,10 @@ help:
 	@echo "$(YELLOW)Ethiopian Payment Gateway - Make Commands$(NC)"
 	@echo ""
 	@echo "$(GREEN)setup$(NC)           - Setup database and dependencies"
+	@echo "$(GREEN)docs$(NC)            - Regenerate the OpenAPI document from handler annotations"
 	@echo "$(GREEN)run-api$(NC)         - Run the API server"
 	@echo "$(GREEN)run-worker$(NC)      - Run the payment processor worker"
+	@echo "$(GREEN)run-outbox-relay$(NC) - Publish outbox messages (outbox.enabled)"
 	@echo "$(GREEN)run-all$(NC)         - Run both API and worker"
 	@echo "$(GREEN)stop$(NC)            - Stop all services"
 	@echo "$(GREEN)test$(NC)            - Test the API with sample Ethiopian data"
@@ -29,10 +31,14 @@ setup:
 	@powershell -Command "& {$$env:PGPASSWORD='postgres'; & 'C:\Program Files\PostgreSQL\15\bin\psql.exe' -U postgres -c \"CREATE DATABASE IF NOT EXISTS ethiopian_payments;\"}"
 	@echo "$(GREEN)✅ Setup complete!$(NC)"
 
+docs:
+	@echo "$(YELLOW)Generating OpenAPI document...$(NC)"
+	@go generate ./internal/apidoc
+
 run-api:
 	@echo "$(YELLOW)Starting Ethiopian Payment Gateway API...$(NC)"
 	@echo "Server will run on: http://localhost:8080"
-	@echo "API Documentation: http://localhost:8080/docs"
+	@echo "API Documentation: http://localhost:8080/api/v1/docs"
 	@go run cmd/api/main.go
 
 run-worker:
@@ -40,6 +46,10 @@ run-worker:
 	@echo "Worker is processing payments in ETB and USD..."
 	@go run cmd/worker/main.go
 
+run-outbox-relay:
+	@echo "$(YELLOW)Starting outbox relay...$(NC)"
+	@go run cmd/outbox-relay/main.go
+
 run-all:
 	@echo "$(YELLOW)Starting both API and Worker...$(NC)"
 	@echo "Open two separate terminals and run:"
@@ -61,7 +71,7 @@ clean:
 
 migrate:
 	@echo "$(YELLOW)Running database migrations...$(NC)"
-	@powershell -Command "& {$$env:PGPASSWORD='postgres'; & 'C:\Program Files\PostgreSQL\15\bin\psql.exe' -U postgres -d ethiopian_payments -f migrations\001_init_schema.up.sql}"
+	@powershell -Command "& {$$env:PGPASSWORD='postgres'; Get-ChildItem migrations\*.up.sql | Sort-Object Name | ForEach-Object { & 'C:\Program Files\PostgreSQL\15\bin\psql.exe' -U postgres -d ethiopian_payments -f $$_.FullName }}"
 	@echo "$(GREEN)✅ Migrations applied!$(NC)"
 
 db-connect:
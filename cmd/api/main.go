@@ -2,34 +2,62 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"payment-gateway/internal/api"
+	"payment-gateway/internal/bank"
 	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/geoip"
+	"payment-gateway/internal/grpcapi"
+	"payment-gateway/internal/iso8583"
 	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/notification"
+	"payment-gateway/internal/pdf"
+	"payment-gateway/internal/provider/registry"
 	"payment-gateway/internal/repository"
+	"payment-gateway/internal/screening"
 	"payment-gateway/internal/service"
+	"payment-gateway/internal/storage"
+	"payment-gateway/internal/tracing"
+	"payment-gateway/migrations"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 )
 
+// @title Ethiopian Payment Gateway API
+// @version 1.0.0
+// @description Payments in ETB (Ethiopian Birr), USD, EUR, GBP, AED or CNY through Ethiopian banks and wallets.
+// @description Merchant endpoints take "Authorization: Bearer <API key>"; a key issued with scopes (payments:create, payments:read, refunds:create, statistics:read) only reaches the endpoints they cover. Statistics, dashboard and analytics take a JWT with any role once jwt.secret is set; a merchant JWT stops working within 30s of its API key being revoked or the merchant suspended.
+// @description Merchant endpoints answer 429 with Retry-After once a merchant (or, without a key, an IP) exceeds its rate_limit.
+// @description purpose_code and mcc are required for foreign currency payments and for ETB payments above 100,000. Business hours are 8:00 AM - 5:00 PM Ethiopian Time (GMT+3).
+// @BasePath /api/v1
+// @securityDefinitions.apikey OperatorToken
+// @in header
+// @name Authorization
+// @description "Bearer <operator token>", or a JWT with the admin or readonly role
+// @securityDefinitions.apikey AgentToken
+// @in header
+// @name Authorization
+// @description "Bearer <agent token>" for a partner agent system or a network agent
 func main() {
 	// Initialize logger with Ethiopian context
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02 15:04:05 EAT",
-	})
+	logger.SetFormatter(etime.LogFormatter(&logrus.JSONFormatter{
+		TimestampFormat: etime.DateTimeLayout,
+	}))
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.InfoLevel)
 
-	// Ethiopian time (GMT+3)
-	ethiopianTime := time.Now().Add(3 * time.Hour)
-
 	logger.Info("Starting Ethiopian Payment Gateway API...")
 	logger.Info("የኢትዮጵያ ክፍያ ግብይት መተግበሪያ እየተጀመረ ነው...")
 
@@ -38,6 +66,24 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to load configuration: ", err)
 	}
+	logger.SetLevel(cfg.Logging.LogrusLevel())
+
+	// Tunable settings are applied again on SIGHUP or when config.yaml
+	// changes; see the subscribers below
+	reloader := config.NewReloader(cfg, logger)
+
+	// Tracing; spans are flushed on shutdown
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: "payment-gateway-api",
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to set up tracing: ", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Database connection
 	dbDSN := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -65,30 +111,447 @@ func main() {
 
 	logger.Info("Connected to PostgreSQL database successfully")
 
-	// RabbitMQ connection
-	rabbitConfig := messaging.RabbitMQConfig{
-		URL:           cfg.RabbitMQ.URL,
-		QueueName:     cfg.RabbitMQ.QueueName,
-		Exchange:      cfg.RabbitMQ.Exchange,
-		ConsumerTag:   cfg.RabbitMQ.ConsumerTag,
-		PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+	// Dependencies /readyz and /api/v1/health report on
+	healthChecks := map[string]func(ctx context.Context) error{
+		"database": dbPool.Ping,
+		"migrations": func(ctx context.Context) error {
+			return migrations.Check(ctx, dbPool)
+		},
 	}
 
-	rabbitClient, err := messaging.NewRabbitMQClient(rabbitConfig, logger)
-	if err != nil {
-		logger.Fatal("Failed to connect to RabbitMQ: ", err)
+	// Read replicas take payment listings and searches off the primary
+	var replicaPools []*pgxpool.Pool
+	for i, dsn := range cfg.Database.ReadDSNs {
+		replica, err := pgxpool.New(context.Background(), strings.TrimSpace(dsn))
+		if err != nil {
+			logger.Fatalf("Failed to connect to read replica %d: %v", i+1, err)
+		}
+		defer replica.Close()
+		if err := replica.Ping(pingCtx); err != nil {
+			logger.Fatalf("Read replica %d ping failed: %v", i+1, err)
+		}
+		replicaPools = append(replicaPools, replica)
+	}
+	if len(replicaPools) > 0 {
+		logger.WithField("replicas", len(replicaPools)).Info("Connected to PostgreSQL read replicas")
 	}
-	defer rabbitClient.Close()
 
-	logger.Info("Connected to RabbitMQ successfully")
+	// Event bodies over the threshold go to object storage; only their key is queued
+	var claimStore storage.ObjectStore
+	if cfg.RabbitMQ.ClaimCheck.Enabled {
+		claimStore, err = storage.FromConfig(cfg.RabbitMQ.ClaimCheck.Storage, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize claim check storage: ", err)
+		}
+	}
+	claims := messaging.NewClaimCheck(claimStore, cfg.RabbitMQ.ClaimCheck.Threshold, logger)
+
+	// Queue messages go through the outbox, published by cmd/outbox-relay,
+	// or straight to the broker
+	var publisher messaging.PaymentPublisher
+	var events messaging.EventPublisher
+	var deadLetters messaging.DeadLetters
+	if cfg.Outbox.Enabled {
+		outboxRepo := repository.NewOutboxRepository(dbPool, logger)
+		publisher = messaging.NewOutboxPublisher(outboxRepo, logger)
+		events = messaging.NewOutboxEventPublisher(outboxRepo, claims, logger)
+		logger.Info("Writing queue messages to the outbox")
+
+		// The admin DLQ endpoints still need the broker; without it they
+		// answer 503 and the API carries on
+		broker, err := messaging.BrokerFromConfig(cfg, logger)
+		if err != nil {
+			logger.WithError(err).Warn("Message broker unreachable; dead letter queue endpoints disabled")
+		} else {
+			defer broker.Close()
+			deadLetters, _ = broker.(messaging.DeadLetters)
+		}
+	} else {
+		broker, err := messaging.BrokerFromConfig(cfg, logger)
+		if err != nil {
+			logger.Fatal("Failed to connect to the message broker: ", err)
+		}
+		defer broker.Close()
+		// With the outbox the relay publishes, so only here does the API
+		// need the broker to take payments
+		healthChecks["message_broker"] = broker.Ping
+
+		publisher = messaging.NewPaymentPublisher(broker, logger)
+		events = messaging.NewEventPublisher(broker, claims, logger)
+		deadLetters, _ = broker.(messaging.DeadLetters)
+	}
 
 	// Initialize dependencies
-	paymentRepo := repository.NewPaymentRepository(dbPool, logger)
-	publisher := messaging.NewPaymentPublisher(rabbitClient, logger)
-	paymentService := service.NewPaymentService(paymentRepo, publisher, logger)
+	paymentRepo := repository.NewPaymentRepository(dbPool, replicaPools, logger)
+	transactor := repository.NewTransactor(dbPool, logger)
+	notificationRepo := repository.NewNotificationRepository(dbPool, logger)
+	telegramRepo := repository.NewTelegramChatRepository(dbPool, logger)
+	templateRepo := repository.NewNotificationTemplateRepository(dbPool, logger)
+	preferenceRepo := repository.NewNotificationPreferenceRepository(dbPool, logger)
+	pushDeviceRepo := repository.NewPushDeviceRepository(dbPool, logger)
+	otpRepo := repository.NewPaymentOTPRepository(dbPool, logger)
+	attemptRepo := repository.NewPaymentAttemptRepository(dbPool, logger)
+	overrideRepo := repository.NewStatusOverrideRepository(dbPool, logger)
+	reviewRepo := repository.NewPaymentReviewRepository(dbPool, logger)
+	customerRepo := repository.NewCustomerRepository(dbPool, logger)
+	reminderRepo := repository.NewReminderRepository(dbPool, logger)
+	receiptRepo := repository.NewReceiptLinkRepository(dbPool, logger)
+	merchantRepo := repository.NewMerchantRepository(dbPool, logger)
+	settlementRepo := repository.NewSettlementRepository(dbPool, logger)
+	bulkPayoutRepo := repository.NewBulkPayoutRepository(dbPool, logger)
+	attachmentRepo := repository.NewAttachmentRepository(dbPool, logger)
+	noteRepo := repository.NewPaymentNoteRepository(dbPool, logger)
+	voucherRepo := repository.NewCashVoucherRepository(dbPool, logger)
+	agentRepo := repository.NewAgentRepository(dbPool, logger)
+
+	// SMS notifications via Ethio Telecom
+	smsSender := notification.NewEthioTelecomSMS(notification.SMSConfig{
+		GatewayURL: cfg.Notifications.SMS.GatewayURL,
+		Username:   cfg.Notifications.SMS.Username,
+		Password:   cfg.Notifications.SMS.Password,
+		SenderID:   cfg.Notifications.SMS.SenderID,
+		Timeout:    cfg.Notifications.SMS.Timeout,
+	}, logger)
+
+	// Email receipts and merchant alerts
+	emailSender := notification.NewSMTPSender(notification.EmailConfig{
+		Host:     cfg.Notifications.Email.SMTPHost,
+		Port:     cfg.Notifications.Email.SMTPPort,
+		Username: cfg.Notifications.Email.Username,
+		Password: cfg.Notifications.Email.Password,
+		Timeout:  cfg.Notifications.Email.Timeout,
+	}, logger)
+
+	// Telegram bot for merchant chats
+	telegramBot := notification.NewTelegramBot(notification.TelegramConfig{
+		BotToken: cfg.Notifications.Telegram.BotToken,
+		Timeout:  cfg.Notifications.Telegram.Timeout,
+	}, logger)
+
+	// FCM pushes to merchant apps
+	var pushSender notification.PushSender
+	if cfg.Notifications.Push.Enabled {
+		pushSender, err = notification.NewFCMSender(notification.FCMConfig{
+			ProjectID:       cfg.Notifications.Push.ProjectID,
+			CredentialsFile: cfg.Notifications.Push.CredentialsFile,
+			Timeout:         cfg.Notifications.Push.Timeout,
+		}, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize FCM: ", err)
+		}
+	}
+
+	defaultLanguage := domain.Language(cfg.Notifications.DefaultLanguage)
+	templateService := service.NewTemplateService(templateRepo, defaultLanguage, logger)
+
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(dbPool, logger), service.WebhookSettings{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Schedule:    cfg.Webhooks.Schedule,
+		Timeout:     cfg.Webhooks.Timeout,
+		Lease:       cfg.Webhooks.Lease,
+		BatchSize:   cfg.Webhooks.BatchSize,
+	}, logger)
+
+	notificationService := service.NewNotificationService(notificationRepo, paymentRepo, merchantRepo, telegramRepo, preferenceRepo, pushDeviceRepo, templateService, smsSender, emailSender, telegramBot, pushSender, webhookService, service.NotificationSettings{
+		DefaultLanguage:  defaultLanguage,
+		SMSEnabled:       cfg.Notifications.SMS.Enabled,
+		NotifyOnSuccess:  cfg.Notifications.SMS.NotifyOnSuccess,
+		NotifyOnFailure:  cfg.Notifications.SMS.NotifyOnFailure,
+		EmailEnabled:     cfg.Notifications.Email.Enabled,
+		SendReceipts:     cfg.Notifications.Email.SendReceipts,
+		RefundReceipts:   cfg.Notifications.Email.RefundReceipts,
+		MerchantReceipts: cfg.Notifications.Email.MerchantReceipts,
+		FailureAlerts:    cfg.Notifications.Email.FailureAlerts,
+		DailySummary:     cfg.Notifications.Email.DailySummary,
+		FromName:         cfg.Notifications.Email.FromName,
+		FromAddress:      cfg.Notifications.Email.FromAddress,
+		MerchantEmails:   cfg.Notifications.Email.MerchantEmails,
+
+		TelegramEnabled:     cfg.Notifications.Telegram.Enabled,
+		TelegramBotUsername: cfg.Notifications.Telegram.BotUsername,
+
+		PushEnabled: cfg.Notifications.Push.Enabled,
+	}, logger)
+
+	// Invoices are settled as their payments succeed, wherever that happens
+	invoiceRepo := repository.NewInvoiceRepository(dbPool, logger)
+	paymentLinkRepo := repository.NewPaymentLinkRepository(dbPool, logger)
+	notificationService = service.NewInvoicingNotifier(notificationService, invoiceRepo, paymentLinkRepo, logger)
+
+	reminderService := service.NewReminderService(reminderRepo, paymentRepo, notificationService, service.ReminderSettings{
+		Enabled:  cfg.Reminders.Enabled,
+		Schedule: cfg.Reminders.Schedule,
+	}, logger)
+
+	var fxCurrencies []domain.Currency
+	for _, currency := range cfg.FX.Currencies {
+		fxCurrencies = append(fxCurrencies, domain.Currency(strings.ToUpper(currency)))
+	}
+	fxService := service.NewFXService(repository.NewFXRepository(dbPool, logger), service.FXSettings{
+		FallbackRates: fxFallbackRates(cfg),
+		QuoteTTL:      cfg.FX.QuoteTTL,
+		SpreadBps:     cfg.FX.SpreadBps,
+		Currencies:    fxCurrencies,
+	}, logger)
+
+	providers, err := registry.FromConfig(cfg.Providers, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize providers: ", err)
+	}
+
+	fraudService := service.NewFraudService(repository.NewFraudRepository(dbPool, logger), fxService, service.FraudSettings{
+		Enabled: cfg.Fraud.Enabled,
+	}, logger)
+
+	velocity := service.VelocitySettings{
+		Enabled: cfg.VelocityLimits.Enabled,
+		Banks:   make(map[string][]domain.VelocityLimit, len(cfg.VelocityLimits.Banks)),
+	}
+	for _, l := range cfg.VelocityLimits.Customer {
+		velocity.Customer = append(velocity.Customer, domain.VelocityLimit{Window: l.Window, MaxPayments: l.MaxPayments, MaxAmountETB: domain.NewAmount(l.MaxAmount)})
+	}
+	for bank, limits := range cfg.VelocityLimits.Banks {
+		for _, l := range limits {
+			velocity.Banks[strings.ToUpper(bank)] = append(velocity.Banks[strings.ToUpper(bank)], domain.VelocityLimit{Window: l.Window, MaxPayments: l.MaxPayments, MaxAmountETB: domain.NewAmount(l.MaxAmount)})
+		}
+	}
+
+	// AML screening of customer names against local lists and the API
+	var screeners []screening.Screener
+	if len(cfg.AML.ListFiles) > 0 {
+		lists, err := screening.LoadLists(cfg.AML.ListFiles, cfg.AML.MinScore)
+		if err != nil {
+			logger.Fatal("Failed to load AML watch lists: ", err)
+		}
+		screeners = append(screeners, lists)
+	}
+	if cfg.AML.API.URL != "" {
+		screeners = append(screeners, screening.NewAPIScreener(screening.APIConfig{
+			Name:     cfg.AML.API.Name,
+			URL:      cfg.AML.API.URL,
+			APIKey:   cfg.AML.API.APIKey,
+			Timeout:  cfg.AML.API.Timeout,
+			MinScore: cfg.AML.API.MinScore,
+		}, logger))
+	}
+	if cfg.AML.Enabled && len(screeners) == 0 {
+		logger.Fatal("AML screening is enabled but has no list_files or api url")
+	}
+
+	paymentService := service.NewPaymentService(paymentRepo, otpRepo, attemptRepo, overrideRepo, reviewRepo, customerRepo, transactor, publisher, notificationService, reminderService, fxService, fraudService, providers, service.OTPSettings{
+		Enabled:     cfg.OTP.Enabled,
+		CodeLength:  cfg.OTP.CodeLength,
+		TTL:         cfg.OTP.TTL,
+		MaxAttempts: cfg.OTP.MaxAttempts,
+		MaxSends:    cfg.OTP.MaxSends,
+	}, service.ReferenceSettings{
+		Prefixes: cfg.Ethiopian.ReferencePrefixes,
+	}, service.CustomerLimitSettings{
+		Enabled:  cfg.CustomerLimits.Enabled,
+		Reject:   cfg.CustomerLimits.Action != "flag",
+		Basic:    domain.LimitTier{Name: "basic", Daily: domain.NewAmount(cfg.CustomerLimits.Basic.Daily), Monthly: domain.NewAmount(cfg.CustomerLimits.Basic.Monthly)},
+		Verified: domain.LimitTier{Name: "verified", Daily: domain.NewAmount(cfg.CustomerLimits.Verified.Daily), Monthly: domain.NewAmount(cfg.CustomerLimits.Verified.Monthly)},
+	}, service.ClientControlSettings{
+		Enabled:          cfg.ClientControls.Enabled,
+		AllowedCountries: cfg.ClientControls.AllowedCountries,
+		CountryAction:    cfg.ClientControls.CountryAction,
+		BlockedIPs:       cfg.ClientControls.BlockedIPs,
+		VelocityWindow:   cfg.ClientControls.VelocityWindow,
+		VelocityMax:      cfg.ClientControls.VelocityMax,
+		VelocityAction:   cfg.ClientControls.VelocityAction,
+	}, service.AMLSettings{
+		Enabled:  cfg.AML.Enabled,
+		Screener: screening.Multi(screeners...),
+	}, velocity, service.KYCSettings{
+		RequireVerifiedAbove: domain.NewAmount(cfg.KYC.RequireVerifiedAbove),
+	}, domain.NewAmount(cfg.Ethiopian.MaxETBAmount), logger)
+
+	if cfg.Cache.Enabled {
+		paymentService = service.NewCachedPaymentService(paymentService, service.CacheSettings{
+			PaymentTTL:    cfg.Cache.PaymentTTL,
+			StatisticsTTL: cfg.Cache.StatisticsTTL,
+			MaxEntries:    cfg.Cache.MaxEntries,
+		}, logger)
+	}
+
+	var receiptFont *pdf.Font
+	if cfg.Receipts.PDFFont != "" {
+		receiptFont, err = pdf.LoadFont(cfg.Receipts.PDFFont)
+		if err != nil {
+			logger.Fatal("Failed to load receipt PDF font: ", err)
+		}
+	}
+	receiptService := service.NewReceiptService(receiptRepo, paymentRepo, merchantRepo, service.ReceiptSettings{
+		BaseURL:       cfg.Receipts.BaseURL,
+		SigningSecret: cfg.Receipts.SigningSecret,
+		PDFFont:       receiptFont,
+	}, logger)
+	paymentLinkBaseURL := cfg.PaymentLinks.BaseURL
+	if paymentLinkBaseURL == "" {
+		paymentLinkBaseURL = cfg.Receipts.BaseURL
+	}
+	paymentLinkService := service.NewPaymentLinkService(paymentLinkRepo, paymentService, providers, service.PaymentLinkSettings{
+		BaseURL: paymentLinkBaseURL,
+	}, logger)
+	invoiceService := service.NewInvoiceService(invoiceRepo, paymentRepo, paymentLinkRepo, paymentLinkService, notificationService, logger)
+	qrCodeService := service.NewQRCodeService(paymentRepo, paymentLinkRepo, domain.QRMerchant{
+		GUID:      cfg.QRCodes.GUID,
+		AccountID: cfg.QRCodes.MerchantID,
+		Name:      cfg.QRCodes.MerchantName,
+		City:      cfg.QRCodes.MerchantCity,
+		MCC:       cfg.QRCodes.MCC,
+	}, logger)
+
+	// Destination account name inquiry, for the banks that support it
+	nameInquirers := make(map[string]bank.NameInquirer)
+	for code, target := range cfg.NameInquiry.Banks {
+		code = strings.ToUpper(code)
+		nameInquirers[code] = bank.NewHTTPNameInquirer(code, bank.NameInquiryConfig{
+			URL:     target.URL,
+			APIKey:  target.APIKey,
+			Timeout: cfg.NameInquiry.Timeout,
+		}, logger)
+	}
+	accountService := service.NewAccountService(nameInquirers, logger)
+	settlementService := service.NewSettlementService(settlementRepo, logger)
+	customerService := service.NewCustomerService(customerRepo, paymentRepo, fxService, logger)
+	regulatoryService := service.NewRegulatoryService(repository.NewRegulatoryRepository(dbPool, logger), fxService, service.RegulatorySettings{
+		InstitutionCode: cfg.RegulatoryReports.InstitutionCode,
+	}, logger)
+	reconciliationService := service.NewReconciliationService(settlementRepo, service.ReconciliationSettings{
+		BookingDays: cfg.Reconciliation.BookingDays,
+		BatchSize:   cfg.Reconciliation.BatchSize,
+	}, logger)
+	dashboardService := service.NewDashboardService(paymentRepo, settlementRepo, logger)
+	analyticsService := service.NewAnalyticsService(repository.NewAnalyticsRepository(dbPool, logger), fxService, logger)
+	merchantService := service.NewMerchantService(merchantRepo, accountService, logger)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(dbPool, logger), merchantRepo, service.APIKeySettings{
+		RotationGrace: cfg.APIKeys.RotationGrace,
+	}, logger)
+
+	// Refunds are only queued here; the worker runs their sagas
+	sagaRunner := service.NewSagaRunner(repository.NewSagaRepository(dbPool, logger), service.SagaSettings{
+		MaxAttempts: cfg.Sagas.MaxAttempts,
+		Backoff:     cfg.Sagas.Backoff,
+		Lease:       cfg.Sagas.Lease,
+		BatchSize:   cfg.Sagas.BatchSize,
+	}, logger)
+	auditService := service.NewAuditService(repository.NewAuditRepository(dbPool, logger), logger)
+	deadLetterService := service.NewDeadLetterService(deadLetters, logger)
+	refundService := service.NewRefundService(repository.NewRefundRepository(dbPool, logger), paymentRepo, transactor, publisher, providers, notificationService, sagaRunner, logger)
+	disputeService := service.NewDisputeService(repository.NewDisputeRepository(dbPool, logger), paymentRepo, transactor, publisher, logger)
+	// The API only uploads jobs; the worker runs them
+	bulkPayoutService := service.NewBulkPayoutService(bulkPayoutRepo, nil, service.BulkPayoutSettings{
+		MaxRows:      cfg.BulkPayouts.MaxRows,
+		MaxETBAmount: domain.NewAmount(cfg.Ethiopian.MaxETBAmount),
+	}, logger)
 
 	// Create and start server
-	server := api.NewServer(cfg, paymentService, logger)
+	// Proof-of-payment attachments in object storage
+	var attachmentStore storage.ObjectStore
+	if cfg.Attachments.SigningSecret != "" {
+		attachmentStore, err = storage.FromConfig(cfg.Attachments.Storage, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize attachment storage: ", err)
+		}
+	}
+	attachmentService := service.NewAttachmentService(attachmentRepo, paymentRepo, attachmentStore, events, service.AttachmentSettings{
+		BaseURL:       cfg.Attachments.BaseURL,
+		SigningSecret: cfg.Attachments.SigningSecret,
+		LinkTTL:       cfg.Attachments.LinkTTL,
+		MaxSize:       cfg.Attachments.MaxSize,
+		AllowedTypes:  cfg.Attachments.AllowedTypes,
+	}, logger)
+
+	noteService := service.NewNoteService(noteRepo, paymentRepo, logger)
+	voucherService := service.NewCashVoucherService(voucherRepo, paymentRepo, notificationService, service.CashVoucherSettings{
+		Enabled: cfg.CashVouchers.Enabled,
+		TTL:     cfg.CashVouchers.TTL,
+	}, logger)
+
+	agentService := service.NewAgentService(agentRepo, voucherRepo, paymentRepo, notificationService, service.AgentSettings{
+		CommissionRate: cfg.AgentNetwork.CommissionRate,
+	}, logger)
+
+	// Country lookup for client controls
+	var geo geoip.Resolver
+	if cfg.ClientControls.GeoIPFile != "" {
+		geo, err = geoip.LoadCSV(cfg.ClientControls.GeoIPFile)
+		if err != nil {
+			logger.Fatal("Failed to load GeoIP file: ", err)
+		}
+	}
+
+	// POS and gRPC listeners; shutdown waits for their connections to close
+	var listeners sync.WaitGroup
+
+	// ISO 8583 listener for POS terminals
+	posCtx, posCancel := context.WithCancel(context.Background())
+	defer posCancel()
+	if cfg.POS.Enabled {
+		terminals := make(map[string]string, len(cfg.POS.Terminals))
+		macKeys := make(map[string][]byte, len(cfg.POS.Terminals))
+		for _, t := range cfg.POS.Terminals {
+			key, err := hex.DecodeString(t.MACKey)
+			if err != nil || len(key) < 16 {
+				logger.Fatalf("POS terminal %s needs a mac_key of at least 16 hex-encoded bytes", t.TerminalID)
+			}
+			terminals[t.TerminalID] = t.MerchantID
+			macKeys[t.TerminalID] = key
+		}
+		posService := service.NewPOSService(repository.NewPOSRepository(dbPool, logger), service.POSSettings{
+			Terminals:    terminals,
+			MaxETBAmount: domain.NewAmount(cfg.Ethiopian.MaxETBAmount),
+		}, logger)
+		reloader.Subscribe(func(cfg *config.Config) {
+			posService.SetMaxETBAmount(domain.NewAmount(cfg.Ethiopian.MaxETBAmount))
+		})
+		posServer := iso8583.NewServer(cfg.POS.ListenAddr, iso8583.NewPOSHandler(posService, macKeys, logger), cfg.POS.IdleTimeout, logger)
+		listeners.Add(1)
+		go func() {
+			defer listeners.Done()
+			if err := posServer.ListenAndServe(posCtx); err != nil {
+				logger.Fatal("POS listener failed: ", err)
+			}
+		}()
+	}
+
+	// gRPC listener for internal services
+	grpcCtx, grpcCancel := context.WithCancel(context.Background())
+	defer grpcCancel()
+	if cfg.GRPC.Enabled {
+		if len(cfg.GRPC.Clients) == 0 {
+			logger.Fatal("gRPC is enabled but grpc.clients is empty")
+		}
+		clients := make([]grpcapi.Client, len(cfg.GRPC.Clients))
+		for i, c := range cfg.GRPC.Clients {
+			if c.Name == "" || len(c.Token) < 32 {
+				logger.Fatal("each gRPC client needs a name and a token of at least 32 characters")
+			}
+			clients[i] = grpcapi.Client{Name: c.Name, Token: c.Token}
+		}
+		grpcServer := grpcapi.NewServer(cfg.GRPC.ListenAddr, clients, paymentService, geo, logger)
+		listeners.Add(1)
+		go func() {
+			defer listeners.Done()
+			if err := grpcServer.ListenAndServe(grpcCtx); err != nil {
+				logger.Fatal("gRPC listener failed: ", err)
+			}
+		}()
+	}
+
+	server := api.NewServer(cfg, paymentService, notificationService, templateService, receiptService, accountService, settlementService, reconciliationService, regulatoryService, fraudService, customerService, bulkPayoutService, attachmentService, noteService, voucherService, agentService, fxService, dashboardService, analyticsService, merchantService, refundService, disputeService, webhookService, apiKeyService, auditService, deadLetterService, paymentLinkService, qrCodeService, invoiceService, geo, healthChecks, reloader, logger)
+
+	// The rate limiter and POS service subscribed to reloads themselves
+	reloader.Subscribe(func(cfg *config.Config) {
+		logger.SetLevel(cfg.Logging.LogrusLevel())
+		fxService.SetFallbackRates(fxFallbackRates(cfg))
+		paymentService.SetMaxETBAmount(domain.NewAmount(cfg.Ethiopian.MaxETBAmount))
+		bulkPayoutService.SetMaxETBAmount(domain.NewAmount(cfg.Ethiopian.MaxETBAmount))
+	})
+	reloadCtx, reloadCancel := context.WithCancel(context.Background())
+	defer reloadCancel()
+	go reloader.Watch(reloadCtx)
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -100,20 +563,46 @@ func main() {
 		}
 	}()
 
-	// Update Ethiopian time for final log
-	ethiopianTime = time.Now().Add(3 * time.Hour)
 	logger.WithFields(logrus.Fields{
 		"port":           cfg.Server.Port,
 		"environment":    cfg.App.Environment,
-		"ethiopian_time": ethiopianTime.Format("15:04:05"),
+		"ethiopian_time": etime.Now().Format("15:04:05"),
 	}).Info("Ethiopian Payment Gateway API is running")
 
 	<-quit
 	logger.Info("Shutting down Ethiopian Payment Gateway API...")
 
-	// For now, just wait a moment for graceful shutdown
-	// In a real implementation, you would use the context to shutdown the server
-	time.Sleep(2 * time.Second)
+	// Stop taking requests and let those in flight finish; deferred closes
+	// then release the broker and, last, the database pool
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.GracefulShutdownTimeout)
+	defer shutdownCancel()
+
+	posCancel()
+	grpcCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.WithError(err).Warn("Requests still in flight at the shutdown timeout")
+	}
+
+	listenersDone := make(chan struct{})
+	go func() {
+		listeners.Wait()
+		close(listenersDone)
+	}()
+	select {
+	case <-listenersDone:
+	case <-shutdownCtx.Done():
+		logger.Warn("POS or gRPC connections still open at the shutdown timeout")
+	}
 
 	logger.Info("Ethiopian Payment Gateway API stopped successfully")
 }
+
+// fxFallbackRates are the configured rates into ETB, used until treasury
+// sets a daily rate
+func fxFallbackRates(cfg *config.Config) map[domain.Currency]float64 {
+	rates := map[domain.Currency]float64{domain.CurrencyUSD: cfg.Ethiopian.USDToETBRate}
+	for currency, rate := range cfg.FX.FallbackRates {
+		rates[domain.Currency(currency)] = rate
+	}
+	return rates
+}
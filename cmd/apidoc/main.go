@@ -0,0 +1,817 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// apidoc writes the OpenAPI document served at /api/v1/docs from the
+// swag-style annotations in the API's source: the general info above
+// cmd/api's main, and an operation for each handler with an @Router line.
+// Models named in @Param and @Success/@Failure are read from their
+// packages' struct declarations. Run it after changing a handler:
+//
+//	go generate ./internal/apidoc
+func main() {
+	root := flag.String("root", ".", "Module root")
+	out := flag.String("o", "internal/apidoc/openapi.json", "Output file")
+	flag.Parse()
+
+	doc, err := generate(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apidoc:", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apidoc:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "apidoc:", err)
+		os.Exit(1)
+	}
+}
+
+type object = map[string]any
+
+func generate(root string) (object, error) {
+	module, err := modulePath(root)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		root:     root,
+		module:   module,
+		fset:     token.NewFileSet(),
+		packages: map[string]*pkg{},
+		schemas:  object{},
+	}
+
+	info, servers, security, err := g.general(filepath.Join(root, "cmd", "api", "main.go"))
+	if err != nil {
+		return nil, err
+	}
+	paths, err := g.operations(filepath.Join(root, "internal", "api", "handlers"))
+	if err != nil {
+		return nil, err
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info":    info,
+		"servers": servers,
+		"paths":   paths,
+		"components": object{
+			"schemas":         g.schemas,
+			"securitySchemes": security,
+		},
+	}, nil
+}
+
+func modulePath(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.TrimSpace(module), nil
+		}
+	}
+	return "", fmt.Errorf("no module line in go.mod")
+}
+
+type generator struct {
+	root     string
+	module   string
+	fset     *token.FileSet
+	packages map[string]*pkg // By import path
+	schemas  object          // components.schemas, by pkg.Name
+}
+
+// annotations returns a doc comment's @ lines as name and value
+func annotations(doc *ast.CommentGroup) [][2]string {
+	if doc == nil {
+		return nil
+	}
+	var lines [][2]string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, " ")
+		lines = append(lines, [2]string{name, strings.TrimSpace(value)})
+	}
+	return lines
+}
+
+// general reads the API's title, base path and security schemes
+func (g *generator) general(mainFile string) (object, []object, object, error) {
+	file, err := parser.ParseFile(g.fset, mainFile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var doc *ast.CommentGroup
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "main" {
+			doc = fn.Doc
+		}
+	}
+
+	info := object{}
+	basePath := "/"
+	security := object{}
+	var scheme object // The @securityDefinitions block being read
+	var description []string
+	for _, a := range annotations(doc) {
+		switch name, value := a[0], a[1]; name {
+		case "@title":
+			info["title"] = value
+		case "@version":
+			info["version"] = value
+		case "@description":
+			if scheme != nil {
+				scheme["description"] = value
+			} else {
+				description = append(description, value)
+			}
+		case "@BasePath":
+			basePath = value
+		case "@securityDefinitions.apikey":
+			scheme = object{"type": "apiKey"}
+			security[value] = scheme
+		case "@in":
+			if scheme != nil {
+				scheme["in"] = value
+			}
+		case "@name":
+			if scheme != nil {
+				scheme["name"] = value
+			}
+		}
+	}
+	if info["title"] == nil || info["version"] == nil {
+		return nil, nil, nil, fmt.Errorf("%s: main needs @title and @version", mainFile)
+	}
+	if len(description) > 0 {
+		info["description"] = strings.Join(description, "\n\n")
+	}
+	return info, []object{{"url": basePath}}, security, nil
+}
+
+var (
+	paramLine    = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)\s+(true|false)\s*(?:"([^"]*)")?`)
+	responseLine = regexp.MustCompile(`^(\d+)(?:\s+\{(\w+)\}\s+(\S+))?\s*(?:"([^"]*)")?`)
+	routerLine   = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]$`)
+)
+
+// operations reads every annotated handler in dir into OpenAPI paths
+func (g *generator) operations(dir string) (object, error) {
+	p, err := g.parseDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := object{}
+	for _, file := range p.files {
+		for _, decl := range file.ast.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			lines := annotations(fn.Doc)
+			where := g.fset.Position(fn.Pos()).String()
+
+			path, method, op, err := g.operation(p, file, lines)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", where, fn.Name.Name, err)
+			}
+			if op == nil {
+				continue
+			}
+
+			item, _ := paths[path].(object)
+			if item == nil {
+				item = object{}
+				paths[path] = item
+			}
+			if _, dup := item[method]; dup {
+				return nil, fmt.Errorf("%s: %s %s is documented twice", where, strings.ToUpper(method), path)
+			}
+			item[method] = op
+		}
+	}
+	return paths, nil
+}
+
+func (g *generator) operation(p *pkg, file *sourceFile, lines [][2]string) (string, string, object, error) {
+	op := object{}
+	var path, method string
+	var accept, produce, tags, description []string
+	var params, formFields []object
+	var body object
+	responses := object{}
+	var security []object
+
+	for _, a := range lines {
+		name, value := a[0], a[1]
+		switch name {
+		case "@Summary":
+			op["summary"] = value
+		case "@Description":
+			description = append(description, value)
+		case "@Tags":
+			for _, tag := range strings.Split(value, ",") {
+				tags = append(tags, strings.TrimSpace(tag))
+			}
+		case "@Accept":
+			accept = append(accept, mimeTypes(value)...)
+		case "@Produce":
+			produce = append(produce, mimeTypes(value)...)
+		case "@Security":
+			security = append(security, object{value: []string{}})
+		case "@Router":
+			m := routerLine.FindStringSubmatch(value)
+			if m == nil {
+				return "", "", nil, fmt.Errorf("malformed @Router %q", value)
+			}
+			path, method = m[1], strings.ToLower(m[2])
+		case "@Param":
+			m := paramLine.FindStringSubmatch(value)
+			if m == nil {
+				return "", "", nil, fmt.Errorf("malformed @Param %q", value)
+			}
+			paramName, in, dataType, required, desc := m[1], m[2], m[3], m[4] == "true", m[5]
+			switch in {
+			case "body":
+				schema, err := g.dataType(p, file, dataType)
+				if err != nil {
+					return "", "", nil, err
+				}
+				body = object{"required": required, "schema": schema}
+				if desc != "" {
+					body["description"] = desc
+				}
+			case "formData":
+				field := object{"name": paramName, "required": required, "schema": primitive(dataType)}
+				if desc != "" {
+					field["schema"].(object)["description"] = desc
+				}
+				formFields = append(formFields, field)
+			case "path", "query", "header":
+				param := object{"name": paramName, "in": in, "required": required || in == "path", "schema": primitive(dataType)}
+				if desc != "" {
+					param["description"] = desc
+				}
+				params = append(params, param)
+			default:
+				return "", "", nil, fmt.Errorf("@Param %s: unknown location %q", paramName, in)
+			}
+		case "@Success", "@Failure":
+			m := responseLine.FindStringSubmatch(value)
+			if m == nil {
+				return "", "", nil, fmt.Errorf("malformed %s %q", name, value)
+			}
+			response, err := g.response(p, file, m[1], m[2], m[3], m[4], produce)
+			if err != nil {
+				return "", "", nil, err
+			}
+			responses[m[1]] = response
+		}
+	}
+	if path == "" {
+		return "", "", nil, nil
+	}
+
+	if len(tags) > 0 {
+		op["tags"] = tags
+	}
+	if len(description) > 0 {
+		op["description"] = strings.Join(description, "\n")
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if body != nil {
+		if len(accept) == 0 {
+			accept = []string{"application/json"}
+		}
+		content := object{}
+		for _, mime := range accept {
+			content[mime] = object{"schema": body["schema"]}
+		}
+		body["content"] = content
+		delete(body, "schema")
+		op["requestBody"] = body
+	}
+	if len(formFields) > 0 {
+		op["requestBody"] = formBody(formFields, accept)
+	}
+	if len(responses) == 0 {
+		responses["default"] = object{"description": "Response"}
+	}
+	op["responses"] = responses
+	if len(security) > 0 {
+		op["security"] = security
+	}
+	return path, method, op, nil
+}
+
+// response builds a @Success or @Failure response. Handlers answer errors
+// in JSON whatever they produce on success, so object and array bodies
+// fall back to JSON when the handler does not produce it.
+func (g *generator) response(p *pkg, file *sourceFile, code, kind, dataType, desc string, produce []string) (object, error) {
+	if desc == "" {
+		status, _ := strconv.Atoi(code)
+		desc = http.StatusText(status)
+	}
+	response := object{"description": desc}
+	if kind == "" {
+		return response, nil
+	}
+
+	var schema object
+	switch kind {
+	case "object":
+		s, err := g.dataType(p, file, dataType)
+		if err != nil {
+			return nil, err
+		}
+		schema = s
+	case "array":
+		items, err := g.dataType(p, file, dataType)
+		if err != nil {
+			return nil, err
+		}
+		schema = object{"type": "array", "items": items}
+	case "file":
+		schema = object{"type": "string", "format": "binary"}
+	default:
+		schema = primitive(kind)
+	}
+
+	mimes := produce
+	if kind == "object" || kind == "array" {
+		hasJSON := false
+		for _, mime := range produce {
+			hasJSON = hasJSON || mime == "application/json"
+		}
+		if !hasJSON {
+			mimes = []string{"application/json"}
+		}
+	} else if len(mimes) == 0 {
+		mimes = []string{"application/octet-stream"}
+	}
+
+	content := object{}
+	for _, mime := range mimes {
+		content[mime] = object{"schema": schema}
+	}
+	response["content"] = content
+	return response, nil
+}
+
+func formBody(fields []object, accept []string) object {
+	mime := "application/x-www-form-urlencoded"
+	for _, a := range accept {
+		if a == "multipart/form-data" {
+			mime = a
+		}
+	}
+
+	properties := object{}
+	var required []string
+	for _, f := range fields {
+		name := f["name"].(string)
+		properties[name] = f["schema"]
+		if f["required"].(bool) {
+			required = append(required, name)
+		}
+		if f["schema"].(object)["format"] == "binary" {
+			mime = "multipart/form-data"
+		}
+	}
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return object{"required": true, "content": object{mime: object{"schema": schema}}}
+}
+
+var mimeAliases = map[string]string{
+	"json":                  "application/json",
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+	"png":                   "image/png",
+	"jpeg":                  "image/jpeg",
+	"gif":                   "image/gif",
+}
+
+func mimeTypes(value string) []string {
+	var mimes []string
+	for _, m := range strings.Split(value, ",") {
+		m = strings.TrimSpace(m)
+		if alias, ok := mimeAliases[m]; ok {
+			m = alias
+		}
+		mimes = append(mimes, m)
+	}
+	return mimes
+}
+
+// primitive is the schema of a parameter type
+func primitive(dataType string) object {
+	switch dataType {
+	case "int", "integer":
+		return object{"type": "integer"}
+	case "number", "float":
+		return object{"type": "number"}
+	case "bool", "boolean":
+		return object{"type": "boolean"}
+	case "file":
+		return object{"type": "string", "format": "binary"}
+	}
+	return object{"type": "string"}
+}
+
+// dataType resolves an annotation's type, such as domain.Payment or
+// map[string]string, as written in file
+func (g *generator) dataType(p *pkg, file *sourceFile, dataType string) (object, error) {
+	switch {
+	case strings.HasPrefix(dataType, "[]"):
+		items, err := g.dataType(p, file, dataType[2:])
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "array", "items": items}, nil
+	case strings.HasPrefix(dataType, "map[string]"):
+		values, err := g.dataType(p, file, strings.TrimPrefix(dataType, "map[string]"))
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "object", "additionalProperties": values}, nil
+	case dataType == "interface{}" || dataType == "any":
+		return object{}, nil
+	case dataType == "string", dataType == "int", dataType == "integer", dataType == "number", dataType == "bool", dataType == "boolean":
+		return primitive(dataType), nil
+	}
+
+	expr, err := parser.ParseExpr(dataType)
+	if err != nil {
+		return nil, fmt.Errorf("unknown type %q", dataType)
+	}
+	return g.typeSchema(p, file, expr)
+}
+
+type pkg struct {
+	name  string
+	path  string
+	files []*sourceFile
+	types map[string]*typeDecl
+	enums map[string][]string // String constants by type name
+}
+
+type sourceFile struct {
+	ast     *ast.File
+	imports map[string]string // Import path by name
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *sourceFile
+}
+
+// importPath finds the package a name refers to in file, or for types
+// named in annotations, in any file of the package
+func importPath(p *pkg, file *sourceFile, name string) (string, bool) {
+	if path, ok := file.imports[name]; ok {
+		return path, true
+	}
+	for _, f := range p.files {
+		if path, ok := f.imports[name]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func (g *generator) parseDir(dir string) (*pkg, error) {
+	parsed, err := parser.ParseDir(g.fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{types: map[string]*typeDecl{}, enums: map[string][]string{}}
+	for name, astPkg := range parsed {
+		p.name = name
+		for _, f := range astPkg.Files {
+			file := &sourceFile{ast: f, imports: map[string]string{}}
+			for _, imp := range f.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				name := path[strings.LastIndex(path, "/")+1:]
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				file.imports[name] = path
+			}
+			p.files = append(p.files, file)
+
+			for _, decl := range f.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						p.types[spec.Name.Name] = &typeDecl{spec: spec, file: file}
+					case *ast.ValueSpec:
+						typ, ok := spec.Type.(*ast.Ident)
+						if !ok || gen.Tok != token.CONST {
+							continue
+						}
+						for _, value := range spec.Values {
+							if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+								s, _ := strconv.Unquote(lit.Value)
+								p.enums[typ.Name] = append(p.enums[typ.Name], s)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return p, nil
+}
+
+// load parses a package of the module by import path
+func (g *generator) load(path string) (*pkg, error) {
+	if p, ok := g.packages[path]; ok {
+		return p, nil
+	}
+	rel, ok := strings.CutPrefix(path, g.module+"/")
+	if !ok {
+		return nil, fmt.Errorf("%s is outside the module", path)
+	}
+	p, err := g.parseDir(filepath.Join(g.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	p.path = path
+	g.packages[path] = p
+	return p, nil
+}
+
+// External types by import path and name; types of the module with their
+// own JSON encoding are listed under their import path too
+var knownTypes = map[string]object{
+	"time.Time":                              {"type": "string", "format": "date-time"},
+	"time.Duration":                          {"type": "integer"},
+	"encoding/json.RawMessage":               {},
+	"net/netip.Addr":                         {"type": "string"},
+	"github.com/google/uuid.UUID":            {"type": "string", "format": "uuid"},
+	"payment-gateway/internal/domain.Amount": {"type": "number", "multipleOf": 0.01},
+}
+
+func (g *generator) typeSchema(p *pkg, file *sourceFile, expr ast.Expr) (object, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return object{"type": "string"}, nil
+		case "bool":
+			return object{"type": "boolean"}, nil
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+			return object{"type": "integer"}, nil
+		case "float32", "float64":
+			return object{"type": "number"}, nil
+		case "any", "error":
+			return object{}, nil
+		}
+		return g.named(p, t.Name)
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T", t.X)
+		}
+		path, ok := importPath(p, file, x.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown package %q", x.Name)
+		}
+		if schema, ok := knownTypes[path+"."+t.Sel.Name]; ok {
+			return schema, nil
+		}
+		if !strings.HasPrefix(path, g.module+"/") {
+			return object{}, nil
+		}
+		other, err := g.load(path)
+		if err != nil {
+			return nil, err
+		}
+		return g.named(other, t.Sel.Name)
+	case *ast.StarExpr:
+		return g.typeSchema(p, file, t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return object{"type": "string", "format": "byte"}, nil
+		}
+		items, err := g.typeSchema(p, file, t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "array", "items": items}, nil
+	case *ast.MapType:
+		values, err := g.typeSchema(p, file, t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return object{"type": "object", "additionalProperties": values}, nil
+	case *ast.InterfaceType:
+		return object{}, nil
+	case *ast.StructType:
+		return g.structSchema(p, file, t)
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// named returns a reference to a struct type, registering its schema, or
+// the schema of another type inline. String types list their constants.
+func (g *generator) named(p *pkg, name string) (object, error) {
+	if schema, ok := knownTypes[p.path+"."+name]; ok {
+		return schema, nil
+	}
+	decl, ok := p.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s.%s", p.name, name)
+	}
+
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		schema, err := g.typeSchema(p, decl.file, decl.spec.Type)
+		if err != nil {
+			return nil, err
+		}
+		if values := p.enums[name]; len(values) > 0 && schema["type"] == "string" {
+			schema = object{"type": "string", "enum": values}
+		}
+		return schema, nil
+	}
+
+	key := p.name + "." + name
+	ref := object{"$ref": "#/components/schemas/" + key}
+	if _, ok := g.schemas[key]; ok {
+		return ref, nil
+	}
+	g.schemas[key] = object{} // Placeholder for types that refer to themselves
+	schema, err := g.structSchema(p, decl.file, st)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	g.schemas[key] = schema
+	return ref, nil
+}
+
+func (g *generator) structSchema(p *pkg, file *sourceFile, st *ast.StructType) (object, error) {
+	properties := object{}
+	var required []string
+
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+		name, _, _ := strings.Cut(tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a name are flattened, as encoding/json does
+		if len(field.Names) == 0 && name == "" {
+			embedded, err := g.embedded(p, file, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range embedded["properties"].(object) {
+				properties[k] = v
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			propName := name
+			if propName == "" {
+				propName = ident.Name
+			}
+
+			schema, err := g.typeSchema(p, file, field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", ident.Name, err)
+			}
+			_, ref := schema["$ref"]
+			if !ref {
+				schema = copyObject(schema)
+				if doc := fieldDoc(field); doc != "" {
+					schema["description"] = doc
+				}
+			}
+
+			for _, rule := range strings.Split(tag.Get("validate"), ",") {
+				switch {
+				case rule == "required":
+					required = append(required, propName)
+				case strings.HasPrefix(rule, "oneof=") && !ref:
+					schema["enum"] = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+				}
+			}
+			properties[propName] = schema
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// embedded returns the schema of an embedded struct with its properties
+// inline rather than as a reference
+func (g *generator) embedded(p *pkg, file *sourceFile, expr ast.Expr) (object, error) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	owner, name := p, ""
+	switch t := expr.(type) {
+	case *ast.Ident:
+		name = t.Name
+	case *ast.SelectorExpr:
+		x, _ := t.X.(*ast.Ident)
+		if x == nil {
+			return nil, fmt.Errorf("unsupported embedded type")
+		}
+		path, _ := importPath(p, file, x.Name)
+		other, err := g.load(path)
+		if err != nil {
+			return nil, err
+		}
+		owner, name = other, t.Sel.Name
+	}
+
+	decl, ok := owner.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedded type %s", name)
+	}
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("embedded type %s is not a struct", name)
+	}
+	return g.structSchema(owner, decl.file, st)
+}
+
+func fieldDoc(field *ast.Field) string {
+	doc := field.Doc
+	if doc == nil {
+		doc = field.Comment
+	}
+	if doc == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+func copyObject(o object) object {
+	c := make(object, len(o)+1)
+	for k, v := range o {
+		c[k] = v
+	}
+	return c
+}
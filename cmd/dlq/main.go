@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dlq lets operators look at and clear the dead letter queue from a shell,
+// the same as the /api/v1/admin/dlq endpoints:
+//
+//	dlq list [-limit 50]
+//	dlq requeue [-all] [message-id...]
+//	dlq purge -yes
+func main() {
+	logger := logrus.New()
+	logger.SetFormatter(etime.LogFormatter(&logrus.JSONFormatter{
+		TimestampFormat: etime.DateTimeLayout,
+	}))
+	logger.SetOutput(os.Stderr)
+	logger.SetLevel(logrus.InfoLevel)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load configuration: ", err)
+	}
+
+	broker, err := messaging.BrokerFromConfig(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to connect to the message broker: ", err)
+	}
+	defer broker.Close()
+
+	queue, _ := broker.(messaging.DeadLetters)
+	deadLetters := service.NewDeadLetterService(queue, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	operator := os.Getenv("USER")
+	if operator == "" {
+		operator = "cli"
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	switch os.Args[1] {
+	case "list":
+		limit := flags.Int("limit", 50, "messages to list (max 500)")
+		flags.Parse(os.Args[2:])
+
+		letters, err := deadLetters.List(ctx, *limit)
+		if err != nil {
+			logger.Fatal("Failed to list dead letters: ", err)
+		}
+		if letters == nil {
+			letters = []*domain.DeadLetter{}
+		}
+		printJSON(letters)
+
+	case "requeue":
+		all := flags.Bool("all", false, "requeue every dead letter")
+		flags.Parse(os.Args[2:])
+
+		requeued, err := deadLetters.Requeue(ctx, domain.RequeueDeadLettersRequest{
+			MessageIDs: flags.Args(),
+			All:        *all,
+		}, operator)
+		if err != nil {
+			logger.Fatal("Failed to requeue dead letters: ", err)
+		}
+		printJSON(map[string]int{"requeued": requeued})
+
+	case "purge":
+		yes := flags.Bool("yes", false, "confirm dropping every dead letter")
+		flags.Parse(os.Args[2:])
+		if !*yes {
+			logger.Fatal("purge drops every dead letter; pass -yes to confirm")
+		}
+
+		purged, err := deadLetters.Purge(ctx, operator)
+		if err != nil {
+			logger.Fatal("Failed to purge dead letters: ", err)
+		}
+		printJSON(map[string]int{"purged": purged})
+
+	default:
+		usage()
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dlq list [-limit N] | requeue [-all] [message-id...] | purge -yes")
+	os.Exit(2)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/repository"
+	"payment-gateway/internal/tracing"
+	"payment-gateway/internal/worker"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// The outbox relay publishes the queue messages the API writes to the
+// outbox table. Run it alongside the API and worker when outbox.enabled is
+// set; several relays may run at once.
+func main() {
+	// Initialize logger
+	logger := logrus.New()
+	logger.SetFormatter(etime.LogFormatter(&logrus.JSONFormatter{
+		TimestampFormat: etime.DateTimeLayout,
+	}))
+	logger.SetOutput(os.Stdout)
+	logger.SetLevel(logrus.InfoLevel)
+
+	logger.Info("Starting Ethiopian Payment Gateway outbox relay...")
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load configuration: ", err)
+	}
+	if !cfg.Outbox.Enabled {
+		logger.Warn("outbox.enabled is off; the API publishes to RabbitMQ directly and the outbox stays empty")
+	}
+
+	// Tracing; spans are flushed on shutdown
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: "payment-gateway-outbox-relay",
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to set up tracing: ", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Database connection
+	dbDSN := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.Name,
+		cfg.Database.SSLMode,
+	)
+
+	dbPool, err := pgxpool.New(context.Background(), dbDSN)
+	if err != nil {
+		logger.Fatal("Failed to connect to database: ", err)
+	}
+	defer dbPool.Close()
+
+	pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dbPool.Ping(pingCtx); err != nil {
+		logger.Fatal("Database ping failed: ", err)
+	}
+
+	logger.Info("Connected to PostgreSQL database")
+
+	// Broker connection; on RabbitMQ this declares the exchange and queues
+	// like the worker
+	broker, err := messaging.BrokerFromConfig(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to connect to the message broker: ", err)
+	}
+	defer broker.Close()
+
+	relay := messaging.NewOutboxRelay(broker, repository.NewOutboxRepository(dbPool, logger), messaging.OutboxRelaySettings{
+		BatchSize:    cfg.Outbox.BatchSize,
+		Lease:        cfg.Outbox.Lease,
+		RetryBackoff: cfg.Outbox.RetryBackoff,
+	}, logger)
+	defer relay.Close()
+
+	relayCtx, relayCancel := context.WithCancel(context.Background())
+	defer relayCancel()
+
+	job := worker.NewOutboxRelayJob(relay, logger, cfg.Outbox.PollInterval)
+	go job.Run(relayCtx)
+
+	logger.WithFields(logrus.Fields{
+		"broker":     cfg.Messaging.Broker,
+		"batch_size": cfg.Outbox.BatchSize,
+	}).Info("Outbox relay is running")
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down outbox relay...")
+	relayCancel()
+
+	time.Sleep(time.Second)
+	logger.Info("Outbox relay stopped")
+}
@@ -5,14 +5,26 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"payment-gateway/internal/api"
+	"payment-gateway/internal/bank"
 	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
 	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/notification"
+	"payment-gateway/internal/provider/registry"
 	"payment-gateway/internal/repository"
+	"payment-gateway/internal/screening"
 	"payment-gateway/internal/service"
+	"payment-gateway/internal/storage"
+	"payment-gateway/internal/tracing"
 	"payment-gateway/internal/worker"
+	"payment-gateway/migrations"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
@@ -21,15 +33,12 @@ import (
 func main() {
 	// Initialize logger
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02 15:04:05 EAT",
-	})
+	logger.SetFormatter(etime.LogFormatter(&logrus.JSONFormatter{
+		TimestampFormat: etime.DateTimeLayout,
+	}))
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.InfoLevel)
 
-	// Ethiopian time (GMT+3)
-	ethiopianTime := time.Now().Add(3 * time.Hour)
-
 	logger.Info("Starting Ethiopian Payment Processor Worker...")
 	logger.Info("የኢትዮጵያ ክፍያ ሂደት ሠራተኛ እየተጀመረ ነው...")
 
@@ -38,6 +47,20 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to load configuration: ", err)
 	}
+	logger.SetLevel(cfg.Logging.LogrusLevel())
+
+	// Tracing; spans are flushed on shutdown
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: "payment-gateway-worker",
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to set up tracing: ", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Database connection
 	dbDSN := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -64,52 +87,382 @@ func main() {
 
 	logger.Info("Connected to PostgreSQL database")
 
-	// RabbitMQ connection
-	rabbitConfig := messaging.RabbitMQConfig{
-		URL:           cfg.RabbitMQ.URL,
-		QueueName:     cfg.RabbitMQ.QueueName,
-		Exchange:      cfg.RabbitMQ.Exchange,
-		ConsumerTag:   cfg.RabbitMQ.ConsumerTag,
-		PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+	// RabbitMQ or Kafka, as configured
+	broker, err := messaging.BrokerFromConfig(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to connect to the message broker: ", err)
+	}
+	defer broker.Close()
+
+	// Event bodies over the threshold go to object storage; only their key is queued
+	var claimStore storage.ObjectStore
+	if cfg.RabbitMQ.ClaimCheck.Enabled {
+		claimStore, err = storage.FromConfig(cfg.RabbitMQ.ClaimCheck.Storage, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize claim check storage: ", err)
+		}
+	}
+	claims := messaging.NewClaimCheck(claimStore, cfg.RabbitMQ.ClaimCheck.Threshold, logger)
+	events := messaging.NewEventPublisher(broker, claims, logger)
+
+	// Initialize dependencies
+	paymentRepo := repository.NewPaymentRepository(dbPool, nil, logger)
+	transactor := repository.NewTransactor(dbPool, logger)
+	notificationRepo := repository.NewNotificationRepository(dbPool, logger)
+	telegramRepo := repository.NewTelegramChatRepository(dbPool, logger)
+	templateRepo := repository.NewNotificationTemplateRepository(dbPool, logger)
+	preferenceRepo := repository.NewNotificationPreferenceRepository(dbPool, logger)
+	merchantRepo := repository.NewMerchantRepository(dbPool, logger)
+	pushDeviceRepo := repository.NewPushDeviceRepository(dbPool, logger)
+	otpRepo := repository.NewPaymentOTPRepository(dbPool, logger)
+	attemptRepo := repository.NewPaymentAttemptRepository(dbPool, logger)
+	overrideRepo := repository.NewStatusOverrideRepository(dbPool, logger)
+	reviewRepo := repository.NewPaymentReviewRepository(dbPool, logger)
+	customerRepo := repository.NewCustomerRepository(dbPool, logger)
+	reminderRepo := repository.NewReminderRepository(dbPool, logger)
+	publisher := messaging.NewPaymentPublisher(broker, logger)
+
+	// SMS notifications via Ethio Telecom
+	smsSender := notification.NewEthioTelecomSMS(notification.SMSConfig{
+		GatewayURL: cfg.Notifications.SMS.GatewayURL,
+		Username:   cfg.Notifications.SMS.Username,
+		Password:   cfg.Notifications.SMS.Password,
+		SenderID:   cfg.Notifications.SMS.SenderID,
+		Timeout:    cfg.Notifications.SMS.Timeout,
+	}, logger)
+
+	// Email receipts and merchant alerts
+	emailSender := notification.NewSMTPSender(notification.EmailConfig{
+		Host:     cfg.Notifications.Email.SMTPHost,
+		Port:     cfg.Notifications.Email.SMTPPort,
+		Username: cfg.Notifications.Email.Username,
+		Password: cfg.Notifications.Email.Password,
+		Timeout:  cfg.Notifications.Email.Timeout,
+	}, logger)
+
+	// Telegram bot for merchant chats
+	telegramBot := notification.NewTelegramBot(notification.TelegramConfig{
+		BotToken: cfg.Notifications.Telegram.BotToken,
+		Timeout:  cfg.Notifications.Telegram.Timeout,
+	}, logger)
+
+	// FCM pushes to merchant apps
+	var pushSender notification.PushSender
+	if cfg.Notifications.Push.Enabled {
+		pushSender, err = notification.NewFCMSender(notification.FCMConfig{
+			ProjectID:       cfg.Notifications.Push.ProjectID,
+			CredentialsFile: cfg.Notifications.Push.CredentialsFile,
+			Timeout:         cfg.Notifications.Push.Timeout,
+		}, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize FCM: ", err)
+		}
+	}
+
+	defaultLanguage := domain.Language(cfg.Notifications.DefaultLanguage)
+	templateService := service.NewTemplateService(templateRepo, defaultLanguage, logger)
+
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(dbPool, logger), service.WebhookSettings{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Schedule:    cfg.Webhooks.Schedule,
+		Timeout:     cfg.Webhooks.Timeout,
+		Lease:       cfg.Webhooks.Lease,
+		BatchSize:   cfg.Webhooks.BatchSize,
+	}, logger)
+
+	notificationService := service.NewNotificationService(notificationRepo, paymentRepo, merchantRepo, telegramRepo, preferenceRepo, pushDeviceRepo, templateService, smsSender, emailSender, telegramBot, pushSender, webhookService, service.NotificationSettings{
+		DefaultLanguage:  defaultLanguage,
+		SMSEnabled:       cfg.Notifications.SMS.Enabled,
+		NotifyOnSuccess:  cfg.Notifications.SMS.NotifyOnSuccess,
+		NotifyOnFailure:  cfg.Notifications.SMS.NotifyOnFailure,
+		EmailEnabled:     cfg.Notifications.Email.Enabled,
+		SendReceipts:     cfg.Notifications.Email.SendReceipts,
+		RefundReceipts:   cfg.Notifications.Email.RefundReceipts,
+		MerchantReceipts: cfg.Notifications.Email.MerchantReceipts,
+		FailureAlerts:    cfg.Notifications.Email.FailureAlerts,
+		DailySummary:     cfg.Notifications.Email.DailySummary,
+		FromName:         cfg.Notifications.Email.FromName,
+		FromAddress:      cfg.Notifications.Email.FromAddress,
+		MerchantEmails:   cfg.Notifications.Email.MerchantEmails,
+
+		TelegramEnabled:     cfg.Notifications.Telegram.Enabled,
+		TelegramBotUsername: cfg.Notifications.Telegram.BotUsername,
+
+		PushEnabled: cfg.Notifications.Push.Enabled,
+	}, logger)
+
+	// Invoices are settled as their payments succeed, wherever that happens
+	invoiceRepo := repository.NewInvoiceRepository(dbPool, logger)
+	paymentLinkRepo := repository.NewPaymentLinkRepository(dbPool, logger)
+	notificationService = service.NewInvoicingNotifier(notificationService, invoiceRepo, paymentLinkRepo, logger)
+
+	reminderService := service.NewReminderService(reminderRepo, paymentRepo, notificationService, service.ReminderSettings{
+		Enabled:  cfg.Reminders.Enabled,
+		Schedule: cfg.Reminders.Schedule,
+	}, logger)
+
+	var fxFeed bank.RateFeed
+	if cfg.FX.Feed.Enabled {
+		fxFeed = bank.NewHTTPRateFeed(bank.RateFeedConfig{
+			Source:  cfg.FX.Feed.Source,
+			URL:     cfg.FX.Feed.URL,
+			APIKey:  cfg.FX.Feed.APIKey,
+			Timeout: cfg.FX.Feed.Timeout,
+		}, logger)
 	}
+	var fxCurrencies []domain.Currency
+	for _, currency := range cfg.FX.Currencies {
+		fxCurrencies = append(fxCurrencies, domain.Currency(strings.ToUpper(currency)))
+	}
+	fxService := service.NewFXService(repository.NewFXRepository(dbPool, logger), service.FXSettings{
+		FallbackRates: fxFallbackRates(cfg),
+		QuoteTTL:      cfg.FX.QuoteTTL,
+		SpreadBps:     cfg.FX.SpreadBps,
+		Currencies:    fxCurrencies,
+		Feed:          fxFeed,
+	}, logger)
 
-	rabbitClient, err := messaging.NewRabbitMQClient(rabbitConfig, logger)
+	providers, err := registry.FromConfig(cfg.Providers, logger)
 	if err != nil {
-		logger.Fatal("Failed to connect to RabbitMQ: ", err)
+		logger.Fatal("Failed to initialize providers: ", err)
 	}
-	defer rabbitClient.Close()
+	providers.SetRetryPolicy(registry.RetryPolicy(cfg.Worker.ProviderRetry), logger)
 
-	logger.Info("Connected to RabbitMQ")
+	fraudService := service.NewFraudService(repository.NewFraudRepository(dbPool, logger), fxService, service.FraudSettings{
+		Enabled: cfg.Fraud.Enabled,
+	}, logger)
 
-	// Initialize dependencies
-	paymentRepo := repository.NewPaymentRepository(dbPool, logger)
-	publisher := messaging.NewPaymentPublisher(rabbitClient, logger)
-	paymentService := service.NewPaymentService(paymentRepo, publisher, logger)
+	velocity := service.VelocitySettings{
+		Enabled: cfg.VelocityLimits.Enabled,
+		Banks:   make(map[string][]domain.VelocityLimit, len(cfg.VelocityLimits.Banks)),
+	}
+	for _, l := range cfg.VelocityLimits.Customer {
+		velocity.Customer = append(velocity.Customer, domain.VelocityLimit{Window: l.Window, MaxPayments: l.MaxPayments, MaxAmountETB: domain.NewAmount(l.MaxAmount)})
+	}
+	for bank, limits := range cfg.VelocityLimits.Banks {
+		for _, l := range limits {
+			velocity.Banks[strings.ToUpper(bank)] = append(velocity.Banks[strings.ToUpper(bank)], domain.VelocityLimit{Window: l.Window, MaxPayments: l.MaxPayments, MaxAmountETB: domain.NewAmount(l.MaxAmount)})
+		}
+	}
+
+	// AML screening of customer names against local lists and the API
+	var screeners []screening.Screener
+	if len(cfg.AML.ListFiles) > 0 {
+		lists, err := screening.LoadLists(cfg.AML.ListFiles, cfg.AML.MinScore)
+		if err != nil {
+			logger.Fatal("Failed to load AML watch lists: ", err)
+		}
+		screeners = append(screeners, lists)
+	}
+	if cfg.AML.API.URL != "" {
+		screeners = append(screeners, screening.NewAPIScreener(screening.APIConfig{
+			Name:     cfg.AML.API.Name,
+			URL:      cfg.AML.API.URL,
+			APIKey:   cfg.AML.API.APIKey,
+			Timeout:  cfg.AML.API.Timeout,
+			MinScore: cfg.AML.API.MinScore,
+		}, logger))
+	}
+	if cfg.AML.Enabled && len(screeners) == 0 {
+		logger.Fatal("AML screening is enabled but has no list_files or api url")
+	}
+
+	paymentService := service.NewPaymentService(paymentRepo, otpRepo, attemptRepo, overrideRepo, reviewRepo, customerRepo, transactor, publisher, notificationService, reminderService, fxService, fraudService, providers, service.OTPSettings{
+		Enabled:     cfg.OTP.Enabled,
+		CodeLength:  cfg.OTP.CodeLength,
+		TTL:         cfg.OTP.TTL,
+		MaxAttempts: cfg.OTP.MaxAttempts,
+		MaxSends:    cfg.OTP.MaxSends,
+	}, service.ReferenceSettings{
+		Prefixes: cfg.Ethiopian.ReferencePrefixes,
+	}, service.CustomerLimitSettings{
+		Enabled:  cfg.CustomerLimits.Enabled,
+		Reject:   cfg.CustomerLimits.Action != "flag",
+		Basic:    domain.LimitTier{Name: "basic", Daily: domain.NewAmount(cfg.CustomerLimits.Basic.Daily), Monthly: domain.NewAmount(cfg.CustomerLimits.Basic.Monthly)},
+		Verified: domain.LimitTier{Name: "verified", Daily: domain.NewAmount(cfg.CustomerLimits.Verified.Daily), Monthly: domain.NewAmount(cfg.CustomerLimits.Verified.Monthly)},
+	}, service.ClientControlSettings{
+		Enabled:          cfg.ClientControls.Enabled,
+		AllowedCountries: cfg.ClientControls.AllowedCountries,
+		CountryAction:    cfg.ClientControls.CountryAction,
+		BlockedIPs:       cfg.ClientControls.BlockedIPs,
+		VelocityWindow:   cfg.ClientControls.VelocityWindow,
+		VelocityMax:      cfg.ClientControls.VelocityMax,
+		VelocityAction:   cfg.ClientControls.VelocityAction,
+	}, service.AMLSettings{
+		Enabled:  cfg.AML.Enabled,
+		Screener: screening.Multi(screeners...),
+	}, velocity, service.KYCSettings{
+		RequireVerifiedAbove: domain.NewAmount(cfg.KYC.RequireVerifiedAbove),
+	}, domain.NewAmount(cfg.Ethiopian.MaxETBAmount), logger)
+
+	// Saga kinds are registered on the runner by the features that use them
+	sagaRunner := service.NewSagaRunner(repository.NewSagaRepository(dbPool, logger), service.SagaSettings{
+		MaxAttempts: cfg.Sagas.MaxAttempts,
+		Backoff:     cfg.Sagas.Backoff,
+		Lease:       cfg.Sagas.Lease,
+		BatchSize:   cfg.Sagas.BatchSize,
+	}, logger)
+	refundService := service.NewRefundService(repository.NewRefundRepository(dbPool, logger), paymentRepo, transactor, publisher, providers, notificationService, sagaRunner, logger)
 
-	// Create payment processor
+	// Create payment processor; it also takes refunds off the queue
 	processor := worker.NewPaymentProcessor(
 		paymentService,
-		rabbitClient,
+		refundService,
+		broker,
 		logger,
 		cfg.Worker.Concurrency,
 	)
 
-	// Context for graceful shutdown
+	// Bulk payout callbacks carry every row, so they are queued rather than
+	// posted by the job loop
+	bulkPayoutService := service.NewBulkPayoutService(repository.NewBulkPayoutRepository(dbPool, logger), events, service.BulkPayoutSettings{
+		MaxRows:        cfg.BulkPayouts.MaxRows,
+		MaxETBAmount:   domain.NewAmount(cfg.Ethiopian.MaxETBAmount),
+		Lease:          cfg.BulkPayouts.Lease,
+		WebhookTimeout: cfg.BulkPayouts.WebhookTimeout,
+	}, logger)
+	processor.Handle(messaging.MessageBulkPayoutCompleted, worker.BulkPayoutCompletionHandler(bulkPayoutService, claims))
+
+	// Context for graceful shutdown; jobs are waited for before the
+	// database pool closes
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	defer workerCancel()
+	var jobs sync.WaitGroup
+	runJob := func(run func(context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			run(workerCtx)
+		}()
+	}
 
 	// Start processing
 	if err := processor.Start(workerCtx); err != nil {
 		logger.Fatal("Failed to start payment processor: ", err)
 	}
 
-	// Update Ethiopian time for final log
-	ethiopianTime = time.Now().Add(3 * time.Hour)
+	// Kubernetes probes; ready while consuming from a connected broker
+	var probeServer *api.ProbeServer
+	if cfg.Worker.ProbeAddr != "" {
+		probeServer = api.NewProbeServer(cfg.Worker.ProbeAddr, map[string]func(ctx context.Context) error{
+			"database": dbPool.Ping,
+			"migrations": func(ctx context.Context) error {
+				return migrations.Check(ctx, dbPool)
+			},
+			"consumer": processor.Check,
+		}, logger)
+		go func() {
+			if err := probeServer.Start(); err != nil {
+				logger.Fatal("Probe listener failed: ", err)
+			}
+		}()
+	}
+
+	// Daily merchant summary emails
+	summaryJob := worker.NewDailySummaryJob(notificationService, logger, cfg.Notifications.Email.DailySummaryTime)
+	runJob(summaryJob.Run)
+
+	// Reminders for payments still awaiting the customer
+	if cfg.Reminders.Enabled {
+		reminderJob := worker.NewReminderJob(reminderService, logger, cfg.Reminders.PollInterval)
+		runJob(reminderJob.Run)
+	}
+
+	// Daily reference FX rates into the rate history
+	if fxFeed != nil {
+		fxFeedJob := worker.NewFXFeedJob(fxService, logger, cfg.FX.Feed.PollInterval)
+		runJob(fxFeedJob.Run)
+	}
+
+	// Resolve payments stuck in PROCESSING via each bank's status inquiry
+	if cfg.StatusRequery.Enabled {
+		statusInquirers := make(map[string]bank.StatusInquirer)
+		for code, target := range cfg.StatusRequery.Banks {
+			code = strings.ToUpper(code)
+			statusInquirers[code] = bank.NewHTTPStatusInquirer(code, bank.StatusInquiryConfig{
+				URL:     target.URL,
+				APIKey:  target.APIKey,
+				Timeout: cfg.StatusRequery.Timeout,
+			}, logger)
+		}
+		requeryService := service.NewRequeryService(paymentRepo, attemptRepo, notificationService, statusInquirers, providers, service.RequerySettings{
+			StaleAfter: cfg.StatusRequery.StaleAfter,
+			BatchSize:  cfg.StatusRequery.BatchSize,
+		}, logger)
+		requeryJob := worker.NewRequeryJob(requeryService, logger, cfg.StatusRequery.PollInterval)
+		runJob(requeryJob.Run)
+	}
+
+	// Expire payments left PENDING, e.g. after a lost queue message
+	if cfg.PaymentExpiry.Enabled {
+		expiryService := service.NewExpiryService(paymentRepo, transactor, publisher, notificationService, reminderService, service.ExpirySettings{
+			TTL:       cfg.PaymentExpiry.TTL,
+			BatchSize: cfg.PaymentExpiry.BatchSize,
+		}, logger)
+		expiryJob := worker.NewExpiryJob(expiryService, logger, cfg.PaymentExpiry.PollInterval)
+		runJob(expiryJob.Run)
+	}
+
+	// Previous business day's NBE transaction report
+	if cfg.RegulatoryReports.Scheduled {
+		regulatoryService := service.NewRegulatoryService(repository.NewRegulatoryRepository(dbPool, logger), fxService, service.RegulatorySettings{
+			InstitutionCode: cfg.RegulatoryReports.InstitutionCode,
+		}, logger)
+		regulatoryJob := worker.NewRegulatoryReportJob(regulatoryService, logger, cfg.RegulatoryReports.RunTime)
+		runJob(regulatoryJob.Run)
+	}
+
+	// Match uploaded bank statements to payments
+	if cfg.Reconciliation.Enabled {
+		reconciliationService := service.NewReconciliationService(repository.NewSettlementRepository(dbPool, logger), service.ReconciliationSettings{
+			BookingDays: cfg.Reconciliation.BookingDays,
+			BatchSize:   cfg.Reconciliation.BatchSize,
+		}, logger)
+		reconciliationJob := worker.NewReconciliationJob(reconciliationService, logger, cfg.Reconciliation.PollInterval)
+		runJob(reconciliationJob.Run)
+	}
+
+	// Resume sagas waiting to retry a step or left running by a crash
+	sagaJob := worker.NewSagaJob(sagaRunner, logger, cfg.Sagas.PollInterval)
+	runJob(sagaJob.Run)
+
+	// Send merchant webhooks queued when payments finished
+	webhookJob := worker.NewWebhookJob(webhookService, logger, cfg.Webhooks.PollInterval)
+	runJob(webhookJob.Run)
+
+	// Pay out queued bulk payout files
+	if cfg.BulkPayouts.Enabled {
+		bulkPayoutJob := worker.NewBulkPayoutJob(bulkPayoutService, logger, cfg.BulkPayouts.PollInterval)
+		runJob(bulkPayoutJob.Run)
+	}
+
+	// Daily settlement run for the agent network
+	if cfg.AgentNetwork.SettlementRun {
+		agentService := service.NewAgentService(repository.NewAgentRepository(dbPool, logger), repository.NewCashVoucherRepository(dbPool, logger), paymentRepo, notificationService, service.AgentSettings{
+			CommissionRate: cfg.AgentNetwork.CommissionRate,
+		}, logger)
+		agentSettlementJob := worker.NewAgentSettlementJob(agentService, logger, cfg.AgentNetwork.SettlementRunTime)
+		runJob(agentSettlementJob.Run)
+	}
+
+	// Tunable settings are applied again on SIGHUP or when config.yaml
+	// changes
+	reloader := config.NewReloader(cfg, logger)
+	reloader.Subscribe(func(cfg *config.Config) {
+		logger.SetLevel(cfg.Logging.LogrusLevel())
+		processor.SetConcurrency(cfg.Worker.Concurrency)
+		fxService.SetFallbackRates(fxFallbackRates(cfg))
+		paymentService.SetMaxETBAmount(domain.NewAmount(cfg.Ethiopian.MaxETBAmount))
+		bulkPayoutService.SetMaxETBAmount(domain.NewAmount(cfg.Ethiopian.MaxETBAmount))
+	})
+	runJob(reloader.Watch)
+
 	logger.WithFields(logrus.Fields{
-		"workers":        cfg.Worker.Concurrency,
-		"queue":          cfg.RabbitMQ.QueueName,
-		"max_retries":    cfg.Worker.MaxRetries,
-		"ethiopian_time": ethiopianTime.Format("15:04:05"),
+		"workers":           cfg.Worker.Concurrency,
+		"queue":             cfg.RabbitMQ.QueueName,
+		"retry_tiers":       cfg.Worker.RetryTiers,
+		"provider_attempts": cfg.Worker.ProviderRetry.MaxAttempts,
+		"ethiopian_time":    etime.Now().Format("15:04:05"),
 	}).Info("Ethiopian Payment Processor is running")
 
 	// Graceful shutdown
@@ -118,9 +471,41 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down Ethiopian Payment Processor...")
+
+	// Stop taking messages and let those in flight be acked or nacked;
+	// deferred closes then release the broker and, last, the database pool
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.GracefulShutdownTimeout)
+	defer shutdownCancel()
+
 	workerCancel()
+	if err := processor.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Messages still in flight at the shutdown timeout; the broker will redeliver them")
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		logger.Warn("Background jobs still running at the shutdown timeout")
+	}
+
+	if probeServer != nil {
+		probeServer.Shutdown(shutdownCtx)
+	}
 
-	// Give some time for graceful shutdown
-	time.Sleep(2 * time.Second)
 	logger.Info("Ethiopian Payment Processor stopped successfully")
 }
+
+// fxFallbackRates are the configured rates into ETB, used until treasury
+// sets a daily rate
+func fxFallbackRates(cfg *config.Config) map[domain.Currency]float64 {
+	rates := map[domain.Currency]float64{domain.CurrencyUSD: cfg.Ethiopian.USDToETBRate}
+	for currency, rate := range cfg.FX.FallbackRates {
+		rates[domain.Currency(currency)] = rate
+	}
+	return rates
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/auth"
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// operatorAuth accepts "Authorization: Bearer <token>" for one of the
+// configured operators, or a JWT with the admin or readonly role, and
+// records the operator's name and role on the context. tokens is nil while
+// JWT auth is off.
+func operatorAuth(operators []config.OperatorConfig, tokens *auth.Tokens) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if len(operators) == 0 && tokens == nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{
+					"error": "Admin endpoints are not configured",
+				})
+			}
+
+			token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if tokens != nil && auth.LooksLikeToken(token) {
+				claims, err := tokens.Verify(token)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error":   "Invalid token",
+						"details": err.Error(),
+					})
+				}
+				if claims.Role == auth.RoleMerchant {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "The merchant role cannot use admin endpoints",
+					})
+				}
+				return admitRole(c, next, claims.Subject, claims.Role)
+			}
+
+			for _, op := range operators {
+				if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(op.Token)) == 1 {
+					return admitRole(c, next, op.Name, operatorRole(op))
+				}
+			}
+
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Invalid operator token",
+			})
+		}
+	}
+}
+
+// operatorRole is admin unless the operator is configured readonly; an
+// unknown role gets the least access
+func operatorRole(op config.OperatorConfig) auth.Role {
+	switch auth.Role(op.Role) {
+	case "", auth.RoleAdmin:
+		return auth.RoleAdmin
+	default:
+		return auth.RoleReadonly
+	}
+}
+
+// admitRole records the caller and refuses changes from readonly callers
+func admitRole(c echo.Context, next echo.HandlerFunc, name string, role auth.Role) error {
+	if role == auth.RoleReadonly && c.Request().Method != http.MethodGet && c.Request().Method != http.MethodHead {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "The readonly role cannot make changes",
+		})
+	}
+
+	c.Set(handlers.OperatorContextKey, name)
+	c.Set(handlers.RoleContextKey, role)
+	setActor(c, domain.ActorOperator, name)
+	return next(c)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// agentAuth accepts "Authorization: Bearer <token>" for one of the partner
+// agent systems or a network agent and records the agent's name (the agent
+// code for network agents) on the context.
+func agentAuth(agents []config.AgentConfig, network service.AgentService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			for _, agent := range agents {
+				if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(agent.Token)) == 1 {
+					c.Set(handlers.AgentContextKey, agent.Name)
+					setActor(c, domain.ActorAgent, agent.Name)
+					return next(c)
+				}
+			}
+
+			agent, err := network.Authenticate(c.Request().Context(), token)
+			switch {
+			case err == nil && agent.Status != domain.AgentActive:
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": domain.ErrAgentSuspended.Error(),
+				})
+			case err == nil:
+				c.Set(handlers.AgentContextKey, agent.Code)
+				c.Set(handlers.NetworkAgentContextKey, agent)
+				setActor(c, domain.ActorAgent, agent.Code)
+				return next(c)
+			case err != domain.ErrAgentNotFound:
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to authenticate agent",
+				})
+			}
+
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Invalid agent token",
+			})
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"payment-gateway/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// auditActor records every caller as anonymous, with its IP, for the audit
+// log; the auth middlewares name the caller once it is known
+func auditActor() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			setActor(c, domain.ActorAnonymous, "")
+			return next(c)
+		}
+	}
+}
+
+// setActor puts the caller on the request context, where the repositories
+// read it when they write audit events
+func setActor(c echo.Context, actorType domain.ActorType, id string) {
+	req := c.Request()
+	actor := domain.Actor{Type: actorType, ID: id, IP: c.RealIP()}
+	c.SetRequest(req.WithContext(domain.WithActor(req.Context(), actor)))
+}
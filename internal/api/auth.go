@@ -0,0 +1,123 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/auth"
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// roleAuth admits a JWT whose role is one of roles and records the caller
+// on the context: the operator name for admin and readonly, the merchant
+// ID for merchant. readonly callers may only read. A merchant token stops
+// working once its API key is revoked or the merchant suspended.
+func roleAuth(tokens *auth.Tokens, keys service.APIKeyService, roles ...auth.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, err := tokens.Verify(strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "))
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error":   "Invalid token",
+					"details": err.Error(),
+				})
+			}
+
+			admitted := false
+			for _, role := range roles {
+				admitted = admitted || claims.Role == role
+			}
+			if !admitted {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "The " + string(claims.Role) + " role cannot use this endpoint",
+				})
+			}
+
+			if claims.Role == auth.RoleMerchant {
+				merchantID, err := uuid.Parse(claims.MerchantID)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error": "Invalid token",
+					})
+				}
+				keyID, err := uuid.Parse(claims.KeyID)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error": "Invalid token",
+					})
+				}
+				switch err := keys.CheckToken(c.Request().Context(), merchantID, keyID); err {
+				case nil:
+				case domain.ErrMerchantSuspended:
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": err.Error(),
+					})
+				case domain.ErrAPIKeyNotFound:
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error":   "Invalid token",
+						"details": "the API key it was issued for is no longer usable",
+					})
+				default:
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "Failed to authenticate",
+					})
+				}
+				c.Set(handlers.MerchantContextKey, merchantID)
+				c.Set(handlers.RoleContextKey, claims.Role)
+				setActor(c, domain.ActorMerchant, merchantID.String())
+				return next(c)
+			}
+			return admitRole(c, next, claims.Subject, claims.Role)
+		}
+	}
+}
+
+// credentialAuth identifies the caller of the token endpoint by an operator
+// token or a merchant API key
+func credentialAuth(operators []config.OperatorConfig, keys service.APIKeyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			credential := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			for _, op := range operators {
+				if credential != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(op.Token)) == 1 {
+					c.Set(handlers.OperatorContextKey, op.Name)
+					c.Set(handlers.RoleContextKey, operatorRole(op))
+					return next(c)
+				}
+			}
+
+			key, err := keys.Authenticate(c.Request().Context(), credential)
+			switch {
+			case err == nil && !key.Allows(domain.ScopeStatisticsRead):
+				// A merchant JWT only reaches the reporting endpoints
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "API key lacks the " + string(domain.ScopeStatisticsRead) + " scope",
+				})
+			case err == nil:
+				c.Set(handlers.MerchantContextKey, key.MerchantID)
+				c.Set(handlers.APIKeyContextKey, key)
+				c.Set(handlers.RoleContextKey, auth.RoleMerchant)
+				return next(c)
+			case err == domain.ErrMerchantSuspended:
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": err.Error(),
+				})
+			case err != domain.ErrAPIKeyNotFound:
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to authenticate",
+				})
+			}
+
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Invalid operator token or API key",
+			})
+		}
+	}
+}
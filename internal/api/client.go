@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/geoip"
+
+	"github.com/labstack/echo/v4"
+)
+
+const deviceFingerprintHeader = "X-Device-Fingerprint"
+
+// clientInfo records the caller's IP, country and device fingerprint on the
+// context. The country comes from countryHeader when set (a CDN such as
+// Cloudflare sends CF-IPCountry), otherwise from the GeoIP resolver.
+func clientInfo(resolver geoip.Resolver, countryHeader string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			client := domain.ClientInfo{
+				IP:                c.RealIP(),
+				DeviceFingerprint: c.Request().Header.Get(deviceFingerprintHeader),
+			}
+			if len(client.DeviceFingerprint) > 128 {
+				client.DeviceFingerprint = client.DeviceFingerprint[:128]
+			}
+
+			if countryHeader != "" {
+				if country := strings.ToUpper(c.Request().Header.Get(countryHeader)); len(country) == 2 && country != "XX" {
+					client.Country = country
+				}
+			}
+			if client.Country == "" && resolver != nil {
+				if ip, err := netip.ParseAddr(client.IP); err == nil {
+					client.Country = resolver.Country(ip)
+				}
+			}
+
+			c.Set(handlers.ClientContextKey, client)
+			return next(c)
+		}
+	}
+}
+
+// ipExtractor takes the client IP from X-Forwarded-For only when the
+// request came through one of the trusted proxies
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	var options []echo.TrustOption
+	for _, cidr := range trustedProxies {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			options = append(options, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+	logger         *logrus.Logger
+}
+
+func NewAccountHandler(accountService service.AccountService, logger *logrus.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// VerifyAccount performs a name inquiry against the destination bank or wallet
+// @Summary Verify destination account
+// @Description Look up the account holder name at the destination bank/wallet so payout mistakes are caught before money moves
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param account body domain.AccountVerificationRequest true "Bank code and account number"
+// @Success 200 {object} domain.AccountVerification
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /accounts/verify [post]
+func (h *AccountHandler) VerifyAccount(c echo.Context) error {
+	var req domain.AccountVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	result, err := h.accountService.VerifyAccount(c.Request().Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid account details",
+				"details": err.Error(),
+			})
+		case err == domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found at the destination bank",
+			})
+		case err == domain.ErrNameInquiryUnsupported:
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Name inquiry is not supported for this bank",
+			})
+		case err == domain.ErrNameInquiryUnavailable:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": "Destination bank is not responding, try again later",
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to verify account")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to verify account",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// OperatorContextKey holds the authenticated operator's name on admin routes
+const OperatorContextKey = "operator"
+
+type AdminHandler struct {
+	paymentService service.PaymentService
+	logger         *logrus.Logger
+}
+
+func NewAdminHandler(paymentService service.PaymentService, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		paymentService: paymentService,
+		logger:         logger,
+	}
+}
+
+// OverrideStatus sets a payment's status by hand
+// @Summary Override payment status
+// @Description Manually set a payment to SUCCESS or FAILED (e.g. after confirming with the bank). A reason is required and the operator is recorded.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param override body domain.OverrideStatusRequest true "New status and reason"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/payments/{id}/status [post]
+func (h *AdminHandler) OverrideStatus(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.OverrideStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	payment, err := h.paymentService.OverrideStatus(c.Request().Context(), id, req, operator)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case err == domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case err == domain.ErrStatusUnchanged:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).WithField("payment_id", id).Error("Failed to override payment status")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to override payment status",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, payment.ToResponse())
+}
+
+// ListStatusOverrides returns the manual status changes made to a payment
+// @Summary List payment status overrides
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.StatusOverride
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/overrides [get]
+func (h *AdminHandler) ListStatusOverrides(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	overrides, err := h.paymentService.ListStatusOverrides(c.Request().Context(), id)
+	if err == domain.ErrPaymentNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("payment_id", id).Error("Failed to list status overrides")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list status overrides",
+		})
+	}
+
+	if overrides == nil {
+		overrides = []*domain.StatusOverride{}
+	}
+
+	return c.JSON(http.StatusOK, overrides)
+}
+
+// ListReviews returns payments held by AML screening
+// @Summary List AML reviews
+// @Description The compliance review queue, oldest first. Defaults to open reviews; status= lists CLEARED or REJECTED ones instead.
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param status query string false "OPEN, CLEARED or REJECTED" default(OPEN)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.PaymentReview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /admin/reviews [get]
+func (h *AdminHandler) ListReviews(c echo.Context) error {
+	status := domain.PaymentReviewStatus(strings.ToUpper(c.QueryParam("status")))
+	if status == "" {
+		status = domain.ReviewOpen
+	}
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	reviews, err := h.paymentService.ListReviews(c.Request().Context(), status, page, limit)
+	if err != nil {
+		return h.reviewError(c, err, "Failed to list payment reviews")
+	}
+
+	if reviews == nil {
+		reviews = []*domain.PaymentReview{}
+	}
+
+	return c.JSON(http.StatusOK, reviews)
+}
+
+// GetReview returns the AML review of a payment with the watch list hits
+// @Summary Get a payment's AML review
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Success 200 {object} domain.PaymentReview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/review [get]
+func (h *AdminHandler) GetReview(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	review, err := h.paymentService.GetReview(c.Request().Context(), id)
+	if err != nil {
+		return h.reviewError(c, err, "Failed to get payment review")
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+// ReviewPayment records the compliance decision on a held payment
+// @Summary Decide an AML review
+// @Description CLEARED releases an UNDER_REVIEW payment as if it had not been held (queued, sent its OTP, or awaiting cash); REJECTED fails it. A note is required and the operator is recorded.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param decision body domain.ReviewDecisionRequest true "Decision and note"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/payments/{id}/review [post]
+func (h *AdminHandler) ReviewPayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.ReviewDecisionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	payment, err := h.paymentService.ReviewPayment(c.Request().Context(), id, req, operator)
+	if err != nil {
+		return h.reviewError(c, err, "Failed to decide payment review")
+	}
+
+	return c.JSON(http.StatusOK, payment.ToResponse())
+}
+
+func (h *AdminHandler) reviewError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrPaymentReviewNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment review not found",
+		})
+	case err == domain.ErrReviewAlreadyDecided:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AgentHandler struct {
+	agentService service.AgentService
+	logger       *logrus.Logger
+}
+
+func NewAgentHandler(agentService service.AgentService, logger *logrus.Logger) *AgentHandler {
+	return &AgentHandler{
+		agentService: agentService,
+		logger:       logger,
+	}
+}
+
+// RegisterAgent adds an agent to the network
+// @Summary Register agent
+// @Description Registers a cash-in agent with its limits. The response carries the agent's API token, which is not shown again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param agent body domain.RegisterAgentRequest true "Agent details"
+// @Success 201 {object} domain.RegisteredAgent
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/agents [post]
+func (h *AgentHandler) RegisterAgent(c echo.Context) error {
+	var req domain.RegisterAgentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	agent, err := h.agentService.Register(c.Request().Context(), req)
+	if err != nil {
+		return h.agentError(c, err, "Failed to register agent")
+	}
+
+	return c.JSON(http.StatusCreated, agent)
+}
+
+// ListAgents returns all network agents
+// @Summary List agents
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Success 200 {array} domain.Agent
+// @Router /admin/agents [get]
+func (h *AgentHandler) ListAgents(c echo.Context) error {
+	agents, err := h.agentService.List(c.Request().Context())
+	if err != nil {
+		return h.agentError(c, err, "Failed to list agents")
+	}
+
+	if agents == nil {
+		agents = []*domain.Agent{}
+	}
+
+	return c.JSON(http.StatusOK, agents)
+}
+
+// GetAgent returns an agent with its float balance
+// @Summary Get agent
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Agent ID"
+// @Success 200 {object} domain.Agent
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/agents/{id} [get]
+func (h *AgentHandler) GetAgent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid agent ID format",
+		})
+	}
+
+	agent, err := h.agentService.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.agentError(c, err, "Failed to get agent")
+	}
+
+	return c.JSON(http.StatusOK, agent)
+}
+
+// UpdateAgent suspends or reactivates an agent or changes its limits
+// @Summary Update agent
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Agent ID"
+// @Param changes body domain.UpdateAgentRequest true "Status and limits"
+// @Success 200 {object} domain.Agent
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/agents/{id} [patch]
+func (h *AgentHandler) UpdateAgent(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid agent ID format",
+		})
+	}
+
+	var req domain.UpdateAgentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	agent, err := h.agentService.Update(c.Request().Context(), id, req)
+	if err != nil {
+		return h.agentError(c, err, "Failed to update agent")
+	}
+
+	return c.JSON(http.StatusOK, agent)
+}
+
+// TopUpFloat credits an agent's float after their deposit is confirmed
+// @Summary Top up agent float
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Agent ID"
+// @Param topup body domain.TopUpFloatRequest true "Deposit"
+// @Success 201 {object} domain.AgentFloatEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/agents/{id}/float [post]
+func (h *AgentHandler) TopUpFloat(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid agent ID format",
+		})
+	}
+
+	var req domain.TopUpFloatRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	entry, err := h.agentService.TopUp(c.Request().Context(), id, req, operator)
+	if err != nil {
+		return h.agentError(c, err, "Failed to top up agent float")
+	}
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// ListFloat returns an agent's float ledger, newest first
+// @Summary Agent float ledger
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Agent ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.AgentFloatEntry
+// @Failure 400 {object} map[string]string
+// @Router /admin/agents/{id}/float [get]
+func (h *AgentHandler) ListFloat(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid agent ID format",
+		})
+	}
+
+	return h.listFloat(c, id)
+}
+
+// ListSettlements returns the agent settlement lines for a business day
+// @Summary Agent settlements
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param date query string false "Ethiopian business day, YYYY-MM-DD (default yesterday)"
+// @Success 200 {array} domain.AgentSettlement
+// @Failure 400 {object} map[string]string
+// @Router /admin/agents/settlements [get]
+func (h *AgentHandler) ListSettlements(c echo.Context) error {
+	date := c.QueryParam("date")
+	if date == "" {
+		date = etime.Date(etime.Now().AddDate(0, 0, -1))
+	}
+
+	settlements, err := h.agentService.ListSettlements(c.Request().Context(), date)
+	if err != nil {
+		return h.agentError(c, err, "Failed to list agent settlements")
+	}
+
+	if settlements == nil {
+		settlements = []*domain.AgentSettlement{}
+	}
+
+	return c.JSON(http.StatusOK, settlements)
+}
+
+// Me returns the calling network agent with its float balance and limits
+// @Summary Current agent
+// @Tags agents
+// @Produce json
+// @Security AgentToken
+// @Success 200 {object} domain.Agent
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /agent/me [get]
+func (h *AgentHandler) Me(c echo.Context) error {
+	agent, ok := c.Get(NetworkAgentContextKey).(*domain.Agent)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error":   "Not a network agent",
+			"details": "Partner systems have no float account",
+		})
+	}
+
+	return c.JSON(http.StatusOK, agent)
+}
+
+// MyFloat returns the calling network agent's float ledger
+// @Summary Current agent float ledger
+// @Tags agents
+// @Produce json
+// @Security AgentToken
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.AgentFloatEntry
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /agent/float [get]
+func (h *AgentHandler) MyFloat(c echo.Context) error {
+	agent, ok := c.Get(NetworkAgentContextKey).(*domain.Agent)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error":   "Not a network agent",
+			"details": "Partner systems have no float account",
+		})
+	}
+
+	return h.listFloat(c, agent.ID)
+}
+
+func (h *AgentHandler) listFloat(c echo.Context, id uuid.UUID) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	entries, err := h.agentService.ListFloat(c.Request().Context(), id, page, limit)
+	if err != nil {
+		return h.agentError(c, err, "Failed to list agent float entries")
+	}
+
+	if entries == nil {
+		entries = []*domain.AgentFloatEntry{}
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func (h *AgentHandler) agentError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrAgentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Agent not found",
+		})
+	case err == domain.ErrAgentExists:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
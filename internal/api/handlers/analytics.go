@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+	logger           *logrus.Logger
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService, logger *logrus.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		logger:           logger,
+	}
+}
+
+// TopCustomers ranks customers by successful ETB volume
+// @Summary Top customers
+// @Description Customers (by phone) with the highest successful volume in the range, valued in ETB.
+// @Tags analytics
+// @Produce json
+// @Param from query string false "First business day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last business day, YYYY-MM-DD (default today)"
+// @Param limit query int false "Customers to return (default 10, max 100)"
+// @Success 200 {array} domain.TopCustomer
+// @Failure 400 {object} map[string]string
+// @Router /analytics/top-customers [get]
+func (h *AnalyticsHandler) TopCustomers(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	customers, err := h.analyticsService.TopCustomers(c.Request().Context(), analyticsRange(c), limit)
+	if err != nil {
+		return h.analyticsError(c, err, "Failed to list top customers")
+	}
+
+	if customers == nil {
+		customers = []*domain.TopCustomer{}
+	}
+
+	return c.JSON(http.StatusOK, customers)
+}
+
+// RepeatRate returns the share of customers who paid more than once
+// @Summary Repeat-payment rate
+// @Tags analytics
+// @Produce json
+// @Param from query string false "First business day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last business day, YYYY-MM-DD (default today)"
+// @Success 200 {object} domain.RepeatRate
+// @Failure 400 {object} map[string]string
+// @Router /analytics/repeat-rate [get]
+func (h *AnalyticsHandler) RepeatRate(c echo.Context) error {
+	rate, err := h.analyticsService.RepeatRate(c.Request().Context(), analyticsRange(c))
+	if err != nil {
+		return h.analyticsError(c, err, "Failed to compute repeat rate")
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// BankMix returns each bank's share of successful volume over time
+// @Summary Bank mix over time
+// @Tags analytics
+// @Produce json
+// @Param from query string false "First business day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last business day, YYYY-MM-DD (default today)"
+// @Param interval query string false "day, week or month (default day)"
+// @Success 200 {array} domain.BankMixPoint
+// @Failure 400 {object} map[string]string
+// @Router /analytics/bank-mix [get]
+func (h *AnalyticsHandler) BankMix(c echo.Context) error {
+	interval := domain.AnalyticsInterval(c.QueryParam("interval"))
+
+	points, err := h.analyticsService.BankMix(c.Request().Context(), analyticsRange(c), interval)
+	if err != nil {
+		return h.analyticsError(c, err, "Failed to compute bank mix")
+	}
+
+	if points == nil {
+		points = []*domain.BankMixPoint{}
+	}
+
+	return c.JSON(http.StatusOK, points)
+}
+
+func analyticsRange(c echo.Context) domain.AnalyticsRange {
+	return domain.AnalyticsRange{
+		From:       c.QueryParam("from"),
+		To:         c.QueryParam("to"),
+		MerchantID: CallerMerchant(c),
+	}
+}
+
+func (h *AnalyticsHandler) analyticsError(c echo.Context, err error, message string) error {
+	if errors.Is(err, domain.ErrInvalidInput) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	}
+
+	h.logger.WithError(err).Error(message)
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// MerchantContextKey holds the authenticated merchant's uuid.UUID on
+// merchant API routes called with an API key
+const MerchantContextKey = "merchant_id"
+
+// APIKeyContextKey holds the *domain.APIKey the request was made with
+const APIKeyContextKey = "api_key"
+
+// CallerMerchant returns the merchant a request is scoped to, or nil for
+// operators and callers without a key, who see every merchant's data
+func CallerMerchant(c echo.Context) *uuid.UUID {
+	if id, ok := c.Get(MerchantContextKey).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// callerMerchantID is CallerMerchant as the string ID notification
+// records are keyed by
+func callerMerchantID(c echo.Context) *string {
+	if id := CallerMerchant(c); id != nil {
+		s := id.String()
+		return &s
+	}
+	return nil
+}
+
+// scopedMerchantID is the merchant a request acts for: always the caller's
+// own when a merchant key is used, otherwise whichever one was asked for
+func scopedMerchantID(c echo.Context, requested string) string {
+	if id := callerMerchantID(c); id != nil {
+		return *id
+	}
+	return requested
+}
+
+// VisibleTo reports whether a record owned by owner may be shown to the
+// caller. Merchants only see their own; anything else reads as not found.
+func VisibleTo(c echo.Context, owner *uuid.UUID) bool {
+	caller := CallerMerchant(c)
+	return caller == nil || (owner != nil && *owner == *caller)
+}
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	logger        *logrus.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// IssueAPIKey creates an API key for a merchant
+// @Summary Issue an API key
+// @Description Returns the key once; only its hash is stored. The merchant sends it as "Authorization: Bearer <key>". Give scopes (payments:create, payments:read, refunds:create, statistics:read) to limit the key, e.g. to read-only for a reporting tool; a key without scopes can call every merchant endpoint.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param key body domain.IssueAPIKeyRequest true "Key name and scopes"
+// @Success 201 {object} domain.IssuedAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/merchants/{id}/api-keys [post]
+func (h *APIKeyHandler) IssueAPIKey(c echo.Context) error {
+	merchantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+
+	var req domain.IssueAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+	key, err := h.apiKeyService.Issue(c.Request().Context(), merchantID, req, operator)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to issue API key")
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys returns a merchant's keys without the keys themselves
+// @Summary List API keys
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Success 200 {array} domain.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/merchants/{id}/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	merchantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+
+	keys, err := h.apiKeyService.List(c.Request().Context(), merchantID)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to list API keys")
+	}
+	if keys == nil {
+		keys = []*domain.APIKey{}
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RotateAPIKey replaces a merchant's key
+// @Summary Rotate an API key
+// @Description Issues a replacement with the same name and scopes. The old key keeps working for the configured grace period (24 hours by default).
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param keyId path string true "API key ID"
+// @Success 201 {object} domain.IssuedAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants/{id}/api-keys/{keyId}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c echo.Context) error {
+	merchantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid API key ID format",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+	key, err := h.apiKeyService.Rotate(c.Request().Context(), merchantID, keyID, operator)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to rotate API key")
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey stops a merchant's key at once
+// @Summary Revoke an API key
+// @Tags admin
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param keyId path string true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/merchants/{id}/api-keys/{keyId} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	merchantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid API key ID format",
+		})
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request().Context(), merchantID, keyID); err != nil {
+		return h.apiKeyError(c, err, "Failed to revoke API key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateOwnAPIKey lets a merchant replace the key the request was made with
+// @Summary Rotate the calling API key
+// @Description Issues a replacement for the key in the Authorization header. The old key keeps working for the configured grace period.
+// @Tags api-keys
+// @Produce json
+// @Success 201 {object} domain.IssuedAPIKey
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api-keys/rotate [post]
+func (h *APIKeyHandler) RotateOwnAPIKey(c echo.Context) error {
+	current, ok := c.Get(APIKeyContextKey).(*domain.APIKey)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "An API key is required",
+		})
+	}
+
+	key, err := h.apiKeyService.Rotate(c.Request().Context(), current.MerchantID, current.ID, "api-key:"+current.Prefix)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to rotate API key")
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) apiKeyError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrMerchantNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Merchant not found",
+		})
+	case err == domain.ErrAPIKeyNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "API key not found",
+		})
+	case err == domain.ErrAPIKeyRevoked:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
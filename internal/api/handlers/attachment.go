@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	logger            *logrus.Logger
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService, logger *logrus.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		logger:            logger,
+	}
+}
+
+// UploadAttachment attaches a proof-of-payment document to a payment
+// @Summary Upload payment attachment
+// @Description Attach a bank transfer slip, approval document or other evidence (PDF, JPEG or PNG). Attachments cannot be changed or removed.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param file formData file true "Document"
+// @Param kind formData string false "TRANSFER_SLIP, APPROVAL or OTHER"
+// @Success 201 {object} domain.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/payments/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "file is required",
+		})
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	// The service enforces the configured limit; this only bounds memory
+	data, err := io.ReadAll(io.LimitReader(src, header.Size+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+	kind := domain.AttachmentKind(c.FormValue("kind"))
+
+	attachment, err := h.attachmentService.Upload(c.Request().Context(), id, kind, header.Filename, data, operator)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case errors.Is(err, domain.ErrAttachmentType):
+			return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+				"error":   "File type is not allowed",
+				"details": err.Error(),
+			})
+		case err == domain.ErrAttachmentTooLarge:
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+				"error": "Attachment is too large",
+			})
+		case err == domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case err == domain.ErrAttachmentsDisabled:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to upload attachment")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to upload attachment",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments returns a payment's attachments with download links
+// @Summary List payment attachments
+// @Tags attachments
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.Attachment
+// @Failure 400 {object} map[string]string
+// @Router /admin/payments/{id}/attachments [get]
+func (h *AttachmentHandler) ListAttachments(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	attachments, err := h.attachmentService.List(c.Request().Context(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list attachments")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list attachments",
+		})
+	}
+
+	if attachments == nil {
+		attachments = []*domain.Attachment{}
+	}
+
+	return c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment serves an attachment through a signed, expiring link
+// @Summary Download payment attachment
+// @Description Links are issued in payment and attachment responses and expire after a few minutes
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Param expires query int true "Link expiry (Unix seconds)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /attachments/{id} [get]
+func (h *AttachmentHandler) DownloadAttachment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Attachment not found",
+		})
+	}
+
+	attachment, body, err := h.attachmentService.Open(c.Request().Context(), id, c.QueryParam("expires"), c.QueryParam("signature"))
+	if err != nil {
+		switch err {
+		case domain.ErrAttachmentLinkInvalid:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		case domain.ErrAttachmentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Attachment not found",
+			})
+		case domain.ErrAttachmentsDisabled:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to download attachment")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to download attachment",
+			})
+		}
+	}
+	defer body.Close()
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "private, no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.FileName))
+
+	return c.Stream(http.StatusOK, attachment.ContentType, body)
+}
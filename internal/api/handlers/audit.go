@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+	logger       *logrus.Logger
+}
+
+func NewAuditHandler(auditService service.AuditService, logger *logrus.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ListAuditEvents returns the audit log of payment, refund and back-office changes
+// @Summary List audit events
+// @Description Every payment created, payment status change, operator status override, refund issued and refund status change, and every merchant activation or suspension, API key issued, rotated or revoked, agent float top-up, dispute opened, answered or resolved, FX rate set and webhook registered or deleted, newest first, with the actor and IP that made it. Changes made by the worker are recorded against the system actor.
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param action query string false "e.g. payment.created, payment.status_changed, refund.created, merchant.status_changed, api_key.revoked, dispute.resolved, fx_rate.set"
+// @Param entity_type query string false "payment, refund, merchant, api_key, agent, dispute, fx_rate or webhook"
+// @Param entity_id query string false "ID of the entity"
+// @Param actor_type query string false "operator, merchant, agent, anonymous or system"
+// @Param actor query string false "Operator name, merchant ID or agent code"
+// @Param from query string false "RFC 3339 time or YYYY-MM-DD date, inclusive"
+// @Param to query string false "RFC 3339 time, exclusive, or YYYY-MM-DD date, inclusive"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Events per page (default 20, max 100)"
+// @Success 200 {object} domain.AuditListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /audit [get]
+func (h *AuditHandler) ListAuditEvents(c echo.Context) error {
+	filter := domain.AuditFilter{
+		Action:     domain.AuditAction(c.QueryParam("action")),
+		EntityType: c.QueryParam("entity_type"),
+		ActorType:  domain.ActorType(c.QueryParam("actor_type")),
+		Actor:      c.QueryParam("actor"),
+		From:       c.QueryParam("from"),
+		To:         c.QueryParam("to"),
+	}
+	if value := c.QueryParam("entity_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid entity_id format",
+			})
+		}
+		filter.EntityID = &id
+	}
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	events, total, err := h.auditService.ListEvents(c.Request().Context(), filter, page, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to list audit events")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list audit events",
+		})
+	}
+
+	if events == nil {
+		events = []*domain.AuditEvent{}
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return c.JSON(http.StatusOK, domain.AuditListResponse{
+		Events:  events,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: total > page*limit,
+	})
+}
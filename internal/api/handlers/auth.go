@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payment-gateway/internal/auth"
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// RoleContextKey holds the caller's auth.Role on routes that check roles
+const RoleContextKey = "role"
+
+type AuthHandler struct {
+	tokens *auth.Tokens // nil while JWT auth is off
+	logger *logrus.Logger
+}
+
+func NewAuthHandler(tokens *auth.Tokens, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		tokens: tokens,
+		logger: logger,
+	}
+}
+
+// IssueToken exchanges an operator token or merchant API key for a JWT
+// @Summary Issue an access token
+// @Description Send an operator token or merchant API key as "Authorization: Bearer <credential>". Operators get their configured role (admin or readonly), API keys the merchant role. Use the token on admin and reporting endpoints.
+// @Tags auth
+// @Produce json
+// @Success 201 {object} auth.AccessToken
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /auth/token [post]
+func (h *AuthHandler) IssueToken(c echo.Context) error {
+	if h.tokens == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Token authentication is not configured",
+		})
+	}
+
+	role, _ := c.Get(RoleContextKey).(auth.Role)
+	subject, _ := c.Get(OperatorContextKey).(string)
+	merchantID, keyID := "", ""
+	if id, ok := c.Get(MerchantContextKey).(uuid.UUID); ok {
+		subject = id.String()
+		merchantID = id.String()
+	}
+	if key, ok := c.Get(APIKeyContextKey).(*domain.APIKey); ok {
+		keyID = key.ID.String()
+	}
+
+	token, err := h.tokens.Issue(subject, role, merchantID, keyID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to issue token")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to issue token",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, token)
+}
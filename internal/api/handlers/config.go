@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payment-gateway/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ConfigHandler shows the settings the API instance is running with
+type ConfigHandler struct {
+	reloader *config.Reloader
+}
+
+func NewConfigHandler(reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{reloader: reloader}
+}
+
+// Snapshot returns the active runtime settings
+// @Summary Active configuration
+// @Description The settings a reload applies without a restart (log level, worker concurrency, rate limits, regulatory amount, fallback FX rates) as this API instance has them, when they were loaded, and why the latest reload was rejected, if it was. The API and worker reload on SIGHUP or when config.yaml changes; restart_required means other settings changed too and wait for a restart.
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Success 200 {object} config.Snapshot
+// @Failure 401 {object} map[string]string
+// @Router /admin/config [get]
+func (h *ConfigHandler) Snapshot(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.reloader.Snapshot())
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type CustomerHandler struct {
+	customerService service.CustomerService
+	paymentService  service.PaymentService
+	logger          *logrus.Logger
+}
+
+func NewCustomerHandler(customerService service.CustomerService, paymentService service.PaymentService, logger *logrus.Logger) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+		paymentService:  paymentService,
+		logger:          logger,
+	}
+}
+
+// CreateCustomer registers a KYC customer profile
+// @Summary Register customer
+// @Description Register a payer with their Fayda national ID or passport number. The customer starts PENDING until an operator verifies the documents; payments above the KYC threshold must name a VERIFIED customer in customer_id.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param customer body domain.CreateCustomerRequest true "Name, identity document and phone"
+// @Success 201 {object} domain.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
+	var req domain.CreateCustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	customer, err := h.customerService.Create(c.Request().Context(), req, CallerMerchant(c))
+	if err != nil {
+		return h.customerError(c, err, "Failed to register customer")
+	}
+
+	return c.JSON(http.StatusCreated, customer)
+}
+
+// ListCustomers returns customers, oldest first
+// @Summary List customers
+// @Description Merchants see the customers they registered; operators see all, e.g. ?status=PENDING for the verification queue.
+// @Tags customers
+// @Produce json
+// @Param status query string false "PENDING, VERIFIED or REJECTED"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.Customer
+// @Failure 400 {object} map[string]string
+// @Router /customers [get]
+func (h *CustomerHandler) ListCustomers(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	status := domain.CustomerVerificationStatus(strings.ToUpper(c.QueryParam("status")))
+
+	customers, err := h.customerService.List(c.Request().Context(), status, CallerMerchant(c), page, limit)
+	if err != nil {
+		return h.customerError(c, err, "Failed to list customers")
+	}
+
+	if customers == nil {
+		customers = []*domain.Customer{}
+	}
+
+	return c.JSON(http.StatusOK, customers)
+}
+
+// GetCustomer returns a customer profile and its verification status
+// @Summary Get customer
+// @Tags customers
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} domain.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid customer ID format",
+		})
+	}
+
+	customer, err := h.customerService.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.customerError(c, err, "Failed to get customer")
+	}
+
+	return c.JSON(http.StatusOK, customer)
+}
+
+// ListCustomerPayments returns a customer's payment history
+// @Summary List a customer's payments
+// @Description Payments linked to a registered customer, newest first, with totals over all of them: counts by outcome, amounts paid in each currency and in ETB, refunds, and the first and last payment. Payments are linked by customer_id, or by the customer's national ID or phone when created without one. A phone number in place of the ID (09..., 9..., 251... or +251...) returns the payments made with that phone as a plain list, as before customers were registered.
+// @Tags customers
+// @Produce json
+// @Param id path string true "Customer ID, or a customer phone number"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} domain.CustomerPaymentHistory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{id}/payments [get]
+func (h *CustomerHandler) ListCustomerPayments(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return h.listPhonePayments(c, c.Param("id"), page, limit)
+	}
+
+	history, err := h.customerService.ListPayments(c.Request().Context(), id, page, limit)
+	if err != nil {
+		return h.customerError(c, err, "Failed to list customer payments")
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// listPhonePayments lists the payments made with a phone number, for
+// payers who were never registered as customers
+func (h *CustomerHandler) listPhonePayments(c echo.Context, phone string, page, limit int) error {
+	payments, total, err := h.paymentService.ListCustomerPayments(c.Request().Context(), phone, CallerMerchant(c), page, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Expected a customer ID or an Ethiopian mobile number, e.g. 0911234567 or +251911234567",
+			})
+		}
+		h.logger.WithError(err).Error("Failed to list customer payments")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list customer payments",
+		})
+	}
+
+	responses := make([]domain.PaymentResponse, len(payments))
+	for i, payment := range payments {
+		responses[i] = payment.ToResponse()
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return c.JSON(http.StatusOK, domain.PaymentListResponse{
+		Payments:   responses,
+		Total:      total,
+		TotalPages: domain.TotalPages(total, limit),
+		Page:       page,
+		Limit:      limit,
+		HasMore:    total > page*limit,
+	})
+}
+
+// VerifyCustomer records the outcome of checking a customer's documents
+// @Summary Verify customer
+// @Description Mark a customer VERIFIED or REJECTED. A decision may be revised later, e.g. when a passport expires.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Customer ID"
+// @Param decision body domain.VerifyCustomerRequest true "VERIFIED or REJECTED, with a note"
+// @Success 200 {object} domain.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/customers/{id}/verify [post]
+func (h *CustomerHandler) VerifyCustomer(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid customer ID format",
+		})
+	}
+
+	var req domain.VerifyCustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	customer, err := h.customerService.Verify(c.Request().Context(), id, req, operator)
+	if err != nil {
+		return h.customerError(c, err, "Failed to verify customer")
+	}
+
+	return c.JSON(http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) customerError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrCustomerNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Customer not found",
+		})
+	case err == domain.ErrCustomerAlreadyExists:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
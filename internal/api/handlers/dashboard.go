@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payment-gateway/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type DashboardHandler struct {
+	dashboardService service.DashboardService
+	logger           *logrus.Logger
+}
+
+func NewDashboardHandler(dashboardService service.DashboardService, logger *logrus.Logger) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+		logger:           logger,
+	}
+}
+
+// GetDashboard returns the merchant portal home screen summary
+// @Summary Merchant dashboard
+// @Description Today's volume per currency, success rate, pending count, recent failures and settlement statement status, for the current Ethiopian business day.
+// @Tags dashboard
+// @Produce json
+// @Success 200 {object} domain.Dashboard
+// @Failure 500 {object} map[string]string
+// @Router /dashboard [get]
+func (h *DashboardHandler) GetDashboard(c echo.Context) error {
+	dashboard, err := h.dashboardService.Today(c.Request().Context(), CallerMerchant(c))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to build dashboard")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build dashboard",
+		})
+	}
+
+	return c.JSON(http.StatusOK, dashboard)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService service.DeadLetterService
+	logger            *logrus.Logger
+}
+
+func NewDeadLetterHandler(deadLetterService service.DeadLetterService, logger *logrus.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+		logger:            logger,
+	}
+}
+
+// ListDeadLetters returns the messages waiting in the dead letter queue
+// @Summary List dead letters
+// @Description Messages the worker gave up on after its retries, oldest first, with the failure reason, the number of retries and the message body. Listing does not take them off the queue.
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param limit query int false "Messages to return (default 50, max 500)"
+// @Success 200 {array} domain.DeadLetter
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/dlq [get]
+func (h *DeadLetterHandler) ListDeadLetters(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	letters, err := h.deadLetterService.List(c.Request().Context(), limit)
+	if err != nil {
+		return h.failed(c, err, "Failed to list dead letters")
+	}
+
+	if letters == nil {
+		letters = []*domain.DeadLetter{}
+	}
+	return c.JSON(http.StatusOK, letters)
+}
+
+// RequeueDeadLetters sends dead letters back to the worker
+// @Summary Requeue dead letters
+// @Description Send the given dead letters, or all of them, back to the worker with their retries reset. The operator is recorded in the logs.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param request body domain.RequeueDeadLettersRequest true "Message IDs, or all"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/dlq/requeue [post]
+func (h *DeadLetterHandler) RequeueDeadLetters(c echo.Context) error {
+	var req domain.RequeueDeadLettersRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	requeued, err := h.deadLetterService.Requeue(c.Request().Context(), req, operator)
+	if err != nil {
+		return h.failed(c, err, "Failed to requeue dead letters")
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{
+		"requeued": requeued,
+	})
+}
+
+// PurgeDeadLetters empties the dead letter queue
+// @Summary Purge dead letters
+// @Description Drop every message in the dead letter queue. The operator is recorded in the logs.
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Success 200 {object} map[string]int
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/dlq [delete]
+func (h *DeadLetterHandler) PurgeDeadLetters(c echo.Context) error {
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	purged, err := h.deadLetterService.Purge(c.Request().Context(), operator)
+	if err != nil {
+		return h.failed(c, err, "Failed to purge dead letters")
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{
+		"purged": purged,
+	})
+}
+
+func (h *DeadLetterHandler) failed(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case errors.Is(err, domain.ErrDeadLettersUnavailable):
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
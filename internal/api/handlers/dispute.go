@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type DisputeHandler struct {
+	disputeService service.DisputeService
+	logger         *logrus.Logger
+}
+
+func NewDisputeHandler(disputeService service.DisputeService, logger *logrus.Logger) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+		logger:         logger,
+	}
+}
+
+// OpenDispute records a chargeback the customer's bank has raised
+// @Summary Open a dispute
+// @Description Records a bank's chargeback claim against a successful payment and publishes dispute.opened. Omit amount to dispute the whole payment.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param dispute body domain.OpenDisputeRequest true "Claim details"
+// @Success 201 {object} domain.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/payments/{id}/disputes [post]
+func (h *DisputeHandler) OpenDispute(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.OpenDisputeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+	dispute, err := h.disputeService.OpenDispute(c.Request().Context(), paymentID, req, operator)
+	if err != nil {
+		return h.disputeError(c, err, "Failed to open dispute")
+	}
+
+	return c.JSON(http.StatusCreated, dispute)
+}
+
+// ListDisputes returns a payment's disputes, oldest first
+// @Summary List payment disputes
+// @Tags disputes
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/disputes [get]
+func (h *DisputeHandler) ListDisputes(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	disputes, err := h.disputeService.ListDisputes(c.Request().Context(), paymentID)
+	if err != nil {
+		return h.disputeError(c, err, "Failed to list disputes")
+	}
+	if disputes == nil {
+		disputes = []*domain.Dispute{}
+	}
+
+	return c.JSON(http.StatusOK, disputes)
+}
+
+// GetDispute returns one dispute
+// @Summary Get dispute
+// @Tags disputes
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} domain.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /disputes/{id} [get]
+func (h *DisputeHandler) GetDispute(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dispute ID format",
+		})
+	}
+
+	dispute, err := h.disputeService.GetDispute(c.Request().Context(), id)
+	if err != nil {
+		return h.disputeError(c, err, "Failed to get dispute")
+	}
+
+	return c.JSON(http.StatusOK, dispute)
+}
+
+// SubmitEvidence records the merchant's answer to an open dispute
+// @Summary Respond to a dispute
+// @Description Submits the merchant's evidence for an OPEN dispute and publishes dispute.evidence_submitted. Evidence can be submitted once.
+// @Tags disputes
+// @Accept json
+// @Produce json
+// @Param id path string true "Dispute ID"
+// @Param evidence body domain.SubmitDisputeEvidenceRequest true "Evidence"
+// @Success 200 {object} domain.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /disputes/{id}/evidence [post]
+func (h *DisputeHandler) SubmitEvidence(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dispute ID format",
+		})
+	}
+
+	var req domain.SubmitDisputeEvidenceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	dispute, err := h.disputeService.SubmitEvidence(c.Request().Context(), id, req)
+	if err != nil {
+		return h.disputeError(c, err, "Failed to submit dispute evidence")
+	}
+
+	return c.JSON(http.StatusOK, dispute)
+}
+
+// ResolveDispute records the bank's decision
+// @Summary Resolve a dispute
+// @Description Marks an undecided dispute WON or LOST and publishes dispute.won or dispute.lost. The operator is recorded.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Dispute ID"
+// @Param resolution body domain.ResolveDisputeRequest true "Outcome"
+// @Success 200 {object} domain.Dispute
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/disputes/{id}/resolve [post]
+func (h *DisputeHandler) ResolveDispute(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid dispute ID format",
+		})
+	}
+
+	var req domain.ResolveDisputeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+	dispute, err := h.disputeService.ResolveDispute(c.Request().Context(), id, req, operator)
+	if err != nil {
+		return h.disputeError(c, err, "Failed to resolve dispute")
+	}
+
+	return c.JSON(http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) disputeError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrPaymentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	case err == domain.ErrDisputeNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Dispute not found",
+		})
+	case err == domain.ErrDisputeExists, err == domain.ErrDisputeClosed:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case err == domain.ErrPaymentNotDisputable, err == domain.ErrDisputeExceedsPayment:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
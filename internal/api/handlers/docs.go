@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payment-gateway/internal/apidoc"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// DocsHandler serves the OpenAPI document generated from the handlers'
+// annotations and a Swagger UI page over it
+type DocsHandler struct {
+	document []byte
+}
+
+// NewDocsHandler builds the document for the routes registered so far, so
+// it is created after every other route
+func NewDocsHandler(routes []*echo.Route, logger *logrus.Logger) *DocsHandler {
+	registered := make([]apidoc.Route, len(routes))
+	for i, r := range routes {
+		registered[i] = apidoc.Route{Method: r.Method, Path: r.Path}
+	}
+
+	document, unrouted, err := apidoc.Document(registered)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load the OpenAPI document")
+	}
+	for _, op := range unrouted {
+		logger.WithField("operation", op).Warn("Documented operation has no route; fix its @Router or run go generate ./internal/apidoc")
+	}
+
+	return &DocsHandler{document: document}
+}
+
+// OpenAPI returns the OpenAPI 3 document
+func (h *DocsHandler) OpenAPI(c echo.Context) error {
+	if h.document == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "API documentation is unavailable",
+		})
+	}
+	return c.JSONBlob(http.StatusOK, h.document)
+}
+
+// SwaggerUI renders the document; the UI's assets come from unpkg
+func (h *DocsHandler) SwaggerUI(c echo.Context) error {
+	return c.HTML(http.StatusOK, swaggerUIPage)
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Ethiopian Payment Gateway API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.onload = function () {
+  window.ui = SwaggerUIBundle({
+    url: "/api/v1/docs/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    persistAuthorization: true
+  });
+};
+</script>
+</body>
+</html>
+`
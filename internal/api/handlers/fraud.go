@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type FraudHandler struct {
+	fraudService service.FraudService
+	logger       *logrus.Logger
+}
+
+func NewFraudHandler(fraudService service.FraudService, logger *logrus.Logger) *FraudHandler {
+	return &FraudHandler{
+		fraudService: fraudService,
+		logger:       logger,
+	}
+}
+
+// CreateRule adds a fraud rule
+// @Summary Create fraud rule
+// @Description Rules are tried in priority order, lowest first, before a payment is created; the first that matches decides. ALLOW accepts without trying later rules, FLAG accepts and logs a hit, BLOCK rejects the payment.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param rule body domain.FraudRuleRequest true "Rule"
+// @Success 201 {object} domain.FraudRule
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /admin/fraud/rules [post]
+func (h *FraudHandler) CreateRule(c echo.Context) error {
+	var req domain.FraudRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	rule, err := h.fraudService.CreateRule(c.Request().Context(), req, operator)
+	if err != nil {
+		return h.fraudError(c, err, "Failed to create fraud rule")
+	}
+
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules returns every fraud rule in evaluation order
+// @Summary List fraud rules
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Success 200 {array} domain.FraudRule
+// @Router /admin/fraud/rules [get]
+func (h *FraudHandler) ListRules(c echo.Context) error {
+	rules, err := h.fraudService.ListRules(c.Request().Context())
+	if err != nil {
+		return h.fraudError(c, err, "Failed to list fraud rules")
+	}
+
+	if rules == nil {
+		rules = []*domain.FraudRule{}
+	}
+
+	return c.JSON(http.StatusOK, rules)
+}
+
+// GetRule returns a fraud rule
+// @Summary Get fraud rule
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Rule ID"
+// @Success 200 {object} domain.FraudRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/fraud/rules/{id} [get]
+func (h *FraudHandler) GetRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid rule ID format",
+		})
+	}
+
+	rule, err := h.fraudService.GetRule(c.Request().Context(), id)
+	if err != nil {
+		return h.fraudError(c, err, "Failed to get fraud rule")
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule replaces a fraud rule's settings
+// @Summary Update fraud rule
+// @Description Every field is replaced; send enabled=false to keep a rule without evaluating it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Rule ID"
+// @Param rule body domain.FraudRuleRequest true "Rule"
+// @Success 200 {object} domain.FraudRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/fraud/rules/{id} [put]
+func (h *FraudHandler) UpdateRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid rule ID format",
+		})
+	}
+
+	var req domain.FraudRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := h.fraudService.UpdateRule(c.Request().Context(), id, req)
+	if err != nil {
+		return h.fraudError(c, err, "Failed to update fraud rule")
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule removes a fraud rule; its hits are kept under the rule's name
+// @Summary Delete fraud rule
+// @Tags admin
+// @Security OperatorToken
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/fraud/rules/{id} [delete]
+func (h *FraudHandler) DeleteRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid rule ID format",
+		})
+	}
+
+	if err := h.fraudService.DeleteRule(c.Request().Context(), id); err != nil {
+		return h.fraudError(c, err, "Failed to delete fraud rule")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListHits returns payments fraud rules flagged or blocked, newest first
+// @Summary List fraud hits
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param rule_id query string false "Only hits of this rule"
+// @Param action query string false "FLAG or BLOCK"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.FraudHit
+// @Failure 400 {object} map[string]string
+// @Router /admin/fraud/hits [get]
+func (h *FraudHandler) ListHits(c echo.Context) error {
+	var ruleID *uuid.UUID
+	if raw := c.QueryParam("rule_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid rule ID format",
+			})
+		}
+		ruleID = &id
+	}
+	action := domain.FraudAction(strings.ToUpper(c.QueryParam("action")))
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	hits, err := h.fraudService.ListHits(c.Request().Context(), ruleID, action, page, limit)
+	if err != nil {
+		return h.fraudError(c, err, "Failed to list fraud hits")
+	}
+
+	if hits == nil {
+		hits = []*domain.FraudHit{}
+	}
+
+	return c.JSON(http.StatusOK, hits)
+}
+
+func (h *FraudHandler) fraudError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrFraudRuleNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Fraud rule not found",
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
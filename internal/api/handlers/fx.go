@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type FXHandler struct {
+	fxService service.FXService
+	logger    *logrus.Logger
+}
+
+func NewFXHandler(fxService service.FXService, logger *logrus.Logger) *FXHandler {
+	return &FXHandler{
+		fxService: fxService,
+		logger:    logger,
+	}
+}
+
+// GetRate returns the current daily rate of a currency into ETB
+// @Summary Get FX rate
+// @Description Returns the daily mid rate set by treasury, or the configured rate if none has been set.
+// @Tags fx
+// @Produce json
+// @Param currency query string false "USD, EUR, GBP, AED or CNY (default USD)"
+// @Success 200 {object} domain.FXRate
+// @Failure 400 {object} map[string]string
+// @Router /fx/rate [get]
+func (h *FXHandler) GetRate(c echo.Context) error {
+	currency := domain.Currency(strings.ToUpper(c.QueryParam("currency")))
+	if currency == "" {
+		currency = domain.CurrencyUSD
+	}
+
+	rate, err := h.fxService.CurrentRate(c.Request().Context(), currency)
+	if err != nil {
+		return h.fxError(c, err, "Failed to get FX rate")
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// ListRates returns the current rate of every accepted foreign currency into
+// ETB, or the rates in force on a past day for reconciliation
+// @Summary List FX rates
+// @Tags fx
+// @Produce json
+// @Param date query string false "Ethiopian business day, YYYY-MM-DD (default now)"
+// @Success 200 {array} domain.FXRate
+// @Failure 400 {object} map[string]string
+// @Router /fx/rates [get]
+func (h *FXHandler) ListRates(c echo.Context) error {
+	var rates []*domain.FXRate
+	var err error
+	if date := c.QueryParam("date"); date != "" {
+		rates, err = h.fxService.RatesOn(c.Request().Context(), date)
+	} else {
+		rates, err = h.fxService.CurrentRates(c.Request().Context())
+	}
+	if err != nil {
+		return h.fxError(c, err, "Failed to list FX rates")
+	}
+
+	return c.JSON(http.StatusOK, rates)
+}
+
+// RateHistory lists every rate treasury has set, for reconciling the rate
+// applied to payments
+// @Summary FX rate history
+// @Tags fx
+// @Produce json
+// @Param currency query string false "USD, EUR, GBP, AED or CNY (default all)"
+// @Param from query string false "First effective date, YYYY-MM-DD"
+// @Param to query string false "Last effective date, YYYY-MM-DD"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Entries per page (default 20, max 100)"
+// @Success 200 {array} domain.FXRate
+// @Failure 400 {object} map[string]string
+// @Router /fx/rates/history [get]
+func (h *FXHandler) RateHistory(c echo.Context) error {
+	filter := domain.FXRateFilter{
+		Currency: domain.Currency(c.QueryParam("currency")),
+		From:     c.QueryParam("from"),
+		To:       c.QueryParam("to"),
+	}
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	rates, err := h.fxService.RateHistory(c.Request().Context(), filter, page, limit)
+	if err != nil {
+		return h.fxError(c, err, "Failed to list FX rate history")
+	}
+
+	if rates == nil {
+		rates = []*domain.FXRate{}
+	}
+
+	return c.JSON(http.StatusOK, rates)
+}
+
+// Convert prices an amount in ETB at today's rate without locking it
+// @Summary Convert to ETB
+// @Description Prices an amount in ETB at the rate a quote would lock now, less the spread. The rate is indicative; create a quote to hold it for a payment.
+// @Tags fx
+// @Produce json
+// @Param currency query string false "An accepted foreign currency (default USD)"
+// @Param amount query number true "Amount in the currency"
+// @Success 200 {object} domain.FXConversion
+// @Failure 400 {object} map[string]string
+// @Router /fx/quote [get]
+func (h *FXHandler) Convert(c echo.Context) error {
+	currency := domain.Currency(strings.ToUpper(c.QueryParam("currency")))
+	if currency == "" {
+		currency = domain.CurrencyUSD
+	}
+	amount, err := domain.ParseAmount(c.QueryParam("amount"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid amount",
+			"details": err.Error(),
+		})
+	}
+
+	conversion, err := h.fxService.Convert(c.Request().Context(), currency, amount)
+	if err != nil {
+		return h.fxError(c, err, "Failed to convert amount")
+	}
+
+	return c.JSON(http.StatusOK, conversion)
+}
+
+// CreateQuote locks a rate into ETB for one payment
+// @Summary Create FX quote
+// @Description Locks the current rate into ETB (less the spread) until the quote expires. Pass the quote ID as fx_quote_id when creating a payment in the quoted currency.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param quote body domain.FXQuoteRequest true "Payment currency"
+// @Success 201 {object} domain.FXQuote
+// @Failure 400 {object} map[string]string
+// @Router /fx/quotes [post]
+func (h *FXHandler) CreateQuote(c echo.Context) error {
+	var req domain.FXQuoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	quote, err := h.fxService.CreateQuote(c.Request().Context(), req)
+	if err != nil {
+		return h.fxError(c, err, "Failed to create FX quote")
+	}
+
+	return c.JSON(http.StatusCreated, quote)
+}
+
+// GetQuote returns a quote and whether a payment has used it
+// @Summary Get FX quote
+// @Tags fx
+// @Produce json
+// @Param id path string true "Quote ID"
+// @Success 200 {object} domain.FXQuote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /fx/quotes/{id} [get]
+func (h *FXHandler) GetQuote(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid quote ID format",
+		})
+	}
+
+	quote, err := h.fxService.GetQuote(c.Request().Context(), id)
+	if err != nil {
+		return h.fxError(c, err, "Failed to get FX quote")
+	}
+
+	return c.JSON(http.StatusOK, quote)
+}
+
+// SetRate records today's rate of a currency into ETB
+// @Summary Set FX rate
+// @Description Sets the daily mid rate used for new quotes and for foreign currency payments without a quote. The operator is recorded.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param rate body domain.SetFXRateRequest true "Currency (default USD) and ETB per unit"
+// @Success 200 {object} domain.FXRate
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /admin/fx/rate [put]
+func (h *FXHandler) SetRate(c echo.Context) error {
+	var req domain.SetFXRateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	rate, err := h.fxService.SetRate(c.Request().Context(), req, operator)
+	if err != nil {
+		return h.fxError(c, err, "Failed to set FX rate")
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+func (h *FXHandler) fxError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrFXQuoteNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "FX quote not found",
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,689 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/graphql"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// graphQLPollInterval is how often a status subscription rereads its
+// payment. Status changes are made by the worker as well as the API, so
+// the payment itself is the one place they all show up.
+const graphQLPollInterval = 2 * time.Second
+
+// graphQLHeartbeat keeps idle subscription streams open through proxies
+const graphQLHeartbeat = 15 * time.Second
+
+// GraphQLHandler serves the payment, customer and statistics queries of
+// the REST API in one request, for dashboards that need a few fields of
+// many records. Object fields carry the REST API's JSON names.
+type GraphQLHandler struct {
+	paymentService  service.PaymentService
+	customerService service.CustomerService
+	schema          *graphql.Schema
+	logger          *logrus.Logger
+}
+
+func NewGraphQLHandler(paymentService service.PaymentService, customerService service.CustomerService, logger *logrus.Logger) *GraphQLHandler {
+	h := &GraphQLHandler{
+		paymentService:  paymentService,
+		customerService: customerService,
+		logger:          logger,
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+// Query runs a GraphQL operation
+// @Summary GraphQL
+// @Description Runs a query or the createPayment mutation; GET /graphql/schema lists the types and fields. Send a subscription with "Accept: text/event-stream" to receive each result as a "next" server-sent event, then "complete" when the stream ends. Fields need the API key scopes of their REST endpoints.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body graphql.Request true "Query, operationName and variables"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} graphql.Response
+// @Router /graphql [post]
+func (h *GraphQLHandler) Query(c echo.Context) error {
+	var req graphql.Request
+	dec := json.NewDecoder(c.Request().Body)
+	dec.UseNumber() // Keeps large integers and decimals exact
+	if err := dec.Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, &graphql.Response{Errors: []*graphql.Error{
+			graphql.NewError(graphql.CodeBadUserInput, "Invalid request body"),
+		}})
+	}
+
+	ctx := context.WithValue(c.Request().Context(), echoContextKey{}, c)
+
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "text/event-stream") {
+		return h.stream(c, ctx, req)
+	}
+
+	resp := h.schema.Execute(ctx, req)
+	if resp.Data == nil {
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// stream sends a subscription's results as server-sent events
+func (h *GraphQLHandler) stream(c echo.Context, ctx context.Context, req graphql.Request) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses, failed := h.schema.Subscribe(ctx, req)
+	if failed != nil {
+		return c.JSON(http.StatusBadRequest, failed)
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx holding events back
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	heartbeat := time.NewTicker(graphQLHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case resp, ok := <-responses:
+			if !ok {
+				fmt.Fprint(w, "event: complete\ndata:\n\n")
+				w.Flush()
+				return nil
+			}
+			data, err := json.Marshal(resp)
+			if err != nil {
+				h.logger.WithError(err).Error("Failed to encode GraphQL subscription result")
+				return nil
+			}
+			if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
+
+// Schema returns the schema in the GraphQL schema definition language
+// @Summary GraphQL schema
+// @Description The types, queries, mutations and subscriptions of POST /graphql, for code generators and GraphQL clients
+// @Tags graphql
+// @Produce plain
+// @Success 200 {string} string
+// @Router /graphql/schema [get]
+func (h *GraphQLHandler) Schema(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.String(http.StatusOK, h.schema.SDL())
+}
+
+// echoContextKey carries the request's echo.Context to resolvers, which
+// need the caller's merchant and API key
+type echoContextKey struct{}
+
+func requestContext(ctx context.Context) echo.Context {
+	c, _ := ctx.Value(echoContextKey{}).(echo.Context)
+	return c
+}
+
+// allowed checks the API key scope a field's REST endpoint requires
+func allowed(ctx context.Context, scope domain.APIKeyScope) error {
+	if key, ok := requestContext(ctx).Get(APIKeyContextKey).(*domain.APIKey); ok && !key.Allows(scope) {
+		return graphql.NewError("FORBIDDEN", "API key lacks the %s scope", scope)
+	}
+	return nil
+}
+
+// resolverError reports a service error the way the REST handlers do:
+// bad input and business rules with their message, anything else logged
+// and reported as an internal error
+func (h *GraphQLHandler) resolverError(err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput), err == domain.ErrBusinessHours, err == domain.ErrAmountTooLarge,
+		err == domain.ErrOTPUnavailable, err == domain.ErrMethodUnavailable:
+		return graphql.NewError(graphql.CodeBadUserInput, "%s", err.Error())
+	case err == domain.ErrPaymentAlreadyExists, err == domain.ErrFXQuoteExpired, err == domain.ErrFXQuoteUsed:
+		return graphql.NewError("CONFLICT", "%s", err.Error())
+	case err == domain.ErrFXQuoteNotFound:
+		return graphql.NewError("NOT_FOUND", "%s", err.Error())
+	case errors.Is(err, domain.ErrClientBlocked), err == domain.ErrPaymentDeclined:
+		return graphql.NewError("FORBIDDEN", "%s", err.Error())
+	case errors.Is(err, domain.ErrVelocityLimitExceeded):
+		return graphql.NewError(domain.ErrorCodeVelocityLimitExceeded, "%s", err.Error())
+	case errors.Is(err, domain.ErrCustomerLimitExceeded), errors.Is(err, domain.ErrCustomerNotVerified):
+		return graphql.NewError(graphql.CodeBadUserInput, "%s", err.Error())
+	}
+	h.logger.WithError(err).Error(message)
+	return graphql.NewError(graphql.CodeInternal, "%s", message)
+}
+
+func (h *GraphQLHandler) buildSchema() *graphql.Schema {
+	decimal := &graphql.Scalar{Name: "Decimal", Description: "An amount in major units, e.g. 1250.50; a number in results, a number or string in arguments"}
+	dateTime := &graphql.Scalar{Name: "DateTime", Description: "An RFC 3339 time"}
+
+	enumOf := func(name string, values ...string) *graphql.Enum {
+		return &graphql.Enum{Name: name, Values: values}
+	}
+	currency := enumOf("Currency")
+	for _, c := range domain.SupportedCurrencies {
+		currency.Values = append(currency.Values, string(c))
+	}
+	paymentStatus := enumOf("PaymentStatus", string(domain.StatusPending), string(domain.StatusAwaitingOTP), string(domain.StatusAwaitingCash),
+		string(domain.StatusUnderReview), string(domain.StatusProcessing), string(domain.StatusSuccess), string(domain.StatusFailed),
+		string(domain.StatusCancelled), string(domain.StatusExpired))
+	paymentMethod := enumOf("PaymentMethod", string(domain.MethodBank), string(domain.MethodArifPay), string(domain.MethodSantimPay), string(domain.MethodCard))
+	verificationStatus := enumOf("VerificationStatus", string(domain.CustomerPending), string(domain.CustomerVerified), string(domain.CustomerRejected))
+	paymentSort := enumOf("PaymentSort", "NEWEST", "OLDEST", "LARGEST", "SMALLEST")
+
+	field := func(name string, t graphql.Type) *graphql.Field {
+		return &graphql.Field{Name: name, Type: t}
+	}
+	arg := func(name string, t graphql.Type) *graphql.Argument {
+		return &graphql.Argument{Name: name, Type: t}
+	}
+	str, nonNull, list := graphql.String, graphql.NonNull, graphql.List
+
+	currencyAmount := &graphql.Object{Name: "CurrencyAmount", Fields: []*graphql.Field{
+		field("currency", nonNull(currency)),
+		field("amount", nonNull(decimal)),
+	}}
+
+	customer := &graphql.Object{Name: "Customer", Description: "A KYC customer profile"}
+	payment := &graphql.Object{Name: "Payment", Fields: []*graphql.Field{
+		field("id", nonNull(graphql.ID)),
+		field("amount", nonNull(decimal)),
+		field("currency", nonNull(currency)),
+		field("currency_symbol", nonNull(str)),
+		field("reference", nonNull(str)),
+		field("status", nonNull(paymentStatus)),
+		field("description", str),
+		field("customer_name", str),
+		field("customer_phone", str),
+		field("customer_email", str),
+		field("customer_national_id", str),
+		field("customer_id", graphql.ID),
+		{Name: "customer", Type: customer, Description: "The registered customer named by customer_id", Resolve: h.resolvePaymentCustomer},
+		field("language", str),
+		field("bank_code", str),
+		field("purpose_code", str),
+		field("mcc", str),
+		field("limit_flag", str),
+		field("client_country", str),
+		field("fx_rate", graphql.Float),
+		field("amount_etb", decimal),
+		field("tags", list(nonNull(str))),
+		field("provider_reference", str),
+		field("checkout_url", str),
+		field("payment_method", nonNull(paymentMethod)),
+		field("refunded_amount", decimal),
+		field("merchant_id", graphql.ID),
+		field("created_at", nonNull(dateTime)),
+		{Name: "created_at_et", Type: nonNull(str), Description: "Ethiopian time"},
+		{Name: "created_at_ethiopian", Type: nonNull(str), Description: "Ethiopian calendar date, e.g. 15-04-2016 EC"},
+		field("created_at_ethiopian_geez", nonNull(str)),
+	}}
+
+	paymentList := &graphql.Object{Name: "PaymentList", Fields: []*graphql.Field{
+		{Name: "payments", Type: nonNull(list(nonNull(payment))), Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return source.(*domain.PaymentListResponse).Payments, nil
+		}},
+		field("total", nonNull(graphql.Int)),
+		field("total_pages", nonNull(graphql.Int)),
+		field("page", nonNull(graphql.Int)),
+		field("limit", nonNull(graphql.Int)),
+		field("has_more", nonNull(graphql.Boolean)),
+		{Name: "next_cursor", Type: str, Description: "Pass as cursor for the next page; null on the last"},
+	}}
+
+	summary := &graphql.Object{Name: "CustomerPaymentSummary", Fields: []*graphql.Field{
+		field("payments", nonNull(graphql.Int)),
+		field("successful", nonNull(graphql.Int)),
+		{Name: "failed", Type: nonNull(graphql.Int), Description: "FAILED, CANCELLED and EXPIRED"},
+		field("in_progress", nonNull(graphql.Int)),
+		field("paid_etb", nonNull(decimal)),
+		field("average_paid_etb", nonNull(decimal)),
+		{Name: "paid", Type: nonNull(list(nonNull(currencyAmount))), Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return currencyAmounts(source.(domain.CustomerPaymentSummary).Paid), nil
+		}},
+		{Name: "refunded", Type: nonNull(list(nonNull(currencyAmount))), Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return currencyAmounts(source.(domain.CustomerPaymentSummary).Refunded), nil
+		}},
+		field("first_payment_at", dateTime),
+		field("last_payment_at", dateTime),
+	}}
+
+	history := &graphql.Object{Name: "CustomerPaymentHistory", Fields: []*graphql.Field{
+		{Name: "summary", Type: nonNull(summary), Description: "Totals over all the customer's payments", Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return source.(*domain.CustomerPaymentHistory).Summary, nil
+		}},
+		{Name: "payments", Type: nonNull(list(nonNull(payment))), Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return source.(*domain.CustomerPaymentHistory).Payments, nil
+		}},
+		field("total", nonNull(graphql.Int)),
+		field("page", nonNull(graphql.Int)),
+		field("limit", nonNull(graphql.Int)),
+		field("has_more", nonNull(graphql.Boolean)),
+	}}
+
+	customer.Fields = []*graphql.Field{
+		field("id", nonNull(graphql.ID)),
+		field("full_name", nonNull(str)),
+		field("national_id", str),
+		field("passport_number", str),
+		field("phone", nonNull(str)),
+		field("email", str),
+		field("verification_status", nonNull(verificationStatus)),
+		field("verification_note", str),
+		field("verified_by", str),
+		field("verified_at", dateTime),
+		field("merchant_id", graphql.ID),
+		field("created_at", nonNull(dateTime)),
+		field("updated_at", nonNull(dateTime)),
+		{
+			Name:        "payments",
+			Type:        nonNull(history),
+			Description: "The customer's payments, newest first",
+			Args:        []*graphql.Argument{pageArg(), limitArg()},
+			Resolve:     h.resolveCustomerPayments,
+		},
+	}
+
+	statistics := &graphql.Object{Name: "Statistics", Fields: []*graphql.Field{
+		field("total_payments", nonNull(graphql.Int)),
+		field("total_amount_etb", nonNull(decimal)),
+		field("total_amount_usd", nonNull(decimal)),
+		field("successful_payments", nonNull(graphql.Int)),
+		field("failed_payments", nonNull(graphql.Int)),
+		field("pending_payments", nonNull(graphql.Int)),
+		field("average_amount_etb", nonNull(decimal)),
+		field("average_amount_usd", nonNull(decimal)),
+		{Name: "totals_by_currency", Type: nonNull(list(nonNull(currencyAmount))), Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return currencyAmounts(source.(*service.PaymentStatistics).TotalsByCurrency), nil
+		}},
+	}}
+
+	filterArgs := []*graphql.Argument{
+		arg("status", paymentStatus),
+		arg("currency", currency),
+		arg("bank_code", str),
+		{Name: "created_from", Type: str, Description: "RFC 3339 time or YYYY-MM-DD in Ethiopian time"},
+		{Name: "created_to", Type: str, Description: "Before a time, or on or before a YYYY-MM-DD date"},
+		arg("purpose_code", str),
+		arg("mcc", str),
+		{Name: "tags", Type: list(nonNull(str)), Description: "Only payments carrying all of them"},
+	}
+	listArgs := append([]*graphql.Argument{
+		{Name: "cursor", Type: str, Description: "next_cursor of the previous page; takes precedence over page"},
+		pageArg(),
+		limitArg(),
+		arg("min_amount", decimal),
+		arg("max_amount", decimal),
+		arg("limit_flagged", graphql.Boolean),
+		{Name: "sort", Type: paymentSort, Description: "NEWEST by default; cursors apply to NEWEST and OLDEST only"},
+	}, filterArgs...)
+
+	createPaymentInput := &graphql.InputObject{Name: "CreatePaymentInput", Description: "The body of POST /payments", Fields: []*graphql.Argument{
+		arg("amount", nonNull(decimal)),
+		arg("currency", nonNull(currency)),
+		{Name: "reference", Type: str, Description: "Generated by the gateway when omitted"},
+		arg("reference_prefix", str),
+		arg("description", str),
+		arg("customer_name", str),
+		arg("customer_phone", str),
+		arg("customer_email", str),
+		arg("customer_national_id", str),
+		arg("customer_id", graphql.ID),
+		arg("language", str),
+		arg("bank_code", str),
+		arg("require_otp", graphql.Boolean),
+		arg("pay_by_cash", graphql.Boolean),
+		arg("purpose_code", str),
+		arg("mcc", str),
+		arg("fx_quote_id", graphql.ID),
+		arg("tags", list(nonNull(str))),
+		arg("payment_method", paymentMethod),
+	}}
+
+	return &graphql.Schema{
+		Query: &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+			{Name: "payment", Type: payment, Args: []*graphql.Argument{arg("id", nonNull(graphql.ID))}, Resolve: h.resolvePayment},
+			{Name: "paymentByReference", Type: payment, Args: []*graphql.Argument{arg("reference", nonNull(str))}, Resolve: h.resolvePaymentByReference},
+			{Name: "payments", Type: nonNull(paymentList), Args: listArgs, Resolve: h.resolvePayments},
+			{Name: "customer", Type: customer, Args: []*graphql.Argument{arg("id", nonNull(graphql.ID))}, Resolve: h.resolveCustomer},
+			{Name: "customers", Type: nonNull(list(nonNull(customer))), Description: "Customers, oldest first", Args: []*graphql.Argument{
+				arg("status", verificationStatus), pageArg(), limitArg(),
+			}, Resolve: h.resolveCustomers},
+			{Name: "statistics", Type: nonNull(statistics), Args: filterArgs, Resolve: h.resolveStatistics},
+		}},
+		Mutation: &graphql.Object{Name: "Mutation", Fields: []*graphql.Field{
+			{Name: "createPayment", Type: nonNull(payment), Args: []*graphql.Argument{arg("input", nonNull(createPaymentInput))}, Resolve: h.resolveCreatePayment},
+		}},
+		Subscription: &graphql.Object{Name: "Subscription", Fields: []*graphql.Field{
+			{
+				Name:        "paymentStatusChanged",
+				Type:        nonNull(payment),
+				Description: "The payment now, then again each time its status changes; ends once the status is final",
+				Args:        []*graphql.Argument{arg("id", nonNull(graphql.ID))},
+				Subscribe:   h.subscribePaymentStatus,
+			},
+		}},
+	}
+}
+
+func pageArg() *graphql.Argument {
+	return &graphql.Argument{Name: "page", Type: graphql.Int, Default: 1}
+}
+
+func limitArg() *graphql.Argument {
+	return &graphql.Argument{Name: "limit", Type: graphql.Int, Default: 20, Description: "At most 100"}
+}
+
+type currencyAmount struct {
+	Currency domain.Currency `json:"currency"`
+	Amount   domain.Amount   `json:"amount"`
+}
+
+// currencyAmounts lists per-currency totals in the order of
+// domain.SupportedCurrencies, as GraphQL has no map type
+func currencyAmounts(totals map[domain.Currency]domain.Amount) []currencyAmount {
+	out := make([]currencyAmount, 0, len(totals))
+	for currency, amount := range totals {
+		out = append(out, currencyAmount{Currency: currency, Amount: amount})
+	}
+	rank := func(c domain.Currency) int {
+		for i, supported := range domain.SupportedCurrencies {
+			if c == supported {
+				return i
+			}
+		}
+		return len(domain.SupportedCurrencies)
+	}
+	sort.Slice(out, func(i, j int) bool { return rank(out[i].Currency) < rank(out[j].Currency) })
+	return out
+}
+
+// visiblePayment hides other merchants' payments as not found
+func (h *GraphQLHandler) visiblePayment(ctx context.Context, payment *domain.Payment, err error) (any, error) {
+	if err == nil && !VisibleTo(requestContext(ctx), payment.MerchantID) {
+		err = domain.ErrPaymentNotFound
+	}
+	if err == domain.ErrPaymentNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to retrieve payment")
+	}
+	return payment.ToResponse(), nil
+}
+
+func (h *GraphQLHandler) resolvePayment(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(args.String("id"))
+	if err != nil {
+		return nil, graphql.NewError(graphql.CodeBadUserInput, "Invalid payment ID format")
+	}
+	payment, err := h.paymentService.GetPayment(ctx, id)
+	return h.visiblePayment(ctx, payment, err)
+}
+
+func (h *GraphQLHandler) resolvePaymentByReference(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	payment, err := h.paymentService.GetPaymentByReference(ctx, args.String("reference"))
+	return h.visiblePayment(ctx, payment, err)
+}
+
+// graphQLPaymentFilter builds the PaymentFilter of GET /payments from field arguments
+func graphQLPaymentFilter(ctx context.Context, args graphql.Args) (domain.PaymentFilter, error) {
+	filter := domain.PaymentFilter{
+		PurposeCode:  args.String("purpose_code"),
+		MCC:          args.String("mcc"),
+		LimitFlagged: args.Bool("limit_flagged"),
+		MerchantID:   CallerMerchant(requestContext(ctx)),
+		Status:       domain.PaymentStatus(args.String("status")),
+		Currency:     domain.Currency(args.String("currency")),
+		BankCode:     args.String("bank_code"),
+		CreatedFrom:  args.String("created_from"),
+		CreatedTo:    args.String("created_to"),
+	}
+	if tags, ok := args["tags"].([]any); ok {
+		for _, tag := range tags {
+			filter.Tags = append(filter.Tags, tag.(string))
+		}
+	}
+	if value := args.String("min_amount"); value != "" {
+		if err := filter.MinAmount.UnmarshalText([]byte(value)); err != nil {
+			return filter, graphql.NewError(graphql.CodeBadUserInput, "min_amount: %v", err)
+		}
+	}
+	if value := args.String("max_amount"); value != "" {
+		if err := filter.MaxAmount.UnmarshalText([]byte(value)); err != nil {
+			return filter, graphql.NewError(graphql.CodeBadUserInput, "max_amount: %v", err)
+		}
+	}
+	switch args.String("sort") {
+	case "OLDEST":
+		filter.Sort = domain.SortOldest
+	case "LARGEST":
+		filter.Sort = domain.SortLargest
+	case "SMALLEST":
+		filter.Sort = domain.SortSmallest
+	}
+	return filter, nil
+}
+
+func (h *GraphQLHandler) resolvePayments(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	filter, err := graphQLPaymentFilter(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	page, limit := args.Int("page", 1), args.Int("limit", 20)
+	result, err := h.paymentService.ListPayments(ctx, filter, args.String("cursor"), page, limit)
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to list payments")
+	}
+
+	responses := make([]domain.PaymentResponse, len(result.Payments))
+	for i, payment := range result.Payments {
+		responses[i] = payment.ToResponse()
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return &domain.PaymentListResponse{
+		Payments:   responses,
+		Total:      result.Total,
+		TotalPages: domain.TotalPages(result.Total, limit),
+		Page:       page,
+		Limit:      limit,
+		HasMore:    result.HasMore,
+		NextCursor: result.NextCursor,
+	}, nil
+}
+
+func (h *GraphQLHandler) resolveStatistics(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopeStatisticsRead); err != nil {
+		return nil, err
+	}
+	filter, err := graphQLPaymentFilter(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := h.paymentService.GetStatistics(ctx, filter)
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to get statistics")
+	}
+	return stats, nil
+}
+
+// visibleCustomer hides other merchants' customers as not found
+func (h *GraphQLHandler) visibleCustomer(ctx context.Context, id uuid.UUID) (*domain.Customer, error) {
+	customer, err := h.customerService.Get(ctx, id)
+	if err == nil && !VisibleTo(requestContext(ctx), customer.MerchantID) {
+		err = domain.ErrCustomerNotFound
+	}
+	if err == domain.ErrCustomerNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to get customer")
+	}
+	return customer, nil
+}
+
+func (h *GraphQLHandler) resolveCustomer(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(args.String("id"))
+	if err != nil {
+		return nil, graphql.NewError(graphql.CodeBadUserInput, "Invalid customer ID format")
+	}
+	return h.visibleCustomer(ctx, id)
+}
+
+func (h *GraphQLHandler) resolvePaymentCustomer(ctx context.Context, source any, _ graphql.Args) (any, error) {
+	payment := source.(domain.PaymentResponse)
+	if payment.CustomerID == nil {
+		return nil, nil
+	}
+	return h.visibleCustomer(ctx, *payment.CustomerID)
+}
+
+func (h *GraphQLHandler) resolveCustomers(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	status := domain.CustomerVerificationStatus(args.String("status"))
+	customers, err := h.customerService.List(ctx, status, CallerMerchant(requestContext(ctx)), args.Int("page", 1), args.Int("limit", 20))
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to list customers")
+	}
+	return customers, nil
+}
+
+func (h *GraphQLHandler) resolveCustomerPayments(ctx context.Context, source any, args graphql.Args) (any, error) {
+	history, err := h.customerService.ListPayments(ctx, source.(*domain.Customer).ID, args.Int("page", 1), args.Int("limit", 20))
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to list customer payments")
+	}
+	return history, nil
+}
+
+// resolveCreatePayment creates a payment as POST /payments does and
+// returns it in full
+func (h *GraphQLHandler) resolveCreatePayment(ctx context.Context, _ any, args graphql.Args) (any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsCreate); err != nil {
+		return nil, err
+	}
+
+	var req domain.CreatePaymentRequest
+	if err := args.Decode("input", &req); err != nil {
+		return nil, graphql.NewError(graphql.CodeBadUserInput, "Invalid input: %v", err)
+	}
+	c := requestContext(ctx)
+	req.Client, _ = c.Get(ClientContextKey).(domain.ClientInfo)
+	req.MerchantID = CallerMerchant(c)
+
+	payment, err := h.paymentService.CreatePayment(ctx, req)
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to create payment")
+	}
+	return payment.ToResponse(), nil
+}
+
+// subscribePaymentStatus sends the payment, then polls it for status
+// changes until the status is final
+func (h *GraphQLHandler) subscribePaymentStatus(ctx context.Context, args graphql.Args) (<-chan any, error) {
+	if err := allowed(ctx, domain.ScopePaymentsRead); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(args.String("id"))
+	if err != nil {
+		return nil, graphql.NewError(graphql.CodeBadUserInput, "Invalid payment ID format")
+	}
+	payment, err := h.paymentService.GetPayment(ctx, id)
+	if err == nil && !VisibleTo(requestContext(ctx), payment.MerchantID) {
+		err = domain.ErrPaymentNotFound
+	}
+	if err == domain.ErrPaymentNotFound {
+		return nil, graphql.NewError("NOT_FOUND", "Payment not found")
+	}
+	if err != nil {
+		return nil, h.resolverError(err, "Failed to retrieve payment")
+	}
+
+	events := make(chan any)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(graphQLPollInterval)
+		defer ticker.Stop()
+
+		status := payment.Status
+		for {
+			select {
+			case events <- payment.ToResponse():
+			case <-ctx.Done():
+				return
+			}
+			if payment.Status.IsTerminal() {
+				return
+			}
+
+			for payment.Status == status {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+				latest, err := h.paymentService.GetPayment(ctx, id)
+				if err != nil {
+					if ctx.Err() == nil {
+						h.logger.WithError(err).WithField("payment_id", id).Warn("Failed to poll payment for a GraphQL subscription")
+					}
+					continue
+				}
+				payment = latest
+			}
+			status = payment.Status
+		}
+	}()
+	return events, nil
+}
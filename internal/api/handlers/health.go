@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/etime"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// healthCheckTimeout bounds each dependency check, so a hung dependency
+// reads as down instead of holding up the load balancer's probe
+const healthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	checks map[string]func(ctx context.Context) error
+	logger *logrus.Logger
+}
+
+// NewHealthHandler reports on the dependencies in checks, by name; the
+// gateway is unhealthy while any of them fails
+func NewHealthHandler(checks map[string]func(ctx context.Context) error, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		checks: checks,
+		logger: logger,
+	}
+}
+
+type dependencyHealth struct {
+	Status    string  `json:"status"` // up or down
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Livez answers Kubernetes liveness probes
+// @Summary Liveness probe
+// @Description The process is up and serving. Dependencies are left to /readyz, so an outage takes instances out of rotation rather than restarting them.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "alive",
+	})
+}
+
+// Readyz answers Kubernetes readiness probes
+// @Summary Readiness probe
+// @Description Ready to take traffic: every dependency is reachable and the database has every migration applied
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{} "A dependency is down"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c echo.Context) error {
+	checks, ok := h.run(c.Request().Context())
+	if !ok {
+		return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "not_ready",
+			"checks": checks,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": "ready",
+		"checks": checks,
+	})
+}
+
+// HealthCheck handles health checks
+// @Summary Health check
+// @Description Check the Ethiopian Payment Gateway and its dependencies (database, migrations, message broker), with each one's status and latency
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{} "A dependency is down"
+// @Router /health [get]
+func (h *HealthHandler) HealthCheck(c echo.Context) error {
+	checks, ok := h.run(c.Request().Context())
+	status, code := "healthy", http.StatusOK
+	if !ok {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+
+	return c.JSON(code, map[string]interface{}{
+		"status":         status,
+		"service":        "Ethiopian Payment Gateway",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+		"ethiopian_time": etime.Now().Format(etime.DateTimeLayout),
+		"version":        "1.0.0",
+		"checks":         checks,
+	})
+}
+
+// run checks every dependency at once; ok is false if any is down
+func (h *HealthHandler) run(ctx context.Context) (map[string]dependencyHealth, bool) {
+	checks := make(map[string]dependencyHealth, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := dependencyHealth{
+				Status:    "up",
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			// The endpoints are public, so why a dependency is down is only logged
+			if err != nil {
+				result.Status = "down"
+				h.logger.WithError(err).WithField("dependency", name).Warn("Health check failed")
+			}
+
+			mu.Lock()
+			checks[name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range checks {
+		if result.Status != "up" {
+			return checks, false
+		}
+	}
+	return checks, true
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type InvoiceHandler struct {
+	invoiceService service.InvoiceService
+	logger         *logrus.Logger
+}
+
+func NewInvoiceHandler(invoiceService service.InvoiceService, logger *logrus.Logger) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+		logger:         logger,
+	}
+}
+
+// CreateInvoice creates a draft invoice
+// @Summary Create invoice
+// @Description Line items are priced and their VAT rounded per line; vat_rate defaults to the standard 15%, 0 for exempt items. The invoice gets a sequential number (INV-000001).
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param invoice body domain.CreateInvoiceRequest true "Customer, line items and due date"
+// @Success 201 {object} domain.Invoice
+// @Failure 400 {object} map[string]string
+// @Router /invoices [post]
+func (h *InvoiceHandler) CreateInvoice(c echo.Context) error {
+	var req domain.CreateInvoiceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	invoice, err := h.invoiceService.Create(c.Request().Context(), req, CallerMerchant(c))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to create invoice")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create invoice",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, invoice)
+}
+
+// GetInvoice returns an invoice and the payments linked to it
+// @Summary Get invoice
+// @Tags invoices
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} domain.Invoice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /invoices/{id} [get]
+func (h *InvoiceHandler) GetInvoice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid invoice ID format",
+		})
+	}
+
+	invoice, err := h.invoiceService.Get(c.Request().Context(), id)
+	if err != nil {
+		if err == domain.ErrInvoiceNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Invoice not found",
+			})
+		}
+		h.logger.WithError(err).Error("Failed to get invoice")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get invoice",
+		})
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// SendInvoice issues an invoice to its customer
+// @Summary Send invoice
+// @Description The first send creates a payment link for the amount still owed; the invoice is emailed and texted to the customer with it where those channels are enabled. Sending again resends the same link. The invoice is marked paid automatically once its payments succeed.
+// @Tags invoices
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} domain.Invoice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /invoices/{id}/send [post]
+func (h *InvoiceHandler) SendInvoice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid invoice ID format",
+		})
+	}
+
+	invoice, err := h.invoiceService.Send(c.Request().Context(), id)
+	if err != nil {
+		switch {
+		case err == domain.ErrInvoiceNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Invoice not found",
+			})
+		case err == domain.ErrInvoicePaid:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to send invoice")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to send invoice",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// LinkInvoicePayment counts an existing payment towards an invoice
+// @Summary Link payment to invoice
+// @Description For payments not made through the invoice's payment link, e.g. a bank transfer quoting the invoice number. A payment that has already succeeded can complete the invoice at once.
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param payment body domain.LinkInvoicePaymentRequest true "Payment to link"
+// @Success 200 {object} domain.Invoice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /invoices/{id}/payments [post]
+func (h *InvoiceHandler) LinkInvoicePayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid invoice ID format",
+		})
+	}
+
+	var req domain.LinkInvoicePaymentRequest
+	if err := c.Bind(&req); err != nil || req.PaymentID == uuid.Nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "payment_id is required",
+		})
+	}
+
+	invoice, err := h.invoiceService.LinkPayment(c.Request().Context(), id, req.PaymentID)
+	if err != nil {
+		switch err {
+		case domain.ErrInvoiceNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Invoice not found",
+			})
+		case domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case domain.ErrInvoicePaid, domain.ErrInvoicePaymentMismatch:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to link invoice payment")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to link invoice payment",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
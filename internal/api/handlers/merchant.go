@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type MerchantHandler struct {
+	merchantService service.MerchantService
+	logger          *logrus.Logger
+}
+
+func NewMerchantHandler(merchantService service.MerchantService, logger *logrus.Logger) *MerchantHandler {
+	return &MerchantHandler{
+		merchantService: merchantService,
+		logger:          logger,
+	}
+}
+
+// RegisterMerchant onboards a merchant in PENDING status
+// @Summary Register merchant
+// @Description Registers a merchant with its business details, TIN and optionally its settlement accounts. The merchant stays PENDING until it has a verified settlement account and is activated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param merchant body domain.RegisterMerchantRequest true "Merchant details"
+// @Success 201 {object} domain.Merchant
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants [post]
+func (h *MerchantHandler) RegisterMerchant(c echo.Context) error {
+	var req domain.RegisterMerchantRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	merchant, err := h.merchantService.Register(c.Request().Context(), req)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to register merchant")
+	}
+
+	return c.JSON(http.StatusCreated, merchant)
+}
+
+// ListMerchants returns merchants, optionally filtered by status
+// @Summary List merchants
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param status query string false "PENDING, ACTIVE or SUSPENDED"
+// @Success 200 {array} domain.Merchant
+// @Failure 400 {object} map[string]string
+// @Router /admin/merchants [get]
+func (h *MerchantHandler) ListMerchants(c echo.Context) error {
+	merchants, err := h.merchantService.List(c.Request().Context(), c.QueryParam("status"))
+	if err != nil {
+		return h.merchantError(c, err, "Failed to list merchants")
+	}
+
+	if merchants == nil {
+		merchants = []*domain.Merchant{}
+	}
+
+	return c.JSON(http.StatusOK, merchants)
+}
+
+// GetMerchant returns a merchant with its settlement accounts
+// @Summary Get merchant
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Success 200 {object} domain.Merchant
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/merchants/{id} [get]
+func (h *MerchantHandler) GetMerchant(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+
+	merchant, err := h.merchantService.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to get merchant")
+	}
+
+	return c.JSON(http.StatusOK, merchant)
+}
+
+// UpdateMerchant changes contact details, or activates or suspends a merchant
+// @Summary Update merchant
+// @Description Activation needs a verified primary settlement account. Status changes need a reason.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param changes body domain.UpdateMerchantRequest true "Contact details and status"
+// @Success 200 {object} domain.Merchant
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants/{id} [patch]
+func (h *MerchantHandler) UpdateMerchant(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+
+	var req domain.UpdateMerchantRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	merchant, err := h.merchantService.Update(c.Request().Context(), id, req, operator)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to update merchant")
+	}
+
+	return c.JSON(http.StatusOK, merchant)
+}
+
+// AddSettlementAccount adds a bank account pending verification
+// @Summary Add settlement account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param account body domain.SettlementAccountRequest true "Bank account"
+// @Success 201 {object} domain.SettlementAccount
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants/{id}/accounts [post]
+func (h *MerchantHandler) AddSettlementAccount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+	}
+
+	var req domain.SettlementAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	account, err := h.merchantService.AddSettlementAccount(c.Request().Context(), id, req)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to add settlement account")
+	}
+
+	return c.JSON(http.StatusCreated, account)
+}
+
+// UpdateSettlementAccount replaces a settlement account's details
+// @Summary Update settlement account
+// @Description Changed details must be verified again. The primary account of an active merchant cannot be changed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param accountId path string true "Settlement account ID"
+// @Param account body domain.SettlementAccountRequest true "Bank account"
+// @Success 200 {object} domain.SettlementAccount
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants/{id}/accounts/{accountId} [put]
+func (h *MerchantHandler) UpdateSettlementAccount(c echo.Context) error {
+	id, accountID, ok := h.accountIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req domain.SettlementAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	account, err := h.merchantService.UpdateSettlementAccount(c.Request().Context(), id, accountID, req)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to update settlement account")
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// VerifySettlementAccount verifies a settlement account
+// @Summary Verify settlement account
+// @Description Runs a bank name inquiry and compares the holder name with the declared one. For banks without name inquiry, send manual=true with a note on the documents checked.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param accountId path string true "Settlement account ID"
+// @Param verification body domain.VerifySettlementAccountRequest false "Manual verification"
+// @Success 200 {object} domain.SettlementAccount
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/merchants/{id}/accounts/{accountId}/verify [post]
+func (h *MerchantHandler) VerifySettlementAccount(c echo.Context) error {
+	id, accountID, ok := h.accountIDs(c)
+	if !ok {
+		return nil
+	}
+
+	var req domain.VerifySettlementAccountRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	account, err := h.merchantService.VerifySettlementAccount(c.Request().Context(), id, accountID, req, operator)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to verify settlement account")
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// SetPrimarySettlementAccount makes a verified account the one settlements go to
+// @Summary Set primary settlement account
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Merchant ID"
+// @Param accountId path string true "Settlement account ID"
+// @Success 200 {object} domain.SettlementAccount
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/merchants/{id}/accounts/{accountId}/primary [post]
+func (h *MerchantHandler) SetPrimarySettlementAccount(c echo.Context) error {
+	id, accountID, ok := h.accountIDs(c)
+	if !ok {
+		return nil
+	}
+
+	account, err := h.merchantService.SetPrimarySettlementAccount(c.Request().Context(), id, accountID)
+	if err != nil {
+		return h.merchantError(c, err, "Failed to set primary settlement account")
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// accountIDs parses the merchant and account IDs, writing a 400 when
+// either is malformed
+func (h *MerchantHandler) accountIDs(c echo.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid merchant ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement account ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return id, accountID, true
+}
+
+func (h *MerchantHandler) merchantError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrMerchantNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Merchant not found",
+		})
+	case err == domain.ErrSettlementAccountNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Settlement account not found",
+		})
+	case err == domain.ErrMerchantExists,
+		err == domain.ErrSettlementAccountExists,
+		err == domain.ErrMerchantNotActivatable,
+		err == domain.ErrSettlementAccountNotReady,
+		err == domain.ErrSettlementAccountPrimary:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case err == domain.ErrNameInquiryUnsupported:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error":   err.Error(),
+			"details": "Verify the account manually with manual=true and a note",
+		})
+	case err == domain.ErrNameInquiryUnavailable:
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error":   err.Error(),
+			"details": "Try again later",
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type NoteHandler struct {
+	noteService service.NoteService
+	logger      *logrus.Logger
+}
+
+func NewNoteHandler(noteService service.NoteService, logger *logrus.Logger) *NoteHandler {
+	return &NoteHandler{
+		noteService: noteService,
+		logger:      logger,
+	}
+}
+
+// AddNote records an internal comment on a payment
+// @Summary Add payment note
+// @Description Internal investigation note for support and finance; the operator is recorded as author
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param note body domain.PaymentNoteRequest true "Note text"
+// @Success 201 {object} domain.PaymentNote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/notes [post]
+func (h *NoteHandler) AddNote(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.PaymentNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	note, err := h.noteService.AddNote(c.Request().Context(), paymentID, req, operator)
+	if err != nil {
+		return h.noteError(c, err, "Failed to add note")
+	}
+
+	return c.JSON(http.StatusCreated, note)
+}
+
+// ListNotes returns a payment's notes, oldest first
+// @Summary List payment notes
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.PaymentNote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/notes [get]
+func (h *NoteHandler) ListNotes(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	notes, err := h.noteService.ListNotes(c.Request().Context(), paymentID)
+	if err != nil {
+		return h.noteError(c, err, "Failed to list notes")
+	}
+
+	if notes == nil {
+		notes = []*domain.PaymentNote{}
+	}
+
+	return c.JSON(http.StatusOK, notes)
+}
+
+// EditNote replaces the text of one of the operator's own notes
+// @Summary Edit payment note
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param noteId path string true "Note ID"
+// @Param note body domain.PaymentNoteRequest true "New note text"
+// @Success 200 {object} domain.PaymentNote
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/notes/{noteId} [put]
+func (h *NoteHandler) EditNote(c echo.Context) error {
+	paymentID, noteID, ok := parseNoteIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment or note ID format",
+		})
+	}
+
+	var req domain.PaymentNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	note, err := h.noteService.EditNote(c.Request().Context(), paymentID, noteID, req, operator)
+	if err != nil {
+		return h.noteError(c, err, "Failed to edit note")
+	}
+
+	return c.JSON(http.StatusOK, note)
+}
+
+// DeleteNote removes one of the operator's own notes
+// @Summary Delete payment note
+// @Tags admin
+// @Security OperatorToken
+// @Param id path string true "Payment ID"
+// @Param noteId path string true "Note ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/payments/{id}/notes/{noteId} [delete]
+func (h *NoteHandler) DeleteNote(c echo.Context) error {
+	paymentID, noteID, ok := parseNoteIDs(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment or note ID format",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	if err := h.noteService.DeleteNote(c.Request().Context(), paymentID, noteID, operator); err != nil {
+		return h.noteError(c, err, "Failed to delete note")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseNoteIDs(c echo.Context) (uuid.UUID, uuid.UUID, bool) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return paymentID, noteID, true
+}
+
+func (h *NoteHandler) noteError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrPaymentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	case err == domain.ErrNoteNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Note not found",
+		})
+	case err == domain.ErrNoteNotAuthor:
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/notification"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationHandler struct {
+	notificationService   service.NotificationService
+	smsReportSecret       string
+	telegramWebhookSecret string
+	logger                *logrus.Logger
+}
+
+func NewNotificationHandler(notificationService service.NotificationService, smsReportSecret, telegramWebhookSecret string, logger *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService:   notificationService,
+		smsReportSecret:       smsReportSecret,
+		telegramWebhookSecret: telegramWebhookSecret,
+		logger:                logger,
+	}
+}
+
+// SMSDeliveryReport receives delivery reports from the Ethio Telecom SMS gateway
+// @Summary SMS delivery report callback
+// @Description Update the delivery status of an SMS notification
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-Report-Secret header string true "Shared secret configured for the gateway"
+// @Param report body domain.DeliveryReport true "Delivery report"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/sms/delivery-report [post]
+func (h *NotificationHandler) SMSDeliveryReport(c echo.Context) error {
+	secret := c.Request().Header.Get("X-Report-Secret")
+	if h.smsReportSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.smsReportSecret)) != 1 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid report secret",
+		})
+	}
+
+	var report domain.DeliveryReport
+	if err := c.Bind(&report); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.notificationService.HandleDeliveryReport(c.Request().Context(), report); err != nil {
+		switch err {
+		case domain.ErrInvalidInput:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "message_id and a valid status are required",
+			})
+		case domain.ErrNotificationNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Notification not found",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to process delivery report",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "ok",
+	})
+}
+
+// ListPaymentNotifications lists notifications sent for a payment
+// @Summary List payment notifications
+// @Description Get SMS notifications and their delivery status for a payment
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /payments/{id}/notifications [get]
+func (h *NotificationHandler) ListPaymentNotifications(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	notifications, err := h.notificationService.ListPaymentNotifications(c.Request().Context(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list notifications")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list notifications",
+		})
+	}
+
+	if notifications == nil {
+		notifications = []*domain.Notification{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"notifications": notifications,
+		"total":         len(notifications),
+	})
+}
+
+// CreateTelegramLink issues a deep link that connects a Telegram chat to the bot
+// @Summary Create Telegram link
+// @Description Create a one-time t.me link; opening it links the chat for payment notifications
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param link body map[string]string true "Label for the chat, e.g. Bole branch"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/telegram/links [post]
+func (h *NotificationHandler) CreateTelegramLink(c echo.Context) error {
+	var req struct {
+		Label string `json:"label"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	chat, deepLink, err := h.notificationService.CreateTelegramLink(c.Request().Context(), req.Label, scopedMerchantID(c, ""))
+	if err != nil {
+		if err == domain.ErrInvalidInput {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "label is required",
+			})
+		}
+		h.logger.WithError(err).Error("Failed to create Telegram link")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create Telegram link",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"id":        chat.ID,
+		"label":     chat.Label,
+		"link_code": chat.LinkCode,
+		"deep_link": deepLink,
+		"message":   "ይህን ሊንክ በቴሌግራም ይክፈቱ (Open this link in Telegram to link the chat)",
+	})
+}
+
+// ListTelegramChats lists linked Telegram chats
+// @Summary List Telegram chats
+// @Description List Telegram chats receiving payment notifications
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /notifications/telegram/chats [get]
+func (h *NotificationHandler) ListTelegramChats(c echo.Context) error {
+	chats, err := h.notificationService.ListTelegramChats(c.Request().Context(), callerMerchantID(c))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list Telegram chats")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list Telegram chats",
+		})
+	}
+
+	if chats == nil {
+		chats = []*domain.TelegramChat{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"chats": chats,
+		"total": len(chats),
+	})
+}
+
+// UnlinkTelegramChat stops notifications to a Telegram chat
+// @Summary Unlink Telegram chat
+// @Tags notifications
+// @Param id path string true "Telegram chat link ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/telegram/chats/{id} [delete]
+func (h *NotificationHandler) UnlinkTelegramChat(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid chat ID format",
+		})
+	}
+
+	if err := h.notificationService.UnlinkTelegramChat(c.Request().Context(), id, callerMerchantID(c)); err != nil {
+		if err == domain.ErrNotificationNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Telegram chat not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to unlink Telegram chat",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TelegramWebhook receives updates from the Telegram Bot API
+// @Summary Telegram bot webhook
+// @Tags notifications
+// @Accept json
+// @Success 200
+// @Failure 401 {object} map[string]string
+// @Router /notifications/telegram/webhook [post]
+func (h *NotificationHandler) TelegramWebhook(c echo.Context) error {
+	token := c.Request().Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if h.telegramWebhookSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.telegramWebhookSecret)) != 1 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid webhook secret",
+		})
+	}
+
+	var update notification.TelegramUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid update body",
+		})
+	}
+
+	// Telegram retries non-2xx responses, so failures are only logged
+	if err := h.notificationService.HandleTelegramUpdate(c.Request().Context(), update); err != nil {
+		h.logger.WithError(err).WithField("update_id", update.UpdateID).Warn("Failed to handle Telegram update")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ListPreferences lists a recipient's notification preferences
+// @Summary List notification preferences
+// @Description List which events trigger which channels for a customer or merchant
+// @Tags notifications
+// @Produce json
+// @Param recipient_type query string true "CUSTOMER or MERCHANT"
+// @Param recipient_id query string false "Customer phone/email or merchant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/preferences [get]
+func (h *NotificationHandler) ListPreferences(c echo.Context) error {
+	recipientType := domain.RecipientType(c.QueryParam("recipient_type"))
+	if recipientType != domain.RecipientCustomer && recipientType != domain.RecipientMerchant {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "recipient_type must be CUSTOMER or MERCHANT",
+		})
+	}
+
+	recipientID := c.QueryParam("recipient_id")
+	if caller := callerMerchantID(c); caller != nil {
+		if recipientType != domain.RecipientMerchant {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Merchant keys can only manage MERCHANT preferences",
+			})
+		}
+		recipientID = *caller
+	}
+
+	prefs, err := h.notificationService.ListPreferences(c.Request().Context(), recipientType, recipientID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list notification preferences")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list notification preferences",
+		})
+	}
+
+	if prefs == nil {
+		prefs = []*domain.NotificationPreference{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"preferences": prefs,
+		"total":       len(prefs),
+	})
+}
+
+// SavePreference turns an event on or off for a recipient's channel
+// @Summary Save notification preference
+// @Description Create or update the preference for a recipient, event and channel. A MERCHANT preference on the SMS channel turns the SMS that merchant's customers receive on or off.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param preference body domain.NotificationPreference true "Preference"
+// @Success 200 {object} domain.NotificationPreference
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/preferences [put]
+func (h *NotificationHandler) SavePreference(c echo.Context) error {
+	var pref domain.NotificationPreference
+	if err := c.Bind(&pref); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if caller := callerMerchantID(c); caller != nil {
+		if pref.RecipientType != domain.RecipientMerchant {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Merchant keys can only manage MERCHANT preferences",
+			})
+		}
+		pref.RecipientID = *caller
+	}
+
+	if err := h.notificationService.SavePreference(c.Request().Context(), &pref); err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid preference",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to save notification preference")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save notification preference",
+		})
+	}
+
+	return c.JSON(http.StatusOK, pref)
+}
+
+// DeletePreference removes a preference so the gateway default applies again
+// @Summary Delete notification preference
+// @Tags notifications
+// @Param id path string true "Preference ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/preferences/{id} [delete]
+func (h *NotificationHandler) DeletePreference(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid preference ID format",
+		})
+	}
+
+	if err := h.notificationService.DeletePreference(c.Request().Context(), id, callerMerchantID(c)); err != nil {
+		if err == domain.ErrPreferenceNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Preference not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete notification preference",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegisterPushDevice registers a merchant app device for FCM pushes
+// @Summary Register push device
+// @Description Register (or refresh) a merchant user's FCM device token
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param device body domain.PushDevice true "Device"
+// @Success 201 {object} domain.PushDevice
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/push/devices [post]
+func (h *NotificationHandler) RegisterPushDevice(c echo.Context) error {
+	var device domain.PushDevice
+	if err := c.Bind(&device); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	device.MerchantID = scopedMerchantID(c, device.MerchantID)
+
+	if err := h.notificationService.RegisterPushDevice(c.Request().Context(), &device); err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid push device",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to register push device")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to register push device",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, device)
+}
+
+// ListPushDevices lists a merchant's registered devices
+// @Summary List push devices
+// @Tags notifications
+// @Produce json
+// @Param merchant_id query string false "Merchant ID (operators only; merchant keys see their own)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /notifications/push/devices [get]
+func (h *NotificationHandler) ListPushDevices(c echo.Context) error {
+	devices, err := h.notificationService.ListPushDevices(c.Request().Context(), scopedMerchantID(c, c.QueryParam("merchant_id")))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list push devices")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list push devices",
+		})
+	}
+
+	if devices == nil {
+		devices = []*domain.PushDevice{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"devices": devices,
+		"total":   len(devices),
+	})
+}
+
+// UnregisterPushDevice stops pushes to a device (e.g. on logout)
+// @Summary Unregister push device
+// @Tags notifications
+// @Param id path string true "Device ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/push/devices/{id} [delete]
+func (h *NotificationHandler) UnregisterPushDevice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid device ID format",
+		})
+	}
+
+	if err := h.notificationService.UnregisterPushDevice(c.Request().Context(), id, callerMerchantID(c)); err != nil {
+		if err == domain.ErrPushDeviceNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Push device not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to unregister push device",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
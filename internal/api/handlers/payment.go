@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
 	"payment-gateway/internal/service"
 
 	"github.com/google/uuid"
@@ -13,28 +16,38 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ClientContextKey holds the caller's domain.ClientInfo
+const ClientContextKey = "client"
+
 type PaymentHandler struct {
-	paymentService service.PaymentService
-	logger         *logrus.Logger
+	paymentService    service.PaymentService
+	attachmentService service.AttachmentService
+	logger            *logrus.Logger
 }
 
-func NewPaymentHandler(paymentService service.PaymentService, logger *logrus.Logger) *PaymentHandler {
+func NewPaymentHandler(paymentService service.PaymentService, attachmentService service.AttachmentService, logger *logrus.Logger) *PaymentHandler {
 	return &PaymentHandler{
-		paymentService: paymentService,
-		logger:         logger,
+		paymentService:    paymentService,
+		attachmentService: attachmentService,
+		logger:            logger,
 	}
 }
 
 // CreatePayment handles Ethiopian payment creation
 // @Summary Create a new Ethiopian payment
-// @Description Create a payment in ETB or USD with Ethiopian context
+// @Description Create a payment in ETB or a supported foreign currency (USD, EUR, GBP, AED, CNY) with Ethiopian context
 // @Tags payments
 // @Accept json
 // @Produce json
 // @Param payment body domain.CreatePaymentRequest true "Payment details"
-// @Success 201 {object} map[string]interface{}
+// @Param X-Device-Fingerprint header string false "Device fingerprint from the checkout page"
+// @Success 201 {object} domain.CreatePaymentResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 429 {object} map[string]string "code VELOCITY_LIMIT_EXCEEDED"
 // @Failure 500 {object} map[string]string
 // @Router /payments [post]
 func (h *PaymentHandler) CreatePayment(c echo.Context) error {
@@ -45,6 +58,8 @@ func (h *PaymentHandler) CreatePayment(c echo.Context) error {
 			"error": "Invalid request body",
 		})
 	}
+	req.Client, _ = c.Get(ClientContextKey).(domain.ClientInfo)
+	req.MerchantID = CallerMerchant(c)
 
 	// Log Ethiopian payment attempt
 	h.logger.WithFields(logrus.Fields{
@@ -59,23 +74,64 @@ func (h *PaymentHandler) CreatePayment(c echo.Context) error {
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create payment")
 
-		switch err {
-		case domain.ErrInvalidInput:
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
 			return c.JSON(http.StatusBadRequest, map[string]string{
 				"error":   "Invalid input data",
 				"details": err.Error(),
 			})
-		case domain.ErrPaymentAlreadyExists:
+		case err == domain.ErrPaymentAlreadyExists:
 			return c.JSON(http.StatusConflict, map[string]string{
 				"error": "Payment with this reference already exists",
 			})
-		case domain.ErrBusinessHours:
+		case err == domain.ErrBusinessHours:
 			return c.JSON(http.StatusBadRequest, map[string]string{
 				"error": "Payments can only be processed during Ethiopian business hours (8:00 AM - 5:00 PM EAT)",
 			})
-		case domain.ErrAmountTooLarge:
+		case err == domain.ErrAmountTooLarge:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Amount exceeds the regulatory limit for the currency",
+			})
+		case err == domain.ErrOTPUnavailable:
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Amount exceeds Ethiopian regulatory limit (1,000,000 ETB)",
+				"error": "OTP confirmation is not enabled on this gateway",
+			})
+		case err == domain.ErrMethodUnavailable:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Payment method is not enabled on this gateway",
+			})
+		case err == domain.ErrFXQuoteNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "FX quote not found",
+			})
+		case err == domain.ErrFXQuoteExpired, err == domain.ErrFXQuoteUsed:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, domain.ErrClientBlocked):
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error":   domain.ErrClientBlocked.Error(),
+				"details": err.Error(),
+			})
+		case err == domain.ErrPaymentDeclined:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, domain.ErrVelocityLimitExceeded):
+			return c.JSON(http.StatusTooManyRequests, map[string]string{
+				"error":   domain.ErrVelocityLimitExceeded.Error(),
+				"code":    domain.ErrorCodeVelocityLimitExceeded,
+				"details": err.Error(),
+			})
+		case errors.Is(err, domain.ErrCustomerLimitExceeded):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error":   domain.ErrCustomerLimitExceeded.Error(),
+				"details": err.Error(),
+			})
+		case errors.Is(err, domain.ErrCustomerNotVerified):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error":   domain.ErrCustomerNotVerified.Error(),
+				"details": err.Error(),
 			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -84,14 +140,23 @@ func (h *PaymentHandler) CreatePayment(c echo.Context) error {
 		}
 	}
 
+	message := "የክፍያ ሂደት ተጀምሯል (Payment process initiated)"
+	switch payment.Status {
+	case domain.StatusAwaitingOTP:
+		message = "የማረጋገጫ ኮድ ወደ ደንበኛው ተልኳል (OTP sent to customer for confirmation)"
+	case domain.StatusUnderReview:
+		message = "ክፍያው ለግምገማ ተይዟል (Payment held for compliance review)"
+	}
+
 	// Return Ethiopian response
-	return c.JSON(http.StatusCreated, map[string]interface{}{
-		"message":        "የክፍያ ሂደት ተጀምሯል (Payment process initiated)",
-		"payment_id":     payment.ID,
-		"status":         payment.Status,
-		"reference":      payment.Reference,
-		"created_at":     payment.CreatedAt.Format("2006-01-02 15:04:05 MST"),
-		"ethiopian_time": payment.CreatedAt.Add(3 * time.Hour).Format("2006-01-02 15:04:05 EAT"),
+	return c.JSON(http.StatusCreated, domain.CreatePaymentResponse{
+		Message:       message,
+		PaymentID:     payment.ID,
+		Status:        payment.Status,
+		Reference:     payment.Reference,
+		CheckoutURL:   payment.CheckoutURL,
+		CreatedAt:     payment.CreatedAt.Format("2006-01-02 15:04:05 MST"),
+		EthiopianTime: etime.In(payment.CreatedAt).Format(etime.DateTimeLayout),
 	})
 }
 
@@ -128,7 +193,7 @@ func (h *PaymentHandler) GetPayment(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, payment.ToResponse())
+	return c.JSON(http.StatusOK, h.withAttachments(c, payment))
 }
 
 // GetPaymentByReference retrieves payment by reference number
@@ -150,6 +215,9 @@ func (h *PaymentHandler) GetPaymentByReference(c echo.Context) error {
 	}
 
 	payment, err := h.paymentService.GetPaymentByReference(c.Request().Context(), reference)
+	if err == nil && !VisibleTo(c, payment.MerchantID) {
+		err = domain.ErrPaymentNotFound
+	}
 	if err != nil {
 		if err == domain.ErrPaymentNotFound {
 			return c.JSON(http.StatusNotFound, map[string]string{
@@ -161,25 +229,399 @@ func (h *PaymentHandler) GetPaymentByReference(c echo.Context) error {
 		})
 	}
 
+	return c.JSON(http.StatusOK, h.withAttachments(c, payment))
+}
+
+// SearchPayments finds payments by part of a reference, customer name or description
+// @Summary Search payments
+// @Description Find payments whose reference, customer name or description contains the query, e.g. the last digits of a reference or part of a customer's name in Amharic or English. Exact references come first, then the closest matches.
+// @Tags payments
+// @Produce json
+// @Param q query string true "3 to 100 characters"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} domain.PaymentListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /payments/search [get]
+func (h *PaymentHandler) SearchPayments(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	result, err := h.paymentService.SearchPayments(c.Request().Context(), c.QueryParam("q"), CallerMerchant(c), page, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid search query",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to search payments")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to search payments",
+		})
+	}
+
+	responses := make([]domain.PaymentResponse, len(result.Payments))
+	for i, payment := range result.Payments {
+		responses[i] = payment.ToResponse()
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return c.JSON(http.StatusOK, domain.PaymentListResponse{
+		Payments: responses,
+		Total:    len(responses),
+		Page:     page,
+		Limit:    limit,
+		HasMore:  result.HasMore,
+	})
+}
+
+// withAttachments adds signed attachment links to a single-payment response.
+// A storage or database hiccup only drops the links, not the payment.
+func (h *PaymentHandler) withAttachments(c echo.Context, payment *domain.Payment) domain.PaymentResponse {
+	response := payment.ToResponse()
+
+	attachments, err := h.attachmentService.List(c.Request().Context(), payment.ID)
+	if err != nil {
+		h.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to list payment attachments")
+	}
+	response.Attachments = attachments
+
+	return response
+}
+
+// ConfirmOTP releases a payment once the customer's OTP is confirmed
+// @Summary Confirm payment OTP
+// @Description Submit the SMS code sent to the customer; the payment is processed only after a match. For wallets that send their own OTP (Amole), the code is passed to the wallet while the payment is PROCESSING
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param otp body domain.ConfirmOTPRequest true "OTP code"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /payments/{id}/confirm-otp [post]
+func (h *PaymentHandler) ConfirmOTP(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.ConfirmOTPRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "OTP code is required",
+		})
+	}
+
+	payment, err := h.paymentService.ConfirmOTP(c.Request().Context(), id, req.Code)
+	if err != nil {
+		return h.otpError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, payment.ToResponse())
+}
+
+// ResendOTP sends the customer a fresh OTP
+// @Summary Resend payment OTP
+// @Tags payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /payments/{id}/resend-otp [post]
+func (h *PaymentHandler) ResendOTP(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	if err := h.paymentService.ResendOTP(c.Request().Context(), id); err != nil {
+		return h.otpError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message": "OTP sent",
+	})
+}
+
+func (h *PaymentHandler) otpError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrPaymentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	case domain.ErrOTPInvalid:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case domain.ErrOTPNotAwaited:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case domain.ErrOTPExpired:
+		return c.JSON(http.StatusGone, map[string]string{
+			"error": err.Error(),
+		})
+	case domain.ErrOTPAttemptsExceeded, domain.ErrOTPResendLimit:
+		return c.JSON(http.StatusTooManyRequests, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error("Failed to handle payment OTP")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to handle payment OTP",
+		})
+	}
+}
+
+// RetryPayment re-queues a failed payment
+// @Summary Retry a failed payment
+// @Description Re-queue a FAILED payment, optionally on a different bank. Earlier attempts are kept in the attempt history.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param retry body domain.RetryPaymentRequest false "Optional bank to retry on"
+// @Success 202 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /payments/{id}/retry [post]
+func (h *PaymentHandler) RetryPayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.RetryPaymentRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	payment, err := h.paymentService.RetryPayment(c.Request().Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case err == domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case err == domain.ErrPaymentNotRetryable:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).WithField("payment_id", id).Error("Failed to retry payment")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to retry payment",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, payment.ToResponse())
+}
+
+// CancelPayment withdraws a payment that has not been processed
+// @Summary Cancel a pending payment
+// @Description Moves a PENDING payment to CANCELLED. Payments already picked up by the worker, or waiting for an OTP or cash, cannot be cancelled.
+// @Tags payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /payments/{id}/cancel [post]
+func (h *PaymentHandler) CancelPayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	payment, err := h.paymentService.CancelPayment(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case domain.ErrPaymentNotPending:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).WithField("payment_id", id).Error("Failed to cancel payment")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to cancel payment",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, payment.ToResponse())
+}
+
+// SetPaymentTags replaces a payment's tags
+// @Summary Set payment tags
+// @Description Replaces the payment's free-form labels (e.g. "ramadan-promo", "branch-bole"). Tags are lowercased; send an empty list to clear them.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param tags body domain.SetTagsRequest true "Tags"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/tags [put]
+func (h *PaymentHandler) SetPaymentTags(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.SetTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	payment, err := h.paymentService.SetTags(c.Request().Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case err == domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to set payment tags")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to set payment tags",
+			})
+		}
+	}
+
 	return c.JSON(http.StatusOK, payment.ToResponse())
 }
 
+// ListPaymentAttempts returns the processing history of a payment
+// @Summary List payment processing attempts
+// @Tags payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.PaymentAttempt
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/attempts [get]
+func (h *PaymentHandler) ListPaymentAttempts(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	attempts, err := h.paymentService.ListAttempts(c.Request().Context(), id)
+	if err == domain.ErrPaymentNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("payment_id", id).Error("Failed to list payment attempts")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list payment attempts",
+		})
+	}
+
+	if attempts == nil {
+		attempts = []*domain.PaymentAttempt{}
+	}
+
+	return c.JSON(http.StatusOK, attempts)
+}
+
 // ListPayments retrieves paginated list of payments
 // @Summary List payments
-// @Description Get paginated list of Ethiopian payments
+// @Description Get paginated list of Ethiopian payments, newest first. Pass the next_cursor of a page as cursor to get the one after it; unlike page numbers, cursors neither skip nor repeat payments while new ones are created.
 // @Tags payments
 // @Produce json
+// @Param cursor query string false "next_cursor of the previous page; takes precedence over page"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
-// @Success 200 {object} map[string]interface{}
+// @Param purpose_code query string false "Only payments with this purpose code"
+// @Param mcc query string false "Only payments with this merchant category code"
+// @Param limit_flagged query bool false "Only payments accepted over a customer limit"
+// @Param tags query string false "Comma-separated tags; only payments carrying all of them"
+// @Param status query string false "Only payments with this status, e.g. SUCCESS"
+// @Param currency query string false "Only payments in this currency"
+// @Param bank_code query string false "Only payments to this bank"
+// @Param min_amount query string false "Smallest amount, inclusive, e.g. 1000.50"
+// @Param max_amount query string false "Largest amount, inclusive"
+// @Param created_from query string false "Created at or after: RFC 3339 time or YYYY-MM-DD in Ethiopian time"
+// @Param created_to query string false "Created before a time, or on or before a YYYY-MM-DD date"
+// @Param sort query string false "-created_at (default), created_at, -amount or amount; cursors apply to created_at orders only"
+// @Success 200 {object} domain.PaymentListResponse
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /payments [get]
 func (h *PaymentHandler) ListPayments(c echo.Context) error {
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	limit, _ := strconv.Atoi(c.QueryParam("limit"))
 
-	payments, total, err := h.paymentService.ListPayments(c.Request().Context(), page, limit)
+	filter, err := paymentFilter(c)
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid filter or cursor",
+			"details": err.Error(),
+		})
+	}
+
+	result, err := h.paymentService.ListPayments(c.Request().Context(), filter, c.QueryParam("cursor"), page, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid filter or cursor",
+				"details": err.Error(),
+			})
+		}
 		h.logger.WithError(err).Error("Failed to list payments")
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to list payments",
@@ -187,31 +629,109 @@ func (h *PaymentHandler) ListPayments(c echo.Context) error {
 	}
 
 	// Convert to responses
-	responses := make([]domain.PaymentResponse, len(payments))
-	for i, payment := range payments {
+	responses := make([]domain.PaymentResponse, len(result.Payments))
+	for i, payment := range result.Payments {
 		responses[i] = payment.ToResponse()
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"payments": responses,
-		"total":    total,
-		"page":     page,
-		"limit":    limit,
-		"has_more": total > page*limit,
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return c.JSON(http.StatusOK, domain.PaymentListResponse{
+		Payments:   responses,
+		Total:      result.Total,
+		TotalPages: domain.TotalPages(result.Total, limit),
+		Page:       page,
+		Limit:      limit,
+		HasMore:    result.HasMore,
+		NextCursor: result.NextCursor,
 	})
 }
 
+// ExportPayments downloads payments as an Excel workbook
+// @Summary Export payments to Excel
+// @Description An .xlsx workbook with three sheets: every matching payment, oldest first, then totals by bank and by currency. Takes the filters of GET /payments; an export holds at most 50,000 payments. Times are in Ethiopian time, with the Ethiopian calendar date alongside.
+// @Tags payments
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param status query string false "Only payments with this status"
+// @Param currency query string false "Only payments in this currency"
+// @Param bank_code query string false "Only payments to this bank"
+// @Param min_amount query string false "Smallest amount, inclusive"
+// @Param max_amount query string false "Largest amount, inclusive"
+// @Param created_from query string false "Created at or after: RFC 3339 time or YYYY-MM-DD in Ethiopian time"
+// @Param created_to query string false "Created before a time, or on or before a YYYY-MM-DD date"
+// @Param sort query string false "created_at (default), -created_at, amount or -amount"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /payments/export.xlsx [get]
+func (h *PaymentHandler) ExportPayments(c echo.Context) error {
+	filter, err := paymentFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+	}
+
+	content, name, err := h.paymentService.ExportPayments(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to export payments")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to export payments",
+		})
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	return c.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content)
+}
+
 // GetStatistics retrieves Ethiopian payment statistics
 // @Summary Get payment statistics
 // @Description Get statistics about Ethiopian payments
 // @Tags statistics
 // @Produce json
+// @Param purpose_code query string false "Only payments with this purpose code"
+// @Param mcc query string false "Only payments with this merchant category code"
+// @Param tags query string false "Comma-separated tags; only payments carrying all of them"
+// @Param status query string false "Only payments with this status"
+// @Param currency query string false "Only payments in this currency"
+// @Param bank_code query string false "Only payments to this bank"
+// @Param created_from query string false "Created at or after: RFC 3339 time or YYYY-MM-DD in Ethiopian time"
+// @Param created_to query string false "Created before a time, or on or before a YYYY-MM-DD date"
 // @Success 200 {object} service.PaymentStatistics
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /statistics [get]
 func (h *PaymentHandler) GetStatistics(c echo.Context) error {
-	stats, err := h.paymentService.GetStatistics(c.Request().Context())
+	filter, err := paymentFilter(c)
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+	}
+
+	stats, err := h.paymentService.GetStatistics(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+		}
 		h.logger.WithError(err).Error("Failed to get statistics")
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to get statistics",
@@ -221,23 +741,34 @@ func (h *PaymentHandler) GetStatistics(c echo.Context) error {
 	return c.JSON(http.StatusOK, stats)
 }
 
-// HealthCheck handles health checks
-// @Summary Health check
-// @Description Check if the Ethiopian Payment Gateway is healthy
-// @Tags health
-// @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Router /health [get]
-func (h *PaymentHandler) HealthCheck(c echo.Context) error {
-	ethiopianTime := time.Now().Add(3 * time.Hour) // GMT+3
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status":         "healthy",
-		"service":        "Ethiopian Payment Gateway",
-		"timestamp":      time.Now().UTC().Format(time.RFC3339),
-		"ethiopian_time": ethiopianTime.Format("2006-01-02 15:04:05 EAT"),
-		"version":        "1.0.0",
-	})
+func paymentFilter(c echo.Context) (domain.PaymentFilter, error) {
+	flagged, _ := strconv.ParseBool(c.QueryParam("limit_flagged"))
+	filter := domain.PaymentFilter{
+		PurposeCode:  c.QueryParam("purpose_code"),
+		MCC:          c.QueryParam("mcc"),
+		LimitFlagged: flagged,
+		MerchantID:   CallerMerchant(c),
+		Status:       domain.PaymentStatus(c.QueryParam("status")),
+		Currency:     domain.Currency(c.QueryParam("currency")),
+		BankCode:     c.QueryParam("bank_code"),
+		CreatedFrom:  c.QueryParam("created_from"),
+		CreatedTo:    c.QueryParam("created_to"),
+		Sort:         domain.PaymentSort(c.QueryParam("sort")),
+	}
+	if tags := c.QueryParam("tags"); tags != "" {
+		filter.Tags = strings.Split(tags, ",")
+	}
+	if value := c.QueryParam("min_amount"); value != "" {
+		if err := filter.MinAmount.UnmarshalText([]byte(value)); err != nil {
+			return filter, errors.New("min_amount: " + err.Error())
+		}
+	}
+	if value := c.QueryParam("max_amount"); value != "" {
+		if err := filter.MaxAmount.UnmarshalText([]byte(value)); err != nil {
+			return filter, errors.New("max_amount: " + err.Error())
+		}
+	}
+	return filter, nil
 }
 
 // EthiopianBankList returns list of Ethiopian banks
@@ -248,41 +779,32 @@ func (h *PaymentHandler) HealthCheck(c echo.Context) error {
 // @Success 200 {object} map[string]interface{}
 // @Router /banks [get]
 func (h *PaymentHandler) EthiopianBankList(c echo.Context) error {
-	banks := []map[string]string{
-		{
-			"code":  "CBE",
-			"name":  "Commercial Bank of Ethiopia",
-			"swift": "CBETETAA",
-		},
-		{
-			"code":  "AWASH",
-			"name":  "Awash Bank",
-			"swift": "AWINETAA",
-		},
-		{
-			"code":  "DASHEN",
-			"name":  "Dashen Bank",
-			"swift": "DASHETAA",
-		},
-		{
-			"code":  "ABYSSINIA",
-			"name":  "Bank of Abyssinia",
-			"swift": "ABYSETAA",
-		},
-		{
-			"code":  "NIB",
-			"name":  "Nib International Bank",
-			"swift": "NIBIETAA",
-		},
-		{
-			"code":  "UNITED",
-			"name":  "United Bank",
-			"swift": "UBNIETAA",
-		},
-	}
-
+	// The bank registry only changes on deploy
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"banks":   banks,
+		"banks":   domain.Banks(),
 		"message": "የኢትዮጵያ ባንኮች ዝርዝር (List of Ethiopian Banks)",
 	})
 }
+
+// PurposeCodeList returns the accepted purpose-of-payment codes
+// @Summary Get purpose codes
+// @Description ISO 20022 purpose codes accepted in purpose_code
+// @Tags banks
+// @Produce json
+// @Success 200 {array} domain.PurposeCode
+// @Router /purpose-codes [get]
+func (h *PaymentHandler) PurposeCodeList(c echo.Context) error {
+	return c.JSON(http.StatusOK, domain.PurposeCodes())
+}
+
+// MerchantCategoryList returns the accepted merchant category codes
+// @Summary Get merchant category codes
+// @Description ISO 18245 MCCs accepted in mcc
+// @Tags banks
+// @Produce json
+// @Success 200 {array} domain.MerchantCategory
+// @Router /merchant-categories [get]
+func (h *PaymentHandler) MerchantCategoryList(c echo.Context) error {
+	return c.JSON(http.StatusOK, domain.MerchantCategories())
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentLinkHandler struct {
+	paymentLinkService service.PaymentLinkService
+	defaultLanguage    domain.Language
+	logger             *logrus.Logger
+}
+
+func NewPaymentLinkHandler(paymentLinkService service.PaymentLinkService, defaultLanguage domain.Language, logger *logrus.Logger) *PaymentLinkHandler {
+	return &PaymentLinkHandler{
+		paymentLinkService: paymentLinkService,
+		defaultLanguage:    defaultLanguage,
+		logger:             logger,
+	}
+}
+
+// CreatePaymentLink issues a hosted payment link
+// @Summary Create payment link
+// @Description Issue a short URL to a hosted checkout page (Amharic/English) where the customer picks a bank or wallet and a payment for the link's amount is created. A one-time link takes a single payment; expires_at stops it being used after that time.
+// @Tags payment-links
+// @Accept json
+// @Produce json
+// @Param link body domain.CreatePaymentLinkRequest true "Amount, expiry and one-time flag"
+// @Success 201 {object} domain.PaymentLink
+// @Failure 400 {object} map[string]string
+// @Router /payment-links [post]
+func (h *PaymentLinkHandler) CreatePaymentLink(c echo.Context) error {
+	var req domain.CreatePaymentLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	link, err := h.paymentLinkService.Create(c.Request().Context(), req, CallerMerchant(c))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to create payment link")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create payment link",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, link)
+}
+
+// GetPaymentLink returns a payment link and the payments made through it
+// @Summary Get payment link
+// @Tags payment-links
+// @Produce json
+// @Param id path string true "Payment link ID"
+// @Success 200 {object} domain.PaymentLink
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payment-links/{id} [get]
+func (h *PaymentLinkHandler) GetPaymentLink(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment link ID format",
+		})
+	}
+
+	link, err := h.paymentLinkService.Get(c.Request().Context(), id)
+	if err != nil {
+		if err == domain.ErrPaymentLinkNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment link not found",
+			})
+		}
+		h.logger.WithError(err).Error("Failed to get payment link")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get payment link",
+		})
+	}
+
+	return c.JSON(http.StatusOK, link)
+}
+
+// DeactivatePaymentLink stops a payment link taking payments
+// @Summary Deactivate payment link
+// @Description Payments already made through the link are not affected.
+// @Tags payment-links
+// @Param id path string true "Payment link ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payment-links/{id} [delete]
+func (h *PaymentLinkHandler) DeactivatePaymentLink(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment link ID format",
+		})
+	}
+
+	if err := h.paymentLinkService.Deactivate(c.Request().Context(), id); err != nil {
+		if err == domain.ErrPaymentLinkNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment link not found",
+			})
+		}
+		h.logger.WithError(err).Error("Failed to deactivate payment link")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to deactivate payment link",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CheckoutPage renders the hosted checkout page of a payment link
+// @Summary Hosted checkout page
+// @Description Public HTML page in Amharic or English where the customer picks a bank or wallet
+// @Tags payment-links
+// @Produce html
+// @Param code path string true "Payment link code"
+// @Param lang query string false "am or en"
+// @Success 200 {string} string
+// @Failure 404 {string} string
+// @Failure 410 {string} string
+// @Router /pay/{code} [get]
+func (h *PaymentLinkHandler) CheckoutPage(c echo.Context) error {
+	setCheckoutHeaders(c)
+
+	link, err := h.paymentLinkService.Resolve(c.Request().Context(), c.Param("code"))
+	language := h.language(c, link)
+	if err != nil {
+		return h.checkoutError(c, err, language)
+	}
+
+	return h.render(c, http.StatusOK, checkoutView{Link: link, Options: h.paymentLinkService.Options(link)}, language)
+}
+
+// SubmitCheckout creates the payment for a submitted checkout page
+// @Summary Pay through a payment link
+// @Description Form post from the hosted checkout page. Wallet and card payments redirect to the provider's page; bank payments show the reference to pay with.
+// @Tags payment-links
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param code path string true "Payment link code"
+// @Param option formData string true "Bank code, or ARIFPAY, SANTIMPAY or CARD"
+// @Param customer_name formData string false "Payer name"
+// @Param customer_phone formData string false "Payer phone"
+// @Param lang formData string false "am or en"
+// @Success 200 {string} string
+// @Success 303 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 410 {string} string
+// @Router /pay/{code} [post]
+func (h *PaymentLinkHandler) SubmitCheckout(c echo.Context) error {
+	setCheckoutHeaders(c)
+
+	ctx := c.Request().Context()
+	code := c.Param("code")
+	link, err := h.paymentLinkService.Resolve(ctx, code)
+	language := h.language(c, link)
+	if err != nil {
+		return h.checkoutError(c, err, language)
+	}
+
+	form := domain.PaymentLinkCheckout{
+		Option:        c.FormValue("option"),
+		CustomerName:  c.FormValue("customer_name"),
+		CustomerPhone: c.FormValue("customer_phone"),
+		Language:      language,
+	}
+	form.Client, _ = c.Get(ClientContextKey).(domain.ClientInfo)
+
+	payment, err := h.paymentLinkService.Checkout(ctx, code, form)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrCustomerLimitExceeded), errors.Is(err, domain.ErrVelocityLimitExceeded),
+			errors.Is(err, domain.ErrCustomerNotVerified),
+			errors.Is(err, domain.ErrClientBlocked), err == domain.ErrMethodUnavailable, err == domain.ErrPaymentDeclined:
+			return h.render(c, http.StatusBadRequest, checkoutView{
+				Link:    link,
+				Options: h.paymentLinkService.Options(link),
+				Problem: err.Error(),
+			}, language)
+		default:
+			return h.checkoutError(c, err, language)
+		}
+	}
+
+	if payment.CheckoutURL != "" {
+		return c.Redirect(http.StatusSeeOther, payment.CheckoutURL)
+	}
+
+	return h.render(c, http.StatusOK, checkoutView{Link: link, Payment: payment}, language)
+}
+
+// setCheckoutHeaders keeps checkout pages out of caches and search engines
+func setCheckoutHeaders(c echo.Context) {
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Robots-Tag", "noindex, nofollow")
+	header.Set("Referrer-Policy", "no-referrer")
+}
+
+func (h *PaymentLinkHandler) language(c echo.Context, link *domain.PaymentLink) domain.Language {
+	language := domain.Language(c.FormValue("lang"))
+	if !language.IsValid() && link != nil {
+		language = link.Language
+	}
+	if !language.IsValid() {
+		language = h.defaultLanguage
+	}
+	if !language.IsValid() {
+		language = domain.LanguageAmharic
+	}
+	return language
+}
+
+func (h *PaymentLinkHandler) checkoutError(c echo.Context, err error, language domain.Language) error {
+	text := checkoutText[language]
+	switch err {
+	case domain.ErrPaymentLinkNotFound:
+		return h.render(c, http.StatusNotFound, checkoutView{Closed: text.NotFound}, language)
+	case domain.ErrPaymentLinkExpired:
+		return h.render(c, http.StatusGone, checkoutView{Closed: text.Expired}, language)
+	case domain.ErrPaymentLinkUsed:
+		return h.render(c, http.StatusGone, checkoutView{Closed: text.Used}, language)
+	case domain.ErrPaymentLinkInactive:
+		return h.render(c, http.StatusGone, checkoutView{Closed: text.Inactive}, language)
+	default:
+		h.logger.WithError(err).Error("Payment link checkout failed")
+		return h.render(c, http.StatusInternalServerError, checkoutView{Closed: text.Unavailable}, language)
+	}
+}
+
+// checkoutView is one state of the checkout page: the form, the payment
+// it created, or why the link is closed
+type checkoutView struct {
+	Link    *domain.PaymentLink
+	Options []domain.CheckoutOption
+	Problem string
+	Payment *domain.Payment
+	Closed  string
+}
+
+func (h *PaymentLinkHandler) render(c echo.Context, status int, view checkoutView, language domain.Language) error {
+	otherLanguage := domain.LanguageEnglish
+	if language == domain.LanguageEnglish {
+		otherLanguage = domain.LanguageAmharic
+	}
+
+	data := struct {
+		checkoutView
+		Lang      domain.Language
+		OtherLang domain.Language
+		L         checkoutLabels
+		Amount    string
+	}{
+		checkoutView: view,
+		Lang:         language,
+		OtherLang:    otherLanguage,
+		L:            checkoutText[language],
+	}
+	if view.Link != nil {
+		data.Amount = fmt.Sprintf("%s %.2f %s", view.Link.Currency.GetSymbol(), view.Link.Amount, view.Link.Currency)
+	}
+
+	var out bytes.Buffer
+	if err := checkoutPage.Execute(&out, data); err != nil {
+		h.logger.WithError(err).Error("Failed to render checkout page")
+		return c.String(http.StatusInternalServerError, "Checkout unavailable / ክፍያው አይገኝም")
+	}
+	return c.HTML(status, out.String())
+}
+
+type checkoutLabels struct {
+	Title, Amount, Choose, Name, Phone, Pay, Started, Reference, Instructions, Footer, Switch string
+	NotFound, Expired, Used, Inactive, Unavailable                                            string
+}
+
+var checkoutText = map[domain.Language]checkoutLabels{
+	domain.LanguageAmharic: {
+		Title:        "ክፍያ ይፈጽሙ",
+		Amount:       "መጠን",
+		Choose:       "ባንክ ወይም ዋሌት ይምረጡ",
+		Name:         "ሙሉ ስም",
+		Phone:        "ስልክ ቁጥር",
+		Pay:          "ክፈል",
+		Started:      "ክፍያዎ ተጀምሯል",
+		Reference:    "ማጣቀሻ",
+		Instructions: "ክፍያውን በባንክ መተግበሪያዎ በዚህ ማጣቀሻ ያጠናቅቁ።",
+		Footer:       "በኢትዮጵያ ክፍያ መግቢያ የቀረበ።",
+		Switch:       "English",
+		NotFound:     "የክፍያ ሊንኩ አልተገኘም።",
+		Expired:      "የክፍያ ሊንኩ ጊዜው አልፏል።",
+		Used:         "ይህ የክፍያ ሊንክ አስቀድሞ ጥቅም ላይ ውሏል።",
+		Inactive:     "ይህ የክፍያ ሊንክ ተሰርዟል።",
+		Unavailable:  "ክፍያው አሁን አይገኝም፤ እባክዎ ቆይተው ይሞክሩ።",
+	},
+	domain.LanguageEnglish: {
+		Title:        "Make a payment",
+		Amount:       "Amount",
+		Choose:       "Choose your bank or wallet",
+		Name:         "Full name",
+		Phone:        "Phone number",
+		Pay:          "Pay",
+		Started:      "Your payment has been started",
+		Reference:    "Reference",
+		Instructions: "Complete the payment in your bank app using this reference.",
+		Footer:       "Provided by the Ethiopian Payment Gateway.",
+		Switch:       "አማርኛ",
+		NotFound:     "This payment link was not found.",
+		Expired:      "This payment link has expired.",
+		Used:         "This payment link has already been used.",
+		Inactive:     "This payment link has been deactivated.",
+		Unavailable:  "Checkout is unavailable right now; please try again later.",
+	},
+}
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.L.Title}}</title>
+<style>
+body{font-family:"Noto Sans Ethiopic","Nyala",sans-serif;background:#f4f6f8;margin:0;padding:24px;color:#1f2933}
+.card{max-width:480px;margin:0 auto;background:#fff;border-radius:8px;padding:24px;box-shadow:0 1px 4px rgba(0,0,0,.1)}
+h1{font-size:20px;margin:0 0 16px}
+.amount{font-size:28px;font-weight:bold;margin-bottom:8px}
+.description{color:#616e7c;margin-bottom:16px}
+label{display:block;margin:12px 0 4px;color:#616e7c}
+select,input{width:100%;box-sizing:border-box;padding:10px;border:1px solid #cbd2d9;border-radius:4px;font-size:16px}
+button{width:100%;margin-top:20px;padding:12px;border:0;border-radius:4px;background:#147d64;color:#fff;font-size:18px}
+.problem{color:#ab091e;margin-bottom:12px}
+.reference{font-size:22px;font-family:monospace;margin:8px 0 16px}
+footer{margin-top:16px;font-size:12px;color:#7b8794;display:flex;justify-content:space-between}
+</style>
+</head>
+<body>
+<div class="card">
+{{if .Closed}}
+<h1>{{.Closed}}</h1>
+{{else if .Payment}}
+<h1>{{.L.Started}}</h1>
+<div class="amount">{{.Amount}}</div>
+<div>{{.L.Reference}}</div>
+<div class="reference">{{.Payment.Reference}}</div>
+<p>{{.L.Instructions}}</p>
+{{else}}
+<h1>{{.L.Title}}</h1>
+<div class="amount">{{.Amount}}</div>
+{{if .Link.Description}}<div class="description">{{.Link.Description}}</div>{{end}}
+{{if .Problem}}<div class="problem">{{.Problem}}</div>{{end}}
+<form method="post">
+<input type="hidden" name="lang" value="{{.Lang}}">
+<label for="option">{{.L.Choose}}</label>
+<select id="option" name="option" required>
+{{range .Options}}<option value="{{.Value}}">{{.Name}}</option>
+{{end}}</select>
+<label for="customer_name">{{.L.Name}}</label>
+<input id="customer_name" name="customer_name" maxlength="100" autocomplete="name">
+<label for="customer_phone">{{.L.Phone}}</label>
+<input id="customer_phone" name="customer_phone" type="tel" maxlength="20" autocomplete="tel" placeholder="09XXXXXXXX">
+<button type="submit">{{.L.Pay}} {{.Amount}}</button>
+</form>
+{{end}}
+<footer><span>{{.L.Footer}}</span>{{if not .Payment}}<a href="?lang={{.OtherLang}}">{{.L.Switch}}</a>{{end}}</footer>
+</div>
+</body>
+</html>`))
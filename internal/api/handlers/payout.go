@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Largest payouts file accepted
+const maxPayoutFileSize = 5 << 20
+
+type PayoutHandler struct {
+	payoutService service.BulkPayoutService
+	logger        *logrus.Logger
+}
+
+func NewPayoutHandler(payoutService service.BulkPayoutService, logger *logrus.Logger) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService: payoutService,
+		logger:        logger,
+	}
+}
+
+// UploadBulk accepts a payouts CSV and queues it for processing
+// @Summary Upload bulk payouts
+// @Description Upload a CSV with bank_code, account, amount and reason columns (currency optional). Invalid rows are rejected individually; the rest are paid out in the background. With callback_url, the response carries a callback_secret, shown only once, that signs the completion callback's X-Signature header (see pkg/webhook).
+// @Tags payouts
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Payouts CSV"
+// @Param callback_url formData string false "URL notified when the job completes"
+// @Success 202 {object} domain.BulkPayoutJob
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /payouts/bulk [post]
+func (h *PayoutHandler) UploadBulk(c echo.Context) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "file is required",
+		})
+	}
+	if header.Size > maxPayoutFileSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": "Payouts file is too large",
+		})
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxPayoutFileSize))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+
+	job, err := h.payoutService.Upload(c.Request().Context(), header.Filename, data, c.FormValue("callback_url"), CallerMerchant(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case errors.Is(err, domain.ErrBulkFileInvalid):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error":   "Payouts file could not be parsed",
+				"details": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to upload bulk payouts")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to upload bulk payouts",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// GetBulkJob returns the progress of a bulk payout job
+// @Summary Get bulk payout job
+// @Tags payouts
+// @Produce json
+// @Param id path string true "Bulk job ID"
+// @Success 200 {object} domain.BulkPayoutJob
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payouts/bulk/{id} [get]
+func (h *PayoutHandler) GetBulkJob(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid bulk job ID format",
+		})
+	}
+
+	job, err := h.payoutService.GetJob(c.Request().Context(), id, CallerMerchant(c))
+	if err == domain.ErrBulkJobNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Bulk payout job not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get bulk payout job")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get bulk payout job",
+		})
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// ListBulkRows returns the per-row outcome of a bulk payout job
+// @Summary List bulk payout rows
+// @Tags payouts
+// @Produce json
+// @Param id path string true "Bulk job ID"
+// @Success 200 {array} domain.Payout
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payouts/bulk/{id}/rows [get]
+func (h *PayoutHandler) ListBulkRows(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid bulk job ID format",
+		})
+	}
+
+	rows, err := h.payoutService.ListRows(c.Request().Context(), id, CallerMerchant(c))
+	if err == domain.ErrBulkJobNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Bulk payout job not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list bulk payout rows")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list bulk payout rows",
+		})
+	}
+
+	if rows == nil {
+		rows = []*domain.Payout{}
+	}
+
+	return c.JSON(http.StatusOK, rows)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider/arifpay"
+	"payment-gateway/internal/provider/chapa"
+	"payment-gateway/internal/provider/santimpay"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// ProviderSettings holds what the provider callbacks check and where they
+// send customers
+type ProviderSettings struct {
+	ChapaWebhookSecret string
+	SantimPayPublicKey string
+	CardResultURL      string // Where card customers land after 3-D Secure; JSON when empty
+}
+
+type ProviderHandler struct {
+	paymentService service.PaymentService
+	settings       ProviderSettings
+	logger         *logrus.Logger
+}
+
+func NewProviderHandler(paymentService service.PaymentService, settings ProviderSettings, logger *logrus.Logger) *ProviderHandler {
+	return &ProviderHandler{
+		paymentService: paymentService,
+		settings:       settings,
+		logger:         logger,
+	}
+}
+
+// ChapaWebhook receives Chapa transaction events. The event only triggers
+// a verify call to Chapa; its body is not trusted for the outcome.
+// @Summary Chapa webhook
+// @Tags providers
+// @Accept json
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /providers/chapa/webhook [post]
+func (h *ProviderHandler) ChapaWebhook(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, 64<<10))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event body",
+		})
+	}
+
+	req := c.Request()
+	if !chapa.VerifySignature(h.settings.ChapaWebhookSecret, body, req.Header.Get("x-chapa-signature"), req.Header.Get("Chapa-Signature")) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid webhook signature",
+		})
+	}
+
+	id, err := chapa.PaymentID(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid event body",
+			"details": err.Error(),
+		})
+	}
+
+	return h.syncPayment(c, "Chapa", id)
+}
+
+// ArifPayWebhook receives ArifPay checkout notifications. ArifPay does not
+// sign them, so the notification only triggers a session lookup.
+// @Summary ArifPay webhook
+// @Tags providers
+// @Accept json
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /providers/arifpay/webhook [post]
+func (h *ProviderHandler) ArifPayWebhook(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, 64<<10))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event body",
+		})
+	}
+
+	id, err := arifpay.PaymentID(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid event body",
+			"details": err.Error(),
+		})
+	}
+
+	return h.syncPayment(c, "ArifPay", id)
+}
+
+// SantimPayWebhook receives SantimPay payment callbacks. The signed-token
+// header must verify against SantimPay's public key; the outcome is still
+// fetched from SantimPay rather than read from the body.
+// @Summary SantimPay webhook
+// @Tags providers
+// @Accept json
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /providers/santimpay/webhook [post]
+func (h *ProviderHandler) SantimPayWebhook(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, 64<<10))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event body",
+		})
+	}
+
+	if err := santimpay.VerifyCallback(h.settings.SantimPayPublicKey, c.Request().Header.Get("signed-token")); err != nil {
+		h.logger.WithError(err).Warn("Rejected SantimPay callback")
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid callback signature",
+		})
+	}
+
+	id, err := santimpay.PaymentID(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid event body",
+			"details": err.Error(),
+		})
+	}
+
+	return h.syncPayment(c, "SantimPay", id)
+}
+
+// EthSwitchReturn is where the card page sends the customer back, after
+// 3-D Secure or on cancelling. The order status is fetched from EthSwitch,
+// then the customer is redirected to the merchant's result page.
+// @Summary EthSwitch card return
+// @Tags providers
+// @Param payment_id query string true "Payment ID"
+// @Success 302
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /providers/ethswitch/return [get]
+func (h *ProviderHandler) EthSwitchReturn(c echo.Context) error {
+	id, err := uuid.Parse(c.QueryParam("payment_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	payment, err := h.paymentService.SyncWithProvider(c.Request().Context(), id)
+	switch {
+	case err == domain.ErrPaymentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	case err != nil:
+		h.logger.WithError(err).WithField("payment_id", id).Warn("Failed to fetch EthSwitch order status")
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to fetch card payment status",
+		})
+	}
+
+	if h.settings.CardResultURL == "" {
+		return c.JSON(http.StatusOK, payment.ToResponse())
+	}
+
+	// PROCESSING means the outcome is not known yet; the result page polls
+	query := url.Values{
+		"payment_id": {payment.ID.String()},
+		"reference":  {payment.Reference},
+		"status":     {string(payment.Status)},
+	}
+	return c.Redirect(http.StatusFound, h.settings.CardResultURL+"?"+query.Encode())
+}
+
+// syncPayment asks the provider for the outcome of a payment it notified
+// us about. Providers retry non-2xx responses.
+func (h *ProviderHandler) syncPayment(c echo.Context, providerName string, id uuid.UUID) error {
+	payment, err := h.paymentService.SyncWithProvider(c.Request().Context(), id)
+	switch {
+	case err == domain.ErrPaymentNotFound:
+		// Not ours; acknowledge so the provider stops retrying
+		h.logger.WithField("payment_id", id).Warnf("%s webhook for unknown payment", providerName)
+		return c.NoContent(http.StatusOK)
+	case err != nil:
+		h.logger.WithError(err).WithField("payment_id", id).Warnf("Failed to verify %s transaction", providerName)
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to verify transaction with " + providerName,
+		})
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"payment_id": id,
+		"status":     payment.Status,
+	}).Infof("%s webhook handled", providerName)
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Pixels per module of a rendered QR code
+const (
+	defaultQRScale = 8
+	maxQRScale     = 40
+)
+
+type QRCodeHandler struct {
+	qrCodeService service.QRCodeService
+	logger        *logrus.Logger
+}
+
+func NewQRCodeHandler(qrCodeService service.QRCodeService, logger *logrus.Logger) *QRCodeHandler {
+	return &QRCodeHandler{
+		qrCodeService: qrCodeService,
+		logger:        logger,
+	}
+}
+
+// GetPaymentQR renders a printable QR code for a pending payment
+// @Summary Payment QR code
+// @Description EMVCo merchant-presented QR code (EthSwitch interoperable QR) with the payment's amount and reference, for Ethiopian bank and wallet apps to scan at the counter. Only pending ETB payments have one.
+// @Tags payments
+// @Produce png
+// @Produce image/svg+xml
+// @Param id path string true "Payment ID"
+// @Param format query string false "png (default) or svg"
+// @Param scale query int false "Pixels per module, 1-40 (default 8)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /payments/{id}/qr [get]
+func (h *QRCodeHandler) GetPaymentQR(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+	format, scale, err := qrOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	image, err := h.qrCodeService.ForPayment(c.Request().Context(), id, format, scale)
+	if err != nil {
+		if err == domain.ErrPaymentNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		}
+		return h.qrError(c, err)
+	}
+
+	return h.send(c, format, image)
+}
+
+// GetPaymentLinkQR renders a printable QR code for a payment link
+// @Summary Payment link QR code
+// @Description EMVCo merchant-presented QR code with the link's amount and code as the reference. Reusable links get a static code that can be paid many times.
+// @Tags payment-links
+// @Produce png
+// @Produce image/svg+xml
+// @Param id path string true "Payment link ID"
+// @Param format query string false "png (default) or svg"
+// @Param scale query int false "Pixels per module, 1-40 (default 8)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /payment-links/{id}/qr [get]
+func (h *QRCodeHandler) GetPaymentLinkQR(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment link ID format",
+		})
+	}
+	format, scale, err := qrOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	image, err := h.qrCodeService.ForPaymentLink(c.Request().Context(), id, format, scale)
+	if err != nil {
+		switch err {
+		case domain.ErrPaymentLinkNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment link not found",
+			})
+		case domain.ErrPaymentLinkExpired, domain.ErrPaymentLinkUsed, domain.ErrPaymentLinkInactive:
+			return c.JSON(http.StatusGone, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return h.qrError(c, err)
+	}
+
+	return h.send(c, format, image)
+}
+
+func qrOptions(c echo.Context) (string, int, error) {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = domain.QRFormatPNG
+	}
+	if format != domain.QRFormatPNG && format != domain.QRFormatSVG {
+		return "", 0, errors.New("format must be png or svg")
+	}
+
+	scale := defaultQRScale
+	if s := c.QueryParam("scale"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxQRScale {
+			return "", 0, errors.New("scale must be between 1 and 40")
+		}
+		scale = n
+	}
+	return format, scale, nil
+}
+
+func (h *QRCodeHandler) qrError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrQRNotAvailable, domain.ErrQRReferenceTooLong:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case domain.ErrQRCodesDisabled:
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error("Failed to render QR code")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to render QR code",
+		})
+	}
+}
+
+func (h *QRCodeHandler) send(c echo.Context, format string, image []byte) error {
+	contentType := "image/png"
+	if format == domain.QRFormatSVG {
+		contentType = "image/svg+xml"
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.Blob(http.StatusOK, contentType, image)
+}
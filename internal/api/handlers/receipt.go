@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type ReceiptHandler struct {
+	receiptService  service.ReceiptService
+	defaultLanguage domain.Language
+	logger          *logrus.Logger
+}
+
+func NewReceiptHandler(receiptService service.ReceiptService, defaultLanguage domain.Language, logger *logrus.Logger) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptService:  receiptService,
+		defaultLanguage: defaultLanguage,
+		logger:          logger,
+	}
+}
+
+// CreateReceiptLink issues a shareable public receipt URL
+// @Summary Create public receipt link
+// @Description Issue a signed, read-only receipt URL for a successful payment. Issuing again invalidates the previous URL.
+// @Tags receipts
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 201 {object} domain.ReceiptLink
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /payments/{id}/receipt-link [post]
+func (h *ReceiptHandler) CreateReceiptLink(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	link, err := h.receiptService.CreateLink(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case domain.ErrReceiptNotAvailable:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		case domain.ErrReceiptLinksDisabled:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to create receipt link")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to create receipt link",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, link)
+}
+
+// RevokeReceiptLink disables the payment's public receipt URL
+// @Summary Revoke public receipt link
+// @Tags receipts
+// @Param id path string true "Payment ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/receipt-link [delete]
+func (h *ReceiptHandler) RevokeReceiptLink(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	if err := h.receiptService.RevokeLink(c.Request().Context(), id); err != nil {
+		if err == domain.ErrReceiptLinkNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "No active receipt link for this payment",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to revoke receipt link",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DownloadReceiptPDF returns a printable receipt
+// @Summary Download receipt PDF
+// @Description Branded A4 receipt for a successful payment, labelled in Amharic and English, with the Ethiopian-calendar date, bank name and reference
+// @Tags receipts
+// @Produce application/pdf
+// @Param id path string true "Payment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /payments/{id}/receipt.pdf [get]
+func (h *ReceiptHandler) DownloadReceiptPDF(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	document, err := h.receiptService.PDF(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case domain.ErrReceiptNotAvailable:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		case domain.ErrReceiptPDFDisabled:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to render receipt PDF")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to render receipt PDF",
+			})
+		}
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "receipt-"+id.String()+".pdf"))
+	return c.Blob(http.StatusOK, "application/pdf", document)
+}
+
+// ViewReceipt renders the public, read-only receipt page
+// @Summary View public receipt
+// @Description Public HTML receipt in Amharic or English with the Ethiopian date
+// @Tags receipts
+// @Produce html
+// @Param token path string true "Signed receipt token"
+// @Param lang query string false "am or en"
+// @Success 200 {string} string
+// @Failure 404 {string} string
+// @Router /receipts/{token} [get]
+func (h *ReceiptHandler) ViewReceipt(c echo.Context) error {
+	// Receipts are personal; keep them out of caches, search engines and referrers
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Robots-Tag", "noindex, nofollow")
+	header.Set("Referrer-Policy", "no-referrer")
+
+	payment, err := h.receiptService.Resolve(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		if err == domain.ErrReceiptLinkNotFound || err == domain.ErrPaymentNotFound || err == domain.ErrReceiptNotAvailable {
+			return c.String(http.StatusNotFound, "Receipt not found / ደረሰኙ አልተገኘም")
+		}
+		h.logger.WithError(err).Error("Failed to resolve receipt")
+		return c.String(http.StatusInternalServerError, "Receipt unavailable / ደረሰኙ አይገኝም")
+	}
+
+	language := domain.Language(c.QueryParam("lang"))
+	if !language.IsValid() {
+		language = payment.Language
+	}
+	if !language.IsValid() {
+		language = h.defaultLanguage
+	}
+
+	page, err := renderReceipt(payment, language)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to render receipt")
+		return c.String(http.StatusInternalServerError, "Receipt unavailable / ደረሰኙ አይገኝም")
+	}
+
+	return c.HTML(http.StatusOK, page)
+}
+
+type receiptLabels struct {
+	Title, Amount, Reference, Status, Paid, Customer, Bank, Description, Date, EthiopianDate, Footer, Switch string
+}
+
+var receiptText = map[domain.Language]receiptLabels{
+	domain.LanguageAmharic: {
+		Title:         "የክፍያ ደረሰኝ",
+		Amount:        "መጠን",
+		Reference:     "ማጣቀሻ",
+		Status:        "ሁኔታ",
+		Paid:          "ተከፍሏል",
+		Customer:      "ከፋይ",
+		Bank:          "ባንክ",
+		Description:   "መግለጫ",
+		Date:          "ቀን (ግሪጎሪያን)",
+		EthiopianDate: "ቀን (ኢትዮጵያ)",
+		Footer:        "ይህ ደረሰኝ በኢትዮጵያ ክፍያ መግቢያ የተሰጠ ነው።",
+		Switch:        "English",
+	},
+	domain.LanguageEnglish: {
+		Title:         "Payment Receipt",
+		Amount:        "Amount",
+		Reference:     "Reference",
+		Status:        "Status",
+		Paid:          "Paid",
+		Customer:      "Payer",
+		Bank:          "Bank",
+		Description:   "Description",
+		Date:          "Date (Gregorian)",
+		EthiopianDate: "Date (Ethiopian)",
+		Footer:        "This receipt was issued by the Ethiopian Payment Gateway.",
+		Switch:        "አማርኛ",
+	},
+}
+
+var receiptPage = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.L.Title}} {{.P.Reference}}</title>
+<style>
+body{font-family:"Noto Sans Ethiopic","Nyala",sans-serif;background:#f4f6f8;margin:0;padding:24px;color:#1f2933}
+.card{max-width:480px;margin:0 auto;background:#fff;border-radius:8px;padding:24px;box-shadow:0 1px 4px rgba(0,0,0,.1)}
+h1{font-size:20px;margin:0 0 16px}
+.amount{font-size:28px;font-weight:bold;margin-bottom:16px}
+table{width:100%;border-collapse:collapse}
+td{padding:8px 0;border-bottom:1px solid #e4e7eb;vertical-align:top}
+td:first-child{color:#616e7c;width:45%}
+.paid{color:#147d64;font-weight:bold}
+footer{margin-top:16px;font-size:12px;color:#7b8794;display:flex;justify-content:space-between}
+</style>
+</head>
+<body>
+<div class="card">
+<h1>{{.L.Title}}</h1>
+<div class="amount">{{.Amount}}</div>
+<table>
+<tr><td>{{.L.Reference}}</td><td>{{.P.Reference}}</td></tr>
+<tr><td>{{.L.Status}}</td><td class="paid">✔ {{.L.Paid}}</td></tr>
+{{if .P.CustomerName}}<tr><td>{{.L.Customer}}</td><td>{{.P.CustomerName}}</td></tr>{{end}}
+{{if .P.BankCode}}<tr><td>{{.L.Bank}}</td><td>{{.P.BankCode}}</td></tr>{{end}}
+{{if .P.Description}}<tr><td>{{.L.Description}}</td><td>{{.P.Description}}</td></tr>{{end}}
+<tr><td>{{.L.EthiopianDate}}</td><td>{{.EthiopianDate}}</td></tr>
+<tr><td>{{.L.Date}}</td><td>{{.Date}}</td></tr>
+</table>
+<footer><span>{{.L.Footer}}</span><a href="?lang={{.OtherLang}}">{{.L.Switch}}</a></footer>
+</div>
+</body>
+</html>`))
+
+func renderReceipt(payment *domain.Payment, language domain.Language) (string, error) {
+	otherLanguage := domain.LanguageEnglish
+	if language == domain.LanguageEnglish {
+		otherLanguage = domain.LanguageAmharic
+	}
+
+	paidAt := etime.In(payment.UpdatedAt)
+
+	data := struct {
+		Lang          domain.Language
+		OtherLang     domain.Language
+		L             receiptLabels
+		P             *domain.Payment
+		Amount        string
+		Date          string
+		EthiopianDate string
+	}{
+		Lang:          language,
+		OtherLang:     otherLanguage,
+		L:             receiptText[language],
+		P:             payment,
+		Amount:        fmt.Sprintf("%s %.2f %s", payment.Currency.GetSymbol(), payment.Amount, payment.Currency),
+		Date:          paidAt.Format("2006-01-02 15:04 MST"),
+		EthiopianDate: receiptEthiopianDate(domain.ToEthiopianDate(paidAt), language),
+	}
+
+	var out bytes.Buffer
+	if err := receiptPage.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// receiptEthiopianDate adds the numeric form accountants reconcile against,
+// e.g. "ታኅሣሥ ፲፭ ቀን ፳፻፲፮ ዓ.ም. (15-04-2016 EC)"
+func receiptEthiopianDate(date domain.EthiopianDate, language domain.Language) string {
+	if language == domain.LanguageEnglish {
+		return date.Format(language) + " (" + date.Numeric() + ")"
+	}
+	return date.FormatGeez() + " (" + date.Numeric() + ")"
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type RefundHandler struct {
+	refundService service.RefundService
+	logger        *logrus.Logger
+}
+
+func NewRefundHandler(refundService service.RefundService, logger *logrus.Logger) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+		logger:        logger,
+	}
+}
+
+// CreateRefund queues a refund of a successful payment
+// @Summary Refund a payment
+// @Description Refunds amount, or whatever has not been refunded yet when amount is omitted. A payment may be refunded several times up to its amount. The refund is processed asynchronously; poll it until SUCCEEDED or FAILED.
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param refund body domain.CreateRefundRequest true "Refund amount and reason"
+// @Success 202 {object} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /payments/{id}/refunds [post]
+func (h *RefundHandler) CreateRefund(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	var req domain.CreateRefundRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	refund, err := h.refundService.CreateRefund(c.Request().Context(), paymentID, req)
+	if err != nil {
+		return h.refundError(c, err, "Failed to create refund")
+	}
+
+	return c.JSON(http.StatusAccepted, refund)
+}
+
+// ListRefunds returns a payment's refunds, oldest first
+// @Summary List payment refunds
+// @Tags refunds
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {array} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/refunds [get]
+func (h *RefundHandler) ListRefunds(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	refunds, err := h.refundService.ListRefunds(c.Request().Context(), paymentID)
+	if err != nil {
+		return h.refundError(c, err, "Failed to list refunds")
+	}
+	if refunds == nil {
+		refunds = []*domain.Refund{}
+	}
+
+	return c.JSON(http.StatusOK, refunds)
+}
+
+// GetRefund returns one refund
+// @Summary Get refund
+// @Tags refunds
+// @Produce json
+// @Param id path string true "Refund ID"
+// @Success 200 {object} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refunds/{id} [get]
+func (h *RefundHandler) GetRefund(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid refund ID format",
+		})
+	}
+
+	refund, err := h.refundService.GetRefund(c.Request().Context(), id)
+	if err != nil {
+		return h.refundError(c, err, "Failed to get refund")
+	}
+
+	return c.JSON(http.StatusOK, refund)
+}
+
+func (h *RefundHandler) refundError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrPaymentNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Payment not found",
+		})
+	case err == domain.ErrRefundNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Refund not found",
+		})
+	case err == domain.ErrPaymentNotRefundable, err == domain.ErrRefundUnsupported, err == domain.ErrRefundExceedsPayment:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
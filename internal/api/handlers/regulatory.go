@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type RegulatoryHandler struct {
+	regulatoryService service.RegulatoryService
+	logger            *logrus.Logger
+}
+
+func NewRegulatoryHandler(regulatoryService service.RegulatoryService, logger *logrus.Logger) *RegulatoryHandler {
+	return &RegulatoryHandler{
+		regulatoryService: regulatoryService,
+		logger:            logger,
+	}
+}
+
+// GenerateReport builds the NBE transaction report for a period
+// @Summary Generate NBE report
+// @Description Aggregates payments per bank, currency and Ethiopian business day over whole days before today and stores the CSV for download. A period may be generated again after corrections; the newest report is current.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security OperatorToken
+// @Param report body domain.GenerateRegulatoryReportRequest true "Period, YYYY-MM-DD (to defaults to from)"
+// @Success 201 {object} domain.RegulatoryReport
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /admin/reports/nbe [post]
+func (h *RegulatoryHandler) GenerateReport(c echo.Context) error {
+	var req domain.GenerateRegulatoryReportRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	report, err := h.regulatoryService.Generate(c.Request().Context(), req, operator)
+	if err != nil {
+		return h.regulatoryError(c, err, "Failed to generate regulatory report")
+	}
+
+	return c.JSON(http.StatusCreated, report)
+}
+
+// ListReports returns generated NBE reports, newest first
+// @Summary List NBE reports
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.RegulatoryReport
+// @Router /admin/reports/nbe [get]
+func (h *RegulatoryHandler) ListReports(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	reports, err := h.regulatoryService.List(c.Request().Context(), page, limit)
+	if err != nil {
+		return h.regulatoryError(c, err, "Failed to list regulatory reports")
+	}
+
+	if reports == nil {
+		reports = []*domain.RegulatoryReport{}
+	}
+
+	return c.JSON(http.StatusOK, reports)
+}
+
+// GetReport returns a generated NBE report's details
+// @Summary Get NBE report
+// @Tags admin
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Report ID"
+// @Success 200 {object} domain.RegulatoryReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/reports/nbe/{id} [get]
+func (h *RegulatoryHandler) GetReport(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid report ID format",
+		})
+	}
+
+	report, err := h.regulatoryService.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.regulatoryError(c, err, "Failed to get regulatory report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// DownloadReport returns a generated NBE report's CSV exactly as generated
+// @Summary Download NBE report
+// @Tags admin
+// @Produce text/csv
+// @Security OperatorToken
+// @Param id path string true "Report ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/reports/nbe/{id}/download [get]
+func (h *RegulatoryHandler) DownloadReport(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid report ID format",
+		})
+	}
+
+	content, name, err := h.regulatoryService.Download(c.Request().Context(), id)
+	if err != nil {
+		return h.regulatoryError(c, err, "Failed to download regulatory report")
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", content)
+}
+
+func (h *RegulatoryHandler) regulatoryError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrRegulatoryReportNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Regulatory report not found",
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
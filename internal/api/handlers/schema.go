@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/schema"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SchemaHandler struct{}
+
+func NewSchemaHandler() *SchemaHandler {
+	return &SchemaHandler{}
+}
+
+// ListSchemas lists the published payload schemas
+// @Summary List payload schemas
+// @Description Versioned JSON Schemas for queue messages and webhook event bodies
+// @Tags schemas
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /schemas [get]
+func (h *SchemaHandler) ListSchemas(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"schemas": schema.List(),
+	})
+}
+
+// GetSchema returns one versioned JSON Schema document
+// @Summary Get payload schema
+// @Tags schemas
+// @Produce json
+// @Param kind path string true "queue or webhook"
+// @Param name path string true "Payload name, e.g. payment.created"
+// @Param version path string true "Version, e.g. v1"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /schemas/{kind}/{name}/{version} [get]
+func (h *SchemaHandler) GetSchema(c echo.Context) error {
+	version, err := strconv.Atoi(strings.TrimPrefix(c.Param("version"), "v"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Schema not found",
+		})
+	}
+
+	baseURL := c.Scheme() + "://" + c.Request().Host
+	doc, ok := schema.Find(baseURL, c.Param("kind"), c.Param("name"), version)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Schema not found",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/schema+json")
+	return c.JSON(http.StatusOK, doc)
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Largest statement accepted; a month of CBE MT940 is well under this
+const maxSettlementFileSize = 20 << 20
+
+type SettlementHandler struct {
+	settlementService     service.SettlementService
+	reconciliationService service.ReconciliationService
+	logger                *logrus.Logger
+}
+
+func NewSettlementHandler(settlementService service.SettlementService, reconciliationService service.ReconciliationService, logger *logrus.Logger) *SettlementHandler {
+	return &SettlementHandler{
+		settlementService:     settlementService,
+		reconciliationService: reconciliationService,
+		logger:                logger,
+	}
+}
+
+// UploadFile accepts a bank statement for reconciliation
+// @Summary Upload settlement file
+// @Description Upload a bank statement (MT940, camt.053 or bank CSV). Lines that cannot be read are skipped and listed in parse_errors.
+// @Tags settlements
+// @Accept multipart/form-data
+// @Produce json
+// @Security OperatorToken
+// @Param file formData file true "Statement file"
+// @Param bank_code formData string true "Bank code (e.g. CBE)"
+// @Param format formData string false "MT940, CAMT053 or CSV; detected when omitted"
+// @Success 201 {object} domain.SettlementFile
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/settlements/files [post]
+func (h *SettlementHandler) UploadFile(c echo.Context) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "file is required",
+		})
+	}
+	if header.Size > maxSettlementFileSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": "Settlement file is too large",
+		})
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxSettlementFileSize))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read uploaded file",
+		})
+	}
+
+	operator, _ := c.Get(OperatorContextKey).(string)
+
+	file, err := h.settlementService.Upload(c.Request().Context(), c.FormValue("bank_code"), c.FormValue("format"), header.Filename, data, operator)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+		case errors.Is(err, domain.ErrSettlementFileInvalid):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error":   "Settlement file could not be parsed",
+				"details": err.Error(),
+			})
+		case err == domain.ErrSettlementFileDuplicate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to upload settlement file")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to upload settlement file",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, file)
+}
+
+// ListFiles returns uploaded settlement files, newest first
+// @Summary List settlement files
+// @Tags settlements
+// @Produce json
+// @Security OperatorToken
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {array} domain.SettlementFile
+// @Router /admin/settlements/files [get]
+func (h *SettlementHandler) ListFiles(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	files, err := h.settlementService.ListFiles(c.Request().Context(), page, limit)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list settlement files")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list settlement files",
+		})
+	}
+
+	if files == nil {
+		files = []*domain.SettlementFile{}
+	}
+
+	return c.JSON(http.StatusOK, files)
+}
+
+// GetFile returns a settlement file with its parse errors
+// @Summary Get settlement file
+// @Tags settlements
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Settlement file ID"
+// @Success 200 {object} domain.SettlementFile
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/settlements/files/{id} [get]
+func (h *SettlementHandler) GetFile(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement file ID format",
+		})
+	}
+
+	file, err := h.settlementService.GetFile(c.Request().Context(), id)
+	if err == domain.ErrSettlementFileNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Settlement file not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get settlement file")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get settlement file",
+		})
+	}
+
+	return c.JSON(http.StatusOK, file)
+}
+
+// ListLines returns the parsed transactions of a settlement file
+// @Summary List settlement file lines
+// @Tags settlements
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Settlement file ID"
+// @Success 200 {array} domain.SettlementLine
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/settlements/files/{id}/lines [get]
+func (h *SettlementHandler) ListLines(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement file ID format",
+		})
+	}
+
+	lines, err := h.settlementService.ListLines(c.Request().Context(), id)
+	if err == domain.ErrSettlementFileNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Settlement file not found",
+		})
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list settlement lines")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list settlement lines",
+		})
+	}
+
+	if lines == nil {
+		lines = []*domain.SettlementLine{}
+	}
+
+	return c.JSON(http.StatusOK, lines)
+}
+
+// Reconcile matches a settlement file's lines to payments again, e.g. after
+// payment statuses were corrected
+// @Summary Reconcile settlement file
+// @Description Matches the statement's credit lines to payments by reference, or by amount and date when a line names no reference, and returns the report. Uploaded files are reconciled by the worker; this re-runs it.
+// @Tags settlements
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Settlement file ID"
+// @Success 200 {object} domain.ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/settlements/files/{id}/reconcile [post]
+func (h *SettlementHandler) Reconcile(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement file ID format",
+		})
+	}
+
+	report, err := h.reconciliationService.Reconcile(c.Request().Context(), id)
+	if err != nil {
+		return h.reconciliationError(c, err, "Failed to reconcile settlement file")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// Reconciliation returns a settlement file's reconciliation report
+// @Summary Settlement reconciliation report
+// @Description Counts of matched and mismatched lines, every line needing a look (amount or status mismatch, duplicate, unmatched), and successful payments at the bank within the statement's value dates that no statement line matched.
+// @Tags settlements
+// @Produce json
+// @Security OperatorToken
+// @Param id path string true "Settlement file ID"
+// @Success 200 {object} domain.ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/settlements/files/{id}/reconciliation [get]
+func (h *SettlementHandler) Reconciliation(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement file ID format",
+		})
+	}
+
+	report, err := h.reconciliationService.Report(c.Request().Context(), id)
+	if err != nil {
+		return h.reconciliationError(c, err, "Failed to get reconciliation report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (h *SettlementHandler) reconciliationError(c echo.Context, err error, message string) error {
+	switch err {
+	case domain.ErrSettlementFileNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Settlement file not found",
+		})
+	case domain.ErrSettlementNotReconciled:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
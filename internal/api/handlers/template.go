@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type TemplateHandler struct {
+	templateService service.TemplateService
+	logger          *logrus.Logger
+}
+
+func NewTemplateHandler(templateService service.TemplateService, logger *logrus.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// ListTemplates lists notification templates
+// @Summary List notification templates
+// @Description List default templates plus overrides for a merchant; merchant keys always see their own
+// @Tags notifications
+// @Produce json
+// @Param merchant_id query string false "Merchant ID for overrides (operators only)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /notifications/templates [get]
+func (h *TemplateHandler) ListTemplates(c echo.Context) error {
+	templates, err := h.templateService.ListTemplates(c.Request().Context(), scopedMerchantID(c, c.QueryParam("merchant_id")))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list templates")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list templates",
+		})
+	}
+
+	if templates == nil {
+		templates = []*domain.NotificationTemplate{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"templates": templates,
+		"total":     len(templates),
+	})
+}
+
+// SaveTemplate creates or replaces a notification template
+// @Summary Save notification template
+// @Description Create or replace the template for an event, channel, language and merchant
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param template body domain.NotificationTemplate true "Template"
+// @Success 200 {object} domain.NotificationTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/templates [put]
+func (h *TemplateHandler) SaveTemplate(c echo.Context) error {
+	var tmpl domain.NotificationTemplate
+	if err := c.Bind(&tmpl); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	tmpl.MerchantID = scopedMerchantID(c, tmpl.MerchantID)
+
+	if err := h.templateService.SaveTemplate(c.Request().Context(), &tmpl); err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error":   "Invalid template",
+				"details": err.Error(),
+			})
+		}
+		h.logger.WithError(err).Error("Failed to save template")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save template",
+		})
+	}
+
+	return c.JSON(http.StatusOK, tmpl)
+}
+
+// DeleteTemplate removes a notification template
+// @Summary Delete notification template
+// @Tags notifications
+// @Param id path string true "Template ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/templates/{id} [delete]
+func (h *TemplateHandler) DeleteTemplate(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid template ID format",
+		})
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Request().Context(), id, callerMerchantID(c)); err != nil {
+		if err == domain.ErrTemplateNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Template not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete template",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// AgentContextKey holds the authenticated agent system's name on agent routes
+const AgentContextKey = "agent"
+
+// NetworkAgentContextKey holds the *domain.Agent when the caller is one of
+// the gateway's own network agents rather than a partner system
+const NetworkAgentContextKey = "network_agent"
+
+type VoucherHandler struct {
+	voucherService service.CashVoucherService
+	agentService   service.AgentService
+	logger         *logrus.Logger
+}
+
+func NewVoucherHandler(voucherService service.CashVoucherService, agentService service.AgentService, logger *logrus.Logger) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+		agentService:   agentService,
+		logger:         logger,
+	}
+}
+
+// IssueVoucher creates the cash voucher for a pay_by_cash payment
+// @Summary Issue cash voucher
+// @Description Issue a voucher code the customer can pay in cash at a partner branch or agent. Issuing again replaces the previous code.
+// @Tags payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 201 {object} domain.CashVoucher
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /payments/{id}/cash-voucher [post]
+func (h *VoucherHandler) IssueVoucher(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid payment ID format",
+		})
+	}
+
+	voucher, err := h.voucherService.IssueVoucher(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrPaymentNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Payment not found",
+			})
+		case domain.ErrVoucherNotAllowed:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error":   err.Error(),
+				"details": "Only payments created with pay_by_cash and not yet paid can get a voucher",
+			})
+		case domain.ErrCashPaymentsDisabled:
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			h.logger.WithError(err).WithField("payment_id", id).Error("Failed to issue cash voucher")
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to issue cash voucher",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, voucher)
+}
+
+// LookupVoucher shows the agent what to collect for a voucher
+// @Summary Look up cash voucher
+// @Tags agents
+// @Produce json
+// @Security AgentToken
+// @Param code path string true "Voucher code"
+// @Success 200 {object} domain.CashVoucher
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /agent/vouchers/{code} [get]
+func (h *VoucherHandler) LookupVoucher(c echo.Context) error {
+	voucher, err := h.voucherService.LookupVoucher(c.Request().Context(), c.Param("code"))
+	if err != nil {
+		return h.voucherError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, voucher)
+}
+
+// ConfirmVoucher records that the agent received the cash and settles the payment
+// @Summary Confirm cash voucher payment
+// @Description Called by the agent system after collecting the cash. The amount must match the voucher exactly. Repeating a confirmation with the same agent_reference returns the original result. Network agents pay from their float and are held to their limits.
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Security AgentToken
+// @Param code path string true "Voucher code"
+// @Param confirmation body domain.ConfirmVoucherRequest true "Cash received"
+// @Success 200 {object} domain.CashVoucher
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /agent/vouchers/{code}/confirm [post]
+func (h *VoucherHandler) ConfirmVoucher(c echo.Context) error {
+	var req domain.ConfirmVoucherRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	var voucher *domain.CashVoucher
+	var err error
+	if networkAgent, ok := c.Get(NetworkAgentContextKey).(*domain.Agent); ok {
+		voucher, err = h.agentService.CashIn(c.Request().Context(), networkAgent, c.Param("code"), req)
+	} else {
+		agent, _ := c.Get(AgentContextKey).(string)
+		voucher, err = h.voucherService.ConfirmVoucher(c.Request().Context(), c.Param("code"), req, agent)
+	}
+	if err != nil {
+		return h.voucherError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, voucher)
+}
+
+func (h *VoucherHandler) voucherError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrVoucherNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Voucher not found",
+		})
+	case err == domain.ErrVoucherNotActive, err == domain.ErrVoucherPaymentClosed:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case err == domain.ErrVoucherExpired:
+		return c.JSON(http.StatusGone, map[string]string{
+			"error": err.Error(),
+		})
+	case err == domain.ErrVoucherAmount,
+		err == domain.ErrAgentTxnLimit,
+		err == domain.ErrAgentDailyLimit,
+		err == domain.ErrAgentFloatInsufficient:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": err.Error(),
+		})
+	case err == domain.ErrAgentSuspended:
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error("Cash voucher request failed")
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Cash voucher request failed",
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	logger         *logrus.Logger
+}
+
+func NewWebhookHandler(webhookService service.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// RegisterWebhook adds a merchant endpoint for payment events
+// @Summary Register a webhook endpoint
+// @Description Payment events are POSTed to url with X-Webhook-ID, X-Webhook-Event, X-Webhook-Timestamp and X-Signature headers. The signature is "sha256=" and the hex HMAC-SHA256 of "timestamp.body" keyed with the secret, which is only returned here; pkg/webhook verifies it. Omit events to receive every event.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body domain.RegisterWebhookRequest true "Endpoint"
+// @Success 201 {object} domain.WebhookEndpoint
+// @Failure 400 {object} map[string]string
+// @Router /webhooks [post]
+func (h *WebhookHandler) RegisterWebhook(c echo.Context) error {
+	var req domain.RegisterWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	endpoint, err := h.webhookService.RegisterEndpoint(c.Request().Context(), req, CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to register webhook")
+	}
+
+	return c.JSON(http.StatusCreated, endpoint)
+}
+
+// ListWebhooks returns the registered endpoints without their secrets
+// @Summary List webhook endpoints
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} domain.WebhookEndpoint
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c echo.Context) error {
+	endpoints, err := h.webhookService.ListEndpoints(c.Request().Context(), CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhooks")
+	}
+	if endpoints == nil {
+		endpoints = []*domain.WebhookEndpoint{}
+	}
+
+	return c.JSON(http.StatusOK, endpoints)
+}
+
+// DeleteWebhook deactivates an endpoint
+// @Summary Delete a webhook endpoint
+// @Description Stops new events to the endpoint. Its delivery history is kept.
+// @Tags webhooks
+// @Param id path string true "Webhook endpoint ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid webhook ID format",
+		})
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request().Context(), id, CallerMerchant(c)); err != nil {
+		return h.webhookError(c, err, "Failed to delete webhook")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries returns an endpoint's latest deliveries, newest first
+// @Summary List webhook deliveries
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid webhook ID format",
+		})
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request().Context(), id, CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhook deliveries")
+	}
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// ListAttempts returns every HTTP attempt of a delivery
+// @Summary List webhook delivery attempts
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Delivery ID"
+// @Success 200 {array} domain.WebhookAttempt
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/deliveries/{id}/attempts [get]
+func (h *WebhookHandler) ListAttempts(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid delivery ID format",
+		})
+	}
+
+	attempts, err := h.webhookService.ListAttempts(c.Request().Context(), id, CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhook attempts")
+	}
+	if attempts == nil {
+		attempts = []*domain.WebhookAttempt{}
+	}
+
+	return c.JSON(http.StatusOK, attempts)
+}
+
+// ListFailedDeliveries returns the dead-letter deliveries
+// @Summary List failed webhook deliveries
+// @Description Deliveries that ran out of attempts, newest first, across all endpoints. Inspect their attempts and replay them once the endpoint is fixed.
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} domain.WebhookDelivery
+// @Router /webhooks/deliveries/failed [get]
+func (h *WebhookHandler) ListFailedDeliveries(c echo.Context) error {
+	deliveries, err := h.webhookService.ListFailed(c.Request().Context(), CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list failed webhook deliveries")
+	}
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery sends a failed delivery again
+// @Summary Replay a failed webhook delivery
+// @Description Sends a FAILED delivery once more with its original payload and X-Webhook-ID, and returns the outcome. It stays FAILED if this attempt fails too.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} domain.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /webhooks/deliveries/{id}/replay [post]
+func (h *WebhookHandler) ReplayDelivery(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid delivery ID format",
+		})
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(c.Request().Context(), id, CallerMerchant(c))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to replay webhook delivery")
+	}
+
+	return c.JSON(http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) webhookError(c echo.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+	case err == domain.ErrWebhookNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Webhook endpoint not found",
+		})
+	case err == domain.ErrWebhookDeliveryNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Webhook delivery not found",
+		})
+	case err == domain.ErrWebhookNotReplayable, err == domain.ErrWebhookEndpointInactive:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.WithError(err).Error(message)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"payment-gateway/internal/api/handlers"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// ProbeServer answers Kubernetes probes for processes that do not serve the
+// API, like the worker
+type ProbeServer struct {
+	e      *echo.Echo
+	addr   string
+	logger *logrus.Logger
+}
+
+func NewProbeServer(addr string, checks map[string]func(ctx context.Context) error, logger *logrus.Logger) *ProbeServer {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	healthHandler := handlers.NewHealthHandler(checks, logger)
+	e.GET("/livez", healthHandler.Livez)
+	e.GET("/readyz", healthHandler.Readyz)
+	e.GET("/health", healthHandler.HealthCheck)
+
+	return &ProbeServer{
+		e:      e,
+		addr:   addr,
+		logger: logger,
+	}
+}
+
+func (s *ProbeServer) Start() error {
+	s.logger.WithField("addr", s.addr).Info("Probe listener started")
+	if err := s.e.Start(s.addr); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *ProbeServer) Shutdown(ctx context.Context) error {
+	return s.e.Shutdown(ctx)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+// Buckets idle this long are dropped; a returning caller starts full
+const rateLimitIdle = 10 * time.Minute
+
+// rateLimiter keeps one token bucket per merchant, or per client IP for
+// calls without an API key. Buckets live in this process, so each API
+// instance enforces the limit on its own. A configuration reload can
+// change the limits or turn them on and off.
+type rateLimiter struct {
+	cfg config.RateLimitConfig
+
+	mu        sync.Mutex
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+type rateBucket struct {
+	limiter    *rate.Limiter
+	merchantID string // Empty for a client IP's bucket
+	seen       time.Time
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{}
+	l.update(cfg)
+	return l
+}
+
+// update applies new limits. Buckets are kept and given the new limits, so
+// callers do not start again with full ones; unchanged limits are left be.
+func (l *rateLimiter) update(cfg config.RateLimitConfig) {
+	if cfg.RPS <= 0 {
+		cfg.RPS = 20
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = 2 * int(math.Ceil(cfg.RPS))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets != nil && reflect.DeepEqual(l.cfg, cfg) {
+		return
+	}
+	l.cfg = cfg
+	if l.buckets == nil {
+		l.buckets = make(map[string]*rateBucket)
+	}
+	now := time.Now()
+	for _, b := range l.buckets {
+		rps, burst := l.limits(b.merchantID)
+		b.limiter.SetLimitAt(now, rate.Limit(rps))
+		b.limiter.SetBurstAt(now, burst)
+	}
+}
+
+// limits are the rate and burst for a merchant's bucket, or a client IP's
+// when merchantID is empty; l.mu must be held
+func (l *rateLimiter) limits(merchantID string) (float64, int) {
+	rps, burst := l.cfg.RPS, l.cfg.Burst
+	if tier, ok := l.cfg.Merchants[merchantID]; ok && merchantID != "" {
+		if tier.RPS > 0 {
+			rps = tier.RPS
+		}
+		if tier.Burst > 0 {
+			burst = tier.Burst
+		}
+	}
+	return rps, burst
+}
+
+func (l *rateLimiter) enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.cfg.Enabled
+}
+
+// reserve takes a token from the caller's bucket, or reports how long until
+// one is available without taking it
+func (l *rateLimiter) reserve(key, merchantID string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > rateLimitIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.seen) > rateLimitIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		rps, burst := l.limits(merchantID)
+		b = &rateBucket{limiter: rate.NewLimiter(rate.Limit(rps), burst), merchantID: merchantID}
+		l.buckets[key] = b
+	}
+	b.seen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// rateLimit answers 429 with Retry-After once the caller's bucket is empty.
+// It runs after API key authentication so keyed calls count against their
+// merchant.
+func rateLimit(l *rateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !l.enabled() {
+				return next(c)
+			}
+
+			key, merchantID := "ip:"+c.RealIP(), ""
+			if id := handlers.CallerMerchant(c); id != nil {
+				merchantID = id.String()
+				key = "merchant:" + merchantID
+			}
+
+			allowed, wait := l.reserve(key, merchantID, time.Now())
+			if !allowed {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"error": "Rate limit exceeded",
+				})
+			}
+			return next(c)
+		}
+	}
+}
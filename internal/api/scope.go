@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ownerLookup finds the merchant that owns the record named by a route's :id
+type ownerLookup func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error)
+
+// merchantScope answers 404 when a merchant caller names another merchant's
+// record, so ids cannot be probed across tenants. Operators and anonymous
+// callers pass through, as do malformed ids and lookup failures: the
+// handler reports those itself.
+func merchantScope(lookup ownerLookup, notFound string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if handlers.CallerMerchant(c) == nil {
+				return next(c)
+			}
+			id, err := uuid.Parse(c.Param("id"))
+			if err != nil {
+				return next(c)
+			}
+			owner, err := lookup(c.Request().Context(), id)
+			if err != nil || handlers.VisibleTo(c, owner) {
+				return next(c)
+			}
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": notFound,
+			})
+		}
+	}
+}
+
+func paymentOwner(payments service.PaymentService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		payment, err := payments.GetPayment(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return payment.MerchantID, nil
+	}
+}
+
+func paymentLinkOwner(links service.PaymentLinkService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		link, err := links.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return link.MerchantID, nil
+	}
+}
+
+func customerOwner(customers service.CustomerService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		customer, err := customers.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return customer.MerchantID, nil
+	}
+}
+
+func invoiceOwner(invoices service.InvoiceService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		invoice, err := invoices.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return invoice.MerchantID, nil
+	}
+}
+
+func refundOwner(refunds service.RefundService, payments service.PaymentService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		refund, err := refunds.GetRefund(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return paymentOwner(payments)(ctx, refund.PaymentID)
+	}
+}
+
+func disputeOwner(disputes service.DisputeService, payments service.PaymentService) ownerLookup {
+	return func(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+		dispute, err := disputes.GetDispute(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return paymentOwner(payments)(ctx, dispute.PaymentID)
+	}
+}
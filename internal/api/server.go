@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"strconv"
-	"time"
 
 	"payment-gateway/internal/api/handlers"
+	"payment-gateway/internal/auth"
 	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/geoip"
 	"payment-gateway/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -20,17 +24,23 @@ type Server struct {
 	cfg    *config.Config
 }
 
-func NewServer(cfg *config.Config, paymentService service.PaymentService, logger *logrus.Logger) *Server {
+func NewServer(cfg *config.Config, paymentService service.PaymentService, notificationService service.NotificationService, templateService service.TemplateService, receiptService service.ReceiptService, accountService service.AccountService, settlementService service.SettlementService, reconciliationService service.ReconciliationService, regulatoryService service.RegulatoryService, fraudService service.FraudService, customerService service.CustomerService, payoutService service.BulkPayoutService, attachmentService service.AttachmentService, noteService service.NoteService, voucherService service.CashVoucherService, agentService service.AgentService, fxService service.FXService, dashboardService service.DashboardService, analyticsService service.AnalyticsService, merchantService service.MerchantService, refundService service.RefundService, disputeService service.DisputeService, webhookService service.WebhookService, apiKeyService service.APIKeyService, auditService service.AuditService, deadLetterService service.DeadLetterService, paymentLinkService service.PaymentLinkService, qrCodeService service.QRCodeService, invoiceService service.InvoiceService, geo geoip.Resolver, healthChecks map[string]func(ctx context.Context) error, reloader *config.Reloader, logger *logrus.Logger) *Server {
 	e := echo.New()
 
 	// Hide banner
 	e.HideBanner = true
 
+	// Client controls, rate limits and the audit log need an IP the caller
+	// cannot spoof, so X-Forwarded-For is only read from trusted proxies
+	e.IPExtractor = ipExtractor(cfg.ClientControls.TrustedProxies)
+
 	// Middleware
 	e.Use(middleware.Recover())
+	e.Use(traceRequests())
+	e.Use(auditActor())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
 	}))
 
 	// Request logging middleware
@@ -40,7 +50,43 @@ func NewServer(cfg *config.Config, paymentService service.PaymentService, logger
 	}))
 
 	// Create handlers
-	paymentHandler := handlers.NewPaymentHandler(paymentService, logger)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, attachmentService, logger)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, cfg.Notifications.SMS.ReportSecret, cfg.Notifications.Telegram.WebhookSecret, logger)
+	templateHandler := handlers.NewTemplateHandler(templateService, logger)
+	schemaHandler := handlers.NewSchemaHandler()
+	accountHandler := handlers.NewAccountHandler(accountService, logger)
+	adminHandler := handlers.NewAdminHandler(paymentService, logger)
+	auditHandler := handlers.NewAuditHandler(auditService, logger)
+	deadLetterHandler := handlers.NewDeadLetterHandler(deadLetterService, logger)
+	settlementHandler := handlers.NewSettlementHandler(settlementService, reconciliationService, logger)
+	regulatoryHandler := handlers.NewRegulatoryHandler(regulatoryService, logger)
+	fraudHandler := handlers.NewFraudHandler(fraudService, logger)
+	customerHandler := handlers.NewCustomerHandler(customerService, paymentService, logger)
+	payoutHandler := handlers.NewPayoutHandler(payoutService, logger)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, logger)
+	noteHandler := handlers.NewNoteHandler(noteService, logger)
+	refundHandler := handlers.NewRefundHandler(refundService, logger)
+	disputeHandler := handlers.NewDisputeHandler(disputeService, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, logger)
+	voucherHandler := handlers.NewVoucherHandler(voucherService, agentService, logger)
+	agentHandler := handlers.NewAgentHandler(agentService, logger)
+	fxHandler := handlers.NewFXHandler(fxService, logger)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardService, logger)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, logger)
+	merchantHandler := handlers.NewMerchantHandler(merchantService, logger)
+	providerHandler := handlers.NewProviderHandler(paymentService, handlers.ProviderSettings{
+		ChapaWebhookSecret: cfg.Providers.Chapa.WebhookSecret,
+		SantimPayPublicKey: cfg.Providers.SantimPay.PublicKey,
+		CardResultURL:      cfg.Providers.EthSwitch.ResultURL,
+	}, logger)
+	receiptHandler := handlers.NewReceiptHandler(receiptService, domain.Language(cfg.Notifications.DefaultLanguage), logger)
+	paymentLinkHandler := handlers.NewPaymentLinkHandler(paymentLinkService, domain.Language(cfg.Notifications.DefaultLanguage), logger)
+	qrCodeHandler := handlers.NewQRCodeHandler(qrCodeService, logger)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, logger)
+	graphQLHandler := handlers.NewGraphQLHandler(paymentService, customerService, logger)
+	healthHandler := handlers.NewHealthHandler(healthChecks, logger)
+	configHandler := handlers.NewConfigHandler(reloader)
 
 	// Routes
 	e.GET("/", func(c echo.Context) error {
@@ -52,56 +98,301 @@ func NewServer(cfg *config.Config, paymentService service.PaymentService, logger
 		})
 	})
 
+	// Kubernetes probes
+	e.GET("/livez", healthHandler.Livez)
+	e.GET("/readyz", healthHandler.Readyz)
+
+	// Public receipt pages (signed links shared with payers)
+	e.GET("/receipts/:token", receiptHandler.ViewReceipt)
+
+	// Hosted checkout pages (payment link short URLs shared with payers)
+	e.GET("/pay/:code", paymentLinkHandler.CheckoutPage)
+	e.POST("/pay/:code", paymentLinkHandler.SubmitCheckout, clientInfo(geo, cfg.ClientControls.CountryHeader))
+
+	// Merchant API key; required once api_keys.required is set
+	merchantAuth := apiKeyAuth(apiKeyService, cfg.APIKeys.Required)
+
+	// Role-bearing JWTs; off while no secret is configured, and reporting
+	// stays on API keys until then
+	var tokens *auth.Tokens
+	reportingAuth := merchantAuth
+	if cfg.JWT.Secret != "" {
+		tokens = auth.NewTokens(cfg.JWT.Secret, cfg.JWT.Issuer, cfg.JWT.TTL)
+		reportingAuth = roleAuth(tokens, apiKeyService, auth.RoleAdmin, auth.RoleMerchant, auth.RoleReadonly)
+	}
+
+	// Per-merchant rate limits, counted once the caller is known. They are
+	// always in the chain, so a reload can turn them on.
+	limiter := newRateLimiter(cfg.RateLimit)
+	reloader.Subscribe(func(cfg *config.Config) { limiter.update(cfg.RateLimit) })
+	limit := rateLimit(limiter)
+	keyAuth, jwtAuth := merchantAuth, reportingAuth
+	merchantAuth = func(next echo.HandlerFunc) echo.HandlerFunc { return keyAuth(limit(next)) }
+	reportingAuth = func(next echo.HandlerFunc) echo.HandlerFunc { return jwtAuth(limit(next)) }
+	authHandler := handlers.NewAuthHandler(tokens, logger)
+
 	// API v1 routes
 	v1 := e.Group("/api/v1")
 	{
 		// Health check
-		v1.GET("/health", paymentHandler.HealthCheck)
+		v1.GET("/health", healthHandler.HealthCheck)
 
 		// Ethiopian banks
 		v1.GET("/banks", paymentHandler.EthiopianBankList)
 
+		// Payment classification code lists
+		v1.GET("/purpose-codes", paymentHandler.PurposeCodeList)
+		v1.GET("/merchant-categories", paymentHandler.MerchantCategoryList)
+
+		// API key scopes
+		createPayments := requireScope(domain.ScopePaymentsCreate)
+		readPayments := requireScope(domain.ScopePaymentsRead)
+		readStatistics := requireScope(domain.ScopeStatisticsRead)
+
 		// Payment routes
-		payments := v1.Group("/payments")
+		payments := v1.Group("/payments", merchantAuth, merchantScope(paymentOwner(paymentService), "Payment not found"))
+		{
+			payments.POST("", paymentHandler.CreatePayment, createPayments, clientInfo(geo, cfg.ClientControls.CountryHeader))
+			payments.GET("", paymentHandler.ListPayments, readPayments)
+			payments.GET("/by-reference", paymentHandler.GetPaymentByReference, readPayments)
+			payments.GET("/search", paymentHandler.SearchPayments, readPayments)
+			payments.GET("/export.xlsx", paymentHandler.ExportPayments, readPayments)
+			payments.GET("/:id", paymentHandler.GetPayment, readPayments)
+			payments.GET("/:id/notifications", notificationHandler.ListPaymentNotifications, readPayments)
+			payments.GET("/:id/qr", qrCodeHandler.GetPaymentQR, readPayments)
+			payments.POST("/:id/confirm-otp", paymentHandler.ConfirmOTP, createPayments)
+			payments.POST("/:id/resend-otp", paymentHandler.ResendOTP, createPayments)
+			payments.POST("/:id/retry", paymentHandler.RetryPayment, createPayments)
+			payments.POST("/:id/cancel", paymentHandler.CancelPayment, createPayments)
+			payments.GET("/:id/attempts", paymentHandler.ListPaymentAttempts, readPayments)
+			payments.PUT("/:id/tags", paymentHandler.SetPaymentTags, createPayments)
+			payments.POST("/:id/refunds", refundHandler.CreateRefund, requireScope(domain.ScopeRefundsCreate))
+			payments.GET("/:id/refunds", refundHandler.ListRefunds, readPayments)
+			payments.GET("/:id/disputes", disputeHandler.ListDisputes, readPayments)
+			payments.POST("/:id/cash-voucher", voucherHandler.IssueVoucher, createPayments)
+			payments.POST("/:id/receipt-link", receiptHandler.CreateReceiptLink, createPayments)
+			payments.DELETE("/:id/receipt-link", receiptHandler.RevokeReceiptLink, createPayments)
+			payments.GET("/:id/receipt.pdf", receiptHandler.DownloadReceiptPDF, readPayments)
+		}
+
+		// Hosted payment links
+		paymentLinks := v1.Group("/payment-links", merchantAuth, merchantScope(paymentLinkOwner(paymentLinkService), "Payment link not found"))
+		{
+			paymentLinks.POST("", paymentLinkHandler.CreatePaymentLink, createPayments)
+			paymentLinks.GET("/:id", paymentLinkHandler.GetPaymentLink, readPayments)
+			paymentLinks.GET("/:id/qr", qrCodeHandler.GetPaymentLinkQR, readPayments)
+			paymentLinks.DELETE("/:id", paymentLinkHandler.DeactivatePaymentLink, createPayments)
+		}
+
+		// KYC customer profiles, named in payments by customer_id
+		customers := v1.Group("/customers", merchantAuth, merchantScope(customerOwner(customerService), "Customer not found"))
+		{
+			customers.POST("", customerHandler.CreateCustomer, createPayments)
+			customers.GET("", customerHandler.ListCustomers, readPayments)
+			customers.GET("/:id", customerHandler.GetCustomer, readPayments)
+			customers.GET("/:id/payments", customerHandler.ListCustomerPayments, readPayments)
+		}
+
+		// Invoices, paid through their payment link or linked payments
+		invoices := v1.Group("/invoices", merchantAuth, merchantScope(invoiceOwner(invoiceService), "Invoice not found"))
 		{
-			payments.POST("", paymentHandler.CreatePayment)
-			payments.GET("", paymentHandler.ListPayments)
-			payments.GET("/by-reference", paymentHandler.GetPaymentByReference)
-			payments.GET("/:id", paymentHandler.GetPayment)
+			invoices.POST("", invoiceHandler.CreateInvoice, createPayments)
+			invoices.GET("/:id", invoiceHandler.GetInvoice, readPayments)
+			invoices.POST("/:id/send", invoiceHandler.SendInvoice, createPayments)
+			invoices.POST("/:id/payments", invoiceHandler.LinkInvoicePayment, createPayments)
+		}
+
+		// GraphQL over the payment, customer and statistics services; fields
+		// check the scopes of their REST endpoints
+		v1.POST("/graphql", graphQLHandler.Query, merchantAuth, clientInfo(geo, cfg.ClientControls.CountryHeader))
+		v1.GET("/graphql/schema", graphQLHandler.Schema)
+
+		v1.GET("/refunds/:id", refundHandler.GetRefund, merchantAuth, readPayments, merchantScope(refundOwner(refundService, paymentService), "Refund not found"))
+
+		// Chargeback disputes; the merchant answers, operators open and resolve
+		disputeScope := merchantScope(disputeOwner(disputeService, paymentService), "Dispute not found")
+		v1.GET("/disputes/:id", disputeHandler.GetDispute, merchantAuth, readPayments, disputeScope)
+		v1.POST("/disputes/:id/evidence", disputeHandler.SubmitEvidence, merchantAuth, unrestrictedKey, disputeScope)
+
+		// Merchant webhook endpoints and their delivery history
+		webhooks := v1.Group("/webhooks", merchantAuth, unrestrictedKey)
+		{
+			webhooks.POST("", webhookHandler.RegisterWebhook)
+			webhooks.GET("", webhookHandler.ListWebhooks)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooks.GET("/deliveries/:id/attempts", webhookHandler.ListAttempts)
+			webhooks.GET("/deliveries/failed", webhookHandler.ListFailedDeliveries)
+			webhooks.POST("/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+		}
+
+		// JWTs for admin and reporting, in exchange for an operator token or API key
+		v1.POST("/auth/token", authHandler.IssueToken, credentialAuth(cfg.Admin.Operators, apiKeyService))
+
+		// A merchant replaces the key it calls with
+		v1.POST("/api-keys/rotate", apiKeyHandler.RotateOwnAPIKey, merchantAuth)
+
+		// Back-office operations (operator token, or JWT with the admin or readonly role)
+		admin := v1.Group("/admin", operatorAuth(cfg.Admin.Operators, tokens))
+		{
+			admin.POST("/payments/:id/status", adminHandler.OverrideStatus)
+			admin.GET("/payments/:id/overrides", adminHandler.ListStatusOverrides)
+			admin.GET("/reviews", adminHandler.ListReviews)
+			admin.GET("/payments/:id/review", adminHandler.GetReview)
+			admin.POST("/payments/:id/review", adminHandler.ReviewPayment)
+			admin.POST("/fraud/rules", fraudHandler.CreateRule)
+			admin.GET("/fraud/rules", fraudHandler.ListRules)
+			admin.GET("/fraud/rules/:id", fraudHandler.GetRule)
+			admin.PUT("/fraud/rules/:id", fraudHandler.UpdateRule)
+			admin.DELETE("/fraud/rules/:id", fraudHandler.DeleteRule)
+			admin.GET("/fraud/hits", fraudHandler.ListHits)
+			admin.GET("/customers", customerHandler.ListCustomers)
+			admin.POST("/customers/:id/verify", customerHandler.VerifyCustomer)
+			admin.POST("/payments/:id/disputes", disputeHandler.OpenDispute)
+			admin.POST("/disputes/:id/resolve", disputeHandler.ResolveDispute)
+			admin.POST("/payments/:id/attachments", attachmentHandler.UploadAttachment)
+			admin.GET("/payments/:id/attachments", attachmentHandler.ListAttachments)
+			admin.POST("/payments/:id/notes", noteHandler.AddNote)
+			admin.GET("/payments/:id/notes", noteHandler.ListNotes)
+			admin.PUT("/payments/:id/notes/:noteId", noteHandler.EditNote)
+			admin.DELETE("/payments/:id/notes/:noteId", noteHandler.DeleteNote)
+			admin.POST("/settlements/files", settlementHandler.UploadFile)
+			admin.GET("/settlements/files", settlementHandler.ListFiles)
+			admin.GET("/settlements/files/:id", settlementHandler.GetFile)
+			admin.GET("/settlements/files/:id/lines", settlementHandler.ListLines)
+			admin.GET("/settlements/files/:id/reconciliation", settlementHandler.Reconciliation)
+			admin.POST("/settlements/files/:id/reconcile", settlementHandler.Reconcile)
+			admin.POST("/reports/nbe", regulatoryHandler.GenerateReport)
+			admin.GET("/reports/nbe", regulatoryHandler.ListReports)
+			admin.GET("/reports/nbe/:id", regulatoryHandler.GetReport)
+			admin.GET("/reports/nbe/:id/download", regulatoryHandler.DownloadReport)
+			admin.POST("/agents", agentHandler.RegisterAgent)
+			admin.GET("/agents", agentHandler.ListAgents)
+			admin.GET("/agents/settlements", agentHandler.ListSettlements)
+			admin.GET("/agents/:id", agentHandler.GetAgent)
+			admin.PATCH("/agents/:id", agentHandler.UpdateAgent)
+			admin.POST("/agents/:id/float", agentHandler.TopUpFloat)
+			admin.GET("/agents/:id/float", agentHandler.ListFloat)
+			admin.POST("/merchants", merchantHandler.RegisterMerchant)
+			admin.GET("/merchants", merchantHandler.ListMerchants)
+			admin.GET("/merchants/:id", merchantHandler.GetMerchant)
+			admin.PATCH("/merchants/:id", merchantHandler.UpdateMerchant)
+			admin.POST("/merchants/:id/accounts", merchantHandler.AddSettlementAccount)
+			admin.PUT("/merchants/:id/accounts/:accountId", merchantHandler.UpdateSettlementAccount)
+			admin.POST("/merchants/:id/accounts/:accountId/verify", merchantHandler.VerifySettlementAccount)
+			admin.POST("/merchants/:id/accounts/:accountId/primary", merchantHandler.SetPrimarySettlementAccount)
+			admin.POST("/merchants/:id/api-keys", apiKeyHandler.IssueAPIKey)
+			admin.GET("/merchants/:id/api-keys", apiKeyHandler.ListAPIKeys)
+			admin.POST("/merchants/:id/api-keys/:keyId/rotate", apiKeyHandler.RotateAPIKey)
+			admin.DELETE("/merchants/:id/api-keys/:keyId", apiKeyHandler.RevokeAPIKey)
+			admin.PUT("/fx/rate", fxHandler.SetRate)
+			admin.GET("/dlq", deadLetterHandler.ListDeadLetters)
+			admin.POST("/dlq/requeue", deadLetterHandler.RequeueDeadLetters)
+			admin.DELETE("/dlq", deadLetterHandler.PurgeDeadLetters)
+			admin.GET("/config", configHandler.Snapshot)
+		}
+
+		// FX rates and quotes into ETB
+		fx := v1.Group("/fx", merchantAuth, unrestrictedKey)
+		{
+			fx.GET("/rate", fxHandler.GetRate)
+			fx.GET("/rates", fxHandler.ListRates)
+			fx.GET("/rates/history", fxHandler.RateHistory)
+			fx.GET("/quote", fxHandler.Convert)
+			fx.POST("/quotes", fxHandler.CreateQuote)
+			fx.GET("/quotes/:id", fxHandler.GetQuote)
+		}
+
+		// Attachment downloads (signed links from payment responses)
+		v1.GET("/attachments/:id", attachmentHandler.DownloadAttachment)
+
+		// Bulk payouts from CSV
+		bulkPayouts := v1.Group("/payouts/bulk", merchantAuth, unrestrictedKey)
+		{
+			bulkPayouts.POST("", payoutHandler.UploadBulk)
+			bulkPayouts.GET("/:id", payoutHandler.GetBulkJob)
+			bulkPayouts.GET("/:id/rows", payoutHandler.ListBulkRows)
+		}
+
+		// Audit log of payment, refund and back-office changes (operator token, or JWT with the admin or readonly role)
+		v1.GET("/audit", auditHandler.ListAuditEvents, operatorAuth(cfg.Admin.Operators, tokens))
+
+		// Partner branch/agent systems and network agents (agent token required)
+		agent := v1.Group("/agent", agentAuth(cfg.CashVouchers.Agents, agentService))
+		{
+			agent.GET("/me", agentHandler.Me)
+			agent.GET("/float", agentHandler.MyFloat)
+			agent.GET("/vouchers/:code", voucherHandler.LookupVoucher)
+			agent.POST("/vouchers/:code/confirm", voucherHandler.ConfirmVoucher)
+		}
+
+		// Destination account name inquiry
+		v1.POST("/accounts/verify", accountHandler.VerifyAccount, merchantAuth, unrestrictedKey)
+
+		// Notification callbacks
+		v1.POST("/notifications/sms/delivery-report", notificationHandler.SMSDeliveryReport)
+		v1.POST("/notifications/telegram/webhook", notificationHandler.TelegramWebhook)
+
+		// Payment provider callbacks; outcomes are verified with the provider
+		v1.POST("/providers/chapa/webhook", providerHandler.ChapaWebhook)
+		v1.POST("/providers/arifpay/webhook", providerHandler.ArifPayWebhook)
+		v1.POST("/providers/santimpay/webhook", providerHandler.SantimPayWebhook)
+		v1.GET("/providers/ethswitch/return", providerHandler.EthSwitchReturn)
+
+		// Telegram chat linking
+		telegram := v1.Group("/notifications/telegram", merchantAuth, unrestrictedKey)
+		{
+			telegram.POST("/links", notificationHandler.CreateTelegramLink)
+			telegram.GET("/chats", notificationHandler.ListTelegramChats)
+			telegram.DELETE("/chats/:id", notificationHandler.UnlinkTelegramChat)
+		}
+
+		// Notification templates (Amharic / English)
+		templates := v1.Group("/notifications/templates", merchantAuth, unrestrictedKey)
+		{
+			templates.GET("", templateHandler.ListTemplates)
+			templates.PUT("", templateHandler.SaveTemplate)
+			templates.DELETE("/:id", templateHandler.DeleteTemplate)
+		}
+
+		// Merchant app devices for FCM pushes
+		push := v1.Group("/notifications/push/devices", merchantAuth, unrestrictedKey)
+		{
+			push.POST("", notificationHandler.RegisterPushDevice)
+			push.GET("", notificationHandler.ListPushDevices)
+			push.DELETE("/:id", notificationHandler.UnregisterPushDevice)
+		}
+
+		// Notification preferences (which events go to which channels)
+		preferences := v1.Group("/notifications/preferences", merchantAuth, unrestrictedKey)
+		{
+			preferences.GET("", notificationHandler.ListPreferences)
+			preferences.PUT("", notificationHandler.SavePreference)
+			preferences.DELETE("/:id", notificationHandler.DeletePreference)
 		}
 
 		// Statistics
-		v1.GET("/statistics", paymentHandler.GetStatistics)
-
-		// Documentation
-		v1.GET("/docs", func(c echo.Context) error {
-			docs := `
-Ethiopian Payment Gateway API Documentation
-
-Available Endpoints:
-GET  /api/v1/health            - Service health check
-GET  /api/v1/banks             - List of Ethiopian banks
-POST /api/v1/payments          - Create new payment
-GET  /api/v1/payments          - List all payments (paginated)
-GET  /api/v1/payments/:id      - Get payment by ID
-GET  /api/v1/payments/by-reference - Get payment by reference
-GET  /api/v1/statistics        - Get payment statistics
-
-Sample Ethiopian Payment Request:
-{
-  "amount": 1500.75,
-  "currency": "ETB",
-  "reference": "CBE-2023-001234",
-  "description": "Payment for Addis Ababa coffee export",
-  "customer_name": "Test Customer",
-  "bank_code": "CBE"
-}
+		v1.GET("/statistics", paymentHandler.GetStatistics, reportingAuth, readStatistics)
 
-Currencies: ETB (Ethiopian Birr) or USD
-Business Hours: 8:00 AM - 5:00 PM Ethiopian Time (GMT+3)
-			`
-			return c.String(http.StatusOK, docs)
-		})
+		// Merchant portal home screen
+		v1.GET("/dashboard", dashboardHandler.GetDashboard, reportingAuth, readStatistics)
+
+		// Merchant analytics over successful payments
+		analytics := v1.Group("/analytics", reportingAuth, readStatistics)
+		{
+			analytics.GET("/top-customers", analyticsHandler.TopCustomers)
+			analytics.GET("/repeat-rate", analyticsHandler.RepeatRate)
+			analytics.GET("/bank-mix", analyticsHandler.BankMix)
+		}
+
+		// JSON Schemas for queue and webhook payloads
+		v1.GET("/schemas", schemaHandler.ListSchemas)
+		v1.GET("/schemas/:kind/:name/:version", schemaHandler.GetSchema)
+
+		// OpenAPI document and Swagger UI, checked against the routes above
+		docsHandler := handlers.NewDocsHandler(e.Routes(), logger)
+		v1.GET("/docs", docsHandler.SwaggerUI)
+		v1.GET("/docs/openapi.json", docsHandler.OpenAPI)
 	}
 
 	return &Server{
@@ -114,15 +405,22 @@ Business Hours: 8:00 AM - 5:00 PM Ethiopian Time (GMT+3)
 func (s *Server) Start() error {
 	addr := ":" + strconv.Itoa(s.cfg.Server.Port)
 
-	// Ethiopian time (GMT+3)
-	ethiopianTime := time.Now().Add(3 * time.Hour)
-
 	s.logger.WithFields(logrus.Fields{
 		"port":           s.cfg.Server.Port,
 		"environment":    s.cfg.App.Environment,
 		"name":           s.cfg.App.Name,
-		"ethiopian_time": ethiopianTime.Format("15:04:05"),
+		"ethiopian_time": etime.Now().Format("15:04:05"),
 	}).Info("Starting Ethiopian Payment Gateway API")
 
-	return s.e.Start(addr)
+	// Shutdown makes Start return http.ErrServerClosed, which is not a failure
+	if err := s.e.Start(addr); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for requests in flight
+// to finish, or until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.e.Shutdown(ctx)
 }
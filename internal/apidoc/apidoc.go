@@ -0,0 +1,72 @@
+// Package apidoc holds the OpenAPI document generated by cmd/apidoc from
+// the handlers' annotations.
+package apidoc
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:generate go run ../../cmd/apidoc -root ../.. -o openapi.json
+
+//go:embed openapi.json
+var generated []byte
+
+// Route is a registered route, with echo's :name path parameters
+type Route struct {
+	Method string
+	Path   string
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Document returns the OpenAPI document for the routes the server
+// registered. Paths are documented relative to the API's base path; an
+// operation served from the root instead, like the hosted checkout page,
+// gets a server of its own. Operations no route serves are left out and
+// returned as unrouted, so annotations that drift from the routing show
+// up at startup.
+func Document(routes []Route) ([]byte, []string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(generated, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	registered := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		registered[r.Method+" "+r.Path] = true
+	}
+
+	basePath := "/"
+	if servers, ok := doc["servers"].([]any); ok && len(servers) > 0 {
+		basePath, _ = servers[0].(map[string]any)["url"].(string)
+	}
+
+	var unrouted []string
+	paths, _ := doc["paths"].(map[string]any)
+	for path, item := range paths {
+		operations, _ := item.(map[string]any)
+		echoPath := pathParam.ReplaceAllString(path, ":$1")
+		for method, op := range operations {
+			m := strings.ToUpper(method)
+			switch {
+			case registered[m+" "+strings.TrimSuffix(basePath, "/")+echoPath]:
+			case registered[m+" "+echoPath]:
+				op.(map[string]any)["servers"] = []any{map[string]any{"url": "/"}}
+			default:
+				delete(operations, method)
+				unrouted = append(unrouted, m+" "+basePath+path)
+			}
+		}
+		if len(operations) == 0 {
+			delete(paths, path)
+		}
+	}
+	sort.Strings(unrouted)
+
+	data, err := json.Marshal(doc)
+	return data, unrouted, err
+}
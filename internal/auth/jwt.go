@@ -0,0 +1,145 @@
+// Package auth issues and verifies the gateway's HS256 JSON Web Tokens.
+// A token names its holder in sub and carries one role; route groups
+// decide which roles they admit.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Role is what a token holder may do
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"    // Back-office operator with full access
+	RoleMerchant Role = "merchant" // A merchant's own reporting
+	RoleReadonly Role = "readonly" // Back-office and reporting reads only
+)
+
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleMerchant || r == RoleReadonly
+}
+
+// Claims are the token fields the gateway uses
+type Claims struct {
+	Subject    string `json:"sub"` // Operator name, or the merchant ID
+	Role       Role   `json:"role"`
+	MerchantID string `json:"merchant_id,omitempty"`
+	KeyID      string `json:"kid,omitempty"` // The API key a merchant token was exchanged for
+	Issuer     string `json:"iss"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// AccessToken is returned by the token endpoint
+type AccessToken struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"` // Always "Bearer"
+	Role      Role      `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// Tokens signs and checks tokens with one shared secret
+type Tokens struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+func NewTokens(secret, issuer string, ttl time.Duration) *Tokens {
+	if issuer == "" {
+		issuer = "payment-gateway"
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	return &Tokens{secret: []byte(secret), issuer: issuer, ttl: ttl}
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Issue signs a token for subject that expires after the configured TTL.
+// Merchant tokens carry the API key they were exchanged for, so revoking
+// the key or suspending the merchant stops the token too.
+func (t *Tokens) Issue(subject string, role Role, merchantID, keyID string) (*AccessToken, error) {
+	now := time.Now().UTC()
+	claims := Claims{
+		Subject:    subject,
+		Role:       role,
+		MerchantID: merchantID,
+		KeyID:      keyID,
+		Issuer:     t.issuer,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(t.ttl).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return &AccessToken{
+		Token:     unsigned + "." + t.sign(unsigned),
+		TokenType: "Bearer",
+		Role:      role,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// Verify checks the signature, issuer, expiry and role of a token
+func (t *Tokens) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if raw, err := base64.RawURLEncoding.DecodeString(parts[0]); err != nil || json.Unmarshal(raw, &header) != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(t.sign(parts[0]+"."+parts[1])), []byte(parts[2])) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(raw, &claims) != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != t.issuer || claims.Subject == "" || !claims.Role.IsValid() {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// LooksLikeToken tells a JWT apart from the gateway's other bearer
+// credentials without verifying it
+func LooksLikeToken(credential string) bool {
+	return strings.HasPrefix(credential, "eyJ") && strings.Count(credential, ".") == 2
+}
+
+func (t *Tokens) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,96 @@
+// Package bank holds clients for the destination banks and wallets the
+// gateway talks to directly.
+package bank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+type NameInquiryConfig struct {
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// NameInquirer resolves the holder name of an account at one bank or wallet
+type NameInquirer interface {
+	LookupAccountName(ctx context.Context, accountNumber string) (string, error)
+}
+
+type httpNameInquirer struct {
+	bankCode string
+	config   NameInquiryConfig
+	client   *http.Client
+	logger   *logrus.Logger
+}
+
+// NewHTTPNameInquirer calls a bank's JSON name-inquiry endpoint:
+// POST {"account_number": ...} -> {"account_name": ...}; 404 means no such account.
+func NewHTTPNameInquirer(bankCode string, config NameInquiryConfig, logger *logrus.Logger) NameInquirer {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &httpNameInquirer{
+		bankCode: bankCode,
+		config:   config,
+		client:   &http.Client{Timeout: timeout},
+		logger:   logger,
+	}
+}
+
+func (n *httpNameInquirer) LookupAccountName(ctx context.Context, accountNumber string) (string, error) {
+	body, err := json.Marshal(map[string]string{"account_number": accountNumber})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.APIKey)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.logger.WithError(err).WithField("bank_code", n.bankCode).Warn("Name inquiry request failed")
+		return "", domain.ErrNameInquiryUnavailable
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", domain.ErrAccountNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		n.logger.WithFields(logrus.Fields{
+			"bank_code": n.bankCode,
+			"status":    resp.StatusCode,
+		}).Warn("Name inquiry rejected by bank")
+		return "", domain.ErrNameInquiryUnavailable
+	}
+
+	var result struct {
+		AccountName string `json:"account_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("%s name inquiry returned invalid body: %w", n.bankCode, err)
+	}
+	if result.AccountName == "" {
+		return "", domain.ErrAccountNotFound
+	}
+
+	return result.AccountName, nil
+}
@@ -0,0 +1,121 @@
+package bank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RateFeedConfig struct {
+	Source  string // Recorded as who set the rates, e.g. "NBE"
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// ReferenceRates is one day's published mid rates into ETB
+type ReferenceRates struct {
+	Source string
+	Date   string // YYYY-MM-DD the rates were published for; empty if the feed does not say
+	Rates  map[domain.Currency]float64
+}
+
+// RateFeed fetches the latest reference exchange rates, such as the National
+// Bank of Ethiopia's daily rates or a commercial bank's rate API
+type RateFeed interface {
+	FetchRates(ctx context.Context) (*ReferenceRates, error)
+}
+
+type httpRateFeed struct {
+	config RateFeedConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// NewHTTPRateFeed reads a JSON rate sheet:
+// GET -> {"date": "YYYY-MM-DD", "rates": [{"currency": "USD", "buying": ..., "selling": ..., "mid": ...}]}.
+// The mid rate is used when given, otherwise the average of buying and
+// selling; currencies with neither are skipped.
+func NewHTTPRateFeed(config RateFeedConfig, logger *logrus.Logger) RateFeed {
+	if config.Source == "" {
+		config.Source = "NBE"
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &httpRateFeed{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+func (f *httpRateFeed) FetchRates(ctx context.Context) (*ReferenceRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.config.APIKey)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s rate feed failed: %w", f.config.Source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s rate feed returned status %d", f.config.Source, resp.StatusCode)
+	}
+
+	var result struct {
+		Date  string `json:"date"`
+		Rates []struct {
+			Currency string  `json:"currency"`
+			Buying   float64 `json:"buying"`
+			Selling  float64 `json:"selling"`
+			Mid      float64 `json:"mid"`
+		} `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%s rate feed returned invalid body: %w", f.config.Source, err)
+	}
+	if result.Date != "" {
+		if _, err := time.Parse("2006-01-02", result.Date); err != nil {
+			return nil, fmt.Errorf("%s rate feed returned invalid date %q", f.config.Source, result.Date)
+		}
+	}
+
+	rates := &ReferenceRates{
+		Source: f.config.Source,
+		Date:   result.Date,
+		Rates:  make(map[domain.Currency]float64, len(result.Rates)),
+	}
+	for _, r := range result.Rates {
+		currency := domain.Currency(strings.ToUpper(strings.TrimSpace(r.Currency)))
+		mid := r.Mid
+		if mid <= 0 && r.Buying > 0 && r.Selling > 0 {
+			mid = (r.Buying + r.Selling) / 2
+		}
+		if !currency.IsForeign() || mid <= 0 {
+			continue
+		}
+		rates.Rates[currency] = mid
+	}
+	if len(rates.Rates) == 0 {
+		return nil, fmt.Errorf("%s rate feed returned no rates", f.config.Source)
+	}
+
+	return rates, nil
+}
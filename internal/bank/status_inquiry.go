@@ -0,0 +1,102 @@
+package bank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+type StatusInquiryConfig struct {
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// StatusInquirer asks a bank what happened to a payment it was sent
+type StatusInquirer interface {
+	// QueryStatus returns SUCCESS, FAILED or PROCESSING (bank still working on it)
+	QueryStatus(ctx context.Context, payment *domain.Payment) (domain.PaymentStatus, string, error)
+}
+
+type httpStatusInquirer struct {
+	bankCode string
+	config   StatusInquiryConfig
+	client   *http.Client
+	logger   *logrus.Logger
+}
+
+// NewHTTPStatusInquirer calls a bank's JSON transaction-status endpoint:
+// POST {"reference": ..., "amount": ..., "currency": ...} ->
+// {"status": "SUCCESS|FAILED|PENDING|NOT_FOUND", "reason": ...}.
+// NOT_FOUND means the bank never received the payment, which is a failure.
+func NewHTTPStatusInquirer(bankCode string, config StatusInquiryConfig, logger *logrus.Logger) StatusInquirer {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &httpStatusInquirer{
+		bankCode: bankCode,
+		config:   config,
+		client:   &http.Client{Timeout: timeout},
+		logger:   logger,
+	}
+}
+
+func (s *httpStatusInquirer) QueryStatus(ctx context.Context, payment *domain.Payment) (domain.PaymentStatus, string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"reference": payment.Reference,
+		"amount":    payment.Amount,
+		"currency":  payment.Currency,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("%s status inquiry failed: %w", s.bankCode, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("%s status inquiry returned status %d", s.bankCode, resp.StatusCode)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", fmt.Errorf("%s status inquiry returned invalid body: %w", s.bankCode, err)
+	}
+
+	switch result.Status {
+	case "SUCCESS":
+		return domain.StatusSuccess, "", nil
+	case "FAILED":
+		return domain.StatusFailed, result.Reason, nil
+	case "NOT_FOUND":
+		return domain.StatusFailed, "not received by bank", nil
+	case "PENDING":
+		return domain.StatusProcessing, "", nil
+	default:
+		return "", "", fmt.Errorf("%s status inquiry returned unknown status %q", s.bankCode, result.Status)
+	}
+}
@@ -0,0 +1,78 @@
+// Package cache holds small in-process caches for hot read paths.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTL is a string-keyed cache whose entries expire after a fixed time. When
+// it grows past maxEntries, expired entries are dropped and, if that is not
+// enough, the cache is emptied.
+type TTL[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	items      map[string]entry[V]
+}
+
+func NewTTL[V any](ttl time.Duration, maxEntries int) *TTL[V] {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &TTL[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      make(map[string]entry[V]),
+	}
+}
+
+func (c *TTL[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *TTL[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.items) >= c.maxEntries {
+		for k, e := range c.items {
+			if !now.Before(e.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		if len(c.items) >= c.maxEntries {
+			c.items = make(map[string]entry[V])
+		}
+	}
+
+	c.items[key] = entry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *TTL[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+func (c *TTL[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]entry[V])
+}
@@ -4,19 +4,56 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	App       AppConfig       `yaml:"app"`
-	Server    ServerConfig    `yaml:"server"`
-	Database  DatabaseConfig  `yaml:"database"`
-	RabbitMQ  RabbitMQConfig  `yaml:"rabbitmq"`
-	Worker    WorkerConfig    `yaml:"worker"`
-	Ethiopian EthiopianConfig `yaml:"ethiopian"`
-	Logging   LoggingConfig   `yaml:"logging"`
+	App               AppConfig               `yaml:"app"`
+	Server            ServerConfig            `yaml:"server"`
+	Database          DatabaseConfig          `yaml:"database"`
+	Messaging         MessagingConfig         `yaml:"messaging"`
+	RabbitMQ          RabbitMQConfig          `yaml:"rabbitmq"`
+	Worker            WorkerConfig            `yaml:"worker"`
+	Ethiopian         EthiopianConfig         `yaml:"ethiopian"`
+	Notifications     NotificationsConfig     `yaml:"notifications"`
+	OTP               OTPConfig               `yaml:"otp"`
+	Reminders         RemindersConfig         `yaml:"reminders"`
+	Receipts          ReceiptsConfig          `yaml:"receipts"`
+	PaymentLinks      PaymentLinksConfig      `yaml:"payment_links"`
+	QRCodes           QRCodesConfig           `yaml:"qr_codes"`
+	NameInquiry       NameInquiryConfig       `yaml:"name_inquiry"`
+	Admin             AdminConfig             `yaml:"admin"`
+	APIKeys           APIKeysConfig           `yaml:"api_keys"`
+	RateLimit         RateLimitConfig         `yaml:"rate_limit"`
+	JWT               JWTConfig               `yaml:"jwt"`
+	StatusRequery     StatusRequeryConfig     `yaml:"status_requery"`
+	PaymentExpiry     PaymentExpiryConfig     `yaml:"payment_expiry"`
+	Reconciliation    ReconciliationConfig    `yaml:"reconciliation"`
+	RegulatoryReports RegulatoryReportsConfig `yaml:"regulatory_reports"`
+	BulkPayouts       BulkPayoutsConfig       `yaml:"bulk_payouts"`
+	Attachments       AttachmentsConfig       `yaml:"attachments"`
+	CashVouchers      CashVouchersConfig      `yaml:"cash_vouchers"`
+	AgentNetwork      AgentNetworkConfig      `yaml:"agent_network"`
+	POS               POSConfig               `yaml:"pos"`
+	GRPC              GRPCConfig              `yaml:"grpc"`
+	CustomerLimits    CustomerLimitsConfig    `yaml:"customer_limits"`
+	VelocityLimits    VelocityLimitsConfig    `yaml:"velocity_limits"`
+	KYC               KYCConfig               `yaml:"kyc"`
+	ClientControls    ClientControlsConfig    `yaml:"client_controls"`
+	AML               AMLConfig               `yaml:"aml"`
+	Fraud             FraudConfig             `yaml:"fraud"`
+	FX                FXConfig                `yaml:"fx"`
+	Cache             CacheConfig             `yaml:"cache"`
+	Sagas             SagasConfig             `yaml:"sagas"`
+	Webhooks          WebhooksConfig          `yaml:"webhooks"`
+	Outbox            OutboxConfig            `yaml:"outbox"`
+	Providers         ProvidersConfig         `yaml:"providers"`
+	Tracing           TracingConfig           `yaml:"tracing"`
+	Logging           LoggingConfig           `yaml:"logging"`
 }
 
 type AppConfig struct {
@@ -42,20 +79,64 @@ type DatabaseConfig struct {
 	MaxConnections        int           `yaml:"max_connections"`
 	MaxIdleConnections    int           `yaml:"max_idle_connections"`
 	ConnectionMaxLifetime time.Duration `yaml:"connection_max_lifetime"`
+	// Read replicas, as postgres:// DSNs. The API reads payment listings,
+	// searches and statistics from them; everything else, lookups of a
+	// single payment included, uses the primary.
+	ReadDSNs []string `yaml:"read_dsns"`
+}
+
+// Which broker carries queue messages between the API, outbox relay and
+// worker
+type MessagingConfig struct {
+	Broker string      `yaml:"broker"` // rabbitmq (default) or kafka
+	Kafka  KafkaConfig `yaml:"kafka"`
+}
+
+// Kafka is reached through a Confluent REST Proxy (v2 API). Each message
+// type goes to its own topic; the workers share one consumer group.
+type KafkaConfig struct {
+	RESTProxyURL  string        `yaml:"rest_proxy_url"`
+	TopicPrefix   string        `yaml:"topic_prefix"` // Topics are <prefix>.<message type>
+	ConsumerGroup string        `yaml:"consumer_group"`
+	PollTimeout   time.Duration `yaml:"poll_timeout"`
 }
 
 type RabbitMQConfig struct {
-	URL           string `yaml:"url"`
-	QueueName     string `yaml:"queue_name"`
-	Exchange      string `yaml:"exchange"`
-	ConsumerTag   string `yaml:"consumer_tag"`
-	PrefetchCount int    `yaml:"prefetch_count"`
+	URL           string           `yaml:"url"`
+	QueueName     string           `yaml:"queue_name"`
+	Exchange      string           `yaml:"exchange"`
+	ConsumerTag   string           `yaml:"consumer_tag"`
+	PrefetchCount int              `yaml:"prefetch_count"`
+	ClaimCheck    ClaimCheckConfig `yaml:"claim_check"`
+}
+
+// Large message bodies (bulk payout results, attachment events) kept in
+// object storage with only their key queued. While disabled every body is
+// queued inline.
+type ClaimCheckConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold int           `yaml:"threshold"` // Bytes; larger bodies are stored
+	Storage   StorageConfig `yaml:"storage"`
 }
 
 type WorkerConfig struct {
-	Concurrency int           `yaml:"concurrency"`
-	MaxRetries  int           `yaml:"max_retries"`
-	RetryDelay  time.Duration `yaml:"retry_delay"`
+	Concurrency int `yaml:"concurrency"`
+	// A message that fails is retried after each delay in turn, then goes
+	// to the dead letter queue
+	RetryTiers []time.Duration `yaml:"retry_tiers"`
+	// Serves /livez, /readyz and /health for Kubernetes when set, e.g. ":8081"
+	ProbeAddr string `yaml:"probe_addr"`
+	// Provider calls that fail in a passing way are retried within the
+	// message's processing, before it falls back on RetryTiers
+	ProviderRetry ProviderRetryConfig `yaml:"provider_retry"`
+}
+
+// Exponential backoff with jitter between attempts at a provider call
+type ProviderRetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"` // Including the first; 1 turns retries off
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Multiplier     float64       `yaml:"multiplier"`
 }
 
 // Ethiopian-specific configuration
@@ -67,28 +148,537 @@ type EthiopianConfig struct {
 	MaxETBAmount       float64  `yaml:"max_etb_amount"` // Ethiopian regulatory limit
 }
 
+type NotificationsConfig struct {
+	DefaultLanguage string         `yaml:"default_language"` // am or en
+	SMS             SMSConfig      `yaml:"sms"`
+	Email           EmailConfig    `yaml:"email"`
+	Telegram        TelegramConfig `yaml:"telegram"`
+	Push            PushConfig     `yaml:"push"`
+}
+
+// Ethio Telecom SMS gateway configuration
+type SMSConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	GatewayURL      string        `yaml:"gateway_url"`
+	Username        string        `yaml:"username"`
+	Password        string        `yaml:"password"`
+	SenderID        string        `yaml:"sender_id"`
+	Timeout         time.Duration `yaml:"timeout"`
+	NotifyOnSuccess bool          `yaml:"notify_on_success"`
+	NotifyOnFailure bool          `yaml:"notify_on_failure"`
+	ReportSecret    string        `yaml:"report_secret"` // Sent by the gateway with delivery reports
+}
+
+// SMTP email configuration for receipts and merchant alerts
+type EmailConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	SMTPHost         string        `yaml:"smtp_host"`
+	SMTPPort         int           `yaml:"smtp_port"`
+	Username         string        `yaml:"username"`
+	Password         string        `yaml:"password"`
+	Timeout          time.Duration `yaml:"timeout"`
+	FromName         string        `yaml:"from_name"`
+	FromAddress      string        `yaml:"from_address"`
+	SendReceipts     bool          `yaml:"send_receipts"`
+	RefundReceipts   bool          `yaml:"refund_receipts"`
+	MerchantReceipts bool          `yaml:"merchant_receipts"`
+	FailureAlerts    bool          `yaml:"failure_alerts"`
+	DailySummary     bool          `yaml:"daily_summary"`
+	DailySummaryTime string        `yaml:"daily_summary_time"` // UTC, HH:MM
+	MerchantEmails   []string      `yaml:"merchant_emails"`
+}
+
+// Telegram bot used for merchant notifications
+type TelegramConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	BotToken      string        `yaml:"bot_token"`
+	BotUsername   string        `yaml:"bot_username"`
+	WebhookSecret string        `yaml:"webhook_secret"`
+	Timeout       time.Duration `yaml:"timeout"`
+}
+
+// Customer OTP confirmation for payments created with require_otp
+type OTPConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	CodeLength  int           `yaml:"code_length"`
+	TTL         time.Duration `yaml:"ttl"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	MaxSends    int           `yaml:"max_sends"` // Initial send plus resends
+}
+
+// Reminders for payments the customer has not completed
+type RemindersConfig struct {
+	Enabled      bool            `yaml:"enabled"`
+	Schedule     []time.Duration `yaml:"schedule"` // Offsets after creation, e.g. [1h, 24h]
+	PollInterval time.Duration   `yaml:"poll_interval"`
+}
+
+// Public receipt links shared with payers
+type ReceiptsConfig struct {
+	BaseURL       string `yaml:"base_url"`
+	SigningSecret string `yaml:"signing_secret"` // Links are disabled while empty
+	PDFFont       string `yaml:"pdf_font"`       // TrueType font with Ge'ez script, e.g. NotoSansEthiopic-Regular.ttf; PDF receipts are disabled while empty
+}
+
+type PaymentLinksConfig struct {
+	BaseURL string `yaml:"base_url"` // Defaults to receipts.base_url
+}
+
+// EMVCo merchant-presented QR codes scanned by Ethiopian bank apps
+type QRCodesConfig struct {
+	GUID         string `yaml:"guid"`          // Scheme identifier, as assigned by EthSwitch
+	MerchantID   string `yaml:"merchant_id"`   // QR codes are disabled while empty
+	MerchantName string `yaml:"merchant_name"` // At most 25 characters
+	MerchantCity string `yaml:"merchant_city"` // At most 15 characters
+	MCC          string `yaml:"mcc"`           // Used when the payment has none
+}
+
+// Firebase Cloud Messaging pushes to merchant apps
+type PushConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	ProjectID       string        `yaml:"project_id"`
+	CredentialsFile string        `yaml:"credentials_file"` // Service account JSON
+	Timeout         time.Duration `yaml:"timeout"`
+}
+
+// Re-query job for payments stuck in PROCESSING
+type PaymentExpiryConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	TTL          time.Duration `yaml:"ttl"` // Time in PENDING before a payment is expired
+	BatchSize    int           `yaml:"batch_size"`
+}
+
+type StatusRequeryConfig struct {
+	Enabled      bool                          `yaml:"enabled"`
+	PollInterval time.Duration                 `yaml:"poll_interval"`
+	StaleAfter   time.Duration                 `yaml:"stale_after"` // Time in PROCESSING before asking the bank
+	BatchSize    int                           `yaml:"batch_size"`
+	Timeout      time.Duration                 `yaml:"timeout"`
+	Banks        map[string]BankEndpointConfig `yaml:"banks"`
+}
+
+// Matching of uploaded bank statements to payments by the worker
+type ReconciliationConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BookingDays  int           `yaml:"booking_days"` // Days a bank may take to book a payment
+	BatchSize    int           `yaml:"batch_size"`   // Files per poll
+}
+
+// NBE transaction reports; the worker generates the previous business
+// day's report once a day when Scheduled
+type RegulatoryReportsConfig struct {
+	InstitutionCode string `yaml:"institution_code"` // Licence number NBE issued the operator
+	Scheduled       bool   `yaml:"scheduled"`
+	RunTime         string `yaml:"run_time"` // UTC, HH:MM
+}
+
+// CSV bulk payouts processed by the worker
+type BulkPayoutsConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	Lease          time.Duration `yaml:"lease"` // A crashed worker's job is resumed after this
+	MaxRows        int           `yaml:"max_rows"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+}
+
+// Proof-of-payment attachments in object storage
+type AttachmentsConfig struct {
+	BaseURL       string        `yaml:"base_url"`
+	SigningSecret string        `yaml:"signing_secret"` // Attachments are disabled while empty
+	LinkTTL       time.Duration `yaml:"link_ttl"`
+	MaxSize       int64         `yaml:"max_size"` // Bytes
+	AllowedTypes  []string      `yaml:"allowed_types"`
+	Storage       StorageConfig `yaml:"storage"`
+}
+
+type StorageConfig struct {
+	Driver   string   `yaml:"driver"` // "local" or "s3"
+	LocalDir string   `yaml:"local_dir"`
+	S3       S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint     string `yaml:"endpoint"`
+	Region       string `yaml:"region"`
+	Bucket       string `yaml:"bucket"`
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	UsePathStyle bool   `yaml:"use_path_style"`
+}
+
+// Cash payments at partner branches/agents; each agent system gets its own
+// token for the /api/v1/agent endpoints
+type CashVouchersConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+	Agents  []AgentConfig `yaml:"agents"`
+}
+
+type AgentConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+}
+
+// The gateway's own agents (registered via /api/v1/admin/agents). The worker
+// settles each Ethiopian business day and credits commission to agent floats.
+type AgentNetworkConfig struct {
+	CommissionRate    float64 `yaml:"commission_rate"` // e.g. 0.005 for 0.5% of cash-in
+	SettlementRun     bool    `yaml:"settlement_run"`
+	SettlementRunTime string  `yaml:"settlement_run_time"` // UTC, HH:MM
+}
+
+// Cumulative per-customer limits (by phone and national ID), in ETB per
+// Ethiopian day and month; 0 disables a limit
+type CustomerLimitsConfig struct {
+	Enabled  bool            `yaml:"enabled"`
+	Action   string          `yaml:"action"`   // reject or flag
+	Basic    LimitTierConfig `yaml:"basic"`    // Phone only
+	Verified LimitTierConfig `yaml:"verified"` // National ID on the payment
+}
+
+type LimitTierConfig struct {
+	Daily   float64 `yaml:"daily"`
+	Monthly float64 `yaml:"monthly"`
+}
+
+// Sliding-window caps on each customer's payments, overall and per bank
+// code. Payments over a limit are rejected with VELOCITY_LIMIT_EXCEEDED.
+type VelocityLimitsConfig struct {
+	Enabled  bool                             `yaml:"enabled"`
+	Customer []VelocityLimitConfig            `yaml:"customer"`
+	Banks    map[string][]VelocityLimitConfig `yaml:"banks"`
+}
+
+type VelocityLimitConfig struct {
+	Window      time.Duration `yaml:"window"`
+	MaxPayments int           `yaml:"max_payments"` // 0 = uncapped
+	MaxAmount   float64       `yaml:"max_amount"`   // ETB; 0 = uncapped
+}
+
+// KYC enforcement: payments above the amount must name a VERIFIED customer
+// registered via /api/v1/customers
+type KYCConfig struct {
+	RequireVerifiedAbove float64 `yaml:"require_verified_above"` // ETB value; 0 = customer_id optional
+}
+
+// Geo-IP and per-IP velocity screening of payment creation. Actions are
+// "block" or "challenge" (hold the payment for the customer's OTP).
+type ClientControlsConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	TrustedProxies   []string      `yaml:"trusted_proxies"` // IPs/CIDRs whose X-Forwarded-For is believed, even when disabled
+	GeoIPFile        string        `yaml:"geoip_file"`      // CSV: start_ip,end_ip,country
+	CountryHeader    string        `yaml:"country_header"`  // e.g. CF-IPCountry behind Cloudflare
+	AllowedCountries []string      `yaml:"allowed_countries"`
+	CountryAction    string        `yaml:"country_action"`
+	BlockedIPs       []string      `yaml:"blocked_ips"` // IPs or CIDRs
+	VelocityWindow   time.Duration `yaml:"velocity_window"`
+	VelocityMax      int           `yaml:"velocity_max"`
+	VelocityAction   string        `yaml:"velocity_action"`
+}
+
+// AML / sanctions screening of customer names at payment creation. A name
+// on a watch list holds the payment UNDER_REVIEW for a compliance decision.
+type AMLConfig struct {
+	Enabled   bool         `yaml:"enabled"`
+	ListFiles []string     `yaml:"list_files"` // One name, or "list,name", per line
+	MinScore  float64      `yaml:"min_score"`  // 0-1; share of the longer name's words that must match
+	API       AMLAPIConfig `yaml:"api"`
+}
+
+// External screening service; off when url is empty
+type AMLAPIConfig struct {
+	Name     string        `yaml:"name"`
+	URL      string        `yaml:"url"`
+	APIKey   string        `yaml:"api_key"`
+	Timeout  time.Duration `yaml:"timeout"`
+	MinScore float64       `yaml:"min_score"`
+}
+
+// Fraud rules engine; rules themselves are managed under /admin/fraud/rules
+type FraudConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// FX quotes into ETB; daily rates are set by treasury and fall back to
+// fallback_rates (USD to ethiopian.usd_to_etb)
+type FXConfig struct {
+	QuoteTTL      time.Duration      `yaml:"quote_ttl"`
+	SpreadBps     int                `yaml:"spread_bps"`
+	FallbackRates map[string]float64 `yaml:"fallback_rates"` // Currency code to ETB per unit
+	Currencies    []string           `yaml:"currencies"`     // Foreign currencies payments may be made in; empty accepts all
+	Feed          FXFeedConfig       `yaml:"feed"`
+}
+
+// Reference rate feed polled by the worker, e.g. the National Bank of
+// Ethiopia's daily rates; each new day's rates are added to the history
+type FXFeedConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Source       string        `yaml:"source"` // Recorded as set_by on the rates
+	URL          string        `yaml:"url"`
+	APIKey       string        `yaml:"api_key"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// In-memory cache for payment lookups and statistics polled by clients
+type CacheConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	PaymentTTL    time.Duration `yaml:"payment_ttl"`    // Payments in SUCCESS or FAILED
+	StatisticsTTL time.Duration `yaml:"statistics_ttl"` // Statistics queries
+	MaxEntries    int           `yaml:"max_entries"`
+}
+
+// Saga runner for multi-step operations; the worker resumes due runs
+type SagasConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	MaxAttempts  int           `yaml:"max_attempts"` // Per step, before compensating
+	Backoff      time.Duration `yaml:"backoff"`      // Multiplied by the attempt number
+	Lease        time.Duration `yaml:"lease"`
+	BatchSize    int           `yaml:"batch_size"`
+}
+
+// Merchant webhook delivery; the worker sends queued events
+type WebhooksConfig struct {
+	PollInterval time.Duration   `yaml:"poll_interval"`
+	MaxAttempts  int             `yaml:"max_attempts"` // Before a delivery is marked FAILED
+	Schedule     []time.Duration `yaml:"schedule"`     // Wait before each retry; the last one repeats
+	Timeout      time.Duration   `yaml:"timeout"`
+	Lease        time.Duration   `yaml:"lease"`
+	BatchSize    int             `yaml:"batch_size"`
+}
+
+// Transactional outbox. When enabled the API writes queue messages to the
+// outbox table and cmd/outbox-relay publishes them to RabbitMQ.
+type OutboxConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	Lease        time.Duration `yaml:"lease"`         // A claim left longer by a crashed relay is taken over
+	RetryBackoff time.Duration `yaml:"retry_backoff"` // Wait after a failed publish, multiplied by the attempt number
+}
+
+// Payment providers that take over from the simulator for some bank codes
+type ProvidersConfig struct {
+	Chapa     ChapaConfig     `yaml:"chapa"`
+	Amole     AmoleConfig     `yaml:"amole"`
+	ArifPay   ArifPayConfig   `yaml:"arifpay"`
+	SantimPay SantimPayConfig `yaml:"santimpay"`
+	EthSwitch EthSwitchConfig `yaml:"ethswitch"`
+
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+}
+
+// Per-bank circuit breakers around provider calls: after FailureThreshold
+// consecutive failures a bank's calls fail at once for OpenTimeout, and its
+// payments wait in the retry queues instead of on its timeouts
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // 0 turns the breakers off
+	OpenTimeout      time.Duration `yaml:"open_timeout"`
+}
+
+// Chapa hosted checkout, for payments with bank_code CHAPA
+type ChapaConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	BaseURL       string        `yaml:"base_url"`
+	SecretKey     string        `yaml:"secret_key"`
+	WebhookSecret string        `yaml:"webhook_secret"` // Secret hash set in the Chapa dashboard
+	CallbackURL   string        `yaml:"callback_url"`   // Public URL of /api/v1/providers/chapa/webhook
+	ReturnURL     string        `yaml:"return_url"`     // Where customers land after paying
+	Timeout       time.Duration `yaml:"timeout"`
+}
+
+// Dashen Bank's Amole wallet, for payments with bank_code DASHEN
+type AmoleConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	URL        string        `yaml:"url"`
+	MerchantID string        `yaml:"merchant_id"`
+	Username   string        `yaml:"username"`
+	Password   string        `yaml:"password"`
+	Signature  string        `yaml:"signature"`  // API key issued by Amole
+	IPAddress  string        `yaml:"ip_address"` // Server IP registered with Amole
+	OTPTTL     time.Duration `yaml:"otp_ttl"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+// ArifPay checkout sessions, for payments with payment_method ARIFPAY
+type ArifPayConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	BaseURL            string        `yaml:"base_url"`
+	APIKey             string        `yaml:"api_key"`
+	NotifyURL          string        `yaml:"notify_url"` // Public URL of /api/v1/providers/arifpay/webhook
+	SuccessURL         string        `yaml:"success_url"`
+	CancelURL          string        `yaml:"cancel_url"`
+	ErrorURL           string        `yaml:"error_url"`
+	BeneficiaryAccount string        `yaml:"beneficiary_account"` // Settlement account ArifPay pays out to
+	BeneficiaryBank    string        `yaml:"beneficiary_bank"`
+	PaymentMethods     []string      `yaml:"payment_methods"`
+	SessionTTL         time.Duration `yaml:"session_ttl"`
+	Timeout            time.Duration `yaml:"timeout"`
+}
+
+// SantimPay hosted checkout, for payments with payment_method SANTIMPAY
+type SantimPayConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	BaseURL    string        `yaml:"base_url"` // Testnet or production gateway
+	MerchantID string        `yaml:"merchant_id"`
+	PrivateKey string        `yaml:"private_key"` // PEM EC key that signs our requests
+	PublicKey  string        `yaml:"public_key"`  // SantimPay's PEM key that signs callbacks
+	NotifyURL  string        `yaml:"notify_url"`  // Public URL of /api/v1/providers/santimpay/webhook
+	SuccessURL string        `yaml:"success_url"`
+	FailureURL string        `yaml:"failure_url"`
+	CancelURL  string        `yaml:"cancel_url"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+// EthSwitch e-commerce gateway, for payments with payment_method CARD
+type EthSwitchConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	BaseURL        string        `yaml:"base_url"`
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	ReturnURL      string        `yaml:"return_url"` // Public URL of /api/v1/providers/ethswitch/return
+	ResultURL      string        `yaml:"result_url"` // Merchant page customers are sent to afterwards
+	SessionTimeout time.Duration `yaml:"session_timeout"`
+	Timeout        time.Duration `yaml:"timeout"`
+}
+
+// ISO 8583 listener for POS terminals; only registered terminals are served
+type POSConfig struct {
+	Enabled     bool                `yaml:"enabled"`
+	ListenAddr  string              `yaml:"listen_addr"`
+	IdleTimeout time.Duration       `yaml:"idle_timeout"`
+	Terminals   []POSTerminalConfig `yaml:"terminals"`
+}
+
+// gRPC payment service for internal Go services; see proto/payment/v1
+type GRPCConfig struct {
+	Enabled    bool               `yaml:"enabled"`
+	ListenAddr string             `yaml:"listen_addr"`
+	Clients    []GRPCClientConfig `yaml:"clients"`
+}
+
+// GRPCClientConfig is an internal service allowed to call the gRPC API;
+// it sends the token as "authorization: Bearer <token>" metadata
+type GRPCClientConfig struct {
+	Name  string `yaml:"name"` // Logged with each call
+	Token string `yaml:"token"`
+}
+
+type POSTerminalConfig struct {
+	TerminalID string `yaml:"terminal_id"` // ISO 8583 field 41
+	MerchantID string `yaml:"merchant_id"` // ISO 8583 field 42
+	MACKey     string `yaml:"mac_key"`     // Hex HMAC-SHA256 key for field 64/128
+}
+
+// Back-office operators; each token identifies one operator in audit logs.
+// Admin endpoints are disabled while the list is empty.
+type AdminConfig struct {
+	Operators []OperatorConfig `yaml:"operators"`
+}
+
+// Merchant API keys. Merchant endpoints accept requests without a key
+// until Required is set, so existing integrations can move over first.
+type APIKeysConfig struct {
+	Required      bool          `yaml:"required"`
+	RotationGrace time.Duration `yaml:"rotation_grace"` // How long a rotated key keeps working
+}
+
+// Token-bucket limits on the merchant API. Each merchant has one bucket
+// shared by its keys; calls without a key are limited per client IP.
+type RateLimitConfig struct {
+	Enabled   bool                     `yaml:"enabled" json:"enabled"`
+	RPS       float64                  `yaml:"rps" json:"rps"`             // Sustained requests per second
+	Burst     int                      `yaml:"burst" json:"burst"`         // Requests allowed at once
+	Merchants map[string]RateLimitTier `yaml:"merchants" json:"merchants"` // Merchant ID to its own limits
+}
+
+type RateLimitTier struct {
+	RPS   float64 `yaml:"rps" json:"rps"`
+	Burst int     `yaml:"burst" json:"burst"`
+}
+
+type OperatorConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"` // admin (default) or readonly
+}
+
+// HS256 tokens for the admin and reporting endpoints, exchanged for an
+// operator token or API key at /auth/token. JWT auth is off while Secret
+// is empty.
+type JWTConfig struct {
+	Secret string        `yaml:"secret"`
+	Issuer string        `yaml:"issuer"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+// Destination account name inquiry, per bank/wallet code
+type NameInquiryConfig struct {
+	Timeout time.Duration                 `yaml:"timeout"`
+	Banks   map[string]BankEndpointConfig `yaml:"banks"`
+}
+
+// A bank-side API endpoint, keyed by bank/wallet code where used
+type BankEndpointConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+}
+
+// OpenTelemetry traces exported over OTLP/HTTP. W3C trace context is
+// propagated from HTTP requests into RabbitMQ messages either way.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Endpoint    string  `yaml:"endpoint"` // Collector host:port; empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"` // Share of new traces kept, 0-1
+}
+
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
+	Level  string `yaml:"level"` // debug, info, warn or error; applied again on reload
 	Format string `yaml:"format"`
 	Output string `yaml:"output"`
 }
 
+// LogrusLevel is Level for the logger; Validate has checked it parses
+func (c LoggingConfig) LogrusLevel() logrus.Level {
+	level, err := logrus.ParseLevel(c.Level)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+	return level
+}
+
+// The configuration file, read from the working directory
+const configFile = "config.yaml"
+
 // Load configuration from YAML and environment variables
 func Load() (*Config, error) {
 	// Try to load from YAML first
 	cfg := &Config{}
 
-	// Read config file
-	data, err := os.ReadFile("config.yaml")
-	if err == nil {
+	// Read config file; without one, everything comes from the environment
+	data, err := os.ReadFile(configFile)
+	switch {
+	case err == nil:
 		if err := yaml.Unmarshal(data, cfg); err != nil {
 			return nil, fmt.Errorf("failed to parse config.yaml: %w", err)
 		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read config.yaml: %w", err)
 	}
 
 	// Override with environment variables
 	overrideFromEnv(cfg)
 
+	if verr := cfg.Validate(); verr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("%w (no config.yaml in the working directory, so only environment variables were read)", verr)
+		}
+		return nil, verr
+	}
+
 	return cfg, nil
 }
 
@@ -111,6 +701,17 @@ func overrideFromEnv(cfg *Config) {
 	if name := os.Getenv("DB_NAME"); name != "" {
 		cfg.Database.Name = name
 	}
+	if dsns := os.Getenv("DB_READ_DSNS"); dsns != "" {
+		cfg.Database.ReadDSNs = strings.Split(dsns, ",")
+	}
+
+	// Messaging
+	if broker := os.Getenv("MESSAGING_BROKER"); broker != "" {
+		cfg.Messaging.Broker = broker
+	}
+	if url := os.Getenv("KAFKA_REST_PROXY_URL"); url != "" {
+		cfg.Messaging.Kafka.RESTProxyURL = url
+	}
 
 	// RabbitMQ
 	if url := os.Getenv("RABBITMQ_URL"); url != "" {
@@ -133,6 +734,326 @@ func overrideFromEnv(cfg *Config) {
 			cfg.Worker.Concurrency = c
 		}
 	}
+	if addr := os.Getenv("WORKER_PROBE_ADDR"); addr != "" {
+		cfg.Worker.ProbeAddr = addr
+	}
+	if attempts := os.Getenv("WORKER_PROVIDER_RETRY_MAX_ATTEMPTS"); attempts != "" {
+		if a, err := strconv.Atoi(attempts); err == nil {
+			cfg.Worker.ProviderRetry.MaxAttempts = a
+		}
+	}
+
+	// Notifications
+	if enabled := os.Getenv("SMS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Notifications.SMS.Enabled = e
+		}
+	}
+	if url := os.Getenv("SMS_GATEWAY_URL"); url != "" {
+		cfg.Notifications.SMS.GatewayURL = url
+	}
+	if user := os.Getenv("SMS_USERNAME"); user != "" {
+		cfg.Notifications.SMS.Username = user
+	}
+	if password := os.Getenv("SMS_PASSWORD"); password != "" {
+		cfg.Notifications.SMS.Password = password
+	}
+	if secret := os.Getenv("SMS_REPORT_SECRET"); secret != "" {
+		cfg.Notifications.SMS.ReportSecret = secret
+	}
+
+	if enabled := os.Getenv("EMAIL_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Notifications.Email.Enabled = e
+		}
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		cfg.Notifications.Email.SMTPHost = host
+	}
+	if port := os.Getenv("SMTP_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			cfg.Notifications.Email.SMTPPort = p
+		}
+	}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		cfg.Notifications.Email.Username = user
+	}
+	if password := os.Getenv("SMTP_PASSWORD"); password != "" {
+		cfg.Notifications.Email.Password = password
+	}
+
+	if enabled := os.Getenv("TELEGRAM_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Notifications.Telegram.Enabled = e
+		}
+	}
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		cfg.Notifications.Telegram.BotToken = token
+	}
+	if secret := os.Getenv("TELEGRAM_WEBHOOK_SECRET"); secret != "" {
+		cfg.Notifications.Telegram.WebhookSecret = secret
+	}
+
+	if enabled := os.Getenv("PUSH_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Notifications.Push.Enabled = e
+		}
+	}
+	if project := os.Getenv("FCM_PROJECT_ID"); project != "" {
+		cfg.Notifications.Push.ProjectID = project
+	}
+	if file := os.Getenv("FCM_CREDENTIALS_FILE"); file != "" {
+		cfg.Notifications.Push.CredentialsFile = file
+	}
+
+	// OTP
+	if enabled := os.Getenv("OTP_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.OTP.Enabled = e
+		}
+	}
+
+	// Reminders
+	if enabled := os.Getenv("REMINDERS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Reminders.Enabled = e
+		}
+	}
+
+	// Receipts
+	if url := os.Getenv("RECEIPT_BASE_URL"); url != "" {
+		cfg.Receipts.BaseURL = url
+	}
+	if secret := os.Getenv("RECEIPT_SIGNING_SECRET"); secret != "" {
+		cfg.Receipts.SigningSecret = secret
+	}
+	if font := os.Getenv("RECEIPT_PDF_FONT"); font != "" {
+		cfg.Receipts.PDFFont = font
+	}
+	if url := os.Getenv("PAYMENT_LINK_BASE_URL"); url != "" {
+		cfg.PaymentLinks.BaseURL = url
+	}
+	if merchantID := os.Getenv("QR_MERCHANT_ID"); merchantID != "" {
+		cfg.QRCodes.MerchantID = merchantID
+	}
+
+	// Status re-query
+	if enabled := os.Getenv("STATUS_REQUERY_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.StatusRequery.Enabled = e
+		}
+	}
+
+	// Payment expiry
+	if enabled := os.Getenv("PAYMENT_EXPIRY_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.PaymentExpiry.Enabled = e
+		}
+	}
+	if ttl := os.Getenv("PAYMENT_EXPIRY_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.PaymentExpiry.TTL = d
+		}
+	}
+
+	// Attachments
+	if secret := os.Getenv("ATTACHMENT_SIGNING_SECRET"); secret != "" {
+		cfg.Attachments.SigningSecret = secret
+	}
+	if driver := os.Getenv("ATTACHMENT_STORAGE_DRIVER"); driver != "" {
+		cfg.Attachments.Storage.Driver = driver
+	}
+	if accessKey := os.Getenv("S3_ACCESS_KEY"); accessKey != "" {
+		cfg.Attachments.Storage.S3.AccessKey = accessKey
+	}
+	if secretKey := os.Getenv("S3_SECRET_KEY"); secretKey != "" {
+		cfg.Attachments.Storage.S3.SecretKey = secretKey
+	}
+
+	// Reconciliation
+	if enabled := os.Getenv("RECONCILIATION_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Reconciliation.Enabled = e
+		}
+	}
+
+	// Regulatory reports
+	if code := os.Getenv("NBE_INSTITUTION_CODE"); code != "" {
+		cfg.RegulatoryReports.InstitutionCode = code
+	}
+	if scheduled := os.Getenv("NBE_REPORTS_SCHEDULED"); scheduled != "" {
+		if s, err := strconv.ParseBool(scheduled); err == nil {
+			cfg.RegulatoryReports.Scheduled = s
+		}
+	}
+
+	// Bulk payouts
+	if enabled := os.Getenv("BULK_PAYOUTS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.BulkPayouts.Enabled = e
+		}
+	}
+
+	// Cash vouchers; agents as name:token pairs separated by commas
+	if enabled := os.Getenv("CASH_VOUCHERS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.CashVouchers.Enabled = e
+		}
+	}
+	if agents := os.Getenv("CASH_VOUCHER_AGENTS"); agents != "" {
+		cfg.CashVouchers.Agents = nil
+		for _, pair := range strings.Split(agents, ",") {
+			name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if ok && name != "" && token != "" {
+				cfg.CashVouchers.Agents = append(cfg.CashVouchers.Agents, AgentConfig{Name: name, Token: token})
+			}
+		}
+	}
+
+	// Agent network
+	if rate := os.Getenv("AGENT_COMMISSION_RATE"); rate != "" {
+		if r, err := strconv.ParseFloat(rate, 64); err == nil {
+			cfg.AgentNetwork.CommissionRate = r
+		}
+	}
+	if enabled := os.Getenv("AGENT_SETTLEMENT_RUN"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.AgentNetwork.SettlementRun = e
+		}
+	}
+
+	// POS terminals
+	if enabled := os.Getenv("POS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.POS.Enabled = e
+		}
+	}
+	if addr := os.Getenv("POS_LISTEN_ADDR"); addr != "" {
+		cfg.POS.ListenAddr = addr
+	}
+
+	// gRPC API; clients as name:token separated by commas
+	if enabled := os.Getenv("GRPC_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.GRPC.Enabled = e
+		}
+	}
+	if addr := os.Getenv("GRPC_LISTEN_ADDR"); addr != "" {
+		cfg.GRPC.ListenAddr = addr
+	}
+	if clients := os.Getenv("GRPC_CLIENTS"); clients != "" {
+		cfg.GRPC.Clients = nil
+		for _, pair := range strings.Split(clients, ",") {
+			name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if ok && name != "" && token != "" {
+				cfg.GRPC.Clients = append(cfg.GRPC.Clients, GRPCClientConfig{Name: name, Token: token})
+			}
+		}
+	}
+
+	// Customer limits
+	if enabled := os.Getenv("CUSTOMER_LIMITS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.CustomerLimits.Enabled = e
+		}
+	}
+	if action := os.Getenv("CUSTOMER_LIMITS_ACTION"); action != "" {
+		cfg.CustomerLimits.Action = action
+	}
+
+	if enabled := os.Getenv("VELOCITY_LIMITS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.VelocityLimits.Enabled = e
+		}
+	}
+
+	if above := os.Getenv("KYC_REQUIRE_VERIFIED_ABOVE"); above != "" {
+		if a, err := strconv.ParseFloat(above, 64); err == nil {
+			cfg.KYC.RequireVerifiedAbove = a
+		}
+	}
+
+	// Client controls
+	if enabled := os.Getenv("CLIENT_CONTROLS_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.ClientControls.Enabled = e
+		}
+	}
+	if blocked := os.Getenv("CLIENT_BLOCKED_IPS"); blocked != "" {
+		cfg.ClientControls.BlockedIPs = nil
+		for _, ip := range strings.Split(blocked, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				cfg.ClientControls.BlockedIPs = append(cfg.ClientControls.BlockedIPs, ip)
+			}
+		}
+	}
+
+	// AML screening
+	if enabled := os.Getenv("AML_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.AML.Enabled = e
+		}
+	}
+	if files := os.Getenv("AML_LIST_FILES"); files != "" {
+		cfg.AML.ListFiles = nil
+		for _, f := range strings.Split(files, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				cfg.AML.ListFiles = append(cfg.AML.ListFiles, f)
+			}
+		}
+	}
+	if url := os.Getenv("AML_API_URL"); url != "" {
+		cfg.AML.API.URL = url
+	}
+	if key := os.Getenv("AML_API_KEY"); key != "" {
+		cfg.AML.API.APIKey = key
+	}
+
+	if enabled := os.Getenv("FRAUD_RULES_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Fraud.Enabled = e
+		}
+	}
+
+	if required := os.Getenv("API_KEYS_REQUIRED"); required != "" {
+		if r, err := strconv.ParseBool(required); err == nil {
+			cfg.APIKeys.Required = r
+		}
+	}
+	if enabled := os.Getenv("TRACING_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Tracing.Enabled = e
+		}
+	}
+	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
+		cfg.Tracing.Endpoint = endpoint
+	}
+	if enabled := os.Getenv("OUTBOX_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Outbox.Enabled = e
+		}
+	}
+	if enabled := os.Getenv("RATE_LIMIT_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.RateLimit.Enabled = e
+		}
+	}
+
+	// Admin operators, as name:token or name:token:role separated by commas
+	if operators := os.Getenv("ADMIN_OPERATORS"); operators != "" {
+		cfg.Admin.Operators = nil
+		for _, pair := range strings.Split(operators, ",") {
+			name, rest, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			token, role, _ := strings.Cut(rest, ":")
+			if ok && name != "" && token != "" {
+				cfg.Admin.Operators = append(cfg.Admin.Operators, OperatorConfig{Name: name, Token: token, Role: role})
+			}
+		}
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		cfg.JWT.Secret = secret
+	}
 
 	// Ethiopian
 	if rate := os.Getenv("ETB_USD_RATE"); rate != "" {
@@ -140,4 +1061,92 @@ func overrideFromEnv(cfg *Config) {
 			cfg.Ethiopian.USDToETBRate = r
 		}
 	}
+	if spread := os.Getenv("FX_SPREAD_BPS"); spread != "" {
+		if b, err := strconv.Atoi(spread); err == nil {
+			cfg.FX.SpreadBps = b
+		}
+	}
+	if currencies := os.Getenv("FX_CURRENCIES"); currencies != "" {
+		cfg.FX.Currencies = nil
+		for _, currency := range strings.Split(currencies, ",") {
+			if currency = strings.ToUpper(strings.TrimSpace(currency)); currency != "" {
+				cfg.FX.Currencies = append(cfg.FX.Currencies, currency)
+			}
+		}
+	}
+	if enabled := os.Getenv("FX_FEED_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.FX.Feed.Enabled = e
+		}
+	}
+	if url := os.Getenv("FX_FEED_URL"); url != "" {
+		cfg.FX.Feed.URL = url
+	}
+	if key := os.Getenv("FX_FEED_API_KEY"); key != "" {
+		cfg.FX.Feed.APIKey = key
+	}
+
+	// Cache
+	if enabled := os.Getenv("CACHE_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Cache.Enabled = e
+		}
+	}
+
+	// Chapa
+	if key := os.Getenv("CHAPA_SECRET_KEY"); key != "" {
+		cfg.Providers.Chapa.SecretKey = key
+	}
+	if secret := os.Getenv("CHAPA_WEBHOOK_SECRET"); secret != "" {
+		cfg.Providers.Chapa.WebhookSecret = secret
+	}
+
+	// Amole
+	if username := os.Getenv("AMOLE_USERNAME"); username != "" {
+		cfg.Providers.Amole.Username = username
+	}
+	if password := os.Getenv("AMOLE_PASSWORD"); password != "" {
+		cfg.Providers.Amole.Password = password
+	}
+	if signature := os.Getenv("AMOLE_SIGNATURE"); signature != "" {
+		cfg.Providers.Amole.Signature = signature
+	}
+
+	// ArifPay
+	if key := os.Getenv("ARIFPAY_API_KEY"); key != "" {
+		cfg.Providers.ArifPay.APIKey = key
+	}
+
+	// SantimPay
+	if enabled := os.Getenv("SANTIMPAY_ENABLED"); enabled != "" {
+		if e, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Providers.SantimPay.Enabled = e
+		}
+	}
+	if url := os.Getenv("SANTIMPAY_BASE_URL"); url != "" {
+		cfg.Providers.SantimPay.BaseURL = url
+	}
+	if key := os.Getenv("SANTIMPAY_PRIVATE_KEY"); key != "" {
+		cfg.Providers.SantimPay.PrivateKey = key
+	}
+
+	// EthSwitch
+	if username := os.Getenv("ETHSWITCH_USERNAME"); username != "" {
+		cfg.Providers.EthSwitch.Username = username
+	}
+	if password := os.Getenv("ETHSWITCH_PASSWORD"); password != "" {
+		cfg.Providers.EthSwitch.Password = password
+	}
+
+	// Provider circuit breakers
+	if threshold := os.Getenv("PROVIDER_CIRCUIT_FAILURE_THRESHOLD"); threshold != "" {
+		if t, err := strconv.Atoi(threshold); err == nil {
+			cfg.Providers.CircuitBreaker.FailureThreshold = t
+		}
+	}
+	if timeout := os.Getenv("PROVIDER_CIRCUIT_OPEN_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			cfg.Providers.CircuitBreaker.OpenTimeout = d
+		}
+	}
 }
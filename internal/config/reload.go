@@ -0,0 +1,194 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// How often config.yaml is checked for changes
+const watchInterval = 5 * time.Second
+
+// Tunables are the settings a reload applies to a running process; the
+// rest are read once at startup. Values set in the environment still win
+// over the file, so they only change with a restart.
+type Tunables struct {
+	LogLevel          string             `json:"log_level"`
+	WorkerConcurrency int                `json:"worker_concurrency"`
+	RateLimit         RateLimitConfig    `json:"rate_limit"`
+	MaxETBAmount      float64            `json:"max_etb_amount"`
+	USDToETBRate      float64            `json:"usd_to_etb"`
+	FXFallbackRates   map[string]float64 `json:"fx_fallback_rates"`
+}
+
+func (c *Config) Tunables() Tunables {
+	return Tunables{
+		LogLevel:          c.Logging.Level,
+		WorkerConcurrency: c.Worker.Concurrency,
+		RateLimit:         c.RateLimit,
+		MaxETBAmount:      c.Ethiopian.MaxETBAmount,
+		USDToETBRate:      c.Ethiopian.USDToETBRate,
+		FXFallbackRates:   c.FX.FallbackRates,
+	}
+}
+
+// withoutTunables is the configuration with its tunables zeroed, to tell
+// whether anything else changed
+func (c Config) withoutTunables() Config {
+	c.Logging.Level = ""
+	c.Worker.Concurrency = 0
+	c.RateLimit = RateLimitConfig{}
+	c.Ethiopian.MaxETBAmount = 0
+	c.Ethiopian.USDToETBRate = 0
+	c.FX.FallbackRates = nil
+	return c
+}
+
+// Snapshot is the configuration a process is running with
+type Snapshot struct {
+	Tunables Tunables  `json:"tunables"`
+	LoadedAt time.Time `json:"loaded_at"`
+	// Why the latest reload was rejected; the values before it stay in effect
+	LastError string `json:"last_error,omitempty"`
+	// Settings other than the tunables changed, and wait for a restart
+	RestartRequired bool `json:"restart_required"`
+}
+
+// Reloader loads the configuration again on SIGHUP or when config.yaml
+// changes, and passes it to the subscribers to apply its Tunables. A
+// configuration that fails to load or validate is rejected whole.
+type Reloader struct {
+	logger *logrus.Logger
+
+	reloading   sync.Mutex // One reload at a time
+	subscribers []func(cfg *Config)
+
+	mu       sync.RWMutex
+	startup  *Config
+	current  *Config
+	loadedAt time.Time
+	lastErr  error
+}
+
+// NewReloader starts from cfg, the configuration the process started with
+func NewReloader(cfg *Config, logger *logrus.Logger) *Reloader {
+	return &Reloader{
+		logger:   logger,
+		startup:  cfg,
+		current:  cfg,
+		loadedAt: time.Now().UTC(),
+	}
+}
+
+// Subscribe calls apply with every configuration reloaded from now on
+func (r *Reloader) Subscribe(apply func(cfg *Config)) {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	r.subscribers = append(r.subscribers, apply)
+}
+
+func (r *Reloader) Snapshot() Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := Snapshot{
+		Tunables:        r.current.Tunables(),
+		LoadedAt:        r.loadedAt,
+		RestartRequired: !reflect.DeepEqual(r.startup.withoutTunables(), r.current.withoutTunables()),
+	}
+	if r.lastErr != nil {
+		snapshot.LastError = r.lastErr.Error()
+	}
+	return snapshot
+}
+
+// Reload loads the configuration and applies it, or keeps the current one
+// and returns why not
+func (r *Reloader) Reload() error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	cfg, err := Load()
+
+	r.mu.Lock()
+	previous := r.current
+	r.lastErr = err
+	if err == nil {
+		r.current, r.loadedAt = cfg, time.Now().UTC()
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		r.logger.WithError(err).Error("Configuration reload rejected; keeping the current settings")
+		return err
+	}
+
+	for _, apply := range r.subscribers {
+		apply(cfg)
+	}
+
+	fields := logrus.Fields{"changed": changedTunables(previous.Tunables(), cfg.Tunables())}
+	if !reflect.DeepEqual(previous.withoutTunables(), cfg.withoutTunables()) {
+		r.logger.WithFields(fields).Warn("Configuration reloaded; settings other than the tunables changed and take effect on restart")
+	} else {
+		r.logger.WithFields(fields).Info("Configuration reloaded")
+	}
+	return nil
+}
+
+// changedTunables names the tunables that differ, by their JSON names
+func changedTunables(before, after Tunables) []string {
+	var changed []string
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < b.NumField(); i++ {
+		if !reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			changed = append(changed, b.Type().Field(i).Tag.Get("json"))
+		}
+	}
+	return changed
+}
+
+// Watch reloads on SIGHUP, and when config.yaml's modification time
+// changes, until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	modified := modTime()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("SIGHUP received, reloading configuration")
+			modified = modTime()
+			r.Reload()
+		case <-ticker.C:
+			if m := modTime(); !m.Equal(modified) {
+				modified = m
+				r.logger.Info("config.yaml changed, reloading configuration")
+				r.Reload()
+			}
+		}
+	}
+}
+
+// modTime is config.yaml's modification time, or zero while there is none
+func modTime() time.Time {
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
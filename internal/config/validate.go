@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError lists every problem found in the configuration, so they
+// can all be fixed in one go rather than one per restart
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate fills in defaults for settings left out and checks the rest,
+// returning a *ValidationError naming each setting that is missing or
+// wrong
+func (c *Config) Validate() error {
+	c.applyDefaults()
+
+	var problems []string
+	require := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	validPort := func(port int) bool { return port > 0 && port <= 65535 }
+
+	// Server
+	require(validPort(c.Server.Port), "server.port %d is not a valid port (SERVER_PORT)", c.Server.Port)
+	require(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	require(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	require(c.Server.GracefulShutdownTimeout > 0, "server.graceful_shutdown_timeout must be positive")
+
+	// Database
+	require(c.Database.Host != "", "database.host is required (DB_HOST)")
+	require(validPort(c.Database.Port), "database.port %d is not a valid port (DB_PORT)", c.Database.Port)
+	require(c.Database.User != "", "database.user is required (DB_USER)")
+	require(c.Database.Name != "", "database.name is required (DB_NAME)")
+	for i, dsn := range c.Database.ReadDSNs {
+		require(hasScheme(dsn, "postgres", "postgresql"), "database.read_dsns[%d] is not a postgres:// DSN (DB_READ_DSNS)", i)
+	}
+
+	// Messaging
+	switch c.Messaging.Broker {
+	case "rabbitmq":
+		require(c.RabbitMQ.URL != "", "rabbitmq.url is required (RABBITMQ_URL)")
+		require(c.RabbitMQ.URL == "" || hasScheme(c.RabbitMQ.URL, "amqp", "amqps"), "rabbitmq.url must be an amqp:// or amqps:// URL (RABBITMQ_URL)")
+		require(c.RabbitMQ.QueueName != "", "rabbitmq.queue_name is required (RABBITMQ_QUEUE)")
+		require(c.RabbitMQ.PrefetchCount > 0, "rabbitmq.prefetch_count must be positive")
+	case "kafka":
+		require(c.Messaging.Kafka.RESTProxyURL != "", "messaging.kafka.rest_proxy_url is required when messaging.broker is kafka (KAFKA_REST_PROXY_URL)")
+		require(c.Messaging.Kafka.RESTProxyURL == "" || hasScheme(c.Messaging.Kafka.RESTProxyURL, "http", "https"), "messaging.kafka.rest_proxy_url must be an http:// or https:// URL (KAFKA_REST_PROXY_URL)")
+	default:
+		problems = append(problems, fmt.Sprintf("messaging.broker %q is not rabbitmq or kafka (MESSAGING_BROKER)", c.Messaging.Broker))
+	}
+
+	// Logging
+	_, err := logrus.ParseLevel(c.Logging.Level)
+	require(err == nil, "logging.level %q is not a log level (debug, info, warn, error)", c.Logging.Level)
+
+	// Worker
+	require(c.Worker.Concurrency > 0, "worker.concurrency must be positive (WORKER_CONCURRENCY)")
+	for i, tier := range c.Worker.RetryTiers {
+		require(tier > 0, "worker.retry_tiers[%d] must be positive", i)
+	}
+	retry := c.Worker.ProviderRetry
+	require(retry.MaxAttempts > 0, "worker.provider_retry.max_attempts must be positive (WORKER_PROVIDER_RETRY_MAX_ATTEMPTS)")
+	require(retry.InitialBackoff > 0, "worker.provider_retry.initial_backoff must be positive")
+	require(retry.MaxBackoff >= retry.InitialBackoff, "worker.provider_retry.max_backoff must be at least initial_backoff")
+	require(retry.Multiplier >= 1, "worker.provider_retry.multiplier must be at least 1")
+
+	// Ethiopian and FX; a zero rate would convert every foreign payment to
+	// nothing
+	require(c.Ethiopian.USDToETBRate > 0, "ethiopian.usd_to_etb must be positive (ETB_USD_RATE)")
+	require(c.Ethiopian.MaxETBAmount > 0, "ethiopian.max_etb_amount must be positive")
+	for _, currency := range slices.Sorted(maps.Keys(c.FX.FallbackRates)) {
+		require(c.FX.FallbackRates[currency] > 0, "fx.fallback_rates.%s must be positive", currency)
+	}
+
+	// Rate limiting
+	require(c.RateLimit.RPS > 0, "rate_limit.rps must be positive")
+	require(c.RateLimit.Burst > 0, "rate_limit.burst must be positive")
+	for _, merchant := range slices.Sorted(maps.Keys(c.RateLimit.Merchants)) {
+		tier := c.RateLimit.Merchants[merchant]
+		require(tier.RPS >= 0, "rate_limit.merchants.%s.rps must not be negative", merchant)
+		require(tier.Burst >= 0, "rate_limit.merchants.%s.burst must not be negative", merchant)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// applyDefaults fills in the settings every deployment needs but few
+// change
+func (c *Config) applyDefaults() {
+	setDefault(&c.Server.Port, 8080)
+	setDefault(&c.Server.ReadTimeout, 30*time.Second)
+	setDefault(&c.Server.WriteTimeout, 30*time.Second)
+	setDefault(&c.Server.GracefulShutdownTimeout, 10*time.Second)
+
+	setDefault(&c.Database.Port, 5432)
+	setDefault(&c.Database.SSLMode, "prefer")
+
+	c.Messaging.Broker = strings.ToLower(c.Messaging.Broker)
+	setDefault(&c.Messaging.Broker, "rabbitmq")
+
+	setDefault(&c.RabbitMQ.QueueName, "ethiopian_payment_queue")
+	setDefault(&c.RabbitMQ.Exchange, "ethiopian_payment_exchange")
+	setDefault(&c.RabbitMQ.ConsumerTag, "ethiopian_payment_consumer")
+	setDefault(&c.RabbitMQ.PrefetchCount, 10)
+
+	setDefault(&c.Worker.Concurrency, 5)
+	setDefault(&c.Worker.ProviderRetry.MaxAttempts, 3)
+	setDefault(&c.Worker.ProviderRetry.InitialBackoff, 200*time.Millisecond)
+	setDefault(&c.Worker.ProviderRetry.MaxBackoff, 2*time.Second)
+	setDefault(&c.Worker.ProviderRetry.Multiplier, 2)
+
+	setDefault(&c.Ethiopian.MaxETBAmount, 1000000)
+
+	setDefault(&c.RateLimit.RPS, 20)
+	setDefault(&c.RateLimit.Burst, 2*int(math.Ceil(c.RateLimit.RPS)))
+
+	setDefault(&c.Logging.Level, "info")
+}
+
+// setDefault sets a setting left at its zero value
+func setDefault[T comparable](setting *T, value T) {
+	var zero T
+	if *setting == zero {
+		*setting = value
+	}
+}
+
+func hasScheme(rawURL string, schemes ...string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Mobile money wallets are addressed by the holder's phone number
+var walletCodes = map[string]bool{
+	"TELEBIRR": true,
+	"MPESA":    true,
+}
+
+func IsWallet(code string) bool {
+	return walletCodes[strings.ToUpper(code)]
+}
+
+// Name inquiry request for a destination bank account or wallet
+type AccountVerificationRequest struct {
+	BankCode      string `json:"bank_code" validate:"required"`
+	AccountNumber string `json:"account_number" validate:"required"`
+}
+
+// Validate normalizes the request in place: bank codes are upper-cased and
+// wallet numbers converted to E.164
+func (r *AccountVerificationRequest) Validate() error {
+	r.BankCode = strings.ToUpper(strings.TrimSpace(r.BankCode))
+	r.AccountNumber = strings.TrimSpace(r.AccountNumber)
+
+	if r.BankCode == "" {
+		return errors.New("bank_code is required")
+	}
+
+	if IsWallet(r.BankCode) {
+		phone, err := NormalizePhone(r.AccountNumber)
+		if err != nil {
+			return fmt.Errorf("wallet account: %w", err)
+		}
+		r.AccountNumber = phone
+		return nil
+	}
+
+	if len(r.AccountNumber) < 8 || len(r.AccountNumber) > 20 {
+		return errors.New("account number must be 8 to 20 digits")
+	}
+	for _, c := range r.AccountNumber {
+		if c < '0' || c > '9' {
+			return errors.New("account number must contain digits only")
+		}
+	}
+
+	return nil
+}
+
+// Result of a name inquiry
+type AccountVerification struct {
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+}
+
+var (
+	ErrNameInquiryUnsupported = errors.New("name inquiry is not supported for this bank")
+	ErrAccountNotFound        = errors.New("account not found at the destination bank")
+	ErrNameInquiryUnavailable = errors.New("destination bank name inquiry is unavailable")
+)
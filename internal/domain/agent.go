@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AgentStatus controls whether an agent may collect cash
+type AgentStatus string
+
+const (
+	AgentActive    AgentStatus = "ACTIVE"
+	AgentSuspended AgentStatus = "SUSPENDED"
+)
+
+func (s AgentStatus) IsValid() bool {
+	return s == AgentActive || s == AgentSuspended
+}
+
+// Agent is a cash-in point in the gateway's own agent network (shops,
+// kiosks). Agents pre-fund an ETB float; each cash-in moves value from the
+// float to the payment, and the agent keeps the customer's cash.
+type Agent struct {
+	ID           uuid.UUID   `json:"id"`
+	Code         string      `json:"code"` // Recorded as redeemed_by on vouchers the agent collects
+	Name         string      `json:"name"`
+	Phone        string      `json:"phone"`
+	Region       string      `json:"region,omitempty"`
+	Status       AgentStatus `json:"status"`
+	FloatBalance Amount      `json:"float_balance"`
+	TxnLimit     Amount      `json:"txn_limit"`   // Largest single cash-in, ETB
+	DailyLimit   Amount      `json:"daily_limit"` // Cash-in total per Ethiopian business day, ETB
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// RegisteredAgent carries the agent's API token, which is only shown once
+type RegisteredAgent struct {
+	Agent
+	Token string `json:"token"`
+}
+
+var agentCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{2,19}$`)
+
+type RegisterAgentRequest struct {
+	Code       string `json:"code" validate:"required,min=3,max=20"`
+	Name       string `json:"name" validate:"required,max=100"`
+	Phone      string `json:"phone" validate:"required"`
+	Region     string `json:"region,omitempty" validate:"max=50"`
+	TxnLimit   Amount `json:"txn_limit" validate:"required,gt=0"`
+	DailyLimit Amount `json:"daily_limit" validate:"required,gt=0"`
+}
+
+// Validate normalizes the code and phone in place
+func (r *RegisterAgentRequest) Validate() error {
+	r.Code = strings.ToUpper(strings.TrimSpace(r.Code))
+	if !agentCodePattern.MatchString(r.Code) {
+		return errors.New("code must be 3-20 letters, digits or dashes")
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name is required and at most 100 characters")
+	}
+
+	phone, err := NormalizePhone(r.Phone)
+	if err != nil {
+		return fmt.Errorf("phone: %w", err)
+	}
+	r.Phone = phone
+
+	r.Region = strings.TrimSpace(r.Region)
+	if len(r.Region) > 50 {
+		return errors.New("region is too long")
+	}
+
+	return validateAgentLimits(r.TxnLimit, r.DailyLimit)
+}
+
+// UpdateAgentRequest changes status or limits; omitted fields are kept
+type UpdateAgentRequest struct {
+	Status     *AgentStatus `json:"status,omitempty"`
+	TxnLimit   *Amount      `json:"txn_limit,omitempty"`
+	DailyLimit *Amount      `json:"daily_limit,omitempty"`
+}
+
+// Apply validates the changes against the agent and applies them
+func (r *UpdateAgentRequest) Apply(agent *Agent) error {
+	if r.Status != nil {
+		if !r.Status.IsValid() {
+			return errors.New("status must be ACTIVE or SUSPENDED")
+		}
+		agent.Status = *r.Status
+	}
+	if r.TxnLimit != nil {
+		agent.TxnLimit = *r.TxnLimit
+	}
+	if r.DailyLimit != nil {
+		agent.DailyLimit = *r.DailyLimit
+	}
+
+	return validateAgentLimits(agent.TxnLimit, agent.DailyLimit)
+}
+
+func validateAgentLimits(txnLimit, dailyLimit Amount) error {
+	if txnLimit <= 0 || dailyLimit <= 0 {
+		return errors.New("txn_limit and daily_limit must be greater than zero")
+	}
+	if txnLimit > dailyLimit {
+		return errors.New("txn_limit cannot exceed daily_limit")
+	}
+	return nil
+}
+
+// FloatEntryKind is the reason an agent's float balance changed
+type FloatEntryKind string
+
+const (
+	FloatTopUp      FloatEntryKind = "TOPUP"      // Agent deposited funds with the gateway
+	FloatCashIn     FloatEntryKind = "CASH_IN"    // Float spent settling a customer's payment
+	FloatCommission FloatEntryKind = "COMMISSION" // Credited by the daily settlement run
+)
+
+// AgentFloatEntry is one line of an agent's float ledger. Amount is signed:
+// credits are positive, cash-ins negative.
+type AgentFloatEntry struct {
+	ID           uuid.UUID      `json:"id"`
+	AgentID      uuid.UUID      `json:"agent_id"`
+	Kind         FloatEntryKind `json:"kind"`
+	Amount       Amount         `json:"amount"`
+	BalanceAfter Amount         `json:"balance_after"`
+	PaymentID    *uuid.UUID     `json:"payment_id,omitempty"`
+	Reference    string         `json:"reference,omitempty"`
+	CreatedBy    string         `json:"created_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type TopUpFloatRequest struct {
+	Amount    Amount `json:"amount" validate:"required,gt=0"`
+	Reference string `json:"reference" validate:"required,max=64"` // Bank deposit slip or transfer reference
+}
+
+func (r *TopUpFloatRequest) Validate() error {
+	if r.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	r.Reference = strings.TrimSpace(r.Reference)
+	if r.Reference == "" || len(r.Reference) > 64 {
+		return errors.New("reference is required and at most 64 characters")
+	}
+
+	return nil
+}
+
+// AgentSettlement is an agent's line in the daily settlement run
+type AgentSettlement struct {
+	ID           uuid.UUID `json:"id"`
+	AgentID      uuid.UUID `json:"agent_id"`
+	AgentCode    string    `json:"agent_code"`
+	BusinessDate string    `json:"business_date"` // Ethiopian business day, YYYY-MM-DD (Gregorian)
+	CashInCount  int       `json:"cash_in_count"`
+	CashInTotal  Amount    `json:"cash_in_total"`
+	Commission   Amount    `json:"commission"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+var (
+	ErrAgentNotFound          = errors.New("agent not found")
+	ErrAgentExists            = errors.New("agent code is already registered")
+	ErrAgentSuspended         = errors.New("agent is suspended")
+	ErrAgentFloatInsufficient = errors.New("agent float balance is insufficient")
+	ErrAgentTxnLimit          = errors.New("amount exceeds the agent's per-transaction limit")
+	ErrAgentDailyLimit        = errors.New("amount exceeds the agent's remaining daily limit")
+)
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Longest range an analytics query may cover
+const MaxAnalyticsDays = 366
+
+// AnalyticsRange is a span of Ethiopian business days, both ends inclusive
+type AnalyticsRange struct {
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`   // YYYY-MM-DD
+
+	// Only this merchant's payments; nil covers every merchant
+	MerchantID *uuid.UUID `json:"-"`
+}
+
+// Validate fills in the last 30 days up to today when dates are missing
+func (r *AnalyticsRange) Validate(today string) error {
+	if r.To == "" {
+		r.To = today
+	}
+	to, err := time.Parse("2006-01-02", r.To)
+	if err != nil {
+		return errors.New("to must be a date in YYYY-MM-DD format")
+	}
+	if r.From == "" {
+		r.From = to.AddDate(0, 0, -29).Format("2006-01-02")
+	}
+	from, err := time.Parse("2006-01-02", r.From)
+	if err != nil {
+		return errors.New("from must be a date in YYYY-MM-DD format")
+	}
+
+	if to.Before(from) {
+		return errors.New("to must not be before from")
+	}
+	if to.Sub(from) >= MaxAnalyticsDays*24*time.Hour {
+		return errors.New("range may cover at most 366 days")
+	}
+	return nil
+}
+
+// AnalyticsInterval buckets a trend series
+type AnalyticsInterval string
+
+const (
+	IntervalDay   AnalyticsInterval = "day"
+	IntervalWeek  AnalyticsInterval = "week"
+	IntervalMonth AnalyticsInterval = "month"
+)
+
+func (i AnalyticsInterval) IsValid() bool {
+	return i == IntervalDay || i == IntervalWeek || i == IntervalMonth
+}
+
+// TopCustomer is a customer's successful volume in the range, identified
+// by phone
+type TopCustomer struct {
+	CustomerPhone string    `json:"customer_phone"`
+	CustomerName  string    `json:"customer_name,omitempty"` // Most recent name used
+	PaymentCount  int       `json:"payment_count"`
+	VolumeETB     Amount    `json:"volume_etb"`
+	LastPaymentAt time.Time `json:"last_payment_at"`
+}
+
+// RepeatRate is the share of paying customers who paid more than once
+type RepeatRate struct {
+	AnalyticsRange
+	Customers       int     `json:"customers"`
+	RepeatCustomers int     `json:"repeat_customers"`
+	Rate            float64 `json:"rate"` // 0-1
+}
+
+// BankMixPoint is one bank's successful payments in one period
+type BankMixPoint struct {
+	Period       string  `json:"period"` // First business day of the bucket, YYYY-MM-DD
+	BankCode     string  `json:"bank_code"`
+	PaymentCount int     `json:"payment_count"`
+	VolumeETB    Amount  `json:"volume_etb"`
+	Share        float64 `json:"share"` // Of the period's ETB volume, 0-1
+}
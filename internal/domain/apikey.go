@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates a merchant's server on the merchant API. Only its
+// hash is stored; the key itself is shown once, when it is issued.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	MerchantID uuid.UUID  `json:"merchant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the key, to tell keys apart
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Set when the key is rotated out
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	// Scopes limits the key to the endpoints needing one of them. A key
+	// without scopes is unrestricted.
+	Scopes []APIKeyScope `json:"scopes,omitempty"`
+}
+
+// APIKeyScope grants a key one kind of call on the merchant API
+type APIKeyScope string
+
+const (
+	ScopePaymentsCreate APIKeyScope = "payments:create" // Create payments and act on them
+	ScopePaymentsRead   APIKeyScope = "payments:read"   // Payments, their refunds and disputes
+	ScopeRefundsCreate  APIKeyScope = "refunds:create"
+	ScopeStatisticsRead APIKeyScope = "statistics:read" // Statistics, dashboard and analytics
+)
+
+var APIKeyScopes = []APIKeyScope{ScopePaymentsCreate, ScopePaymentsRead, ScopeRefundsCreate, ScopeStatisticsRead}
+
+func (s APIKeyScope) IsValid() bool {
+	for _, scope := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Restricted reports whether the key is limited to its scopes
+func (k *APIKey) Restricted() bool {
+	return len(k.Scopes) > 0
+}
+
+// Allows reports whether the key may make calls needing scope
+func (k *APIKey) Allows(scope APIKeyScope) bool {
+	if !k.Restricted() {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable reports whether the key still authenticates at now
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// IssuedAPIKey carries the key itself, which is only shown once
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name   string        `json:"name" validate:"required,max=100"` // e.g. "production server"
+	Scopes []APIKeyScope `json:"scopes,omitempty"`                 // Omit for an unrestricted key
+}
+
+// Validate trims the name and drops repeated scopes in place
+func (r *IssueAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name is required and at most 100 characters")
+	}
+
+	seen := make(map[APIKeyScope]bool, len(r.Scopes))
+	scopes := r.Scopes[:0]
+	for _, scope := range r.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	r.Scopes = scopes
+	return nil
+}
+
+var (
+	ErrAPIKeyNotFound    = errors.New("API key not found")
+	ErrAPIKeyRevoked     = errors.New("API key has been revoked or rotated")
+	ErrMerchantSuspended = errors.New("merchant is suspended")
+)
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentKind describes what a document attached to a payment proves
+type AttachmentKind string
+
+const (
+	AttachmentTransferSlip AttachmentKind = "TRANSFER_SLIP"
+	AttachmentApproval     AttachmentKind = "APPROVAL"
+	AttachmentOther        AttachmentKind = "OTHER"
+)
+
+func (k AttachmentKind) IsValid() bool {
+	switch k {
+	case AttachmentTransferSlip, AttachmentApproval, AttachmentOther:
+		return true
+	}
+	return false
+}
+
+// Attachment is a proof-of-payment document kept in object storage.
+// Attachments are never modified or deleted, so they can back audits and
+// disputes.
+type Attachment struct {
+	ID          uuid.UUID      `json:"id"`
+	PaymentID   uuid.UUID      `json:"payment_id"`
+	Kind        AttachmentKind `json:"kind"`
+	FileName    string         `json:"file_name"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	Checksum    string         `json:"checksum"` // SHA-256 of the contents, hex
+	StorageKey  string         `json:"-"`
+	UploadedBy  string         `json:"uploaded_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+
+	// Short-lived signed download link, filled in when listed
+	URL          string     `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// AttachmentUploadedEvent hands a new attachment, contents included, to
+// back-office systems
+type AttachmentUploadedEvent struct {
+	Attachment *Attachment `json:"attachment"`
+	Content    []byte      `json:"content"`
+}
+
+var (
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrAttachmentType        = errors.New("attachment type is not allowed")
+	ErrAttachmentLinkInvalid = errors.New("attachment link is invalid or expired")
+	ErrAttachmentsDisabled   = errors.New("attachments are not configured")
+)
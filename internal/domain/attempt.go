@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttemptStatus string
+
+const (
+	AttemptQueued  AttemptStatus = "QUEUED" // Manual retry waiting for the worker
+	AttemptSuccess AttemptStatus = "SUCCESS"
+	AttemptFailed  AttemptStatus = "FAILED"
+)
+
+// What started a processing attempt
+type AttemptTrigger string
+
+const (
+	AttemptTriggerAuto  AttemptTrigger = "AUTO"         // First processing after creation or OTP confirmation
+	AttemptTriggerRetry AttemptTrigger = "MANUAL_RETRY" // POST /payments/:id/retry
+)
+
+// PaymentAttempt is one try at settling a payment with a bank. Attempts are
+// never updated once finished, so the failure history survives retries.
+type PaymentAttempt struct {
+	ID            uuid.UUID      `json:"id"`
+	PaymentID     uuid.UUID      `json:"payment_id"`
+	AttemptNumber int            `json:"attempt_number"`
+	BankCode      string         `json:"bank_code,omitempty"`
+	Trigger       AttemptTrigger `json:"trigger"`
+	Status        AttemptStatus  `json:"status"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	FinishedAt    *time.Time     `json:"finished_at,omitempty"`
+}
+
+type RetryPaymentRequest struct {
+	BankCode string `json:"bank_code,omitempty" validate:"max=20"` // Route the retry to a different bank
+}
+
+var ErrPaymentNotRetryable = errors.New("only failed payments can be retried")
@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/etime"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction names a state change recorded in the audit log
+type AuditAction string
+
+const (
+	AuditPaymentCreated        AuditAction = "payment.created"
+	AuditPaymentStatusChanged  AuditAction = "payment.status_changed"
+	AuditPaymentStatusOverride AuditAction = "payment.status_overridden"
+	AuditPaymentReviewed       AuditAction = "payment.reviewed" // AML review cleared or rejected
+	AuditRefundCreated         AuditAction = "refund.created"
+	AuditRefundStatusChanged   AuditAction = "refund.status_changed"
+	AuditMerchantStatusChanged AuditAction = "merchant.status_changed" // Activated or suspended
+	AuditAPIKeyIssued          AuditAction = "api_key.issued"
+	AuditAPIKeyRotated         AuditAction = "api_key.rotated"
+	AuditAPIKeyRevoked         AuditAction = "api_key.revoked"
+	AuditAgentFloatTopUp       AuditAction = "agent.float_topped_up"
+	AuditDisputeOpened         AuditAction = "dispute.opened"
+	AuditDisputeEvidence       AuditAction = "dispute.evidence_submitted"
+	AuditDisputeResolved       AuditAction = "dispute.resolved"
+	AuditFXRateSet             AuditAction = "fx_rate.set"
+	AuditWebhookRegistered     AuditAction = "webhook.registered"
+	AuditWebhookDeleted        AuditAction = "webhook.deleted"
+	AuditFraudRuleCreated      AuditAction = "fraud_rule.created"
+	AuditFraudRuleUpdated      AuditAction = "fraud_rule.updated"
+	AuditFraudRuleDeleted      AuditAction = "fraud_rule.deleted"
+	AuditCustomerVerified      AuditAction = "customer.verified" // KYC verified or rejected
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditPaymentCreated, AuditPaymentStatusChanged, AuditPaymentStatusOverride, AuditPaymentReviewed,
+		AuditRefundCreated, AuditRefundStatusChanged,
+		AuditMerchantStatusChanged,
+		AuditAPIKeyIssued, AuditAPIKeyRotated, AuditAPIKeyRevoked,
+		AuditAgentFloatTopUp,
+		AuditDisputeOpened, AuditDisputeEvidence, AuditDisputeResolved,
+		AuditFXRateSet,
+		AuditWebhookRegistered, AuditWebhookDeleted,
+		AuditFraudRuleCreated, AuditFraudRuleUpdated, AuditFraudRuleDeleted,
+		AuditCustomerVerified:
+		return true
+	}
+	return false
+}
+
+// Entities the audit log records changes to
+const (
+	AuditEntityPayment   = "payment"
+	AuditEntityRefund    = "refund"
+	AuditEntityMerchant  = "merchant"
+	AuditEntityAPIKey    = "api_key"
+	AuditEntityAgent     = "agent"
+	AuditEntityDispute   = "dispute"
+	AuditEntityFXRate    = "fx_rate"
+	AuditEntityWebhook   = "webhook"
+	AuditEntityFraudRule = "fraud_rule"
+	AuditEntityCustomer  = "customer"
+)
+
+func isAuditEntity(entityType string) bool {
+	switch entityType {
+	case AuditEntityPayment, AuditEntityRefund, AuditEntityMerchant, AuditEntityAPIKey,
+		AuditEntityAgent, AuditEntityDispute, AuditEntityFXRate, AuditEntityWebhook, AuditEntityFraudRule,
+		AuditEntityCustomer:
+		return true
+	}
+	return false
+}
+
+// ActorType says who made a change: a caller of the API or the gateway itself
+type ActorType string
+
+const (
+	ActorOperator  ActorType = "operator"
+	ActorMerchant  ActorType = "merchant"
+	ActorAgent     ActorType = "agent"
+	ActorAnonymous ActorType = "anonymous"
+	ActorSystem    ActorType = "system" // The worker and background jobs
+)
+
+// Actor is the caller a change is recorded against
+type Actor struct {
+	Type ActorType
+	ID   string // Operator name, merchant ID or agent code; empty for anonymous and system
+	IP   string
+}
+
+type actorContextKey struct{}
+
+// WithActor returns ctx carrying the caller that changes made with it are
+// recorded against
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFrom returns ctx's caller; changes made outside a request belong to
+// the system
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorContextKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: ActorSystem}
+}
+
+// AuditEvent is one append-only audit log entry. Before and After hold the
+// changed fields, or the whole record on creation.
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	Action     AuditAction     `json:"action"`
+	EntityType string          `json:"entity_type"` // payment, refund, merchant, api_key, agent, dispute, fx_rate or webhook
+	EntityID   uuid.UUID       `json:"entity_id"`
+	ActorType  ActorType       `json:"actor_type"`
+	Actor      string          `json:"actor,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit events; empty fields match everything. From
+// and To take an RFC 3339 time or a YYYY-MM-DD date in Ethiopian time.
+type AuditFilter struct {
+	Action     AuditAction
+	EntityType string
+	EntityID   *uuid.UUID
+	ActorType  ActorType
+	Actor      string
+	From       string // Inclusive
+	To         string // Exclusive for a time; a date includes that whole day
+}
+
+// Validate checks the action, entity type and actor type, and rewrites
+// From and To as RFC 3339 times
+func (f *AuditFilter) Validate() error {
+	if f.Action != "" && !f.Action.IsValid() {
+		return errors.New("unknown action")
+	}
+	if f.EntityType != "" && !isAuditEntity(f.EntityType) {
+		return errors.New("entity_type must be payment, refund, merchant, api_key, agent, dispute, fx_rate or webhook")
+	}
+	switch f.ActorType {
+	case "", ActorOperator, ActorMerchant, ActorAgent, ActorAnonymous, ActorSystem:
+	default:
+		return errors.New("actor_type must be operator, merchant, agent, anonymous or system")
+	}
+
+	var from, to time.Time
+	var err error
+	if f.From != "" {
+		if from, err = parseAuditTime(f.From, false); err != nil {
+			return errors.New("from must be an RFC 3339 time or a date in YYYY-MM-DD format")
+		}
+		f.From = from.Format(time.RFC3339)
+	}
+	if f.To != "" {
+		if to, err = parseAuditTime(f.To, true); err != nil {
+			return errors.New("to must be an RFC 3339 time or a date in YYYY-MM-DD format")
+		}
+		f.To = to.Format(time.RFC3339)
+	}
+	if f.From != "" && f.To != "" && !to.After(from) {
+		return errors.New("to must be after from")
+	}
+	return nil
+}
+
+// parseAuditTime reads a date as midnight in Ethiopia, or the
+// following midnight when it ends a range
+func parseAuditTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := etime.ParseDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// Paginated list of audit events
+type AuditListResponse struct {
+	Events  []*AuditEvent `json:"events"`
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	Limit   int           `json:"limit"`
+	HasMore bool          `json:"has_more"`
+}
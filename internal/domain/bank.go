@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Bank is a receiving bank the gateway settles with
+type Bank struct {
+	Code   EthiopianBank `json:"code"`
+	Name   string        `json:"name"`
+	NameAm string        `json:"name_am"` // As printed on Amharic receipts
+	SWIFT  string        `json:"swift"`
+	// Human-readable form of referencePattern, shown to merchants
+	ReferenceFormat string `json:"reference_format"`
+
+	// References the bank accepts in its settlement files
+	referencePattern *regexp.Regexp
+}
+
+// Patterns come from each bank's settlement file specification; a reference
+// that does not match is bounced during settlement, days after the payment.
+var banks = []Bank{
+	{
+		Code:             BankCBE,
+		Name:             "Commercial Bank of Ethiopia",
+		NameAm:           "የኢትዮጵያ ንግድ ባንክ",
+		SWIFT:            "CBETETAA",
+		ReferenceFormat:  "4-digit branch code, optional - or /, then 4-20 letters or digits (e.g. 1000-INV2024001)",
+		referencePattern: regexp.MustCompile(`^(?i)\d{4}[-/]?[A-Z0-9]{4,20}$`),
+	},
+	{
+		Code:             BankAwash,
+		Name:             "Awash Bank",
+		NameAm:           "አዋሽ ባንክ",
+		SWIFT:            "AWINETAA",
+		ReferenceFormat:  "6-25 letters or digits, no separators",
+		referencePattern: regexp.MustCompile(`^(?i)[A-Z0-9]{6,25}$`),
+	},
+	{
+		Code:             BankDashen,
+		Name:             "Dashen Bank",
+		NameAm:           "ዳሽን ባንክ",
+		SWIFT:            "DASHETAA",
+		ReferenceFormat:  "5-30 letters, digits or -",
+		referencePattern: regexp.MustCompile(`^(?i)[A-Z0-9-]{5,30}$`),
+	},
+	{
+		Code:             BankAbyssinia,
+		Name:             "Bank of Abyssinia",
+		NameAm:           "አቢሲኒያ ባንክ",
+		SWIFT:            "ABYSETAA",
+		ReferenceFormat:  "5-16 letters or digits, no separators",
+		referencePattern: regexp.MustCompile(`^(?i)[A-Z0-9]{5,16}$`),
+	},
+	{
+		Code:             BankNib,
+		Name:             "Nib International Bank",
+		NameAm:           "ንብ ኢንተርናሽናል ባንክ",
+		SWIFT:            "NIBIETAA",
+		ReferenceFormat:  "5-20 letters, digits, - or /",
+		referencePattern: regexp.MustCompile(`^(?i)[A-Z0-9/-]{5,20}$`),
+	},
+	{
+		Code:             BankUnited,
+		Name:             "United Bank",
+		NameAm:           "ሕብረት ባንክ",
+		SWIFT:            "UBNIETAA",
+		ReferenceFormat:  "5-35 letters, digits or -",
+		referencePattern: regexp.MustCompile(`^(?i)[A-Z0-9-]{5,35}$`),
+	},
+}
+
+// Banks returns the bank registry
+func Banks() []Bank {
+	return banks
+}
+
+// LookupBank finds a bank by code, case-insensitively
+func LookupBank(code string) (Bank, bool) {
+	for _, b := range banks {
+		if strings.EqualFold(string(b.Code), code) {
+			return b, true
+		}
+	}
+	return Bank{}, false
+}
+
+// Gateway-generated references (see GenerateReference) are registered with
+// every bank, so they are accepted regardless of the bank's own pattern.
+var generatedReferencePattern = regexp.MustCompile(`^[A-Z]+(-[A-Z]+)?-\d{8}-[0-9A-Z]{9}$`)
+
+func IsGeneratedReference(reference string) bool {
+	return generatedReferencePattern.MatchString(reference) && ValidReferenceChecksum(reference)
+}
+
+// ValidateReference rejects references the bank would bounce at settlement
+func (b Bank) ValidateReference(reference string) error {
+	if IsGeneratedReference(reference) || b.referencePattern.MatchString(reference) {
+		return nil
+	}
+	return fmt.Errorf("reference is not accepted by %s: expected %s", b.Name, b.ReferenceFormat)
+}
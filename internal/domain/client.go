@@ -0,0 +1,13 @@
+package domain
+
+import "errors"
+
+// ClientInfo identifies where a create-payment request came from. The API
+// layer fills it in; it is not part of the request body.
+type ClientInfo struct {
+	IP                string
+	Country           string // ISO 3166 alpha-2, empty when unknown
+	DeviceFingerprint string // From the merchant checkout's X-Device-Fingerprint header
+}
+
+var ErrClientBlocked = errors.New("request blocked by client controls")
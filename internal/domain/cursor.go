@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentCursor marks the last payment of a page; the next page starts
+// after it in (created_at, id) order, newest first. Unlike an offset it
+// stays put while new payments are created.
+type PaymentCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the opaque next_cursor clients pass back as ?cursor=
+func (c PaymentCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParsePaymentCursor decodes a cursor returned by Encode
+func ParsePaymentCursor(s string) (*PaymentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &PaymentCursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// PaymentPage is one page of a payment listing
+type PaymentPage struct {
+	Payments   []*Payment
+	Total      int // Payments on all pages; searches leave it 0
+	HasMore    bool
+	NextCursor string // Empty on the last page and for orders a cursor cannot page
+}
+
+var ErrInvalidCursor = errors.New("cursor is not one returned by a previous page")
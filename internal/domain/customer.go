@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CustomerVerificationStatus string
+
+const (
+	CustomerPending  CustomerVerificationStatus = "PENDING" // Registered, documents not yet checked
+	CustomerVerified CustomerVerificationStatus = "VERIFIED"
+	CustomerRejected CustomerVerificationStatus = "REJECTED"
+)
+
+func (s CustomerVerificationStatus) IsValid() bool {
+	return s == CustomerPending || s == CustomerVerified || s == CustomerRejected
+}
+
+// Customer is a KYC profile: who a payer is, by the identity document a
+// merchant collected, and whether an operator has verified it. Customers
+// belong to the merchant that registered them.
+type Customer struct {
+	ID                 uuid.UUID                  `json:"id"`
+	FullName           string                     `json:"full_name"`
+	NationalID         string                     `json:"national_id,omitempty"`     // Fayda FIN or FAN
+	PassportNumber     string                     `json:"passport_number,omitempty"` // Foreign nationals and Ethiopians without a Fayda ID
+	Phone              string                     `json:"phone"`
+	Email              string                     `json:"email,omitempty"`
+	VerificationStatus CustomerVerificationStatus `json:"verification_status"`
+	VerificationNote   string                     `json:"verification_note,omitempty"`
+	VerifiedBy         string                     `json:"verified_by,omitempty"`
+	VerifiedAt         *time.Time                 `json:"verified_at,omitempty"` // When the status was last decided
+	MerchantID         *uuid.UUID                 `json:"merchant_id,omitempty"`
+	CreatedAt          time.Time                  `json:"created_at"`
+	UpdatedAt          time.Time                  `json:"updated_at"`
+}
+
+type CreateCustomerRequest struct {
+	FullName       string `json:"full_name" validate:"required,min=2,max=255"`
+	NationalID     string `json:"national_id,omitempty" validate:"max=19"`     // Fayda FIN or FAN
+	PassportNumber string `json:"passport_number,omitempty" validate:"max=20"` // Required without national_id
+	Phone          string `json:"phone" validate:"required,max=20"`
+	Email          string `json:"email,omitempty" validate:"omitempty,email,max=254"`
+}
+
+// Validate checks the request and normalizes the phone and documents
+func (r *CreateCustomerRequest) Validate() error {
+	r.FullName = strings.Join(strings.Fields(r.FullName), " ")
+	if len(r.FullName) < 2 {
+		return errors.New("full_name is required")
+	}
+	if len(r.FullName) > 255 {
+		return errors.New("full_name is too long")
+	}
+
+	phone, err := NormalizePhone(r.Phone)
+	if err != nil {
+		return errors.New("phone: " + err.Error())
+	}
+	r.Phone = phone
+
+	r.NationalID = strings.TrimSpace(r.NationalID)
+	r.PassportNumber = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(r.PassportNumber), " ", ""))
+	if r.NationalID == "" && r.PassportNumber == "" {
+		return errors.New("national_id or passport_number is required")
+	}
+
+	if r.NationalID != "" {
+		id, err := NormalizeNationalID(r.NationalID)
+		if err != nil {
+			return errors.New("national_id: " + err.Error())
+		}
+		r.NationalID = id
+	}
+
+	if r.PassportNumber != "" {
+		if len(r.PassportNumber) < 6 || len(r.PassportNumber) > 20 {
+			return errors.New("passport_number must be 6 to 20 characters")
+		}
+		for _, c := range r.PassportNumber {
+			if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+				return errors.New("passport_number must contain only letters and digits")
+			}
+		}
+	}
+
+	r.Email = strings.TrimSpace(r.Email)
+	if len(r.Email) > 254 {
+		return errors.New("email is too long")
+	}
+
+	return nil
+}
+
+type VerifyCustomerRequest struct {
+	Decision CustomerVerificationStatus `json:"decision" validate:"required,oneof=VERIFIED REJECTED"`
+	Note     string                     `json:"note" validate:"required,min=10,max=500"` // e.g. which document was checked and how
+}
+
+func (r *VerifyCustomerRequest) Validate() error {
+	if r.Decision != CustomerVerified && r.Decision != CustomerRejected {
+		return errors.New("decision must be VERIFIED or REJECTED")
+	}
+
+	r.Note = strings.TrimSpace(r.Note)
+	if len(r.Note) < 10 {
+		return errors.New("note must be at least 10 characters")
+	}
+	if len(r.Note) > 500 {
+		return errors.New("note is too long")
+	}
+
+	return nil
+}
+
+// CustomerPaymentSummary aggregates every payment of a customer. Amounts
+// paid count successful payments only; ETB values use the settled ETB
+// amount of foreign currency payments, or today's rate without one.
+type CustomerPaymentSummary struct {
+	Payments       int                 `json:"payments"`
+	Successful     int                 `json:"successful"`
+	Failed         int                 `json:"failed"`      // FAILED, CANCELLED and EXPIRED
+	InProgress     int                 `json:"in_progress"` // Not yet successful or failed
+	PaidETB        Amount              `json:"paid_etb"`
+	AveragePaidETB Amount              `json:"average_paid_etb"` // Per successful payment, rounded to the santim
+	Paid           map[Currency]Amount `json:"paid"`             // By payment currency
+	Refunded       map[Currency]Amount `json:"refunded"`         // Refunds that have not failed, by currency
+	FirstPaymentAt *time.Time          `json:"first_payment_at,omitempty"`
+	LastPaymentAt  *time.Time          `json:"last_payment_at,omitempty"`
+}
+
+// CustomerPaymentHistory is a page of a customer's payments, newest first,
+// with aggregates over all of them
+type CustomerPaymentHistory struct {
+	Customer *Customer              `json:"customer"`
+	Summary  CustomerPaymentSummary `json:"summary"`
+	Payments []PaymentResponse      `json:"payments"`
+	Total    int                    `json:"total"`
+	Page     int                    `json:"page"`
+	Limit    int                    `json:"limit"`
+	HasMore  bool                   `json:"has_more"`
+}
+
+var (
+	ErrCustomerNotFound      = errors.New("customer not found")
+	ErrCustomerAlreadyExists = errors.New("customer with this phone or identity document already exists")
+	// Payments above the KYC threshold must name a VERIFIED customer
+	ErrCustomerNotVerified = errors.New("payment requires a verified customer")
+)
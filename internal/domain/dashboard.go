@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Dashboard is the merchant portal home screen for one Ethiopian business day
+type Dashboard struct {
+	BusinessDate   string              `json:"business_date"`
+	Volume         []DashboardVolume   `json:"volume"` // Per currency
+	TotalCount     int                 `json:"total_count"`
+	SuccessCount   int                 `json:"success_count"`
+	FailedCount    int                 `json:"failed_count"`
+	PendingCount   int                 `json:"pending_count"` // Not yet SUCCESS or FAILED
+	SuccessRate    float64             `json:"success_rate"`  // Share of finished payments that succeeded, 0-1
+	RecentFailures []PaymentResponse   `json:"recent_failures"`
+	Settlement     DashboardSettlement `json:"settlement"`
+	GeneratedAt    time.Time           `json:"generated_at"`
+}
+
+// DashboardVolume totals one currency's payments for the day
+type DashboardVolume struct {
+	Currency      Currency `json:"currency"`
+	Count         int      `json:"count"`
+	Amount        Amount   `json:"amount"`         // All payments created today
+	SuccessAmount Amount   `json:"success_amount"` // Payments that succeeded
+}
+
+// DashboardSettlement reports whether today's bank statements are in
+type DashboardSettlement struct {
+	StatementsToday int             `json:"statements_today"`
+	LastStatement   *SettlementFile `json:"last_statement,omitempty"`
+}
+
+// DashboardCounts are the per-status, per-currency aggregates a dashboard
+// is built from
+type DashboardCounts struct {
+	Currency Currency
+	Status   PaymentStatus
+	Count    int
+	Amount   Amount
+}
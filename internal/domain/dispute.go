@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeStatus follows a chargeback from the bank's claim to its decision
+type DisputeStatus string
+
+const (
+	DisputeOpen              DisputeStatus = "OPEN"               // Raised by the customer's bank, waiting for the merchant
+	DisputeEvidenceSubmitted DisputeStatus = "EVIDENCE_SUBMITTED" // Merchant has answered, waiting for the bank
+	DisputeWon               DisputeStatus = "WON"                // Decided for the merchant
+	DisputeLost              DisputeStatus = "LOST"               // Decided for the customer; the amount is charged back
+)
+
+func (s DisputeStatus) IsTerminal() bool {
+	return s == DisputeWon || s == DisputeLost
+}
+
+// Dispute is a customer's claim, through their bank, against a successful
+// payment. Operators open and resolve disputes as the bank reports them;
+// the merchant answers with evidence in between.
+type Dispute struct {
+	ID            uuid.UUID     `json:"id"`
+	PaymentID     uuid.UUID     `json:"payment_id"`
+	Amount        Amount        `json:"amount"`
+	Currency      Currency      `json:"currency"`
+	Reason        string        `json:"reason"`
+	BankReference string        `json:"bank_reference,omitempty"` // The bank's case number
+	Status        DisputeStatus `json:"status"`
+	Evidence      string        `json:"evidence,omitempty"`
+	Resolution    string        `json:"resolution,omitempty"`
+	OpenedBy      string        `json:"opened_by"`
+	ResolvedBy    string        `json:"resolved_by,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	ResolvedAt    *time.Time    `json:"resolved_at,omitempty"`
+}
+
+type OpenDisputeRequest struct {
+	Amount        Amount `json:"amount,omitempty"` // Omit to dispute the whole payment
+	Reason        string `json:"reason" validate:"required,max=200"`
+	BankReference string `json:"bank_reference,omitempty" validate:"max=100"`
+}
+
+func (r *OpenDisputeRequest) Validate() error {
+	if r.Amount < 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if len(r.Reason) > 200 {
+		return errors.New("reason is too long")
+	}
+
+	r.BankReference = strings.TrimSpace(r.BankReference)
+	if len(r.BankReference) > 100 {
+		return errors.New("bank_reference is too long")
+	}
+
+	return nil
+}
+
+type SubmitDisputeEvidenceRequest struct {
+	Evidence string `json:"evidence" validate:"required,max=5000"` // Delivery proof, correspondence, receipts
+}
+
+func (r *SubmitDisputeEvidenceRequest) Validate() error {
+	r.Evidence = strings.TrimSpace(r.Evidence)
+	if r.Evidence == "" {
+		return errors.New("evidence is required")
+	}
+	if len(r.Evidence) > 5000 {
+		return errors.New("evidence is too long")
+	}
+
+	return nil
+}
+
+type ResolveDisputeRequest struct {
+	Outcome    DisputeStatus `json:"outcome" validate:"required,oneof=WON LOST"`
+	Resolution string        `json:"resolution" validate:"required,max=500"` // The bank's decision
+}
+
+func (r *ResolveDisputeRequest) Validate() error {
+	r.Outcome = DisputeStatus(strings.ToUpper(strings.TrimSpace(string(r.Outcome))))
+	if !r.Outcome.IsTerminal() {
+		return errors.New("outcome must be WON or LOST")
+	}
+
+	r.Resolution = strings.TrimSpace(r.Resolution)
+	if r.Resolution == "" {
+		return errors.New("resolution is required")
+	}
+	if len(r.Resolution) > 500 {
+		return errors.New("resolution is too long")
+	}
+
+	return nil
+}
+
+var (
+	ErrDisputeNotFound       = errors.New("dispute not found")
+	ErrDisputeExists         = errors.New("payment already has an open dispute")
+	ErrDisputeClosed         = errors.New("dispute does not accept this change in its current status")
+	ErrPaymentNotDisputable  = errors.New("only successful payments can be disputed")
+	ErrDisputeExceedsPayment = errors.New("dispute amount exceeds the payment")
+)
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EthiopianDate is a date in the Ethiopian (Ge'ez) calendar: twelve 30-day
+// months plus Pagume, which has 5 days, or 6 in a leap year.
+type EthiopianDate struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+var ethiopianMonthsAmharic = [13]string{
+	"መስከረም", "ጥቅምት", "ኅዳር", "ታኅሣሥ", "ጥር", "የካቲት",
+	"መጋቢት", "ሚያዝያ", "ግንቦት", "ሰኔ", "ሐምሌ", "ነሐሴ", "ጳጉሜ",
+}
+
+var ethiopianMonthsEnglish = [13]string{
+	"Meskerem", "Tikimt", "Hidar", "Tahsas", "Tir", "Yekatit",
+	"Megabit", "Miazia", "Genbot", "Sene", "Hamle", "Nehase", "Pagume",
+}
+
+// Julian day number of 1 Meskerem 1 (Amete Mihret era), minus one
+const ethiopianEpochJDN = 1723856
+
+// ToEthiopianDate converts the calendar day of t (as given, no zone
+// conversion) to the Ethiopian calendar.
+func ToEthiopianDate(t time.Time) EthiopianDate {
+	jdn := gregorianToJDN(t.Year(), int(t.Month()), t.Day())
+
+	r := (jdn - ethiopianEpochJDN) % 1461
+	n := r%365 + 365*(r/1460)
+
+	return EthiopianDate{
+		Year:  4*((jdn-ethiopianEpochJDN)/1461) + r/365 - r/1460,
+		Month: n/30 + 1,
+		Day:   n%30 + 1,
+	}
+}
+
+func gregorianToJDN(year, month, day int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	return day + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+}
+
+func (d EthiopianDate) MonthName(language Language) string {
+	if d.Month < 1 || d.Month > 13 {
+		return ""
+	}
+	if language == LanguageEnglish {
+		return ethiopianMonthsEnglish[d.Month-1]
+	}
+	return ethiopianMonthsAmharic[d.Month-1]
+}
+
+// Format renders the date the way it is written in each language,
+// e.g. "መስከረም 1 ቀን 2016 ዓ.ም." or "Meskerem 1, 2016 E.C."
+func (d EthiopianDate) Format(language Language) string {
+	if language == LanguageEnglish {
+		return fmt.Sprintf("%s %d, %d E.C.", d.MonthName(language), d.Day, d.Year)
+	}
+	return fmt.Sprintf("%s %d ቀን %d ዓ.ም.", d.MonthName(language), d.Day, d.Year)
+}
+
+// FormatGeez is Format in Amharic with Ge'ez numerals,
+// e.g. "ታኅሣሥ ፲፭ ቀን ፳፻፲፮ ዓ.ም."
+func (d EthiopianDate) FormatGeez() string {
+	return fmt.Sprintf("%s %s ቀን %s ዓ.ም.", d.MonthName(LanguageAmharic), GeezNumeral(d.Day), GeezNumeral(d.Year))
+}
+
+// Numeric is the day-first form used in Ethiopian bookkeeping,
+// e.g. "15-04-2016 EC"
+func (d EthiopianDate) Numeric() string {
+	return fmt.Sprintf("%02d-%02d-%04d EC", d.Day, d.Month, d.Year)
+}
+
+// GeezNumeral writes n in Ge'ez numerals, which have no zero and no place
+// value: digits pair up in hundreds, separated by ፻ (100) and ፼ (10,000),
+// and a leading one before either is dropped (100 is ፻, not ፩፻).
+func GeezNumeral(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+
+	var groups []int
+	for ; n > 0; n /= 100 {
+		groups = append(groups, n%100)
+	}
+
+	var b strings.Builder
+	for k := len(groups) - 1; k >= 0; k-- {
+		v := groups[k]
+		if v != 0 && !(v == 1 && k > 0) {
+			if tens := v / 10; tens > 0 {
+				b.WriteRune(rune(0x1372 + tens - 1)) // ፲ to ፺
+			}
+			if ones := v % 10; ones > 0 {
+				b.WriteRune(rune(0x1369 + ones - 1)) // ፩ to ፱
+			}
+		}
+		switch {
+		case k%2 == 1 && v != 0:
+			b.WriteRune('፻')
+		case k%2 == 0 && k > 0:
+			b.WriteRune('፼')
+		}
+	}
+	return b.String()
+}
+
+func (d EthiopianDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FraudRuleKind is the condition a fraud rule tests
+type FraudRuleKind string
+
+const (
+	// Payment's ETB value is at least AmountETB
+	FraudAmountThreshold FraudRuleKind = "AMOUNT_THRESHOLD"
+	// Customer has no successful payment yet and pays at least AmountETB
+	FraudNewCustomerAmount FraudRuleKind = "NEW_CUSTOMER_AMOUNT"
+	// Customer had at least Failures failed payments in the last WindowMinutes
+	FraudRepeatedFailures FraudRuleKind = "REPEATED_FAILURES"
+	// Payment is made between StartHour and EndHour Ethiopian time, and is
+	// worth at least AmountETB when set
+	FraudUnusualHour FraudRuleKind = "UNUSUAL_HOUR"
+)
+
+type FraudAction string
+
+const (
+	FraudAllow FraudAction = "ALLOW" // Accept without checking later rules
+	FraudFlag  FraudAction = "FLAG"  // Accept, and record the hit for investigation
+	FraudBlock FraudAction = "BLOCK" // Reject the payment
+)
+
+// FraudRule is one rule of the fraud engine. Enabled rules are tried in
+// priority order, lowest first, and the first that matches decides; a
+// payment no rule matches is allowed.
+type FraudRule struct {
+	ID            uuid.UUID     `json:"id"`
+	Name          string        `json:"name"`
+	Kind          FraudRuleKind `json:"kind"`
+	Action        FraudAction   `json:"action"`
+	Priority      int           `json:"priority"`
+	Enabled       bool          `json:"enabled"`
+	AmountETB     Amount        `json:"amount_etb,omitempty"`
+	Failures      int           `json:"failures,omitempty"`
+	WindowMinutes int           `json:"window_minutes,omitempty"`
+	StartHour     int           `json:"start_hour"` // 0-23; a window past midnight has StartHour > EndHour
+	EndHour       int           `json:"end_hour"`   // Exclusive
+	CreatedBy     string        `json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// FraudRuleRequest creates a rule, or replaces one's settings
+type FraudRuleRequest struct {
+	Name          string        `json:"name" validate:"required,max=100"`
+	Kind          FraudRuleKind `json:"kind" validate:"required"`
+	Action        FraudAction   `json:"action" validate:"required,oneof=ALLOW FLAG BLOCK"`
+	Priority      int           `json:"priority"`
+	Enabled       *bool         `json:"enabled,omitempty"` // Defaults to true
+	AmountETB     Amount        `json:"amount_etb,omitempty"`
+	Failures      int           `json:"failures,omitempty"`
+	WindowMinutes int           `json:"window_minutes,omitempty"`
+	StartHour     int           `json:"start_hour"`
+	EndHour       int           `json:"end_hour"`
+}
+
+func (r *FraudRuleRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > 100 {
+		return errors.New("name is too long")
+	}
+	r.Kind = FraudRuleKind(strings.ToUpper(string(r.Kind)))
+	r.Action = FraudAction(strings.ToUpper(string(r.Action)))
+	if r.Action != FraudAllow && r.Action != FraudFlag && r.Action != FraudBlock {
+		return errors.New("action must be ALLOW, FLAG or BLOCK")
+	}
+	if r.AmountETB < 0 {
+		return errors.New("amount_etb must not be negative")
+	}
+
+	switch r.Kind {
+	case FraudAmountThreshold, FraudNewCustomerAmount:
+		if r.AmountETB <= 0 {
+			return fmt.Errorf("%s rules need an amount_etb", r.Kind)
+		}
+	case FraudRepeatedFailures:
+		if r.Failures < 1 {
+			return errors.New("REPEATED_FAILURES rules need failures of at least 1")
+		}
+		if r.WindowMinutes < 1 || r.WindowMinutes > 30*24*60 {
+			return errors.New("window_minutes must be between 1 and 43200")
+		}
+	case FraudUnusualHour:
+		if r.StartHour < 0 || r.StartHour > 23 || r.EndHour < 0 || r.EndHour > 23 {
+			return errors.New("start_hour and end_hour must be between 0 and 23")
+		}
+		if r.StartHour == r.EndHour {
+			return errors.New("start_hour and end_hour must differ")
+		}
+	default:
+		return errors.New("kind must be AMOUNT_THRESHOLD, NEW_CUSTOMER_AMOUNT, REPEATED_FAILURES or UNUSUAL_HOUR")
+	}
+	return nil
+}
+
+// FraudCheck is what the rules are evaluated against
+type FraudCheck struct {
+	AmountETB      Amount
+	NewCustomer    bool        // No successful payment by the customer's phone or national ID
+	RecentFailures map[int]int // Customer's failed payments in each rule's window, by WindowMinutes
+	Hour           int         // Ethiopian local hour, 0-23
+}
+
+// Matches reports whether the rule's condition holds for the check
+func (r *FraudRule) Matches(check FraudCheck) bool {
+	switch r.Kind {
+	case FraudAmountThreshold:
+		return check.AmountETB >= r.AmountETB
+	case FraudNewCustomerAmount:
+		return check.NewCustomer && check.AmountETB >= r.AmountETB
+	case FraudRepeatedFailures:
+		return check.RecentFailures[r.WindowMinutes] >= r.Failures
+	case FraudUnusualHour:
+		if check.AmountETB < r.AmountETB {
+			return false
+		}
+		if r.StartHour < r.EndHour {
+			return check.Hour >= r.StartHour && check.Hour < r.EndHour
+		}
+		return check.Hour >= r.StartHour || check.Hour < r.EndHour
+	}
+	return false
+}
+
+// FraudHit records a FLAG or BLOCK decision. PaymentID is nil for blocked
+// payments, which are never created; hits outlive their rule.
+type FraudHit struct {
+	ID            uuid.UUID   `json:"id"`
+	RuleID        *uuid.UUID  `json:"rule_id,omitempty"` // Nil once the rule is deleted
+	RuleName      string      `json:"rule_name"`
+	Action        FraudAction `json:"action"`
+	PaymentID     *uuid.UUID  `json:"payment_id,omitempty"`
+	Reference     string      `json:"reference,omitempty"`
+	Amount        Amount      `json:"amount"`
+	Currency      Currency    `json:"currency"`
+	AmountETB     Amount      `json:"amount_etb"`
+	CustomerPhone string      `json:"customer_phone,omitempty"`
+	MerchantID    *uuid.UUID  `json:"merchant_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+var (
+	ErrFraudRuleNotFound = errors.New("fraud rule not found")
+	// Deliberately vague; the rule that matched is only in the hit log
+	ErrPaymentDeclined = errors.New("payment declined by risk checks")
+)
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FXPair names the conversion of a foreign currency into ETB, e.g. "EUR/ETB"
+func FXPair(currency Currency) string {
+	return string(currency) + "/ETB"
+}
+
+// CurrencyOfPair is the foreign side of an FX pair
+func CurrencyOfPair(pair string) Currency {
+	return Currency(strings.TrimSuffix(pair, "/ETB"))
+}
+
+// FXRate is a daily mid rate into ETB set by treasury
+type FXRate struct {
+	ID            *uuid.UUID `json:"id,omitempty"` // Empty for the configured fallback
+	Pair          string     `json:"pair"`
+	Currency      Currency   `json:"currency"`
+	Rate          float64    `json:"rate"`                     // ETB per unit of currency
+	EffectiveDate string     `json:"effective_date,omitempty"` // Ethiopian business day; empty for the configured fallback
+	SetBy         string     `json:"set_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// FXRateFilter selects rate history by currency and effective date
+type FXRateFilter struct {
+	Currency Currency
+	From     string // YYYY-MM-DD, inclusive
+	To       string // YYYY-MM-DD, inclusive
+}
+
+// Validate normalizes the currency in place
+func (f *FXRateFilter) Validate() error {
+	if f.Currency != "" {
+		f.Currency = Currency(strings.ToUpper(string(f.Currency)))
+		if !f.Currency.IsForeign() {
+			return errors.New("currency must be one of USD, EUR, GBP, AED or CNY")
+		}
+	}
+
+	var from, to time.Time
+	var err error
+	if f.From != "" {
+		if from, err = time.Parse("2006-01-02", f.From); err != nil {
+			return errors.New("from must be a date in YYYY-MM-DD format")
+		}
+	}
+	if f.To != "" {
+		if to, err = time.Parse("2006-01-02", f.To); err != nil {
+			return errors.New("to must be a date in YYYY-MM-DD format")
+		}
+	}
+	if f.From != "" && f.To != "" && to.Before(from) {
+		return errors.New("to must not be before from")
+	}
+	return nil
+}
+
+type SetFXRateRequest struct {
+	Currency Currency `json:"currency,omitempty" validate:"omitempty,oneof=USD EUR GBP AED CNY"` // Defaults to USD
+	Rate     float64  `json:"rate" validate:"required,gt=0"`
+}
+
+func (r *SetFXRateRequest) Validate() error {
+	if r.Currency == "" {
+		r.Currency = CurrencyUSD
+	}
+	if !r.Currency.IsForeign() {
+		return errors.New("currency must be one of USD, EUR, GBP, AED or CNY")
+	}
+	if r.Rate <= 0 || math.IsInf(r.Rate, 0) || math.IsNaN(r.Rate) {
+		return errors.New("rate must be greater than zero")
+	}
+	return nil
+}
+
+type FXQuoteRequest struct {
+	Currency Currency `json:"currency" validate:"required,oneof=USD EUR GBP AED CNY"` // Currency the payment will be made in
+}
+
+// FXQuote locks a conversion rate for one payment until it expires
+type FXQuote struct {
+	ID        uuid.UUID  `json:"id"`
+	Pair      string     `json:"pair"`
+	Currency  Currency   `json:"currency"`
+	Rate      float64    `json:"rate"`              // Locked ETB per unit of currency, after spread
+	MidRate   float64    `json:"mid_rate"`          // Daily rate the quote was priced from
+	RateID    *uuid.UUID `json:"rate_id,omitempty"` // History entry of the mid rate; empty for the configured fallback
+	SpreadBps int        `json:"spread_bps"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	PaymentID *uuid.UUID `json:"payment_id,omitempty"` // Set once a payment uses the quote
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CheckUsable reports why the quote cannot back a new payment
+func (q *FXQuote) CheckUsable(currency Currency, now time.Time) error {
+	switch {
+	case q.PaymentID != nil:
+		return ErrFXQuoteUsed
+	case !now.Before(q.ExpiresAt):
+		return ErrFXQuoteExpired
+	case currency != q.Currency:
+		return ErrFXQuoteCurrency
+	}
+	return nil
+}
+
+// FXConversion is an indicative price in ETB at the rate a quote would lock
+// now; nothing is held, so a payment needs a quote to be sure of the rate
+type FXConversion struct {
+	Pair      string     `json:"pair"`
+	Currency  Currency   `json:"currency"`
+	Amount    Amount     `json:"amount"`
+	Rate      float64    `json:"rate"`     // ETB per unit of currency, after spread
+	MidRate   float64    `json:"mid_rate"` // Daily rate the conversion was priced from
+	RateID    *uuid.UUID `json:"rate_id,omitempty"`
+	SpreadBps int        `json:"spread_bps"`
+	AmountETB Amount     `json:"amount_etb"`
+}
+
+// ConvertToETB rounds to the santim
+func ConvertToETB(amount Amount, rate float64) Amount {
+	return amount.Mul(rate)
+}
+
+var (
+	ErrFXQuoteNotFound = errors.New("FX quote not found")
+	ErrFXQuoteExpired  = errors.New("FX quote has expired")
+	ErrFXQuoteUsed     = errors.New("FX quote has already been used")
+	ErrFXQuoteCurrency = errors.New("FX quote does not match the payment currency")
+)
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/etime"
+
+	"github.com/google/uuid"
+)
+
+// StandardVATRate is Ethiopia's standard VAT rate, in percent
+const StandardVATRate = 15.0
+
+type InvoiceStatus string
+
+const (
+	InvoiceDraft InvoiceStatus = "DRAFT"
+	InvoiceSent  InvoiceStatus = "SENT" // Issued with a payment link; emailed or texted when the customer has an address
+	InvoicePaid  InvoiceStatus = "PAID" // Its linked successful payments cover the total
+)
+
+// Invoice bills a customer for line items with VAT. It is marked paid once
+// the successful payments linked to it, directly or through its payment
+// link, cover the total.
+type Invoice struct {
+	ID            uuid.UUID      `json:"id"`
+	Number        string         `json:"number"` // Sequential, e.g. INV-000042
+	Status        InvoiceStatus  `json:"status"`
+	CustomerName  string         `json:"customer_name"`
+	CustomerEmail string         `json:"customer_email,omitempty"`
+	CustomerPhone string         `json:"customer_phone,omitempty"`
+	Language      Language       `json:"language,omitempty"`
+	Currency      Currency       `json:"currency"`
+	Items         []*InvoiceItem `json:"items"`
+	Subtotal      Amount         `json:"subtotal"`   // Before VAT
+	VATAmount     Amount         `json:"vat_amount"` // Sum of the items' VAT
+	Total         Amount         `json:"total"`
+	DueDate       string         `json:"due_date"` // YYYY-MM-DD
+	Overdue       bool           `json:"overdue,omitempty"`
+	Notes         string         `json:"notes,omitempty"`
+	PaymentLinkID *uuid.UUID     `json:"payment_link_id,omitempty"` // Created when the invoice is first sent
+	PaymentURL    string         `json:"payment_url,omitempty"`
+	MerchantID    *uuid.UUID     `json:"merchant_id,omitempty"`
+	SentAt        *time.Time     `json:"sent_at,omitempty"`
+	PaidAt        *time.Time     `json:"paid_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+
+	// Payments linked to the invoice, newest first; set by GET only
+	PaymentIDs []uuid.UUID `json:"payment_ids,omitempty"`
+}
+
+type InvoiceItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   Amount  `json:"unit_price"`
+	VATRate     float64 `json:"vat_rate"`   // Percent; 0 for exempt items
+	Amount      Amount  `json:"amount"`     // Quantity x unit price, before VAT
+	VATAmount   Amount  `json:"vat_amount"` // Rounded per line
+}
+
+// IsOverdue reports whether an unpaid invoice is past its due date in
+// Ethiopian time
+func (i *Invoice) IsOverdue(now time.Time) bool {
+	return i.Status != InvoicePaid && etime.Date(now) > i.DueDate
+}
+
+type CreateInvoiceItem struct {
+	Description string   `json:"description"`
+	Quantity    float64  `json:"quantity"`
+	UnitPrice   Amount   `json:"unit_price"`         // At most two decimal places
+	VATRate     *float64 `json:"vat_rate,omitempty"` // Standard 15% when empty
+}
+
+type CreateInvoiceRequest struct {
+	CustomerName  string              `json:"customer_name"`
+	CustomerEmail string              `json:"customer_email,omitempty"`
+	CustomerPhone string              `json:"customer_phone,omitempty"`
+	Language      Language            `json:"language,omitempty"`
+	Currency      Currency            `json:"currency"`
+	Items         []CreateInvoiceItem `json:"items"`
+	DueDate       string              `json:"due_date"` // YYYY-MM-DD
+	Notes         string              `json:"notes,omitempty"`
+}
+
+// maxInvoiceItems bounds the line items on one invoice
+const maxInvoiceItems = 100
+
+func (r *CreateInvoiceRequest) Validate() error {
+	r.CustomerName = strings.TrimSpace(r.CustomerName)
+	if r.CustomerName == "" || len(r.CustomerName) > 100 {
+		return errors.New("customer_name is required and at most 100 characters")
+	}
+	r.CustomerEmail = strings.TrimSpace(r.CustomerEmail)
+	if r.CustomerEmail != "" {
+		if _, err := mail.ParseAddress(r.CustomerEmail); err != nil {
+			return errors.New("customer_email is not a valid address")
+		}
+	}
+	if r.CustomerPhone != "" {
+		if _, err := NormalizePhone(r.CustomerPhone); err != nil {
+			return err
+		}
+	}
+	if r.Language != "" && !r.Language.IsValid() {
+		return errors.New("language must be am or en")
+	}
+
+	if r.Currency == "" {
+		r.Currency = CurrencyETB
+	}
+	if !r.Currency.IsValid() {
+		return errors.New("currency must be one of ETB, USD, EUR, GBP, AED or CNY")
+	}
+
+	if len(r.Items) == 0 || len(r.Items) > maxInvoiceItems {
+		return fmt.Errorf("an invoice needs 1 to %d items", maxInvoiceItems)
+	}
+	for i, item := range r.Items {
+		if strings.TrimSpace(item.Description) == "" || len(item.Description) > 255 {
+			return fmt.Errorf("item %d: description is required and at most 255 characters", i+1)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("item %d: quantity must be greater than zero", i+1)
+		}
+		if item.UnitPrice <= 0 {
+			return fmt.Errorf("item %d: unit_price must be greater than zero", i+1)
+		}
+		if item.VATRate != nil && (*item.VATRate < 0 || *item.VATRate > 100) {
+			return fmt.Errorf("item %d: vat_rate must be between 0 and 100", i+1)
+		}
+	}
+
+	if _, err := time.Parse("2006-01-02", r.DueDate); err != nil {
+		return errors.New("due_date must be a date in YYYY-MM-DD format")
+	}
+	if len(r.Notes) > 1000 {
+		return errors.New("notes is too long")
+	}
+
+	return nil
+}
+
+// NewInvoice builds a draft invoice from a validated request, pricing each
+// line and rounding its VAT to the cent
+func NewInvoice(r CreateInvoiceRequest, merchantID *uuid.UUID, now time.Time) (*Invoice, error) {
+	invoice := &Invoice{
+		ID:            uuid.New(),
+		Status:        InvoiceDraft,
+		CustomerName:  r.CustomerName,
+		CustomerEmail: r.CustomerEmail,
+		CustomerPhone: r.CustomerPhone,
+		Language:      r.Language,
+		Currency:      r.Currency,
+		DueDate:       r.DueDate,
+		Notes:         strings.TrimSpace(r.Notes),
+		MerchantID:    merchantID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	for _, req := range r.Items {
+		item := &InvoiceItem{
+			Description: strings.TrimSpace(req.Description),
+			Quantity:    req.Quantity,
+			UnitPrice:   req.UnitPrice,
+			VATRate:     StandardVATRate,
+		}
+		if req.VATRate != nil {
+			item.VATRate = *req.VATRate
+		}
+		item.Amount = item.UnitPrice.Mul(item.Quantity)
+		item.VATAmount = item.Amount.Mul(item.VATRate / 100)
+
+		invoice.Items = append(invoice.Items, item)
+		invoice.Subtotal += item.Amount
+		invoice.VATAmount += item.VATAmount
+	}
+	invoice.Total = invoice.Subtotal + invoice.VATAmount
+
+	if invoice.Total > invoice.Currency.MaxAmount() {
+		return nil, fmt.Errorf("%w: at most %s per payment", ErrAmountTooLarge, NewMoney(invoice.Currency.MaxAmount(), invoice.Currency))
+	}
+
+	return invoice, nil
+}
+
+// FormatInvoiceNumber formats the invoice sequence number
+func FormatInvoiceNumber(seq int64) string {
+	return fmt.Sprintf("INV-%06d", seq)
+}
+
+// LinkInvoicePaymentRequest links an existing payment to an invoice
+type LinkInvoicePaymentRequest struct {
+	PaymentID uuid.UUID `json:"payment_id"`
+}
+
+var (
+	ErrInvoiceNotFound        = errors.New("invoice not found")
+	ErrInvoicePaid            = errors.New("invoice has already been paid")
+	ErrInvoiceNoRecipient     = errors.New("invoice has no customer email or phone to send it to")
+	ErrInvoicePaymentMismatch = errors.New("payment is not in the invoice's currency")
+)
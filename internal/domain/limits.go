@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// LimitFlag records which cumulative customer limit a payment went over
+type LimitFlag string
+
+const (
+	LimitFlagDaily   LimitFlag = "DAILY_LIMIT"
+	LimitFlagMonthly LimitFlag = "MONTHLY_LIMIT"
+)
+
+// LimitTier caps a customer's cumulative ETB volume, following the NBE
+// mobile-money account levels; zero means no limit
+type LimitTier struct {
+	Name    string
+	Daily   Amount
+	Monthly Amount
+}
+
+// CustomerUsage is the ETB volume a customer already has on the current
+// Ethiopian day and month
+type CustomerUsage struct {
+	DayETB   Amount
+	MonthETB Amount
+}
+
+// Check returns the limit amountETB would take the customer over, or ""
+func (t LimitTier) Check(usage CustomerUsage, amountETB Amount) LimitFlag {
+	if t.Daily > 0 && usage.DayETB+amountETB > t.Daily {
+		return LimitFlagDaily
+	}
+	if t.Monthly > 0 && usage.MonthETB+amountETB > t.Monthly {
+		return LimitFlagMonthly
+	}
+	return ""
+}
+
+// NormalizeNationalID accepts a Fayda FIN (12 digits) or FAN (16 digits),
+// with or without spaces and dashes
+func NormalizeNationalID(id string) (string, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(id)
+	if len(digits) != 12 && len(digits) != 16 {
+		return "", errors.New("national ID must be a 12-digit FIN or 16-digit FAN")
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", errors.New("national ID must contain only digits")
+		}
+	}
+	return digits, nil
+}
+
+var ErrCustomerLimitExceeded = errors.New("payment exceeds the customer's cumulative limit")
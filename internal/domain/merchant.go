@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// MerchantStatus controls whether a merchant may take payments and be
+// settled. New merchants wait in PENDING until an operator activates them.
+type MerchantStatus string
+
+const (
+	MerchantPending   MerchantStatus = "PENDING"
+	MerchantActive    MerchantStatus = "ACTIVE"
+	MerchantSuspended MerchantStatus = "SUSPENDED"
+)
+
+func (s MerchantStatus) IsValid() bool {
+	return s == MerchantPending || s == MerchantActive || s == MerchantSuspended
+}
+
+// Merchant is a business onboarded onto the gateway
+type Merchant struct {
+	ID           uuid.UUID      `json:"id"`
+	LegalName    string         `json:"legal_name"`
+	TradeName    string         `json:"trade_name,omitempty"`
+	TIN          string         `json:"tin"` // Ethiopian Taxpayer Identification Number
+	Email        string         `json:"email"`
+	Phone        string         `json:"phone"`
+	Region       string         `json:"region,omitempty"`
+	Status       MerchantStatus `json:"status"`
+	StatusReason string         `json:"status_reason,omitempty"` // Why it was last suspended or reactivated
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+
+	SettlementAccounts []*SettlementAccount `json:"settlement_accounts,omitempty"`
+}
+
+// SettlementAccountStatus tracks verification of a merchant's bank account
+type SettlementAccountStatus string
+
+const (
+	SettlementAccountPending  SettlementAccountStatus = "PENDING"
+	SettlementAccountVerified SettlementAccountStatus = "VERIFIED"
+	SettlementAccountRejected SettlementAccountStatus = "REJECTED"
+)
+
+// SettlementAccount is a bank account a merchant is settled to. Only a
+// verified account can be primary; settlements go to the primary account.
+type SettlementAccount struct {
+	ID            uuid.UUID               `json:"id"`
+	MerchantID    uuid.UUID               `json:"merchant_id"`
+	BankCode      string                  `json:"bank_code"`
+	AccountNumber string                  `json:"account_number"`
+	AccountName   string                  `json:"account_name"` // As declared by the merchant
+	Primary       bool                    `json:"primary"`
+	Status        SettlementAccountStatus `json:"status"`
+	VerifiedName  string                  `json:"verified_name,omitempty"` // Returned by the bank's name inquiry
+	StatusNote    string                  `json:"status_note,omitempty"`
+	VerifiedBy    string                  `json:"verified_by,omitempty"`
+	VerifiedAt    *time.Time              `json:"verified_at,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+var tinPattern = regexp.MustCompile(`^\d{10}$`)
+
+type RegisterMerchantRequest struct {
+	LegalName          string                     `json:"legal_name" validate:"required,max=150"`
+	TradeName          string                     `json:"trade_name,omitempty" validate:"max=150"`
+	TIN                string                     `json:"tin" validate:"required,len=10"`
+	Email              string                     `json:"email" validate:"required,email"`
+	Phone              string                     `json:"phone" validate:"required"`
+	Region             string                     `json:"region,omitempty" validate:"max=50"`
+	SettlementAccounts []SettlementAccountRequest `json:"settlement_accounts,omitempty"`
+}
+
+// Validate normalizes the request in place
+func (r *RegisterMerchantRequest) Validate() error {
+	r.LegalName = strings.TrimSpace(r.LegalName)
+	if r.LegalName == "" || len(r.LegalName) > 150 {
+		return errors.New("legal_name is required and at most 150 characters")
+	}
+
+	r.TradeName = strings.TrimSpace(r.TradeName)
+	if len(r.TradeName) > 150 {
+		return errors.New("trade_name is too long")
+	}
+
+	r.TIN = strings.TrimSpace(r.TIN)
+	if !tinPattern.MatchString(r.TIN) {
+		return errors.New("tin must be 10 digits")
+	}
+
+	if err := validateMerchantContact(&r.Email, &r.Phone, &r.Region); err != nil {
+		return err
+	}
+
+	if len(r.SettlementAccounts) > 5 {
+		return errors.New("at most 5 settlement accounts")
+	}
+	for i := range r.SettlementAccounts {
+		if err := r.SettlementAccounts[i].Validate(); err != nil {
+			return fmt.Errorf("settlement_accounts[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateMerchantRequest changes contact details or status; omitted fields
+// are kept. Status changes need a reason.
+type UpdateMerchantRequest struct {
+	TradeName *string         `json:"trade_name,omitempty"`
+	Email     *string         `json:"email,omitempty"`
+	Phone     *string         `json:"phone,omitempty"`
+	Region    *string         `json:"region,omitempty"`
+	Status    *MerchantStatus `json:"status,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// Apply validates the changes against the merchant and applies them.
+// Whether the merchant may be activated is checked by the caller.
+func (r *UpdateMerchantRequest) Apply(m *Merchant) error {
+	if r.TradeName != nil {
+		m.TradeName = strings.TrimSpace(*r.TradeName)
+		if len(m.TradeName) > 150 {
+			return errors.New("trade_name is too long")
+		}
+	}
+
+	email, phone, region := m.Email, m.Phone, m.Region
+	if r.Email != nil {
+		email = *r.Email
+	}
+	if r.Phone != nil {
+		phone = *r.Phone
+	}
+	if r.Region != nil {
+		region = *r.Region
+	}
+	if err := validateMerchantContact(&email, &phone, &region); err != nil {
+		return err
+	}
+	m.Email, m.Phone, m.Region = email, phone, region
+
+	if r.Status != nil && *r.Status != m.Status {
+		if *r.Status != MerchantActive && *r.Status != MerchantSuspended {
+			return errors.New("status must be ACTIVE or SUSPENDED")
+		}
+		r.Reason = strings.TrimSpace(r.Reason)
+		if r.Reason == "" || len(r.Reason) > 500 {
+			return errors.New("reason is required for a status change and at most 500 characters")
+		}
+		m.Status = *r.Status
+		m.StatusReason = r.Reason
+	}
+
+	return nil
+}
+
+func validateMerchantContact(email, phone, region *string) error {
+	*email = strings.ToLower(strings.TrimSpace(*email))
+	if addr, err := mail.ParseAddress(*email); err != nil || addr.Address != *email {
+		return errors.New("email is not a valid address")
+	}
+
+	normalized, err := NormalizePhone(*phone)
+	if err != nil {
+		return fmt.Errorf("phone: %w", err)
+	}
+	*phone = normalized
+
+	*region = strings.TrimSpace(*region)
+	if len(*region) > 50 {
+		return errors.New("region is too long")
+	}
+
+	return nil
+}
+
+// SettlementAccountRequest adds a settlement account or replaces its
+// details. Changed details have to be verified again.
+type SettlementAccountRequest struct {
+	BankCode      string `json:"bank_code" validate:"required"`
+	AccountNumber string `json:"account_number" validate:"required"`
+	AccountName   string `json:"account_name" validate:"required,max=150"`
+}
+
+// Validate normalizes the request in place. Merchants are settled to
+// bank accounts only, not wallets.
+func (r *SettlementAccountRequest) Validate() error {
+	bank, ok := LookupBank(strings.TrimSpace(r.BankCode))
+	if !ok {
+		return errors.New("bank_code is not a supported bank")
+	}
+
+	dest := AccountVerificationRequest{BankCode: string(bank.Code), AccountNumber: r.AccountNumber}
+	if err := dest.Validate(); err != nil {
+		return err
+	}
+	r.BankCode, r.AccountNumber = dest.BankCode, dest.AccountNumber
+
+	r.AccountName = strings.TrimSpace(r.AccountName)
+	if r.AccountName == "" || len(r.AccountName) > 150 {
+		return errors.New("account_name is required and at most 150 characters")
+	}
+
+	return nil
+}
+
+// VerifySettlementAccountRequest asks for a bank name inquiry. For banks
+// without name inquiry an operator verifies manually from documents (a
+// bank letter or cheque leaf), giving a note on what was checked.
+type VerifySettlementAccountRequest struct {
+	Manual bool   `json:"manual,omitempty"`
+	Reject bool   `json:"reject,omitempty"` // Only with manual
+	Note   string `json:"note,omitempty"`
+}
+
+func (r *VerifySettlementAccountRequest) Validate() error {
+	r.Note = strings.TrimSpace(r.Note)
+	if len(r.Note) > 500 {
+		return errors.New("note is too long")
+	}
+	if r.Reject && !r.Manual {
+		return errors.New("reject is only allowed with manual")
+	}
+	if r.Manual && r.Note == "" {
+		return errors.New("note is required for a manual verification")
+	}
+	return nil
+}
+
+// NamesMatch compares a declared account name with the bank's, ignoring
+// case, punctuation and word order
+func NamesMatch(declared, verified string) bool {
+	a, b := nameWords(declared), nameWords(verified)
+	return a != "" && a == b
+}
+
+func nameWords(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+var (
+	ErrMerchantNotFound          = errors.New("merchant not found")
+	ErrMerchantExists            = errors.New("a merchant with this TIN is already registered")
+	ErrMerchantNotActivatable    = errors.New("merchant needs a verified primary settlement account before activation")
+	ErrSettlementAccountNotFound = errors.New("settlement account not found")
+	ErrSettlementAccountExists   = errors.New("settlement account is already registered for this merchant")
+	ErrSettlementAccountNotReady = errors.New("settlement account is not verified")
+	ErrSettlementAccountPrimary  = errors.New("the primary settlement account of an active merchant cannot be changed; make another account primary first")
+)
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Every supported currency has two decimal places: santim for ETB, cents
+// for the rest
+const minorUnits = 100
+
+// Amount is a sum of money in minor units. Sums of Amounts are exact, where
+// float64 drifts by a santim here and there. It reads and writes as a
+// decimal with two places in JSON, SQL and templates, so clients, NUMERIC
+// columns and printf "%.2f" see the same numbers as before.
+type Amount int64
+
+// NewAmount rounds a major-unit value to the nearest minor unit
+func NewAmount(major float64) Amount {
+	return Amount(math.Round(major * minorUnits))
+}
+
+// ParseAmount reads a decimal such as "1250", "1250.5" or "-0.05" exactly.
+// More than two decimal places is an error rather than being rounded away.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := s
+	if negative || strings.HasPrefix(s, "+") {
+		digits = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidAmount
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > 2 {
+		return 0, ErrAmountPrecision
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if strings.ContainsAny(whole+frac, "+-") {
+		return 0, ErrInvalidAmount
+	}
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > math.MaxInt64/minorUnits-1 {
+		return 0, ErrInvalidAmount
+	}
+	minor, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	a := Amount(major*minorUnits + minor)
+	if negative {
+		a = -a
+	}
+	return a, nil
+}
+
+// Float64 is the amount in major units, for ratios and display only
+func (a Amount) Float64() float64 {
+	return float64(a) / minorUnits
+}
+
+// String is the amount with two decimal places, e.g. "1250.50"
+func (a Amount) String() string {
+	sign := ""
+	n := int64(a)
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/minorUnits, n%minorUnits)
+}
+
+// Mul multiplies the amount by a rate, e.g. an FX rate or a fee
+// percentage, rounding half away from zero to the minor unit
+func (a Amount) Mul(rate float64) Amount {
+	return Amount(math.Round(float64(a) * rate))
+}
+
+// Div divides the amount by a rate, rounding as Mul does
+func (a Amount) Div(rate float64) Amount {
+	return Amount(math.Round(float64(a) / rate))
+}
+
+// Format makes the amount print as a decimal with %v, %s and %f, so
+// templates written against float amounts keep working; %d prints the
+// minor units
+func (a Amount) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'd':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), int64(a))
+	case 'v', 's':
+		padAmount(f, a.String())
+	case 'f', 'F', 'g', 'G', 'e', 'E':
+		if prec, ok := f.Precision(); (verb == 'f' || verb == 'F') && (!ok || prec == 2) {
+			padAmount(f, a.String())
+			return
+		}
+		fmt.Fprintf(f, fmt.FormatString(f, verb), a.Float64())
+	default:
+		fmt.Fprintf(f, "%%!%c(domain.Amount=%s)", verb, a.String())
+	}
+}
+
+// padAmount writes s padded to the verb's width, left-aligned for %-
+func padAmount(f fmt.State, s string) {
+	if width, ok := f.Width(); ok && len(s) < width {
+		fill := strings.Repeat(" ", width-len(s))
+		if f.Flag('-') {
+			s += fill
+		} else {
+			s = fill + s
+		}
+	}
+	io.WriteString(f, s)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	} else if strings.ContainsAny(s, "eE") {
+		// Exponent notation from clients that print floats that way
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return ErrInvalidAmount
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// UnmarshalText reads query and form values
+func (a *Amount) UnmarshalText(text []byte) error {
+	parsed, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column, which pgx hands over as its decimal text
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case string:
+		parsed, err := ParseAmount(v)
+		if errors.Is(err, ErrAmountPrecision) {
+			// Computed columns such as AVG(amount) carry more places; round
+			// them as a NUMERIC(15,2) column would
+			f, ferr := strconv.ParseFloat(v, 64)
+			parsed, err = NewAmount(f), ferr
+		}
+		if err != nil {
+			return fmt.Errorf("scan amount %q: %w", v, err)
+		}
+		*a = parsed
+	case []byte:
+		return a.Scan(string(v))
+	case int64:
+		*a = Amount(v * minorUnits)
+	case float64:
+		*a = NewAmount(v)
+	default:
+		return fmt.Errorf("scan amount: unsupported type %T", src)
+	}
+	return nil
+}
+
+// Value writes the amount as decimal text for a NUMERIC column
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Money is an amount together with its currency
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoney pairs an amount with its currency
+func NewMoney(amount Amount, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// String is the amount followed by the currency code, e.g. "1250.50 ETB"
+func (m Money) String() string {
+	return m.Amount.String() + " " + string(m.Currency)
+}
+
+var (
+	ErrInvalidAmount   = errors.New("amount must be a decimal number")
+	ErrAmountPrecision = errors.New("amount must have at most two decimal places")
+)
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentNote is an internal comment left by support or finance staff
+// while investigating a payment. Unlike status overrides, notes are working
+// material: their author may edit or remove them.
+type PaymentNote struct {
+	ID        uuid.UUID  `json:"id"`
+	PaymentID uuid.UUID  `json:"payment_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+type PaymentNoteRequest struct {
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+func (r *PaymentNoteRequest) Validate() error {
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Body == "" {
+		return errors.New("body is required")
+	}
+	if len(r.Body) > 2000 {
+		return errors.New("body is too long")
+	}
+
+	return nil
+}
+
+var (
+	ErrNoteNotFound  = errors.New("note not found")
+	ErrNoteNotAuthor = errors.New("only the note's author can change it")
+)
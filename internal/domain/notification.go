@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification channels
+type NotificationChannel string
+
+const (
+	ChannelSMS      NotificationChannel = "SMS"
+	ChannelEmail    NotificationChannel = "EMAIL"
+	ChannelTelegram NotificationChannel = "TELEGRAM"
+	ChannelWebhook  NotificationChannel = "WEBHOOK"
+	ChannelPush     NotificationChannel = "PUSH" // FCM to merchant apps
+)
+
+// Notification delivery statuses
+type NotificationStatus string
+
+const (
+	NotificationQueued    NotificationStatus = "QUEUED"
+	NotificationSent      NotificationStatus = "SENT"
+	NotificationDelivered NotificationStatus = "DELIVERED"
+	NotificationFailed    NotificationStatus = "FAILED"
+)
+
+func (s NotificationStatus) IsValid() bool {
+	switch s {
+	case NotificationQueued, NotificationSent, NotificationDelivered, NotificationFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Notification is a single message sent to a customer about a payment
+type Notification struct {
+	ID                uuid.UUID           `json:"id"`
+	PaymentID         uuid.UUID           `json:"payment_id"`
+	Channel           NotificationChannel `json:"channel"`
+	Recipient         string              `json:"recipient"`
+	Message           string              `json:"message"`
+	Status            NotificationStatus  `json:"status"`
+	ProviderMessageID string              `json:"provider_message_id,omitempty"`
+	Error             string              `json:"error,omitempty"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+}
+
+// Delivery report pushed back by the SMS gateway
+type DeliveryReport struct {
+	MessageID string             `json:"message_id"`
+	Status    NotificationStatus `json:"status"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// TelegramChat is a merchant Telegram chat linked to the gateway bot.
+// A chat is linked when someone sends "/start <link_code>" to the bot.
+// An empty MerchantID is the gateway's own operators, who hear about every
+// payment; a merchant's chat only hears about its own.
+type TelegramChat struct {
+	ID         uuid.UUID  `json:"id"`
+	MerchantID string     `json:"merchant_id,omitempty"`
+	Label      string     `json:"label"`
+	LinkCode   string     `json:"link_code,omitempty"`
+	ChatID     int64      `json:"chat_id,omitempty"`
+	ChatTitle  string     `json:"chat_title,omitempty"`
+	LinkedAt   *time.Time `json:"linked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (c *TelegramChat) IsLinked() bool {
+	return c.LinkedAt != nil
+}
+
+// Notification errors
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrTelegramLinkNotFound = errors.New("telegram link code not found or already used")
+)
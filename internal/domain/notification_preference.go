@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Who a notification preference belongs to
+type RecipientType string
+
+const (
+	RecipientCustomer RecipientType = "CUSTOMER" // Identified by phone number (or email if no phone)
+	RecipientMerchant RecipientType = "MERCHANT" // Identified by merchant ID; empty is the gateway itself
+)
+
+// NotificationPreference turns one event on or off for one channel of a
+// recipient. Without a preference the gateway configuration decides.
+// Merchants receive no SMS, so a merchant's SMS preference switches the
+// SMS its customers get; a customer's own preference still wins.
+type NotificationPreference struct {
+	ID            uuid.UUID           `json:"id"`
+	RecipientType RecipientType       `json:"recipient_type"`
+	RecipientID   string              `json:"recipient_id"`
+	EventType     NotificationEvent   `json:"event_type"`
+	Channel       NotificationChannel `json:"channel"`
+	Enabled       bool                `json:"enabled"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+func (p *NotificationPreference) Validate() error {
+	switch p.RecipientType {
+	case RecipientCustomer:
+		if p.RecipientID == "" {
+			return errors.New("recipient_id is required for customers")
+		}
+	case RecipientMerchant:
+	default:
+		return errors.New("recipient_type must be CUSTOMER or MERCHANT")
+	}
+
+	if len(p.RecipientID) > 254 {
+		return errors.New("recipient_id is too long")
+	}
+
+	if !p.EventType.IsValid() {
+		return errors.New("unknown event type")
+	}
+
+	switch p.Channel {
+	case ChannelSMS, ChannelEmail, ChannelTelegram, ChannelWebhook, ChannelPush:
+	default:
+		return errors.New("channel must be SMS, EMAIL, TELEGRAM or WEBHOOK")
+	}
+
+	return nil
+}
+
+var ErrPreferenceNotFound = errors.New("notification preference not found")
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification event types
+type NotificationEvent string
+
+const (
+	EventPaymentSucceeded NotificationEvent = "payment.succeeded"
+	EventPaymentFailed    NotificationEvent = "payment.failed"
+	EventDailySummary     NotificationEvent = "daily_summary"
+	EventPaymentOTP       NotificationEvent = "payment.otp"
+	EventPaymentReminder  NotificationEvent = "payment.reminder"
+	EventRefundSucceeded  NotificationEvent = "refund.succeeded"
+	EventPaymentExpired   NotificationEvent = "payment.expired"
+	EventInvoiceSent      NotificationEvent = "invoice.sent"
+)
+
+func (e NotificationEvent) IsValid() bool {
+	switch e {
+	case EventPaymentSucceeded, EventPaymentFailed, EventDailySummary, EventPaymentOTP, EventPaymentReminder, EventRefundSucceeded, EventPaymentExpired, EventInvoiceSent:
+		return true
+	default:
+		return false
+	}
+}
+
+// Notification languages
+type Language string
+
+const (
+	LanguageAmharic Language = "am"
+	LanguageEnglish Language = "en"
+)
+
+func (l Language) IsValid() bool {
+	return l == LanguageAmharic || l == LanguageEnglish
+}
+
+// NotificationTemplate is the message content for one event, channel and
+// language. An empty MerchantID is the gateway default; a merchant row
+// overrides it.
+type NotificationTemplate struct {
+	ID         uuid.UUID           `json:"id"`
+	EventType  NotificationEvent   `json:"event_type"`
+	Channel    NotificationChannel `json:"channel"`
+	Language   Language            `json:"language"`
+	MerchantID string              `json:"merchant_id,omitempty"`
+	Subject    string              `json:"subject,omitempty"` // Email subject or push title
+	Body       string              `json:"body"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+func (t *NotificationTemplate) Validate() error {
+	if !t.EventType.IsValid() {
+		return errors.New("unknown event type")
+	}
+
+	switch t.Channel {
+	case ChannelSMS, ChannelEmail, ChannelTelegram, ChannelPush:
+	default:
+		return errors.New("unknown channel")
+	}
+
+	if !t.Language.IsValid() {
+		return errors.New("language must be am or en")
+	}
+
+	if t.Body == "" {
+		return errors.New("template body is required")
+	}
+
+	if t.Channel == ChannelEmail && t.Subject == "" {
+		return errors.New("email templates require a subject")
+	}
+
+	if t.Channel == ChannelPush && t.Subject == "" {
+		return errors.New("push templates require a subject (the notification title)")
+	}
+
+	return nil
+}
+
+var ErrTemplateNotFound = errors.New("notification template not found")
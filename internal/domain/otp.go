@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentOTP is the one-time code a customer must confirm before an
+// AWAITING_OTP payment is released for processing. Only a hash of the
+// code is stored.
+type PaymentOTP struct {
+	PaymentID  uuid.UUID  `json:"payment_id"`
+	CodeHash   string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Attempts   int        `json:"attempts"`
+	SendCount  int        `json:"send_count"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (o *PaymentOTP) IsExpired(now time.Time) bool {
+	return now.After(o.ExpiresAt)
+}
+
+type ConfirmOTPRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+var (
+	ErrOTPUnavailable      = errors.New("OTP confirmation is not enabled")
+	ErrOTPNotAwaited       = errors.New("payment is not awaiting OTP confirmation")
+	ErrOTPInvalid          = errors.New("OTP code is incorrect")
+	ErrOTPExpired          = errors.New("OTP code has expired")
+	ErrOTPAttemptsExceeded = errors.New("too many incorrect OTP attempts")
+	ErrOTPResendLimit      = errors.New("OTP resend limit reached")
+)
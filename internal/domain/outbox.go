@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is a queue message waiting in the outbox table to be
+// published to RabbitMQ
+type OutboxMessage struct {
+	ID            uuid.UUID
+	RoutingKey    string
+	Body          []byte
+	Headers       map[string]string // Trace context of the request that wrote it
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+// DeadLetter is a queue message the worker gave up on, waiting in the dead
+// letter queue for an operator
+type DeadLetter struct {
+	MessageID     string                 `json:"message_id"`
+	RoutingKey    string                 `json:"routing_key"` // Where a requeue sends it
+	FailureReason string                 `json:"failure_reason,omitempty"`
+	Attempts      int                    `json:"attempts"` // Retries before it was given up on
+	FailedAt      *time.Time             `json:"failed_at,omitempty"`
+	Headers       map[string]interface{} `json:"headers,omitempty"`
+	Body          json.RawMessage        `json:"body"`
+}
+
+// RequeueDeadLettersRequest picks dead letters to send back to the worker
+type RequeueDeadLettersRequest struct {
+	MessageIDs []string `json:"message_ids"`
+	All        bool     `json:"all"` // Every dead letter; message_ids is ignored
+}
+
+var ErrDeadLettersUnavailable = errors.New("the dead letter queue is not reachable from this process")
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusOverride is the audit record of an operator setting a payment's
+// status by hand, e.g. after confirming a transfer with the bank by phone.
+type StatusOverride struct {
+	ID         uuid.UUID     `json:"id"`
+	PaymentID  uuid.UUID     `json:"payment_id"`
+	FromStatus PaymentStatus `json:"from_status"`
+	ToStatus   PaymentStatus `json:"to_status"`
+	Reason     string        `json:"reason"`
+	Operator   string        `json:"operator"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type OverrideStatusRequest struct {
+	Status PaymentStatus `json:"status" validate:"required,oneof=SUCCESS FAILED"`
+	Reason string        `json:"reason" validate:"required,min=10,max=500"`
+}
+
+func (r *OverrideStatusRequest) Validate() error {
+	if r.Status != StatusSuccess && r.Status != StatusFailed {
+		return errors.New("status must be SUCCESS or FAILED")
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len(r.Reason) < 10 {
+		return errors.New("reason must be at least 10 characters")
+	}
+	if len(r.Reason) > 500 {
+		return errors.New("reason is too long")
+	}
+
+	return nil
+}
+
+var ErrStatusUnchanged = errors.New("payment already has this status")
@@ -2,8 +2,13 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
+	"payment-gateway/internal/etime"
+
 	"github.com/google/uuid"
 )
 
@@ -13,8 +18,33 @@ type Currency string
 const (
 	CurrencyETB Currency = "ETB" // Ethiopian Birr
 	CurrencyUSD Currency = "USD" // US Dollar
+	CurrencyEUR Currency = "EUR" // Euro
+	CurrencyGBP Currency = "GBP" // Pound Sterling
+	CurrencyAED Currency = "AED" // UAE Dirham
+	CurrencyCNY Currency = "CNY" // Chinese Yuan
 )
 
+type currencyInfo struct {
+	symbol    string
+	numeric   string // ISO 4217 numeric code
+	maxAmount Amount // Per-payment cap in the currency itself
+}
+
+// Foreign currency caps follow NBE rules for import/export settlement; the
+// ETB cap is the domestic regulatory limit, used when ethiopian.max_etb_amount
+// is not set
+var currencies = map[Currency]currencyInfo{
+	CurrencyETB: {symbol: "Br", numeric: "230", maxAmount: 1000000_00},
+	CurrencyUSD: {symbol: "$", numeric: "840", maxAmount: 50000_00},
+	CurrencyEUR: {symbol: "€", numeric: "978", maxAmount: 45000_00},
+	CurrencyGBP: {symbol: "£", numeric: "826", maxAmount: 40000_00},
+	CurrencyAED: {symbol: "د.إ", numeric: "784", maxAmount: 180000_00},
+	CurrencyCNY: {symbol: "¥", numeric: "156", maxAmount: 350000_00},
+}
+
+// SupportedCurrencies lists ETB first, then the foreign currencies
+var SupportedCurrencies = []Currency{CurrencyETB, CurrencyUSD, CurrencyEUR, CurrencyGBP, CurrencyAED, CurrencyCNY}
+
 // Ethiopian Bank codes (for reference generation)
 type EthiopianBank string
 
@@ -24,71 +54,166 @@ const (
 	BankDashen    EthiopianBank = "DASHEN"    // Dashen Bank
 	BankAbyssinia EthiopianBank = "ABYSSINIA" // Bank of Abyssinia
 	BankNib       EthiopianBank = "NIB"       // Nib International Bank
+	BankUnited    EthiopianBank = "UNITED"    // United Bank
 )
 
 func (c Currency) IsValid() bool {
-	return c == CurrencyETB || c == CurrencyUSD
+	_, ok := currencies[c]
+	return ok
+}
+
+// IsForeign reports whether payments in c are converted to ETB
+func (c Currency) IsForeign() bool {
+	return c.IsValid() && c != CurrencyETB
 }
 
 func (c Currency) GetSymbol() string {
-	switch c {
-	case CurrencyETB:
-		return "Br"
-	case CurrencyUSD:
-		return "$"
-	default:
-		return ""
-	}
+	return currencies[c].symbol
+}
+
+// MaxAmount is the largest single payment allowed in c
+func (c Currency) MaxAmount() Amount {
+	return currencies[c].maxAmount
 }
 
 // Payment statuses
 type PaymentStatus string
 
 const (
-	StatusPending PaymentStatus = "PENDING"
-	StatusSuccess PaymentStatus = "SUCCESS"
-	StatusFailed  PaymentStatus = "FAILED"
+	StatusPending      PaymentStatus = "PENDING"
+	StatusSuccess      PaymentStatus = "SUCCESS"
+	StatusFailed       PaymentStatus = "FAILED"
+	StatusAwaitingOTP  PaymentStatus = "AWAITING_OTP"  // Held until the customer confirms the OTP
+	StatusProcessing   PaymentStatus = "PROCESSING"    // Sent to the bank, result not yet known
+	StatusAwaitingCash PaymentStatus = "AWAITING_CASH" // Held until cash is paid against a voucher
+	StatusCancelled    PaymentStatus = "CANCELLED"     // Withdrawn by the merchant before it was processed
+	StatusExpired      PaymentStatus = "EXPIRED"       // Left PENDING past payment_expiry.ttl, e.g. after a lost message
+	StatusUnderReview  PaymentStatus = "UNDER_REVIEW"  // Held for compliance after the customer name matched a watch list
 )
 
+func (s PaymentStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusSuccess, StatusFailed, StatusAwaitingOTP, StatusProcessing,
+		StatusAwaitingCash, StatusCancelled, StatusExpired, StatusUnderReview:
+		return true
+	}
+	return false
+}
+
 func (s PaymentStatus) IsTerminal() bool {
-	return s == StatusSuccess || s == StatusFailed
+	return s == StatusSuccess || s == StatusFailed || s == StatusCancelled || s == StatusExpired
+}
+
+// PaymentMethod picks how the customer pays. BANK payments go to the
+// provider for their bank_code; the others go to the aggregator named.
+type PaymentMethod string
+
+const (
+	MethodBank      PaymentMethod = "BANK"
+	MethodArifPay   PaymentMethod = "ARIFPAY"   // ArifPay hosted checkout
+	MethodSantimPay PaymentMethod = "SANTIMPAY" // SantimPay hosted checkout
+	MethodCard      PaymentMethod = "CARD"      // Domestic debit card through EthSwitch
+)
+
+func (m PaymentMethod) IsValid() bool {
+	return m == MethodBank || m == MethodArifPay || m == MethodSantimPay || m == MethodCard
+}
+
+// Redirects reports whether the customer pays on the provider's page, so
+// the checkout URL is needed as soon as the payment is created
+func (m PaymentMethod) Redirects() bool {
+	return m == MethodArifPay || m == MethodSantimPay || m == MethodCard
 }
 
 // Payment represents an Ethiopian payment transaction
 type Payment struct {
-	ID           uuid.UUID     `json:"id"`
-	Amount       float64       `json:"amount"`
-	Currency     Currency      `json:"currency"`
-	Reference    string        `json:"reference"`
-	Status       PaymentStatus `json:"status"`
-	Description  string        `json:"description,omitempty"`   // Ethiopian context: e.g., "Coffee export payment"
-	CustomerName string        `json:"customer_name,omitempty"` // Ethiopian customer name
-	BankCode     string        `json:"bank_code,omitempty"`     // Ethiopian bank code
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+	ID                 uuid.UUID     `json:"id"`
+	Amount             Amount        `json:"amount"`
+	Currency           Currency      `json:"currency"`
+	Reference          string        `json:"reference"`
+	Status             PaymentStatus `json:"status"`
+	Description        string        `json:"description,omitempty"`          // Ethiopian context: e.g., "Coffee export payment"
+	CustomerName       string        `json:"customer_name,omitempty"`        // Ethiopian customer name
+	CustomerPhone      string        `json:"customer_phone,omitempty"`       // Used for SMS notifications
+	CustomerEmail      string        `json:"customer_email,omitempty"`       // Used for email receipts
+	CustomerNationalID string        `json:"customer_national_id,omitempty"` // Fayda FIN/FAN; counted with the phone for cumulative limits
+	CustomerID         *uuid.UUID    `json:"customer_id,omitempty"`          // KYC profile, see Customer
+	Language           Language      `json:"language,omitempty"`             // Customer notification language
+	BankCode           string        `json:"bank_code,omitempty"`            // Ethiopian bank code
+	PurposeCode        string        `json:"purpose_code,omitempty"`         // ISO 20022 purpose, see PurposeCodes
+	MCC                string        `json:"mcc,omitempty"`                  // ISO 18245 merchant category code
+	LimitFlag          LimitFlag     `json:"limit_flag,omitempty"`           // Set when accepted over a cumulative customer limit
+	ClientIP           string        `json:"client_ip,omitempty"`
+	ClientCountry      string        `json:"client_country,omitempty"`
+	DeviceFingerprint  string        `json:"device_fingerprint,omitempty"`
+	FXQuoteID          *uuid.UUID    `json:"fx_quote_id,omitempty"`
+	FXRate             float64       `json:"fx_rate,omitempty"`            // USD payments: quoted rate, or the daily rate when the payment succeeded
+	AmountETB          Amount        `json:"amount_etb,omitempty"`         // USD payments: amount settled to the merchant in ETB
+	Tags               []string      `json:"tags,omitempty"`               // Merchant labels, see NormalizeTags
+	ProviderReference  string        `json:"provider_reference,omitempty"` // The payment provider's transaction reference
+	CheckoutURL        string        `json:"checkout_url,omitempty"`       // Hosted checkout page to send the customer to
+	PaymentMethod      PaymentMethod `json:"payment_method"`
+	RefundedAmount     Amount        `json:"refunded_amount,omitempty"` // Refunds that have not failed, see Refund
+	MerchantID         *uuid.UUID    `json:"merchant_id,omitempty"`     // Set when created with the merchant's API key
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+}
+
+// ProviderCode is what the payment provider is registered under: the
+// aggregator for aggregator methods, otherwise the bank code
+func (p *Payment) ProviderCode() string {
+	if p.PaymentMethod != "" && p.PaymentMethod != MethodBank {
+		return string(p.PaymentMethod)
+	}
+	return p.BankCode
 }
 
 // Ethiopian payment request with validation
 type CreatePaymentRequest struct {
-	Amount       float64  `json:"amount" validate:"required,gt=0"`
-	Currency     Currency `json:"currency" validate:"required,oneof=ETB USD"`
-	Reference    string   `json:"reference" validate:"required,min=5,max=50"`
-	Description  string   `json:"description,omitempty" validate:"max=200"`
-	CustomerName string   `json:"customer_name,omitempty" validate:"max=100"`
-	BankCode     string   `json:"bank_code,omitempty" validate:"max=20"`
+	Amount             Amount        `json:"amount" validate:"required,gt=0"`
+	Currency           Currency      `json:"currency" validate:"required,oneof=ETB USD EUR GBP AED CNY"`
+	Reference          string        `json:"reference,omitempty" validate:"omitempty,min=5,max=50"` // Generated by the gateway when empty
+	ReferencePrefix    string        `json:"reference_prefix,omitempty" validate:"max=10"`          // One of the configured prefixes; generated references only
+	Description        string        `json:"description,omitempty" validate:"max=200"`
+	CustomerName       string        `json:"customer_name,omitempty" validate:"max=100"`
+	CustomerPhone      string        `json:"customer_phone,omitempty" validate:"max=20"`
+	CustomerEmail      string        `json:"customer_email,omitempty" validate:"omitempty,email,max=254"`
+	CustomerNationalID string        `json:"customer_national_id,omitempty" validate:"max=19"` // Fayda FIN or FAN
+	CustomerID         string        `json:"customer_id,omitempty"`                            // Registered KYC customer; required above the KYC threshold
+	Language           Language      `json:"language,omitempty" validate:"omitempty,oneof=am en"`
+	BankCode           string        `json:"bank_code,omitempty" validate:"max=20"`
+	RequireOTP         bool          `json:"require_otp,omitempty"`                             // Customer must confirm an SMS OTP before debiting
+	PayByCash          bool          `json:"pay_by_cash,omitempty"`                             // Customer pays cash at a branch/agent against a voucher
+	PurposeCode        string        `json:"purpose_code,omitempty" validate:"omitempty,len=4"` // Required for FX and high-value payments
+	MCC                string        `json:"mcc,omitempty" validate:"omitempty,len=4"`          // Required for FX and high-value payments
+	FXQuoteID          string        `json:"fx_quote_id,omitempty"`                             // From POST /fx/quotes; locks the rate to ETB
+	Tags               []string      `json:"tags,omitempty"`                                    // Merchant labels, e.g. "ramadan-promo", "branch-bole"
+	PaymentMethod      PaymentMethod `json:"payment_method,omitempty"`                          // BANK (default), ARIFPAY, SANTIMPAY or CARD
+
+	Client     ClientInfo `json:"-"`
+	MerchantID *uuid.UUID `json:"-"` // From the API key the request was made with
 }
 
-// Validate Ethiopian payment request
-func (r *CreatePaymentRequest) Validate() error {
+// Validate Ethiopian payment request. A positive maxETB is the configured
+// ETB cap, in place of the default one.
+func (r *CreatePaymentRequest) Validate(maxETB Amount) error {
 	if r.Amount <= 0 {
 		return errors.New("amount must be greater than zero")
 	}
 
 	if !r.Currency.IsValid() {
-		return errors.New("currency must be ETB or USD")
+		return errors.New("currency must be one of ETB, USD, EUR, GBP, AED or CNY")
+	}
+
+	limit := r.Currency.MaxAmount()
+	if r.Currency == CurrencyETB && maxETB > 0 {
+		limit = maxETB
+	}
+	if r.Amount > limit {
+		return fmt.Errorf("%w: at most %s per payment", ErrAmountTooLarge, NewMoney(limit, r.Currency))
 	}
 
-	if len(r.Reference) < 5 {
+	if r.Reference != "" && len(r.Reference) < 5 {
 		return errors.New("reference must be at least 5 characters")
 	}
 
@@ -96,43 +221,245 @@ func (r *CreatePaymentRequest) Validate() error {
 		return errors.New("reference is too long")
 	}
 
+	if r.Reference != "" && r.ReferencePrefix != "" {
+		return errors.New("reference_prefix only applies to generated references")
+	}
+
+	// Unknown bank codes are not checked; the bank registry only covers
+	// banks we settle with directly
+	if r.Reference != "" && r.BankCode != "" {
+		if bank, ok := LookupBank(r.BankCode); ok {
+			if err := bank.ValidateReference(r.Reference); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.CustomerPhone != "" {
+		if _, err := NormalizePhone(r.CustomerPhone); err != nil {
+			return fmt.Errorf("customer phone: %w", err)
+		}
+	}
+
+	if r.CustomerNationalID != "" {
+		id, err := NormalizeNationalID(r.CustomerNationalID)
+		if err != nil {
+			return fmt.Errorf("customer national ID: %w", err)
+		}
+		r.CustomerNationalID = id
+	}
+
+	if r.CustomerID != "" {
+		if _, err := uuid.Parse(r.CustomerID); err != nil {
+			return errors.New("customer_id is not a valid customer ID")
+		}
+	}
+
+	if r.FXQuoteID != "" {
+		if _, err := uuid.Parse(r.FXQuoteID); err != nil {
+			return errors.New("fx_quote_id is not a valid quote ID")
+		}
+		if !r.Currency.IsForeign() {
+			return ErrFXQuoteCurrency
+		}
+	}
+
+	if len(r.Tags) > 0 {
+		tags, err := NormalizeTags(r.Tags)
+		if err != nil {
+			return err
+		}
+		r.Tags = tags
+	}
+
+	r.PaymentMethod = PaymentMethod(strings.ToUpper(strings.TrimSpace(string(r.PaymentMethod))))
+	if r.PaymentMethod == "" {
+		r.PaymentMethod = MethodBank
+	}
+	if !r.PaymentMethod.IsValid() {
+		return errors.New("payment_method must be BANK, ARIFPAY, SANTIMPAY or CARD")
+	}
+	if r.PaymentMethod.Redirects() {
+		if r.Currency != CurrencyETB {
+			return fmt.Errorf("%s payments must be in ETB", r.PaymentMethod)
+		}
+		if r.RequireOTP || r.PayByCash {
+			return fmt.Errorf("%s payments cannot use require_otp or pay_by_cash", r.PaymentMethod)
+		}
+	}
+
+	if r.PayByCash && r.RequireOTP {
+		return errors.New("require_otp cannot be combined with pay_by_cash")
+	}
+
+	if r.PayByCash && r.Currency != CurrencyETB {
+		return errors.New("cash payments must be in ETB")
+	}
+
+	if r.RequireOTP && r.CustomerPhone == "" {
+		return errors.New("customer phone is required for OTP confirmation")
+	}
+
+	if r.CustomerEmail != "" {
+		if _, err := mail.ParseAddress(r.CustomerEmail); err != nil || len(r.CustomerEmail) > 254 {
+			return errors.New("customer email is invalid")
+		}
+	}
+
+	if r.Language != "" && !r.Language.IsValid() {
+		return errors.New("language must be am or en")
+	}
+
 	// Ethiopian business rule: For large ETB amounts, require description
-	if r.Currency == CurrencyETB && r.Amount > 100000 && r.Description == "" {
+	if r.Currency == CurrencyETB && r.Amount > HighValueETBAmount && r.Description == "" {
 		return errors.New("description is required for large ETB payments")
 	}
 
+	var err error
+	if r.PurposeCode != "" {
+		if r.PurposeCode, err = ValidatePurposeCode(r.PurposeCode); err != nil {
+			return err
+		}
+	}
+	if r.MCC != "" {
+		if r.MCC, err = ValidateMCC(r.MCC); err != nil {
+			return err
+		}
+	}
+
+	// NBE reporting classifies every FX and high-value payment
+	if RequiresClassification(r.Currency, r.Amount) && (r.PurposeCode == "" || r.MCC == "") {
+		return errors.New("purpose_code and mcc are required for foreign currency and high-value payments")
+	}
+
 	return nil
 }
 
 // Ethiopian payment response
 type PaymentResponse struct {
-	ID             uuid.UUID     `json:"id"`
-	Amount         float64       `json:"amount"`
-	Currency       Currency      `json:"currency"`
-	CurrencySymbol string        `json:"currency_symbol"`
-	Reference      string        `json:"reference"`
-	Status         PaymentStatus `json:"status"`
-	Description    string        `json:"description,omitempty"`
-	CustomerName   string        `json:"customer_name,omitempty"`
-	BankCode       string        `json:"bank_code,omitempty"`
-	CreatedAt      time.Time     `json:"created_at"`
-	CreatedAtET    string        `json:"created_at_et"` // Ethiopian time
+	ID                 uuid.UUID     `json:"id"`
+	Amount             Amount        `json:"amount"`
+	Currency           Currency      `json:"currency"`
+	CurrencySymbol     string        `json:"currency_symbol"`
+	Reference          string        `json:"reference"`
+	Status             PaymentStatus `json:"status"`
+	Description        string        `json:"description,omitempty"`
+	CustomerName       string        `json:"customer_name,omitempty"`
+	CustomerPhone      string        `json:"customer_phone,omitempty"`
+	CustomerEmail      string        `json:"customer_email,omitempty"`
+	CustomerNationalID string        `json:"customer_national_id,omitempty"`
+	CustomerID         *uuid.UUID    `json:"customer_id,omitempty"`
+	Language           Language      `json:"language,omitempty"`
+	BankCode           string        `json:"bank_code,omitempty"`
+	PurposeCode        string        `json:"purpose_code,omitempty"`
+	MCC                string        `json:"mcc,omitempty"`
+	LimitFlag          LimitFlag     `json:"limit_flag,omitempty"`
+	ClientIP           string        `json:"client_ip,omitempty"`
+	ClientCountry      string        `json:"client_country,omitempty"`
+	DeviceFingerprint  string        `json:"device_fingerprint,omitempty"`
+	FXQuoteID          *uuid.UUID    `json:"fx_quote_id,omitempty"`
+	FXRate             float64       `json:"fx_rate,omitempty"`
+	AmountETB          Amount        `json:"amount_etb,omitempty"`
+	Tags               []string      `json:"tags,omitempty"`
+	ProviderReference  string        `json:"provider_reference,omitempty"`
+	CheckoutURL        string        `json:"checkout_url,omitempty"` // Send the customer here while the payment is PROCESSING
+	PaymentMethod      PaymentMethod `json:"payment_method"`
+	RefundedAmount     Amount        `json:"refunded_amount,omitempty"`
+	MerchantID         *uuid.UUID    `json:"merchant_id,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+	CreatedAtET        string        `json:"created_at_et"` // Ethiopian time
+	// Ethiopian calendar date, as reconciled by accounting teams
+	CreatedAtEthiopian     string `json:"created_at_ethiopian"`      // e.g. "15-04-2016 EC"
+	CreatedAtEthiopianGeez string `json:"created_at_ethiopian_geez"` // e.g. "ታኅሣሥ ፲፭ ቀን ፳፻፲፮ ዓ.ም."
+
+	// Proof-of-payment documents; only set on single-payment lookups
+	Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+// Response to payment creation
+type CreatePaymentResponse struct {
+	Message       string        `json:"message"`
+	PaymentID     uuid.UUID     `json:"payment_id"`
+	Status        PaymentStatus `json:"status"`
+	Reference     string        `json:"reference"`
+	CheckoutURL   string        `json:"checkout_url,omitempty"` // Send the customer here for redirect payment methods
+	CreatedAt     string        `json:"created_at"`
+	EthiopianTime string        `json:"ethiopian_time"`
+}
+
+// Paginated list of payments
+type PaymentListResponse struct {
+	Payments   []PaymentResponse `json:"payments"`
+	Total      int               `json:"total"`
+	TotalPages int               `json:"total_pages"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page; empty on the last
+}
+
+// PaymentTotal counts and sums the payments in one currency and status
+type PaymentTotal struct {
+	Currency Currency
+	Status   PaymentStatus
+	Count    int
+	Amount   Amount
+}
+
+// TotalPages is how many pages of limit items hold total items
+func TotalPages(total, limit int) int {
+	if limit < 1 {
+		return 0
+	}
+	return (total + limit - 1) / limit
+}
+
+// Error body returned by every API endpoint
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
 }
 
 // Convert to response with Ethiopian context
 func (p *Payment) ToResponse() PaymentResponse {
+	createdAt := etime.In(p.CreatedAt)
+	createdOn := ToEthiopianDate(createdAt)
+
 	return PaymentResponse{
-		ID:             p.ID,
-		Amount:         p.Amount,
-		Currency:       p.Currency,
-		CurrencySymbol: p.Currency.GetSymbol(),
-		Reference:      p.Reference,
-		Status:         p.Status,
-		Description:    p.Description,
-		CustomerName:   p.CustomerName,
-		BankCode:       p.BankCode,
-		CreatedAt:      p.CreatedAt,
-		CreatedAtET:    p.CreatedAt.Add(3 * time.Hour).Format(time.RFC3339), // GMT+3
+		ID:                 p.ID,
+		Amount:             p.Amount,
+		Currency:           p.Currency,
+		CurrencySymbol:     p.Currency.GetSymbol(),
+		Reference:          p.Reference,
+		Status:             p.Status,
+		Description:        p.Description,
+		CustomerName:       p.CustomerName,
+		CustomerPhone:      p.CustomerPhone,
+		CustomerEmail:      p.CustomerEmail,
+		CustomerNationalID: p.CustomerNationalID,
+		CustomerID:         p.CustomerID,
+		Language:           p.Language,
+		BankCode:           p.BankCode,
+		PurposeCode:        p.PurposeCode,
+		MCC:                p.MCC,
+		LimitFlag:          p.LimitFlag,
+		ClientIP:           p.ClientIP,
+		ClientCountry:      p.ClientCountry,
+		DeviceFingerprint:  p.DeviceFingerprint,
+		FXQuoteID:          p.FXQuoteID,
+		FXRate:             p.FXRate,
+		AmountETB:          p.AmountETB,
+		Tags:               p.Tags,
+		ProviderReference:  p.ProviderReference,
+		CheckoutURL:        p.CheckoutURL,
+		PaymentMethod:      p.PaymentMethod,
+		RefundedAmount:     p.RefundedAmount,
+		MerchantID:         p.MerchantID,
+		CreatedAt:          p.CreatedAt,
+		CreatedAtET:        createdAt.Format(time.RFC3339),
+
+		CreatedAtEthiopian:     createdOn.Numeric(),
+		CreatedAtEthiopianGeez: createdOn.FormatGeez(),
 	}
 }
 
@@ -142,7 +469,8 @@ var (
 	ErrInvalidInput         = errors.New("invalid input")
 	ErrPaymentAlreadyExists = errors.New("payment with this reference already exists")
 	ErrPaymentNotPending    = errors.New("payment is not in pending state")
-	ErrAmountTooLarge       = errors.New("amount exceeds Ethiopian regulatory limit")
+	ErrAmountTooLarge       = errors.New("amount exceeds the regulatory limit")
 	ErrBusinessHours        = errors.New("payment outside Ethiopian business hours")
 	ErrDatabase             = errors.New("database error")
+	ErrMethodUnavailable    = errors.New("payment method is not enabled")
 )
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentLinkCodeLength is the length of the code in a payment link's
+// short URL; 8 base62 characters are about 47 random bits
+const PaymentLinkCodeLength = 8
+
+const paymentLinkAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// PaymentLink is a shareable short URL to a hosted checkout page where the
+// customer picks a bank or wallet and a payment is created for them.
+type PaymentLink struct {
+	ID          uuid.UUID  `json:"id"`
+	Code        string     `json:"code"`
+	URL         string     `json:"url,omitempty"`
+	Amount      Amount     `json:"amount"`
+	Currency    Currency   `json:"currency"`
+	Description string     `json:"description,omitempty"`
+	Language    Language   `json:"language,omitempty"` // Checkout page language; the customer can switch
+	OneTime     bool       `json:"one_time"`           // Deactivated once a payment is created from it
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Uses        int        `json:"uses"` // Payments created from the link
+	Active      bool       `json:"active"`
+	MerchantID  *uuid.UUID `json:"merchant_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Payments created from the link, newest first; set by GET only
+	PaymentIDs []uuid.UUID `json:"payment_ids,omitempty"`
+}
+
+// CheckUsable reports why a customer can no longer pay through the link
+func (l *PaymentLink) CheckUsable(now time.Time) error {
+	switch {
+	case !l.Active:
+		return ErrPaymentLinkInactive
+	case l.ExpiresAt != nil && !now.Before(*l.ExpiresAt):
+		return ErrPaymentLinkExpired
+	case l.OneTime && l.Uses > 0:
+		return ErrPaymentLinkUsed
+	}
+	return nil
+}
+
+type CreatePaymentLinkRequest struct {
+	Amount      Amount     `json:"amount"`
+	Currency    Currency   `json:"currency"`
+	Description string     `json:"description,omitempty"`
+	Language    Language   `json:"language,omitempty"`
+	OneTime     bool       `json:"one_time,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Never expires when empty
+}
+
+func (r *CreatePaymentLinkRequest) Validate(now time.Time) error {
+	if r.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	if r.Currency == "" {
+		r.Currency = CurrencyETB
+	}
+	if !r.Currency.IsValid() {
+		return errors.New("currency must be one of ETB, USD, EUR, GBP, AED or CNY")
+	}
+	if r.Amount > r.Currency.MaxAmount() {
+		return fmt.Errorf("%w: at most %s per payment", ErrAmountTooLarge, NewMoney(r.Currency.MaxAmount(), r.Currency))
+	}
+
+	r.Description = strings.TrimSpace(r.Description)
+	if len(r.Description) > 255 {
+		return errors.New("description is too long")
+	}
+	if r.Currency == CurrencyETB && r.Amount > HighValueETBAmount && r.Description == "" {
+		return errors.New("description is required for large ETB payments")
+	}
+
+	if r.Language != "" && !r.Language.IsValid() {
+		return errors.New("language must be am or en")
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// PaymentLinkCheckout is what the customer submits on the checkout page
+type PaymentLinkCheckout struct {
+	Option        string // A bank code, or a payment method that redirects
+	CustomerName  string
+	CustomerPhone string
+	Language      Language
+	Client        ClientInfo
+}
+
+// GeneratePaymentLinkCode returns a random code without look-alike
+// characters (0/O, 1/l/I)
+func GeneratePaymentLinkCode() (string, error) {
+	code := make([]byte, PaymentLinkCodeLength)
+	max := big.NewInt(int64(len(paymentLinkAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = paymentLinkAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+var (
+	ErrPaymentLinkNotFound = errors.New("payment link not found")
+	ErrPaymentLinkInactive = errors.New("payment link has been deactivated")
+	ErrPaymentLinkExpired  = errors.New("payment link has expired")
+	ErrPaymentLinkUsed     = errors.New("payment link has already been used")
+)
+
+// CheckoutOption is a bank or wallet offered on the hosted checkout page
+type CheckoutOption struct {
+	Value string // Submitted back as PaymentLinkCheckout.Option
+	Name  string
+}
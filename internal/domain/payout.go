@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PayoutStatus string
+
+const (
+	PayoutPending  PayoutStatus = "PENDING"
+	PayoutSuccess  PayoutStatus = "SUCCESS"
+	PayoutFailed   PayoutStatus = "FAILED"   // Sent to the bank and declined
+	PayoutRejected PayoutStatus = "REJECTED" // Failed validation, never sent
+)
+
+// Payout sends money out to a bank account or wallet
+type Payout struct {
+	ID            uuid.UUID    `json:"id"`
+	JobID         *uuid.UUID   `json:"job_id,omitempty"`
+	RowNumber     int          `json:"row_number,omitempty"` // Line in the uploaded CSV
+	BankCode      string       `json:"bank_code"`
+	AccountNumber string       `json:"account_number"`
+	Amount        Amount       `json:"amount"`
+	Currency      Currency     `json:"currency"`
+	Reason        string       `json:"reason"`
+	Status        PayoutStatus `json:"status"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// Validate normalizes the destination and checks the amount against the
+// regulatory limit (maxETB; zero means no limit)
+func (p *Payout) Validate(maxETB Amount) error {
+	dest := AccountVerificationRequest{BankCode: p.BankCode, AccountNumber: p.AccountNumber}
+	if err := dest.Validate(); err != nil {
+		return err
+	}
+	if !IsWallet(dest.BankCode) {
+		if _, ok := LookupBank(dest.BankCode); !ok {
+			return fmt.Errorf("unknown bank code %q", dest.BankCode)
+		}
+	}
+	p.BankCode, p.AccountNumber = dest.BankCode, dest.AccountNumber
+
+	if p.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	if !p.Currency.IsValid() {
+		return errors.New("currency must be one of ETB, USD, EUR, GBP, AED or CNY")
+	}
+	if p.Currency == CurrencyETB && maxETB > 0 && p.Amount > maxETB {
+		return ErrAmountTooLarge
+	}
+	if p.Currency.IsForeign() && p.Amount > p.Currency.MaxAmount() {
+		return ErrAmountTooLarge
+	}
+
+	p.Reason = strings.TrimSpace(p.Reason)
+	if p.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if len(p.Reason) > 140 {
+		return errors.New("reason is too long (max 140 characters)")
+	}
+
+	return nil
+}
+
+type BulkJobStatus string
+
+const (
+	BulkJobQueued     BulkJobStatus = "QUEUED"
+	BulkJobProcessing BulkJobStatus = "PROCESSING"
+	BulkJobCompleted  BulkJobStatus = "COMPLETED"
+)
+
+// BulkPayoutJob is one uploaded payouts CSV. Every row becomes a Payout,
+// invalid rows as REJECTED, so per-row outcomes live in one place.
+type BulkPayoutJob struct {
+	ID             uuid.UUID     `json:"id"`
+	FileName       string        `json:"file_name"`
+	Status         BulkJobStatus `json:"status"`
+	TotalRows      int           `json:"total_rows"`
+	RejectedRows   int           `json:"rejected_rows"`
+	PendingRows    int           `json:"pending_rows"`
+	SucceededRows  int           `json:"succeeded_rows"`
+	FailedRows     int           `json:"failed_rows"`
+	TotalAmount    Amount        `json:"total_amount"`          // Of accepted rows
+	MerchantID     *uuid.UUID    `json:"merchant_id,omitempty"` // Merchant whose API key uploaded the file
+	CallbackURL    string        `json:"callback_url,omitempty"`
+	CallbackSecret string        `json:"callback_secret,omitempty"` // Signs the callback; only returned on upload
+	WebhookStatus  string        `json:"webhook_status,omitempty"`  // Outcome of the completion callback
+	CreatedAt      time.Time     `json:"created_at"`
+	CompletedAt    *time.Time    `json:"completed_at,omitempty"`
+}
+
+// BulkPayoutCompletion is queued when a job finishes; the worker posts it,
+// rows and all, to the merchant's callback URL
+type BulkPayoutCompletion struct {
+	Job     *BulkPayoutJob `json:"job"`
+	Payouts []*Payout      `json:"payouts"`
+}
+
+var (
+	ErrBulkJobNotFound = errors.New("bulk payout job not found")
+	ErrBulkFileInvalid = errors.New("bulk payout file is invalid")
+)
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Ethiopian country calling code
+const EthiopiaCallingCode = "251"
+
+// Mobile network operators, identified by the first digit of the subscriber number
+type MobileOperator string
+
+const (
+	OperatorEthioTelecom MobileOperator = "ETHIO_TELECOM" // 09x
+	OperatorSafaricom    MobileOperator = "SAFARICOM"     // 07x
+)
+
+var ErrInvalidPhone = errors.New("phone number is not a valid Ethiopian mobile number")
+
+// Every rejection wraps ErrInvalidPhone so callers can still match on it
+var (
+	ErrPhoneEmpty      = fmt.Errorf("%w: number is empty", ErrInvalidPhone)
+	ErrPhoneCharacters = fmt.Errorf("%w: only digits, spaces, dashes, dots, parentheses and a leading + are allowed", ErrInvalidPhone)
+	ErrPhoneCountry    = fmt.Errorf("%w: only +251 numbers are supported", ErrInvalidPhone)
+	ErrPhoneLength     = fmt.Errorf("%w: expected 10 digits (09xxxxxxxx / 07xxxxxxxx) or +251 followed by 9 digits", ErrInvalidPhone)
+	ErrPhoneNotMobile  = fmt.Errorf("%w: number must start with 09 (Ethio Telecom) or 07 (Safaricom)", ErrInvalidPhone)
+)
+
+// NormalizePhone converts the formats customers actually type (0911..., 911...,
+// 251911..., +251 911 ...) into E.164 (+251911...), so one customer always
+// maps to one key. Only mobile numbers (9xx Ethio Telecom, 7xx Safaricom) are
+// accepted.
+func NormalizePhone(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrPhoneEmpty
+	}
+
+	var digits strings.Builder
+	for i, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", ErrPhoneCharacters
+		}
+	}
+
+	number := digits.String()
+	international := strings.HasPrefix(raw, "+") || strings.HasPrefix(number, "00")
+	switch {
+	case strings.HasPrefix(number, "00"+EthiopiaCallingCode):
+		number = strings.TrimPrefix(number, "00"+EthiopiaCallingCode)
+	case strings.HasPrefix(number, EthiopiaCallingCode) && len(number) == 12:
+		number = strings.TrimPrefix(number, EthiopiaCallingCode)
+	case international:
+		return "", ErrPhoneCountry
+	case strings.HasPrefix(number, "0") && len(number) == 10:
+		number = number[1:]
+	}
+
+	if len(number) != 9 {
+		return "", ErrPhoneLength
+	}
+	if number[0] != '9' && number[0] != '7' {
+		return "", ErrPhoneNotMobile
+	}
+
+	return "+" + EthiopiaCallingCode + number, nil
+}
+
+// PhoneOperator reports which network a number belongs to
+func PhoneOperator(raw string) (MobileOperator, error) {
+	phone, err := NormalizePhone(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if phone[len("+"+EthiopiaCallingCode)] == '7' {
+		return OperatorSafaricom, nil
+	}
+	return OperatorEthioTelecom, nil
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// POS response codes (ISO 8583 field 39) returned to terminals
+const (
+	POSApproved           = "00"
+	POSInvalidMerchant    = "03"
+	POSInvalidTransaction = "12"
+	POSInvalidAmount      = "13"
+	POSFormatError        = "30"
+	POSNotPermitted       = "58"
+	POSExceedsLimit       = "61"
+	POSSecurityViolation  = "63"
+	POSDuplicate          = "94"
+	POSSystemError        = "96"
+)
+
+// CardTransaction is a card payment pushed by a POS terminal, mapped from
+// the ISO 8583 request
+type CardTransaction struct {
+	ID           uuid.UUID `json:"id"`
+	PaymentID    uuid.UUID `json:"payment_id"`
+	TerminalID   string    `json:"terminal_id"`   // Field 41
+	MerchantID   string    `json:"merchant_id"`   // Field 42
+	STAN         string    `json:"stan"`          // Field 11
+	RRN          string    `json:"rrn"`           // Field 37
+	MaskedPAN    string    `json:"masked_pan"`    // Field 2 or 35; the full PAN is never stored
+	EntryMode    string    `json:"entry_mode"`    // Field 22
+	AcceptorName string    `json:"acceptor_name"` // Field 43
+	Amount       Amount    `json:"amount"`        // Field 4, in major units
+	Currency     Currency  `json:"currency"`      // Field 49
+	MCC          string    `json:"mcc,omitempty"` // Field 18
+	ApprovalCode string    `json:"approval_code"` // Field 38 of the response
+	ResponseCode string    `json:"response_code"` // Field 39 of the response
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// MaskPAN keeps the first six and last four digits
+func MaskPAN(pan string) string {
+	if len(pan) < 13 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// CurrencyFromNumeric maps an ISO 4217 numeric code
+func CurrencyFromNumeric(code string) (Currency, bool) {
+	for currency, info := range currencies {
+		if info.numeric == code {
+			return currency, true
+		}
+	}
+	return "", false
+}
+
+var (
+	ErrCardTxnNotFound  = errors.New("card transaction not found")
+	ErrCardTxnDuplicate = errors.New("card transaction already recorded for this terminal and RRN")
+)
@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HighValueETBAmount is the ETB amount above which a payment needs a
+// description and classification codes for NBE reporting
+const HighValueETBAmount Amount = 100000_00
+
+// PurposeCode classifies why money moves. Codes follow the ISO 20022
+// ExternalPurpose1Code list, restricted to the purposes NBE foreign
+// exchange and large-value returns ask for.
+type PurposeCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+var purposeCodes = map[string]string{
+	"SALA": "Salary payment",
+	"PENS": "Pension payment",
+	"SUPP": "Supplier payment",
+	"GDDS": "Purchase or sale of goods",
+	"SCVE": "Purchase or sale of services",
+	"TRAD": "Trade services",
+	"EDUC": "Education fees",
+	"MDCS": "Medical services",
+	"TAXS": "Tax payment",
+	"GOVT": "Government payment",
+	"RENT": "Rent",
+	"LOAN": "Loan disbursement or repayment",
+	"INTE": "Interest",
+	"DIVD": "Dividend",
+	"INSU": "Insurance premium",
+	"CHAR": "Charity donation",
+	"CASH": "Cash management transfer",
+	"OTHR": "Other",
+}
+
+// MerchantCategory is an ISO 18245 merchant category code (MCC)
+type MerchantCategory struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// MCCs accepted by Ethiopian acquirers; codes outside this list are rejected
+var merchantCategories = map[string]string{
+	"0763": "Agricultural cooperatives",
+	"4111": "Commuter transport",
+	"4121": "Taxis and ride hailing",
+	"4511": "Airlines",
+	"4722": "Travel agencies and tour operators",
+	"4814": "Telecommunication services",
+	"4900": "Utilities: electric, gas, water",
+	"5045": "Computers and software",
+	"5172": "Petroleum products",
+	"5200": "Home supply warehouse stores",
+	"5311": "Department stores",
+	"5411": "Grocery stores and supermarkets",
+	"5499": "Miscellaneous food stores",
+	"5541": "Service stations",
+	"5651": "Family clothing stores",
+	"5732": "Electronics stores",
+	"5812": "Restaurants",
+	"5814": "Fast food restaurants",
+	"5912": "Pharmacies",
+	"5999": "Miscellaneous retail",
+	"6012": "Financial institutions",
+	"6300": "Insurance",
+	"7011": "Hotels and lodging",
+	"7399": "Business services",
+	"8011": "Doctors",
+	"8062": "Hospitals",
+	"8211": "Elementary and secondary schools",
+	"8220": "Colleges and universities",
+	"8398": "Charitable organizations",
+	"9311": "Tax payments",
+	"9399": "Government services",
+}
+
+var mccPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+var (
+	ErrUnknownPurposeCode = errors.New("unknown purpose code")
+	ErrUnknownMCC         = errors.New("unknown merchant category code")
+)
+
+// PurposeCodes lists the accepted purpose codes, sorted by code
+func PurposeCodes() []PurposeCode {
+	codes := make([]PurposeCode, 0, len(purposeCodes))
+	for code, description := range purposeCodes {
+		codes = append(codes, PurposeCode{Code: code, Description: description})
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// MerchantCategories lists the accepted MCCs, sorted by code
+func MerchantCategories() []MerchantCategory {
+	codes := make([]MerchantCategory, 0, len(merchantCategories))
+	for code, description := range merchantCategories {
+		codes = append(codes, MerchantCategory{Code: code, Description: description})
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// ValidatePurposeCode upper-cases the code and checks it against the list
+func ValidatePurposeCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := purposeCodes[code]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownPurposeCode, code)
+	}
+	return code, nil
+}
+
+func ValidateMCC(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if !mccPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q must be 4 digits", ErrUnknownMCC, code)
+	}
+	if _, ok := merchantCategories[code]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownMCC, code)
+	}
+	return code, nil
+}
+
+// RequiresClassification reports whether a payment must carry a purpose
+// code and MCC: every foreign currency payment and high-value ETB ones.
+func RequiresClassification(currency Currency, amount Amount) bool {
+	return currency != CurrencyETB || amount > HighValueETBAmount
+}
+
+// PaymentFilter narrows payment lists and statistics; empty fields match
+// all. CreatedFrom and CreatedTo take an RFC 3339 time or a YYYY-MM-DD date
+// in Ethiopian time.
+type PaymentFilter struct {
+	PurposeCode  string
+	MCC          string
+	LimitFlagged bool       // Only payments accepted over a customer limit
+	Tags         []string   // Payments carrying all of these tags
+	MerchantID   *uuid.UUID // One merchant's payments; all when nil
+	Status       PaymentStatus
+	Currency     Currency
+	BankCode     string
+	MinAmount    Amount      // Inclusive, in the payment's own currency
+	MaxAmount    Amount      // Inclusive
+	CreatedFrom  string      // Inclusive
+	CreatedTo    string      // Exclusive for a time; a date includes that whole day
+	Sort         PaymentSort // Order of payment lists; newest first when empty
+}
+
+// Validate normalizes the filter codes in place and rewrites CreatedFrom
+// and CreatedTo as RFC 3339 times
+func (f *PaymentFilter) Validate() error {
+	var err error
+	if f.PurposeCode != "" {
+		if f.PurposeCode, err = ValidatePurposeCode(f.PurposeCode); err != nil {
+			return err
+		}
+	}
+	if f.MCC != "" {
+		if f.MCC, err = ValidateMCC(f.MCC); err != nil {
+			return err
+		}
+	}
+	if len(f.Tags) > 0 {
+		if f.Tags, err = NormalizeTags(f.Tags); err != nil {
+			return err
+		}
+	}
+
+	if f.Status != "" {
+		f.Status = PaymentStatus(strings.ToUpper(string(f.Status)))
+		if !f.Status.IsValid() {
+			return errors.New("unknown status")
+		}
+	}
+	if f.Currency != "" {
+		f.Currency = Currency(strings.ToUpper(string(f.Currency)))
+		if !f.Currency.IsValid() {
+			return errors.New("unsupported currency")
+		}
+	}
+	if f.BankCode != "" {
+		bank, ok := LookupBank(f.BankCode)
+		if !ok {
+			return errors.New("unknown bank_code")
+		}
+		f.BankCode = string(bank.Code)
+	}
+
+	if f.MinAmount < 0 || f.MaxAmount < 0 {
+		return errors.New("min_amount and max_amount must not be negative")
+	}
+	if f.MaxAmount > 0 && f.MinAmount > f.MaxAmount {
+		return errors.New("min_amount must not exceed max_amount")
+	}
+
+	var from, to time.Time
+	if f.CreatedFrom != "" {
+		if from, err = parseAuditTime(f.CreatedFrom, false); err != nil {
+			return errors.New("created_from must be an RFC 3339 time or a date in YYYY-MM-DD format")
+		}
+		f.CreatedFrom = from.Format(time.RFC3339Nano)
+	}
+	if f.CreatedTo != "" {
+		if to, err = parseAuditTime(f.CreatedTo, true); err != nil {
+			return errors.New("created_to must be an RFC 3339 time or a date in YYYY-MM-DD format")
+		}
+		f.CreatedTo = to.Format(time.RFC3339Nano)
+	}
+	if f.CreatedFrom != "" && f.CreatedTo != "" && !to.After(from) {
+		return errors.New("created_to must be after created_from")
+	}
+
+	if f.Sort != "" && !f.Sort.IsValid() {
+		return errors.New("sort must be created_at, -created_at, amount or -amount")
+	}
+	return nil
+}
+
+// PaymentSort orders payment lists by a column, descending with a leading -
+type PaymentSort string
+
+const (
+	SortNewest   PaymentSort = "-created_at"
+	SortOldest   PaymentSort = "created_at"
+	SortLargest  PaymentSort = "-amount"
+	SortSmallest PaymentSort = "amount"
+)
+
+func (s PaymentSort) IsValid() bool {
+	return s == SortNewest || s == SortOldest || s == SortLargest || s == SortSmallest
+}
+
+// Descending reports whether s lists payments from the highest value down
+func (s PaymentSort) Descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// Keyset reports whether lists in this order can be paged by a
+// PaymentCursor. Amount orders page by page number only.
+func (s PaymentSort) Keyset() bool {
+	return s == "" || s == SortNewest || s == SortOldest
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PushPlatform string
+
+const (
+	PlatformAndroid PushPlatform = "android"
+	PlatformIOS     PushPlatform = "ios"
+	PlatformWeb     PushPlatform = "web"
+)
+
+func (p PushPlatform) IsValid() bool {
+	return p == PlatformAndroid || p == PlatformIOS || p == PlatformWeb
+}
+
+// PushDevice is a merchant user's app installation registered for FCM
+// pushes. An empty MerchantID is the gateway's own operators.
+type PushDevice struct {
+	ID         uuid.UUID    `json:"id"`
+	MerchantID string       `json:"merchant_id,omitempty"`
+	UserID     string       `json:"user_id"`
+	Token      string       `json:"token"`
+	Platform   PushPlatform `json:"platform"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastSeenAt time.Time    `json:"last_seen_at"`
+}
+
+func (d *PushDevice) Validate() error {
+	if d.UserID == "" {
+		return errors.New("user_id is required")
+	}
+
+	if d.Token == "" || len(d.Token) > 4096 {
+		return errors.New("token is required")
+	}
+
+	if !d.Platform.IsValid() {
+		return errors.New("platform must be android, ios or web")
+	}
+
+	return nil
+}
+
+var ErrPushDeviceNotFound = errors.New("push device not found")
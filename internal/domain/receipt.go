@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReceiptLink is the current public receipt link of a payment. Issuing a
+// new link rotates the nonce, so only the latest URL verifies.
+type ReceiptLink struct {
+	PaymentID uuid.UUID  `json:"payment_id"`
+	Nonce     string     `json:"-"`
+	URL       string     `json:"url,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (l *ReceiptLink) IsRevoked() bool {
+	return l.RevokedAt != nil
+}
+
+var (
+	ErrReceiptLinkNotFound  = errors.New("receipt link not found or revoked")
+	ErrReceiptNotAvailable  = errors.New("receipts are only available for successful payments")
+	ErrReceiptLinksDisabled = errors.New("receipt links are not configured")
+	ErrReceiptPDFDisabled   = errors.New("PDF receipts are not configured")
+)
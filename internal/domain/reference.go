@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default prefix when none is configured
+const DefaultReferencePrefix = "ETB"
+
+// Crockford base32: no I, L, O or U, so references survive being read out over the phone
+const referenceAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const referenceRandomLength = 8
+
+const checksumAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GenerateReference builds a gateway reference of the form
+// PREFIX-BANK-YYYYMMDD-RRRRRRRRC, where the date is the Ethiopian calendar
+// date of now, R is random and C is a check character. The bank segment is
+// left out when bankCode is empty.
+func GenerateReference(prefix, bankCode string, now time.Time) (string, error) {
+	random := make([]byte, referenceRandomLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	for i, b := range random {
+		random[i] = referenceAlphabet[int(b)%len(referenceAlphabet)]
+	}
+
+	date := ToEthiopianDate(now)
+	parts := []string{strings.ToUpper(prefix)}
+	if bankCode != "" {
+		parts = append(parts, strings.ToUpper(bankCode))
+	}
+	parts = append(parts, fmt.Sprintf("%04d%02d%02d", date.Year, date.Month, date.Day), string(random))
+
+	reference := strings.Join(parts, "-")
+	return reference + string(referenceCheckChar(reference)), nil
+}
+
+// ValidReferenceChecksum reports whether a generated reference is intact
+func ValidReferenceChecksum(reference string) bool {
+	if len(reference) < 2 {
+		return false
+	}
+	body, check := reference[:len(reference)-1], reference[len(reference)-1]
+	return referenceCheckChar(body) == check
+}
+
+// referenceCheckChar is the Luhn mod 36 check character over the
+// alphanumeric characters of s; it catches single-character typos and
+// most adjacent transpositions.
+func referenceCheckChar(s string) byte {
+	n := len(checksumAlphabet)
+	sum := 0
+	factor := 2
+
+	upper := strings.ToUpper(s)
+	for i := len(upper) - 1; i >= 0; i-- {
+		code := strings.IndexByte(checksumAlphabet, upper[i])
+		if code < 0 {
+			continue
+		}
+		addend := factor * code
+		addend = addend/n + addend%n
+		sum += addend
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+
+	return checksumAlphabet[(n-sum%n)%n]
+}
+
+var ErrReferenceGeneration = errors.New("could not generate a unique payment reference")
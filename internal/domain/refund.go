@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefundStatus follows a refund through the queue and the provider
+type RefundStatus string
+
+const (
+	RefundPending    RefundStatus = "PENDING"    // Queued for the worker
+	RefundProcessing RefundStatus = "PROCESSING" // Sent to the provider
+	RefundSucceeded  RefundStatus = "SUCCEEDED"
+	RefundFailed     RefundStatus = "FAILED"
+)
+
+func (s RefundStatus) IsTerminal() bool {
+	return s == RefundSucceeded || s == RefundFailed
+}
+
+// Refund returns some or all of a successful payment's money to the
+// customer; a payment may be refunded several times up to its amount
+type Refund struct {
+	ID                uuid.UUID    `json:"id"`
+	PaymentID         uuid.UUID    `json:"payment_id"`
+	Amount            Amount       `json:"amount"`
+	Currency          Currency     `json:"currency"`
+	Reason            string       `json:"reason"`
+	Status            RefundStatus `json:"status"`
+	FailureReason     string       `json:"failure_reason,omitempty"`
+	ProviderReference string       `json:"provider_reference,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+type CreateRefundRequest struct {
+	Amount Amount `json:"amount,omitempty"` // Omit to refund whatever has not been refunded yet
+	Reason string `json:"reason" validate:"required,max=200"`
+}
+
+func (r *CreateRefundRequest) Validate() error {
+	if r.Amount < 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if len(r.Reason) > 200 {
+		return errors.New("reason is too long")
+	}
+
+	return nil
+}
+
+var (
+	ErrRefundNotFound       = errors.New("refund not found")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the payment's unrefunded amount")
+	ErrPaymentNotRefundable = errors.New("only successful payments can be refunded")
+	ErrRefundUnsupported    = errors.New("payment provider does not support refunds")
+)
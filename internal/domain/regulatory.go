@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Longest period one regulatory report may cover
+const MaxRegulatoryReportDays = 92
+
+// RegulatoryRow is one bank, currency and Ethiopian business day of a
+// regulatory report. Payments count on the day they were created; refunds
+// on the day they were requested. Bank is the payment's bank code, or its
+// payment method for aggregator and card payments.
+type RegulatoryRow struct {
+	Date             string   `json:"date"`
+	Bank             string   `json:"bank"`
+	Currency         Currency `json:"currency"`
+	SuccessCount     int      `json:"success_count"`
+	SuccessVolume    Amount   `json:"success_volume"`
+	SuccessVolumeETB Amount   `json:"success_volume_etb"`
+	FailedCount      int      `json:"failed_count"`
+	RefundCount      int      `json:"refund_count"`
+	RefundVolume     Amount   `json:"refund_volume"`
+}
+
+// RegulatoryReport is a generated NBE transaction report. The CSV is kept
+// as generated; Checksum is its SHA-256.
+type RegulatoryReport struct {
+	ID              uuid.UUID `json:"id"`
+	From            string    `json:"from"` // First business day, YYYY-MM-DD
+	To              string    `json:"to"`   // Last business day, inclusive
+	InstitutionCode string    `json:"institution_code"`
+	RowCount        int       `json:"row_count"`
+	Checksum        string    `json:"checksum"`
+	GeneratedBy     string    `json:"generated_by"` // Operator, or "scheduler"
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type GenerateRegulatoryReportRequest struct {
+	From string `json:"from" validate:"required"` // YYYY-MM-DD
+	To   string `json:"to,omitempty"`             // YYYY-MM-DD, defaults to From
+}
+
+// Validate checks the period covers whole business days before today
+func (r *GenerateRegulatoryReportRequest) Validate(today string) error {
+	from, err := time.Parse("2006-01-02", r.From)
+	if err != nil {
+		return errors.New("from must be a date in YYYY-MM-DD format")
+	}
+	if r.To == "" {
+		r.To = r.From
+	}
+	to, err := time.Parse("2006-01-02", r.To)
+	if err != nil {
+		return errors.New("to must be a date in YYYY-MM-DD format")
+	}
+
+	if to.Before(from) {
+		return errors.New("to must not be before from")
+	}
+	if r.To >= today {
+		return errors.New("to must be before today; a day is reported once it has ended")
+	}
+	if to.Sub(from) >= MaxRegulatoryReportDays*24*time.Hour {
+		return errors.New("period may cover at most 92 days")
+	}
+	return nil
+}
+
+var ErrRegulatoryReportNotFound = errors.New("regulatory report not found")
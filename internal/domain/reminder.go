@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What a reminder is chasing. Payment links and invoices add their own
+// subject types when they schedule reminders.
+type ReminderSubject string
+
+const (
+	ReminderSubjectPayment ReminderSubject = "PAYMENT" // Payment awaiting customer confirmation
+)
+
+type ReminderStatus string
+
+const (
+	ReminderActive    ReminderStatus = "ACTIVE"
+	ReminderCompleted ReminderStatus = "COMPLETED" // Paid, or every step sent
+	ReminderExpired   ReminderStatus = "EXPIRED"
+	ReminderCancelled ReminderStatus = "CANCELLED"
+)
+
+// PaymentReminder tracks the reminder schedule for one unpaid subject.
+// Step counts reminders already sent; NextAt is when the next one is due.
+type PaymentReminder struct {
+	ID          uuid.UUID       `json:"id"`
+	SubjectType ReminderSubject `json:"subject_type"`
+	SubjectID   uuid.UUID       `json:"subject_id"`
+	PaymentID   uuid.UUID       `json:"payment_id"`
+	Step        int             `json:"step"`
+	NextAt      time.Time       `json:"next_at"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+	Status      ReminderStatus  `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SagaStatus tracks a multi-step operation through its steps and, if a step
+// keeps failing, back through their compensations
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "RUNNING"
+	SagaCompensating SagaStatus = "COMPENSATING"
+	SagaCompleted    SagaStatus = "COMPLETED"
+	SagaCompensated  SagaStatus = "COMPENSATED" // Undone after a step failed
+	SagaFailed       SagaStatus = "FAILED"      // A compensation kept failing; needs an operator
+)
+
+func (s SagaStatus) IsTerminal() bool {
+	return s == SagaCompleted || s == SagaCompensated || s == SagaFailed
+}
+
+// SagaRun is the persisted state of one saga. While running, Step is the
+// next step to do; while compensating, steps before Step remain to undo.
+type SagaRun struct {
+	ID            uuid.UUID         `json:"id"`
+	Kind          string            `json:"kind"`
+	Status        SagaStatus        `json:"status"`
+	Step          int               `json:"step"`
+	Data          map[string]string `json:"data"` // Shared by the steps, saved after each one
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+var (
+	ErrSagaNotFound = errors.New("saga not found")
+	ErrUnknownSaga  = errors.New("no saga registered for this kind")
+)
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScreeningHit is one watch list entry a customer name matched
+type ScreeningHit struct {
+	List   string  `json:"list"`   // e.g. UN, OFAC, or the local list's name
+	Name   string  `json:"name"`   // Name as it appears on the list
+	Score  float64 `json:"score"`  // 0-1; 1 is an exact match
+	Source string  `json:"source"` // "local" or the screening API
+}
+
+type PaymentReviewStatus string
+
+const (
+	ReviewOpen     PaymentReviewStatus = "OPEN"
+	ReviewCleared  PaymentReviewStatus = "CLEARED"  // False positive; the payment was released
+	ReviewRejected PaymentReviewStatus = "REJECTED" // The payment was failed
+)
+
+// PaymentReview holds an UNDER_REVIEW payment for a compliance officer.
+// ReleaseStatus is what the payment would have been created in, and the
+// status it takes when cleared.
+type PaymentReview struct {
+	ID            uuid.UUID           `json:"id"`
+	PaymentID     uuid.UUID           `json:"payment_id"`
+	CustomerName  string              `json:"customer_name"`
+	Hits          []ScreeningHit      `json:"hits"`
+	Reason        string              `json:"reason"`
+	ReleaseStatus PaymentStatus       `json:"release_status"`
+	Status        PaymentReviewStatus `json:"status"`
+	DecisionNote  string              `json:"decision_note,omitempty"`
+	DecidedBy     string              `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time          `json:"decided_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+type ReviewDecisionRequest struct {
+	Decision PaymentReviewStatus `json:"decision" validate:"required,oneof=CLEARED REJECTED"`
+	Note     string              `json:"note" validate:"required,min=10,max=500"`
+}
+
+func (r *ReviewDecisionRequest) Validate() error {
+	if r.Decision != ReviewCleared && r.Decision != ReviewRejected {
+		return errors.New("decision must be CLEARED or REJECTED")
+	}
+
+	r.Note = strings.TrimSpace(r.Note)
+	if len(r.Note) < 10 {
+		return errors.New("note must be at least 10 characters")
+	}
+	if len(r.Note) > 500 {
+		return errors.New("note is too long")
+	}
+
+	return nil
+}
+
+var (
+	ErrPaymentReviewNotFound = errors.New("payment review not found")
+	ErrReviewAlreadyDecided  = errors.New("payment review already decided")
+)
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SettlementFileStatus string
+
+const (
+	SettlementParsed           SettlementFileStatus = "PARSED"
+	SettlementParsedWithErrors SettlementFileStatus = "PARSED_WITH_ERRORS" // Some lines could not be read, see ParseErrors
+)
+
+// SettlementParseError points at a line of the uploaded file that was skipped
+type SettlementParseError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// SettlementFile is an uploaded bank statement (MT940, camt.053 or CSV)
+type SettlementFile struct {
+	ID             uuid.UUID              `json:"id"`
+	BankCode       string                 `json:"bank_code"`
+	Format         string                 `json:"format"`
+	FileName       string                 `json:"file_name"`
+	Checksum       string                 `json:"checksum"` // SHA-256 of the file, rejects re-uploads
+	AccountNumber  string                 `json:"account_number,omitempty"`
+	Currency       string                 `json:"currency,omitempty"`
+	OpeningBalance *Amount                `json:"opening_balance,omitempty"`
+	ClosingBalance *Amount                `json:"closing_balance,omitempty"`
+	LineCount      int                    `json:"line_count"`
+	ErrorCount     int                    `json:"error_count"`
+	ParseErrors    []SettlementParseError `json:"parse_errors"`
+	Status         SettlementFileStatus   `json:"status"`
+	UploadedBy     string                 `json:"uploaded_by"`
+	ReconciledAt   *time.Time             `json:"reconciled_at,omitempty"` // Empty until the lines have been matched to payments
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// SettlementMatchStatus is the outcome of reconciling a statement line
+type SettlementMatchStatus string
+
+const (
+	MatchMatched        SettlementMatchStatus = "MATCHED"
+	MatchAmountMismatch SettlementMatchStatus = "AMOUNT_MISMATCH" // Reference found, amount differs
+	MatchStatusMismatch SettlementMatchStatus = "STATUS_MISMATCH" // Money arrived for a payment not marked SUCCESS
+	MatchDuplicate      SettlementMatchStatus = "DUPLICATE"       // Payment was already matched to another line
+	MatchUnmatched      SettlementMatchStatus = "UNMATCHED"       // No payment found
+	MatchIgnored        SettlementMatchStatus = "IGNORED"         // Debits: refunds, payouts and bank charges
+)
+
+// SettlementLine is one booked transaction from a statement
+type SettlementLine struct {
+	ID          uuid.UUID             `json:"id"`
+	FileID      uuid.UUID             `json:"file_id"`
+	LineNumber  int                   `json:"line_number"`
+	ValueDate   time.Time             `json:"value_date"`
+	Direction   string                `json:"direction"` // CREDIT or DEBIT
+	Amount      Amount                `json:"amount"`
+	Currency    string                `json:"currency"`
+	Reference   string                `json:"reference,omitempty"`
+	BankRef     string                `json:"bank_ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	PaymentID   *uuid.UUID            `json:"payment_id,omitempty"`
+	MatchStatus SettlementMatchStatus `json:"match_status,omitempty"` // Empty until reconciled
+	MatchNote   string                `json:"match_note,omitempty"`
+}
+
+// ReconciliationPayment is a payment as reconciliation sees it
+type ReconciliationPayment struct {
+	ID        uuid.UUID     `json:"id"`
+	Reference string        `json:"reference"`
+	Amount    Amount        `json:"amount"`
+	Currency  Currency      `json:"currency"`
+	AmountETB Amount        `json:"amount_etb,omitempty"` // Foreign currency payments, at the settlement rate
+	Status    PaymentStatus `json:"status"`
+	BankCode  string        `json:"bank_code,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	// Set when a line of another statement already matched the payment
+	MatchedFileID *uuid.UUID `json:"-"`
+}
+
+// ReconciliationSummary counts a statement's lines by outcome
+type ReconciliationSummary struct {
+	Lines           int    `json:"lines"`
+	Matched         int    `json:"matched"`
+	AmountMismatch  int    `json:"amount_mismatch"`
+	StatusMismatch  int    `json:"status_mismatch"`
+	Duplicate       int    `json:"duplicate"`
+	Unmatched       int    `json:"unmatched"`
+	Ignored         int    `json:"ignored"`
+	Credits         Amount `json:"credits"`          // Total of the statement's credit lines
+	MatchedAmount   Amount `json:"matched_amount"`   // Total of the matched lines
+	MissingPayments int    `json:"missing_payments"` // Successful payments at the bank no statement line matched
+	MissingAmount   Amount `json:"missing_amount"`
+}
+
+// ReconciliationReport is a statement's reconciliation: what matched, the
+// lines that need a look, and the payments the bank did not report. From
+// and To are the statement's first and last value dates.
+type ReconciliationReport struct {
+	File       *SettlementFile          `json:"file"`
+	From       string                   `json:"from,omitempty"`
+	To         string                   `json:"to,omitempty"`
+	Summary    ReconciliationSummary    `json:"summary"`
+	Mismatches []*SettlementLine        `json:"mismatches"`       // Every line that is neither matched nor ignored
+	Missing    []*ReconciliationPayment `json:"missing_payments"` // May still appear on the next day's statement
+}
+
+var (
+	ErrSettlementFileNotFound  = errors.New("settlement file not found")
+	ErrSettlementFileDuplicate = errors.New("settlement file was already uploaded")
+	ErrSettlementFileInvalid   = errors.New("settlement file could not be parsed")
+	ErrSettlementNotReconciled = errors.New("settlement file has not been reconciled yet")
+)
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tags per payment
+const MaxPaymentTags = 10
+
+// Lowercase letters, digits, '-', '_' and ':'; starts with a letter or digit
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_:-]{0,49}$`)
+
+// NormalizeTags lowercases, de-duplicates and sorts tags
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q must be 1-50 letters, digits, '-', '_' or ':'", tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxPaymentTags {
+		return nil, fmt.Errorf("at most %d tags per payment", MaxPaymentTags)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// SetTagsRequest replaces a payment's tags; an empty list clears them
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (r *SetTagsRequest) Validate() error {
+	if r.Tags == nil {
+		return errors.New("tags is required; send [] to clear")
+	}
+
+	tags, err := NormalizeTags(r.Tags)
+	if err != nil {
+		return err
+	}
+	r.Tags = tags
+	return nil
+}
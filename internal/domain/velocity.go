@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorCodeVelocityLimitExceeded is the "code" of the API error returned
+// when a velocity limit stops a payment, so integrators can tell it from
+// other rejections and retry later
+const ErrorCodeVelocityLimitExceeded = "VELOCITY_LIMIT_EXCEEDED"
+
+// VelocityLimit caps how many payments, or how much ETB value, one customer
+// may make within a sliding window. Zero leaves that measure uncapped.
+type VelocityLimit struct {
+	Window       time.Duration
+	MaxPayments  int
+	MaxAmountETB Amount
+}
+
+// VelocityUsage is a customer's activity within a window
+type VelocityUsage struct {
+	Payments  int
+	AmountETB Amount
+}
+
+// Check returns why a payment of amountETB on top of usage goes over the
+// limit, or nil
+func (l VelocityLimit) Check(usage VelocityUsage, amountETB Amount) error {
+	if l.MaxPayments > 0 && usage.Payments+1 > l.MaxPayments {
+		return fmt.Errorf("%w: at most %d payments per %s", ErrVelocityLimitExceeded, l.MaxPayments, windowText(l.Window))
+	}
+	if l.MaxAmountETB > 0 && usage.AmountETB+amountETB > l.MaxAmountETB {
+		return fmt.Errorf("%w: at most %s ETB per %s", ErrVelocityLimitExceeded, l.MaxAmountETB, windowText(l.Window))
+	}
+	return nil
+}
+
+// windowText is a window without zero units, e.g. 1h rather than 1h0m0s
+func windowText(d time.Duration) string {
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
+
+var ErrVelocityLimitExceeded = errors.New("velocity limit exceeded")
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VoucherStatus is the lifecycle of a cash voucher
+type VoucherStatus string
+
+const (
+	VoucherActive    VoucherStatus = "ACTIVE"
+	VoucherRedeemed  VoucherStatus = "REDEEMED"
+	VoucherCancelled VoucherStatus = "CANCELLED" // Replaced by a newer voucher for the same payment
+)
+
+// VoucherCodeLength is the number of digits in a voucher code, including
+// the trailing Luhn check digit that catches mistyped codes at the agent
+const VoucherCodeLength = 10
+
+// CashVoucher lets a customer settle an AWAITING_CASH payment in cash at a
+// partner bank branch or agent, who confirms it with the code.
+type CashVoucher struct {
+	ID             uuid.UUID     `json:"id"`
+	PaymentID      uuid.UUID     `json:"payment_id"`
+	Code           string        `json:"code"`
+	Amount         Amount        `json:"amount"`
+	Currency       Currency      `json:"currency"`
+	Status         VoucherStatus `json:"status"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+	RedeemedAt     *time.Time    `json:"redeemed_at,omitempty"`
+	RedeemedBy     string        `json:"redeemed_by,omitempty"`     // Agent name from the agent token
+	AgentReference string        `json:"agent_reference,omitempty"` // Agent's own transaction ID
+	Branch         string        `json:"branch,omitempty"`
+
+	// Payment details shown to the agent at lookup
+	Reference    string `json:"reference,omitempty"`
+	CustomerName string `json:"customer_name,omitempty"`
+}
+
+func (v *CashVoucher) IsExpired(now time.Time) bool {
+	return !now.Before(v.ExpiresAt)
+}
+
+// RedeemedFor reports whether this exact agent transaction already paid the
+// voucher, so a retried confirmation can repeat the earlier answer
+func (v *CashVoucher) RedeemedFor(agent, agentReference string) bool {
+	return v.Status == VoucherRedeemed && v.RedeemedBy == agent && v.AgentReference == agentReference
+}
+
+// CheckRedeemable verifies the voucher can be paid now with amount
+func (v *CashVoucher) CheckRedeemable(amount Amount, now time.Time) error {
+	switch {
+	case v.Status != VoucherActive:
+		return ErrVoucherNotActive
+	case v.IsExpired(now):
+		return ErrVoucherExpired
+	case v.Amount != amount:
+		return ErrVoucherAmount
+	}
+	return nil
+}
+
+// ConfirmVoucherRequest is sent by the agent system after taking the cash
+type ConfirmVoucherRequest struct {
+	Amount         Amount `json:"amount" validate:"required,gt=0"`
+	AgentReference string `json:"agent_reference" validate:"required,max=64"`
+	Branch         string `json:"branch,omitempty" validate:"max=100"`
+}
+
+func (r *ConfirmVoucherRequest) Validate() error {
+	if r.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	r.AgentReference = strings.TrimSpace(r.AgentReference)
+	if r.AgentReference == "" {
+		return errors.New("agent_reference is required")
+	}
+	if len(r.AgentReference) > 64 {
+		return errors.New("agent_reference is too long")
+	}
+
+	r.Branch = strings.TrimSpace(r.Branch)
+	if len(r.Branch) > 100 {
+		return errors.New("branch is too long")
+	}
+
+	return nil
+}
+
+// GenerateVoucherCode returns random digits followed by a Luhn check digit
+func GenerateVoucherCode() (string, error) {
+	digits := make([]byte, VoucherCodeLength-1)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	// A leading zero is easily dropped when read out or typed
+	if digits[0] == '0' {
+		digits[0] = '1'
+	}
+
+	return string(digits) + string(luhnDigit(string(digits))), nil
+}
+
+// NormalizeVoucherCode strips the spaces and dashes agents type for
+// readability and verifies the check digit
+func NormalizeVoucherCode(code string) (string, bool) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+
+	if len(code) != VoucherCodeLength {
+		return "", false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	if luhnDigit(code[:VoucherCodeLength-1]) != code[VoucherCodeLength-1] {
+		return "", false
+	}
+
+	return code, true
+}
+
+func luhnDigit(digits string) byte {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+var (
+	ErrVoucherNotFound      = errors.New("voucher not found")
+	ErrVoucherExpired       = errors.New("voucher has expired")
+	ErrVoucherNotActive     = errors.New("voucher is no longer active")
+	ErrVoucherAmount        = errors.New("amount does not match the voucher")
+	ErrVoucherNotAllowed    = errors.New("payment cannot be paid by cash voucher")
+	ErrVoucherPaymentClosed = errors.New("payment is no longer awaiting cash")
+	ErrVoucherCodeTaken     = errors.New("voucher code already in use")
+	ErrCashPaymentsDisabled = errors.New("cash voucher payments are not enabled")
+)
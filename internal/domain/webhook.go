@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvents are the events a webhook endpoint can subscribe to
+var WebhookEvents = []NotificationEvent{EventPaymentSucceeded, EventPaymentFailed, EventPaymentExpired}
+
+// WebhookEndpoint is a merchant URL that receives payment events by HTTP
+// POST. Each request is signed with the endpoint's secret (see
+// pkg/webhook), which is only returned when the endpoint is registered.
+type WebhookEndpoint struct {
+	ID          uuid.UUID           `json:"id"`
+	URL         string              `json:"url"`
+	Description string              `json:"description,omitempty"`
+	Events      []NotificationEvent `json:"events"` // Empty means every event
+	Active      bool                `json:"active"`
+	Secret      string              `json:"secret,omitempty"`
+	MerchantID  *uuid.UUID          `json:"merchant_id,omitempty"` // Receives only this merchant's payments
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// OwnedBy reports whether a caller scoped to merchantID may manage the
+// endpoint; a nil merchantID (an operator) manages every endpoint
+func (e *WebhookEndpoint) OwnedBy(merchantID *uuid.UUID) bool {
+	return merchantID == nil || (e.MerchantID != nil && *e.MerchantID == *merchantID)
+}
+
+// Subscribed reports whether the endpoint wants event
+func (e *WebhookEndpoint) Subscribed(event NotificationEvent) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+type RegisterWebhookRequest struct {
+	URL         string              `json:"url" validate:"required,url"`
+	Description string              `json:"description,omitempty" validate:"max=200"`
+	Events      []NotificationEvent `json:"events,omitempty"` // Omit for every event
+}
+
+func (r *RegisterWebhookRequest) Validate() error {
+	r.URL = strings.TrimSpace(r.URL)
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	if len(r.URL) > 500 {
+		return errors.New("url is too long")
+	}
+
+	r.Description = strings.TrimSpace(r.Description)
+	if len(r.Description) > 200 {
+		return errors.New("description is too long")
+	}
+
+	seen := map[NotificationEvent]bool{}
+	events := r.Events[:0]
+	for _, event := range r.Events {
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("unsupported event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	r.Events = events
+
+	return nil
+}
+
+func isWebhookEvent(event NotificationEvent) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus follows one event to one endpoint
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "PENDING" // Waiting for its first or next attempt
+	WebhookDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookFailed    WebhookDeliveryStatus = "FAILED" // Gave up after the last attempt; kept for inspection and replay
+)
+
+// WebhookDelivery is one event queued for one endpoint. The payload is
+// fixed when the event happens, so retries send the same body.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id"`
+	Event          NotificationEvent     `json:"event"`
+	PaymentID      uuid.UUID             `json:"payment_id"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt is one HTTP POST of a delivery
+type WebhookAttempt struct {
+	ID            uuid.UUID `json:"id"`
+	DeliveryID    uuid.UUID `json:"delivery_id"`
+	AttemptNumber int       `json:"attempt_number"`
+	StatusCode    int       `json:"status_code,omitempty"` // Zero when no response was received
+	Error         string    `json:"error,omitempty"`
+	DurationMS    int64     `json:"duration_ms"`
+	AttemptedAt   time.Time `json:"attempted_at"`
+}
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookNotReplayable    = errors.New("only failed webhook deliveries can be replayed")
+	ErrWebhookEndpointInactive = errors.New("webhook endpoint has been deleted")
+)
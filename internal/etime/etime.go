@@ -0,0 +1,70 @@
+// Package etime is Ethiopian time (EAT, Africa/Addis_Ababa). Business days,
+// reference dates, receipts and logs follow the Ethiopian wall clock
+// whatever zone the host runs in.
+package etime
+
+import (
+	"time"
+	_ "time/tzdata" // Containers often ship without a zone database
+
+	"github.com/sirupsen/logrus"
+)
+
+// Location is Africa/Addis_Ababa
+var Location = mustLoad("Africa/Addis_Ababa")
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err) // Unreachable with the embedded database
+	}
+	return loc
+}
+
+// Layouts for Ethiopian wall-clock times; MST prints the zone as "EAT"
+const (
+	DateLayout     = "2006-01-02"
+	DateTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+// Now is the current time in Ethiopia
+func Now() time.Time {
+	return time.Now().In(Location)
+}
+
+// In converts t to Ethiopian time
+func In(t time.Time) time.Time {
+	return t.In(Location)
+}
+
+// Date is the Ethiopian calendar day of t in Gregorian form, YYYY-MM-DD
+func Date(t time.Time) string {
+	return t.In(Location).Format(DateLayout)
+}
+
+// StartOfDay is midnight in Ethiopia at the start of t's Ethiopian day
+func StartOfDay(t time.Time) time.Time {
+	local := t.In(Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Location)
+}
+
+// ParseDate reads a YYYY-MM-DD date as midnight in Ethiopia
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, value, Location)
+}
+
+// LogFormatter stamps log entries with Ethiopian time, so a TimestampFormat
+// ending in MST reads "EAT" on any host
+func LogFormatter(formatter logrus.Formatter) logrus.Formatter {
+	return logFormatter{formatter}
+}
+
+type logFormatter struct {
+	logrus.Formatter
+}
+
+func (f logFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	local := *entry
+	local.Time = entry.Time.In(Location)
+	return f.Formatter.Format(&local)
+}
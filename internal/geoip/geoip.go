@@ -0,0 +1,90 @@
+// Package geoip maps client IP addresses to ISO 3166 country codes using a
+// range file such as the DB-IP or IP2Location LITE country CSVs.
+package geoip
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Resolver returns the two-letter country for an address, or "" if unknown
+type Resolver interface {
+	Country(ip netip.Addr) string
+}
+
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+type rangeResolver struct {
+	ranges []ipRange // Sorted by start, non-overlapping
+}
+
+// LoadCSV reads "start_ip,end_ip,country" rows; IPv4 and IPv6 ranges may be
+// mixed. Rows that do not parse (headers, comments) are skipped.
+func LoadCSV(path string) (Resolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseCSV(f)
+}
+
+func parseCSV(r io.Reader) (Resolver, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var ranges []ipRange
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %w", err)
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		start, err1 := netip.ParseAddr(strings.TrimSpace(record[0]))
+		end, err2 := netip.ParseAddr(strings.TrimSpace(record[1]))
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if err1 != nil || err2 != nil || len(country) != 2 || start.Is4() != end.Is4() || end.Less(start) {
+			continue
+		}
+		ranges = append(ranges, ipRange{start: start, end: end, country: country})
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("geoip: no ranges found")
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Less(ranges[j].start) })
+	return &rangeResolver{ranges: ranges}, nil
+}
+
+func (r *rangeResolver) Country(ip netip.Addr) string {
+	ip = ip.Unmap()
+
+	// Last range starting at or before ip
+	i := sort.Search(len(r.ranges), func(i int) bool { return ip.Less(r.ranges[i].start) }) - 1
+	if i < 0 {
+		return ""
+	}
+
+	rng := r.ranges[i]
+	if rng.start.Is4() != ip.Is4() || rng.end.Less(ip) {
+		return ""
+	}
+	return rng.country
+}
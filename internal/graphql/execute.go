@@ -0,0 +1,489 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request as clients POST it
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error codes in an error's extensions
+const (
+	CodeParseFailed      = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+	CodeBadUserInput     = "BAD_USER_INPUT"
+	CodeInternal         = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is a GraphQL error. Resolvers return one to set its extensions;
+// other errors are reported with their message.
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// NewError is an error with an extensions code
+func NewError(code, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Extensions: map[string]any{"code": code}}
+}
+
+// errorResponse is a request that failed before execution
+func errorResponse(code string, err error) *Response {
+	return &Response{Errors: []*Error{NewError(code, "%s", err.Error())}}
+}
+
+// Execute runs a query or mutation. Requests that fail to parse or
+// validate have no data; resolver errors null their field and are
+// listed alongside the rest of the result.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	ex, op, resp := s.prepare(req)
+	if resp != nil {
+		return resp
+	}
+
+	var root *Object
+	switch op.Kind {
+	case OperationQuery:
+		root = s.Query
+	case OperationMutation:
+		root = s.Mutation
+	default:
+		return errorResponse(CodeBadUserInput, fmt.Errorf("%s operations are not executed here", op.Kind))
+	}
+
+	data, _ := ex.selectionSet(ctx, root, nil, op.Selections, nil)
+	return &Response{Data: data, Errors: ex.errors}
+}
+
+// Subscribe starts a subscription operation, which has one root field.
+// Each event yields a response on the channel, which is closed when the
+// field's event stream ends or ctx is done. A request that cannot start
+// returns a response with its errors instead.
+func (s *Schema) Subscribe(ctx context.Context, req Request) (<-chan *Response, *Response) {
+	ex, op, resp := s.prepare(req)
+	if resp != nil {
+		return nil, resp
+	}
+	if op.Kind != OperationSubscription {
+		return nil, errorResponse(CodeBadUserInput, fmt.Errorf("%s operations are not subscriptions", op.Kind))
+	}
+
+	fields, order := ex.collectFields(s.Subscription, op.Selections, map[string]bool{})
+	if len(order) != 1 || len(fields[order[0]]) != 1 {
+		return nil, errorResponse(CodeValidationFailed, errors.New("a subscription must select exactly one field"))
+	}
+	node := fields[order[0]][0]
+	field := s.Subscription.field(node.Name)
+	if field == nil || field.Subscribe == nil {
+		return nil, errorResponse(CodeValidationFailed, fmt.Errorf("cannot subscribe to %q", node.Name))
+	}
+
+	args, err := coerceArgs(field.Args, node.Arguments, ex.vars)
+	if err != nil {
+		return nil, errorResponse(CodeBadUserInput, err)
+	}
+	events, err := field.Subscribe(ctx, args)
+	if err != nil {
+		return nil, &Response{Errors: []*Error{toError(err, node, []any{node.ResponseKey()})}}
+	}
+
+	responses := make(chan *Response)
+	go func() {
+		defer close(responses)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				run := &executor{schema: s, doc: ex.doc, vars: ex.vars}
+				data, _ := run.selectionSet(ctx, s.Subscription, event, op.Selections, nil)
+				select {
+				case responses <- &Response{Data: data, Errors: run.errors}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return responses, nil
+}
+
+// prepare parses and validates a request, picking its operation and
+// setting its variables
+func (s *Schema) prepare(req Request) (*executor, *Operation, *Response) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, nil, errorResponse(CodeBadUserInput, errors.New("query is required"))
+	}
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return nil, nil, errorResponse(CodeParseFailed, err)
+	}
+
+	var op *Operation
+	for _, candidate := range doc.Operations {
+		if req.OperationName == "" || candidate.Name == req.OperationName {
+			if op != nil {
+				return nil, nil, errorResponse(CodeBadUserInput, errors.New("operationName is required for a document with several operations"))
+			}
+			op = candidate
+		}
+	}
+	if op == nil {
+		return nil, nil, errorResponse(CodeBadUserInput, fmt.Errorf("operation %q not found", req.OperationName))
+	}
+
+	root := map[string]*Object{OperationQuery: s.Query, OperationMutation: s.Mutation, OperationSubscription: s.Subscription}[op.Kind]
+	if root == nil {
+		return nil, nil, errorResponse(CodeValidationFailed, fmt.Errorf("schema does not support %s operations", op.Kind))
+	}
+
+	vars := map[string]any{}
+	for _, def := range op.Variables {
+		vars[definedMarker+def.Name] = true
+		value, ok := req.Variables[def.Name]
+		if !ok && def.Default != nil {
+			value, ok = def.Default, true
+		}
+		if def.Required && value == nil {
+			return nil, nil, errorResponse(CodeBadUserInput, fmt.Errorf("variable $%s of type %s is required", def.Name, def.Type))
+		}
+		if ok {
+			vars[def.Name] = value
+		}
+	}
+
+	ex := &executor{schema: s, doc: doc, vars: vars}
+	if err := ex.validate(root, op.Selections, map[string]bool{}); err != nil {
+		return nil, nil, errorResponse(CodeValidationFailed, err)
+	}
+	return ex, op, nil
+}
+
+type executor struct {
+	schema *Schema
+	doc    *Document
+	vars   map[string]any
+	errors []*Error
+}
+
+// validate checks that the selections name fields of t, with
+// subselections exactly on object fields
+func (ex *executor) validate(t *Object, selections []Selection, spreading map[string]bool) error {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *FieldSelection:
+			if sel.Name == "__typename" {
+				if sel.Selections != nil {
+					return fmt.Errorf("field \"__typename\" must not have a selection at line %d, column %d", sel.Line, sel.Col)
+				}
+				continue
+			}
+			field := t.field(sel.Name)
+			if field == nil {
+				return fmt.Errorf("cannot query field %q on type %q at line %d, column %d", sel.Name, t.Name, sel.Line, sel.Col)
+			}
+			for name := range sel.Arguments {
+				if findArg(field.Args, name) == nil {
+					return fmt.Errorf("unknown argument %q on field %q at line %d, column %d", name, sel.Name, sel.Line, sel.Col)
+				}
+			}
+			object, isObject := namedType(field.Type).(*Object)
+			switch {
+			case isObject && sel.Selections == nil:
+				return fmt.Errorf("field %q of type %s must have a selection of subfields at line %d, column %d", sel.Name, field.Type, sel.Line, sel.Col)
+			case !isObject && sel.Selections != nil:
+				return fmt.Errorf("field %q of type %s must not have a selection at line %d, column %d", sel.Name, field.Type, sel.Line, sel.Col)
+			case isObject:
+				if err := ex.validate(object, sel.Selections, spreading); err != nil {
+					return err
+				}
+			}
+		case *InlineFragment:
+			if err := ex.validate(ex.conditionType(t, sel.TypeCondition), sel.Selections, spreading); err != nil {
+				return err
+			}
+		case *FragmentSpread:
+			fragment, ok := ex.doc.Fragments[sel.Name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", sel.Name)
+			}
+			if spreading[sel.Name] {
+				return fmt.Errorf("fragment %q spreads itself", sel.Name)
+			}
+			spreading[sel.Name] = true
+			err := ex.validate(ex.conditionType(t, fragment.TypeCondition), fragment.Selections, spreading)
+			delete(spreading, sel.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// conditionType is the type a fragment's fields are checked against.
+// The schema has no interfaces or unions, so a condition naming another
+// type never applies and its fields are not checked.
+func (ex *executor) conditionType(t *Object, condition string) *Object {
+	if condition == "" || condition == t.Name {
+		return t
+	}
+	return &Object{Name: condition, Fields: t.Fields}
+}
+
+// collectFields flattens fragments and drops skipped fields, grouping
+// fields by response key in the order they first appear
+func (ex *executor) collectFields(t *Object, selections []Selection, visited map[string]bool) (map[string][]*FieldSelection, []string) {
+	fields := map[string][]*FieldSelection{}
+	var order []string
+
+	var collect func(selections []Selection)
+	collect = func(selections []Selection) {
+		for _, selection := range selections {
+			switch sel := selection.(type) {
+			case *FieldSelection:
+				if !ex.included(sel.Directives) {
+					continue
+				}
+				key := sel.ResponseKey()
+				if _, ok := fields[key]; !ok {
+					order = append(order, key)
+				}
+				fields[key] = append(fields[key], sel)
+			case *InlineFragment:
+				if ex.included(sel.Directives) && (sel.TypeCondition == "" || sel.TypeCondition == t.Name) {
+					collect(sel.Selections)
+				}
+			case *FragmentSpread:
+				if visited[sel.Name] || !ex.included(sel.Directives) {
+					continue
+				}
+				visited[sel.Name] = true
+				if fragment := ex.doc.Fragments[sel.Name]; fragment != nil && fragment.TypeCondition == t.Name {
+					collect(fragment.Selections)
+				}
+			}
+		}
+	}
+	collect(selections)
+	return fields, order
+}
+
+// included applies @skip and @include
+func (ex *executor) included(directives []*Directive) bool {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			continue
+		}
+		value, _, _ := substitute(d.Arguments["if"], ex.vars)
+		condition, _ := value.(bool)
+		if d.Name == "skip" && condition || d.Name == "include" && !condition {
+			return false
+		}
+	}
+	return true
+}
+
+// selectionSet resolves the selected fields of t on source. ok is false
+// when a non-null field failed, which nulls the object itself.
+func (ex *executor) selectionSet(ctx context.Context, t *Object, source any, selections []Selection, path []any) (any, bool) {
+	fields, order := ex.collectFields(t, selections, map[string]bool{})
+	result := &orderedMap{values: make(map[string]any, len(order))}
+	projected := &projection{source: source}
+
+	for _, key := range order {
+		nodes := fields[key]
+		fieldPath := append(append([]any{}, path...), key)
+
+		if nodes[0].Name == "__typename" {
+			result.set(key, t.Name)
+			continue
+		}
+
+		field := t.field(nodes[0].Name)
+		value, ok := ex.resolveField(ctx, field, nodes, source, projected, fieldPath)
+		if !ok {
+			if _, nonNull := field.Type.(*nonNullType); nonNull {
+				return nil, false
+			}
+			value = nil
+		}
+		result.set(key, value)
+	}
+	return result, true
+}
+
+func (ex *executor) resolveField(ctx context.Context, field *Field, nodes []*FieldSelection, source any, projected *projection, path []any) (any, bool) {
+	node := nodes[0]
+	args, err := coerceArgs(field.Args, node.Arguments, ex.vars)
+	if err != nil {
+		ex.errors = append(ex.errors, toError(NewError(CodeBadUserInput, "%s", err.Error()), node, path))
+		return nil, false
+	}
+
+	var value any
+	switch {
+	case field.Resolve != nil:
+		value, err = field.Resolve(ctx, source, args)
+	case field.Subscribe != nil:
+		value = source
+	default:
+		value, err = projected.get(field.Name)
+	}
+	if err != nil {
+		ex.errors = append(ex.errors, toError(err, node, path))
+		return nil, false
+	}
+
+	var selections []Selection
+	for _, n := range nodes {
+		selections = append(selections, n.Selections...)
+	}
+	return ex.complete(ctx, field.Type, node, selections, value, path)
+}
+
+// complete shapes a resolved value to its type
+func (ex *executor) complete(ctx context.Context, t Type, node *FieldSelection, selections []Selection, value any, path []any) (any, bool) {
+	if nn, ok := t.(*nonNullType); ok {
+		completed, ok := ex.complete(ctx, nn.of, node, selections, value, path)
+		if ok && completed == nil {
+			ex.errors = append(ex.errors, toError(NewError(CodeInternal, "non-null field %q resolved to null", node.Name), node, path))
+			return nil, false
+		}
+		return completed, ok
+	}
+	if isNil(value) {
+		return nil, true
+	}
+
+	switch t := t.(type) {
+	case *listType:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			ex.errors = append(ex.errors, toError(NewError(CodeInternal, "field %q resolved to a non-list", node.Name), node, path))
+			return nil, false
+		}
+		out := make([]any, items.Len())
+		for i := range out {
+			item, ok := ex.complete(ctx, t.of, node, selections, items.Index(i).Interface(), append(append([]any{}, path...), i))
+			if !ok {
+				if _, nonNull := t.of.(*nonNullType); nonNull {
+					return nil, false
+				}
+			}
+			out[i] = item
+		}
+		return out, true
+	case *Object:
+		return ex.selectionSet(ctx, t, value, selections, path)
+	}
+	return value, true
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func toError(err error, node *FieldSelection, path []any) *Error {
+	out := &Error{Message: err.Error()}
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		out.Message, out.Extensions = gqlErr.Message, gqlErr.Extensions
+	}
+	out.Locations = []Location{{Line: node.Line, Column: node.Col}}
+	out.Path = path
+	return out
+}
+
+// projection is a source's JSON properties, read once on first use
+type projection struct {
+	source any
+	fields map[string]any
+	err    error
+	done   bool
+}
+
+func (p *projection) get(name string) (any, error) {
+	if !p.done {
+		p.done = true
+		if fields, ok := p.source.(map[string]any); ok {
+			p.fields = fields
+		} else {
+			var data []byte
+			if data, p.err = json.Marshal(p.source); p.err == nil {
+				dec := json.NewDecoder(bytes.NewReader(data))
+				dec.UseNumber()
+				p.err = dec.Decode(&p.fields)
+			}
+		}
+	}
+	return p.fields[name], p.err
+}
+
+// orderedMap marshals its keys in selection order, as the spec asks
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
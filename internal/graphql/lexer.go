@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	col   int
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas
+// and comments as the spec treats them as insignificant
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"): // Byte order mark
+			l.pos += len("\uFEFF")
+		default:
+			return l.scan()
+		}
+	}
+	return token{kind: tokenEOF, line: l.line, col: l.col}, nil
+}
+
+func (l *lexer) scan() (token, error) {
+	start := token{line: l.line, col: l.col}
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		start.kind, start.value = tokenPunct, "..."
+		return start, nil
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.advance(1)
+		start.kind, start.value = tokenPunct, string(c)
+		return start, nil
+	case c == '_' || isLetter(c):
+		end := l.pos
+		for end < len(l.src) && (l.src[end] == '_' || isLetter(l.src[end]) || isDigit(l.src[end])) {
+			end++
+		}
+		start.kind, start.value = tokenName, l.src[l.pos:end]
+		l.advance(end - l.pos)
+		return start, nil
+	case c == '-' || isDigit(c):
+		return l.scanNumber(start)
+	case c == '"':
+		return l.scanString(start)
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return start, fmt.Errorf("unexpected character %q at line %d, column %d", r, start.line, start.col)
+}
+
+func (l *lexer) scanNumber(start token) (token, error) {
+	end := l.pos
+	if l.src[end] == '-' {
+		end++
+	}
+	digits := end
+	for end < len(l.src) && isDigit(l.src[end]) {
+		end++
+	}
+	if end == digits {
+		return start, fmt.Errorf("invalid number at line %d, column %d", start.line, start.col)
+	}
+
+	start.kind = tokenInt
+	if end < len(l.src) && l.src[end] == '.' {
+		start.kind = tokenFloat
+		end++
+		for end < len(l.src) && isDigit(l.src[end]) {
+			end++
+		}
+	}
+	if end < len(l.src) && (l.src[end] == 'e' || l.src[end] == 'E') {
+		start.kind = tokenFloat
+		end++
+		if end < len(l.src) && (l.src[end] == '+' || l.src[end] == '-') {
+			end++
+		}
+		for end < len(l.src) && isDigit(l.src[end]) {
+			end++
+		}
+	}
+
+	start.value = l.src[l.pos:end]
+	l.advance(end - l.pos)
+	return start, nil
+}
+
+func (l *lexer) scanString(start token) (token, error) {
+	start.kind = tokenString
+
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return start, fmt.Errorf("unterminated block string at line %d, column %d", start.line, start.col)
+		}
+		start.value = strings.TrimSpace(l.src[l.pos+3 : l.pos+3+end])
+		l.advance(end + 6)
+		return start, nil
+	}
+
+	l.advance(1)
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			start.value = b.String()
+			return start, nil
+		case c == '\n':
+			return start, fmt.Errorf("unterminated string at line %d, column %d", start.line, start.col)
+		case c == '\\' && l.pos+1 < len(l.src):
+			escape := l.src[l.pos+1]
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					return start, fmt.Errorf("invalid unicode escape at line %d", l.line)
+				}
+				var r rune
+				if _, err := fmt.Sscanf(l.src[l.pos+2:l.pos+6], "%04x", &r); err != nil {
+					return start, fmt.Errorf("invalid unicode escape at line %d", l.line)
+				}
+				b.WriteRune(r)
+				l.advance(4)
+			default:
+				return start, fmt.Errorf("invalid escape \\%c at line %d", escape, l.line)
+			}
+			l.advance(2)
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.advance(size)
+		}
+	}
+	return start, fmt.Errorf("unterminated string at line %d, column %d", start.line, start.col)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,504 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Document is a parsed executable GraphQL document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation kinds
+const (
+	OperationQuery        = "query"
+	OperationMutation     = "mutation"
+	OperationSubscription = "subscription"
+)
+
+type Operation struct {
+	Kind       string
+	Name       string
+	Variables  []*VariableDefinition
+	Selections []Selection
+}
+
+type VariableDefinition struct {
+	Name     string
+	Type     string // As written, e.g. "ID!" or "[String]"
+	Default  any
+	Required bool
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Selections    []Selection
+}
+
+// Selection is a *FieldSelection, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+type FieldSelection struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]any
+	Directives []*Directive
+	Selections []Selection
+	Line, Col  int
+}
+
+// ResponseKey is the alias, or the name without one
+func (f *FieldSelection) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	Selections    []Selection
+}
+
+type Directive struct {
+	Name      string
+	Arguments map[string]any
+}
+
+// Variable is a $name reference in an argument value
+type Variable string
+
+// EnumValue is an unquoted enum literal, e.g. SUCCESS
+type EnumValue string
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// Parse reads an executable document: operations and fragments
+func Parse(src string) (*Document, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Kind: OperationQuery, Selections: selections})
+		case p.peek(tokenName, "fragment"):
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, fmt.Errorf("fragment %q is defined more than once", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		case p.peek(tokenName, OperationQuery), p.peek(tokenName, OperationMutation), p.peek(tokenName, OperationSubscription):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) read() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of document")
+	}
+	return fmt.Errorf("unexpected %q at line %d, column %d", p.tok.value, p.tok.line, p.tok.col)
+}
+
+func (p *parser) expect(value string) error {
+	if !p.peek(tokenPunct, value) {
+		return p.unexpected()
+	}
+	return p.read()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.read()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := &Operation{Kind: p.tok.value}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.Name = p.tok.value
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek(tokenPunct, "(") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		for !p.peek(tokenPunct, ")") {
+			def, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = append(op.Variables, def)
+		}
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	op.Selections, err = p.selectionSet()
+	return op, err
+}
+
+func (p *parser) variableDefinition() (*VariableDefinition, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	def := &VariableDefinition{Name: name, Type: typ, Required: typ[len(typ)-1] == '!'}
+
+	if p.peek(tokenPunct, "=") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if def.Default, err = p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if p.peek(tokenPunct, "[") {
+		if err := p.read(); err != nil {
+			return "", err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+
+	if p.peek(tokenPunct, "!") {
+		if err := p.read(); err != nil {
+			return "", err
+		}
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("fragment cannot be named \"on\"")
+	}
+	if !p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	typeCondition, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &Fragment{Name: name, TypeCondition: typeCondition, Selections: selections}, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []Selection
+	for !p.peek(tokenPunct, "}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set at line %d, column %d", p.tok.line, p.tok.col)
+	}
+	return selections, p.read()
+}
+
+func (p *parser) selection() (Selection, error) {
+	if p.peek(tokenPunct, "...") {
+		return p.fragmentSelection()
+	}
+
+	field := &FieldSelection{Line: p.tok.line, Col: p.tok.col}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, ":") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		field.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+
+	if field.Arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, "{") {
+		if field.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) fragmentSelection() (Selection, error) {
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		name := p.tok.value
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		directives, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		return &FragmentSpread{Name: name, Directives: directives}, nil
+	}
+
+	inline := &InlineFragment{}
+	if p.peek(tokenName, "on") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		var err error
+		if inline.TypeCondition, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if inline.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	inline.Selections, err = p.selectionSet()
+	return inline, err
+}
+
+func (p *parser) arguments() (map[string]any, error) {
+	if !p.peek(tokenPunct, "(") {
+		return nil, nil
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+
+	args := map[string]any{}
+	for !p.peek(tokenPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := args[name]; ok {
+			return nil, fmt.Errorf("argument %q is given more than once", name)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.read()
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek(tokenPunct, "@") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &Directive{Name: name, Arguments: args})
+	}
+	return directives, nil
+}
+
+// value reads an argument value. Constant values, such as variable
+// defaults, may not reference variables.
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch {
+	case p.peek(tokenPunct, "$"):
+		if constant {
+			return nil, fmt.Errorf("variable not allowed at line %d, column %d", tok.line, tok.col)
+		}
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return Variable(name), err
+	case p.peek(tokenPunct, "["):
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.peek(tokenPunct, "]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.read()
+	case p.peek(tokenPunct, "{"):
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		object := map[string]any{}
+		for !p.peek(tokenPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.read()
+	}
+
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("integer %s out of range", tok.value)
+		}
+		return n, nil
+	case tokenFloat:
+		return strconv.ParseFloat(tok.value, 64)
+	case tokenString:
+		return tok.value, nil
+	case tokenName:
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return EnumValue(tok.value), nil
+	}
+	p.tok = tok
+	return nil, p.unexpected()
+}
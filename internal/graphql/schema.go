@@ -0,0 +1,472 @@
+// Package graphql executes GraphQL queries, mutations and subscriptions
+// against a schema declared in Go. It covers what API clients send:
+// operations with variables, aliases, fragments and @skip/@include, over
+// object, list, enum, input object and scalar types. Introspection is not
+// supported beyond __typename; Schema.SDL prints the schema instead.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type is a *Scalar, *Enum, *Object, *InputObject, or a List or NonNull of one
+type Type interface {
+	String() string
+}
+
+type Scalar struct {
+	Name        string
+	Description string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Built-in scalars
+var (
+	String  = &Scalar{Name: "String"}
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+)
+
+func builtin(s *Scalar) bool {
+	return s == String || s == Int || s == Float || s == Boolean || s == ID
+}
+
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+func (e *Enum) String() string { return e.Name }
+
+type listType struct{ of Type }
+
+func (l *listType) String() string { return "[" + l.of.String() + "]" }
+
+type nonNullType struct{ of Type }
+
+func (n *nonNullType) String() string { return n.of.String() + "!" }
+
+// List is a list of t
+func List(t Type) Type { return &listType{of: t} }
+
+// NonNull is t without null
+func NonNull(t Type) Type { return &nonNullType{of: t} }
+
+// ResolveFunc returns a field's value from its parent object's value. The
+// root types' source is nil.
+type ResolveFunc func(ctx context.Context, source any, args Args) (any, error)
+
+// SubscribeFunc starts a subscription root field's event stream, which
+// ends when ctx is done. Each event is the source of the field's Resolve,
+// or its value without one.
+type SubscribeFunc func(ctx context.Context, args Args) (<-chan any, error)
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Field is an object field. Without Resolve its value is the source's
+// JSON property of the same name, so domain types resolve as the REST
+// API renders them.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	Resolve     ResolveFunc
+	Subscribe   SubscribeFunc
+}
+
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     any
+}
+
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*Argument
+}
+
+func (i *InputObject) String() string { return i.Name }
+
+type Schema struct {
+	Query        *Object
+	Mutation     *Object
+	Subscription *Object
+}
+
+// Args are a field's arguments, coerced to their declared types: string
+// for String, ID, enums and custom scalars, int, float64, bool, []any and
+// map[string]any for input objects. Omitted arguments without a default
+// are absent.
+type Args map[string]any
+
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns the argument, or def when it is absent
+func (a Args) Int(name string, def int) int {
+	if n, ok := a[name].(int); ok {
+		return n
+	}
+	return def
+}
+
+func (a Args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// Decode unmarshals an input object argument into v through JSON, so
+// request structs bind as they do from a REST body
+func (a Args) Decode(name string, v any) error {
+	data, err := json.Marshal(a[name])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// coerceArgs checks a field's arguments and resolves variables in them
+func coerceArgs(defs []*Argument, given map[string]any, vars map[string]any) (Args, error) {
+	for name := range given {
+		if findArg(defs, name) == nil {
+			return nil, fmt.Errorf("unknown argument %q", name)
+		}
+	}
+
+	args := Args{}
+	for _, def := range defs {
+		raw, ok := given[def.Name]
+		if ok {
+			var err error
+			if raw, ok, err = substitute(raw, vars); err != nil {
+				return nil, err
+			}
+		}
+		if !ok {
+			if def.Default != nil {
+				args[def.Name] = def.Default
+				continue
+			}
+			if _, required := def.Type.(*nonNullType); required {
+				return nil, fmt.Errorf("argument %q of type %s is required", def.Name, def.Type)
+			}
+			continue
+		}
+
+		value, err := coerce(def.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", def.Name, err)
+		}
+		if value != nil {
+			args[def.Name] = value
+		}
+	}
+	return args, nil
+}
+
+func findArg(defs []*Argument, name string) *Argument {
+	for _, def := range defs {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// substitute replaces variables in a literal value; ok is false for a
+// bare variable the request did not set
+func substitute(value any, vars map[string]any) (any, bool, error) {
+	switch v := value.(type) {
+	case Variable:
+		resolved, ok := vars[string(v)]
+		if !ok {
+			if _, defined := vars[definedMarker+string(v)]; !defined {
+				return nil, false, fmt.Errorf("variable $%s is not defined", v)
+			}
+		}
+		return resolved, ok, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, _, err := substitute(item, vars)
+			if err != nil {
+				return nil, false, err
+			}
+			out[i] = resolved
+		}
+		return out, true, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, ok, err := substitute(item, vars)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out[key] = resolved
+			}
+		}
+		return out, true, nil
+	}
+	return value, true, nil
+}
+
+// definedMarker keys record variables an operation declares, set or not
+const definedMarker = "\x00"
+
+// coerce converts an input value, from a literal or JSON variables, to t
+func coerce(t Type, value any) (any, error) {
+	if nn, ok := t.(*nonNullType); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected %s, got null", t)
+		}
+		return coerce(nn.of, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *listType:
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerce(t.of, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = coerced
+		}
+		return out, nil
+	case *Enum:
+		var s string
+		switch v := value.(type) {
+		case EnumValue:
+			s = string(v)
+		case string:
+			s = v
+		}
+		for _, allowed := range t.Values {
+			if s != "" && s == allowed {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of %s", strings.Join(t.Values, ", "))
+	case *InputObject:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an input object %s", t.Name)
+		}
+		out, err := coerceArgs(t.Fields, fields, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", t.Name, err)
+		}
+		return map[string]any(out), nil
+	case *Scalar:
+		return coerceScalar(t, value)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+func coerceScalar(t *Scalar, value any) (any, error) {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			value = i
+		} else if f, err := n.Float64(); err == nil {
+			value = f
+		}
+	}
+
+	switch t {
+	case String:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case ID:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		}
+	case Int:
+		switch v := value.(type) {
+		case int64:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		}
+	case Float:
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case Boolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	default:
+		// Custom scalars arrive as text, so decimals keep their digits
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s", t.Name)
+}
+
+// namedType strips List and NonNull
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *listType:
+			t = w.of
+		case *nonNullType:
+			t = w.of
+		default:
+			return t
+		}
+	}
+}
+
+// SDL prints the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	types := map[string]Type{}
+	var visit func(t Type)
+	visit = func(t Type) {
+		t = namedType(t)
+		if sc, ok := t.(*Scalar); ok && builtin(sc) {
+			return
+		}
+		if _, seen := types[t.String()]; seen {
+			return
+		}
+		types[t.String()] = t
+		switch t := t.(type) {
+		case *Object:
+			for _, f := range t.Fields {
+				visit(f.Type)
+				for _, a := range f.Args {
+					visit(a.Type)
+				}
+			}
+		case *InputObject:
+			for _, f := range t.Fields {
+				visit(f.Type)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("schema {\n")
+	for _, root := range []struct {
+		name string
+		obj  *Object
+	}{{"query", s.Query}, {"mutation", s.Mutation}, {"subscription", s.Subscription}} {
+		if root.obj != nil {
+			fmt.Fprintf(&b, "  %s: %s\n", root.name, root.obj.Name)
+			visit(root.obj)
+		}
+	}
+	b.WriteString("}\n")
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		b.WriteString("\n")
+		switch t := types[name].(type) {
+		case *Scalar:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "scalar %s\n", t.Name)
+		case *Enum:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "enum %s {\n", t.Name)
+			for _, v := range t.Values {
+				fmt.Fprintf(&b, "  %s\n", v)
+			}
+			b.WriteString("}\n")
+		case *InputObject:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "input %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s: %s\n", f.Name, f.Type)
+			}
+			b.WriteString("}\n")
+		case *Object:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "type %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s", f.Name)
+				if len(f.Args) > 0 {
+					args := make([]string, len(f.Args))
+					for i, a := range f.Args {
+						args[i] = a.Name + ": " + a.Type.String()
+						if a.Default != nil {
+							def, _ := json.Marshal(a.Default)
+							args[i] += " = " + string(def)
+						}
+					}
+					fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+				}
+				fmt.Fprintf(&b, ": %s\n", f.Type)
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%s\n", indent, strconv.Quote(description))
+	}
+}
@@ -0,0 +1,233 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/geoip"
+	"payment-gateway/internal/service"
+	"payment-gateway/pkg/paymentpb"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// paymentServer implements paymentpb.PaymentServiceServer. Internal
+// callers act for any merchant, as operators do on the REST API.
+type paymentServer struct {
+	paymentpb.UnimplementedPaymentServiceServer
+	paymentService service.PaymentService
+	geo            geoip.Resolver
+	logger         *logrus.Logger
+}
+
+func (s *paymentServer) CreatePayment(ctx context.Context, in *paymentpb.CreatePaymentRequest) (*paymentpb.Payment, error) {
+	amount, err := domain.ParseAmount(in.GetAmount())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "amount: %v", err)
+	}
+	merchantID, err := optionalUUID(in.GetMerchantId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid merchant_id")
+	}
+
+	req := domain.CreatePaymentRequest{
+		Amount:             amount,
+		Currency:           domain.Currency(in.GetCurrency()),
+		Reference:          in.GetReference(),
+		ReferencePrefix:    in.GetReferencePrefix(),
+		Description:        in.GetDescription(),
+		CustomerName:       in.GetCustomerName(),
+		CustomerPhone:      in.GetCustomerPhone(),
+		CustomerEmail:      in.GetCustomerEmail(),
+		CustomerNationalID: in.GetCustomerNationalId(),
+		CustomerID:         in.GetCustomerId(),
+		Language:           domain.Language(in.GetLanguage()),
+		BankCode:           in.GetBankCode(),
+		RequireOTP:         in.GetRequireOtp(),
+		PayByCash:          in.GetPayByCash(),
+		PurposeCode:        in.GetPurposeCode(),
+		MCC:                in.GetMcc(),
+		FXQuoteID:          in.GetFxQuoteId(),
+		Tags:               in.GetTags(),
+		PaymentMethod:      domain.PaymentMethod(in.GetPaymentMethod()),
+		Client: domain.ClientInfo{
+			IP:                in.GetClientIp(),
+			DeviceFingerprint: in.GetDeviceFingerprint(),
+		},
+		MerchantID: merchantID,
+	}
+	if ip, err := netip.ParseAddr(req.Client.IP); err == nil && s.geo != nil {
+		req.Client.Country = s.geo.Country(ip)
+	}
+
+	payment, err := s.paymentService.CreatePayment(ctx, req)
+	if err != nil {
+		return nil, s.statusError(err, "Failed to create payment")
+	}
+	return toProto(payment), nil
+}
+
+func (s *paymentServer) GetPayment(ctx context.Context, in *paymentpb.GetPaymentRequest) (*paymentpb.Payment, error) {
+	var payment *domain.Payment
+	switch {
+	case in.GetId() != "":
+		id, err := uuid.Parse(in.GetId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid payment ID format")
+		}
+		payment, err = s.paymentService.GetPayment(ctx, id)
+		if err != nil {
+			return nil, s.statusError(err, "Failed to get payment")
+		}
+	case in.GetReference() != "":
+		var err error
+		payment, err = s.paymentService.GetPaymentByReference(ctx, in.GetReference())
+		if err != nil {
+			return nil, s.statusError(err, "Failed to get payment")
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or reference is required")
+	}
+	return toProto(payment), nil
+}
+
+func (s *paymentServer) ListPayments(ctx context.Context, in *paymentpb.ListPaymentsRequest) (*paymentpb.ListPaymentsResponse, error) {
+	merchantID, err := optionalUUID(in.GetMerchantId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid merchant_id")
+	}
+
+	filter := domain.PaymentFilter{
+		PurposeCode:  in.GetPurposeCode(),
+		MCC:          in.GetMcc(),
+		LimitFlagged: in.GetLimitFlagged(),
+		Tags:         in.GetTags(),
+		MerchantID:   merchantID,
+		Status:       domain.PaymentStatus(in.GetStatus()),
+		Currency:     domain.Currency(in.GetCurrency()),
+		BankCode:     in.GetBankCode(),
+		CreatedFrom:  in.GetCreatedFrom(),
+		CreatedTo:    in.GetCreatedTo(),
+		Sort:         domain.PaymentSort(in.GetSort()),
+	}
+	if value := in.GetMinAmount(); value != "" {
+		if filter.MinAmount, err = domain.ParseAmount(value); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "min_amount: %v", err)
+		}
+	}
+	if value := in.GetMaxAmount(); value != "" {
+		if filter.MaxAmount, err = domain.ParseAmount(value); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "max_amount: %v", err)
+		}
+	}
+
+	page, limit := int(in.GetPage()), int(in.GetLimit())
+	result, err := s.paymentService.ListPayments(ctx, filter, in.GetCursor(), page, limit)
+	if err != nil {
+		return nil, s.statusError(err, "Failed to list payments")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	resp := &paymentpb.ListPaymentsResponse{
+		Payments:   make([]*paymentpb.Payment, len(result.Payments)),
+		Total:      int32(result.Total),
+		TotalPages: int32(domain.TotalPages(result.Total, limit)),
+		Page:       int32(page),
+		Limit:      int32(limit),
+		HasMore:    result.HasMore,
+		NextCursor: result.NextCursor,
+	}
+	for i, payment := range result.Payments {
+		resp.Payments[i] = toProto(payment)
+	}
+	return resp, nil
+}
+
+// statusError maps service errors to gRPC codes as the REST handlers map
+// them to HTTP statuses; unexpected errors are logged and hidden
+func (s *paymentServer) statusError(err error, message string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput), err == domain.ErrBusinessHours, err == domain.ErrAmountTooLarge,
+		err == domain.ErrOTPUnavailable, err == domain.ErrMethodUnavailable:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case err == domain.ErrPaymentNotFound, err == domain.ErrFXQuoteNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case err == domain.ErrPaymentAlreadyExists:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case err == domain.ErrFXQuoteExpired, err == domain.ErrFXQuoteUsed,
+		errors.Is(err, domain.ErrCustomerLimitExceeded), errors.Is(err, domain.ErrCustomerNotVerified):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrClientBlocked), err == domain.ErrPaymentDeclined:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrVelocityLimitExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	s.logger.WithError(err).Error(message)
+	return status.Error(codes.Internal, message)
+}
+
+func optionalUUID(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func toProto(p *domain.Payment) *paymentpb.Payment {
+	out := &paymentpb.Payment{
+		Id:                 p.ID.String(),
+		Reference:          p.Reference,
+		Amount:             p.Amount.String(),
+		Currency:           string(p.Currency),
+		Status:             string(p.Status),
+		PaymentMethod:      string(p.PaymentMethod),
+		Description:        p.Description,
+		CustomerName:       p.CustomerName,
+		CustomerPhone:      p.CustomerPhone,
+		CustomerEmail:      p.CustomerEmail,
+		CustomerNationalId: p.CustomerNationalID,
+		Language:           string(p.Language),
+		BankCode:           p.BankCode,
+		PurposeCode:        p.PurposeCode,
+		Mcc:                p.MCC,
+		LimitFlag:          string(p.LimitFlag),
+		FxRate:             p.FXRate,
+		Tags:               p.Tags,
+		ProviderReference:  p.ProviderReference,
+		CheckoutUrl:        p.CheckoutURL,
+		CreatedAt:          timestamppb.New(p.CreatedAt),
+		UpdatedAt:          timestamppb.New(p.UpdatedAt),
+	}
+	if p.CustomerID != nil {
+		out.CustomerId = p.CustomerID.String()
+	}
+	if p.FXQuoteID != nil {
+		out.FxQuoteId = p.FXQuoteID.String()
+	}
+	if p.AmountETB != 0 {
+		out.AmountEtb = p.AmountETB.String()
+	}
+	if p.RefundedAmount != 0 {
+		out.RefundedAmount = p.RefundedAmount.String()
+	}
+	if p.MerchantID != nil {
+		out.MerchantId = p.MerchantID.String()
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+// Package grpcapi serves the payment service of proto/payment/v1 to the
+// gateway's internal Go services, over the same service layer as the
+// REST API
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/geoip"
+	"payment-gateway/internal/service"
+	"payment-gateway/pkg/paymentpb"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client is an internal service allowed to call the API
+type Client struct {
+	Name  string
+	Token string
+}
+
+type Server struct {
+	addr   string
+	server *grpc.Server
+	logger *logrus.Logger
+}
+
+func NewServer(addr string, clients []Client, paymentService service.PaymentService, geo geoip.Resolver, logger *logrus.Logger) *Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(authenticate(clients, logger)))
+	paymentpb.RegisterPaymentServiceServer(server, &paymentServer{
+		paymentService: paymentService,
+		geo:            geo,
+		logger:         logger,
+	})
+
+	return &Server{
+		addr:   addr,
+		server: server,
+		logger: logger,
+	}
+}
+
+// ListenAndServe blocks until the listener fails, or ctx is cancelled and
+// the calls in flight have finished
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	s.logger.WithField("addr", s.addr).Info("gRPC listener started")
+
+	err = s.server.Serve(listener)
+	if ctx.Err() != nil {
+		<-stopped
+		return nil
+	}
+	return err
+}
+
+// authenticate admits calls bearing a client's token and logs each call
+// with the client's name
+func authenticate(clients []Client, logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token, _ = strings.CutPrefix(values[0], "Bearer ")
+			}
+		}
+
+		client := ""
+		for _, c := range clients {
+			if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1 {
+				client = c.Name
+				break
+			}
+		}
+		if client == "" {
+			logger.WithField("method", info.FullMethod).Warn("gRPC call without a valid client token")
+			return nil, status.Error(codes.Unauthenticated, "a valid client token is required")
+		}
+
+		resp, err := handler(ctx, req)
+
+		logger.WithFields(logrus.Fields{
+			"method":  info.FullMethod,
+			"client":  client,
+			"code":    status.Code(err).String(),
+			"latency": time.Since(start).String(),
+		}).Info("gRPC call")
+		return resp, err
+	}
+}
@@ -0,0 +1,75 @@
+package iso8583
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// ErrBadMAC means a request's MAC is missing or does not match the
+// terminal's key
+var ErrBadMAC = errors.New("ISO 8583 MAC missing or invalid")
+
+// macLength is the size of field 64/128: the first 8 bytes of an
+// HMAC-SHA256 over the packed message up to the MAC field
+const macLength = 8
+
+// macField is the last field of the message's bitmap: 128 when the
+// secondary bitmap is present, 64 otherwise
+func (m *Message) macField() int {
+	for field := range m.Fields {
+		if field > 64 && field != FieldSecondaryMAC {
+			return FieldSecondaryMAC
+		}
+	}
+	return FieldMAC
+}
+
+// mac computes the MAC of the message as it would be packed with its MAC
+// field present. The MAC field is always packed last, so everything before
+// its 8 bytes is covered.
+func (m *Message) mac(key []byte) ([]byte, error) {
+	field := m.macField()
+	signed := &Message{MTI: m.MTI, Fields: make(map[int]string, len(m.Fields)+1)}
+	for f, v := range m.Fields {
+		signed.Fields[f] = v
+	}
+	signed.Fields[field] = string(make([]byte, macLength))
+
+	packed, err := signed.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(packed[:len(packed)-macLength])
+	return h.Sum(nil)[:macLength], nil
+}
+
+// VerifyMAC checks the request's MAC against the terminal's key
+func (m *Message) VerifyMAC(key []byte) error {
+	got := m.Get(m.macField())
+	if len(got) != macLength || len(key) == 0 {
+		return ErrBadMAC
+	}
+
+	want, err := m.mac(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(got), want) {
+		return ErrBadMAC
+	}
+	return nil
+}
+
+// SignMAC sets the message's MAC field so the terminal can verify the
+// response
+func (m *Message) SignMAC(key []byte) error {
+	sum, err := m.mac(key)
+	if err != nil {
+		return err
+	}
+	m.Set(m.macField(), string(sum))
+	return nil
+}
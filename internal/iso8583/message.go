@@ -0,0 +1,292 @@
+// Package iso8583 speaks ISO 8583:1987 with POS terminals: ASCII fields, a
+// binary bitmap and a 2-byte big-endian length header on each message.
+package iso8583
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Message type indicators handled by the gateway
+const (
+	MTIAuthorizationRequest  = "0100"
+	MTIFinancialRequest      = "0200"
+	MTIReversalRequest       = "0400"
+	MTIReversalAdvice        = "0420"
+	MTINetworkManagement     = "0800"
+	MTINetworkManagementResp = "0810"
+)
+
+// Data elements the gateway reads or writes
+const (
+	FieldPAN                 = 2
+	FieldProcessingCode      = 3
+	FieldAmount              = 4
+	FieldTransmissionTime    = 7
+	FieldSTAN                = 11
+	FieldLocalTime           = 12
+	FieldLocalDate           = 13
+	FieldExpiry              = 14
+	FieldMCC                 = 18
+	FieldEntryMode           = 22
+	FieldConditionCode       = 25
+	FieldAcquirerID          = 32
+	FieldTrack2              = 35
+	FieldRRN                 = 37
+	FieldApprovalCode        = 38
+	FieldResponseCode        = 39
+	FieldTerminalID          = 41
+	FieldMerchantID          = 42
+	FieldAcceptorName        = 43
+	FieldCurrency            = 49
+	FieldPINBlock            = 52
+	FieldICC                 = 55
+	FieldMAC                 = 64
+	FieldNetworkManagementID = 70
+	FieldSecondaryMAC        = 128
+)
+
+type fieldKind int
+
+const (
+	fixed  fieldKind = iota // Exactly length characters
+	llvar                   // 2-digit length prefix, up to length characters
+	lllvar                  // 3-digit length prefix, up to length characters
+)
+
+type fieldSpec struct {
+	kind    fieldKind
+	length  int
+	numeric bool // Left-padded with zeros rather than right-padded with spaces
+}
+
+// Only fields listed here can be parsed; a message using any other field is
+// rejected, since its length cannot be known.
+var fieldSpecs = map[int]fieldSpec{
+	2:   {llvar, 19, true},
+	3:   {fixed, 6, true},
+	4:   {fixed, 12, true},
+	7:   {fixed, 10, true},
+	11:  {fixed, 6, true},
+	12:  {fixed, 6, true},
+	13:  {fixed, 4, true},
+	14:  {fixed, 4, true},
+	18:  {fixed, 4, true},
+	22:  {fixed, 3, true},
+	24:  {fixed, 3, true},
+	25:  {fixed, 2, true},
+	32:  {llvar, 11, true},
+	35:  {llvar, 37, false},
+	37:  {fixed, 12, false},
+	38:  {fixed, 6, false},
+	39:  {fixed, 2, false},
+	41:  {fixed, 8, false},
+	42:  {fixed, 15, false},
+	43:  {fixed, 40, false},
+	49:  {fixed, 3, true},
+	52:  {fixed, 8, false}, // Binary PIN block
+	54:  {lllvar, 120, false},
+	55:  {lllvar, 999, false}, // Binary EMV data
+	60:  {lllvar, 999, false},
+	62:  {lllvar, 999, false},
+	63:  {lllvar, 999, false},
+	64:  {fixed, 8, false}, // Binary MAC
+	70:  {fixed, 3, true},
+	128: {fixed, 8, false}, // Binary MAC
+}
+
+var ErrMalformed = errors.New("malformed ISO 8583 message")
+
+// Message is one ISO 8583 message. Field values are kept as received;
+// binary fields hold raw bytes.
+type Message struct {
+	MTI    string
+	Fields map[int]string
+}
+
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, Fields: make(map[int]string)}
+}
+
+func (m *Message) Get(field int) string {
+	return m.Fields[field]
+}
+
+func (m *Message) Has(field int) bool {
+	_, ok := m.Fields[field]
+	return ok
+}
+
+func (m *Message) Set(field int, value string) {
+	m.Fields[field] = value
+}
+
+// Response starts the reply to m: the MTI with its function digit moved to
+// response (0200 -> 0210) and the fields a terminal matches replies on.
+func (m *Message) Response() *Message {
+	mti := []byte(m.MTI)
+	if len(mti) == 4 && mti[2] < '9' {
+		mti[2]++
+	}
+
+	resp := NewMessage(string(mti))
+	for _, field := range []int{FieldProcessingCode, FieldAmount, FieldTransmissionTime, FieldSTAN, FieldLocalTime, FieldLocalDate, FieldRRN, FieldTerminalID, FieldMerchantID, FieldCurrency, FieldNetworkManagementID} {
+		if v, ok := m.Fields[field]; ok {
+			resp.Fields[field] = v
+		}
+	}
+	return resp
+}
+
+// Unpack parses a message body (without the length header)
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("%w: too short", ErrMalformed)
+	}
+
+	msg := NewMessage(string(data[:4]))
+	if _, ok := parseDigits([]byte(msg.MTI)); !ok {
+		return nil, fmt.Errorf("%w: MTI %q is not numeric", ErrMalformed, msg.MTI)
+	}
+
+	bitmap := data[4:12]
+	pos := 12
+	maxField := 64
+	if bitmap[0]&0x80 != 0 {
+		if len(data) < 20 {
+			return nil, fmt.Errorf("%w: secondary bitmap missing", ErrMalformed)
+		}
+		bitmap = data[4:20]
+		pos = 20
+		maxField = 128
+	}
+
+	for field := 2; field <= maxField; field++ {
+		if bitmap[(field-1)/8]&(0x80>>uint((field-1)%8)) == 0 {
+			continue
+		}
+
+		spec, ok := fieldSpecs[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %d is not supported", ErrMalformed, field)
+		}
+
+		length := spec.length
+		if spec.kind != fixed {
+			digits := 2
+			if spec.kind == lllvar {
+				digits = 3
+			}
+			if pos+digits > len(data) {
+				return nil, fmt.Errorf("%w: field %d length truncated", ErrMalformed, field)
+			}
+			n, ok := parseDigits(data[pos : pos+digits])
+			if !ok || n > spec.length {
+				return nil, fmt.Errorf("%w: field %d has invalid length", ErrMalformed, field)
+			}
+			pos += digits
+			length = n
+		}
+
+		if pos+length > len(data) {
+			return nil, fmt.Errorf("%w: field %d truncated", ErrMalformed, field)
+		}
+		msg.Fields[field] = string(data[pos : pos+length])
+		pos += length
+	}
+
+	if pos != len(data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformed, len(data)-pos)
+	}
+
+	return msg, nil
+}
+
+// parseDigits reads an unsigned decimal such as an LLVAR/LLLVAR prefix; only
+// ASCII digits are accepted, so signs and spaces that strconv would allow
+// cannot produce a negative length
+func parseDigits(prefix []byte) (int, bool) {
+	n := 0
+	for _, b := range prefix {
+		if b < '0' || b > '9' {
+			return 0, false
+		}
+		n = n*10 + int(b-'0')
+	}
+	return n, true
+}
+
+// Pack encodes the message body (without the length header). Fixed fields
+// shorter than their length are padded.
+func (m *Message) Pack() ([]byte, error) {
+	if len(m.MTI) != 4 {
+		return nil, fmt.Errorf("MTI %q must be 4 digits", m.MTI)
+	}
+
+	fields := make([]int, 0, len(m.Fields))
+	secondary := false
+	for field := range m.Fields {
+		if _, ok := fieldSpecs[field]; !ok {
+			return nil, fmt.Errorf("field %d is not supported", field)
+		}
+		if field > 64 {
+			secondary = true
+		}
+		fields = append(fields, field)
+	}
+	sort.Ints(fields)
+
+	bitmap := make([]byte, 8)
+	if secondary {
+		bitmap = make([]byte, 16)
+		bitmap[0] |= 0x80
+	}
+
+	var body strings.Builder
+	for _, field := range fields {
+		spec := fieldSpecs[field]
+		value := m.Fields[field]
+		bitmap[(field-1)/8] |= 0x80 >> uint((field-1)%8)
+
+		switch spec.kind {
+		case fixed:
+			if len(value) > spec.length {
+				return nil, fmt.Errorf("field %d is longer than %d", field, spec.length)
+			}
+			pad := strings.Repeat(" ", spec.length-len(value))
+			if spec.numeric {
+				body.WriteString(strings.Repeat("0", len(pad)) + value)
+			} else {
+				body.WriteString(value + pad)
+			}
+		case llvar, lllvar:
+			if len(value) > spec.length {
+				return nil, fmt.Errorf("field %d is longer than %d", field, spec.length)
+			}
+			format := "%02d"
+			if spec.kind == lllvar {
+				format = "%03d"
+			}
+			body.WriteString(fmt.Sprintf(format, len(value)) + value)
+		}
+	}
+
+	out := make([]byte, 0, 4+len(bitmap)+body.Len())
+	out = append(out, m.MTI...)
+	out = append(out, bitmap...)
+	out = append(out, body.String()...)
+	return out, nil
+}
+
+// frame prefixes a packed message with its 2-byte length header
+func frame(body []byte) ([]byte, error) {
+	if len(body) > 0xFFFF {
+		return nil, fmt.Errorf("message of %d bytes is too long", len(body))
+	}
+	out := make([]byte, 2, 2+len(body))
+	binary.BigEndian.PutUint16(out, uint16(len(body)))
+	return append(out, body...), nil
+}
@@ -0,0 +1,131 @@
+package iso8583
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// POSHandler maps terminal requests onto the POS service. Purchases (0200
+// with processing code 00) and echo tests (0800) are supported; other
+// requests are answered with "invalid transaction". Purchases must carry a
+// MAC (field 64 or 128) made with the terminal's key, and responses to
+// terminals with a key are MACed the same way.
+type POSHandler struct {
+	posService service.POSService
+	macKeys    map[string][]byte // Terminal ID -> MAC key
+	logger     *logrus.Logger
+}
+
+func NewPOSHandler(posService service.POSService, macKeys map[string][]byte, logger *logrus.Logger) *POSHandler {
+	return &POSHandler{
+		posService: posService,
+		macKeys:    macKeys,
+		logger:     logger,
+	}
+}
+
+func (h *POSHandler) Handle(ctx context.Context, req *Message) *Message {
+	resp := req.Response()
+	key := h.macKeys[strings.TrimSpace(req.Get(FieldTerminalID))]
+
+	switch req.MTI {
+	case MTINetworkManagement:
+		resp.Set(FieldResponseCode, domain.POSApproved)
+	case MTIFinancialRequest:
+		if err := req.VerifyMAC(key); err != nil {
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"terminal_id": req.Get(FieldTerminalID),
+				"stan":        req.Get(FieldSTAN),
+			}).Warn("Card purchase rejected: MAC verification failed")
+			resp.Set(FieldResponseCode, domain.POSSecurityViolation)
+			break
+		}
+		h.purchase(ctx, req, resp)
+	default:
+		h.logger.WithField("mti", req.MTI).Warn("Unsupported ISO 8583 message type")
+		resp.Set(FieldResponseCode, domain.POSInvalidTransaction)
+	}
+
+	if key != nil {
+		if err := resp.SignMAC(key); err != nil {
+			h.logger.WithError(err).WithField("mti", resp.MTI).Error("Failed to MAC POS response")
+		}
+	}
+	return resp
+}
+
+func (h *POSHandler) purchase(ctx context.Context, req, resp *Message) {
+	if !strings.HasPrefix(req.Get(FieldProcessingCode), "00") {
+		resp.Set(FieldResponseCode, domain.POSInvalidTransaction)
+		return
+	}
+
+	txn, ok := cardTransaction(req)
+	if !ok {
+		h.logger.WithFields(logrus.Fields{
+			"terminal_id": req.Get(FieldTerminalID),
+			"stan":        req.Get(FieldSTAN),
+		}).Warn("Card purchase missing required fields")
+		resp.Set(FieldResponseCode, domain.POSFormatError)
+		return
+	}
+
+	code, err := h.posService.Authorize(ctx, txn)
+	if err != nil {
+		h.logger.WithError(err).WithField("rrn", txn.RRN).Error("Card purchase failed")
+	}
+
+	resp.Set(FieldResponseCode, code)
+	if code == domain.POSApproved {
+		resp.Set(FieldApprovalCode, txn.ApprovalCode)
+	}
+}
+
+// cardTransaction maps the request's data elements; ok is false when a
+// mandatory element is missing or unreadable
+func cardTransaction(req *Message) (*domain.CardTransaction, bool) {
+	for _, field := range []int{FieldAmount, FieldSTAN, FieldRRN, FieldTerminalID, FieldMerchantID, FieldCurrency} {
+		if strings.TrimSpace(req.Get(field)) == "" {
+			return nil, false
+		}
+	}
+
+	minor, err := strconv.ParseInt(req.Get(FieldAmount), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	currency, ok := domain.CurrencyFromNumeric(req.Get(FieldCurrency))
+	if !ok {
+		return nil, false
+	}
+
+	pan := req.Get(FieldPAN)
+	if pan == "" {
+		// Track 2: PAN, separator ('=' or 'D'), expiry, service code, ...
+		track := strings.TrimSuffix(strings.TrimPrefix(req.Get(FieldTrack2), ";"), "?")
+		pan, _, _ = strings.Cut(strings.ReplaceAll(track, "D", "="), "=")
+	}
+	if pan == "" {
+		return nil, false
+	}
+
+	return &domain.CardTransaction{
+		TerminalID:   strings.TrimSpace(req.Get(FieldTerminalID)),
+		MerchantID:   strings.TrimSpace(req.Get(FieldMerchantID)),
+		STAN:         req.Get(FieldSTAN),
+		RRN:          strings.TrimSpace(req.Get(FieldRRN)),
+		MaskedPAN:    domain.MaskPAN(pan),
+		EntryMode:    req.Get(FieldEntryMode),
+		AcceptorName: strings.TrimSpace(req.Get(FieldAcceptorName)),
+		Amount:       domain.Amount(minor),
+		Currency:     currency,
+		MCC:          req.Get(FieldMCC),
+	}, true
+}
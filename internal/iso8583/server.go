@@ -0,0 +1,133 @@
+package iso8583
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Handler answers one request; a nil response sends nothing (advices the
+// terminal does not wait for)
+type Handler interface {
+	Handle(ctx context.Context, req *Message) *Message
+}
+
+// Server accepts terminal connections and answers their requests in order.
+// Terminals usually keep one connection open and send echo tests (0800)
+// while idle.
+type Server struct {
+	addr        string
+	handler     Handler
+	idleTimeout time.Duration
+	logger      *logrus.Logger
+}
+
+func NewServer(addr string, handler Handler, idleTimeout time.Duration, logger *logrus.Logger) *Server {
+	if idleTimeout <= 0 {
+		idleTimeout = 5 * time.Minute
+	}
+
+	return &Server{
+		addr:        addr,
+		handler:     handler,
+		idleTimeout: idleTimeout,
+		logger:      logger,
+	}
+}
+
+// ListenAndServe blocks until ctx is cancelled or the listener fails
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	s.logger.WithField("addr", s.addr).Info("ISO 8583 POS listener started")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			wg.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	log := s.logger.WithField("remote", conn.RemoteAddr().String())
+	// A bad frame must only cost its own connection, never the process
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("panic", r).Error("Recovered from panic on POS connection")
+		}
+	}()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	header := make([]byte, 2)
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				log.WithError(err).Debug("POS connection closed")
+			}
+			return
+		}
+
+		body := make([]byte, binary.BigEndian.Uint16(header))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			log.WithError(err).Warn("POS message truncated")
+			return
+		}
+
+		req, err := Unpack(body)
+		if err != nil {
+			// Without a parsed message there is nothing to match a reply to
+			log.WithError(err).Warn("Dropping POS connection after malformed message")
+			return
+		}
+
+		resp := s.handler.Handle(ctx, req)
+		if resp == nil {
+			continue
+		}
+
+		packed, err := resp.Pack()
+		if err == nil {
+			packed, err = frame(packed)
+		}
+		if err != nil {
+			log.WithError(err).WithField("mti", resp.MTI).Error("Failed to pack POS response")
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		if _, err := conn.Write(packed); err != nil {
+			log.WithError(err).Warn("Failed to write POS response")
+			return
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Broker carries queue messages between the API, the outbox relay and the
+// worker. RabbitMQ routes them through one exchange to the worker's queue;
+// Kafka writes each message type to its own topic.
+type Broker interface {
+	// Publish hands a message to the broker under its routing key
+	Publish(ctx context.Context, message *Message) error
+	// Consume delivers the worker's messages until the broker is closed
+	Consume() (<-chan Delivery, error)
+	// Ping reports whether the broker can be reached, for health checks
+	Ping(ctx context.Context) error
+	// System names the broker in traces' messaging.system attribute
+	System() string
+	Close() error
+}
+
+// Message is a message on its way to the broker
+type Message struct {
+	ID         string
+	RoutingKey string
+	Body       []byte // JSON
+	Headers    map[string]interface{}
+	Timestamp  time.Time
+}
+
+// Delivery is a consumed message. Exactly one of Ack, Nack or Retry must
+// be called once the worker is done with it.
+type Delivery struct {
+	MessageID  string
+	RoutingKey string
+	Body       []byte
+	Headers    map[string]interface{}
+
+	ack   func() error
+	nack  func(reason string) error
+	retry func() error
+}
+
+func (d Delivery) Ack() error {
+	return d.ack()
+}
+
+// Nack gives up on the message, moving it to the dead letter queue (a
+// topic on Kafka) with the reason for operators to read
+func (d Delivery) Nack(reason string) error {
+	return d.nack(reason)
+}
+
+// Retry settles this copy of the message and sends it to wait out the
+// next retry tier, after which it is delivered again. It returns
+// ErrRetriesExhausted, leaving the message unsettled, once every tier has
+// been used.
+func (d Delivery) Retry() error {
+	return d.retry()
+}
+
+// Attempts is how many times the message has been retried
+func (d Delivery) Attempts() int {
+	return retryCount(d.Headers)
+}
+
+var ErrRetriesExhausted = errors.New("message retries exhausted")
+
+// DefaultRetryTiers are the delays before each retry of a failed message
+var DefaultRetryTiers = []time.Duration{30 * time.Second, 5 * time.Minute, 30 * time.Minute}
+
+const (
+	retryCountHeader         = "retry_count"
+	originalRoutingKeyHeader = "original_routing_key" // Set on retried and dead-lettered messages
+	failureReasonHeader      = "failure_reason"
+	failedAtHeader           = "failed_at"
+)
+
+// retryCount reads the retry_count header, whatever integer type the
+// broker decoded it as
+func retryCount(headers map[string]interface{}) int {
+	switch n := headers[retryCountHeader].(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// retryHeaders copies a delivery's headers for its next attempt
+func retryHeaders(d Delivery) map[string]interface{} {
+	headers := make(map[string]interface{}, len(d.Headers)+2)
+	for key, value := range d.Headers {
+		headers[key] = value
+	}
+	headers[retryCountHeader] = int32(retryCount(d.Headers) + 1)
+	headers[originalRoutingKeyHeader] = d.RoutingKey
+	return headers
+}
+
+// deadLetterHeaders copies a delivery's headers for the dead letter queue
+func deadLetterHeaders(d Delivery, reason string) map[string]interface{} {
+	headers := make(map[string]interface{}, len(d.Headers)+3)
+	for key, value := range d.Headers {
+		headers[key] = value
+	}
+	headers[originalRoutingKeyHeader] = d.RoutingKey
+	headers[failureReasonHeader] = reason
+	headers[failedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+	return headers
+}
+
+// requeueHeaders strips a dead letter's failure and retry headers so a
+// requeued message starts over
+func requeueHeaders(headers map[string]interface{}) map[string]interface{} {
+	fresh := make(map[string]interface{}, len(headers))
+	for key, value := range headers {
+		switch key {
+		case originalRoutingKeyHeader, failureReasonHeader, failedAtHeader, retryAtHeader, "original_topic", "x-death",
+			"x-first-death-exchange", "x-first-death-queue", "x-first-death-reason",
+			"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason":
+			continue
+		}
+		fresh[key] = value
+	}
+	fresh[retryCountHeader] = int32(0)
+	return fresh
+}
+
+// deadLetter describes a message read from the dead letter queue
+func deadLetter(messageID, routingKey string, headers map[string]interface{}, body []byte) *domain.DeadLetter {
+	dl := &domain.DeadLetter{
+		MessageID:  messageID,
+		RoutingKey: routingKey,
+		Attempts:   retryCount(headers),
+		Headers:    headers,
+		Body:       body,
+	}
+	if original, ok := headers[originalRoutingKeyHeader].(string); ok && original != "" {
+		dl.RoutingKey = original
+	}
+	if reason, ok := headers[failureReasonHeader].(string); ok {
+		dl.FailureReason = reason
+	}
+	if failedAt, ok := headers[failedAtHeader].(string); ok {
+		if t, err := time.Parse(time.RFC3339, failedAt); err == nil {
+			dl.FailedAt = &t
+		}
+	}
+	if !json.Valid(dl.Body) {
+		// Keep the listing valid JSON whatever the body is
+		dl.Body, _ = json.Marshal(string(body))
+	}
+	return dl
+}
+
+// tierName names a retry tier's queue or topic, e.g. retry_30s
+func tierName(delay time.Duration) string {
+	return "retry_" + strings.ReplaceAll(delay.String(), "m0s", "m")
+}
+
+// WorkerMessages are the message types the worker consumes
+var WorkerMessages = []string{
+	MessagePaymentCreated,
+	MessagePaymentCancelled,
+	MessageRefundRequested,
+	MessageBulkPayoutCompleted,
+}
+
+// partitionKeyHeader holds the payment a message is about. Kafka keys
+// records by it so a payment's messages stay in order.
+const partitionKeyHeader = "payment_id"
+
+// brokerSender publishes straight to a broker
+type brokerSender struct {
+	broker Broker
+}
+
+func (s brokerSender) send(ctx context.Context, routingKey string, body []byte, headers map[string]interface{}) error {
+	return s.broker.Publish(ctx, &Message{
+		ID:         uuid.New().String(),
+		RoutingKey: routingKey,
+		Body:       body,
+		Headers:    headers,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// BrokerFromConfig connects to the configured broker, declaring the
+// RabbitMQ exchange and queues when that is the one in use
+func BrokerFromConfig(cfg *config.Config, logger *logrus.Logger) (Broker, error) {
+	switch strings.ToLower(cfg.Messaging.Broker) {
+	case "", "rabbitmq":
+		return NewRabbitMQClient(RabbitMQConfig{
+			URL:           cfg.RabbitMQ.URL,
+			QueueName:     cfg.RabbitMQ.QueueName,
+			Exchange:      cfg.RabbitMQ.Exchange,
+			ConsumerTag:   cfg.RabbitMQ.ConsumerTag,
+			PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+			RetryTiers:    cfg.Worker.RetryTiers,
+		}, logger)
+	case "kafka":
+		return NewKafkaBroker(KafkaConfig{
+			RESTProxyURL:  cfg.Messaging.Kafka.RESTProxyURL,
+			TopicPrefix:   cfg.Messaging.Kafka.TopicPrefix,
+			ConsumerGroup: cfg.Messaging.Kafka.ConsumerGroup,
+			PollTimeout:   cfg.Messaging.Kafka.PollTimeout,
+			RetryTiers:    cfg.Worker.RetryTiers,
+		}, logger)
+	default:
+		return nil, fmt.Errorf("unknown messaging broker %q", cfg.Messaging.Broker)
+	}
+}
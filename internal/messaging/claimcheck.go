@@ -0,0 +1,170 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"payment-gateway/internal/repository"
+	"payment-gateway/internal/storage"
+	"payment-gateway/internal/tracing"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ErrClaimNotFound means a message refers to a payload that is no longer in
+// the store
+var ErrClaimNotFound = errors.New("claimed payload not found")
+
+// Envelope carries a message body inline or, when it is too large for the
+// broker, the key of the object it was stored under
+type Envelope struct {
+	Type     string          `json:"type"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	ClaimKey string          `json:"claim_key,omitempty"`
+	Size     int             `json:"size"`
+}
+
+// ClaimCheck keeps large message bodies out of RabbitMQ. Payloads over the
+// threshold are written to object storage and only their key is queued;
+// consumers fetch them back with Open. Stored payloads are not deleted, so
+// give the "claims/" prefix a lifecycle rule in the bucket. Without a store
+// every payload is queued inline.
+type ClaimCheck struct {
+	store     storage.ObjectStore
+	threshold int
+	logger    *logrus.Logger
+}
+
+// NewClaimCheck stores payloads larger than threshold bytes (64 KiB if not
+// set) in store
+func NewClaimCheck(store storage.ObjectStore, threshold int, logger *logrus.Logger) *ClaimCheck {
+	if threshold <= 0 {
+		threshold = 64 * 1024
+	}
+	return &ClaimCheck{store: store, threshold: threshold, logger: logger}
+}
+
+// Seal wraps v in an envelope, storing it first if it is over the threshold
+func (c *ClaimCheck) Seal(ctx context.Context, messageType string, v interface{}) (*Envelope, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{Type: messageType, Size: len(payload)}
+	if c.store == nil || len(payload) <= c.threshold {
+		env.Payload = payload
+		return env, nil
+	}
+
+	env.ClaimKey = fmt.Sprintf("claims/%s/%s/%s.json", messageType, time.Now().UTC().Format("2006-01-02"), uuid.New())
+	if err := c.store.Put(ctx, env.ClaimKey, "application/json", payload); err != nil {
+		c.logger.WithError(err).WithField("claim_key", env.ClaimKey).Error("Failed to store claimed payload")
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// Open decodes the envelope's payload into v, fetching it from the store
+// when it was claimed
+func (c *ClaimCheck) Open(ctx context.Context, env *Envelope, v interface{}) error {
+	if env.ClaimKey == "" {
+		return json.Unmarshal(env.Payload, v)
+	}
+
+	if c.store == nil {
+		return ErrClaimNotFound
+	}
+	r, err := c.store.Get(ctx, env.ClaimKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return ErrClaimNotFound
+	}
+	if err != nil {
+		c.logger.WithError(err).WithField("claim_key", env.ClaimKey).Error("Failed to fetch claimed payload")
+		return err
+	}
+	defer r.Close()
+
+	return json.NewDecoder(io.LimitReader(r, int64(env.Size)+1)).Decode(v)
+}
+
+// EventPublisher publishes events whose bodies may be too large for the
+// broker, sealed in an Envelope by a ClaimCheck
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, routingKey string, v interface{}) error
+}
+
+type eventPublisher struct {
+	sender messageSender
+	direct bool // Sent to the broker rather than written to the database
+	claims *ClaimCheck
+	logger *logrus.Logger
+}
+
+// NewEventPublisher publishes straight to the broker, holding events
+// published inside a repository transaction until it commits
+func NewEventPublisher(broker Broker, claims *ClaimCheck, logger *logrus.Logger) EventPublisher {
+	return &eventPublisher{sender: brokerSender{broker: broker}, direct: true, claims: claims, logger: logger}
+}
+
+// NewOutboxEventPublisher writes events to the outbox for the relay; only
+// the envelope goes in the database
+func NewOutboxEventPublisher(repo repository.OutboxRepository, claims *ClaimCheck, logger *logrus.Logger) EventPublisher {
+	return &eventPublisher{sender: &outboxSender{repo: repo}, claims: claims, logger: logger}
+}
+
+func (p *eventPublisher) PublishEvent(ctx context.Context, routingKey string, v interface{}) error {
+	if p.direct && repository.InTx(ctx) {
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			if err := p.PublishEvent(ctx, routingKey, v); err != nil {
+				p.logger.WithError(err).WithField("type", routingKey).Error("Failed to publish event after commit")
+			}
+		})
+		return nil
+	}
+
+	env, err := p.claims.Seal(ctx, routingKey, v)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := startPublishSpan(ctx, routingKey, uuid.Nil)
+	defer span.End()
+
+	headers := amqp.Table{
+		"retry_count": 0,
+		"claim_check": env.ClaimKey != "",
+	}
+	tracing.InjectAMQP(ctx, headers)
+
+	err = p.sender.send(ctx, routingKey, body, headers)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		p.logger.WithError(err).WithField("routing_key", routingKey).Error("Failed to publish event")
+		return err
+	}
+
+	return nil
+}
+
+// OpenDelivery decodes a delivery published by an EventPublisher into v
+func (c *ClaimCheck) OpenDelivery(ctx context.Context, delivery Delivery, v interface{}) error {
+	var env Envelope
+	if err := json.Unmarshal(delivery.Body, &env); err != nil {
+		return err
+	}
+	return c.Open(ctx, &env, v)
+}
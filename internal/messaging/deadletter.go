@@ -0,0 +1,248 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetters lets operators look at the messages the worker gave up on,
+// send some back to be processed again, and clear the rest. Both brokers
+// implement it.
+type DeadLetters interface {
+	ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error)
+	// RequeueDeadLetters sends the messages with the given IDs, or all of
+	// them, back to the worker with their retries reset
+	RequeueDeadLetters(ctx context.Context, messageIDs []string, all bool) (int, error)
+	PurgeDeadLetters(ctx context.Context) (int, error)
+}
+
+// ListDeadLetters peeks at the DLQ without taking messages off it: they
+// are fetched unacked and go back when the channel closes
+func (c *RabbitMQClient) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	channel, err := c.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+
+	var letters []*domain.DeadLetter
+	for len(letters) < limit {
+		msg, ok, err := channel.Get(c.dlqName(), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, rabbitDeadLetter(msg))
+	}
+
+	return letters, nil
+}
+
+// RequeueDeadLetters goes through the DLQ once, publishing the chosen
+// messages to the exchange in confirm mode and acking each only once the
+// broker has it. The rest go back to the DLQ when the channel closes.
+func (c *RabbitMQClient) RequeueDeadLetters(ctx context.Context, messageIDs []string, all bool) (int, error) {
+	channel, err := c.openChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	if err := channel.Confirm(false); err != nil {
+		return 0, err
+	}
+	queue, err := channel.QueueDeclarePassive(c.dlqName(), true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		wanted[id] = true
+	}
+
+	requeued := 0
+	for i := 0; i < queue.Messages; i++ {
+		msg, ok, err := channel.Get(c.dlqName(), false)
+		if err != nil {
+			return requeued, err
+		}
+		if !ok {
+			break
+		}
+		if !all && !wanted[msg.MessageId] {
+			continue
+		}
+
+		letter := rabbitDeadLetter(msg)
+		publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		confirm, err := channel.PublishWithDeferredConfirmWithContext(publishCtx,
+			c.Config.Exchange,
+			letter.RoutingKey,
+			true,  // mandatory
+			false, // immediate
+			amqp.Publishing{
+				ContentType:  msg.ContentType,
+				Body:         msg.Body,
+				DeliveryMode: amqp.Persistent,
+				MessageId:    msg.MessageId,
+				Timestamp:    msg.Timestamp,
+				Headers:      requeueHeaders(msg.Headers),
+			},
+		)
+		if err == nil {
+			var acked bool
+			acked, err = confirm.WaitContext(publishCtx)
+			if err == nil && !acked {
+				err = errors.New("broker rejected the message")
+			}
+		}
+		cancel()
+		if err != nil {
+			return requeued, err
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+func (c *RabbitMQClient) PurgeDeadLetters(ctx context.Context) (int, error) {
+	channel, err := c.openChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	return channel.QueuePurge(c.dlqName(), false)
+}
+
+func rabbitDeadLetter(msg amqp.Delivery) *domain.DeadLetter {
+	routingKey := msg.RoutingKey
+	// Rejected without a reason, the message keeps its queue's dead letter
+	// routing key; the original is in x-death
+	if deaths, ok := msg.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if keys, ok := death["routing-keys"].([]interface{}); ok && len(keys) > 0 {
+				if key, ok := keys[0].(string); ok {
+					routingKey = key
+				}
+			}
+		}
+	}
+
+	return deadLetter(msg.MessageId, routingKey, msg.Headers, msg.Body)
+}
+
+// The dead letter topic is a log, so the operators' place in it is kept as
+// the committed offset of its own consumer group: listing reads on from
+// there, purging commits everything read. Requeued messages stay listed
+// until the next purge.
+func (b *KafkaBroker) dlqGroup() string {
+	return b.config.ConsumerGroup + ".dlq"
+}
+
+func (b *KafkaBroker) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	var letters []*domain.DeadLetter
+	_, err := b.readDeadLetters(ctx, false, func(value *kafkaValue) error {
+		letters = append(letters, deadLetter(value.ID, value.Type, value.Headers, value.Body))
+		if len(letters) >= limit {
+			return errStopReading
+		}
+		return nil
+	})
+	return letters, err
+}
+
+func (b *KafkaBroker) RequeueDeadLetters(ctx context.Context, messageIDs []string, all bool) (int, error) {
+	wanted := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		wanted[id] = true
+	}
+
+	requeued := 0
+	_, err := b.readDeadLetters(ctx, false, func(value *kafkaValue) error {
+		if !all && !wanted[value.ID] {
+			return nil
+		}
+		letter := deadLetter(value.ID, value.Type, value.Headers, value.Body)
+		fresh := *value
+		fresh.Type = letter.RoutingKey
+		fresh.Headers = requeueHeaders(value.Headers)
+		if err := b.produce(ctx, b.topic(fresh.Type), &fresh); err != nil {
+			return err
+		}
+		requeued++
+		return nil
+	})
+	return requeued, err
+}
+
+func (b *KafkaBroker) PurgeDeadLetters(ctx context.Context) (int, error) {
+	return b.readDeadLetters(ctx, true, func(*kafkaValue) error { return nil })
+}
+
+// errStopReading ends readDeadLetters early without an error
+var errStopReading = errors.New("stop reading")
+
+// readDeadLetters reads the dead letter topic from the operators' place
+// until a fetch comes back empty, committing what was read if commit is
+// set, and returns how many records it read
+func (b *KafkaBroker) readDeadLetters(ctx context.Context, commit bool, fn func(*kafkaValue) error) (int, error) {
+	// Each read is a fresh instance in the group, so nothing is left
+	// half-consumed if the caller goes away
+	consumer, err := b.subscribe(ctx, b.dlqGroup(), []string{b.dlqTopic()})
+	if err != nil {
+		return 0, err
+	}
+	defer b.unsubscribe(consumer)
+
+	read := 0
+	// The first fetches after joining can come back empty while the group
+	// assigns partitions
+	for empty := 0; empty < 3; {
+		records, err := b.fetch(consumer)
+		if err != nil {
+			return read, fmt.Errorf("reading dead letter topic: %w", err)
+		}
+		if len(records) == 0 {
+			empty++
+			continue
+		}
+
+		for _, record := range records {
+			var value kafkaValue
+			if err := json.Unmarshal(record.Value, &value); err != nil {
+				continue
+			}
+			read++
+			if err := fn(&value); err != nil {
+				if errors.Is(err, errStopReading) {
+					return read, nil
+				}
+				return read, err
+			}
+		}
+
+		if commit {
+			if err := b.commit(consumer, records); err != nil {
+				return read, err
+			}
+		}
+	}
+
+	return read, nil
+}
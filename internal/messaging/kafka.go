@@ -0,0 +1,551 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type KafkaConfig struct {
+	RESTProxyURL  string
+	TopicPrefix   string
+	ConsumerGroup string
+	PollTimeout   time.Duration   // How long a fetch waits for records
+	RetryTiers    []time.Duration // Delay before each retry; DefaultRetryTiers when empty
+}
+
+// KafkaBroker talks to Kafka through a Confluent REST Proxy (v2 API), so
+// the gateway needs no Kafka client library. Every message type has its
+// own topic, <prefix>.<type>, and records carry the message ID, type and
+// headers next to the body since v2 has no record headers. Retried
+// messages wait in a topic per retry tier, <prefix>.retry_<delay>. Each
+// worker process is one instance in the consumer group. Offsets are
+// committed once every record of a fetched batch has been settled, so a
+// crash redelivers the unfinished batch.
+type KafkaBroker struct {
+	config KafkaConfig
+	client *http.Client
+	logger *logrus.Logger
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+const (
+	kafkaBinaryType = "application/vnd.kafka.binary.v2+json"
+	kafkaJSONType   = "application/vnd.kafka.v2+json"
+)
+
+func NewKafkaBroker(config KafkaConfig, logger *logrus.Logger) (*KafkaBroker, error) {
+	if config.RESTProxyURL == "" {
+		return nil, errors.New("kafka rest_proxy_url is required")
+	}
+	config.RESTProxyURL = strings.TrimRight(config.RESTProxyURL, "/")
+	if config.TopicPrefix == "" {
+		config.TopicPrefix = "payments"
+	}
+	if config.ConsumerGroup == "" {
+		config.ConsumerGroup = "payment-workers"
+	}
+	if config.PollTimeout <= 0 {
+		config.PollTimeout = time.Second
+	}
+	if len(config.RetryTiers) == 0 {
+		config.RetryTiers = DefaultRetryTiers
+	}
+
+	b := &KafkaBroker{
+		config: config,
+		client: &http.Client{Timeout: config.PollTimeout + 30*time.Second},
+		logger: logger,
+		closed: make(chan struct{}),
+	}
+
+	// The proxy is stateless for producers; check it is there at all
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.do(ctx, http.MethodGet, config.RESTProxyURL+"/topics", "", kafkaJSONType, nil, nil); err != nil {
+		return nil, fmt.Errorf("kafka rest proxy: %w", err)
+	}
+
+	logger.WithField("rest_proxy", config.RESTProxyURL).Info("Connected to Kafka REST Proxy successfully")
+	return b, nil
+}
+
+func (b *KafkaBroker) System() string {
+	return "kafka"
+}
+
+// Ping checks the REST Proxy answers; the proxy holds no connection of
+// ours to look at
+func (b *KafkaBroker) Ping(ctx context.Context) error {
+	return b.do(ctx, http.MethodGet, b.config.RESTProxyURL+"/topics", "", kafkaJSONType, nil, nil)
+}
+
+func (b *KafkaBroker) topic(routingKey string) string {
+	return b.config.TopicPrefix + "." + routingKey
+}
+
+// dlqTopic takes the messages the worker gave up on
+func (b *KafkaBroker) dlqTopic() string {
+	return b.config.TopicPrefix + ".dlq"
+}
+
+// kafkaValue is a record's value
+type kafkaValue struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Headers   map[string]interface{} `json:"headers,omitempty"`
+	Body      json.RawMessage        `json:"body"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// kafkaRecord is a record as fetched in binary format
+type kafkaRecord struct {
+	Topic     string `json:"topic"`
+	Key       []byte `json:"key"`
+	Value     []byte `json:"value"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+func (b *KafkaBroker) Publish(ctx context.Context, message *Message) error {
+	return b.produce(ctx, b.topic(message.RoutingKey), &kafkaValue{
+		ID:        message.ID,
+		Type:      message.RoutingKey,
+		Headers:   message.Headers,
+		Body:      message.Body,
+		Timestamp: message.Timestamp,
+	})
+}
+
+// produce writes one record, keyed by the payment it is about, and returns
+// once Kafka has it
+func (b *KafkaBroker) produce(ctx context.Context, topic string, value *kafkaValue) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	record := map[string]interface{}{"value": encoded}
+	if key, ok := value.Headers[partitionKeyHeader].(string); ok && key != "" {
+		record["key"] = []byte(key)
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": []interface{}{record}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var resp struct {
+		Offsets []struct {
+			Partition int    `json:"partition"`
+			Offset    int64  `json:"offset"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := b.do(ctx, http.MethodPost, b.config.RESTProxyURL+"/topics/"+url.PathEscape(topic), kafkaBinaryType, kafkaJSONType, body, &resp); err != nil {
+		return err
+	}
+	for _, offset := range resp.Offsets {
+		if offset.Error != "" {
+			return fmt.Errorf("kafka produce to %s: %s", topic, offset.Error)
+		}
+	}
+	return nil
+}
+
+// Consume joins the consumer group and delivers records from the worker's
+// topics until the broker is closed, rejoining whenever the proxy drops
+// the consumer instance. It also forwards retried messages from each retry
+// tier's topic back to their own topic once their delay is up.
+func (b *KafkaBroker) Consume() (<-chan Delivery, error) {
+	topics := make([]string, len(WorkerMessages))
+	for i, messageType := range WorkerMessages {
+		topics[i] = b.topic(messageType)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	consumer, err := b.subscribe(ctx, b.config.ConsumerGroup, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		b.poll(consumer, b.config.ConsumerGroup, topics, func(records []kafkaRecord) (bool, error) {
+			settled, ok := b.deliver(records, out)
+			if !settled {
+				return false, nil
+			}
+			if !ok {
+				return true, errors.New("dead letter topic unavailable")
+			}
+			return true, nil
+		})
+	}()
+
+	for _, delay := range b.config.RetryTiers {
+		go b.forward(delay)
+	}
+
+	return out, nil
+}
+
+// poll hands batches fetched by consumer to handle, committing each batch
+// handle accepts, until the broker closes or handle returns false. After a
+// failure the instance rejoins the group, so an uncommitted batch is
+// delivered again.
+func (b *KafkaBroker) poll(consumer, group string, topics []string, handle func([]kafkaRecord) (bool, error)) {
+	defer func() { b.unsubscribe(consumer) }()
+
+	backoff := reconnectBackoff
+	for !b.isClosed() {
+		records, err := b.fetch(consumer)
+		if err == nil {
+			if len(records) == 0 {
+				continue
+			}
+
+			var more bool
+			more, err = handle(records)
+			if !more {
+				return
+			}
+			if err == nil {
+				backoff = reconnectBackoff
+				if err := b.commit(consumer, records); err != nil {
+					b.logger.WithError(err).Warn("Failed to commit Kafka offsets; the batch will be redelivered")
+				}
+				continue
+			}
+		}
+
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"group":    group,
+			"retry_in": backoff.String(),
+		}).Warn("Kafka consumer failed, rejoining the group")
+		select {
+		case <-b.closed:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxReconnectBackoff)
+
+		b.unsubscribe(consumer)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		next, err := b.subscribe(ctx, group, topics)
+		cancel()
+		if err != nil {
+			b.logger.WithError(err).WithField("group", group).Warn("Failed to rejoin the Kafka consumer group")
+			continue
+		}
+		consumer = next
+		b.logger.WithField("group", group).Info("Rejoined the Kafka consumer group")
+	}
+}
+
+// tierTopic holds messages waiting out a retry tier
+func (b *KafkaBroker) tierTopic(delay time.Duration) string {
+	return b.config.TopicPrefix + "." + tierName(delay)
+}
+
+// forward moves messages from a retry tier's topic back to their own topic
+// once they are due. Every message in a tier waits the same delay, so they
+// come due in the order they were written. A wait longer than the proxy's
+// consumer.instance.timeout.ms can forward a message twice, which the
+// worker tolerates like any redelivery.
+func (b *KafkaBroker) forward(delay time.Duration) {
+	group := b.config.ConsumerGroup + "." + tierName(delay)
+	topics := []string{b.tierTopic(delay)}
+
+	var consumer string
+	for consumer == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		next, err := b.subscribe(ctx, group, topics)
+		cancel()
+		if err == nil {
+			consumer = next
+			break
+		}
+		b.logger.WithError(err).WithField("group", group).Warn("Failed to join the Kafka retry group")
+		select {
+		case <-b.closed:
+			return
+		case <-time.After(maxReconnectBackoff):
+		}
+	}
+
+	b.poll(consumer, group, topics, func(records []kafkaRecord) (bool, error) {
+		for _, record := range records {
+			var value kafkaValue
+			if err := json.Unmarshal(record.Value, &value); err != nil {
+				continue
+			}
+
+			due, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(value.Headers[retryAtHeader]))
+			select {
+			case <-b.closed:
+				return false, nil
+			case <-time.After(time.Until(due)):
+			}
+
+			if err := b.produce(context.Background(), b.topic(value.Type), &value); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	})
+}
+
+// retryAtHeader holds when a message waiting out a retry tier is due
+const retryAtHeader = "retry_at"
+
+// retry writes a copy of the message to its next tier's topic
+func (b *KafkaBroker) retry(d Delivery, value *kafkaValue) error {
+	attempt := retryCount(d.Headers)
+	if attempt >= len(b.config.RetryTiers) {
+		return ErrRetriesExhausted
+	}
+	delay := b.config.RetryTiers[attempt]
+
+	headers := retryHeaders(d)
+	headers[retryAtHeader] = time.Now().UTC().Add(delay).Format(time.RFC3339Nano)
+	retried := *value
+	retried.Headers = headers
+
+	return b.produce(context.Background(), b.tierTopic(delay), &retried)
+}
+
+// deliver hands a batch to the workers and waits until every record is
+// settled. ok is false when a nacked record could not be dead-lettered;
+// settled is false when the broker closed first.
+func (b *KafkaBroker) deliver(records []kafkaRecord, out chan<- Delivery) (settled, ok bool) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok = true
+
+	for _, record := range records {
+		var value kafkaValue
+		if err := json.Unmarshal(record.Value, &value); err != nil {
+			b.logger.WithError(err).WithFields(logrus.Fields{
+				"topic":  record.Topic,
+				"offset": record.Offset,
+			}).Warn("Skipping unreadable Kafka record")
+			continue
+		}
+
+		var once sync.Once
+		wg.Add(1)
+		delivery := Delivery{
+			MessageID:  value.ID,
+			RoutingKey: value.Type,
+			Body:       value.Body,
+			Headers:    value.Headers,
+			ack: func() error {
+				once.Do(wg.Done)
+				return nil
+			},
+			nack: func(reason string) error {
+				defer once.Do(wg.Done)
+				err := b.deadLetter(record, &value, reason)
+				if err != nil {
+					mu.Lock()
+					ok = false
+					mu.Unlock()
+				}
+				return err
+			},
+		}
+		delivery.retry = func() error {
+			if err := b.retry(delivery, &value); err != nil {
+				return err
+			}
+			once.Do(wg.Done)
+			return nil
+		}
+
+		select {
+		case out <- delivery:
+		case <-b.closed:
+			return false, false
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		mu.Lock()
+		defer mu.Unlock()
+		return true, ok
+	case <-b.closed:
+		return false, false
+	}
+}
+
+// deadLetter moves a record the worker gave up on to the dead letter topic
+func (b *KafkaBroker) deadLetter(record kafkaRecord, value *kafkaValue, reason string) error {
+	headers := deadLetterHeaders(Delivery{RoutingKey: value.Type, Headers: value.Headers}, reason)
+	headers["original_topic"] = record.Topic
+	dead := *value
+	dead.Headers = headers
+
+	err := b.produce(context.Background(), b.dlqTopic(), &dead)
+	if err != nil {
+		b.logger.WithError(err).WithField("message_id", value.ID).Error("Failed to dead-letter Kafka record")
+	}
+	return err
+}
+
+// subscribe creates a consumer instance in group, subscribed to topics,
+// and returns its base URI
+func (b *KafkaBroker) subscribe(ctx context.Context, group string, topics []string) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"name":               "worker-" + uuid.New().String(),
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var instance struct {
+		InstanceID string `json:"instance_id"`
+		BaseURI    string `json:"base_uri"`
+	}
+	if err := b.do(ctx, http.MethodPost, b.config.RESTProxyURL+"/consumers/"+url.PathEscape(group), kafkaJSONType, kafkaJSONType, body, &instance); err != nil {
+		return "", err
+	}
+
+	body, err = json.Marshal(map[string][]string{"topics": topics})
+	if err != nil {
+		return "", err
+	}
+	if err := b.do(ctx, http.MethodPost, instance.BaseURI+"/subscription", kafkaJSONType, kafkaJSONType, body, nil); err != nil {
+		b.unsubscribe(instance.BaseURI)
+		return "", err
+	}
+
+	return instance.BaseURI, nil
+}
+
+// unsubscribe removes the consumer instance so the group rebalances at once
+func (b *KafkaBroker) unsubscribe(consumer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.do(ctx, http.MethodDelete, consumer, kafkaJSONType, kafkaJSONType, nil, nil); err != nil {
+		b.logger.WithError(err).Debug("Failed to remove Kafka consumer instance")
+	}
+}
+
+func (b *KafkaBroker) fetch(consumer string) ([]kafkaRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.PollTimeout+10*time.Second)
+	defer cancel()
+
+	var records []kafkaRecord
+	query := "?timeout=" + strconv.FormatInt(b.config.PollTimeout.Milliseconds(), 10)
+	err := b.do(ctx, http.MethodGet, consumer+"/records"+query, "", kafkaBinaryType, nil, &records)
+	return records, err
+}
+
+// commit stores the last offset of each partition in the batch; the proxy
+// commits the one after it
+func (b *KafkaBroker) commit(consumer string, records []kafkaRecord) error {
+	type partition struct {
+		Topic     string `json:"topic"`
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+	}
+	last := make(map[string]*partition)
+	for _, record := range records {
+		key := record.Topic + "/" + strconv.Itoa(record.Partition)
+		if p, ok := last[key]; !ok || record.Offset > p.Offset {
+			last[key] = &partition{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset}
+		}
+	}
+	offsets := make([]*partition, 0, len(last))
+	for _, p := range last {
+		offsets = append(offsets, p)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"offsets": offsets})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return b.do(ctx, http.MethodPost, consumer+"/offsets", kafkaJSONType, kafkaJSONType, body, nil)
+}
+
+// do sends a REST Proxy request and decodes the response into out, if any
+func (b *KafkaBroker) do(ctx context.Context, method, target, contentType, accept string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" && body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var proxyErr struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &proxyErr) == nil && proxyErr.Message != "" {
+			return fmt.Errorf("kafka rest proxy: %s (%d)", proxyErr.Message, proxyErr.ErrorCode)
+		}
+		return fmt.Errorf("kafka rest proxy: HTTP %d", resp.StatusCode)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (b *KafkaBroker) isClosed() bool {
+	select {
+	case <-b.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops consuming; the consumer instance is removed from the group
+// on the way out
+func (b *KafkaBroker) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
@@ -0,0 +1,250 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// outboxSender writes messages to the outbox table for the relay to publish
+type outboxSender struct {
+	repo repository.OutboxRepository
+}
+
+// NewOutboxPublisher writes messages to the outbox instead of RabbitMQ, so
+// the caller only needs the database; cmd/outbox-relay publishes them.
+// Published inside repository.Transactor.InTx, the message is written in
+// the same transaction as the change it announces.
+func NewOutboxPublisher(repo repository.OutboxRepository, logger *logrus.Logger) PaymentPublisher {
+	return &paymentPublisher{
+		sender: &outboxSender{repo: repo},
+		logger: logger,
+	}
+}
+
+func (s *outboxSender) send(ctx context.Context, routingKey string, body []byte, headers map[string]interface{}) error {
+	// Only the trace context and partition key are kept; the relay starts
+	// retry_count over
+	trace := make(map[string]string)
+	for key, value := range headers {
+		if v, ok := value.(string); ok {
+			trace[key] = v
+		}
+	}
+
+	return s.repo.Add(ctx, &domain.OutboxMessage{
+		ID:         uuid.New(),
+		RoutingKey: routingKey,
+		Body:       body,
+		Headers:    trace,
+		CreatedAt:  time.Now().UTC(),
+	})
+}
+
+type OutboxRelaySettings struct {
+	BatchSize    int
+	Lease        time.Duration // How long a claimed batch is held before another relay may take it
+	RetryBackoff time.Duration // Multiplied by the attempt number
+}
+
+// OutboxRelay publishes outbox messages and marks each one sent only once
+// the broker has acknowledged it. A crash between the two publishes the
+// message again, so consumers must tolerate duplicates; the outbox ID is
+// sent as the message ID. On RabbitMQ a batch is published on a channel in
+// confirm mode, reopened after the client reconnects; other brokers
+// acknowledge each Publish.
+type OutboxRelay struct {
+	broker   Broker
+	client   *RabbitMQClient // Set when broker is RabbitMQ
+	channel  *amqp.Channel
+	repo     repository.OutboxRepository
+	settings OutboxRelaySettings
+	logger   *logrus.Logger
+}
+
+func NewOutboxRelay(broker Broker, repo repository.OutboxRepository, settings OutboxRelaySettings, logger *logrus.Logger) *OutboxRelay {
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 100
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = time.Minute
+	}
+	if settings.RetryBackoff <= 0 {
+		settings.RetryBackoff = 5 * time.Second
+	}
+
+	client, _ := broker.(*RabbitMQClient)
+	return &OutboxRelay{
+		broker:   broker,
+		client:   client,
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (r *OutboxRelay) Close() error {
+	if r.channel == nil {
+		return nil
+	}
+	return r.channel.Close()
+}
+
+// confirmChannel returns the relay's channel, opening a new one in confirm
+// mode when there is none or it went down with the connection
+func (r *OutboxRelay) confirmChannel() (*amqp.Channel, error) {
+	if r.channel != nil && !r.channel.IsClosed() {
+		return r.channel, nil
+	}
+
+	channel, err := r.client.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	r.channel = channel
+	return channel, nil
+}
+
+// RelayDue publishes one batch of due messages and returns how many the
+// broker confirmed
+func (r *OutboxRelay) RelayDue(ctx context.Context) (int, error) {
+	if r.client == nil {
+		return r.relayEach(ctx)
+	}
+
+	channel, err := r.confirmChannel()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	messages, err := r.repo.ClaimDue(ctx, now, now.Add(r.settings.Lease), r.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Publish the whole batch, then collect the confirms
+	confirms := make([]*amqp.DeferredConfirmation, len(messages))
+	for i, message := range messages {
+		confirms[i], err = r.publish(ctx, channel, message)
+		if err != nil {
+			r.failed(ctx, message, err)
+		}
+	}
+
+	sent := 0
+	for i, confirm := range confirms {
+		if confirm == nil {
+			continue
+		}
+		message := messages[i]
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		acked, err := confirm.WaitContext(waitCtx)
+		cancel()
+		switch {
+		case err != nil:
+			r.failed(ctx, message, err)
+		case !acked:
+			r.failed(ctx, message, errors.New("broker rejected the message"))
+		default:
+			if err := r.repo.MarkSent(ctx, message.ID, time.Now().UTC()); err != nil {
+				// Published but still unsent in the outbox; it goes out again after the lease
+				return sent, err
+			}
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// relayEach publishes due messages one at a time, for brokers that have
+// stored a message by the time Publish returns
+func (r *OutboxRelay) relayEach(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	messages, err := r.repo.ClaimDue(ctx, now, now.Add(r.settings.Lease), r.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		err := r.broker.Publish(ctx, &Message{
+			ID:         message.ID.String(),
+			RoutingKey: message.RoutingKey,
+			Body:       message.Body,
+			Headers:    relayHeaders(message),
+			Timestamp:  message.CreatedAt,
+		})
+		if err != nil {
+			r.failed(ctx, message, err)
+			continue
+		}
+		if err := r.repo.MarkSent(ctx, message.ID, time.Now().UTC()); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func relayHeaders(message *domain.OutboxMessage) amqp.Table {
+	headers := amqp.Table{
+		"retry_count": 0,
+	}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	return headers
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, channel *amqp.Channel, message *domain.OutboxMessage) (*amqp.DeferredConfirmation, error) {
+	headers := relayHeaders(message)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return channel.PublishWithDeferredConfirmWithContext(
+		ctx,
+		r.client.Config.Exchange,
+		message.RoutingKey,
+		true,  // mandatory
+		false, // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         message.Body,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    message.ID.String(),
+			Timestamp:    message.CreatedAt,
+			Headers:      headers,
+		},
+	)
+}
+
+// failed puts the message back for a later attempt
+func (r *OutboxRelay) failed(ctx context.Context, message *domain.OutboxMessage, cause error) {
+	next := time.Now().UTC().Add(time.Duration(message.Attempts+1) * r.settings.RetryBackoff)
+
+	r.logger.WithError(cause).WithFields(logrus.Fields{
+		"message_id":  message.ID,
+		"routing_key": message.RoutingKey,
+		"attempts":    message.Attempts + 1,
+	}).Warn("Failed to publish outbox message")
+
+	if err := r.repo.MarkFailed(ctx, message.ID, cause.Error(), next); err != nil {
+		r.logger.WithError(err).WithField("message_id", message.ID).Error("Failed to release outbox message")
+	}
+}
@@ -3,11 +3,19 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 	"time"
 
+	"payment-gateway/internal/repository"
+	"payment-gateway/internal/tracing"
+
 	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RabbitMQConfig struct {
@@ -16,26 +24,66 @@ type RabbitMQConfig struct {
 	Exchange      string
 	ConsumerTag   string
 	PrefetchCount int
+	RetryTiers    []time.Duration // Delay before each retry; DefaultRetryTiers when empty
 }
 
+// RabbitMQClient redials the broker whenever the connection or channel is
+// lost, re-declaring the exchange and queues, and keeps consumers fed
+// across the reconnect. Publishing fails while the broker is away.
 type RabbitMQClient struct {
+	mu      sync.RWMutex
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	queue   amqp.Queue
 	logger  *logrus.Logger
 	Config  RabbitMQConfig // Changed to exported (uppercase)
+
+	reconnected chan struct{} // Closed, and replaced, after each redial
+	closed      chan struct{}
+	closeOnce   sync.Once
 }
 
+// Wait between redials, doubling up to the maximum
+const (
+	reconnectBackoff    = time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
 func NewRabbitMQClient(config RabbitMQConfig, logger *logrus.Logger) (*RabbitMQClient, error) {
+	if len(config.RetryTiers) == 0 {
+		config.RetryTiers = DefaultRetryTiers
+	}
+
+	c := &RabbitMQClient{
+		logger:      logger,
+		Config:      config, // Changed to uppercase
+		reconnected: make(chan struct{}),
+		closed:      make(chan struct{}),
+	}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Connected to RabbitMQ successfully")
+
+	go c.reconnect()
+	return c, nil
+}
+
+// connect dials the broker and declares the exchange, queue, bindings and
+// dead letter queue
+func (c *RabbitMQClient) connect() error {
+	config := c.Config
+
 	conn, err := amqp.Dial(config.URL)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	channel, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return nil, err
+		return err
 	}
 
 	// Set QoS for fair dispatch
@@ -47,7 +95,7 @@ func NewRabbitMQClient(config RabbitMQConfig, logger *logrus.Logger) (*RabbitMQC
 	if err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, err
+		return err
 	}
 
 	// Declare exchange
@@ -63,7 +111,7 @@ func NewRabbitMQClient(config RabbitMQConfig, logger *logrus.Logger) (*RabbitMQC
 	if err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, err
+		return err
 	}
 
 	// Declare queue with DLQ (Dead Letter Queue) for failed messages
@@ -81,21 +129,23 @@ func NewRabbitMQClient(config RabbitMQConfig, logger *logrus.Logger) (*RabbitMQC
 	if err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, err
+		return err
 	}
 
-	// Bind queue to exchange
-	err = channel.QueueBind(
-		queue.Name,
-		"payment.created",
-		config.Exchange,
-		false,
-		nil,
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, err
+	// Bind queue to exchange; payments, refunds and bulk payout results share the queue
+	for _, routingKey := range WorkerMessages {
+		err = channel.QueueBind(
+			queue.Name,
+			routingKey,
+			config.Exchange,
+			false,
+			nil,
+		)
+		if err != nil {
+			channel.Close()
+			conn.Close()
+			return err
+		}
 	}
 
 	// Declare DLQ
@@ -110,21 +160,97 @@ func NewRabbitMQClient(config RabbitMQConfig, logger *logrus.Logger) (*RabbitMQC
 	if err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, err
+		return err
 	}
 
-	logger.Info("Connected to RabbitMQ successfully")
+	// Declare a delay queue per retry tier. Nothing consumes them; a message
+	// expires after the tier's delay and is dead-lettered back to the queue.
+	for _, delay := range config.RetryTiers {
+		_, err = channel.QueueDeclare(
+			c.tierQueue(delay),
+			true,  // durable
+			false, // autoDelete
+			false, // exclusive
+			false, // noWait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": config.QueueName,
+			},
+		)
+		if err != nil {
+			channel.Close()
+			conn.Close()
+			return err
+		}
+	}
 
-	return &RabbitMQClient{
-		conn:    conn,
-		channel: channel,
-		queue:   queue,
-		logger:  logger,
-		Config:  config, // Changed to uppercase
-	}, nil
+	c.mu.Lock()
+	c.conn, c.channel, c.queue = conn, channel, queue
+	c.mu.Unlock()
+	return nil
+}
+
+// reconnect waits for the connection or channel to close and redials
+// until it succeeds, for as long as the client is open
+func (c *RabbitMQClient) reconnect() {
+	for {
+		c.mu.RLock()
+		conn, channel := c.conn, c.channel
+		c.mu.RUnlock()
+
+		var reason *amqp.Error
+		select {
+		case <-c.closed:
+			return
+		case reason = <-conn.NotifyClose(make(chan *amqp.Error, 1)):
+		case reason = <-channel.NotifyClose(make(chan *amqp.Error, 1)):
+			// A channel error leaves the connection up; start over on a new one
+			conn.Close()
+		}
+		if c.isClosed() {
+			return
+		}
+		c.logger.WithField("reason", reason).Warn("RabbitMQ connection lost, reconnecting")
+
+		backoff := reconnectBackoff
+		for {
+			select {
+			case <-c.closed:
+				return
+			case <-time.After(backoff):
+			}
+
+			err := c.connect()
+			if err == nil {
+				break
+			}
+			c.logger.WithError(err).WithField("retry_in", backoff.String()).Warn("Failed to reconnect to RabbitMQ")
+			backoff = min(2*backoff, maxReconnectBackoff)
+		}
+
+		c.logger.Info("Reconnected to RabbitMQ")
+		c.mu.Lock()
+		close(c.reconnected)
+		c.reconnected = make(chan struct{})
+		c.mu.Unlock()
+	}
+}
+
+func (c *RabbitMQClient) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
 }
 
 func (c *RabbitMQClient) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.channel != nil {
 		c.channel.Close()
 	}
@@ -134,9 +260,164 @@ func (c *RabbitMQClient) Close() error {
 	return nil
 }
 
-func (c *RabbitMQClient) Consume() (<-chan amqp.Delivery, error) {
-	return c.channel.Consume(
-		c.queue.Name,
+func (c *RabbitMQClient) System() string {
+	return "rabbitmq"
+}
+
+// Ping reports the state of the connection and channel; while they are
+// down the client is reconnecting
+func (c *RabbitMQClient) Ping(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch {
+	case c.conn == nil || c.conn.IsClosed():
+		return errors.New("rabbitmq connection is closed")
+	case c.channel == nil || c.channel.IsClosed():
+		return errors.New("rabbitmq channel is closed")
+	}
+	return nil
+}
+
+// openChannel opens another channel on the current connection
+func (c *RabbitMQClient) openChannel() (*amqp.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn.Channel()
+}
+
+// Consume delivers messages from the queue until the client is closed,
+// consuming again after every reconnect. Messages received before a
+// reconnect can no longer be acked; the broker delivers them again.
+func (c *RabbitMQClient) Consume() (<-chan Delivery, error) {
+	deliveries, err := c.consume()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for {
+			for delivery := range deliveries {
+				select {
+				case out <- c.delivery(delivery):
+				case <-c.closed:
+					return
+				}
+			}
+
+			// The channel closed; consume again once it is back
+			for {
+				c.mu.RLock()
+				reconnected := c.reconnected
+				c.mu.RUnlock()
+
+				if deliveries, err = c.consume(); err == nil {
+					c.logger.WithField("queue", c.Config.QueueName).Info("Resumed consuming after reconnect")
+					break
+				}
+				select {
+				case <-c.closed:
+					return
+				case <-reconnected:
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (c *RabbitMQClient) delivery(delivery amqp.Delivery) Delivery {
+	d := Delivery{
+		MessageID:  delivery.MessageId,
+		RoutingKey: delivery.RoutingKey,
+		Body:       delivery.Body,
+		Headers:    delivery.Headers,
+		ack:        func() error { return delivery.Ack(false) },
+	}
+	// Back from a delay queue, the message is routed by the queue's name
+	if original, ok := delivery.Headers[originalRoutingKeyHeader].(string); ok {
+		d.RoutingKey = original
+	}
+	d.retry = func() error {
+		return c.retry(d, delivery)
+	}
+	d.nack = func(reason string) error {
+		return c.deadLetter(d, delivery, reason)
+	}
+	return d
+}
+
+// deadLetter moves the message to the DLQ with the reason it failed. If
+// that publish fails the message is rejected instead, reaching the DLQ
+// through the queue's dead letter exchange without a reason.
+func (c *RabbitMQClient) deadLetter(d Delivery, delivery amqp.Delivery, reason string) error {
+	err := c.publishToQueue(c.dlqName(), delivery, deadLetterHeaders(d, reason))
+	if err != nil {
+		c.logger.WithError(err).WithField("message_id", delivery.MessageId).Warn("Failed to write failure reason to DLQ")
+		return delivery.Nack(false, false)
+	}
+	return delivery.Ack(false)
+}
+
+func (c *RabbitMQClient) dlqName() string {
+	return c.Config.QueueName + "_dlq"
+}
+
+// publishToQueue publishes a copy of delivery straight to a queue through
+// the default exchange
+func (c *RabbitMQClient) publishToQueue(queue string, delivery amqp.Delivery, headers amqp.Table) error {
+	c.mu.RLock()
+	channel := c.channel
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return channel.PublishWithContext(ctx,
+		"", // Default exchange, straight to the queue
+		queue,
+		true,  // mandatory
+		false, // immediate
+		amqp.Publishing{
+			ContentType:  delivery.ContentType,
+			Body:         delivery.Body,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    delivery.MessageId,
+			Timestamp:    delivery.Timestamp,
+			Headers:      headers,
+		},
+	)
+}
+
+// tierQueue names the delay queue of a retry tier
+func (c *RabbitMQClient) tierQueue(delay time.Duration) string {
+	return c.Config.QueueName + "_" + tierName(delay)
+}
+
+// retry publishes a copy of the message to its next tier's delay queue,
+// then acks the original
+func (c *RabbitMQClient) retry(d Delivery, delivery amqp.Delivery) error {
+	attempt := retryCount(d.Headers)
+	if attempt >= len(c.Config.RetryTiers) {
+		return ErrRetriesExhausted
+	}
+
+	err := c.publishToQueue(c.tierQueue(c.Config.RetryTiers[attempt]), delivery, retryHeaders(d))
+	if err != nil {
+		return err
+	}
+	return delivery.Ack(false)
+}
+
+func (c *RabbitMQClient) consume() (<-chan amqp.Delivery, error) {
+	c.mu.RLock()
+	channel, queue := c.channel, c.queue
+	c.mu.RUnlock()
+
+	return channel.Consume(
+		queue.Name,
 		c.Config.ConsumerTag, // Use uppercase Config
 		false,                // auto-ack
 		false,                // exclusive
@@ -146,27 +427,151 @@ func (c *RabbitMQClient) Consume() (<-chan amqp.Delivery, error) {
 	)
 }
 
+// Message types, also used as routing keys
+const (
+	MessagePaymentCreated   = "payment.created"
+	MessagePaymentCancelled = "payment.cancelled"
+	MessageRefundRequested  = "refund.requested"
+
+	// Sealed in an Envelope by a ClaimCheck; open with OpenDelivery
+	MessageBulkPayoutCompleted = "bulk_payout.completed"
+)
+
+// Attachment events for back-office systems, sealed in an Envelope with the
+// file's contents. The worker's queue is not bound to them.
+const EventAttachmentUploaded = "attachment.uploaded"
+
+// Sent when the expiry job gives up on a payment left PENDING. Like
+// dispute events, the worker's queue is not bound to it.
+const EventPaymentExpired = "payment.expired"
+
+// Dispute events for merchants, published to the exchange under their type.
+// The worker's queue is not bound to them; merchants bind their own queue.
+const (
+	EventDisputeOpened            = "dispute.opened"
+	EventDisputeEvidenceSubmitted = "dispute.evidence_submitted"
+	EventDisputeWon               = "dispute.won"
+	EventDisputeLost              = "dispute.lost"
+)
+
 type PaymentPublisher interface {
 	PublishPaymentCreated(ctx context.Context, paymentID uuid.UUID) error
+	PublishPaymentCancelled(ctx context.Context, paymentID uuid.UUID) error
+	PublishPaymentExpired(ctx context.Context, paymentID uuid.UUID) error
+	PublishRefundRequested(ctx context.Context, paymentID, refundID uuid.UUID) error
+	PublishDisputeEvent(ctx context.Context, event string, paymentID, disputeID uuid.UUID) error
+}
+
+// messageSender hands a published message to the broker or the outbox
+type messageSender interface {
+	send(ctx context.Context, routingKey string, body []byte, headers map[string]interface{}) error
 }
 
 type paymentPublisher struct {
-	client *RabbitMQClient
+	sender messageSender
+	direct bool // Sent to the broker rather than written to the database
 	logger *logrus.Logger
 }
 
-func NewPaymentPublisher(client *RabbitMQClient, logger *logrus.Logger) PaymentPublisher {
+// NewPaymentPublisher publishes straight to the broker. Messages published
+// inside a repository transaction are held until it commits, so the worker
+// never sees a change that may still roll back.
+func NewPaymentPublisher(broker Broker, logger *logrus.Logger) PaymentPublisher {
 	return &paymentPublisher{
-		client: client,
+		sender: brokerSender{broker: broker},
+		direct: true,
 		logger: logger,
 	}
 }
 
 func (p *paymentPublisher) PublishPaymentCreated(ctx context.Context, paymentID uuid.UUID) error {
-	message := PaymentMessage{
+	err := p.publish(ctx, PaymentMessage{
+		PaymentID: paymentID,
+		Type:      MessagePaymentCreated,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to publish payment message")
+		return err
+	}
+
+	p.logger.WithField("payment_id", paymentID).Debug("Payment message published to RabbitMQ")
+	return nil
+}
+
+func (p *paymentPublisher) PublishPaymentCancelled(ctx context.Context, paymentID uuid.UUID) error {
+	err := p.publish(ctx, PaymentMessage{
+		PaymentID: paymentID,
+		Type:      MessagePaymentCancelled,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to publish payment cancelled message")
+		return err
+	}
+
+	p.logger.WithField("payment_id", paymentID).Debug("Payment cancelled message published to RabbitMQ")
+	return nil
+}
+
+func (p *paymentPublisher) PublishPaymentExpired(ctx context.Context, paymentID uuid.UUID) error {
+	err := p.publish(ctx, PaymentMessage{
 		PaymentID: paymentID,
-		Type:      "payment.created",
+		Type:      EventPaymentExpired,
 		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to publish payment expired event")
+		return err
+	}
+
+	p.logger.WithField("payment_id", paymentID).Debug("Payment expired event published to RabbitMQ")
+	return nil
+}
+
+func (p *paymentPublisher) PublishRefundRequested(ctx context.Context, paymentID, refundID uuid.UUID) error {
+	err := p.publish(ctx, PaymentMessage{
+		PaymentID: paymentID,
+		RefundID:  refundID,
+		Type:      MessageRefundRequested,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to publish refund message")
+		return err
+	}
+
+	p.logger.WithField("refund_id", refundID).Debug("Refund message published to RabbitMQ")
+	return nil
+}
+
+func (p *paymentPublisher) PublishDisputeEvent(ctx context.Context, event string, paymentID, disputeID uuid.UUID) error {
+	err := p.publish(ctx, PaymentMessage{
+		PaymentID: paymentID,
+		DisputeID: disputeID,
+		Type:      event,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to publish dispute event")
+		return err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"dispute_id": disputeID,
+		"event":      event,
+	}).Debug("Dispute event published to RabbitMQ")
+	return nil
+}
+
+func (p *paymentPublisher) publish(ctx context.Context, message PaymentMessage) error {
+	if p.direct && repository.InTx(ctx) {
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			if err := p.publish(ctx, message); err != nil {
+				p.logger.WithError(err).WithField("type", message.Type).Error("Failed to publish message after commit")
+			}
+		})
+		return nil
 	}
 
 	body, err := json.Marshal(message)
@@ -174,38 +579,67 @@ func (p *paymentPublisher) PublishPaymentCreated(ctx context.Context, paymentID
 		return err
 	}
 
+	ctx, span := startPublishSpan(ctx, message.Type, message.PaymentID)
+	defer span.End()
+
+	headers := amqp.Table{
+		"retry_count":      0,
+		partitionKeyHeader: message.PaymentID.String(),
+	}
+	tracing.InjectAMQP(ctx, headers)
+
+	err = p.sender.send(ctx, message.Type, body, headers)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (c *RabbitMQClient) Publish(ctx context.Context, message *Message) error {
+	c.mu.RLock()
+	channel := c.channel
+	c.mu.RUnlock()
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err = p.client.channel.PublishWithContext(
+	return channel.PublishWithContext(
 		ctx,
-		p.client.Config.Exchange, // Use uppercase Config
-		"payment.created",
+		c.Config.Exchange, // Use uppercase Config
+		message.RoutingKey,
 		true,  // mandatory
 		false, // immediate
 		amqp.Publishing{
 			ContentType:  "application/json",
-			Body:         body,
+			Body:         message.Body,
 			DeliveryMode: amqp.Persistent,
-			MessageId:    uuid.New().String(),
-			Timestamp:    time.Now().UTC(),
-			Headers: amqp.Table{
-				"retry_count": 0,
-			},
+			MessageId:    message.ID,
+			Timestamp:    message.Timestamp,
+			Headers:      message.Headers,
 		},
 	)
+}
 
-	if err != nil {
-		p.logger.WithError(err).Error("Failed to publish payment message")
-		return err
+// startPublishSpan starts a producer span for a message; its context is
+// injected into the message headers so the consumer continues the trace
+func startPublishSpan(ctx context.Context, routingKey string, paymentID uuid.UUID) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", routingKey),
 	}
-
-	p.logger.WithField("payment_id", paymentID).Debug("Payment message published to RabbitMQ")
-	return nil
+	if paymentID != uuid.Nil {
+		attrs = append(attrs, attribute.String("payment.id", paymentID.String()))
+	}
+	return tracing.Tracer().Start(ctx, "publish "+routingKey,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...),
+	)
 }
 
 type PaymentMessage struct {
 	PaymentID uuid.UUID `json:"payment_id"`
+	RefundID  uuid.UUID `json:"refund_id"`  // refund.requested only
+	DisputeID uuid.UUID `json:"dispute_id"` // dispute.* events only
 	Type      string    `json:"type"`
 	Timestamp time.Time `json:"timestamp"`
 }
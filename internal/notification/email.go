@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// Email is a single HTML message
+type Email struct {
+	FromName    string
+	FromAddress string
+	To          []string
+	Subject     string
+	HTML        string
+}
+
+// EmailSender sends an email and returns its Message-ID
+type EmailSender interface {
+	SendEmail(ctx context.Context, email Email) (string, error)
+}
+
+type smtpSender struct {
+	config EmailConfig
+	logger *logrus.Logger
+}
+
+func NewSMTPSender(config EmailConfig, logger *logrus.Logger) EmailSender {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &smtpSender{config: config, logger: logger}
+}
+
+func (s *smtpSender) SendEmail(ctx context.Context, email Email) (string, error) {
+	if len(email.To) == 0 {
+		return "", fmt.Errorf("email has no recipients")
+	}
+
+	domainPart := "localhost"
+	if addr, err := mail.ParseAddress(email.FromAddress); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domainPart = addr.Address[at+1:]
+		}
+	}
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New().String(), domainPart)
+
+	from := mail.Address{Name: email.FromName, Address: email.FromAddress}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	// Subjects are often Amharic, so they must be MIME encoded
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(email.HTML)
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	// net/smtp has no context support, so bound the call ourselves
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, email.FromAddress, email.To, msg.Bytes())
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return "", err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"to":         email.To,
+		"message_id": messageID,
+	}).Debug("Email accepted by SMTP server")
+
+	return messageID, nil
+}
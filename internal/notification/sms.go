@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type SMSConfig struct {
+	GatewayURL string
+	Username   string
+	Password   string
+	SenderID   string
+	Timeout    time.Duration
+}
+
+// SMSSender sends a single text message and returns the gateway message ID
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, text string) (string, error)
+}
+
+// Ethio Telecom bulk SMS HTTP gateway
+type ethioTelecomSMS struct {
+	config SMSConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+func NewEthioTelecomSMS(config SMSConfig, logger *logrus.Logger) SMSSender {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &ethioTelecomSMS{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+type sendSMSRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+type sendSMSResponse struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
+
+func (s *ethioTelecomSMS) SendSMS(ctx context.Context, to, text string) (string, error) {
+	body, err := json.Marshal(sendSMSRequest{
+		Username: s.config.Username,
+		Password: s.config.Password,
+		From:     s.config.SenderID,
+		To:       to,
+		Text:     text,
+		Encoding: "UCS2", // Required for Amharic (Ge'ez script) text
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.GatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result sendSMSResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("sms gateway returned status %d: %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("sms gateway returned status %d: %s", resp.StatusCode, result.Error)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"to":         to,
+		"message_id": result.MessageID,
+	}).Debug("SMS accepted by Ethio Telecom gateway")
+
+	return result.MessageID, nil
+}
@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const telegramAPIURL = "https://api.telegram.org"
+
+type TelegramConfig struct {
+	BotToken string
+	Timeout  time.Duration
+}
+
+// TelegramSender posts messages to chats through the Telegram Bot API
+type TelegramSender interface {
+	SendMessage(ctx context.Context, chatID int64, text string) (string, error)
+}
+
+// TelegramUpdate is the subset of a Bot API update the gateway needs
+type TelegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID        int64  `json:"id"`
+			Type      string `json:"type"`
+			Title     string `json:"title"`
+			FirstName string `json:"first_name"`
+			Username  string `json:"username"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+type telegramBot struct {
+	config TelegramConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+func NewTelegramBot(config TelegramConfig, logger *logrus.Logger) TelegramSender {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &telegramBot{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+}
+
+func (b *telegramBot) SendMessage(ctx context.Context, chatID int64, text string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "HTML",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIURL, b.config.BotToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("telegram returned status %d: %w", resp.StatusCode, err)
+	}
+
+	if !result.OK {
+		return "", fmt.Errorf("telegram returned status %d: %s", resp.StatusCode, result.Description)
+	}
+
+	b.logger.WithField("chat_id", chatID).Debug("Telegram message sent")
+
+	return strconv.FormatInt(result.Result.MessageID, 10), nil
+}
@@ -0,0 +1,340 @@
+// Package pdf writes simple single-purpose PDF documents (receipts) with
+// Latin text in the standard Helvetica fonts and everything else, Ge'ez
+// script in particular, in an embedded TrueType font.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Style is how Text draws a string
+type Style struct {
+	Size  float64
+	Bold  bool // Latin text only; the embedded font has one weight
+	Color [3]uint8
+}
+
+type Document struct {
+	width  float64
+	height float64
+	font   *Font
+	used   map[uint16]rune // Embedded glyphs drawn, for widths and ToUnicode
+	pages  []*Page
+}
+
+// New starts a document whose pages are width x height points. font may
+// be nil, in which case text outside Latin-1 is drawn as '?'.
+func New(width, height float64, font *Font) *Document {
+	return &Document{
+		width:  width,
+		height: height,
+		font:   font,
+		used:   make(map[uint16]rune),
+	}
+}
+
+// Page is drawn on in points from its top-left corner
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Font resources, by kind of run
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+	fontEmbed   = "F3"
+)
+
+// run is part of a string drawn in one font
+type run struct {
+	font  string
+	text  []rune
+	width float64 // In thousandths of the font size
+}
+
+// runs splits s by font: ASCII and Latin-1 in Helvetica, everything the
+// embedded font covers in it
+func (d *Document) runs(s string, bold bool) []run {
+	latin := fontRegular
+	if bold {
+		latin = fontBold
+	}
+
+	var runs []run
+	for _, r := range s {
+		font, width := latin, 0.0
+		if gid, ok := d.embedded(r); ok {
+			font, width = fontEmbed, d.font.width(gid)
+		} else {
+			if r > 0xFF || (r < ' ') || (r >= 0x7F && r < 0xA0) {
+				r = '?'
+			}
+			width = helveticaWidth(r, bold)
+		}
+
+		if len(runs) == 0 || runs[len(runs)-1].font != font {
+			runs = append(runs, run{font: font})
+		}
+		last := &runs[len(runs)-1]
+		last.text = append(last.text, r)
+		last.width += width
+	}
+	return runs
+}
+
+func (d *Document) embedded(r rune) (uint16, bool) {
+	if d.font == nil || r < 0x80 {
+		return 0, false
+	}
+	return d.font.glyph(r)
+}
+
+// TextWidth is how wide Text draws s, in points
+func (d *Document) TextWidth(s string, style Style) float64 {
+	var width float64
+	for _, run := range d.runs(s, style.Bold) {
+		width += run.width
+	}
+	return width * style.Size / 1000
+}
+
+// Text draws s with its baseline at y
+func (p *Page) Text(x, y float64, s string, style Style) {
+	fmt.Fprintf(&p.content, "%s rg\n", rgb(style.Color))
+	for _, run := range p.doc.runs(s, style.Bold) {
+		fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td ", run.font, num(style.Size), num(x), num(p.doc.height-y))
+		if run.font == fontEmbed {
+			p.content.WriteByte('<')
+			for _, r := range run.text {
+				gid, _ := p.doc.font.glyph(r)
+				p.doc.used[gid] = r
+				fmt.Fprintf(&p.content, "%04X", gid)
+			}
+			p.content.WriteByte('>')
+		} else {
+			p.content.WriteByte('(')
+			for _, r := range run.text {
+				switch {
+				case r == '(' || r == ')' || r == '\\':
+					p.content.WriteByte('\\')
+					p.content.WriteRune(r)
+				case r > 0x7E:
+					fmt.Fprintf(&p.content, "\\%03o", r) // WinAnsi matches Latin-1 here
+				default:
+					p.content.WriteRune(r)
+				}
+			}
+			p.content.WriteByte(')')
+		}
+		p.content.WriteString(" Tj ET\n")
+		x += run.width * style.Size / 1000
+	}
+}
+
+// Rect fills a rectangle whose top-left corner is at x, y
+func (p *Page) Rect(x, y, width, height float64, color [3]uint8) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		rgb(color), num(x), num(p.doc.height-y-height), num(width), num(height))
+}
+
+// Line strokes a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64, color [3]uint8) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n",
+		strings.ToUpper(rgb(color)), num(width), num(x1), num(p.doc.height-y1), num(x2), num(p.doc.height-y2))
+}
+
+func rgb(c [3]uint8) string {
+	return fmt.Sprintf("%s %s %s", num(float64(c[0])/255), num(float64(c[1])/255), num(float64(c[2])/255))
+}
+
+func num(f float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.3f", f), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() ([]byte, error) {
+	w := &writer{}
+	catalog, pages := w.reserve(), w.reserve()
+
+	fonts := fmt.Sprintf("/%s %d 0 R /%s %d 0 R", fontRegular,
+		w.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"),
+		fontBold,
+		w.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"),
+	)
+	if d.font != nil && len(d.used) > 0 {
+		font, err := d.embedFont(w)
+		if err != nil {
+			return nil, err
+		}
+		fonts += fmt.Sprintf(" /%s %d 0 R", fontEmbed, font)
+	}
+
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		content, err := w.stream("", page.content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		kids[i] = fmt.Sprintf("%d 0 R", w.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pages, num(d.width), num(d.height), fonts, content,
+		)))
+	}
+
+	w.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	return w.finish(catalog), nil
+}
+
+// embedFont adds the TrueType font as a CID font addressed by glyph ID
+// (Identity-H), with widths and a ToUnicode map for the glyphs drawn so the
+// text can be searched and copied
+func (d *Document) embedFont(w *writer) (int, error) {
+	f := d.font
+
+	file, err := w.stream(fmt.Sprintf("/Length1 %d", len(f.data)), f.data)
+	if err != nil {
+		return 0, err
+	}
+	descriptor := w.add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), file,
+	))
+
+	gids := make([]int, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var widths, cmap strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, int(f.width(uint16(gid))+0.5))
+	}
+	for i, gid := range gids {
+		if i%100 == 0 { // bfchar blocks hold at most 100 entries
+			if i > 0 {
+				cmap.WriteString("endbfchar\n")
+			}
+			fmt.Fprintf(&cmap, "%d beginbfchar\n", min(100, len(gids)-i))
+		}
+		fmt.Fprintf(&cmap, "<%04X> <", gid)
+		for _, unit := range utf16.Encode([]rune{d.used[uint16(gid)]}) {
+			fmt.Fprintf(&cmap, "%04X", unit)
+		}
+		cmap.WriteString(">\n")
+	}
+	cmap.WriteString("endbfchar\n")
+
+	toUnicode, err := w.stream("", []byte(`/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+`+cmap.String()+`endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`))
+	if err != nil {
+		return 0, err
+	}
+
+	cidFont := w.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		f.name, descriptor, strings.TrimSpace(widths.String()),
+	))
+
+	return w.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, cidFont, toUnicode,
+	)), nil
+}
+
+// writer numbers objects from 1 in the order they are added
+type writer struct {
+	objects [][]byte
+}
+
+func (w *writer) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+func (w *writer) set(id int, object string) {
+	w.objects[id-1] = []byte(object)
+}
+
+func (w *writer) add(object string) int {
+	id := w.reserve()
+	w.set(id, object)
+	return id
+}
+
+// stream adds Flate-compressed data, with extra dictionary entries
+func (w *writer) stream(extra string, data []byte) (int, error) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	dict := fmt.Sprintf("<< /Length %d /Filter /FlateDecode", compressed.Len())
+	if extra != "" {
+		dict += " " + extra
+	}
+	object := append([]byte(dict+" >>\nstream\n"), compressed.Bytes()...)
+	id := w.reserve()
+	w.objects[id-1] = append(object, "\nendstream"...)
+	return id, nil
+}
+
+func (w *writer) finish(root int) []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(w.objects))
+	for i, object := range w.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(object)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%EOF\n", len(w.objects)+1, root, xref)
+
+	return out.Bytes()
+}
@@ -0,0 +1,269 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// Font is a TrueType font embedded whole in the documents that use it.
+// Ge'ez script needs no shaping: every syllable is its own code point with
+// its own glyph, so mapping runes through the cmap is enough.
+type Font struct {
+	name       string
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []uint16 // Per glyph, in font units
+	glyphs     map[rune]uint16
+}
+
+// LoadFont reads a TrueType (.ttf) font, e.g. Noto Sans Ethiopic
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+var errBadFont = errors.New("pdf: not a TrueType font")
+
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 && version != 0x74727565 { // 1.0 or 'true'
+		return nil, fmt.Errorf("%w (OpenType CFF fonts are not supported)", errBadFont)
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errBadFont
+		}
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, errBadFont
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "glyf"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("%w: no %s table", errBadFont, tag)
+		}
+	}
+
+	f := &Font{data: data, glyphs: make(map[rune]uint16)}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errBadFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, errBadFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < 4*numMetrics {
+		return nil, errBadFont
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for i := range f.advances {
+		// Glyphs past the last metric share its advance
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*min(i, numMetrics-1):])
+	}
+
+	if err := f.parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	f.name = postScriptName(tables["name"])
+
+	return f, nil
+}
+
+// parseCmap reads the Unicode subtable: format 12 (full repertoire) when
+// the font has one, else format 4 (BMP)
+func (f *Font) parseCmap(cmap []byte) error {
+	if len(cmap) < 4 {
+		return errBadFont
+	}
+
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return errBadFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+4 > len(cmap) || (platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	switch {
+	case format12 != nil:
+		return f.parseFormat12(format12)
+	case format4 != nil:
+		return f.parseFormat4(format4)
+	default:
+		return fmt.Errorf("%w: no Unicode cmap", errBadFont)
+	}
+}
+
+func (f *Font) parseFormat4(t []byte) error {
+	if len(t) < 14 {
+		return errBadFont
+	}
+	segCount := int(binary.BigEndian.Uint16(t[6:])) / 2
+	ends := 14
+	starts := ends + 2*segCount + 2
+	deltas := starts + 2*segCount
+	rangeOffsets := deltas + 2*segCount
+	if rangeOffsets+2*segCount > len(t) {
+		return errBadFont
+	}
+
+	u16 := func(at int) int { return int(binary.BigEndian.Uint16(t[at:])) }
+	for i := 0; i < segCount; i++ {
+		end, start := u16(ends+2*i), u16(starts+2*i)
+		delta, rangeOffset := u16(deltas+2*i), u16(rangeOffsets+2*i)
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			gid := 0
+			if rangeOffset == 0 {
+				gid = (c + delta) & 0xFFFF
+			} else {
+				at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if at+2 > len(t) {
+					continue
+				}
+				if gid = u16(at); gid != 0 {
+					gid = (gid + delta) & 0xFFFF
+				}
+			}
+			if gid != 0 && gid < len(f.advances) {
+				f.glyphs[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Font) parseFormat12(t []byte) error {
+	if len(t) < 16 {
+		return errBadFont
+	}
+	numGroups := int(binary.BigEndian.Uint32(t[12:]))
+	if 16+12*numGroups > len(t) {
+		return errBadFont
+	}
+
+	for i := 0; i < numGroups; i++ {
+		group := t[16+12*i:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		gid := binary.BigEndian.Uint32(group[8:])
+		if end > 0x10FFFF || end < start {
+			return errBadFont
+		}
+		for c := start; c <= end; c, gid = c+1, gid+1 {
+			if gid != 0 && int(gid) < len(f.advances) {
+				f.glyphs[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+// postScriptName is name ID 6, which PDF uses as the BaseFont
+func postScriptName(name []byte) string {
+	const fallback = "EmbeddedFont"
+	if len(name) < 6 {
+		return fallback
+	}
+
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		record := 6 + 12*i
+		if record+12 > len(name) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(name[record:])
+		nameID := binary.BigEndian.Uint16(name[record+6:])
+		length := int(binary.BigEndian.Uint16(name[record+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(name[record+10:]))
+		if nameID != 6 || offset+length > len(name) {
+			continue
+		}
+
+		raw := name[offset : offset+length]
+		var s string
+		switch platform {
+		case 1: // Macintosh Roman; PostScript names are ASCII
+			s = string(raw)
+		case 0, 3: // UTF-16BE
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			s = string(utf16.Decode(units))
+		default:
+			continue
+		}
+
+		// PDF names cannot hold delimiters or spaces
+		s = strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+				return -1
+			}
+			return r
+		}, s)
+		if s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+// glyph returns the glyph for r, or false if the font has none
+func (f *Font) glyph(r rune) (uint16, bool) {
+	gid, ok := f.glyphs[r]
+	return gid, ok
+}
+
+// width is the advance of glyph gid in thousandths of the font size
+func (f *Font) width(gid uint16) float64 {
+	return float64(f.advances[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale converts font units to thousandths of the font size
+func (f *Font) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
@@ -0,0 +1,231 @@
+// Package amole debits Dashen Bank's Amole wallet. Amole sends the
+// customer an OTP; the customer gives it to the merchant, and the debit is
+// made with it.
+package amole
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Amole payment actions
+const (
+	actionSendOTP = "09"
+	actionDebit   = "01"
+)
+
+// Amole answers 00001 for success; anything else is a decline
+const codeSuccess = "00001"
+
+type Config struct {
+	URL        string // The Amole pay endpoint
+	MerchantID string
+	Username   string
+	Password   string
+	Signature  string        // API key issued by Amole
+	IPAddress  string        // Server IP registered with Amole
+	OTPTTL     time.Duration // How long the customer has to enter the OTP
+	Timeout    time.Duration
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+	logger *logrus.Logger
+}
+
+// New returns the Amole provider; it also implements provider.OTPConfirmer
+func New(config Config, logger *logrus.Logger) provider.PaymentProvider {
+	if config.OTPTTL <= 0 {
+		config.OTPTTL = 5 * time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+
+	return &client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		logger: logger,
+	}
+}
+
+// Authorize asks Amole to send the customer an OTP for this payment
+func (c *client) Authorize(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.Currency != domain.CurrencyETB {
+		return provider.Result{Status: provider.Declined, Reason: "Amole accepts ETB only"}, nil
+	}
+	if payment.CustomerPhone == "" {
+		return provider.Result{Status: provider.Declined, Reason: "customer_phone is required for Amole"}, nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return provider.Result{}, err
+	}
+	txRef := payment.ID.String() + "-" + hex.EncodeToString(suffix)
+
+	resp, err := c.call(ctx, actionSendOTP, payment, txRef, "")
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if resp.Code != codeSuccess {
+		return provider.Result{Status: provider.Declined, Reason: resp.reason()}, nil
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"tx_ref":     txRef,
+	}).Info("Amole OTP sent to customer")
+
+	return provider.Result{Status: provider.Pending, Reference: txRef}, nil
+}
+
+// Capture is not used: the debit happens in ConfirmOTP
+func (c *client) Capture(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	return c.Query(ctx, payment)
+}
+
+// ConfirmOTP debits the wallet with the customer's OTP. A wrong code is a
+// decline; the merchant retries the payment to send a new one.
+func (c *client) ConfirmOTP(ctx context.Context, payment *domain.Payment, code string) (provider.Result, error) {
+	if time.Since(payment.UpdatedAt) > c.config.OTPTTL {
+		return provider.Result{}, domain.ErrOTPExpired
+	}
+
+	resp, err := c.call(ctx, actionDebit, payment, payment.ProviderReference, code)
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	result := provider.Result{Reference: payment.ProviderReference}
+	if resp.Code != codeSuccess {
+		result.Status = provider.Declined
+		result.Reason = resp.reason()
+		return result, nil
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"amole_txn":  resp.TransactionID,
+		"tx_ref":     payment.ProviderReference,
+	}).Info("Amole wallet debited")
+
+	result.Status = provider.Captured
+	return result, nil
+}
+
+// Query: Amole has no status inquiry. Nothing is debited without the OTP,
+// so a payment whose OTP was never confirmed is declined once well past
+// the OTP lifetime.
+func (c *client) Query(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	result := provider.Result{Status: provider.Pending, Reference: payment.ProviderReference}
+	if time.Since(payment.UpdatedAt) > 2*c.config.OTPTTL {
+		result.Status = provider.Declined
+		result.Reason = "Amole OTP was not confirmed in time"
+	}
+	return result, nil
+}
+
+type response struct {
+	Code          string `json:"MSG_ErrorCode"`
+	ShortMessage  string `json:"MSG_ShortMessage"`
+	LongMessage   string `json:"MSG_LongMessage"`
+	TransactionID string `json:"MSG_TransactionID"`
+}
+
+func (r response) reason() string {
+	if r.LongMessage != "" {
+		return "Amole: " + r.LongMessage
+	}
+	return "Amole: declined (" + r.Code + ")"
+}
+
+func (c *client) call(ctx context.Context, action string, payment *domain.Payment, txRef, otp string) (response, error) {
+	form := url.Values{
+		"BODY_CardNumber":       {localPhone(payment.CustomerPhone)},
+		"BODY_ExpirationDate":   {""},
+		"BODY_PIN":              {otp},
+		"BODY_PaymentAction":    {action},
+		"BODY_AmountX":          {payment.Amount.String()},
+		"BODY_AmoleMerchantID":  {c.config.MerchantID},
+		"BODY_OrderDescription": {payment.Description},
+		"BODY_SourceTransID":    {txRef},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return response{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HDR_Signature", c.config.Signature)
+	req.Header.Set("HDR_IPAddress", c.config.IPAddress)
+	req.Header.Set("HDR_UserName", c.config.Username)
+	req.Header.Set("HDR_Password", c.config.Password)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return response{}, fmt.Errorf("amole request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return response{}, &apiError{status: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return response{}, fmt.Errorf("amole request failed: %w", err)
+	}
+
+	// Amole wraps its answer in a one-element array
+	var answers []response
+	if err := json.Unmarshal(data, &answers); err != nil {
+		var single response
+		if err := json.Unmarshal(data, &single); err != nil {
+			return response{}, fmt.Errorf("amole returned invalid body: %w", err)
+		}
+		answers = []response{single}
+	}
+	if len(answers) == 0 || answers[0].Code == "" {
+		return response{}, errors.New("amole returned no result code")
+	}
+
+	return answers[0], nil
+}
+
+// apiError is a non-200 answer from Amole
+type apiError struct {
+	status int
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("amole returned status %d", e.status)
+}
+
+// StatusCode lets provider.Retryable tell a server error from a rejection
+func (e *apiError) StatusCode() int {
+	return e.status
+}
+
+// localPhone turns +2519XXXXXXXX into the 09XXXXXXXX form Amole expects
+func localPhone(phone string) string {
+	if strings.HasPrefix(phone, "+251") && len(phone) == 13 {
+		return "0" + phone[4:]
+	}
+	return phone
+}
@@ -0,0 +1,276 @@
+// Package arifpay routes payments through ArifPay's checkout sessions. The
+// customer picks a wallet or bank on ArifPay's page; the outcome arrives on
+// the notify URL or is polled with the session lookup.
+package arifpay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBaseURL = "https://gateway.arifpay.net"
+
+type Config struct {
+	BaseURL            string
+	APIKey             string
+	NotifyURL          string // Our webhook endpoint
+	SuccessURL         string // Where ArifPay sends the customer after paying
+	CancelURL          string
+	ErrorURL           string
+	BeneficiaryAccount string   // Merchant account ArifPay settles to
+	BeneficiaryBank    string   // ArifPay's code for the beneficiary bank
+	PaymentMethods     []string // Offered on the checkout page, e.g. TELEBIRR, CBE
+	SessionTTL         time.Duration
+	Timeout            time.Duration
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+	logger *logrus.Logger
+}
+
+func New(config Config, logger *logrus.Logger) provider.PaymentProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = time.Hour
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+
+	return &client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		logger: logger,
+	}
+}
+
+// Authorize creates a checkout session. The nonce is the payment ID plus a
+// random suffix, so a retried payment gets a fresh session and a
+// notification leads back to the payment.
+func (c *client) Authorize(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.Currency != domain.CurrencyETB {
+		return provider.Result{Status: provider.Declined, Reason: "ArifPay accepts ETB only"}, nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return provider.Result{}, err
+	}
+	nonce := payment.ID.String() + "-" + hex.EncodeToString(suffix)
+
+	name := payment.Description
+	if name == "" {
+		name = payment.Reference
+	}
+	body := map[string]interface{}{
+		"nonce":          nonce,
+		"phone":          internationalPhone(payment.CustomerPhone),
+		"email":          payment.CustomerEmail,
+		"notifyUrl":      c.config.NotifyURL,
+		"successUrl":     c.config.SuccessURL,
+		"cancelUrl":      c.config.CancelURL,
+		"errorUrl":       c.config.ErrorURL,
+		"paymentMethods": c.config.PaymentMethods,
+		"expireDate":     time.Now().Add(c.config.SessionTTL).UTC().Format("2006-01-02T15:04:05"),
+		"lang":           "EN",
+		"items": []map[string]interface{}{{
+			"name":        name,
+			"quantity":    1,
+			"price":       payment.Amount,
+			"description": payment.Reference,
+		}},
+		"beneficiaries": []map[string]interface{}{{
+			"accountNumber": c.config.BeneficiaryAccount,
+			"bank":          c.config.BeneficiaryBank,
+			"amount":        payment.Amount,
+		}},
+	}
+	if payment.Language == domain.LanguageAmharic {
+		body["lang"] = "AM"
+	}
+
+	var session struct {
+		SessionID  string `json:"sessionId"`
+		PaymentURL string `json:"paymentUrl"`
+	}
+	err := c.call(ctx, http.MethodPost, "/api/checkout/session", body, &session)
+	var rejected *apiError
+	if errors.As(err, &rejected) && rejected.status < 500 {
+		// No session was opened, so the payment can safely fail
+		return provider.Result{Status: provider.Declined, Reason: "rejected by ArifPay: " + rejected.message}, nil
+	}
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if session.SessionID == "" || session.PaymentURL == "" {
+		return provider.Result{}, errors.New("arifpay returned no session")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"session_id": session.SessionID,
+	}).Info("ArifPay checkout session opened")
+
+	return provider.Result{Status: provider.Pending, Reference: session.SessionID, CheckoutURL: session.PaymentURL}, nil
+}
+
+// Capture is not used: ArifPay captures when the customer pays
+func (c *client) Capture(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	return c.Query(ctx, payment)
+}
+
+// Query looks the session up at ArifPay; notifications only trigger this
+func (c *client) Query(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.ProviderReference == "" {
+		return provider.Result{}, errors.New("payment has no ArifPay session")
+	}
+
+	var session struct {
+		TotalAmount float64 `json:"totalAmount"`
+		Transaction *struct {
+			TransactionID     string `json:"transactionId"`
+			TransactionStatus string `json:"transactionStatus"`
+		} `json:"transaction"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/api/checkout/session/"+url.PathEscape(payment.ProviderReference), nil, &session); err != nil {
+		return provider.Result{}, err
+	}
+
+	result := provider.Result{Status: provider.Pending, Reference: payment.ProviderReference}
+	if session.Transaction == nil {
+		// Nothing can be paid once the session has expired; the margin
+		// covers clock skew with ArifPay
+		if time.Since(payment.UpdatedAt) > c.config.SessionTTL+10*time.Minute {
+			result.Status = provider.Declined
+			result.Reason = "arifpay session expired unpaid"
+		}
+		return result, nil
+	}
+
+	switch strings.ToUpper(session.Transaction.TransactionStatus) {
+	case "SUCCESS":
+		if domain.NewAmount(session.TotalAmount) != payment.Amount {
+			c.logger.WithFields(logrus.Fields{
+				"payment_id":   payment.ID,
+				"total_amount": session.TotalAmount,
+			}).Error("ArifPay session does not match the payment")
+			result.Status = provider.Declined
+			result.Reason = "amount paid at ArifPay does not match the payment"
+			return result, nil
+		}
+		result.Status = provider.Captured
+	case "FAILED", "CANCELED", "CANCELLED", "EXPIRED":
+		result.Status = provider.Declined
+		result.Reason = "arifpay transaction " + strings.ToLower(session.Transaction.TransactionStatus)
+	}
+
+	return result, nil
+}
+
+// call sends a request and unwraps ArifPay's {error, msg, data} envelope
+func (c *client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = strings.NewReader(string(payload))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-arifpay-key", c.config.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("arifpay %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("arifpay %s failed: %w", path, err)
+	}
+
+	var envelope struct {
+		Error bool            `json:"error"`
+		Msg   string          `json:"msg"`
+		Data  json.RawMessage `json:"data"`
+	}
+	_ = json.Unmarshal(data, &envelope)
+
+	if resp.StatusCode != http.StatusOK || envelope.Error {
+		status := resp.StatusCode
+		if status == http.StatusOK {
+			status = http.StatusBadRequest
+		}
+		return &apiError{path: path, status: status, message: envelope.Msg}
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("arifpay %s returned invalid body: %w", path, err)
+	}
+	return nil
+}
+
+// apiError is a failed answer from ArifPay
+type apiError struct {
+	path    string
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("arifpay %s returned status %d: %s", e.path, e.status, e.message)
+}
+
+// StatusCode lets provider.Retryable tell a server error from a rejection
+func (e *apiError) StatusCode() int {
+	return e.status
+}
+
+// PaymentID recovers the payment from a notification body's nonce
+func PaymentID(body []byte) (uuid.UUID, error) {
+	var event struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return uuid.Nil, err
+	}
+	if len(event.Nonce) < 36 {
+		return uuid.Nil, errors.New("nonce was not issued by this gateway")
+	}
+	return uuid.Parse(event.Nonce[:36])
+}
+
+// internationalPhone turns +2519XXXXXXXX into the 2519XXXXXXXX form
+// ArifPay expects
+func internationalPhone(phone string) string {
+	return strings.TrimPrefix(phone, "+")
+}
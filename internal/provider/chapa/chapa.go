@@ -0,0 +1,296 @@
+// Package chapa routes payments through Chapa's hosted checkout, so
+// merchants can take payments without direct bank contracts. The customer
+// pays on Chapa's page; the outcome arrives by webhook or is polled with
+// the transaction verify endpoint.
+package chapa
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// BankCode is the code payments use to be routed through Chapa
+const BankCode = "CHAPA"
+
+const defaultBaseURL = "https://api.chapa.co/v1"
+
+type Config struct {
+	BaseURL     string
+	SecretKey   string
+	CallbackURL string // Our webhook endpoint, sent with each checkout
+	ReturnURL   string // Where Chapa sends the customer after paying
+	Timeout     time.Duration
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+	logger *logrus.Logger
+}
+
+func New(config Config, logger *logrus.Logger) provider.PaymentProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+
+	return &client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		logger: logger,
+	}
+}
+
+// Authorize opens a hosted checkout. Chapa needs a fresh tx_ref for every
+// attempt, so a retried payment gets a new one.
+func (c *client) Authorize(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.Currency != domain.CurrencyETB && payment.Currency != domain.CurrencyUSD {
+		return provider.Result{Status: provider.Declined, Reason: "currency not supported by Chapa"}, nil
+	}
+
+	txRef, err := newTxRef(payment.ID)
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	firstName, lastName := splitName(payment.CustomerName)
+	body := map[string]interface{}{
+		"amount":       payment.Amount.String(),
+		"currency":     string(payment.Currency),
+		"email":        payment.CustomerEmail,
+		"first_name":   firstName,
+		"last_name":    lastName,
+		"phone_number": localPhone(payment.CustomerPhone),
+		"tx_ref":       txRef,
+		"callback_url": c.config.CallbackURL,
+		"return_url":   c.config.ReturnURL,
+		"customization": map[string]string{
+			"description": chapaText(payment.Description),
+		},
+	}
+
+	var resp struct {
+		Data struct {
+			CheckoutURL string `json:"checkout_url"`
+		} `json:"data"`
+	}
+	err = c.call(ctx, http.MethodPost, "/transaction/initialize", body, &resp)
+	var rejected *apiError
+	if errors.As(err, &rejected) && rejected.status < 500 {
+		// Nothing was opened, so the payment can safely fail
+		return provider.Result{Status: provider.Declined, Reason: fmt.Sprintf("rejected by Chapa: %v", rejected.message)}, nil
+	}
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if resp.Data.CheckoutURL == "" {
+		return provider.Result{}, errors.New("chapa returned no checkout_url")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"tx_ref":     txRef,
+	}).Info("Chapa checkout opened")
+
+	return provider.Result{Status: provider.Pending, Reference: txRef, CheckoutURL: resp.Data.CheckoutURL}, nil
+}
+
+// Capture is not used: Chapa captures when the customer pays
+func (c *client) Capture(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	return c.Query(ctx, payment)
+}
+
+// Query verifies the transaction with Chapa. A webhook is only a hint to
+// call this; its body is never trusted for the outcome.
+func (c *client) Query(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.ProviderReference == "" {
+		return provider.Result{}, errors.New("payment has no Chapa tx_ref")
+	}
+
+	var resp struct {
+		Data struct {
+			Status   string          `json:"status"`
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		} `json:"data"`
+	}
+	result := provider.Result{Status: provider.Pending, Reference: payment.ProviderReference}
+
+	err := c.call(ctx, http.MethodGet, "/transaction/verify/"+url.PathEscape(payment.ProviderReference), nil, &resp)
+	var unknown *apiError
+	if errors.As(err, &unknown) && (unknown.status == http.StatusNotFound || unknown.status == http.StatusBadRequest) {
+		// Chapa only knows the transaction once the customer starts paying
+		return result, nil
+	}
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	switch strings.ToLower(resp.Data.Status) {
+	case "success":
+		amount, err := domain.ParseAmount(strings.Trim(string(resp.Data.Amount), `"`))
+		if err != nil || amount != payment.Amount || !strings.EqualFold(resp.Data.Currency, string(payment.Currency)) {
+			c.logger.WithFields(logrus.Fields{
+				"payment_id": payment.ID,
+				"amount":     string(resp.Data.Amount),
+				"currency":   resp.Data.Currency,
+			}).Error("Chapa transaction does not match the payment")
+			result.Status = provider.Declined
+			result.Reason = "amount paid at Chapa does not match the payment"
+			return result, nil
+		}
+		result.Status = provider.Captured
+	case "failed", "cancelled":
+		result.Status = provider.Declined
+		result.Reason = "chapa transaction " + strings.ToLower(resp.Data.Status)
+	}
+
+	return result, nil
+}
+
+func (c *client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = strings.NewReader(string(payload))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.SecretKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("chapa %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("chapa %s failed: %w", path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Message interface{} `json:"message"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		return &apiError{path: path, status: resp.StatusCode, message: failure.Message}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("chapa %s returned invalid body: %w", path, err)
+	}
+	return nil
+}
+
+// apiError is a non-200 answer from Chapa
+type apiError struct {
+	path    string
+	status  int
+	message interface{}
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("chapa %s returned status %d: %v", e.path, e.status, e.message)
+}
+
+// StatusCode lets provider.Retryable tell a server error from a rejection
+func (e *apiError) StatusCode() int {
+	return e.status
+}
+
+// VerifySignature checks a webhook against the secret configured in the
+// Chapa dashboard. Chapa sends x-chapa-signature, an HMAC-SHA256 of the
+// body, and Chapa-Signature, an HMAC-SHA256 of the secret itself; either
+// is accepted.
+func VerifySignature(secret string, body []byte, bodySignature, secretSignature string) bool {
+	if secret == "" {
+		return false
+	}
+	if bodySignature != "" && hmac.Equal([]byte(sign(secret, body)), []byte(strings.ToLower(bodySignature))) {
+		return true
+	}
+	return secretSignature != "" && hmac.Equal([]byte(sign(secret, []byte(secret))), []byte(strings.ToLower(secretSignature)))
+}
+
+// PaymentID recovers the payment from a webhook body's tx_ref
+func PaymentID(body []byte) (uuid.UUID, error) {
+	var event struct {
+		TxRef string `json:"tx_ref"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return uuid.Nil, err
+	}
+	if len(event.TxRef) < 36 {
+		return uuid.Nil, errors.New("tx_ref was not issued by this gateway")
+	}
+	return uuid.Parse(event.TxRef[:36])
+}
+
+func sign(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newTxRef is the payment ID plus a random suffix: unique per attempt, and
+// it leads back to the payment when a webhook arrives
+func newTxRef(id uuid.UUID) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return id.String() + "-" + hex.EncodeToString(suffix), nil
+}
+
+func splitName(name string) (string, string) {
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return first, strings.TrimSpace(last)
+}
+
+// localPhone turns +2519XXXXXXXX into the 09XXXXXXXX form Chapa expects
+func localPhone(phone string) string {
+	if strings.HasPrefix(phone, "+251") && len(phone) == 13 {
+		return "0" + phone[4:]
+	}
+	return phone
+}
+
+// chapaText keeps the characters Chapa accepts in customization fields
+func chapaText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 128 && (r == ' ' || r == '-' || r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen means the bank's provider failed too often lately and the
+// call was refused without being sent
+var ErrCircuitOpen = errors.New("payment provider circuit open")
+
+// BreakerSettings stop calls to a bank whose provider keeps failing, so
+// they fail at once rather than each waiting out its timeout
+type BreakerSettings struct {
+	FailureThreshold int           // Consecutive failures that open the circuit
+	OpenTimeout      time.Duration // How long it stays open before a trial call is let through
+}
+
+type circuitState int
+
+const (
+	circuitClosed   circuitState = iota
+	circuitOpen                  // Calls are refused until OpenTimeout has passed
+	circuitHalfOpen              // One trial call decides whether to close or reopen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker guards the calls to one bank code
+type breaker struct {
+	bankCode string
+	settings BreakerSettings
+	logger   *logrus.Logger
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial call is in flight
+}
+
+// allow reports whether a call may go out; a half-open circuit lets
+// through one call at a time
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.settings.OpenTimeout {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		b.trial = true
+		return true
+	case circuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record counts the outcome of a call allow let through
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if !isProviderFault(err) {
+		b.failures = 0
+		if b.state != circuitClosed {
+			b.setState(circuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.openedAt = time.Now()
+		if b.state != circuitOpen {
+			b.setState(circuitOpen)
+		}
+	}
+}
+
+func (b *breaker) setState(state circuitState) {
+	log := b.logger.WithFields(logrus.Fields{
+		"bank_code": b.bankCode,
+		"from":      b.state.String(),
+		"to":        state.String(),
+	})
+	if state == circuitOpen {
+		log.WithField("failures", b.failures).Warn("Payment provider circuit opened")
+	} else {
+		log.Info("Payment provider circuit state changed")
+	}
+	b.state = state
+}
+
+// isProviderFault reports whether err says the provider is unwell. A call
+// given up by its caller and a customer's expired OTP say nothing about
+// the provider; declines come back as results, not errors.
+func isProviderFault(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, domain.ErrOTPExpired)
+}
+
+func (b *breaker) do(call func() (Result, error)) (Result, error) {
+	if !b.allow() {
+		return Result{}, ErrCircuitOpen
+	}
+	result, err := call()
+	b.record(err)
+	return result, err
+}
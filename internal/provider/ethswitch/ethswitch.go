@@ -0,0 +1,237 @@
+// Package ethswitch takes domestic debit cards through EthSwitch's
+// e-commerce gateway. The customer enters the card, and completes 3-D
+// Secure, on the gateway's page, so card data never reaches us; the
+// gateway then sends the customer back to our return endpoint.
+package ethswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ISO 4217 numeric code the gateway expects for ETB
+const currencyETB = "230"
+
+// Gateway order statuses
+const (
+	orderRegistered = 0 // Card not entered yet
+	orderHeld       = 1 // Two-stage payments only
+	orderDeposited  = 2
+	orderReversed   = 3
+	orderRefunded   = 4
+	orderACS        = 5 // Customer is at the card issuer's 3-D Secure page
+	orderDeclined   = 6
+)
+
+type Config struct {
+	BaseURL        string // e.g. https://ecom.ethswitch.et/payment/rest
+	Username       string
+	Password       string
+	ReturnURL      string        // Public URL of /api/v1/providers/ethswitch/return
+	SessionTimeout time.Duration // How long the card page stays open
+	Timeout        time.Duration
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+	logger *logrus.Logger
+}
+
+func New(config Config, logger *logrus.Logger) provider.PaymentProvider {
+	if config.SessionTimeout <= 0 {
+		config.SessionTimeout = 20 * time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+
+	return &client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		logger: logger,
+	}
+}
+
+// Authorize registers an order and returns the card page. The order
+// number is the payment ID with the attempt time, since the gateway
+// refuses a number it has seen before.
+func (c *client) Authorize(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.Currency != domain.CurrencyETB {
+		return provider.Result{Status: provider.Declined, Reason: "domestic cards are charged in ETB only"}, nil
+	}
+
+	returnURL := c.config.ReturnURL + "?payment_id=" + payment.ID.String()
+	language := "en"
+	if payment.Language == domain.LanguageAmharic {
+		language = "am"
+	}
+
+	params := url.Values{
+		"orderNumber":        {strings.ReplaceAll(payment.ID.String(), "-", "")[:24] + strconv.FormatInt(time.Now().Unix()%1e8, 10)},
+		"amount":             {strconv.FormatInt(int64(payment.Amount), 10)},
+		"currency":           {currencyETB},
+		"returnUrl":          {returnURL},
+		"failUrl":            {returnURL},
+		"description":        {payment.Reference},
+		"language":           {language},
+		"sessionTimeoutSecs": {strconv.Itoa(int(c.config.SessionTimeout.Seconds()))},
+	}
+
+	var resp struct {
+		OrderID string `json:"orderId"`
+		FormURL string `json:"formUrl"`
+	}
+	code, message, err := c.call(ctx, "/register.do", params, &resp)
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if code != 0 {
+		// No order was registered, so the payment can safely fail
+		return provider.Result{Status: provider.Declined, Reason: "rejected by EthSwitch: " + message}, nil
+	}
+	if resp.OrderID == "" || resp.FormURL == "" {
+		return provider.Result{}, errors.New("ethswitch returned no order")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"order_id":   resp.OrderID,
+	}).Info("EthSwitch card order registered")
+
+	return provider.Result{Status: provider.Pending, Reference: resp.OrderID, CheckoutURL: resp.FormURL}, nil
+}
+
+// Capture is not used: orders are one-stage and deposited on approval
+func (c *client) Capture(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	return c.Query(ctx, payment)
+}
+
+// Query reads the order status; the customer's return only triggers this
+func (c *client) Query(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.ProviderReference == "" {
+		return provider.Result{}, errors.New("payment has no EthSwitch order")
+	}
+
+	var order struct {
+		OrderStatus *int   `json:"orderStatus"`
+		ActionCode  int    `json:"actionCode"`
+		Amount      int64  `json:"amount"`
+		Currency    string `json:"currency"`
+		ActionDesc  string `json:"actionCodeDescription"`
+	}
+	code, message, err := c.call(ctx, "/getOrderStatusExtended.do", url.Values{"orderId": {payment.ProviderReference}}, &order)
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if code != 0 || order.OrderStatus == nil {
+		return provider.Result{}, fmt.Errorf("ethswitch order status failed: %s", message)
+	}
+
+	result := provider.Result{Status: provider.Pending, Reference: payment.ProviderReference}
+	switch *order.OrderStatus {
+	case orderDeposited:
+		if order.Amount != int64(payment.Amount) || order.Currency != currencyETB {
+			c.logger.WithFields(logrus.Fields{
+				"payment_id": payment.ID,
+				"amount":     order.Amount,
+				"currency":   order.Currency,
+			}).Error("EthSwitch order does not match the payment")
+			result.Status = provider.Declined
+			result.Reason = "amount charged by EthSwitch does not match the payment"
+			return result, nil
+		}
+		result.Status = provider.Captured
+	case orderDeclined, orderReversed, orderRefunded:
+		result.Status = provider.Declined
+		result.Reason = "card declined"
+		if order.ActionDesc != "" {
+			result.Reason = "card declined: " + order.ActionDesc
+		}
+	case orderRegistered:
+		// The card page has closed without a card being entered
+		if time.Since(payment.UpdatedAt) > c.config.SessionTimeout+10*time.Minute {
+			result.Status = provider.Declined
+			result.Reason = "card page expired unpaid"
+		}
+	case orderHeld:
+		result.Status = provider.Authorized
+	}
+
+	return result, nil
+}
+
+// call posts to the gateway, which answers 200 with errorCode 0 on success
+func (c *client) call(ctx context.Context, path string, params url.Values, out interface{}) (int, string, error) {
+	params.Set("userName", c.config.Username)
+	params.Set("password", c.config.Password)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.BaseURL+path, strings.NewReader(params.Encode()))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("ethswitch %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", &apiError{path: path, status: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, "", fmt.Errorf("ethswitch %s failed: %w", path, err)
+	}
+
+	var status struct {
+		ErrorCode    string `json:"errorCode"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return 0, "", fmt.Errorf("ethswitch %s returned invalid body: %w", path, err)
+	}
+	if status.ErrorCode != "" && status.ErrorCode != "0" {
+		code, _ := strconv.Atoi(status.ErrorCode)
+		if code == 0 {
+			code = -1
+		}
+		return code, status.ErrorMessage, nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return 0, "", fmt.Errorf("ethswitch %s returned invalid body: %w", path, err)
+	}
+	return 0, "", nil
+}
+
+// apiError is a non-200 answer from the gateway
+type apiError struct {
+	path   string
+	status int
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("ethswitch %s returned status %d", e.path, e.status)
+}
+
+// StatusCode lets provider.Retryable tell a server error from a rejection
+func (e *apiError) StatusCode() int {
+	return e.status
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+
+	"payment-gateway/internal/domain"
+)
+
+// guarded sends a provider's calls through its bank's breaker, retrying
+// those that failed in a way the retry policy allows
+type guarded struct {
+	provider PaymentProvider
+	bankCode string
+	breaker  *breaker // nil when the breakers are off
+	retry    *retrier // nil when calls are not retried
+}
+
+// do makes the call; idempotent says sending it twice is harmless, so a
+// call that may have reached the provider can be retried
+func (g *guarded) do(ctx context.Context, idempotent bool, call func() (Result, error)) (Result, error) {
+	attempt := call
+	if g.breaker != nil {
+		attempt = func() (Result, error) { return g.breaker.do(call) }
+	}
+	if g.retry == nil {
+		return attempt()
+	}
+	return g.retry.do(ctx, g.bankCode, idempotent, attempt)
+}
+
+func (g *guarded) Authorize(ctx context.Context, payment *domain.Payment) (Result, error) {
+	return g.do(ctx, false, func() (Result, error) { return g.provider.Authorize(ctx, payment) })
+}
+
+func (g *guarded) Capture(ctx context.Context, payment *domain.Payment) (Result, error) {
+	return g.do(ctx, false, func() (Result, error) { return g.provider.Capture(ctx, payment) })
+}
+
+func (g *guarded) Query(ctx context.Context, payment *domain.Payment) (Result, error) {
+	return g.do(ctx, true, func() (Result, error) { return g.provider.Query(ctx, payment) })
+}
+
+func (g *guarded) confirmOTP(ctx context.Context, payment *domain.Payment, code string) (Result, error) {
+	return g.do(ctx, false, func() (Result, error) {
+		return g.provider.(OTPConfirmer).ConfirmOTP(ctx, payment, code)
+	})
+}
+
+// refund is idempotent: Refunder implementations pass the refund ID as the
+// provider's idempotency key
+func (g *guarded) refund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (Result, error) {
+	return g.do(ctx, true, func() (Result, error) {
+		return g.provider.(Refunder).Refund(ctx, payment, refund)
+	})
+}
+
+// The optional interfaces are kept, so callers' type assertions still
+// find what the provider supports
+
+type guardedOTPConfirmer struct{ *guarded }
+
+func (g guardedOTPConfirmer) ConfirmOTP(ctx context.Context, payment *domain.Payment, code string) (Result, error) {
+	return g.confirmOTP(ctx, payment, code)
+}
+
+type guardedRefunder struct{ *guarded }
+
+func (g guardedRefunder) Refund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (Result, error) {
+	return g.refund(ctx, payment, refund)
+}
+
+type guardedOTPRefunder struct{ *guarded }
+
+func (g guardedOTPRefunder) ConfirmOTP(ctx context.Context, payment *domain.Payment, code string) (Result, error) {
+	return g.confirmOTP(ctx, payment, code)
+}
+
+func (g guardedOTPRefunder) Refund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (Result, error) {
+	return g.refund(ctx, payment, refund)
+}
+
+func guard(g *guarded) PaymentProvider {
+	_, confirms := g.provider.(OTPConfirmer)
+	_, refunds := g.provider.(Refunder)
+	switch {
+	case confirms && refunds:
+		return guardedOTPRefunder{g}
+	case confirms:
+		return guardedOTPConfirmer{g}
+	case refunds:
+		return guardedRefunder{g}
+	default:
+		return g
+	}
+}
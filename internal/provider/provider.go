@@ -0,0 +1,185 @@
+// Package provider connects payment processing to the banks and wallets
+// that move the money. Each integration implements PaymentProvider and is
+// registered under the bank codes it serves.
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoProvider means no provider is registered for the payment's bank
+var ErrNoProvider = errors.New("no payment provider for this bank")
+
+// Status is a provider's view of a payment
+type Status string
+
+const (
+	Authorized Status = "AUTHORIZED" // Funds held; Capture completes the payment
+	Captured   Status = "CAPTURED"   // Funds moved; the payment succeeded
+	Declined   Status = "DECLINED"
+	Pending    Status = "PENDING" // Outcome not known yet; ask again with Query
+)
+
+// Result is the outcome of a provider call
+type Result struct {
+	Status      Status
+	Reason      string // Why it was declined, for the attempt history
+	Reference   string // Transaction reference at the provider, if any; kept for Query
+	CheckoutURL string // Hosted checkout page, when the customer pays at the provider
+}
+
+// PaymentProvider sends payments to one bank or wallet. Single-message
+// integrations (most Ethiopian bank transfers) return Captured straight
+// from Authorize and never see Capture.
+type PaymentProvider interface {
+	Authorize(ctx context.Context, payment *domain.Payment) (Result, error)
+	Capture(ctx context.Context, payment *domain.Payment) (Result, error)
+	// Query asks what happened to a payment already sent; it must not send
+	// it again
+	Query(ctx context.Context, payment *domain.Payment) (Result, error)
+}
+
+// OTPConfirmer is implemented by providers whose customers approve each
+// debit with a one-time code the provider sends them. Authorize sends the
+// code and returns Pending; the code the customer enters goes to
+// ConfirmOTP.
+type OTPConfirmer interface {
+	ConfirmOTP(ctx context.Context, payment *domain.Payment, code string) (Result, error)
+}
+
+// Refunder is implemented by providers that can return a captured
+// payment's money. Captured means the refund was sent; Pending means the
+// provider will finish it later. A refund whose answer was lost is sent
+// again with the same refund ID, so integrations should pass it as the
+// provider's idempotency key.
+type Refunder interface {
+	Refund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (Result, error)
+}
+
+// Registry finds the provider for a bank code, falling back to a default
+// when one is set. With circuit breakers on, each bank code's calls go
+// through a breaker of its own, so one bank being down does not hold up
+// payments to the others. With a retry policy set, calls that failed in a
+// passing way are made again.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]PaymentProvider
+	fallback  PaymentProvider
+
+	breakerSettings BreakerSettings
+	breakerLogger   *logrus.Logger
+	breakers        map[string]*breaker
+	retry           *retrier
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]PaymentProvider),
+		breakers:  make(map[string]*breaker),
+	}
+}
+
+// SetCircuitBreakers guards every bank code's provider calls with the
+// settings; a FailureThreshold of zero turns the breakers off
+func (r *Registry) SetCircuitBreakers(settings BreakerSettings, logger *logrus.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breakerSettings, r.breakerLogger = settings, logger
+	r.breakers = make(map[string]*breaker)
+}
+
+// SetRetryPolicy retries every bank code's failed provider calls by the
+// policy; see Retryable for which failures are retried
+func (r *Registry) SetRetryPolicy(policy RetryPolicy, logger *logrus.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retry = nil
+	if policy.MaxAttempts > 1 {
+		r.retry = &retrier{policy: policy, logger: logger}
+	}
+}
+
+// Register serves the bank codes with p, replacing any earlier provider
+func (r *Registry) Register(p PaymentProvider, bankCodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range bankCodes {
+		r.providers[strings.ToUpper(code)] = p
+	}
+}
+
+// SetFallback serves bank codes that have no provider of their own
+func (r *Registry) SetFallback(p PaymentProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = p
+}
+
+// Registered reports whether code has a provider of its own, rather than
+// the fallback
+func (r *Registry) Registered(code string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.providers[strings.ToUpper(code)]
+	return ok
+}
+
+// Lookup returns the provider for the bank code. While the code's circuit
+// is open, its calls fail with ErrCircuitOpen without reaching the bank;
+// each retry counts towards the circuit.
+func (r *Registry) Lookup(bankCode string) (PaymentProvider, error) {
+	code := strings.ToUpper(bankCode)
+
+	r.mu.RLock()
+	p, ok := r.providers[code]
+	if !ok {
+		p = r.fallback
+	}
+	breakers, retry := r.breakerSettings.FailureThreshold > 0, r.retry
+	r.mu.RUnlock()
+
+	if p == nil {
+		return nil, ErrNoProvider
+	}
+	if !breakers && retry == nil {
+		return p, nil
+	}
+
+	g := &guarded{provider: p, bankCode: code, retry: retry}
+	if breakers {
+		g.breaker = r.breaker(code)
+	}
+	return guard(g), nil
+}
+
+// breaker returns the bank code's breaker, creating it on first use
+func (r *Registry) breaker(code string) *breaker {
+	r.mu.RLock()
+	b, ok := r.breakers[code]
+	r.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok = r.breakers[code]
+	if !ok {
+		b = &breaker{bankCode: code, settings: r.breakerSettings, logger: r.breakerLogger}
+		r.breakers[code] = b
+	}
+	return b
+}
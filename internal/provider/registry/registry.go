@@ -0,0 +1,119 @@
+// Package registry builds the provider registry from configuration, so the
+// API and the worker route payments to the same integrations.
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/config"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+	"payment-gateway/internal/provider/amole"
+	"payment-gateway/internal/provider/arifpay"
+	"payment-gateway/internal/provider/chapa"
+	"payment-gateway/internal/provider/ethswitch"
+	"payment-gateway/internal/provider/santimpay"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FromConfig registers every enabled provider. Every bank is simulated
+// until its integration is registered here.
+func FromConfig(cfg config.ProvidersConfig, logger *logrus.Logger) (*provider.Registry, error) {
+	providers := provider.NewSimulatedRegistry()
+
+	breaker := provider.BreakerSettings{
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      cfg.CircuitBreaker.OpenTimeout,
+	}
+	if breaker.OpenTimeout <= 0 {
+		breaker.OpenTimeout = 30 * time.Second
+	}
+	providers.SetCircuitBreakers(breaker, logger)
+
+	if cfg.Chapa.Enabled {
+		providers.Register(chapa.New(chapa.Config{
+			BaseURL:     cfg.Chapa.BaseURL,
+			SecretKey:   cfg.Chapa.SecretKey,
+			CallbackURL: cfg.Chapa.CallbackURL,
+			ReturnURL:   cfg.Chapa.ReturnURL,
+			Timeout:     cfg.Chapa.Timeout,
+		}, logger), chapa.BankCode)
+	}
+	if cfg.Amole.Enabled {
+		providers.Register(amole.New(amole.Config{
+			URL:        cfg.Amole.URL,
+			MerchantID: cfg.Amole.MerchantID,
+			Username:   cfg.Amole.Username,
+			Password:   cfg.Amole.Password,
+			Signature:  cfg.Amole.Signature,
+			IPAddress:  cfg.Amole.IPAddress,
+			OTPTTL:     cfg.Amole.OTPTTL,
+			Timeout:    cfg.Amole.Timeout,
+		}, logger), string(domain.BankDashen))
+	}
+	if cfg.ArifPay.Enabled {
+		providers.Register(arifpay.New(arifpay.Config{
+			BaseURL:            cfg.ArifPay.BaseURL,
+			APIKey:             cfg.ArifPay.APIKey,
+			NotifyURL:          cfg.ArifPay.NotifyURL,
+			SuccessURL:         cfg.ArifPay.SuccessURL,
+			CancelURL:          cfg.ArifPay.CancelURL,
+			ErrorURL:           cfg.ArifPay.ErrorURL,
+			BeneficiaryAccount: cfg.ArifPay.BeneficiaryAccount,
+			BeneficiaryBank:    cfg.ArifPay.BeneficiaryBank,
+			PaymentMethods:     cfg.ArifPay.PaymentMethods,
+			SessionTTL:         cfg.ArifPay.SessionTTL,
+			Timeout:            cfg.ArifPay.Timeout,
+		}, logger), string(domain.MethodArifPay))
+	}
+	if cfg.SantimPay.Enabled {
+		santimPay, err := santimpay.New(santimpay.Config{
+			BaseURL:    cfg.SantimPay.BaseURL,
+			MerchantID: cfg.SantimPay.MerchantID,
+			PrivateKey: cfg.SantimPay.PrivateKey,
+			NotifyURL:  cfg.SantimPay.NotifyURL,
+			SuccessURL: cfg.SantimPay.SuccessURL,
+			FailureURL: cfg.SantimPay.FailureURL,
+			CancelURL:  cfg.SantimPay.CancelURL,
+			Timeout:    cfg.SantimPay.Timeout,
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("santimpay: %w", err)
+		}
+		providers.Register(santimPay, string(domain.MethodSantimPay))
+	}
+	if cfg.EthSwitch.Enabled {
+		providers.Register(ethswitch.New(ethswitch.Config{
+			BaseURL:        cfg.EthSwitch.BaseURL,
+			Username:       cfg.EthSwitch.Username,
+			Password:       cfg.EthSwitch.Password,
+			ReturnURL:      cfg.EthSwitch.ReturnURL,
+			SessionTimeout: cfg.EthSwitch.SessionTimeout,
+			Timeout:        cfg.EthSwitch.Timeout,
+		}, logger), string(domain.MethodCard))
+	}
+	return providers, nil
+}
+
+// RetryPolicy is the worker's policy for provider calls, with defaults for
+// what the configuration leaves out
+func RetryPolicy(cfg config.ProviderRetryConfig) provider.RetryPolicy {
+	policy := provider.RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     cfg.Multiplier,
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 200 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 2 * time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	return policy
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RetryPolicy retries failed provider calls with exponential backoff.
+// Waits grow by Multiplier from InitialBackoff up to MaxBackoff, and each
+// is jittered so workers retrying the same bank do not retry together.
+type RetryPolicy struct {
+	MaxAttempts    int // Including the first call; 1 or less never retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// backoff is the wait before the given retry, counting from 1: half of
+// the exponential delay, plus up to as much again at random
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// Retryable reports whether a failed call is worth making again. A call
+// that never reached the provider always is; one that may have, only when
+// it is idempotent and the failure looks temporary: a timeout, a dropped
+// connection, or a server error or rate limit from the provider.
+func Retryable(err error, idempotent bool) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if notSent(err) {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+
+	var status interface{ StatusCode() int }
+	if errors.As(err, &status) {
+		code := status.StatusCode()
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// notSent reports whether the request failed before a connection to the
+// provider was made
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type retrier struct {
+	policy RetryPolicy
+	logger *logrus.Logger
+}
+
+// do makes the call until it succeeds, fails for good, runs out of
+// attempts or ctx ends; the last outcome is returned
+func (r *retrier) do(ctx context.Context, bankCode string, idempotent bool, call func() (Result, error)) (Result, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := call()
+		if attempt >= r.policy.MaxAttempts || !Retryable(err, idempotent) {
+			return result, err
+		}
+
+		wait := r.policy.backoff(attempt)
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"bank_code": bankCode,
+			"attempt":   attempt,
+			"backoff":   wait.String(),
+		}).Warn("Payment provider call failed, retrying")
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Package santimpay routes payments through SantimPay's hosted checkout.
+// Every request carries an ES256 token signed with the merchant's key, and
+// SantimPay signs its callbacks with its own key.
+package santimpay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBaseURL = "https://services.santimpay.com/api/v1/gateway"
+
+type Config struct {
+	BaseURL    string // The testnet gateway outside production
+	MerchantID string
+	PrivateKey string // PEM EC P-256 key registered with SantimPay
+	NotifyURL  string // Our callback endpoint
+	SuccessURL string
+	FailureURL string
+	CancelURL  string
+	Timeout    time.Duration
+}
+
+type client struct {
+	config Config
+	key    *ecdsa.PrivateKey
+	http   *http.Client
+	logger *logrus.Logger
+}
+
+func New(config Config, logger *logrus.Logger) (provider.PaymentProvider, error) {
+	if config.MerchantID == "" {
+		return nil, errors.New("SantimPay merchant ID is required")
+	}
+	key, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 15 * time.Second
+	}
+
+	return &client{
+		config: config,
+		key:    key,
+		http:   &http.Client{Timeout: config.Timeout},
+		logger: logger,
+	}, nil
+}
+
+// Authorize opens a checkout. The transaction ID is the payment ID plus a
+// random suffix, so a retried payment gets a fresh checkout and a
+// callback leads back to the payment.
+func (c *client) Authorize(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.Currency != domain.CurrencyETB {
+		return provider.Result{Status: provider.Declined, Reason: "SantimPay accepts ETB only"}, nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return provider.Result{}, err
+	}
+	txnID := payment.ID.String() + "-" + hex.EncodeToString(suffix)
+
+	reason := payment.Description
+	if reason == "" {
+		reason = payment.Reference
+	}
+	amount := payment.Amount.String()
+
+	token, err := c.sign(map[string]interface{}{
+		"amount":        amount,
+		"paymentReason": reason,
+		"merchantId":    c.config.MerchantID,
+		"generated":     time.Now().Unix(),
+	})
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	body := map[string]interface{}{
+		"id":                 txnID,
+		"amount":             amount,
+		"reason":             reason,
+		"merchantId":         c.config.MerchantID,
+		"signedToken":        token,
+		"successRedirectUrl": c.config.SuccessURL,
+		"failureRedirectUrl": c.config.FailureURL,
+		"cancelRedirectUrl":  c.config.CancelURL,
+		"notifyUrl":          c.config.NotifyURL,
+	}
+	if payment.CustomerPhone != "" {
+		body["phoneNumber"] = payment.CustomerPhone
+	}
+
+	var resp struct {
+		URL string `json:"url"`
+	}
+	err = c.call(ctx, "/initiate-payment", token, body, &resp)
+	var rejected *apiError
+	if errors.As(err, &rejected) && rejected.status < 500 {
+		// Nothing was opened, so the payment can safely fail
+		return provider.Result{Status: provider.Declined, Reason: "rejected by SantimPay: " + rejected.message}, nil
+	}
+	if err != nil {
+		return provider.Result{}, err
+	}
+	if resp.URL == "" {
+		return provider.Result{}, errors.New("santimpay returned no checkout url")
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"txn_id":     txnID,
+	}).Info("SantimPay checkout opened")
+
+	return provider.Result{Status: provider.Pending, Reference: txnID, CheckoutURL: resp.URL}, nil
+}
+
+// Capture is not used: SantimPay captures when the customer pays
+func (c *client) Capture(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	return c.Query(ctx, payment)
+}
+
+// Query fetches the transaction status; callbacks only trigger this
+func (c *client) Query(ctx context.Context, payment *domain.Payment) (provider.Result, error) {
+	if payment.ProviderReference == "" {
+		return provider.Result{}, errors.New("payment has no SantimPay transaction")
+	}
+
+	token, err := c.sign(map[string]interface{}{
+		"id":        payment.ProviderReference,
+		"merId":     c.config.MerchantID,
+		"generated": time.Now().Unix(),
+	})
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	var resp struct {
+		Status string          `json:"status"`
+		Amount json.RawMessage `json:"amount"`
+	}
+	result := provider.Result{Status: provider.Pending, Reference: payment.ProviderReference}
+
+	err = c.call(ctx, "/fetch-transaction-status", token, map[string]interface{}{
+		"id":          payment.ProviderReference,
+		"merchantId":  c.config.MerchantID,
+		"signedToken": token,
+	}, &resp)
+	var unknown *apiError
+	if errors.As(err, &unknown) && unknown.status == http.StatusNotFound {
+		// SantimPay only knows the transaction once the customer starts paying
+		return result, nil
+	}
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	switch strings.ToUpper(resp.Status) {
+	case "COMPLETED", "SUCCESS":
+		amount, err := domain.ParseAmount(strings.Trim(string(resp.Amount), `"`))
+		if err != nil || amount != payment.Amount {
+			c.logger.WithFields(logrus.Fields{
+				"payment_id": payment.ID,
+				"amount":     string(resp.Amount),
+			}).Error("SantimPay transaction does not match the payment")
+			result.Status = provider.Declined
+			result.Reason = "amount paid at SantimPay does not match the payment"
+			return result, nil
+		}
+		result.Status = provider.Captured
+	case "FAILED", "CANCELLED", "CANCELED", "EXPIRED":
+		result.Status = provider.Declined
+		result.Reason = "santimpay transaction " + strings.ToLower(resp.Status)
+	}
+
+	return result, nil
+}
+
+func (c *client) call(ctx context.Context, path, token string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.BaseURL+path, strings.NewReader(string(payload)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("santimpay %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("santimpay %s failed: %w", path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		return &apiError{path: path, status: resp.StatusCode, message: failure.Message}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("santimpay %s returned invalid body: %w", path, err)
+	}
+	return nil
+}
+
+// apiError is a non-200 answer from SantimPay
+type apiError struct {
+	path    string
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("santimpay %s returned status %d: %s", e.path, e.status, e.message)
+}
+
+// StatusCode lets provider.Retryable tell a server error from a rejection
+func (e *apiError) StatusCode() int {
+	return e.status
+}
+
+// sign builds the ES256 token SantimPay expects with every request
+func (c *client) sign(claims map[string]interface{}) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","typ":"JWT"}`))
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS wants r and s as fixed-width big-endian halves
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyCallback checks the token SantimPay sends with each callback
+// against SantimPay's public key. An expired token is rejected.
+func VerifyCallback(publicKeyPEM, token string) error {
+	key, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("callback token is malformed")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if raw, err := base64.RawURLEncoding.DecodeString(parts[0]); err != nil || json.Unmarshal(raw, &header) != nil || header.Alg != "ES256" {
+		return errors.New("callback token is not ES256")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return errors.New("callback token signature is malformed")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		return errors.New("callback token signature is invalid")
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(raw, &claims) != nil {
+		return errors.New("callback token claims are malformed")
+	}
+	if claims.Exp != 0 && time.Now().Unix() > claims.Exp {
+		return errors.New("callback token has expired")
+	}
+
+	return nil
+}
+
+// PaymentID recovers the payment from a callback body's thirdPartyId
+func PaymentID(body []byte) (uuid.UUID, error) {
+	var event struct {
+		ThirdPartyID string `json:"thirdPartyId"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return uuid.Nil, err
+	}
+	if len(event.ThirdPartyID) < 36 {
+		return uuid.Nil, errors.New("thirdPartyId was not issued by this gateway")
+	}
+	return uuid.Parse(event.ThirdPartyID[:36])
+}
+
+func parsePrivateKey(pemKey string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("SantimPay private key is not PEM")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("SantimPay private key is not ECDSA")
+	}
+	return key, nil
+}
+
+func parsePublicKey(pemKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("SantimPay public key is not PEM")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("SantimPay public key is not ECDSA")
+	}
+	return key, nil
+}
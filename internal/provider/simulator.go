@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"payment-gateway/internal/domain"
+)
+
+type simulator struct {
+	successRate float64
+}
+
+// NewSimulator approves payments at random with the given success rate,
+// after a short delay, for development and for banks not yet integrated
+func NewSimulator(successRate float64) PaymentProvider {
+	return &simulator{successRate: successRate}
+}
+
+func (s *simulator) Authorize(ctx context.Context, payment *domain.Payment) (Result, error) {
+	select {
+	case <-time.After(time.Millisecond * time.Duration(rand.Intn(500)+100)):
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	if rand.Float64() < s.successRate {
+		return Result{Status: Captured}, nil
+	}
+	return Result{Status: Declined, Reason: "declined by bank"}, nil
+}
+
+// Capture is not reached: simulated payments are captured on authorization
+func (s *simulator) Capture(ctx context.Context, payment *domain.Payment) (Result, error) {
+	return Result{Status: Captured}, nil
+}
+
+// Query knows nothing of earlier simulated calls, so the outcome stays
+// pending; stuck payments are left to an operator
+func (s *simulator) Query(ctx context.Context, payment *domain.Payment) (Result, error) {
+	return Result{Status: Pending}, nil
+}
+
+// Refund always succeeds: a simulated payment moved no real money
+func (s *simulator) Refund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (Result, error) {
+	return Result{Status: Captured}, nil
+}
+
+// NewSimulatedRegistry simulates every bank, with success rates roughly
+// matching what each bank sees in production
+func NewSimulatedRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(NewSimulator(0.95), string(domain.BankCBE))
+	r.Register(NewSimulator(0.90), string(domain.BankAwash))
+	r.Register(NewSimulator(0.88), string(domain.BankDashen))
+	r.Register(NewSimulator(0.92), string(domain.BankAbyssinia))
+	r.SetFallback(NewSimulator(0.85))
+	return r
+}
@@ -0,0 +1,202 @@
+// Package qrcode encodes QR codes (ISO/IEC 18004) in byte mode at error
+// correction level M, which survives the smudges and creases of a code
+// printed at a shop counter, and renders them as PNG or SVG.
+package qrcode
+
+import (
+	"errors"
+)
+
+// ErrTooLong means the content does not fit the largest supported version
+var ErrTooLong = errors.New("qrcode: content too long")
+
+// Code is an encoded QR code: a square of dark and light modules
+type Code struct {
+	Size    int // Modules per side, without the quiet zone
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// blockLayout is a version's error correction at level M: every block has
+// ecc codewords, the first blocks1 hold data1 data codewords and the rest
+// (blocks2) one more
+type blockLayout struct {
+	ecc, blocks1, data1, blocks2 int
+}
+
+// Level M block layouts for versions 1-20, enough for a few hundred bytes
+var layouts = []blockLayout{
+	1: {10, 1, 16, 0}, 2: {16, 1, 28, 0}, 3: {26, 1, 44, 0}, 4: {18, 2, 32, 0},
+	5: {24, 2, 43, 0}, 6: {16, 4, 27, 0}, 7: {18, 4, 31, 0}, 8: {22, 2, 38, 2},
+	9: {22, 3, 36, 2}, 10: {26, 4, 43, 1}, 11: {30, 1, 50, 4}, 12: {22, 6, 36, 2},
+	13: {22, 8, 37, 1}, 14: {24, 4, 40, 5}, 15: {24, 5, 41, 5}, 16: {28, 7, 45, 3},
+	17: {28, 10, 46, 1}, 18: {26, 9, 43, 4}, 19: {26, 3, 44, 11}, 20: {26, 3, 41, 13},
+}
+
+var alignmentPositions = [][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+	11: {6, 30, 54}, 12: {6, 32, 58}, 13: {6, 34, 62}, 14: {6, 26, 46, 66},
+	15: {6, 26, 48, 70}, 16: {6, 26, 50, 74}, 17: {6, 30, 54, 78},
+	18: {6, 30, 56, 82}, 19: {6, 30, 58, 86}, 20: {6, 34, 62, 90},
+}
+
+func (l blockLayout) dataCodewords() int {
+	return l.blocks1*l.data1 + l.blocks2*(l.data1+1)
+}
+
+// Encode picks the smallest version that holds content
+func Encode(content []byte) (*Code, error) {
+	for version := 1; version < len(layouts); version++ {
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(content) <= 8*layouts[version].dataCodewords() {
+			return encode(content, version, countBits), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+func encode(content []byte, version, countBits int) *Code {
+	layout := layouts[version]
+
+	// Byte mode indicator, character count, data, then a terminator of up
+	// to four zero bits and alternating pad bytes
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(content), countBits)
+	for _, b := range content {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * layout.dataCodewords()
+	bits.append(0, min(4, capacity-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+	data := bits.bytes()
+	for pad := byte(0xEC); len(data) < layout.dataCodewords(); pad ^= 0xEC ^ 0x11 {
+		data = append(data, pad)
+	}
+
+	c := newCanvas(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(interleave(data, layout))
+
+	// Keep the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // XOR undoes it
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+
+	return &Code{Size: c.size, modules: c.modules}
+}
+
+// interleave splits data into blocks, appends each block's error
+// correction, and interleaves the blocks codeword by codeword
+func interleave(data []byte, layout blockLayout) []byte {
+	divisor := rsDivisor(layout.ecc)
+
+	var blocks, eccs [][]byte
+	for i, offset := 0, 0; i < layout.blocks1+layout.blocks2; i++ {
+		n := layout.data1
+		if i >= layout.blocks1 {
+			n++
+		}
+		block := data[offset : offset+n]
+		offset += n
+		blocks = append(blocks, block)
+		eccs = append(eccs, rsRemainder(block, divisor))
+	}
+
+	var out []byte
+	for i := 0; i <= layout.data1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ecc; i++ {
+		for _, ecc := range eccs {
+			out = append(out, ecc[i])
+		}
+	}
+	return out
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	out := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// Reed-Solomon over GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1
+
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor is the generator polynomial of the given degree, highest
+// coefficient (always 1) dropped
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
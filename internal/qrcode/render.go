@@ -0,0 +1,58 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone is the light border, in modules, that scanners need around
+// the symbol
+const QuietZone = 4
+
+// PNG renders the code with scale pixels per module
+func (c *Code) PNG(scale int) ([]byte, error) {
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := (y+QuietZone)*scale + dy
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+QuietZone)*scale+dx, row, 1)
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// SVG renders the code as a single path, scale pixels per module; it
+// scales without blurring for printing
+func (c *Code) SVG(scale int) []byte {
+	side := c.Size + 2*QuietZone
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		side*scale, side*scale, side, side)
+	fmt.Fprintf(&out, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&out, "M%d %dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	out.WriteString(`"/></svg>`)
+	return out.Bytes()
+}
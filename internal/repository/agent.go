@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type AgentRepository interface {
+	Create(ctx context.Context, agent *domain.Agent, tokenHash string) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Agent, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Agent, error)
+	List(ctx context.Context) ([]*domain.Agent, error)
+	// Update saves status and limits
+	Update(ctx context.Context, agent *domain.Agent) error
+	TopUp(ctx context.Context, agentID uuid.UUID, amount domain.Amount, reference, createdBy string, at time.Time) (*domain.AgentFloatEntry, error)
+	// CashIn checks the agent's status, limits (daily total counted from
+	// dayStart) and float, then debits the float and redeems the voucher in
+	// one transaction.
+	CashIn(ctx context.Context, agentID uuid.UUID, voucher *domain.CashVoucher, agentReference, branch string, dayStart, at time.Time) (*domain.AgentFloatEntry, error)
+	ListFloatEntries(ctx context.Context, agentID uuid.UUID, limit, offset int) ([]*domain.AgentFloatEntry, error)
+	// Settle writes one settlement line per agent with cash-ins in [from, to)
+	// not yet settled for businessDate and credits their commission.
+	Settle(ctx context.Context, businessDate string, from, to time.Time, commissionRate float64, at time.Time) ([]*domain.AgentSettlement, error)
+	ListSettlements(ctx context.Context, businessDate string) ([]*domain.AgentSettlement, error)
+}
+
+type agentRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewAgentRepository(db *pgxpool.Pool, logger *logrus.Logger) AgentRepository {
+	return &agentRepository{db: db, logger: logger}
+}
+
+const agentColumns = `id, code, name, phone, COALESCE(region, ''), status, float_balance, txn_limit, daily_limit, created_at, updated_at`
+
+func scanAgent(row pgx.Row) (*domain.Agent, error) {
+	var a domain.Agent
+	err := row.Scan(
+		&a.ID,
+		&a.Code,
+		&a.Name,
+		&a.Phone,
+		&a.Region,
+		&a.Status,
+		&a.FloatBalance,
+		&a.TxnLimit,
+		&a.DailyLimit,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *agentRepository) Create(ctx context.Context, a *domain.Agent, tokenHash string) error {
+	query := `
+		INSERT INTO agents (id, code, name, phone, region, status, float_balance, txn_limit, daily_limit, token_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, 0, $7, $8, $9, $10, $10)
+		ON CONFLICT (code) DO NOTHING
+		RETURNING id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		a.ID, a.Code, a.Name, a.Phone, a.Region, a.Status, a.TxnLimit, a.DailyLimit, tokenHash, a.CreatedAt,
+	).Scan(&a.ID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrAgentExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create agent")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *agentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Agent, error) {
+	return r.getOne(ctx, `SELECT `+agentColumns+` FROM agents WHERE id = $1`, id)
+}
+
+func (r *agentRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Agent, error) {
+	return r.getOne(ctx, `SELECT `+agentColumns+` FROM agents WHERE token_hash = $1`, tokenHash)
+}
+
+func (r *agentRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.Agent, error) {
+	agent, err := scanAgent(r.db.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAgentNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get agent")
+		return nil, domain.ErrDatabase
+	}
+	return agent, nil
+}
+
+func (r *agentRepository) List(ctx context.Context) ([]*domain.Agent, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+agentColumns+` FROM agents ORDER BY code`)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list agents")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var agents []*domain.Agent
+	for rows.Next() {
+		agent, err := scanAgent(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan agent")
+			return nil, domain.ErrDatabase
+		}
+		agents = append(agents, agent)
+	}
+
+	return agents, rows.Err()
+}
+
+func (r *agentRepository) Update(ctx context.Context, a *domain.Agent) error {
+	result, err := r.db.Exec(ctx,
+		"UPDATE agents SET status = $1, txn_limit = $2, daily_limit = $3 WHERE id = $4",
+		a.Status, a.TxnLimit, a.DailyLimit, a.ID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update agent")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrAgentNotFound
+	}
+
+	return nil
+}
+
+func (r *agentRepository) TopUp(ctx context.Context, agentID uuid.UUID, amount domain.Amount, reference, createdBy string, at time.Time) (*domain.AgentFloatEntry, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return nil, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	entry, err := r.postFloat(ctx, tx, agentID, domain.FloatTopUp, amount, nil, reference, createdBy, at)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(ctx, tx, r.logger, domain.AuditAgentFloatTopUp, domain.AuditEntityAgent, agentID,
+		map[string]any{"float_balance": entry.BalanceAfter - amount}, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit float top-up")
+		return nil, domain.ErrDatabase
+	}
+
+	return entry, nil
+}
+
+func (r *agentRepository) CashIn(ctx context.Context, agentID uuid.UUID, voucher *domain.CashVoucher, agentReference, branch string, dayStart, at time.Time) (*domain.AgentFloatEntry, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return nil, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the agent so concurrent cash-ins see each other's float and limits
+	agent, err := scanAgent(tx.QueryRow(ctx, `SELECT `+agentColumns+` FROM agents WHERE id = $1 FOR UPDATE`, agentID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAgentNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to lock agent")
+		return nil, domain.ErrDatabase
+	}
+
+	var collectedToday domain.Amount
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(-SUM(amount), 0)
+		FROM agent_float_entries
+		WHERE agent_id = $1 AND kind = $2 AND created_at >= $3
+	`, agentID, domain.FloatCashIn, dayStart).Scan(&collectedToday)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to sum agent cash-ins")
+		return nil, domain.ErrDatabase
+	}
+
+	switch {
+	case agent.Status != domain.AgentActive:
+		return nil, domain.ErrAgentSuspended
+	case voucher.Amount > agent.TxnLimit:
+		return nil, domain.ErrAgentTxnLimit
+	case collectedToday+voucher.Amount > agent.DailyLimit:
+		return nil, domain.ErrAgentDailyLimit
+	case voucher.Amount > agent.FloatBalance:
+		return nil, domain.ErrAgentFloatInsufficient
+	}
+
+	if err := redeemVoucher(ctx, tx, r.logger, voucher, agent.Code, agentReference, branch, at); err != nil {
+		return nil, err
+	}
+
+	entry, err := r.postFloat(ctx, tx, agentID, domain.FloatCashIn, -voucher.Amount, &voucher.PaymentID, agentReference, agent.Code, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit agent cash-in")
+		return nil, domain.ErrDatabase
+	}
+
+	return entry, nil
+}
+
+// postFloat moves the float balance by amount and appends the ledger entry
+func (r *agentRepository) postFloat(ctx context.Context, tx pgx.Tx, agentID uuid.UUID, kind domain.FloatEntryKind, amount domain.Amount, paymentID *uuid.UUID, reference, createdBy string, at time.Time) (*domain.AgentFloatEntry, error) {
+	entry := &domain.AgentFloatEntry{
+		ID:        uuid.New(),
+		AgentID:   agentID,
+		Kind:      kind,
+		Amount:    amount,
+		PaymentID: paymentID,
+		Reference: reference,
+		CreatedBy: createdBy,
+		CreatedAt: at,
+	}
+
+	err := tx.QueryRow(ctx,
+		"UPDATE agents SET float_balance = float_balance + $1 WHERE id = $2 RETURNING float_balance",
+		amount, agentID,
+	).Scan(&entry.BalanceAfter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAgentNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update agent float")
+		return nil, domain.ErrDatabase
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO agent_float_entries (id, agent_id, kind, amount, balance_after, payment_id, reference, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)
+	`, entry.ID, agentID, kind, amount, entry.BalanceAfter, paymentID, reference, createdBy, at)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record agent float entry")
+		return nil, domain.ErrDatabase
+	}
+
+	return entry, nil
+}
+
+func (r *agentRepository) ListFloatEntries(ctx context.Context, agentID uuid.UUID, limit, offset int) ([]*domain.AgentFloatEntry, error) {
+	query := `
+		SELECT id, agent_id, kind, amount, balance_after, payment_id, COALESCE(reference, ''), created_by, created_at
+		FROM agent_float_entries
+		WHERE agent_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, agentID, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list agent float entries")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var entries []*domain.AgentFloatEntry
+	for rows.Next() {
+		var e domain.AgentFloatEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.AgentID,
+			&e.Kind,
+			&e.Amount,
+			&e.BalanceAfter,
+			&e.PaymentID,
+			&e.Reference,
+			&e.CreatedBy,
+			&e.CreatedAt,
+		); err != nil {
+			r.logger.WithError(err).Error("Failed to scan agent float entry")
+			return nil, domain.ErrDatabase
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *agentRepository) Settle(ctx context.Context, businessDate string, from, to time.Time, commissionRate float64, at time.Time) ([]*domain.AgentSettlement, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return nil, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT e.agent_id, a.code, COUNT(*), -SUM(e.amount)
+		FROM agent_float_entries e
+		JOIN agents a ON a.id = e.agent_id
+		WHERE e.kind = $1 AND e.created_at >= $2 AND e.created_at < $3
+		  AND NOT EXISTS (
+		      SELECT 1 FROM agent_settlements s
+		      WHERE s.agent_id = e.agent_id AND s.business_date = $4::date
+		  )
+		GROUP BY e.agent_id, a.code
+		ORDER BY a.code
+	`, domain.FloatCashIn, from, to, businessDate)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to aggregate agent cash-ins")
+		return nil, domain.ErrDatabase
+	}
+
+	var settlements []*domain.AgentSettlement
+	for rows.Next() {
+		s := &domain.AgentSettlement{
+			ID:           uuid.New(),
+			BusinessDate: businessDate,
+			CreatedAt:    at,
+		}
+		if err := rows.Scan(&s.AgentID, &s.AgentCode, &s.CashInCount, &s.CashInTotal); err != nil {
+			rows.Close()
+			r.logger.WithError(err).Error("Failed to scan agent cash-in totals")
+			return nil, domain.ErrDatabase
+		}
+		s.Commission = s.CashInTotal.Mul(commissionRate)
+		settlements = append(settlements, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.WithError(err).Error("Failed to aggregate agent cash-ins")
+		return nil, domain.ErrDatabase
+	}
+
+	for _, s := range settlements {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO agent_settlements (id, agent_id, business_date, cash_in_count, cash_in_total, commission, created_at)
+			VALUES ($1, $2, $3::date, $4, $5, $6, $7)
+		`, s.ID, s.AgentID, businessDate, s.CashInCount, s.CashInTotal, s.Commission, at)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to record agent settlement")
+			return nil, domain.ErrDatabase
+		}
+
+		if s.Commission > 0 {
+			if _, err := r.postFloat(ctx, tx, s.AgentID, domain.FloatCommission, s.Commission, nil, "SETTLEMENT-"+businessDate, "settlement-run", at); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit agent settlement")
+		return nil, domain.ErrDatabase
+	}
+
+	return settlements, nil
+}
+
+func (r *agentRepository) ListSettlements(ctx context.Context, businessDate string) ([]*domain.AgentSettlement, error) {
+	query := `
+		SELECT s.id, s.agent_id, a.code, to_char(s.business_date, 'YYYY-MM-DD'), s.cash_in_count, s.cash_in_total, s.commission, s.created_at
+		FROM agent_settlements s
+		JOIN agents a ON a.id = s.agent_id
+		WHERE s.business_date = $1::date
+		ORDER BY a.code
+	`
+
+	rows, err := r.db.Query(ctx, query, businessDate)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list agent settlements")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var settlements []*domain.AgentSettlement
+	for rows.Next() {
+		var s domain.AgentSettlement
+		if err := rows.Scan(
+			&s.ID,
+			&s.AgentID,
+			&s.AgentCode,
+			&s.BusinessDate,
+			&s.CashInCount,
+			&s.CashInTotal,
+			&s.Commission,
+			&s.CreatedAt,
+		); err != nil {
+			r.logger.WithError(err).Error("Failed to scan agent settlement")
+			return nil, domain.ErrDatabase
+		}
+		settlements = append(settlements, &s)
+	}
+
+	return settlements, rows.Err()
+}
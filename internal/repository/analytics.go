@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// etbValue is a payment's ETB value: the settled amount where one is
+// recorded, otherwise the amount at the rates passed as $1 (currencies) and
+// $2 (rates) and joined as fx, rounded to the santim as amount_etb is
+const etbValue = `CASE
+			WHEN p.currency = 'ETB' THEN p.amount
+			WHEN p.amount_etb IS NOT NULL THEN p.amount_etb
+			ELSE ROUND(p.amount * COALESCE(fx.rate, 0)::numeric, 2)
+		END`
+
+const fxJoin = `LEFT JOIN unnest($1::text[], $2::float8[]) AS fx(currency, rate) ON fx.currency = p.currency`
+
+// AnalyticsRepository aggregates successful payments for merchant BI. A nil
+// merchantID aggregates across every merchant.
+type AnalyticsRepository interface {
+	TopCustomers(ctx context.Context, from, to time.Time, merchantID *uuid.UUID, rates map[domain.Currency]float64, limit int) ([]*domain.TopCustomer, error)
+	// RepeatCustomers counts customers with a successful payment in the
+	// range, and those with more than one
+	RepeatCustomers(ctx context.Context, from, to time.Time, merchantID *uuid.UUID) (customers, repeat int, err error)
+	// BankMix buckets by Ethiopian business day truncated to interval
+	BankMix(ctx context.Context, from, to time.Time, merchantID *uuid.UUID, interval domain.AnalyticsInterval, rates map[domain.Currency]float64) ([]*domain.BankMixPoint, error)
+}
+
+type analyticsRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewAnalyticsRepository(db *pgxpool.Pool, logger *logrus.Logger) AnalyticsRepository {
+	return &analyticsRepository{db: db, logger: logger}
+}
+
+func (r *analyticsRepository) TopCustomers(ctx context.Context, from, to time.Time, merchantID *uuid.UUID, rates map[domain.Currency]float64, limit int) ([]*domain.TopCustomer, error) {
+	query := `
+		SELECT p.customer_phone,
+			(ARRAY_AGG(p.customer_name ORDER BY p.created_at DESC))[1],
+			COUNT(*),
+			COALESCE(SUM(` + etbValue + `), 0) AS volume,
+			MAX(p.created_at)
+		FROM payments p
+		` + fxJoin + `
+		WHERE p.status = 'SUCCESS'
+		  AND p.customer_phone IS NOT NULL AND p.customer_phone <> ''
+		  AND p.created_at >= $3 AND p.created_at < $4
+		  AND ($6::uuid IS NULL OR p.merchant_id = $6)
+		GROUP BY p.customer_phone
+		ORDER BY volume DESC
+		LIMIT $5
+	`
+
+	currencies, values := rateArrays(rates)
+	rows, err := r.db.Query(ctx, query, currencies, values, from, to, limit, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list top customers")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var customers []*domain.TopCustomer
+	for rows.Next() {
+		var c domain.TopCustomer
+		if err := rows.Scan(&c.CustomerPhone, &c.CustomerName, &c.PaymentCount, &c.VolumeETB, &c.LastPaymentAt); err != nil {
+			r.logger.WithError(err).Error("Failed to scan top customer")
+			return nil, domain.ErrDatabase
+		}
+		customers = append(customers, &c)
+	}
+
+	return customers, rows.Err()
+}
+
+func (r *analyticsRepository) RepeatCustomers(ctx context.Context, from, to time.Time, merchantID *uuid.UUID) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE payments > 1)
+		FROM (
+			SELECT customer_phone, COUNT(*) AS payments
+			FROM payments
+			WHERE status = 'SUCCESS'
+			  AND customer_phone IS NOT NULL AND customer_phone <> ''
+			  AND created_at >= $1 AND created_at < $2
+			  AND ($3::uuid IS NULL OR merchant_id = $3)
+			GROUP BY customer_phone
+		) customers
+	`
+
+	var customers, repeat int
+	if err := r.db.QueryRow(ctx, query, from, to, merchantID).Scan(&customers, &repeat); err != nil {
+		r.logger.WithError(err).Error("Failed to count repeat customers")
+		return 0, 0, domain.ErrDatabase
+	}
+
+	return customers, repeat, nil
+}
+
+func (r *analyticsRepository) BankMix(ctx context.Context, from, to time.Time, merchantID *uuid.UUID, interval domain.AnalyticsInterval, rates map[domain.Currency]float64) ([]*domain.BankMixPoint, error) {
+	query := `
+		SELECT to_char(period, 'YYYY-MM-DD'), bank_code, payments, volume,
+			COALESCE(volume / NULLIF(SUM(volume) OVER (PARTITION BY period), 0), 0)
+		FROM (
+			SELECT date_trunc($5::text, (p.created_at AT TIME ZONE 'UTC') + INTERVAL '3 hours') AS period,
+				p.bank_code,
+				COUNT(*) AS payments,
+				COALESCE(SUM(` + etbValue + `), 0) AS volume
+			FROM payments p
+			` + fxJoin + `
+			WHERE p.status = 'SUCCESS'
+			  AND p.created_at >= $3 AND p.created_at < $4
+			  AND ($6::uuid IS NULL OR p.merchant_id = $6)
+			GROUP BY 1, 2
+		) mix
+		ORDER BY period, volume DESC
+	`
+
+	currencies, values := rateArrays(rates)
+	rows, err := r.db.Query(ctx, query, currencies, values, from, to, string(interval), merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to compute bank mix")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var points []*domain.BankMixPoint
+	for rows.Next() {
+		var p domain.BankMixPoint
+		if err := rows.Scan(&p.Period, &p.BankCode, &p.PaymentCount, &p.VolumeETB, &p.Share); err != nil {
+			r.logger.WithError(err).Error("Failed to scan bank mix")
+			return nil, domain.ErrDatabase
+		}
+		points = append(points, &p)
+	}
+
+	return points, rows.Err()
+}
+
+// rateArrays splits FX rates into parallel arrays for unnest
+func rateArrays(rates map[domain.Currency]float64) ([]string, []float64) {
+	currencies := make([]string, 0, len(rates))
+	values := make([]float64, 0, len(rates))
+	for currency, rate := range rates {
+		currencies = append(currencies, string(currency))
+		values = append(values, rate)
+	}
+	return currencies, values
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyRepository stores merchant API keys by the hash of the key
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey, keyHash string) error
+	GetByID(ctx context.Context, merchantID, id uuid.UUID) (*domain.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	ListByMerchant(ctx context.Context, merchantID uuid.UUID) ([]*domain.APIKey, error)
+	// Rotate saves the replacement key and makes the old one expire at
+	// expiresAt (or keeps an earlier expiry), in one transaction. It
+	// returns ErrAPIKeyRevoked when the old key is revoked or expired.
+	Rotate(ctx context.Context, oldID uuid.UUID, key *domain.APIKey, keyHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, merchantID, id uuid.UUID, at time.Time) error
+	// Touch records use of the key, at most once a minute
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool, logger *logrus.Logger) APIKeyRepository {
+	return &apiKeyRepository{db: db, logger: logger}
+}
+
+const apiKeyColumns = `id, merchant_id, name, prefix, created_by, created_at, last_used_at, expires_at, revoked_at, scopes`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var scopes []string
+	err := row.Scan(
+		&k.ID,
+		&k.MerchantID,
+		&k.Name,
+		&k.Prefix,
+		&k.CreatedBy,
+		&k.CreatedAt,
+		&k.LastUsedAt,
+		&k.ExpiresAt,
+		&k.RevokedAt,
+		&scopes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		k.Scopes = append(k.Scopes, domain.APIKeyScope(scope))
+	}
+	return &k, nil
+}
+
+func scopeStrings(scopes []domain.APIKeyScope) []string {
+	out := make([]string, len(scopes))
+	for i, scope := range scopes {
+		out[i] = string(scope)
+	}
+	return out
+}
+
+const insertAPIKeyQuery = `
+	INSERT INTO api_keys (id, merchant_id, name, prefix, key_hash, created_by, created_at, scopes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey, keyHash string) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, insertAPIKeyQuery, key.ID, key.MerchantID, key.Name, key.Prefix, keyHash, key.CreatedBy, key.CreatedAt, scopeStrings(key.Scopes))
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to create API key")
+			return domain.ErrDatabase
+		}
+		return recordAudit(ctx, tx, r.logger, domain.AuditAPIKeyIssued, domain.AuditEntityAPIKey, key.ID, nil, key)
+	})
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, merchantID, id uuid.UUID) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND merchant_id = $2`, id, merchantID)
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash)
+}
+
+func (r *apiKeyRepository) getOne(ctx context.Context, query string, args ...interface{}) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get API key")
+		return nil, domain.ErrDatabase
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) ListByMerchant(ctx context.Context, merchantID uuid.UUID) ([]*domain.APIKey, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE merchant_id = $1 ORDER BY created_at`, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list API keys")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan API key")
+			return nil, domain.ErrDatabase
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Rotate(ctx context.Context, oldID uuid.UUID, key *domain.APIKey, keyHash string, expiresAt time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE api_keys SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1 AND merchant_id = $3 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $4)
+	`, oldID, expiresAt, key.MerchantID, key.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to expire rotated API key")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrAPIKeyRevoked
+	}
+
+	_, err = tx.Exec(ctx, insertAPIKeyQuery, key.ID, key.MerchantID, key.Name, key.Prefix, keyHash, key.CreatedBy, key.CreatedAt, scopeStrings(key.Scopes))
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create API key")
+		return domain.ErrDatabase
+	}
+
+	err = recordAudit(ctx, tx, r.logger, domain.AuditAPIKeyRotated, domain.AuditEntityAPIKey, oldID,
+		map[string]any{"expires_at": nil}, map[string]any{"expires_at": expiresAt, "replaced_by": key.ID})
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, r.logger, domain.AuditAPIKeyIssued, domain.AuditEntityAPIKey, key.ID, nil, key); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, merchantID, id uuid.UUID, at time.Time) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		var revokedAt time.Time
+		err := tx.QueryRow(ctx, `
+			UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $3)
+			WHERE id = $1 AND merchant_id = $2
+			RETURNING revoked_at
+		`, id, merchantID, at).Scan(&revokedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrAPIKeyNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to revoke API key")
+			return domain.ErrDatabase
+		}
+
+		if !revokedAt.Equal(at) {
+			// Already revoked; nothing changed
+			return nil
+		}
+		return recordAudit(ctx, tx, r.logger, domain.AuditAPIKeyRevoked, domain.AuditEntityAPIKey, id,
+			nil, map[string]any{"revoked_at": revokedAt})
+	})
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute')
+	`, id, at)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record API key use")
+		return domain.ErrDatabase
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *domain.Attachment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error)
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Attachment, error)
+}
+
+type attachmentRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewAttachmentRepository(db *pgxpool.Pool, logger *logrus.Logger) AttachmentRepository {
+	return &attachmentRepository{db: db, logger: logger}
+}
+
+const attachmentColumns = `id, payment_id, kind, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at`
+
+func (r *attachmentRepository) Create(ctx context.Context, a *domain.Attachment) error {
+	query := `
+		INSERT INTO payment_attachments (` + attachmentColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		a.ID, a.PaymentID, a.Kind, a.FileName, a.ContentType,
+		a.Size, a.Checksum, a.StorageKey, a.UploadedBy, a.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment attachment")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *attachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM payment_attachments WHERE id = $1`
+
+	attachment, err := scanAttachment(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get payment attachment")
+		return nil, domain.ErrDatabase
+	}
+
+	return attachment, nil
+}
+
+func (r *attachmentRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM payment_attachments WHERE payment_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payment attachments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var attachments []*domain.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment attachment")
+			return nil, domain.ErrDatabase
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func scanAttachment(row pgx.Row) (*domain.Attachment, error) {
+	var a domain.Attachment
+	err := row.Scan(
+		&a.ID, &a.PaymentID, &a.Kind, &a.FileName, &a.ContentType,
+		&a.Size, &a.Checksum, &a.StorageKey, &a.UploadedBy, &a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentAttemptRepository interface {
+	// Queue records a manual retry the worker has not picked up yet
+	Queue(ctx context.Context, attempt *domain.PaymentAttempt) error
+	// RecordResult finishes the queued attempt, or records a new AUTO attempt if none is queued
+	RecordResult(ctx context.Context, paymentID uuid.UUID, bankCode string, status domain.AttemptStatus, failureReason string) error
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentAttempt, error)
+}
+
+type paymentAttemptRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPaymentAttemptRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentAttemptRepository {
+	return &paymentAttemptRepository{db: db, logger: logger}
+}
+
+func (r *paymentAttemptRepository) Queue(ctx context.Context, attempt *domain.PaymentAttempt) error {
+	query := `
+		INSERT INTO payment_attempts (id, payment_id, attempt_number, bank_code, trigger, status, created_at)
+		SELECT $1, $2, COALESCE(MAX(attempt_number), 0) + 1, NULLIF($3, ''), $4, $5, $6
+		FROM payment_attempts
+		WHERE payment_id = $2
+		RETURNING attempt_number
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		attempt.ID,
+		attempt.PaymentID,
+		attempt.BankCode,
+		attempt.Trigger,
+		attempt.Status,
+		attempt.CreatedAt,
+	).Scan(&attempt.AttemptNumber)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to queue payment attempt")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentAttemptRepository) RecordResult(ctx context.Context, paymentID uuid.UUID, bankCode string, status domain.AttemptStatus, failureReason string) error {
+	now := time.Now().UTC()
+
+	result, err := r.db.Exec(ctx, `
+		UPDATE payment_attempts
+		SET status = $1, failure_reason = NULLIF($2, ''), finished_at = $3
+		WHERE payment_id = $4 AND status = 'QUEUED'
+	`, status, failureReason, now, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to finish payment attempt")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO payment_attempts (id, payment_id, attempt_number, bank_code, trigger, status, failure_reason, created_at, finished_at)
+		SELECT $1, $2, COALESCE(MAX(attempt_number), 0) + 1, NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $7
+		FROM payment_attempts
+		WHERE payment_id = $2
+	`, uuid.New(), paymentID, bankCode, domain.AttemptTriggerAuto, status, failureReason, now)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record payment attempt")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentAttemptRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentAttempt, error) {
+	query := `
+		SELECT id, payment_id, attempt_number, COALESCE(bank_code, ''), trigger, status, COALESCE(failure_reason, ''), created_at, finished_at
+		FROM payment_attempts
+		WHERE payment_id = $1
+		ORDER BY attempt_number
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payment attempts")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var attempts []*domain.PaymentAttempt
+	for rows.Next() {
+		var attempt domain.PaymentAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.PaymentID,
+			&attempt.AttemptNumber,
+			&attempt.BankCode,
+			&attempt.Trigger,
+			&attempt.Status,
+			&attempt.FailureReason,
+			&attempt.CreatedAt,
+			&attempt.FinishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
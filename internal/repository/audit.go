@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditRepository reads the audit log. Entries are written by the
+// repositories that make the change, in the same transaction: payment and
+// refund changes, and back-office changes to merchants, API keys, agent
+// float, disputes, FX rates and webhook endpoints.
+type AuditRepository interface {
+	// List returns matching events, newest first
+	List(ctx context.Context, filter domain.AuditFilter, limit, offset int) ([]*domain.AuditEvent, error)
+	Count(ctx context.Context, filter domain.AuditFilter) (int, error)
+}
+
+type auditRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewAuditRepository(db *pgxpool.Pool, logger *logrus.Logger) AuditRepository {
+	return &auditRepository{db: db, logger: logger}
+}
+
+// statusState is the before or after state of a status change
+func statusState(status any) map[string]any {
+	return map[string]any{"status": status}
+}
+
+// recordAudit appends an audit event for a change made in tx, against the
+// actor on ctx. before and after are marshaled to JSON; nil leaves them empty.
+func recordAudit(ctx context.Context, tx pgx.Tx, logger *logrus.Logger, action domain.AuditAction, entityType string, entityID uuid.UUID, before, after any) error {
+	actor := domain.ActorFrom(ctx)
+
+	beforeJSON, err := auditState(before)
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode audit state")
+		return domain.ErrDatabase
+	}
+	afterJSON, err := auditState(after)
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode audit state")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_events (id, action, entity_type, entity_id, actor_type, actor, ip, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10)
+	`,
+		uuid.New(),
+		action,
+		entityType,
+		entityID,
+		actor.Type,
+		actor.ID,
+		actor.IP,
+		beforeJSON,
+		afterJSON,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		logger.WithError(err).WithField("action", action).Error("Failed to record audit event")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+// audited runs fn in a transaction, a savepoint of the caller's when ctx
+// carries one, so a change and the audit events fn records commit together
+func audited(ctx context.Context, db *pgxpool.Pool, logger *logrus.Logger, fn func(tx pgx.Tx) error) error {
+	tx, err := begin(ctx, db)
+	if err != nil {
+		logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+	return nil
+}
+
+func auditState(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+const auditFilterClause = `
+		WHERE ($1::text = '' OR action = $1)
+		  AND ($2::text = '' OR entity_type = $2)
+		  AND ($3::uuid IS NULL OR entity_id = $3)
+		  AND ($4::text = '' OR actor_type = $4)
+		  AND ($5::text = '' OR actor = $5)
+		  AND ($6::text = '' OR created_at >= $6::timestamptz)
+		  AND ($7::text = '' OR created_at < $7::timestamptz)
+`
+
+func auditFilterArgs(filter domain.AuditFilter) []any {
+	return []any{filter.Action, filter.EntityType, filter.EntityID, filter.ActorType, filter.Actor, filter.From, filter.To}
+}
+
+func (r *auditRepository) List(ctx context.Context, filter domain.AuditFilter, limit, offset int) ([]*domain.AuditEvent, error) {
+	query := `
+		SELECT id, action, entity_type, entity_id, actor_type, COALESCE(actor, ''), COALESCE(ip, ''), before, after, created_at
+		FROM audit_events` + auditFilterClause + `
+		ORDER BY created_at DESC
+		LIMIT $8 OFFSET $9
+	`
+
+	rows, err := r.db.Query(ctx, query, append(auditFilterArgs(filter), limit, offset)...)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list audit events")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var events []*domain.AuditEvent
+	for rows.Next() {
+		var e domain.AuditEvent
+		err := rows.Scan(&e.ID, &e.Action, &e.EntityType, &e.EntityID, &e.ActorType, &e.Actor, &e.IP, &e.Before, &e.After, &e.CreatedAt)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan audit event")
+			return nil, domain.ErrDatabase
+		}
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *auditRepository) Count(ctx context.Context, filter domain.AuditFilter) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events"+auditFilterClause, auditFilterArgs(filter)...).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count audit events")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// CustomerRepository keeps KYC customer profiles
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *domain.Customer) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Customer, error)
+	// List returns customers oldest first, in one status when status is set
+	List(ctx context.Context, status domain.CustomerVerificationStatus, merchantID *uuid.UUID, limit, offset int) ([]*domain.Customer, error)
+	// Verify records an operator's verification decision
+	Verify(ctx context.Context, id uuid.UUID, status domain.CustomerVerificationStatus, note, operator string, at time.Time) (*domain.Customer, error)
+	// Match finds the merchant's customer a payment's details belong to: by
+	// national ID, else by phone unless the national IDs differ
+	Match(ctx context.Context, merchantID *uuid.UUID, phone, nationalID string) (*domain.Customer, error)
+	// PaymentSummary aggregates the customer's payments
+	PaymentSummary(ctx context.Context, id uuid.UUID, rates map[domain.Currency]float64) (domain.CustomerPaymentSummary, error)
+}
+
+type customerRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewCustomerRepository(db *pgxpool.Pool, logger *logrus.Logger) CustomerRepository {
+	return &customerRepository{db: db, logger: logger}
+}
+
+const customerColumns = `id, full_name, COALESCE(national_id, ''), COALESCE(passport_number, ''), phone, COALESCE(email, ''),
+	verification_status, COALESCE(verification_note, ''), COALESCE(verified_by, ''), verified_at, merchant_id, created_at, updated_at`
+
+// Create also links the merchant's earlier payments with the customer's
+// phone or national ID, so their history starts complete
+func (r *customerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	// Any of the phone and document indexes may conflict
+	err = tx.QueryRow(ctx, `
+		INSERT INTO customers (id, full_name, national_id, passport_number, phone, email, verification_status, merchant_id, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9, $10)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`,
+		customer.ID,
+		customer.FullName,
+		customer.NationalID,
+		customer.PassportNumber,
+		customer.Phone,
+		customer.Email,
+		customer.VerificationStatus,
+		customer.MerchantID,
+		customer.CreatedAt,
+		customer.UpdatedAt,
+	).Scan(&customer.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrCustomerAlreadyExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create customer")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payments
+		SET customer_id = $1
+		WHERE customer_id IS NULL
+		  AND merchant_id IS NOT DISTINCT FROM $2
+		  AND (customer_phone = $3 OR (customer_national_id = $4 AND $4 <> ''))
+	`, customer.ID, customer.MerchantID, customer.Phone, customer.NationalID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to link customer payments")
+		return domain.ErrDatabase
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit customer")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *customerRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Customer, error) {
+	customer, err := scanCustomer(r.db.QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get customer")
+		return nil, domain.ErrDatabase
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) List(ctx context.Context, status domain.CustomerVerificationStatus, merchantID *uuid.UUID, limit, offset int) ([]*domain.Customer, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+customerColumns+`
+		FROM customers
+		WHERE ($1 = '' OR verification_status = $1)
+		  AND ($2::uuid IS NULL OR merchant_id = $2)
+		ORDER BY created_at
+		LIMIT $3 OFFSET $4
+	`, string(status), merchantID, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list customers")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var customers []*domain.Customer
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan customer")
+			return nil, domain.ErrDatabase
+		}
+		customers = append(customers, customer)
+	}
+
+	return customers, rows.Err()
+}
+
+func (r *customerRepository) Verify(ctx context.Context, id uuid.UUID, status domain.CustomerVerificationStatus, note, operator string, at time.Time) (*domain.Customer, error) {
+	var customer *domain.Customer
+	err := audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		before, err := scanCustomer(tx.QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1 FOR UPDATE", id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrCustomerNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to lock customer")
+			return domain.ErrDatabase
+		}
+
+		customer, err = scanCustomer(tx.QueryRow(ctx, `
+			UPDATE customers
+			SET verification_status = $1, verification_note = $2, verified_by = $3, verified_at = $4, updated_at = $4
+			WHERE id = $5
+			RETURNING `+customerColumns,
+			status, note, operator, at, id,
+		))
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to verify customer")
+			return domain.ErrDatabase
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditCustomerVerified, domain.AuditEntityCustomer, id,
+			statusState(before.VerificationStatus), statusState(customer.VerificationStatus))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) Match(ctx context.Context, merchantID *uuid.UUID, phone, nationalID string) (*domain.Customer, error) {
+	customer, err := scanCustomer(conn(ctx, r.db).QueryRow(ctx, `
+		SELECT `+customerColumns+`
+		FROM customers
+		WHERE merchant_id IS NOT DISTINCT FROM $1
+		  AND ((national_id = $3 AND $3 <> '')
+		    OR (phone = $2 AND ($3 = '' OR national_id IS NULL OR national_id = $3)))
+		ORDER BY national_id = $3 DESC NULLS LAST
+		LIMIT 1
+	`, merchantID, phone, nationalID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to match customer")
+		return nil, domain.ErrDatabase
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) PaymentSummary(ctx context.Context, id uuid.UUID, rates map[domain.Currency]float64) (domain.CustomerPaymentSummary, error) {
+	summary := domain.CustomerPaymentSummary{
+		Paid:     make(map[domain.Currency]domain.Amount),
+		Refunded: make(map[domain.Currency]domain.Amount),
+	}
+
+	currencies, values := rateArrays(rates)
+	rows, err := r.db.Query(ctx, `
+		SELECT p.currency,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE p.status = 'SUCCESS'),
+			COUNT(*) FILTER (WHERE p.status IN ('FAILED', 'CANCELLED', 'EXPIRED')),
+			COALESCE(SUM(p.amount) FILTER (WHERE p.status = 'SUCCESS'), 0),
+			COALESCE(SUM(`+etbValue+`) FILTER (WHERE p.status = 'SUCCESS'), 0),
+			COALESCE(SUM(p.refunded_amount), 0),
+			MIN(p.created_at),
+			MAX(p.created_at)
+		FROM payments p
+		`+fxJoin+`
+		WHERE p.customer_id = $3
+		GROUP BY p.currency
+	`, currencies, values, id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to summarize customer payments")
+		return summary, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			currency                domain.Currency
+			count, success, failed  int
+			paid, paidETB, refunded domain.Amount
+			first, last             time.Time
+		)
+		if err := rows.Scan(&currency, &count, &success, &failed, &paid, &paidETB, &refunded, &first, &last); err != nil {
+			r.logger.WithError(err).Error("Failed to scan customer payment summary")
+			return summary, domain.ErrDatabase
+		}
+
+		summary.Payments += count
+		summary.Successful += success
+		summary.Failed += failed
+		summary.InProgress += count - success - failed
+		summary.PaidETB += paidETB
+		if paid > 0 {
+			summary.Paid[currency] = paid
+		}
+		if refunded > 0 {
+			summary.Refunded[currency] = refunded
+		}
+		if summary.FirstPaymentAt == nil || first.Before(*summary.FirstPaymentAt) {
+			summary.FirstPaymentAt = &first
+		}
+		if summary.LastPaymentAt == nil || last.After(*summary.LastPaymentAt) {
+			summary.LastPaymentAt = &last
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithError(err).Error("Failed to summarize customer payments")
+		return summary, domain.ErrDatabase
+	}
+
+	if summary.Successful > 0 {
+		summary.AveragePaidETB = domain.Amount(math.Round(float64(summary.PaidETB) / float64(summary.Successful)))
+	}
+
+	return summary, nil
+}
+
+func scanCustomer(row pgx.Row) (*domain.Customer, error) {
+	var customer domain.Customer
+	err := row.Scan(
+		&customer.ID,
+		&customer.FullName,
+		&customer.NationalID,
+		&customer.PassportNumber,
+		&customer.Phone,
+		&customer.Email,
+		&customer.VerificationStatus,
+		&customer.VerificationNote,
+		&customer.VerifiedBy,
+		&customer.VerifiedAt,
+		&customer.MerchantID,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
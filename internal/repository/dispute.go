@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type DisputeRepository interface {
+	// Create fails with ErrDisputeExists while the payment has an
+	// undecided dispute
+	Create(ctx context.Context, dispute *domain.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispute, error)
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Dispute, error)
+	// SubmitEvidence moves an OPEN dispute to EVIDENCE_SUBMITTED
+	SubmitEvidence(ctx context.Context, id uuid.UUID, evidence string) (bool, error)
+	// Resolve decides a dispute that is not decided yet
+	Resolve(ctx context.Context, id uuid.UUID, outcome domain.DisputeStatus, resolution, operator string, at time.Time) (bool, error)
+}
+
+type disputeRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewDisputeRepository(db *pgxpool.Pool, logger *logrus.Logger) DisputeRepository {
+	return &disputeRepository{db: db, logger: logger}
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *domain.Dispute) error {
+	query := `
+		INSERT INTO disputes (id, payment_id, amount, currency, reason, bank_reference, status, opened_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		ON CONFLICT (payment_id) WHERE status IN ('OPEN', 'EVIDENCE_SUBMITTED') DO NOTHING
+		RETURNING id
+	`
+
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			dispute.ID,
+			dispute.PaymentID,
+			dispute.Amount,
+			dispute.Currency,
+			dispute.Reason,
+			dispute.BankReference,
+			dispute.Status,
+			dispute.OpenedBy,
+			dispute.CreatedAt,
+			dispute.UpdatedAt,
+		).Scan(&dispute.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrDisputeExists
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to create dispute")
+			return domain.ErrDatabase
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditDisputeOpened, domain.AuditEntityDispute, dispute.ID, nil, dispute)
+	})
+}
+
+const disputeColumns = `id, payment_id, amount, currency, reason, COALESCE(bank_reference, ''), status, COALESCE(evidence, ''), COALESCE(resolution, ''), opened_by, COALESCE(resolved_by, ''), created_at, updated_at, resolved_at`
+
+func scanDispute(row pgx.Row) (*domain.Dispute, error) {
+	var dispute domain.Dispute
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.PaymentID,
+		&dispute.Amount,
+		&dispute.Currency,
+		&dispute.Reason,
+		&dispute.BankReference,
+		&dispute.Status,
+		&dispute.Evidence,
+		&dispute.Resolution,
+		&dispute.OpenedBy,
+		&dispute.ResolvedBy,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+		&dispute.ResolvedAt,
+	)
+	return &dispute, err
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispute, error) {
+	dispute, err := scanDispute(r.db.QueryRow(ctx, "SELECT "+disputeColumns+" FROM disputes WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrDisputeNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get dispute")
+		return nil, domain.ErrDatabase
+	}
+
+	return dispute, nil
+}
+
+func (r *disputeRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Dispute, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+disputeColumns+" FROM disputes WHERE payment_id = $1 ORDER BY created_at", paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list disputes")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var disputes []*domain.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan dispute")
+			return nil, domain.ErrDatabase
+		}
+		disputes = append(disputes, dispute)
+	}
+
+	return disputes, rows.Err()
+}
+
+func (r *disputeRepository) SubmitEvidence(ctx context.Context, id uuid.UUID, evidence string) (bool, error) {
+	submitted := false
+	err := audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx,
+			"UPDATE disputes SET status = $1, evidence = $2, updated_at = $3 WHERE id = $4 AND status = $5",
+			domain.DisputeEvidenceSubmitted, evidence, time.Now().UTC(), id, domain.DisputeOpen,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to submit dispute evidence")
+			return domain.ErrDatabase
+		}
+		if submitted = result.RowsAffected() > 0; !submitted {
+			return nil
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditDisputeEvidence, domain.AuditEntityDispute, id,
+			statusState(domain.DisputeOpen), map[string]any{"status": domain.DisputeEvidenceSubmitted, "evidence": evidence})
+	})
+	return submitted, err
+}
+
+func (r *disputeRepository) Resolve(ctx context.Context, id uuid.UUID, outcome domain.DisputeStatus, resolution, operator string, at time.Time) (bool, error) {
+	resolved := false
+	err := audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		var from domain.DisputeStatus
+		err := tx.QueryRow(ctx, `
+			UPDATE disputes d
+			SET status = $1, resolution = $2, resolved_by = $3, resolved_at = $4, updated_at = $4
+			FROM (SELECT id, status FROM disputes WHERE id = $5 FOR UPDATE) old
+			WHERE d.id = old.id AND old.status IN ($6, $7)
+			RETURNING old.status
+		`, outcome, resolution, operator, at, id, domain.DisputeOpen, domain.DisputeEvidenceSubmitted).Scan(&from)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to resolve dispute")
+			return domain.ErrDatabase
+		}
+		resolved = true
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditDisputeResolved, domain.AuditEntityDispute, id,
+			statusState(from), map[string]any{"status": outcome, "resolution": resolution})
+	})
+	return resolved, err
+}
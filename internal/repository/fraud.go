@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// FraudRepository keeps fraud rules and their hits, and answers the
+// customer history questions rules ask
+type FraudRepository interface {
+	CreateRule(ctx context.Context, rule *domain.FraudRule) error
+	GetRule(ctx context.Context, id uuid.UUID) (*domain.FraudRule, error)
+	// ListRules returns rules in evaluation order: priority, then age
+	ListRules(ctx context.Context, enabledOnly bool) ([]*domain.FraudRule, error)
+	UpdateRule(ctx context.Context, rule *domain.FraudRule) error
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	CreateHit(ctx context.Context, hit *domain.FraudHit) error
+	// ListHits returns hits newest first, of one rule for a non-nil ruleID
+	ListHits(ctx context.Context, ruleID *uuid.UUID, action domain.FraudAction, limit, offset int) ([]*domain.FraudHit, error)
+	// HasSucceeded reports whether the customer, by phone or national ID,
+	// has a successful payment
+	HasSucceeded(ctx context.Context, phone, nationalID string) (bool, error)
+	// CountFailures counts the customer's FAILED payments created since
+	CountFailures(ctx context.Context, phone, nationalID string, since time.Time) (int, error)
+}
+
+type fraudRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewFraudRepository(db *pgxpool.Pool, logger *logrus.Logger) FraudRepository {
+	return &fraudRepository{db: db, logger: logger}
+}
+
+const fraudRuleColumns = `id, name, kind, action, priority, enabled, amount_etb, failures, window_minutes,
+	start_hour, end_hour, created_by, created_at, updated_at`
+
+func (r *fraudRepository) CreateRule(ctx context.Context, rule *domain.FraudRule) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO fraud_rules (id, name, kind, action, priority, enabled, amount_etb, failures, window_minutes,
+				start_hour, end_hour, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`,
+			rule.ID,
+			rule.Name,
+			rule.Kind,
+			rule.Action,
+			rule.Priority,
+			rule.Enabled,
+			rule.AmountETB,
+			rule.Failures,
+			rule.WindowMinutes,
+			rule.StartHour,
+			rule.EndHour,
+			rule.CreatedBy,
+			rule.CreatedAt,
+			rule.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to create fraud rule")
+			return domain.ErrDatabase
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditFraudRuleCreated, domain.AuditEntityFraudRule, rule.ID, nil, rule)
+	})
+}
+
+func (r *fraudRepository) GetRule(ctx context.Context, id uuid.UUID) (*domain.FraudRule, error) {
+	rule, err := scanFraudRule(r.db.QueryRow(ctx, "SELECT "+fraudRuleColumns+" FROM fraud_rules WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrFraudRuleNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get fraud rule")
+		return nil, domain.ErrDatabase
+	}
+
+	return rule, nil
+}
+
+func (r *fraudRepository) ListRules(ctx context.Context, enabledOnly bool) ([]*domain.FraudRule, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+fraudRuleColumns+`
+		FROM fraud_rules
+		WHERE enabled OR NOT $1
+		ORDER BY priority, created_at
+	`, enabledOnly)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list fraud rules")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var rules []*domain.FraudRule
+	for rows.Next() {
+		rule, err := scanFraudRule(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan fraud rule")
+			return nil, domain.ErrDatabase
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *fraudRepository) UpdateRule(ctx context.Context, rule *domain.FraudRule) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		before, err := scanFraudRule(tx.QueryRow(ctx, "SELECT "+fraudRuleColumns+" FROM fraud_rules WHERE id = $1 FOR UPDATE", rule.ID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrFraudRuleNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to lock fraud rule")
+			return domain.ErrDatabase
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE fraud_rules
+			SET name = $1, kind = $2, action = $3, priority = $4, enabled = $5, amount_etb = $6, failures = $7,
+				window_minutes = $8, start_hour = $9, end_hour = $10, updated_at = $11
+			WHERE id = $12
+		`,
+			rule.Name,
+			rule.Kind,
+			rule.Action,
+			rule.Priority,
+			rule.Enabled,
+			rule.AmountETB,
+			rule.Failures,
+			rule.WindowMinutes,
+			rule.StartHour,
+			rule.EndHour,
+			rule.UpdatedAt,
+			rule.ID,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to update fraud rule")
+			return domain.ErrDatabase
+		}
+
+		rule.CreatedBy = before.CreatedBy
+		rule.CreatedAt = before.CreatedAt
+		return recordAudit(ctx, tx, r.logger, domain.AuditFraudRuleUpdated, domain.AuditEntityFraudRule, rule.ID, before, rule)
+	})
+}
+
+func (r *fraudRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		before, err := scanFraudRule(tx.QueryRow(ctx, "DELETE FROM fraud_rules WHERE id = $1 RETURNING "+fraudRuleColumns, id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrFraudRuleNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to delete fraud rule")
+			return domain.ErrDatabase
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditFraudRuleDeleted, domain.AuditEntityFraudRule, id, before, nil)
+	})
+}
+
+func (r *fraudRepository) CreateHit(ctx context.Context, hit *domain.FraudHit) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `
+		INSERT INTO fraud_hits (id, rule_id, rule_name, action, payment_id, reference, amount, currency, amount_etb,
+			customer_phone, merchant_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, NULLIF($10, ''), $11, $12)
+	`,
+		hit.ID,
+		hit.RuleID,
+		hit.RuleName,
+		hit.Action,
+		hit.PaymentID,
+		hit.Reference,
+		hit.Amount,
+		hit.Currency,
+		hit.AmountETB,
+		hit.CustomerPhone,
+		hit.MerchantID,
+		hit.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record fraud hit")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *fraudRepository) ListHits(ctx context.Context, ruleID *uuid.UUID, action domain.FraudAction, limit, offset int) ([]*domain.FraudHit, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, rule_id, rule_name, action, payment_id, COALESCE(reference, ''), amount, currency, amount_etb,
+			COALESCE(customer_phone, ''), merchant_id, created_at
+		FROM fraud_hits
+		WHERE ($1::uuid IS NULL OR rule_id = $1)
+		  AND ($2::text = '' OR action = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, ruleID, action, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list fraud hits")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var hits []*domain.FraudHit
+	for rows.Next() {
+		var hit domain.FraudHit
+		err := rows.Scan(
+			&hit.ID,
+			&hit.RuleID,
+			&hit.RuleName,
+			&hit.Action,
+			&hit.PaymentID,
+			&hit.Reference,
+			&hit.Amount,
+			&hit.Currency,
+			&hit.AmountETB,
+			&hit.CustomerPhone,
+			&hit.MerchantID,
+			&hit.CreatedAt,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan fraud hit")
+			return nil, domain.ErrDatabase
+		}
+		hits = append(hits, &hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// fraudCustomer matches the customer's payments by phone or national ID
+const fraudCustomer = `(($1::text <> '' AND customer_phone = $1) OR ($2::text <> '' AND customer_national_id = $2))`
+
+func (r *fraudRepository) HasSucceeded(ctx context.Context, phone, nationalID string) (bool, error) {
+	var succeeded bool
+	err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM payments WHERE "+fraudCustomer+" AND status = 'SUCCESS')",
+		phone, nationalID,
+	).Scan(&succeeded)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to check customer payment history")
+		return false, domain.ErrDatabase
+	}
+
+	return succeeded, nil
+}
+
+func (r *fraudRepository) CountFailures(ctx context.Context, phone, nationalID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		"SELECT COUNT(*) FROM payments WHERE "+fraudCustomer+" AND status = 'FAILED' AND created_at >= $3",
+		phone, nationalID, since,
+	).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count customer failures")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
+
+func scanFraudRule(row pgx.Row) (*domain.FraudRule, error) {
+	var rule domain.FraudRule
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Kind,
+		&rule.Action,
+		&rule.Priority,
+		&rule.Enabled,
+		&rule.AmountETB,
+		&rule.Failures,
+		&rule.WindowMinutes,
+		&rule.StartHour,
+		&rule.EndHour,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type FXRepository interface {
+	AddRate(ctx context.Context, rate *domain.FXRate) error
+	// LatestRate returns nil when no rate has been set yet
+	LatestRate(ctx context.Context, pair string) (*domain.FXRate, error)
+	// RateOn returns the last rate set with an effective date on or before
+	// date, or nil if there is none
+	RateOn(ctx context.Context, pair, date string) (*domain.FXRate, error)
+	// ListRates returns rate history, newest first
+	ListRates(ctx context.Context, filter domain.FXRateFilter, limit, offset int) ([]*domain.FXRate, error)
+	CreateQuote(ctx context.Context, quote *domain.FXQuote) error
+	GetQuote(ctx context.Context, id uuid.UUID) (*domain.FXQuote, error)
+}
+
+type fxRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewFXRepository(db *pgxpool.Pool, logger *logrus.Logger) FXRepository {
+	return &fxRepository{db: db, logger: logger}
+}
+
+func (r *fxRepository) AddRate(ctx context.Context, rate *domain.FXRate) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO fx_rates (id, pair, rate, effective_date, set_by, created_at)
+			VALUES ($1, $2, $3, $4::date, $5, $6)
+		`, rate.ID, rate.Pair, rate.Rate, rate.EffectiveDate, rate.SetBy, rate.CreatedAt)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to add FX rate")
+			return domain.ErrDatabase
+		}
+		return recordAudit(ctx, tx, r.logger, domain.AuditFXRateSet, domain.AuditEntityFXRate, *rate.ID, nil, rate)
+	})
+}
+
+func (r *fxRepository) LatestRate(ctx context.Context, pair string) (*domain.FXRate, error) {
+	query := `
+		SELECT id, pair, rate, to_char(effective_date, 'YYYY-MM-DD'), set_by, created_at
+		FROM fx_rates
+		WHERE pair = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var rate domain.FXRate
+	err := r.db.QueryRow(ctx, query, pair).Scan(&rate.ID, &rate.Pair, &rate.Rate, &rate.EffectiveDate, &rate.SetBy, &rate.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get FX rate")
+		return nil, domain.ErrDatabase
+	}
+	rate.Currency = domain.CurrencyOfPair(rate.Pair)
+
+	return &rate, nil
+}
+
+func (r *fxRepository) RateOn(ctx context.Context, pair, date string) (*domain.FXRate, error) {
+	query := `
+		SELECT id, pair, rate, to_char(effective_date, 'YYYY-MM-DD'), set_by, created_at
+		FROM fx_rates
+		WHERE pair = $1 AND effective_date <= $2::date
+		ORDER BY effective_date DESC, created_at DESC
+		LIMIT 1
+	`
+
+	var rate domain.FXRate
+	err := r.db.QueryRow(ctx, query, pair, date).Scan(&rate.ID, &rate.Pair, &rate.Rate, &rate.EffectiveDate, &rate.SetBy, &rate.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get FX rate")
+		return nil, domain.ErrDatabase
+	}
+	rate.Currency = domain.CurrencyOfPair(rate.Pair)
+
+	return &rate, nil
+}
+
+func (r *fxRepository) ListRates(ctx context.Context, filter domain.FXRateFilter, limit, offset int) ([]*domain.FXRate, error) {
+	query := `
+		SELECT id, pair, rate, to_char(effective_date, 'YYYY-MM-DD'), set_by, created_at
+		FROM fx_rates
+		WHERE ($1::text = '' OR pair = $1)
+		  AND ($2::text = '' OR effective_date >= $2::date)
+		  AND ($3::text = '' OR effective_date <= $3::date)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	pair := ""
+	if filter.Currency != "" {
+		pair = domain.FXPair(filter.Currency)
+	}
+
+	rows, err := r.db.Query(ctx, query, pair, filter.From, filter.To, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list FX rates")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var rates []*domain.FXRate
+	for rows.Next() {
+		var rate domain.FXRate
+		if err := rows.Scan(&rate.ID, &rate.Pair, &rate.Rate, &rate.EffectiveDate, &rate.SetBy, &rate.CreatedAt); err != nil {
+			r.logger.WithError(err).Error("Failed to scan FX rate")
+			return nil, domain.ErrDatabase
+		}
+		rate.Currency = domain.CurrencyOfPair(rate.Pair)
+		rates = append(rates, &rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *fxRepository) CreateQuote(ctx context.Context, q *domain.FXQuote) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO fx_quotes (id, pair, rate, mid_rate, rate_id, spread_bps, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, q.ID, q.Pair, q.Rate, q.MidRate, q.RateID, q.SpreadBps, q.ExpiresAt, q.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create FX quote")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *fxRepository) GetQuote(ctx context.Context, id uuid.UUID) (*domain.FXQuote, error) {
+	query := `
+		SELECT id, pair, rate, mid_rate, rate_id, spread_bps, expires_at, payment_id, created_at
+		FROM fx_quotes
+		WHERE id = $1
+	`
+
+	var q domain.FXQuote
+	err := r.db.QueryRow(ctx, query, id).Scan(&q.ID, &q.Pair, &q.Rate, &q.MidRate, &q.RateID, &q.SpreadBps, &q.ExpiresAt, &q.PaymentID, &q.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrFXQuoteNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get FX quote")
+		return nil, domain.ErrDatabase
+	}
+	q.Currency = domain.CurrencyOfPair(q.Pair)
+
+	return &q, nil
+}
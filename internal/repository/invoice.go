@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type InvoiceRepository interface {
+	// Create numbers the invoice from invoice_number_seq and stores it with
+	// its items
+	Create(ctx context.Context, invoice *domain.Invoice) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Invoice, error)
+	// MarkSent records a send and the invoice's payment link; a paid
+	// invoice keeps its status
+	MarkSent(ctx context.Context, id uuid.UUID, paymentLinkID uuid.UUID, sentAt time.Time) error
+	// MarkPaid returns false if the invoice was already paid
+	MarkPaid(ctx context.Context, id uuid.UUID, paidAt time.Time) (bool, error)
+	AddPayment(ctx context.Context, invoiceID, paymentID uuid.UUID) error
+	// ListPayments covers payments linked directly and those made through
+	// the invoice's payment link
+	ListPayments(ctx context.Context, invoiceID uuid.UUID) ([]uuid.UUID, error)
+	// PaidAmount sums the successful payments ListPayments returns
+	PaidAmount(ctx context.Context, invoiceID uuid.UUID) (domain.Amount, error)
+	// ListUnpaidByPayment returns the unpaid invoices a payment is linked
+	// to, without their items
+	ListUnpaidByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Invoice, error)
+}
+
+type invoiceRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewInvoiceRepository(db *pgxpool.Pool, logger *logrus.Logger) InvoiceRepository {
+	return &invoiceRepository{db: db, logger: logger}
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	var seq int64
+	if err := tx.QueryRow(ctx, "SELECT nextval('invoice_number_seq')").Scan(&seq); err != nil {
+		r.logger.WithError(err).Error("Failed to number invoice")
+		return domain.ErrDatabase
+	}
+	invoice.Number = domain.FormatInvoiceNumber(seq)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO invoices (id, number, status, customer_name, customer_email, customer_phone, language, currency,
+			subtotal, vat_amount, total, due_date, notes, merchant_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12::date, NULLIF($13, ''), $14, $15, $16)
+	`,
+		invoice.ID,
+		invoice.Number,
+		invoice.Status,
+		invoice.CustomerName,
+		invoice.CustomerEmail,
+		invoice.CustomerPhone,
+		invoice.Language,
+		invoice.Currency,
+		invoice.Subtotal,
+		invoice.VATAmount,
+		invoice.Total,
+		invoice.DueDate,
+		invoice.Notes,
+		invoice.MerchantID,
+		invoice.CreatedAt,
+		invoice.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create invoice")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"invoice_items"},
+		[]string{"invoice_id", "position", "description", "quantity", "unit_price", "vat_rate", "amount", "vat_amount"},
+		pgx.CopyFromSlice(len(invoice.Items), func(i int) ([]interface{}, error) {
+			item := invoice.Items[i]
+			return []interface{}{invoice.ID, i + 1, item.Description, item.Quantity, item.UnitPrice, item.VATRate, item.Amount, item.VATAmount}, nil
+		}),
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to store invoice items")
+		return domain.ErrDatabase
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+const invoiceColumns = `id, number, status, customer_name, COALESCE(customer_email, ''), COALESCE(customer_phone, ''), COALESCE(language, ''),
+	currency, subtotal, vat_amount, total, to_char(due_date, 'YYYY-MM-DD'), COALESCE(notes, ''), payment_link_id, merchant_id,
+	sent_at, paid_at, created_at, updated_at`
+
+func scanInvoice(row pgx.Row) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.Number,
+		&invoice.Status,
+		&invoice.CustomerName,
+		&invoice.CustomerEmail,
+		&invoice.CustomerPhone,
+		&invoice.Language,
+		&invoice.Currency,
+		&invoice.Subtotal,
+		&invoice.VATAmount,
+		&invoice.Total,
+		&invoice.DueDate,
+		&invoice.Notes,
+		&invoice.PaymentLinkID,
+		&invoice.MerchantID,
+		&invoice.SentAt,
+		&invoice.PaidAt,
+		&invoice.CreatedAt,
+		&invoice.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := scanInvoice(r.db.QueryRow(ctx, "SELECT "+invoiceColumns+" FROM invoices WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrInvoiceNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get invoice")
+		return nil, domain.ErrDatabase
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT description, quantity, unit_price, vat_rate, amount, vat_amount
+		FROM invoice_items
+		WHERE invoice_id = $1
+		ORDER BY position
+	`, id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get invoice items")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.InvoiceItem
+		if err := rows.Scan(&item.Description, &item.Quantity, &item.UnitPrice, &item.VATRate, &item.Amount, &item.VATAmount); err != nil {
+			r.logger.WithError(err).Error("Failed to scan invoice item")
+			return nil, domain.ErrDatabase
+		}
+		invoice.Items = append(invoice.Items, &item)
+	}
+
+	return invoice, rows.Err()
+}
+
+func (r *invoiceRepository) MarkSent(ctx context.Context, id uuid.UUID, paymentLinkID uuid.UUID, sentAt time.Time) error {
+	query := `
+		UPDATE invoices
+		SET status = CASE WHEN status = 'PAID' THEN status ELSE 'SENT' END,
+		    payment_link_id = COALESCE(payment_link_id, $2),
+		    sent_at = $3
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query, id, paymentLinkID, sentAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to mark invoice sent")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrInvoiceNotFound
+	}
+
+	return nil
+}
+
+func (r *invoiceRepository) MarkPaid(ctx context.Context, id uuid.UUID, paidAt time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx,
+		"UPDATE invoices SET status = 'PAID', paid_at = $2 WHERE id = $1 AND status <> 'PAID'",
+		id, paidAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to mark invoice paid")
+		return false, domain.ErrDatabase
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *invoiceRepository) AddPayment(ctx context.Context, invoiceID, paymentID uuid.UUID) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO invoice_payments (invoice_id, payment_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		invoiceID, paymentID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to link invoice payment")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+// invoicePayments selects the payments linked to invoice $1 with when they
+// were linked
+const invoicePayments = `
+	SELECT payment_id, created_at FROM invoice_payments WHERE invoice_id = $1
+	UNION
+	SELECT lp.payment_id, lp.created_at
+	FROM payment_link_payments lp
+	JOIN invoices i ON i.payment_link_id = lp.link_id
+	WHERE i.id = $1
+`
+
+func (r *invoiceRepository) ListPayments(ctx context.Context, invoiceID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, "SELECT payment_id FROM ("+invoicePayments+") linked ORDER BY created_at DESC", invoiceID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list invoice payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			r.logger.WithError(err).Error("Failed to scan invoice payment")
+			return nil, domain.ErrDatabase
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *invoiceRepository) PaidAmount(ctx context.Context, invoiceID uuid.UUID) (domain.Amount, error) {
+	query := `
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM payments p
+		WHERE p.status = 'SUCCESS'
+		  AND p.id IN (SELECT payment_id FROM (` + invoicePayments + `) linked)
+	`
+
+	var paid domain.Amount
+	if err := r.db.QueryRow(ctx, query, invoiceID).Scan(&paid); err != nil {
+		r.logger.WithError(err).Error("Failed to sum invoice payments")
+		return 0, domain.ErrDatabase
+	}
+
+	return paid, nil
+}
+
+func (r *invoiceRepository) ListUnpaidByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE status <> 'PAID'
+		  AND (id IN (SELECT invoice_id FROM invoice_payments WHERE payment_id = $1)
+		    OR payment_link_id IN (SELECT link_id FROM payment_link_payments WHERE payment_id = $1))
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list invoices by payment")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var invoices []*domain.Invoice
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan invoice")
+			return nil, domain.ErrDatabase
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
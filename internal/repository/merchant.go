@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type MerchantRepository interface {
+	// Create saves the merchant with its initial settlement accounts
+	Create(ctx context.Context, merchant *domain.Merchant, accounts []*domain.SettlementAccount) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Merchant, error)
+	// List returns merchants, all of them when status is empty
+	List(ctx context.Context, status domain.MerchantStatus) ([]*domain.Merchant, error)
+	// Update saves contact details and status
+	Update(ctx context.Context, merchant *domain.Merchant) error
+	CreateAccount(ctx context.Context, account *domain.SettlementAccount) error
+	GetAccount(ctx context.Context, merchantID, accountID uuid.UUID) (*domain.SettlementAccount, error)
+	ListAccounts(ctx context.Context, merchantID uuid.UUID) ([]*domain.SettlementAccount, error)
+	// UpdateAccount saves account details and verification. An account
+	// that is no longer verified stops being primary; otherwise primary is
+	// changed with SetPrimaryAccount only.
+	UpdateAccount(ctx context.Context, account *domain.SettlementAccount) error
+	// SetPrimaryAccount makes a verified account the merchant's primary one
+	SetPrimaryAccount(ctx context.Context, merchantID, accountID uuid.UUID) error
+}
+
+type merchantRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewMerchantRepository(db *pgxpool.Pool, logger *logrus.Logger) MerchantRepository {
+	return &merchantRepository{db: db, logger: logger}
+}
+
+const merchantColumns = `id, legal_name, COALESCE(trade_name, ''), tin, email, phone, COALESCE(region, ''), status, COALESCE(status_reason, ''), created_at, updated_at`
+
+func scanMerchant(row pgx.Row) (*domain.Merchant, error) {
+	var m domain.Merchant
+	err := row.Scan(
+		&m.ID,
+		&m.LegalName,
+		&m.TradeName,
+		&m.TIN,
+		&m.Email,
+		&m.Phone,
+		&m.Region,
+		&m.Status,
+		&m.StatusReason,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+const settlementAccountColumns = `id, merchant_id, bank_code, account_number, account_name, is_primary, status,
+	COALESCE(verified_name, ''), COALESCE(status_note, ''), COALESCE(verified_by, ''), verified_at, created_at, updated_at`
+
+func scanSettlementAccount(row pgx.Row) (*domain.SettlementAccount, error) {
+	var a domain.SettlementAccount
+	err := row.Scan(
+		&a.ID,
+		&a.MerchantID,
+		&a.BankCode,
+		&a.AccountNumber,
+		&a.AccountName,
+		&a.Primary,
+		&a.Status,
+		&a.VerifiedName,
+		&a.StatusNote,
+		&a.VerifiedBy,
+		&a.VerifiedAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *merchantRepository) Create(ctx context.Context, m *domain.Merchant, accounts []*domain.SettlementAccount) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO merchants (id, legal_name, trade_name, tin, email, phone, region, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), $8, $9, $9)
+		ON CONFLICT (tin) DO NOTHING
+		RETURNING id
+	`, m.ID, m.LegalName, m.TradeName, m.TIN, m.Email, m.Phone, m.Region, m.Status, m.CreatedAt).Scan(&m.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrMerchantExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create merchant")
+		return domain.ErrDatabase
+	}
+
+	for _, a := range accounts {
+		if err := r.insertAccount(ctx, tx, a); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit merchant")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *merchantRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Merchant, error) {
+	merchant, err := scanMerchant(r.db.QueryRow(ctx, `SELECT `+merchantColumns+` FROM merchants WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrMerchantNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get merchant")
+		return nil, domain.ErrDatabase
+	}
+	return merchant, nil
+}
+
+func (r *merchantRepository) List(ctx context.Context, status domain.MerchantStatus) ([]*domain.Merchant, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+merchantColumns+` FROM merchants
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY legal_name
+	`, string(status))
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list merchants")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var merchants []*domain.Merchant
+	for rows.Next() {
+		merchant, err := scanMerchant(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan merchant")
+			return nil, domain.ErrDatabase
+		}
+		merchants = append(merchants, merchant)
+	}
+
+	return merchants, rows.Err()
+}
+
+// Update saves the merchant; a status change, such as activation or
+// suspension, is audited with it
+func (r *merchantRepository) Update(ctx context.Context, m *domain.Merchant) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		var previous domain.MerchantStatus
+		err := tx.QueryRow(ctx, "SELECT status FROM merchants WHERE id = $1 FOR UPDATE", m.ID).Scan(&previous)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrMerchantNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to lock merchant")
+			return domain.ErrDatabase
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE merchants
+			SET trade_name = NULLIF($1, ''), email = $2, phone = $3, region = NULLIF($4, ''), status = $5, status_reason = NULLIF($6, '')
+			WHERE id = $7
+		`, m.TradeName, m.Email, m.Phone, m.Region, m.Status, m.StatusReason, m.ID)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to update merchant")
+			return domain.ErrDatabase
+		}
+
+		if m.Status == previous {
+			return nil
+		}
+		return recordAudit(ctx, tx, r.logger, domain.AuditMerchantStatusChanged, domain.AuditEntityMerchant, m.ID,
+			statusState(previous), map[string]any{"status": m.Status, "reason": m.StatusReason})
+	})
+}
+
+func (r *merchantRepository) CreateAccount(ctx context.Context, a *domain.SettlementAccount) error {
+	return r.insertAccount(ctx, r.db, a)
+}
+
+func (r *merchantRepository) insertAccount(ctx context.Context, q rowQuerier, a *domain.SettlementAccount) error {
+	err := q.QueryRow(ctx, `
+		INSERT INTO merchant_settlement_accounts (id, merchant_id, bank_code, account_number, account_name, is_primary, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, FALSE, $6, $7, $7)
+		ON CONFLICT (merchant_id, bank_code, account_number) DO NOTHING
+		RETURNING id
+	`, a.ID, a.MerchantID, a.BankCode, a.AccountNumber, a.AccountName, a.Status, a.CreatedAt).Scan(&a.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrSettlementAccountExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create settlement account")
+		return domain.ErrDatabase
+	}
+	return nil
+}
+
+func (r *merchantRepository) GetAccount(ctx context.Context, merchantID, accountID uuid.UUID) (*domain.SettlementAccount, error) {
+	account, err := scanSettlementAccount(r.db.QueryRow(ctx, `
+		SELECT `+settlementAccountColumns+` FROM merchant_settlement_accounts
+		WHERE id = $1 AND merchant_id = $2
+	`, accountID, merchantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSettlementAccountNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get settlement account")
+		return nil, domain.ErrDatabase
+	}
+	return account, nil
+}
+
+func (r *merchantRepository) ListAccounts(ctx context.Context, merchantID uuid.UUID) ([]*domain.SettlementAccount, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+settlementAccountColumns+` FROM merchant_settlement_accounts
+		WHERE merchant_id = $1
+		ORDER BY is_primary DESC, created_at
+	`, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list settlement accounts")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var accounts []*domain.SettlementAccount
+	for rows.Next() {
+		account, err := scanSettlementAccount(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan settlement account")
+			return nil, domain.ErrDatabase
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (r *merchantRepository) UpdateAccount(ctx context.Context, a *domain.SettlementAccount) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE merchant_settlement_accounts
+		SET bank_code = $1, account_number = $2, account_name = $3, status = $4, verified_name = NULLIF($5, ''),
+			status_note = NULLIF($6, ''), verified_by = NULLIF($7, ''), verified_at = $8,
+			is_primary = is_primary AND $4 = 'VERIFIED'
+		WHERE id = $9 AND merchant_id = $10
+	`, a.BankCode, a.AccountNumber, a.AccountName, a.Status, a.VerifiedName, a.StatusNote, a.VerifiedBy, a.VerifiedAt, a.ID, a.MerchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update settlement account")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSettlementAccountNotFound
+	}
+
+	return nil
+}
+
+func (r *merchantRepository) SetPrimaryAccount(ctx context.Context, merchantID, accountID uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE merchant_settlement_accounts SET is_primary = FALSE
+		WHERE merchant_id = $1 AND is_primary AND id <> $2
+	`, merchantID, accountID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to clear primary settlement account")
+		return domain.ErrDatabase
+	}
+
+	result, err := tx.Exec(ctx, `
+		UPDATE merchant_settlement_accounts SET is_primary = TRUE
+		WHERE id = $1 AND merchant_id = $2 AND status = $3
+	`, accountID, merchantID, domain.SettlementAccountVerified)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to set primary settlement account")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSettlementAccountNotReady
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit primary settlement account")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentNoteRepository interface {
+	Create(ctx context.Context, note *domain.PaymentNote) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentNote, error)
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentNote, error)
+	UpdateBody(ctx context.Context, id uuid.UUID, body string, editedAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type paymentNoteRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPaymentNoteRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentNoteRepository {
+	return &paymentNoteRepository{db: db, logger: logger}
+}
+
+func (r *paymentNoteRepository) Create(ctx context.Context, note *domain.PaymentNote) error {
+	query := `
+		INSERT INTO payment_notes (id, payment_id, author, body, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.Exec(ctx, query, note.ID, note.PaymentID, note.Author, note.Body, note.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment note")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentNote, error) {
+	query := `
+		SELECT id, payment_id, author, body, created_at, edited_at
+		FROM payment_notes
+		WHERE id = $1
+	`
+
+	var note domain.PaymentNote
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&note.ID,
+		&note.PaymentID,
+		&note.Author,
+		&note.Body,
+		&note.CreatedAt,
+		&note.EditedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrNoteNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get payment note")
+		return nil, domain.ErrDatabase
+	}
+
+	return &note, nil
+}
+
+func (r *paymentNoteRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentNote, error) {
+	query := `
+		SELECT id, payment_id, author, body, created_at, edited_at
+		FROM payment_notes
+		WHERE payment_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payment notes")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var notes []*domain.PaymentNote
+	for rows.Next() {
+		var note domain.PaymentNote
+		if err := rows.Scan(
+			&note.ID,
+			&note.PaymentID,
+			&note.Author,
+			&note.Body,
+			&note.CreatedAt,
+			&note.EditedAt,
+		); err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment note")
+			return nil, domain.ErrDatabase
+		}
+		notes = append(notes, &note)
+	}
+
+	return notes, rows.Err()
+}
+
+func (r *paymentNoteRepository) UpdateBody(ctx context.Context, id uuid.UUID, body string, editedAt time.Time) error {
+	result, err := r.db.Exec(ctx,
+		"UPDATE payment_notes SET body = $1, edited_at = $2 WHERE id = $3",
+		body, editedAt, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update payment note")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+func (r *paymentNoteRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM payment_notes WHERE id = $1", id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete payment note")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrNoteNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *domain.Notification) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.NotificationStatus, providerMessageID, errMsg string) error
+	UpdateStatusByProviderID(ctx context.Context, providerMessageID string, status domain.NotificationStatus, errMsg string) error
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Notification, error)
+}
+
+type notificationRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(db *pgxpool.Pool, logger *logrus.Logger) NotificationRepository {
+	return &notificationRepository{db: db, logger: logger}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	query := `
+		INSERT INTO notifications (id, payment_id, channel, recipient, message, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		notification.ID,
+		notification.PaymentID,
+		notification.Channel,
+		notification.Recipient,
+		notification.Message,
+		notification.Status,
+		notification.CreatedAt,
+		notification.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create notification")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.NotificationStatus, providerMessageID, errMsg string) error {
+	query := `
+		UPDATE notifications
+		SET status = $1, provider_message_id = NULLIF($2, ''), error = NULLIF($3, ''), updated_at = $4
+		WHERE id = $5
+	`
+
+	result, err := r.db.Exec(ctx, query, status, providerMessageID, errMsg, time.Now().UTC(), id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update notification status")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// Used by delivery reports, which only know the gateway's message ID
+func (r *notificationRepository) UpdateStatusByProviderID(ctx context.Context, providerMessageID string, status domain.NotificationStatus, errMsg string) error {
+	query := `
+		UPDATE notifications
+		SET status = $1, error = NULLIF($2, ''), updated_at = $3
+		WHERE provider_message_id = $4
+	`
+
+	result, err := r.db.Exec(ctx, query, status, errMsg, time.Now().UTC(), providerMessageID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update notification delivery status")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Notification, error) {
+	query := `
+		SELECT id, payment_id, channel, recipient, message, status,
+		       COALESCE(provider_message_id, ''), COALESCE(error, ''), created_at, updated_at
+		FROM notifications
+		WHERE payment_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list notifications")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		var n domain.Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.PaymentID,
+			&n.Channel,
+			&n.Recipient,
+			&n.Message,
+			&n.Status,
+			&n.ProviderMessageID,
+			&n.Error,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationPreferenceRepository interface {
+	List(ctx context.Context, recipientType domain.RecipientType, recipientID string) ([]*domain.NotificationPreference, error)
+	// ForEvent returns the explicit channel choices a recipient made for an event
+	ForEvent(ctx context.Context, recipientType domain.RecipientType, recipientID string, event domain.NotificationEvent) (map[domain.NotificationChannel]bool, error)
+	Upsert(ctx context.Context, pref *domain.NotificationPreference) error
+	// Delete removes a preference; with a merchant ID only that merchant's own
+	Delete(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type notificationPreferenceRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewNotificationPreferenceRepository(db *pgxpool.Pool, logger *logrus.Logger) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db, logger: logger}
+}
+
+func (r *notificationPreferenceRepository) List(ctx context.Context, recipientType domain.RecipientType, recipientID string) ([]*domain.NotificationPreference, error) {
+	query := `
+		SELECT id, recipient_type, recipient_id, event_type, channel, enabled, updated_at
+		FROM notification_preferences
+		WHERE recipient_type = $1 AND recipient_id = $2
+		ORDER BY event_type, channel
+	`
+
+	rows, err := r.db.Query(ctx, query, recipientType, recipientID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list notification preferences")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var prefs []*domain.NotificationPreference
+	for rows.Next() {
+		var pref domain.NotificationPreference
+		err := rows.Scan(
+			&pref.ID,
+			&pref.RecipientType,
+			&pref.RecipientID,
+			&pref.EventType,
+			&pref.Channel,
+			&pref.Enabled,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, &pref)
+	}
+
+	return prefs, rows.Err()
+}
+
+func (r *notificationPreferenceRepository) ForEvent(ctx context.Context, recipientType domain.RecipientType, recipientID string, event domain.NotificationEvent) (map[domain.NotificationChannel]bool, error) {
+	query := `
+		SELECT channel, enabled
+		FROM notification_preferences
+		WHERE recipient_type = $1 AND recipient_id = $2 AND event_type = $3
+	`
+
+	rows, err := r.db.Query(ctx, query, recipientType, recipientID, event)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to load notification preferences")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	prefs := make(map[domain.NotificationChannel]bool)
+	for rows.Next() {
+		var channel domain.NotificationChannel
+		var enabled bool
+		if err := rows.Scan(&channel, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[channel] = enabled
+	}
+
+	return prefs, rows.Err()
+}
+
+func (r *notificationPreferenceRepository) Upsert(ctx context.Context, pref *domain.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (id, recipient_type, recipient_id, event_type, channel, enabled, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (recipient_type, recipient_id, event_type, channel)
+		DO UPDATE SET enabled = EXCLUDED.enabled
+		RETURNING id, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		pref.ID,
+		pref.RecipientType,
+		pref.RecipientID,
+		pref.EventType,
+		pref.Channel,
+		pref.Enabled,
+		time.Now().UTC(),
+	).Scan(&pref.ID, &pref.UpdatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to save notification preference")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *notificationPreferenceRepository) Delete(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM notification_preferences
+		WHERE id = $1 AND ($2::text IS NULL OR (recipient_type = 'MERCHANT' AND recipient_id = $2))
+	`, id, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete notification preference")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrPreferenceNotFound
+	}
+
+	return nil
+}
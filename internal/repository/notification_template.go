@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationTemplateRepository interface {
+	// Find returns the best match: the merchant override if present,
+	// otherwise the gateway default for the same event/channel/language.
+	Find(ctx context.Context, event domain.NotificationEvent, channel domain.NotificationChannel, language domain.Language, merchantID string) (*domain.NotificationTemplate, error)
+	List(ctx context.Context, merchantID string) ([]*domain.NotificationTemplate, error)
+	Upsert(ctx context.Context, tmpl *domain.NotificationTemplate) error
+	// Delete removes a template; with a merchant ID only that merchant's
+	// override, never a gateway default
+	Delete(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type notificationTemplateRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewNotificationTemplateRepository(db *pgxpool.Pool, logger *logrus.Logger) NotificationTemplateRepository {
+	return &notificationTemplateRepository{db: db, logger: logger}
+}
+
+func (r *notificationTemplateRepository) Find(ctx context.Context, event domain.NotificationEvent, channel domain.NotificationChannel, language domain.Language, merchantID string) (*domain.NotificationTemplate, error) {
+	query := `
+		SELECT id, event_type, channel, language, merchant_id, COALESCE(subject, ''), body, updated_at
+		FROM notification_templates
+		WHERE event_type = $1 AND channel = $2 AND language = $3 AND merchant_id IN ($4, '')
+		ORDER BY merchant_id DESC
+		LIMIT 1
+	`
+
+	var tmpl domain.NotificationTemplate
+	err := r.db.QueryRow(ctx, query, event, channel, language, merchantID).Scan(
+		&tmpl.ID,
+		&tmpl.EventType,
+		&tmpl.Channel,
+		&tmpl.Language,
+		&tmpl.MerchantID,
+		&tmpl.Subject,
+		&tmpl.Body,
+		&tmpl.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrTemplateNotFound
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to find notification template")
+		return nil, domain.ErrDatabase
+	}
+
+	return &tmpl, nil
+}
+
+func (r *notificationTemplateRepository) List(ctx context.Context, merchantID string) ([]*domain.NotificationTemplate, error) {
+	query := `
+		SELECT id, event_type, channel, language, merchant_id, COALESCE(subject, ''), body, updated_at
+		FROM notification_templates
+		WHERE merchant_id IN ($1, '')
+		ORDER BY event_type, channel, language, merchant_id
+	`
+
+	rows, err := r.db.Query(ctx, query, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list notification templates")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var templates []*domain.NotificationTemplate
+	for rows.Next() {
+		var tmpl domain.NotificationTemplate
+		err := rows.Scan(
+			&tmpl.ID,
+			&tmpl.EventType,
+			&tmpl.Channel,
+			&tmpl.Language,
+			&tmpl.MerchantID,
+			&tmpl.Subject,
+			&tmpl.Body,
+			&tmpl.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, &tmpl)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *notificationTemplateRepository) Upsert(ctx context.Context, tmpl *domain.NotificationTemplate) error {
+	query := `
+		INSERT INTO notification_templates (id, event_type, channel, language, merchant_id, subject, body, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		ON CONFLICT (event_type, channel, language, merchant_id)
+		DO UPDATE SET subject = EXCLUDED.subject, body = EXCLUDED.body
+		RETURNING id, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		tmpl.ID,
+		tmpl.EventType,
+		tmpl.Channel,
+		tmpl.Language,
+		tmpl.MerchantID,
+		tmpl.Subject,
+		tmpl.Body,
+		time.Now().UTC(),
+	).Scan(&tmpl.ID, &tmpl.UpdatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to save notification template")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *notificationTemplateRepository) Delete(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM notification_templates WHERE id = $1 AND ($2::text IS NULL OR merchant_id = $2)", id, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete notification template")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrTemplateNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentOTPRepository interface {
+	Create(ctx context.Context, otp *domain.PaymentOTP) error
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.PaymentOTP, error)
+	// Reissue replaces the code, resets attempts and counts the send
+	Reissue(ctx context.Context, paymentID uuid.UUID, codeHash string, expiresAt time.Time) error
+	// UseAttempt consumes one verification attempt; false once maxAttempts is reached
+	UseAttempt(ctx context.Context, paymentID uuid.UUID, maxAttempts int) (bool, error)
+	MarkVerified(ctx context.Context, paymentID uuid.UUID) error
+}
+
+type paymentOTPRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPaymentOTPRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentOTPRepository {
+	return &paymentOTPRepository{db: db, logger: logger}
+}
+
+func (r *paymentOTPRepository) Create(ctx context.Context, otp *domain.PaymentOTP) error {
+	query := `
+		INSERT INTO payment_otps (payment_id, code_hash, expires_at, attempts, send_count, created_at)
+		VALUES ($1, $2, $3, 0, 1, $4)
+	`
+
+	_, err := r.db.Exec(ctx, query, otp.PaymentID, otp.CodeHash, otp.ExpiresAt, otp.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment OTP")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentOTPRepository) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.PaymentOTP, error) {
+	query := `
+		SELECT payment_id, code_hash, expires_at, attempts, send_count, verified_at, created_at
+		FROM payment_otps
+		WHERE payment_id = $1
+	`
+
+	var otp domain.PaymentOTP
+	err := r.db.QueryRow(ctx, query, paymentID).Scan(
+		&otp.PaymentID,
+		&otp.CodeHash,
+		&otp.ExpiresAt,
+		&otp.Attempts,
+		&otp.SendCount,
+		&otp.VerifiedAt,
+		&otp.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrOTPNotAwaited
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get payment OTP")
+		return nil, domain.ErrDatabase
+	}
+
+	return &otp, nil
+}
+
+func (r *paymentOTPRepository) Reissue(ctx context.Context, paymentID uuid.UUID, codeHash string, expiresAt time.Time) error {
+	query := `
+		UPDATE payment_otps
+		SET code_hash = $1, expires_at = $2, attempts = 0, send_count = send_count + 1
+		WHERE payment_id = $3 AND verified_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, codeHash, expiresAt, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to reissue payment OTP")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrOTPNotAwaited
+	}
+
+	return nil
+}
+
+func (r *paymentOTPRepository) UseAttempt(ctx context.Context, paymentID uuid.UUID, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE payment_otps
+		SET attempts = attempts + 1
+		WHERE payment_id = $1 AND attempts < $2
+	`
+
+	result, err := r.db.Exec(ctx, query, paymentID, maxAttempts)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record OTP attempt")
+		return false, domain.ErrDatabase
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *paymentOTPRepository) MarkVerified(ctx context.Context, paymentID uuid.UUID) error {
+	query := `
+		UPDATE payment_otps
+		SET verified_at = $1
+		WHERE payment_id = $2 AND verified_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, time.Now().UTC(), paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to mark payment OTP verified")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrOTPNotAwaited
+	}
+
+	return nil
+}
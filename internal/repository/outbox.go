@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, message *domain.OutboxMessage) error
+	// ClaimDue locks up to limit unsent messages that are due until
+	// lockedUntil, oldest first, so concurrent relays take different ones
+	ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, id uuid.UUID, at time.Time) error
+	// MarkFailed records a failed publish and releases the message until nextAttemptAt
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string, nextAttemptAt time.Time) error
+}
+
+type outboxRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewOutboxRepository(db *pgxpool.Pool, logger *logrus.Logger) OutboxRepository {
+	return &outboxRepository{db: db, logger: logger}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, message *domain.OutboxMessage) error {
+	if message.Headers == nil {
+		message.Headers = map[string]string{}
+	}
+	headers, err := json.Marshal(message.Headers)
+	if err != nil {
+		return err
+	}
+
+	// Inside Transactor.InTx the message commits with the change it announces
+	_, err = conn(ctx, r.db).Exec(ctx, `
+		INSERT INTO outbox (id, routing_key, body, headers, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, message.ID, message.RoutingKey, message.Body, headers, message.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to add outbox message")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.OutboxMessage, error) {
+	// Returned oldest first, so the queue sees messages in the order they were written
+	query := `
+		WITH claimed AS (
+			UPDATE outbox SET locked_until = $2
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE sent_at IS NULL
+				  AND next_attempt_at <= $1
+				  AND (locked_until IS NULL OR locked_until < $1)
+				ORDER BY created_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, routing_key, body, headers, attempts, COALESCE(last_error, '') AS last_error, next_attempt_at, created_at
+		)
+		SELECT * FROM claimed ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, now, lockedUntil, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim outbox messages")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var messages []*domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		var headers []byte
+		err := rows.Scan(&m.ID, &m.RoutingKey, &m.Body, &headers, &m.Attempts, &m.LastError, &m.NextAttemptAt, &m.CreatedAt)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan outbox message")
+			return nil, domain.ErrDatabase
+		}
+		if err := json.Unmarshal(headers, &m.Headers); err != nil {
+			r.logger.WithError(err).WithField("message_id", m.ID).Error("Invalid outbox message headers")
+			return nil, domain.ErrDatabase
+		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithError(err).Error("Failed to claim outbox messages")
+		return nil, domain.ErrDatabase
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET sent_at = $1, attempts = attempts + 1, locked_until = NULL WHERE id = $2",
+		at, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to mark outbox message sent")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, locked_until = NULL WHERE id = $3",
+		reason, nextAttemptAt, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record outbox publish failure")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type StatusOverrideRepository interface {
+	// Apply sets the payment status and writes the audit record in one
+	// transaction, filling in override.FromStatus.
+	Apply(ctx context.Context, override *domain.StatusOverride) error
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.StatusOverride, error)
+}
+
+type statusOverrideRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewStatusOverrideRepository(db *pgxpool.Pool, logger *logrus.Logger) StatusOverrideRepository {
+	return &statusOverrideRepository{db: db, logger: logger}
+}
+
+func (r *statusOverrideRepository) Apply(ctx context.Context, override *domain.StatusOverride) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		"SELECT status FROM payments WHERE id = $1 FOR UPDATE",
+		override.PaymentID,
+	).Scan(&override.FromStatus)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPaymentNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to lock payment row")
+		return domain.ErrDatabase
+	}
+
+	if override.FromStatus == override.ToStatus {
+		return domain.ErrStatusUnchanged
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3",
+		override.ToStatus, override.CreatedAt, override.PaymentID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to override payment status")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO payment_status_overrides (id, payment_id, from_status, to_status, reason, operator, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		override.ID,
+		override.PaymentID,
+		override.FromStatus,
+		override.ToStatus,
+		override.Reason,
+		override.Operator,
+		override.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record status override")
+		return domain.ErrDatabase
+	}
+
+	err = recordAudit(ctx, tx, r.logger, domain.AuditPaymentStatusOverride, domain.AuditEntityPayment, override.PaymentID,
+		statusState(override.FromStatus),
+		map[string]any{"status": override.ToStatus, "reason": override.Reason},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *statusOverrideRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.StatusOverride, error) {
+	query := `
+		SELECT id, payment_id, from_status, to_status, reason, operator, created_at
+		FROM payment_status_overrides
+		WHERE payment_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list status overrides")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var overrides []*domain.StatusOverride
+	for rows.Next() {
+		var o domain.StatusOverride
+		err := rows.Scan(&o.ID, &o.PaymentID, &o.FromStatus, &o.ToStatus, &o.Reason, &o.Operator, &o.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, &o)
+	}
+
+	return overrides, rows.Err()
+}
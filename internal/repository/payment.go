@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"payment-gateway/internal/domain"
@@ -19,28 +20,87 @@ type PaymentRepository interface {
 	GetByReference(ctx context.Context, reference string) (*domain.Payment, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.PaymentStatus) error
 	UpdateStatusIfPending(ctx context.Context, id uuid.UUID, status domain.PaymentStatus) (bool, error)
-	List(ctx context.Context, limit, offset int) ([]*domain.Payment, error)
-	Count(ctx context.Context) (int, error)
+	// TransitionStatus moves a payment from one status to another; false if it was not in "from"
+	TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.PaymentStatus) (bool, error)
+	// RequeueFailed moves a FAILED payment back to PENDING, optionally on another bank; false if it was not FAILED
+	RequeueFailed(ctx context.Context, id uuid.UUID, bankCode string) (bool, error)
+	// List returns payments in the filter's sort order, newest first by
+	// default. With a cursor the page starts after it and offset is ignored.
+	List(ctx context.Context, filter domain.PaymentFilter, cursor *domain.PaymentCursor, limit, offset int) ([]*domain.Payment, error)
+	// Search finds payments whose reference, customer name or description
+	// contains the query or its words, best matches first
+	Search(ctx context.Context, terms string, merchantID *uuid.UUID, limit, offset int) ([]*domain.Payment, error)
+	ListCreatedBetween(ctx context.Context, from, to time.Time) ([]*domain.Payment, error)
+	// ListStale returns payments that have sat in a status since before updatedBefore, oldest first
+	ListStale(ctx context.Context, status domain.PaymentStatus, updatedBefore time.Time, limit int) ([]*domain.Payment, error)
+	// ListByCustomerPhone and CountByCustomerPhone cover every merchant for a nil merchantID
+	ListByCustomerPhone(ctx context.Context, phone string, merchantID *uuid.UUID, limit, offset int) ([]*domain.Payment, error)
+	// ListByCustomer returns the customer's payments, newest first
+	ListByCustomer(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*domain.Payment, error)
+	// Count counts the payments List returns for the filter, on all pages
+	Count(ctx context.Context, filter domain.PaymentFilter) (int, error)
+	// Totals counts and sums the filtered payments by currency and status
+	Totals(ctx context.Context, filter domain.PaymentFilter) ([]domain.PaymentTotal, error)
+	CountByCustomerPhone(ctx context.Context, phone string, merchantID *uuid.UUID) (int, error)
+	// CustomerVolume sums the ETB value of the customer's payments, matched
+	// by phone or national ID, since dayStart and monthStart. FAILED,
+	// CANCELLED and EXPIRED payments are not counted.
+	CustomerVolume(ctx context.Context, phone, nationalID string, dayStart, monthStart time.Time, rates map[domain.Currency]float64) (domain.CustomerUsage, error)
+	// CustomerVelocity counts the customer's payments created since, at one
+	// bank for a non-empty bankCode, and sums the ETB value of those not
+	// FAILED, CANCELLED or EXPIRED. CANCELLED payments are not counted.
+	CustomerVelocity(ctx context.Context, phone, nationalID, bankCode string, since time.Time, rates map[domain.Currency]float64) (domain.VelocityUsage, error)
+	// LockCustomer takes an advisory lock on the customer's phone and
+	// national ID, held until the transaction on ctx ends, so concurrent
+	// payments for one customer are checked against limits one at a time
+	LockCustomer(ctx context.Context, phone, nationalID string) error
+	CountByClientIP(ctx context.Context, ip string, since time.Time) (int, error)
+	// CreateWithQuote creates the payment and marks the FX quote used in one
+	// transaction; the quote must be unused and unexpired at now
+	CreateWithQuote(ctx context.Context, payment *domain.Payment, now time.Time) error
+	// SetSettlementRate records the rate and ETB amount of an unquoted foreign currency payment
+	SetSettlementRate(ctx context.Context, id uuid.UUID, rate float64, amountETB domain.Amount) error
+	// SetProviderCheckout records the provider's transaction reference and,
+	// for hosted checkouts, the page the customer pays on
+	SetProviderCheckout(ctx context.Context, id uuid.UUID, providerReference, checkoutURL string) error
+	// SetTags replaces the payment's tags
+	SetTags(ctx context.Context, id uuid.UUID, tags []string) error
+	// CountsBetween aggregates payments created in [from, to) by currency and
+	// status; a nil merchantID counts every merchant's
+	CountsBetween(ctx context.Context, from, to time.Time, merchantID *uuid.UUID) ([]domain.DashboardCounts, error)
+	// ListRecentByStatus returns payments that moved to status since, newest first
+	ListRecentByStatus(ctx context.Context, status domain.PaymentStatus, since time.Time, merchantID *uuid.UUID, limit int) ([]*domain.Payment, error)
 }
 
 type paymentRepository struct {
 	db     *pgxpool.Pool
+	reads  *readPools
 	logger *logrus.Logger
 }
 
-func NewPaymentRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentRepository {
-	return &paymentRepository{db: db, logger: logger}
+// NewPaymentRepository writes to db. Listings, searches and counts are
+// read from replicas when given and may lag db slightly; lookups by ID and
+// reference always read db, as they often follow a write or check a status
+// about to change.
+func NewPaymentRepository(db *pgxpool.Pool, replicas []*pgxpool.Pool, logger *logrus.Logger) PaymentRepository {
+	return &paymentRepository{db: db, reads: newReadPools(db, replicas), logger: logger}
 }
 
-func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
+// rowQuerier is satisfied by both the pool and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// insertPayment returns pgx.ErrNoRows when the reference is taken
+func insertPayment(ctx context.Context, q rowQuerier, payment *domain.Payment) error {
 	query := `
-		INSERT INTO payments (id, amount, currency, reference, status, description, customer_name, bank_code, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO payments (id, amount, currency, reference, status, description, customer_name, customer_phone, customer_email, customer_national_id, language, bank_code, purpose_code, mcc, limit_flag, client_ip, client_country, device_fingerprint, fx_quote_id, fx_rate, amount_etb, created_at, updated_at, tags, payment_method, merchant_id, customer_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''), NULLIF($17, ''), NULLIF($18, ''), $19, NULLIF($20, 0), NULLIF($21, 0), $22, $23, COALESCE($24::text[], '{}'), COALESCE(NULLIF($25, ''), 'BANK'), $26, $27)
 		ON CONFLICT (reference) DO NOTHING
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	return q.QueryRow(ctx, query,
 		payment.ID,
 		payment.Amount,
 		payment.Currency,
@@ -48,20 +108,55 @@ func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment)
 		payment.Status,
 		payment.Description,
 		payment.CustomerName,
+		payment.CustomerPhone,
+		payment.CustomerEmail,
+		payment.CustomerNationalID,
+		payment.Language,
 		payment.BankCode,
+		payment.PurposeCode,
+		payment.MCC,
+		payment.LimitFlag,
+		payment.ClientIP,
+		payment.ClientCountry,
+		payment.DeviceFingerprint,
+		payment.FXQuoteID,
+		payment.FXRate,
+		payment.AmountETB,
 		payment.CreatedAt,
 		payment.UpdatedAt,
+		payment.Tags,
+		payment.PaymentMethod,
+		payment.MerchantID,
+		payment.CustomerID,
 	).Scan(&payment.ID)
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
 
+	err = insertPayment(ctx, tx, payment)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return domain.ErrPaymentAlreadyExists
 	}
-
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to create payment")
 		return domain.ErrDatabase
 	}
 
+	if err := recordAudit(ctx, tx, r.logger, domain.AuditPaymentCreated, domain.AuditEntityPayment, payment.ID, nil, payment.ToResponse()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit payment")
+		return domain.ErrDatabase
+	}
+
 	r.logger.WithFields(logrus.Fields{
 		"payment_id": payment.ID,
 		"reference":  payment.Reference,
@@ -72,15 +167,110 @@ func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment)
 	return nil
 }
 
+func (r *paymentRepository) CreateWithQuote(ctx context.Context, payment *domain.Payment, now time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertPayment(ctx, tx, payment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPaymentAlreadyExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment")
+		return domain.ErrDatabase
+	}
+
+	result, err := tx.Exec(ctx,
+		"UPDATE fx_quotes SET payment_id = $1 WHERE id = $2 AND payment_id IS NULL AND expires_at > $3",
+		payment.ID, payment.FXQuoteID, now,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to use FX quote")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		// Lost a race with another payment or the expiry
+		return domain.ErrFXQuoteUsed
+	}
+
+	if err := recordAudit(ctx, tx, r.logger, domain.AuditPaymentCreated, domain.AuditEntityPayment, payment.ID, nil, payment.ToResponse()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit quoted payment")
+		return domain.ErrDatabase
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"payment_id":  payment.ID,
+		"reference":   payment.Reference,
+		"fx_quote_id": payment.FXQuoteID,
+		"fx_rate":     payment.FXRate,
+	}).Info("Payment created at quoted rate")
+
+	return nil
+}
+
+func (r *paymentRepository) SetSettlementRate(ctx context.Context, id uuid.UUID, rate float64, amountETB domain.Amount) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payments SET fx_rate = $1, amount_etb = $2 WHERE id = $3 AND fx_rate IS NULL",
+		rate, amountETB, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record payment settlement rate")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentRepository) SetProviderCheckout(ctx context.Context, id uuid.UUID, providerReference, checkoutURL string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payments SET provider_reference = NULLIF($1, ''), checkout_url = NULLIF($2, '') WHERE id = $3",
+		providerReference, checkoutURL, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record payment provider checkout")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	result, err := r.db.Exec(ctx,
+		"UPDATE payments SET tags = $1, updated_at = $2 WHERE id = $3",
+		tags, time.Now().UTC(), id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to set payment tags")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrPaymentNotFound
+	}
+
+	return nil
+}
+
 func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
 	query := `
-		SELECT id, amount, currency, reference, status, description, customer_name, bank_code, created_at, updated_at
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
 		FROM payments
 		WHERE id = $1
 	`
 
 	var payment domain.Payment
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
 		&payment.ID,
 		&payment.Amount,
 		&payment.Currency,
@@ -88,7 +278,27 @@ func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 		&payment.Status,
 		&payment.Description,
 		&payment.CustomerName,
+		&payment.CustomerPhone,
+		&payment.CustomerEmail,
+		&payment.CustomerNationalID,
+		&payment.Language,
 		&payment.BankCode,
+		&payment.PurposeCode,
+		&payment.MCC,
+		&payment.LimitFlag,
+		&payment.ClientIP,
+		&payment.ClientCountry,
+		&payment.DeviceFingerprint,
+		&payment.FXQuoteID,
+		&payment.FXRate,
+		&payment.AmountETB,
+		&payment.Tags,
+		&payment.ProviderReference,
+		&payment.CheckoutURL,
+		&payment.PaymentMethod,
+		&payment.RefundedAmount,
+		&payment.MerchantID,
+		&payment.CustomerID,
 		&payment.CreatedAt,
 		&payment.UpdatedAt,
 	)
@@ -107,13 +317,13 @@ func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 
 func (r *paymentRepository) GetByReference(ctx context.Context, reference string) (*domain.Payment, error) {
 	query := `
-		SELECT id, amount, currency, reference, status, description, customer_name, bank_code, created_at, updated_at
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
 		FROM payments
 		WHERE reference = $1
 	`
 
 	var payment domain.Payment
-	err := r.db.QueryRow(ctx, query, reference).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, reference).Scan(
 		&payment.ID,
 		&payment.Amount,
 		&payment.Currency,
@@ -121,7 +331,27 @@ func (r *paymentRepository) GetByReference(ctx context.Context, reference string
 		&payment.Status,
 		&payment.Description,
 		&payment.CustomerName,
+		&payment.CustomerPhone,
+		&payment.CustomerEmail,
+		&payment.CustomerNationalID,
+		&payment.Language,
 		&payment.BankCode,
+		&payment.PurposeCode,
+		&payment.MCC,
+		&payment.LimitFlag,
+		&payment.ClientIP,
+		&payment.ClientCountry,
+		&payment.DeviceFingerprint,
+		&payment.FXQuoteID,
+		&payment.FXRate,
+		&payment.AmountETB,
+		&payment.Tags,
+		&payment.ProviderReference,
+		&payment.CheckoutURL,
+		&payment.PaymentMethod,
+		&payment.RefundedAmount,
+		&payment.MerchantID,
+		&payment.CustomerID,
 		&payment.CreatedAt,
 		&payment.UpdatedAt,
 	)
@@ -139,29 +369,57 @@ func (r *paymentRepository) GetByReference(ctx context.Context, reference string
 }
 
 func (r *paymentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.PaymentStatus) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	// The subquery reads the status from before the update
 	query := `
-		UPDATE payments
+		UPDATE payments p
 		SET status = $1, updated_at = $2
-		WHERE id = $3
+		FROM (SELECT id, status FROM payments WHERE id = $3 FOR UPDATE) old
+		WHERE p.id = old.id
+		RETURNING old.status
 	`
 
-	result, err := r.db.Exec(ctx, query, status, time.Now().UTC(), id)
+	var previous domain.PaymentStatus
+	err = tx.QueryRow(ctx, query, status, time.Now().UTC(), id).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPaymentNotFound
+	}
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to update payment status")
 		return domain.ErrDatabase
 	}
 
-	if result.RowsAffected() == 0 {
-		return domain.ErrPaymentNotFound
+	if err := r.recordStatusChange(ctx, tx, id, previous, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit payment status")
+		return domain.ErrDatabase
 	}
 
 	return nil
 }
 
+// recordStatusChange audits a status change made in tx; a status written
+// again unchanged is not recorded
+func (r *paymentRepository) recordStatusChange(ctx context.Context, tx pgx.Tx, id uuid.UUID, from, to domain.PaymentStatus) error {
+	if from == to {
+		return nil
+	}
+	return recordAudit(ctx, tx, r.logger, domain.AuditPaymentStatusChanged, domain.AuditEntityPayment, id, statusState(from), statusState(to))
+}
+
 // Idempotent update - only updates if status is PENDING
 func (r *paymentRepository) UpdateStatusIfPending(ctx context.Context, id uuid.UUID, newStatus domain.PaymentStatus) (bool, error) {
 	// Start transaction for atomic update
-	tx, err := r.db.Begin(ctx)
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to begin transaction")
 		return false, domain.ErrDatabase
@@ -202,6 +460,10 @@ func (r *paymentRepository) UpdateStatusIfPending(ctx context.Context, id uuid.U
 		return false, domain.ErrDatabase
 	}
 
+	if err := r.recordStatusChange(ctx, tx, id, currentStatus, newStatus); err != nil {
+		return false, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		r.logger.WithError(err).Error("Failed to commit transaction")
 		return false, domain.ErrDatabase
@@ -210,21 +472,428 @@ func (r *paymentRepository) UpdateStatusIfPending(ctx context.Context, id uuid.U
 	return true, nil
 }
 
-func (r *paymentRepository) List(ctx context.Context, limit, offset int) ([]*domain.Payment, error) {
+func (r *paymentRepository) TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.PaymentStatus) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return false, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx,
+		"UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4",
+		to, time.Now().UTC(), id, from,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to transition payment status")
+		return false, domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := r.recordStatusChange(ctx, tx, id, from, to); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit payment status")
+		return false, domain.ErrDatabase
+	}
+
+	return true, nil
+}
+
+func (r *paymentRepository) RequeueFailed(ctx context.Context, id uuid.UUID, bankCode string) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return false, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx,
+		"UPDATE payments SET status = $1, bank_code = COALESCE(NULLIF($2, ''), bank_code), provider_reference = NULL, checkout_url = NULL, updated_at = $3 WHERE id = $4 AND status = $5",
+		domain.StatusPending, bankCode, time.Now().UTC(), id, domain.StatusFailed,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to requeue payment")
+		return false, domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := r.recordStatusChange(ctx, tx, id, domain.StatusFailed, domain.StatusPending); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit payment requeue")
+		return false, domain.ErrDatabase
+	}
+
+	return true, nil
+}
+
+const paymentFilterClause = `
+		WHERE ($1::text = '' OR purpose_code = $1)
+		  AND ($2::text = '' OR mcc = $2)
+		  AND (NOT $3::boolean OR limit_flag IS NOT NULL)
+		  AND (cardinality($4::text[]) = 0 OR tags @> $4::text[])
+		  AND ($5::uuid IS NULL OR merchant_id = $5)
+		  AND ($6::text = '' OR status = $6)
+		  AND ($7::text = '' OR currency = $7)
+		  AND ($8::text = '' OR bank_code = $8)
+		  AND ($9::numeric IS NULL OR amount >= $9)
+		  AND ($10::numeric IS NULL OR amount <= $10)
+		  AND ($11::text = '' OR created_at >= $11::timestamptz)
+		  AND ($12::text = '' OR created_at < $12::timestamptz)
+`
+
+func paymentFilterArgs(filter domain.PaymentFilter) []any {
+	tags := filter.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return []any{filter.PurposeCode, filter.MCC, filter.LimitFlagged, tags, filter.MerchantID,
+		filter.Status, filter.Currency, filter.BankCode, amountBound(filter.MinAmount), amountBound(filter.MaxAmount), filter.CreatedFrom, filter.CreatedTo}
+}
+
+func (r *paymentRepository) List(ctx context.Context, filter domain.PaymentFilter, cursor *domain.PaymentCursor, limit, offset int) ([]*domain.Payment, error) {
+	// Keyset paging compares (created_at, id) in the direction of the sort
+	after, order := "<", "created_at DESC, id DESC"
+	switch filter.Sort {
+	case domain.SortOldest:
+		after, order = ">", "created_at, id"
+	case domain.SortLargest:
+		order = "amount DESC, created_at DESC, id DESC"
+	case domain.SortSmallest:
+		order = "amount, created_at DESC, id DESC"
+	}
+
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments` + paymentFilterClause + `
+		  AND ($13::timestamptz IS NULL OR (created_at, id) ` + after + ` ($13, $14::uuid))
+		ORDER BY ` + order + `
+		LIMIT $15 OFFSET $16
+	`
+
+	var afterAt *time.Time
+	var afterID *uuid.UUID
+	if cursor != nil {
+		afterAt, afterID, offset = &cursor.CreatedAt, &cursor.ID, 0
+	}
+
+	rows, err := r.reads.reader(ctx).Query(ctx, query, append(paymentFilterArgs(filter), afterAt, afterID, limit, offset)...)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+// amountBound is a filter bound for SQL, NULL when unset
+func amountBound(a domain.Amount) *domain.Amount {
+	if a == 0 {
+		return nil
+	}
+	return &a
+}
+
+func (r *paymentRepository) Search(ctx context.Context, terms string, merchantID *uuid.UUID, limit, offset int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments
+		WHERE ($2::uuid IS NULL OR merchant_id = $2)
+		  AND (search_vector @@ plainto_tsquery('simple', $1)
+		    OR reference ILIKE $3
+		    OR customer_name ILIKE $3
+		    OR description ILIKE $3)
+		ORDER BY upper(reference) = upper($1) DESC,
+		         GREATEST(similarity(reference, $1), similarity(COALESCE(customer_name, ''), $1), ts_rank(search_vector, plainto_tsquery('simple', $1))) DESC,
+		         created_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.reads.reader(ctx).Query(ctx, query, terms, merchantID, "%"+escapeLike(terms)+"%", limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to search payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+// escapeLike makes LIKE treat % and _ in s as literal characters
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *paymentRepository) Totals(ctx context.Context, filter domain.PaymentFilter) ([]domain.PaymentTotal, error) {
+	query := `
+		SELECT currency, status, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM payments` + paymentFilterClause + `
+		GROUP BY currency, status
+		ORDER BY currency, status
+	`
+
+	rows, err := r.reads.reader(ctx).Query(ctx, query, paymentFilterArgs(filter)...)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to total payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var totals []domain.PaymentTotal
+	for rows.Next() {
+		var t domain.PaymentTotal
+		if err := rows.Scan(&t.Currency, &t.Status, &t.Count, &t.Amount); err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment totals")
+			return nil, domain.ErrDatabase
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *paymentRepository) ListCreatedBetween(ctx context.Context, from, to time.Time) ([]*domain.Payment, error) {
 	query := `
-		SELECT id, amount, currency, reference, status, description, customer_name, bank_code, created_at, updated_at
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
 		FROM payments
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payments by date range")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+func (r *paymentRepository) ListStale(ctx context.Context, status domain.PaymentStatus, updatedBefore time.Time, limit int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, updatedBefore, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list stale payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+func (r *paymentRepository) ListRecentByStatus(ctx context.Context, status domain.PaymentStatus, since time.Time, merchantID *uuid.UUID, limit int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments
+		WHERE status = $1 AND updated_at >= $2 AND ($4::uuid IS NULL OR merchant_id = $4)
+		ORDER BY updated_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, since, limit, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list recent payments by status")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+func (r *paymentRepository) CountsBetween(ctx context.Context, from, to time.Time, merchantID *uuid.UUID) ([]domain.DashboardCounts, error) {
+	query := `
+		SELECT currency, status, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM payments
+		WHERE created_at >= $1 AND created_at < $2 AND ($3::uuid IS NULL OR merchant_id = $3)
+		GROUP BY currency, status
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to aggregate payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var counts []domain.DashboardCounts
+	for rows.Next() {
+		var c domain.DashboardCounts
+		if err := rows.Scan(&c.Currency, &c.Status, &c.Count, &c.Amount); err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment aggregate")
+			return nil, domain.ErrDatabase
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *paymentRepository) ListByCustomerPhone(ctx context.Context, phone string, merchantID *uuid.UUID, limit, offset int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments
+		WHERE customer_phone = $1 AND ($4::uuid IS NULL OR merchant_id = $4)
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
+	rows, err := r.db.Query(ctx, query, phone, limit, offset, merchantID)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to list payments")
+		r.logger.WithError(err).Error("Failed to list payments by customer phone")
 		return nil, domain.ErrDatabase
 	}
 	defer rows.Close()
 
+	return scanPayments(rows)
+}
+
+func (r *paymentRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*domain.Payment, error) {
+	query := `
+		SELECT id, amount, currency, reference, status, description, customer_name, COALESCE(customer_phone, ''), COALESCE(customer_email, ''), COALESCE(customer_national_id, ''), COALESCE(language, ''), bank_code, COALESCE(purpose_code, ''), COALESCE(mcc, ''), COALESCE(limit_flag, ''), COALESCE(client_ip, ''), COALESCE(client_country, ''), COALESCE(device_fingerprint, ''), fx_quote_id, COALESCE(fx_rate, 0), COALESCE(amount_etb, 0), tags, COALESCE(provider_reference, ''), COALESCE(checkout_url, ''), payment_method, refunded_amount, merchant_id, customer_id, created_at, updated_at
+		FROM payments
+		WHERE customer_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, customerID, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payments by customer")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+func (r *paymentRepository) Count(ctx context.Context, filter domain.PaymentFilter) (int, error) {
+	var count int
+	err := r.reads.reader(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM payments"+paymentFilterClause, paymentFilterArgs(filter)...).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count payments")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
+
+func (r *paymentRepository) CountByCustomerPhone(ctx context.Context, phone string, merchantID *uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM payments WHERE customer_phone = $1 AND ($2::uuid IS NULL OR merchant_id = $2)`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, phone, merchantID).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count payments by customer phone")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
+
+func (r *paymentRepository) CountByClientIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM payments WHERE client_ip = $1 AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, ip, since).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count payments by client IP")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
+
+func (r *paymentRepository) CustomerVolume(ctx context.Context, phone, nationalID string, dayStart, monthStart time.Time, rates map[domain.Currency]float64) (domain.CustomerUsage, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(` + etbValue + `) FILTER (WHERE p.created_at >= $5), 0),
+			COALESCE(SUM(` + etbValue + `), 0)
+		FROM payments p
+		` + fxJoin + `
+		WHERE (($3::text <> '' AND p.customer_phone = $3) OR ($4::text <> '' AND p.customer_national_id = $4))
+		  AND p.created_at >= $6
+		  AND p.status IN ('PENDING', 'AWAITING_OTP', 'AWAITING_CASH', 'PROCESSING', 'SUCCESS', 'UNDER_REVIEW')
+	`
+
+	currencies, values := rateArrays(rates)
+
+	var usage domain.CustomerUsage
+	err := conn(ctx, r.db).QueryRow(ctx, query, currencies, values, phone, nationalID, dayStart, monthStart).Scan(&usage.DayETB, &usage.MonthETB)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to sum customer volume")
+		return domain.CustomerUsage{}, domain.ErrDatabase
+	}
+
+	return usage, nil
+}
+
+func (r *paymentRepository) CustomerVelocity(ctx context.Context, phone, nationalID, bankCode string, since time.Time, rates map[domain.Currency]float64) (domain.VelocityUsage, error) {
+	query := `
+		SELECT COUNT(*),
+			COALESCE(SUM(` + etbValue + `) FILTER (WHERE p.status NOT IN ('FAILED', 'EXPIRED')), 0)
+		FROM payments p
+		` + fxJoin + `
+		WHERE (($3::text <> '' AND p.customer_phone = $3) OR ($4::text <> '' AND p.customer_national_id = $4))
+		  AND p.created_at >= $5
+		  AND ($6::text = '' OR p.bank_code = $6)
+		  AND p.status <> 'CANCELLED'
+	`
+
+	currencies, values := rateArrays(rates)
+
+	var usage domain.VelocityUsage
+	err := conn(ctx, r.db).QueryRow(ctx, query, currencies, values, phone, nationalID, since, bankCode).Scan(&usage.Payments, &usage.AmountETB)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get customer velocity")
+		return domain.VelocityUsage{}, domain.ErrDatabase
+	}
+
+	return usage, nil
+}
+
+func (r *paymentRepository) LockCustomer(ctx context.Context, phone, nationalID string) error {
+	if !InTx(ctx) {
+		// A transaction-scoped lock taken outside one is released at once
+		r.logger.Error("Customer lock taken outside a transaction")
+		return domain.ErrDatabase
+	}
+
+	// Always phone before national ID, so two payments never wait on each other
+	var keys []string
+	if phone != "" {
+		keys = append(keys, "phone:"+phone)
+	}
+	if nationalID != "" {
+		keys = append(keys, "national_id:"+nationalID)
+	}
+	for _, key := range keys {
+		if _, err := conn(ctx, r.db).Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, "customer_limit:"+key); err != nil {
+			r.logger.WithError(err).Error("Failed to lock customer")
+			return domain.ErrDatabase
+		}
+	}
+	return nil
+}
+
+func scanPayments(rows pgx.Rows) ([]*domain.Payment, error) {
 	var payments []*domain.Payment
 	for rows.Next() {
 		var payment domain.Payment
@@ -236,7 +905,27 @@ func (r *paymentRepository) List(ctx context.Context, limit, offset int) ([]*dom
 			&payment.Status,
 			&payment.Description,
 			&payment.CustomerName,
+			&payment.CustomerPhone,
+			&payment.CustomerEmail,
+			&payment.CustomerNationalID,
+			&payment.Language,
 			&payment.BankCode,
+			&payment.PurposeCode,
+			&payment.MCC,
+			&payment.LimitFlag,
+			&payment.ClientIP,
+			&payment.ClientCountry,
+			&payment.DeviceFingerprint,
+			&payment.FXQuoteID,
+			&payment.FXRate,
+			&payment.AmountETB,
+			&payment.Tags,
+			&payment.ProviderReference,
+			&payment.CheckoutURL,
+			&payment.PaymentMethod,
+			&payment.RefundedAmount,
+			&payment.MerchantID,
+			&payment.CustomerID,
 			&payment.CreatedAt,
 			&payment.UpdatedAt,
 		)
@@ -246,18 +935,5 @@ func (r *paymentRepository) List(ctx context.Context, limit, offset int) ([]*dom
 		payments = append(payments, &payment)
 	}
 
-	return payments, nil
-}
-
-func (r *paymentRepository) Count(ctx context.Context) (int, error) {
-	query := `SELECT COUNT(*) FROM payments`
-
-	var count int
-	err := r.db.QueryRow(ctx, query).Scan(&count)
-	if err != nil {
-		r.logger.WithError(err).Error("Failed to count payments")
-		return 0, domain.ErrDatabase
-	}
-
-	return count, nil
+	return payments, rows.Err()
 }
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentLinkRepository interface {
+	// Create returns false, saving nothing, when the code is already taken
+	Create(ctx context.Context, link *domain.PaymentLink) (bool, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentLink, error)
+	GetByCode(ctx context.Context, code string) (*domain.PaymentLink, error)
+	Deactivate(ctx context.Context, id uuid.UUID) error
+	// Claim counts a use of the link; false if it is inactive, expired or
+	// a one-time link already used
+	Claim(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// Release gives back a use claimed for a payment that was not created
+	Release(ctx context.Context, id uuid.UUID) error
+	AddPayment(ctx context.Context, linkID, paymentID uuid.UUID) error
+	ListPayments(ctx context.Context, linkID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type paymentLinkRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPaymentLinkRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentLinkRepository {
+	return &paymentLinkRepository{db: db, logger: logger}
+}
+
+func (r *paymentLinkRepository) Create(ctx context.Context, link *domain.PaymentLink) (bool, error) {
+	query := `
+		INSERT INTO payment_links (id, code, amount, currency, description, language, one_time, expires_at, active, merchant_id, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11)
+		ON CONFLICT (code) DO NOTHING
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		link.ID,
+		link.Code,
+		link.Amount,
+		link.Currency,
+		link.Description,
+		link.Language,
+		link.OneTime,
+		link.ExpiresAt,
+		link.Active,
+		link.MerchantID,
+		link.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment link")
+		return false, domain.ErrDatabase
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+const paymentLinkColumns = `id, code, amount, currency, COALESCE(description, ''), COALESCE(language, ''), one_time, expires_at, uses, active, merchant_id, created_at`
+
+func (r *paymentLinkRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PaymentLink, error) {
+	return r.get(ctx, "SELECT "+paymentLinkColumns+" FROM payment_links WHERE id = $1", id)
+}
+
+func (r *paymentLinkRepository) GetByCode(ctx context.Context, code string) (*domain.PaymentLink, error) {
+	return r.get(ctx, "SELECT "+paymentLinkColumns+" FROM payment_links WHERE code = $1", code)
+}
+
+func (r *paymentLinkRepository) get(ctx context.Context, query string, arg any) (*domain.PaymentLink, error) {
+	var link domain.PaymentLink
+	err := r.db.QueryRow(ctx, query, arg).Scan(
+		&link.ID,
+		&link.Code,
+		&link.Amount,
+		&link.Currency,
+		&link.Description,
+		&link.Language,
+		&link.OneTime,
+		&link.ExpiresAt,
+		&link.Uses,
+		&link.Active,
+		&link.MerchantID,
+		&link.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrPaymentLinkNotFound
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get payment link")
+		return nil, domain.ErrDatabase
+	}
+
+	return &link, nil
+}
+
+func (r *paymentLinkRepository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, "UPDATE payment_links SET active = FALSE WHERE id = $1", id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to deactivate payment link")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrPaymentLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *paymentLinkRepository) Claim(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	query := `
+		UPDATE payment_links
+		SET uses = uses + 1
+		WHERE id = $1
+		  AND active
+		  AND (expires_at IS NULL OR expires_at > $2)
+		  AND (NOT one_time OR uses = 0)
+	`
+
+	result, err := r.db.Exec(ctx, query, id, now)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim payment link")
+		return false, domain.ErrDatabase
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *paymentLinkRepository) Release(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, "UPDATE payment_links SET uses = uses - 1 WHERE id = $1 AND uses > 0", id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to release payment link")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentLinkRepository) AddPayment(ctx context.Context, linkID, paymentID uuid.UUID) error {
+	_, err := r.db.Exec(ctx,
+		"INSERT INTO payment_link_payments (link_id, payment_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		linkID, paymentID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record payment link payment")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentLinkRepository) ListPayments(ctx context.Context, linkID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT payment_id FROM payment_link_payments WHERE link_id = $1 ORDER BY created_at DESC",
+		linkID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payment link payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment link payment")
+			return nil, domain.ErrDatabase
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// PaymentReviewRepository keeps the compliance review queue of payments
+// held UNDER_REVIEW after AML screening
+type PaymentReviewRepository interface {
+	Create(ctx context.Context, review *domain.PaymentReview) error
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.PaymentReview, error)
+	// List returns reviews in a status, or all for "", oldest first
+	List(ctx context.Context, status domain.PaymentReviewStatus, limit, offset int) ([]*domain.PaymentReview, error)
+	// Decide records the decision and moves the payment from UNDER_REVIEW
+	// to paymentStatus in one transaction; ErrReviewAlreadyDecided if the
+	// review was no longer open
+	Decide(ctx context.Context, review *domain.PaymentReview, paymentStatus domain.PaymentStatus) error
+}
+
+type paymentReviewRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPaymentReviewRepository(db *pgxpool.Pool, logger *logrus.Logger) PaymentReviewRepository {
+	return &paymentReviewRepository{db: db, logger: logger}
+}
+
+const paymentReviewColumns = `id, payment_id, customer_name, hits, reason, release_status, status,
+	COALESCE(decision_note, ''), COALESCE(decided_by, ''), decided_at, created_at`
+
+func (r *paymentReviewRepository) Create(ctx context.Context, review *domain.PaymentReview) error {
+	hits, err := json.Marshal(review.Hits)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to encode screening hits")
+		return domain.ErrDatabase
+	}
+
+	_, err = conn(ctx, r.db).Exec(ctx, `
+		INSERT INTO payment_reviews (id, payment_id, customer_name, hits, reason, release_status, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		review.ID,
+		review.PaymentID,
+		review.CustomerName,
+		hits,
+		review.Reason,
+		review.ReleaseStatus,
+		review.Status,
+		review.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment review")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *paymentReviewRepository) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.PaymentReview, error) {
+	review, err := scanPaymentReview(conn(ctx, r.db).QueryRow(ctx,
+		"SELECT "+paymentReviewColumns+" FROM payment_reviews WHERE payment_id = $1", paymentID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrPaymentReviewNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get payment review")
+		return nil, domain.ErrDatabase
+	}
+
+	return review, nil
+}
+
+func (r *paymentReviewRepository) List(ctx context.Context, status domain.PaymentReviewStatus, limit, offset int) ([]*domain.PaymentReview, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+paymentReviewColumns+`
+		FROM payment_reviews
+		WHERE ($1::text = '' OR status = $1)
+		ORDER BY created_at
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payment reviews")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var reviews []*domain.PaymentReview
+	for rows.Next() {
+		review, err := scanPaymentReview(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan payment review")
+			return nil, domain.ErrDatabase
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+func (r *paymentReviewRepository) Decide(ctx context.Context, review *domain.PaymentReview, paymentStatus domain.PaymentStatus) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `
+			UPDATE payment_reviews
+			SET status = $1, decision_note = $2, decided_by = $3, decided_at = $4
+			WHERE id = $5 AND status = $6
+		`, review.Status, review.DecisionNote, review.DecidedBy, review.DecidedAt, review.ID, domain.ReviewOpen)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to record review decision")
+			return domain.ErrDatabase
+		}
+		if result.RowsAffected() == 0 {
+			return domain.ErrReviewAlreadyDecided
+		}
+
+		result, err = tx.Exec(ctx,
+			"UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4",
+			paymentStatus, review.DecidedAt, review.PaymentID, domain.StatusUnderReview,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to release reviewed payment")
+			return domain.ErrDatabase
+		}
+		if result.RowsAffected() == 0 {
+			// Settled by an operator override while under review
+			return domain.ErrReviewAlreadyDecided
+		}
+
+		return recordAudit(ctx, tx, r.logger, domain.AuditPaymentReviewed, domain.AuditEntityPayment, review.PaymentID,
+			statusState(domain.StatusUnderReview),
+			map[string]any{"status": paymentStatus, "review": review.Status, "note": review.DecisionNote},
+		)
+	})
+}
+
+func scanPaymentReview(row pgx.Row) (*domain.PaymentReview, error) {
+	var review domain.PaymentReview
+	var hits []byte
+	err := row.Scan(
+		&review.ID,
+		&review.PaymentID,
+		&review.CustomerName,
+		&hits,
+		&review.Reason,
+		&review.ReleaseStatus,
+		&review.Status,
+		&review.DecisionNote,
+		&review.DecidedBy,
+		&review.DecidedAt,
+		&review.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(hits, &review.Hits); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type BulkPayoutRepository interface {
+	// Create stores the job and all its rows in one transaction
+	Create(ctx context.Context, job *domain.BulkPayoutJob, payouts []*domain.Payout) error
+	// GetByID returns the job with row counts derived from its payouts; a
+	// merchant only finds its own jobs, nil finds any
+	GetByID(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error)
+	ListPayouts(ctx context.Context, jobID uuid.UUID) ([]*domain.Payout, error)
+	// ClaimNext leases the oldest queued job, or a job whose lease expired; nil if none
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*domain.BulkPayoutJob, error)
+	ListPendingPayouts(ctx context.Context, jobID uuid.UUID) ([]*domain.Payout, error)
+	FinishPayout(ctx context.Context, id uuid.UUID, status domain.PayoutStatus, failureReason string) error
+	Complete(ctx context.Context, jobID uuid.UUID, completedAt time.Time) error
+	SetWebhookStatus(ctx context.Context, jobID uuid.UUID, status string) error
+	// CallbackSecret is left out of GetByID; "" if the job has none
+	CallbackSecret(ctx context.Context, jobID uuid.UUID) (string, error)
+}
+
+type bulkPayoutRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewBulkPayoutRepository(db *pgxpool.Pool, logger *logrus.Logger) BulkPayoutRepository {
+	return &bulkPayoutRepository{db: db, logger: logger}
+}
+
+func (r *bulkPayoutRepository) Create(ctx context.Context, job *domain.BulkPayoutJob, payouts []*domain.Payout) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO bulk_payout_jobs (id, file_name, status, total_rows, merchant_id, callback_url, callback_secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+	`, job.ID, job.FileName, job.Status, job.TotalRows, job.MerchantID, job.CallbackURL, job.CallbackSecret, job.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create bulk payout job")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"payouts"},
+		[]string{"id", "job_id", "row_number", "bank_code", "account_number", "amount", "currency", "reason", "status", "failure_reason", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(payouts), func(i int) ([]interface{}, error) {
+			p := payouts[i]
+			return []interface{}{p.ID, p.JobID, p.RowNumber, p.BankCode, p.AccountNumber, p.Amount, p.Currency, p.Reason, p.Status, nullIfEmpty(p.FailureReason), p.CreatedAt, p.UpdatedAt}, nil
+		}),
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to store bulk payout rows")
+		return domain.ErrDatabase
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *bulkPayoutRepository) GetByID(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error) {
+	query := `
+		SELECT j.id, j.file_name, j.status, j.total_rows, j.merchant_id, COALESCE(j.callback_url, ''), COALESCE(j.webhook_status, ''),
+			COUNT(p.id) FILTER (WHERE p.status = 'REJECTED'),
+			COUNT(p.id) FILTER (WHERE p.status = 'PENDING'),
+			COUNT(p.id) FILTER (WHERE p.status = 'SUCCESS'),
+			COUNT(p.id) FILTER (WHERE p.status = 'FAILED'),
+			COALESCE(SUM(p.amount) FILTER (WHERE p.status <> 'REJECTED'), 0),
+			j.created_at, j.completed_at
+		FROM bulk_payout_jobs j
+		LEFT JOIN payouts p ON p.job_id = j.id
+		WHERE j.id = $1 AND ($2::uuid IS NULL OR j.merchant_id = $2)
+		GROUP BY j.id
+	`
+
+	var job domain.BulkPayoutJob
+	err := r.db.QueryRow(ctx, query, id, merchantID).Scan(
+		&job.ID,
+		&job.FileName,
+		&job.Status,
+		&job.TotalRows,
+		&job.MerchantID,
+		&job.CallbackURL,
+		&job.WebhookStatus,
+		&job.RejectedRows,
+		&job.PendingRows,
+		&job.SucceededRows,
+		&job.FailedRows,
+		&job.TotalAmount,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrBulkJobNotFound
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get bulk payout job")
+		return nil, domain.ErrDatabase
+	}
+
+	return &job, nil
+}
+
+func (r *bulkPayoutRepository) ListPayouts(ctx context.Context, jobID uuid.UUID) ([]*domain.Payout, error) {
+	return r.listPayouts(ctx, "WHERE job_id = $1", jobID)
+}
+
+func (r *bulkPayoutRepository) ListPendingPayouts(ctx context.Context, jobID uuid.UUID) ([]*domain.Payout, error) {
+	return r.listPayouts(ctx, "WHERE job_id = $1 AND status = 'PENDING'", jobID)
+}
+
+func (r *bulkPayoutRepository) listPayouts(ctx context.Context, where string, jobID uuid.UUID) ([]*domain.Payout, error) {
+	query := `
+		SELECT id, job_id, COALESCE(row_number, 0), bank_code, account_number, amount, currency, reason, status,
+			COALESCE(failure_reason, ''), created_at, updated_at
+		FROM payouts
+		` + where + `
+		ORDER BY row_number
+	`
+
+	rows, err := r.db.Query(ctx, query, jobID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list payouts")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var payouts []*domain.Payout
+	for rows.Next() {
+		var p domain.Payout
+		err := rows.Scan(
+			&p.ID,
+			&p.JobID,
+			&p.RowNumber,
+			&p.BankCode,
+			&p.AccountNumber,
+			&p.Amount,
+			&p.Currency,
+			&p.Reason,
+			&p.Status,
+			&p.FailureReason,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, &p)
+	}
+
+	return payouts, rows.Err()
+}
+
+func (r *bulkPayoutRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*domain.BulkPayoutJob, error) {
+	query := `
+		UPDATE bulk_payout_jobs
+		SET status = 'PROCESSING', claimed_until = $2
+		WHERE id = (
+			SELECT id FROM bulk_payout_jobs
+			WHERE status = 'QUEUED' OR (status = 'PROCESSING' AND claimed_until < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, file_name, status, total_rows, merchant_id, COALESCE(callback_url, ''), COALESCE(callback_secret, ''), created_at
+	`
+
+	var job domain.BulkPayoutJob
+	err := r.db.QueryRow(ctx, query, now, now.Add(lease)).Scan(
+		&job.ID,
+		&job.FileName,
+		&job.Status,
+		&job.TotalRows,
+		&job.MerchantID,
+		&job.CallbackURL,
+		&job.CallbackSecret,
+		&job.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim bulk payout job")
+		return nil, domain.ErrDatabase
+	}
+
+	return &job, nil
+}
+
+func (r *bulkPayoutRepository) FinishPayout(ctx context.Context, id uuid.UUID, status domain.PayoutStatus, failureReason string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payouts SET status = $1, failure_reason = NULLIF($2, '') WHERE id = $3 AND status = 'PENDING'",
+		status, failureReason, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update payout")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *bulkPayoutRepository) Complete(ctx context.Context, jobID uuid.UUID, completedAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE bulk_payout_jobs SET status = 'COMPLETED', completed_at = $1, claimed_until = NULL WHERE id = $2",
+		completedAt, jobID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to complete bulk payout job")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *bulkPayoutRepository) SetWebhookStatus(ctx context.Context, jobID uuid.UUID, status string) error {
+	_, err := r.db.Exec(ctx, "UPDATE bulk_payout_jobs SET webhook_status = $1 WHERE id = $2", status, jobID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record bulk payout webhook status")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *bulkPayoutRepository) CallbackSecret(ctx context.Context, jobID uuid.UUID) (string, error) {
+	var secret string
+	err := r.db.QueryRow(ctx, "SELECT COALESCE(callback_secret, '') FROM bulk_payout_jobs WHERE id = $1", jobID).Scan(&secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrBulkJobNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get bulk payout callback secret")
+		return "", domain.ErrDatabase
+	}
+
+	return secret, nil
+}
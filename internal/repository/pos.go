@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type POSRepository interface {
+	// Create stores the payment and its card transaction together
+	Create(ctx context.Context, payment *domain.Payment, txn *domain.CardTransaction) error
+	GetByTerminalRRN(ctx context.Context, terminalID, rrn string) (*domain.CardTransaction, error)
+}
+
+type posRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPOSRepository(db *pgxpool.Pool, logger *logrus.Logger) POSRepository {
+	return &posRepository{db: db, logger: logger}
+}
+
+func (r *posRepository) Create(ctx context.Context, payment *domain.Payment, txn *domain.CardTransaction) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	err = insertPayment(ctx, tx, payment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPaymentAlreadyExists
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create card payment")
+		return domain.ErrDatabase
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO pos_transactions (id, payment_id, terminal_id, merchant_id, stan, rrn, masked_pan, entry_mode, acceptor_name, approval_code, response_code, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12)
+		ON CONFLICT (terminal_id, rrn) DO NOTHING
+		RETURNING id
+	`,
+		txn.ID,
+		txn.PaymentID,
+		txn.TerminalID,
+		txn.MerchantID,
+		txn.STAN,
+		txn.RRN,
+		txn.MaskedPAN,
+		txn.EntryMode,
+		txn.AcceptorName,
+		txn.ApprovalCode,
+		txn.ResponseCode,
+		txn.CreatedAt,
+	).Scan(&txn.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrCardTxnDuplicate
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record card transaction")
+		return domain.ErrDatabase
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit card payment")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *posRepository) GetByTerminalRRN(ctx context.Context, terminalID, rrn string) (*domain.CardTransaction, error) {
+	query := `
+		SELECT t.id, t.payment_id, t.terminal_id, t.merchant_id, t.stan, t.rrn, t.masked_pan, COALESCE(t.entry_mode, ''), COALESCE(t.acceptor_name, ''),
+		       p.amount, p.currency, COALESCE(p.mcc, ''), t.approval_code, t.response_code, t.created_at
+		FROM pos_transactions t
+		JOIN payments p ON p.id = t.payment_id
+		WHERE t.terminal_id = $1 AND t.rrn = $2
+	`
+
+	var txn domain.CardTransaction
+	err := r.db.QueryRow(ctx, query, terminalID, rrn).Scan(
+		&txn.ID,
+		&txn.PaymentID,
+		&txn.TerminalID,
+		&txn.MerchantID,
+		&txn.STAN,
+		&txn.RRN,
+		&txn.MaskedPAN,
+		&txn.EntryMode,
+		&txn.AcceptorName,
+		&txn.Amount,
+		&txn.Currency,
+		&txn.MCC,
+		&txn.ApprovalCode,
+		&txn.ResponseCode,
+		&txn.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrCardTxnNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get card transaction")
+		return nil, domain.ErrDatabase
+	}
+
+	return &txn, nil
+}
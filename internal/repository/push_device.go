@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type PushDeviceRepository interface {
+	// Register saves the device; re-registering a token moves it to the new user
+	Register(ctx context.Context, device *domain.PushDevice) error
+	ListByMerchant(ctx context.Context, merchantID string) ([]*domain.PushDevice, error)
+	// Delete removes a device; with a merchant ID only that merchant's device
+	Delete(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type pushDeviceRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewPushDeviceRepository(db *pgxpool.Pool, logger *logrus.Logger) PushDeviceRepository {
+	return &pushDeviceRepository{db: db, logger: logger}
+}
+
+func (r *pushDeviceRepository) Register(ctx context.Context, device *domain.PushDevice) error {
+	query := `
+		INSERT INTO push_devices (id, merchant_id, user_id, token, platform, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (token)
+		DO UPDATE SET merchant_id = EXCLUDED.merchant_id, user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform, last_seen_at = EXCLUDED.last_seen_at
+		RETURNING id, created_at, last_seen_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		device.ID,
+		device.MerchantID,
+		device.UserID,
+		device.Token,
+		device.Platform,
+		device.CreatedAt,
+	).Scan(&device.ID, &device.CreatedAt, &device.LastSeenAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to register push device")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *pushDeviceRepository) ListByMerchant(ctx context.Context, merchantID string) ([]*domain.PushDevice, error) {
+	query := `
+		SELECT id, merchant_id, user_id, token, platform, created_at, last_seen_at
+		FROM push_devices
+		WHERE merchant_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list push devices")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var devices []*domain.PushDevice
+	for rows.Next() {
+		var device domain.PushDevice
+		err := rows.Scan(
+			&device.ID,
+			&device.MerchantID,
+			&device.UserID,
+			&device.Token,
+			&device.Platform,
+			&device.CreatedAt,
+			&device.LastSeenAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, &device)
+	}
+
+	return devices, rows.Err()
+}
+
+func (r *pushDeviceRepository) Delete(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM push_devices WHERE id = $1 AND ($2::text IS NULL OR merchant_id = $2)", id, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete push device")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrPushDeviceNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type ReceiptLinkRepository interface {
+	// Upsert issues the link, replacing the nonce and clearing any revocation
+	Upsert(ctx context.Context, link *domain.ReceiptLink) error
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.ReceiptLink, error)
+	Revoke(ctx context.Context, paymentID uuid.UUID) error
+}
+
+type receiptLinkRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewReceiptLinkRepository(db *pgxpool.Pool, logger *logrus.Logger) ReceiptLinkRepository {
+	return &receiptLinkRepository{db: db, logger: logger}
+}
+
+func (r *receiptLinkRepository) Upsert(ctx context.Context, link *domain.ReceiptLink) error {
+	query := `
+		INSERT INTO receipt_links (payment_id, nonce, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (payment_id)
+		DO UPDATE SET nonce = EXCLUDED.nonce, created_at = EXCLUDED.created_at, revoked_at = NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, link.PaymentID, link.Nonce, link.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to save receipt link")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *receiptLinkRepository) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*domain.ReceiptLink, error) {
+	query := `
+		SELECT payment_id, nonce, created_at, revoked_at
+		FROM receipt_links
+		WHERE payment_id = $1
+	`
+
+	var link domain.ReceiptLink
+	err := r.db.QueryRow(ctx, query, paymentID).Scan(
+		&link.PaymentID,
+		&link.Nonce,
+		&link.CreatedAt,
+		&link.RevokedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReceiptLinkNotFound
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get receipt link")
+		return nil, domain.ErrDatabase
+	}
+
+	return &link, nil
+}
+
+func (r *receiptLinkRepository) Revoke(ctx context.Context, paymentID uuid.UUID) error {
+	result, err := r.db.Exec(ctx,
+		"UPDATE receipt_links SET revoked_at = $1 WHERE payment_id = $2 AND revoked_at IS NULL",
+		time.Now().UTC(), paymentID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to revoke receipt link")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrReceiptLinkNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type RefundRepository interface {
+	// Create fails with ErrRefundExceedsPayment when the refunds that have
+	// not failed would add up to more than the payment
+	Create(ctx context.Context, refund *domain.Refund) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Refund, error)
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Refund, error)
+	// TransitionStatus moves the refund only if it is still in from. A
+	// refund moved to FAILED is taken off the payment's refunded_amount.
+	TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.RefundStatus, failureReason string) (bool, error)
+	SetProviderReference(ctx context.Context, id uuid.UUID, reference string) error
+}
+
+type refundRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewRefundRepository(db *pgxpool.Pool, logger *logrus.Logger) RefundRepository {
+	return &refundRepository{db: db, logger: logger}
+}
+
+// Create adds the refund to the payment's refunded_amount in the same
+// transaction, so concurrent refunds cannot add up to more than the payment
+func (r *refundRepository) Create(ctx context.Context, refund *domain.Refund) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx,
+		"UPDATE payments SET refunded_amount = refunded_amount + $1 WHERE id = $2 AND refunded_amount + $1 <= amount",
+		refund.Amount, refund.PaymentID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to reserve refund amount")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRefundExceedsPayment
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refunds (id, payment_id, amount, currency, reason, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		refund.ID,
+		refund.PaymentID,
+		refund.Amount,
+		refund.Currency,
+		refund.Reason,
+		refund.Status,
+		refund.CreatedAt,
+		refund.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create refund")
+		return domain.ErrDatabase
+	}
+
+	if err := recordAudit(ctx, tx, r.logger, domain.AuditRefundCreated, domain.AuditEntityRefund, refund.ID, nil, refund); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+const refundColumns = `id, payment_id, amount, currency, reason, status, COALESCE(failure_reason, ''), COALESCE(provider_reference, ''), created_at, updated_at`
+
+func scanRefund(row pgx.Row) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := row.Scan(
+		&refund.ID,
+		&refund.PaymentID,
+		&refund.Amount,
+		&refund.Currency,
+		&refund.Reason,
+		&refund.Status,
+		&refund.FailureReason,
+		&refund.ProviderReference,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+	return &refund, err
+}
+
+func (r *refundRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Refund, error) {
+	refund, err := scanRefund(r.db.QueryRow(ctx, "SELECT "+refundColumns+" FROM refunds WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRefundNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get refund")
+		return nil, domain.ErrDatabase
+	}
+
+	return refund, nil
+}
+
+func (r *refundRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*domain.Refund, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+refundColumns+" FROM refunds WHERE payment_id = $1 ORDER BY created_at", paymentID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list refunds")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var refunds []*domain.Refund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan refund")
+			return nil, domain.ErrDatabase
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+func (r *refundRepository) TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.RefundStatus, failureReason string) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return false, domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	var paymentID uuid.UUID
+	var amount domain.Amount
+	err = tx.QueryRow(ctx,
+		"UPDATE refunds SET status = $1, failure_reason = NULLIF($2, ''), updated_at = $3 WHERE id = $4 AND status = $5 RETURNING payment_id, amount",
+		to, failureReason, time.Now().UTC(), id, from,
+	).Scan(&paymentID, &amount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to transition refund status")
+		return false, domain.ErrDatabase
+	}
+
+	if to == domain.RefundFailed && from != domain.RefundFailed {
+		_, err = tx.Exec(ctx, "UPDATE payments SET refunded_amount = refunded_amount - $1 WHERE id = $2", amount, paymentID)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to release refund amount")
+			return false, domain.ErrDatabase
+		}
+	}
+
+	after := map[string]any{"status": to}
+	if failureReason != "" {
+		after["failure_reason"] = failureReason
+	}
+	if err := recordAudit(ctx, tx, r.logger, domain.AuditRefundStatusChanged, domain.AuditEntityRefund, id, statusState(from), after); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return false, domain.ErrDatabase
+	}
+
+	return true, nil
+}
+
+func (r *refundRepository) SetProviderReference(ctx context.Context, id uuid.UUID, reference string) error {
+	_, err := r.db.Exec(ctx, "UPDATE refunds SET provider_reference = NULLIF($1, '') WHERE id = $2", reference, id)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to set refund provider reference")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// RegulatoryRepository aggregates payments for NBE reports and keeps the
+// reports generated
+type RegulatoryRepository interface {
+	// Aggregate returns one row per business day, bank and currency with
+	// activity in [from, to), ordered by day, bank and currency
+	Aggregate(ctx context.Context, from, to time.Time, rates map[domain.Currency]float64) ([]*domain.RegulatoryRow, error)
+	Create(ctx context.Context, report *domain.RegulatoryReport, content []byte) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RegulatoryReport, error)
+	// Content returns the report's CSV as generated
+	Content(ctx context.Context, id uuid.UUID) ([]byte, error)
+	List(ctx context.Context, limit, offset int) ([]*domain.RegulatoryReport, error)
+	// ExistsForPeriod reports whether a report covering exactly from..to
+	// was generated
+	ExistsForPeriod(ctx context.Context, from, to string) (bool, error)
+}
+
+type regulatoryRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewRegulatoryRepository(db *pgxpool.Pool, logger *logrus.Logger) RegulatoryRepository {
+	return &regulatoryRepository{db: db, logger: logger}
+}
+
+// regulatoryBank is the bank a payment is reported under
+const regulatoryBank = `COALESCE(NULLIF(p.bank_code, ''), p.payment_method)`
+
+func (r *regulatoryRepository) Aggregate(ctx context.Context, from, to time.Time, rates map[domain.Currency]float64) ([]*domain.RegulatoryRow, error) {
+	query := `
+		WITH payment_days AS (
+			SELECT to_char(p.created_at AT TIME ZONE 'Africa/Addis_Ababa', 'YYYY-MM-DD') AS day,
+				` + regulatoryBank + ` AS bank,
+				p.currency,
+				COUNT(*) FILTER (WHERE p.status = 'SUCCESS') AS success_count,
+				COALESCE(SUM(p.amount) FILTER (WHERE p.status = 'SUCCESS'), 0) AS success_volume,
+				COALESCE(SUM(` + etbValue + `) FILTER (WHERE p.status = 'SUCCESS'), 0) AS success_volume_etb,
+				COUNT(*) FILTER (WHERE p.status = 'FAILED') AS failed_count
+			FROM payments p
+			` + fxJoin + `
+			WHERE p.status IN ('SUCCESS', 'FAILED')
+			  AND p.created_at >= $3 AND p.created_at < $4
+			GROUP BY 1, 2, 3
+		), refund_days AS (
+			SELECT to_char(rf.created_at AT TIME ZONE 'Africa/Addis_Ababa', 'YYYY-MM-DD') AS day,
+				` + regulatoryBank + ` AS bank,
+				rf.currency,
+				COUNT(*) AS refund_count,
+				SUM(rf.amount) AS refund_volume
+			FROM refunds rf
+			JOIN payments p ON p.id = rf.payment_id
+			WHERE rf.status = 'SUCCEEDED'
+			  AND rf.created_at >= $3 AND rf.created_at < $4
+			GROUP BY 1, 2, 3
+		)
+		SELECT COALESCE(pd.day, rd.day), COALESCE(pd.bank, rd.bank), COALESCE(pd.currency, rd.currency),
+			COALESCE(pd.success_count, 0), COALESCE(pd.success_volume, 0), COALESCE(pd.success_volume_etb, 0),
+			COALESCE(pd.failed_count, 0), COALESCE(rd.refund_count, 0), COALESCE(rd.refund_volume, 0)
+		FROM payment_days pd
+		FULL OUTER JOIN refund_days rd ON rd.day = pd.day AND rd.bank = pd.bank AND rd.currency = pd.currency
+		ORDER BY 1, 2, 3
+	`
+
+	currencies, values := rateArrays(rates)
+	rows, err := r.db.Query(ctx, query, currencies, values, from, to)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to aggregate regulatory report")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var result []*domain.RegulatoryRow
+	for rows.Next() {
+		var row domain.RegulatoryRow
+		err := rows.Scan(
+			&row.Date,
+			&row.Bank,
+			&row.Currency,
+			&row.SuccessCount,
+			&row.SuccessVolume,
+			&row.SuccessVolumeETB,
+			&row.FailedCount,
+			&row.RefundCount,
+			&row.RefundVolume,
+		)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan regulatory report row")
+			return nil, domain.ErrDatabase
+		}
+		result = append(result, &row)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *regulatoryRepository) Create(ctx context.Context, report *domain.RegulatoryReport, content []byte) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO regulatory_reports (id, period_from, period_to, institution_code, row_count, content, checksum, generated_by, created_at)
+		VALUES ($1, $2::date, $3::date, $4, $5, $6, $7, $8, $9)
+	`,
+		report.ID,
+		report.From,
+		report.To,
+		report.InstitutionCode,
+		report.RowCount,
+		string(content),
+		report.Checksum,
+		report.GeneratedBy,
+		report.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create regulatory report")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+const regulatoryReportColumns = `id, to_char(period_from, 'YYYY-MM-DD'), to_char(period_to, 'YYYY-MM-DD'),
+	institution_code, row_count, checksum, generated_by, created_at`
+
+func (r *regulatoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RegulatoryReport, error) {
+	report, err := scanRegulatoryReport(r.db.QueryRow(ctx, "SELECT "+regulatoryReportColumns+" FROM regulatory_reports WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRegulatoryReportNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get regulatory report")
+		return nil, domain.ErrDatabase
+	}
+
+	return report, nil
+}
+
+func (r *regulatoryRepository) Content(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var content string
+	err := r.db.QueryRow(ctx, "SELECT content FROM regulatory_reports WHERE id = $1", id).Scan(&content)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRegulatoryReportNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get regulatory report content")
+		return nil, domain.ErrDatabase
+	}
+
+	return []byte(content), nil
+}
+
+func (r *regulatoryRepository) List(ctx context.Context, limit, offset int) ([]*domain.RegulatoryReport, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+regulatoryReportColumns+" FROM regulatory_reports ORDER BY created_at DESC LIMIT $1 OFFSET $2",
+		limit, offset,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list regulatory reports")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var reports []*domain.RegulatoryReport
+	for rows.Next() {
+		report, err := scanRegulatoryReport(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan regulatory report")
+			return nil, domain.ErrDatabase
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *regulatoryRepository) ExistsForPeriod(ctx context.Context, from, to string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM regulatory_reports WHERE period_from = $1::date AND period_to = $2::date)
+	`, from, to).Scan(&exists)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to check regulatory report period")
+		return false, domain.ErrDatabase
+	}
+
+	return exists, nil
+}
+
+func scanRegulatoryReport(row pgx.Row) (*domain.RegulatoryReport, error) {
+	var report domain.RegulatoryReport
+	err := row.Scan(
+		&report.ID,
+		&report.From,
+		&report.To,
+		&report.InstitutionCode,
+		&report.RowCount,
+		&report.Checksum,
+		&report.GeneratedBy,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
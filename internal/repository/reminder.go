@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type ReminderRepository interface {
+	// Create schedules a reminder; an existing schedule for the subject is kept
+	Create(ctx context.Context, reminder *domain.PaymentReminder) error
+	// ClaimDue returns active reminders due at now and pushes their next_at
+	// out by lease, so concurrent workers do not send the same reminder.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.PaymentReminder, error)
+	Advance(ctx context.Context, id uuid.UUID, step int, nextAt time.Time) error
+	Finish(ctx context.Context, id uuid.UUID, status domain.ReminderStatus) error
+	FinishBySubject(ctx context.Context, subjectType domain.ReminderSubject, subjectID uuid.UUID, status domain.ReminderStatus) error
+}
+
+type reminderRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewReminderRepository(db *pgxpool.Pool, logger *logrus.Logger) ReminderRepository {
+	return &reminderRepository{db: db, logger: logger}
+}
+
+func (r *reminderRepository) Create(ctx context.Context, reminder *domain.PaymentReminder) error {
+	query := `
+		INSERT INTO payment_reminders (id, subject_type, subject_id, payment_id, step, next_at, expires_at, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (subject_type, subject_id) DO NOTHING
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		reminder.ID,
+		reminder.SubjectType,
+		reminder.SubjectID,
+		reminder.PaymentID,
+		reminder.Step,
+		reminder.NextAt,
+		reminder.ExpiresAt,
+		reminder.Status,
+		reminder.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create payment reminder")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.PaymentReminder, error) {
+	query := `
+		UPDATE payment_reminders
+		SET next_at = $2
+		WHERE id IN (
+			SELECT id FROM payment_reminders
+			WHERE status = 'ACTIVE' AND next_at <= $1
+			ORDER BY next_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, subject_type, subject_id, payment_id, step, next_at, expires_at, status, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim due reminders")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var reminders []*domain.PaymentReminder
+	for rows.Next() {
+		var reminder domain.PaymentReminder
+		err := rows.Scan(
+			&reminder.ID,
+			&reminder.SubjectType,
+			&reminder.SubjectID,
+			&reminder.PaymentID,
+			&reminder.Step,
+			&reminder.NextAt,
+			&reminder.ExpiresAt,
+			&reminder.Status,
+			&reminder.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (r *reminderRepository) Advance(ctx context.Context, id uuid.UUID, step int, nextAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payment_reminders SET step = $1, next_at = $2 WHERE id = $3 AND status = 'ACTIVE'",
+		step, nextAt, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to advance payment reminder")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) Finish(ctx context.Context, id uuid.UUID, status domain.ReminderStatus) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payment_reminders SET status = $1 WHERE id = $2 AND status = 'ACTIVE'",
+		status, id,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to finish payment reminder")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) FinishBySubject(ctx context.Context, subjectType domain.ReminderSubject, subjectID uuid.UUID, status domain.ReminderStatus) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE payment_reminders SET status = $1 WHERE subject_type = $2 AND subject_id = $3 AND status = 'ACTIVE'",
+		status, subjectType, subjectID,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to finish payment reminders")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// readPools sends reads that tolerate replication lag, listings and
+// searches, to read replicas, round robin. Reads inside the caller's
+// transaction stay on it, and without replicas every read goes to the
+// primary.
+type readPools struct {
+	primary  *pgxpool.Pool
+	replicas []*pgxpool.Pool
+	next     atomic.Uint64
+}
+
+func newReadPools(primary *pgxpool.Pool, replicas []*pgxpool.Pool) *readPools {
+	return &readPools{primary: primary, replicas: replicas}
+}
+
+// reader is the pool or transaction a lag-tolerant read should use
+func (p *readPools) reader(ctx context.Context) querier {
+	if len(p.replicas) == 0 || InTx(ctx) {
+		return conn(ctx, p.primary)
+	}
+	return p.replicas[(p.next.Add(1)-1)%uint64(len(p.replicas))]
+}
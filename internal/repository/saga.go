@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// SagaRepository persists saga progress. A run is leased to one process
+// while it executes; a run whose lease lapsed is picked up again.
+type SagaRepository interface {
+	Create(ctx context.Context, run *domain.SagaRun, lockedUntil time.Time) error
+	// Save records progress; a zero lockedUntil releases the lease
+	Save(ctx context.Context, run *domain.SagaRun, lockedUntil time.Time) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SagaRun, error)
+	// ClaimDue leases unfinished runs whose next attempt is due and whose
+	// lease has lapsed
+	ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.SagaRun, error)
+}
+
+type sagaRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewSagaRepository(db *pgxpool.Pool, logger *logrus.Logger) SagaRepository {
+	return &sagaRepository{db: db, logger: logger}
+}
+
+const sagaColumns = `id, kind, status, step, data, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, updated_at`
+
+func scanSagaRun(row pgx.Row) (*domain.SagaRun, error) {
+	var run domain.SagaRun
+	var data []byte
+	err := row.Scan(&run.ID, &run.Kind, &run.Status, &run.Step, &data, &run.Attempts, &run.LastError, &run.NextAttemptAt, &run.CreatedAt, &run.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &run.Data); err != nil {
+		return nil, err
+	}
+	if run.Data == nil {
+		run.Data = map[string]string{}
+	}
+	return &run, nil
+}
+
+func (r *sagaRepository) Create(ctx context.Context, run *domain.SagaRun, lockedUntil time.Time) error {
+	data, err := json.Marshal(run.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO saga_runs (id, kind, status, step, data, attempts, last_error, next_attempt_at, locked_until, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11)
+	`, run.ID, run.Kind, run.Status, run.Step, data, run.Attempts, run.LastError, run.NextAttemptAt, lockedUntil, run.CreatedAt, run.UpdatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create saga run")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *sagaRepository) Save(ctx context.Context, run *domain.SagaRun, lockedUntil time.Time) error {
+	data, err := json.Marshal(run.Data)
+	if err != nil {
+		return err
+	}
+
+	var lease *time.Time
+	if !lockedUntil.IsZero() {
+		lease = &lockedUntil
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE saga_runs
+		SET status = $1, step = $2, data = $3, attempts = $4, last_error = NULLIF($5, ''),
+			next_attempt_at = $6, locked_until = $7, updated_at = $8
+		WHERE id = $9
+	`, run.Status, run.Step, data, run.Attempts, run.LastError, run.NextAttemptAt, lease, run.UpdatedAt, run.ID)
+	if err != nil {
+		r.logger.WithError(err).WithField("saga_id", run.ID).Error("Failed to save saga run")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *sagaRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SagaRun, error) {
+	run, err := scanSagaRun(r.db.QueryRow(ctx, "SELECT "+sagaColumns+" FROM saga_runs WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSagaNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get saga run")
+		return nil, domain.ErrDatabase
+	}
+
+	return run, nil
+}
+
+func (r *sagaRepository) ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.SagaRun, error) {
+	query := `
+		UPDATE saga_runs SET locked_until = $2
+		WHERE id IN (
+			SELECT id FROM saga_runs
+			WHERE status IN ('RUNNING', 'COMPENSATING')
+			  AND next_attempt_at <= $1
+			  AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + sagaColumns
+
+	rows, err := r.db.Query(ctx, query, now, lockedUntil, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim saga runs")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var runs []*domain.SagaRun
+	for rows.Next() {
+		run, err := scanSagaRun(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan saga run")
+			return nil, domain.ErrDatabase
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type SettlementRepository interface {
+	// Create stores a statement and all its lines in one transaction
+	Create(ctx context.Context, file *domain.SettlementFile, lines []*domain.SettlementLine) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SettlementFile, error)
+	GetByChecksum(ctx context.Context, checksum string) (*domain.SettlementFile, error)
+	List(ctx context.Context, limit, offset int) ([]*domain.SettlementFile, error)
+	ListLines(ctx context.Context, fileID uuid.UUID) ([]*domain.SettlementLine, error)
+	CountSince(ctx context.Context, since time.Time) (int, error)
+	// ListUnreconciled returns files waiting for reconciliation, oldest first
+	ListUnreconciled(ctx context.Context, limit int) ([]*domain.SettlementFile, error)
+	// ReconciliationCandidates returns the payments whose reference is one of
+	// references, and the successful payments at the bank updated in
+	// [from, to)
+	ReconciliationCandidates(ctx context.Context, fileID uuid.UUID, bankCode string, references []string, from, to time.Time) ([]*domain.ReconciliationPayment, error)
+	// SaveReconciliation stores the outcome of every line and marks the file
+	// reconciled, in one transaction
+	SaveReconciliation(ctx context.Context, fileID uuid.UUID, lines []*domain.SettlementLine, reconciledAt time.Time) error
+	// UnmatchedPayments returns successful payments at the bank updated in
+	// [from, to) that no statement line has matched
+	UnmatchedPayments(ctx context.Context, bankCode string, from, to time.Time) ([]*domain.ReconciliationPayment, error)
+}
+
+type settlementRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewSettlementRepository(db *pgxpool.Pool, logger *logrus.Logger) SettlementRepository {
+	return &settlementRepository{db: db, logger: logger}
+}
+
+const settlementFileColumns = `id, bank_code, format, file_name, checksum, COALESCE(account_number, ''), COALESCE(currency, ''),
+	opening_balance, closing_balance, line_count, error_count, parse_errors, status, uploaded_by, reconciled_at, created_at`
+
+func (r *settlementRepository) Create(ctx context.Context, file *domain.SettlementFile, lines []*domain.SettlementLine) error {
+	parseErrors, err := json.Marshal(file.ParseErrors)
+	if err != nil {
+		return err
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO settlement_files (id, bank_code, format, file_name, checksum, account_number, currency,
+			opening_balance, closing_balance, line_count, error_count, parse_errors, status, uploaded_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, $13, $14, $15)
+	`,
+		file.ID,
+		file.BankCode,
+		file.Format,
+		file.FileName,
+		file.Checksum,
+		file.AccountNumber,
+		file.Currency,
+		file.OpeningBalance,
+		file.ClosingBalance,
+		file.LineCount,
+		file.ErrorCount,
+		parseErrors,
+		file.Status,
+		file.UploadedBy,
+		file.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create settlement file")
+		return domain.ErrDatabase
+	}
+
+	// Statements can run to thousands of lines; COPY keeps the upload fast
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"settlement_lines"},
+		[]string{"id", "file_id", "line_number", "value_date", "direction", "amount", "currency", "reference", "bank_ref", "description"},
+		pgx.CopyFromSlice(len(lines), func(i int) ([]interface{}, error) {
+			l := lines[i]
+			return []interface{}{l.ID, l.FileID, l.LineNumber, l.ValueDate, l.Direction, l.Amount, nullIfEmpty(l.Currency), nullIfEmpty(l.Reference), nullIfEmpty(l.BankRef), nullIfEmpty(l.Description)}, nil
+		}),
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to store settlement lines")
+		return domain.ErrDatabase
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *settlementRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SettlementFile, error) {
+	return r.getOne(ctx, "SELECT "+settlementFileColumns+" FROM settlement_files WHERE id = $1", id)
+}
+
+func (r *settlementRepository) GetByChecksum(ctx context.Context, checksum string) (*domain.SettlementFile, error) {
+	return r.getOne(ctx, "SELECT "+settlementFileColumns+" FROM settlement_files WHERE checksum = $1", checksum)
+}
+
+func (r *settlementRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.SettlementFile, error) {
+	file, err := scanSettlementFile(r.db.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrSettlementFileNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get settlement file")
+		return nil, domain.ErrDatabase
+	}
+
+	return file, nil
+}
+
+func (r *settlementRepository) List(ctx context.Context, limit, offset int) ([]*domain.SettlementFile, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+settlementFileColumns+" FROM settlement_files ORDER BY created_at DESC LIMIT $1 OFFSET $2",
+		limit, offset,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list settlement files")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var files []*domain.SettlementFile
+	for rows.Next() {
+		file, err := scanSettlementFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+func (r *settlementRepository) CountSince(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM settlement_files WHERE created_at >= $1", since).Scan(&count)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to count settlement files")
+		return 0, domain.ErrDatabase
+	}
+
+	return count, nil
+}
+
+func (r *settlementRepository) ListLines(ctx context.Context, fileID uuid.UUID) ([]*domain.SettlementLine, error) {
+	query := `
+		SELECT id, file_id, line_number, value_date, direction, amount, COALESCE(currency, ''),
+			COALESCE(reference, ''), COALESCE(bank_ref, ''), COALESCE(description, ''),
+			payment_id, COALESCE(match_status, ''), COALESCE(match_note, '')
+		FROM settlement_lines
+		WHERE file_id = $1
+		ORDER BY line_number
+	`
+
+	rows, err := r.db.Query(ctx, query, fileID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list settlement lines")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var lines []*domain.SettlementLine
+	for rows.Next() {
+		var l domain.SettlementLine
+		err := rows.Scan(
+			&l.ID,
+			&l.FileID,
+			&l.LineNumber,
+			&l.ValueDate,
+			&l.Direction,
+			&l.Amount,
+			&l.Currency,
+			&l.Reference,
+			&l.BankRef,
+			&l.Description,
+			&l.PaymentID,
+			&l.MatchStatus,
+			&l.MatchNote,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, &l)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *settlementRepository) ListUnreconciled(ctx context.Context, limit int) ([]*domain.SettlementFile, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+settlementFileColumns+" FROM settlement_files WHERE reconciled_at IS NULL ORDER BY created_at LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list unreconciled settlement files")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var files []*domain.SettlementFile
+	for rows.Next() {
+		file, err := scanSettlementFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+const reconciliationPaymentColumns = `p.id, p.reference, p.amount, p.currency, COALESCE(p.amount_etb, 0), p.status,
+	COALESCE(p.bank_code, ''), p.updated_at`
+
+func (r *settlementRepository) ReconciliationCandidates(ctx context.Context, fileID uuid.UUID, bankCode string, references []string, from, to time.Time) ([]*domain.ReconciliationPayment, error) {
+	query := `
+		SELECT ` + reconciliationPaymentColumns + `,
+			(SELECT l.file_id FROM settlement_lines l
+			 WHERE l.payment_id = p.id AND l.match_status = 'MATCHED' AND l.file_id <> $1
+			 LIMIT 1)
+		FROM payments p
+		WHERE p.reference = ANY($2)
+		   OR (p.bank_code = $3 AND p.status = 'SUCCESS' AND p.updated_at >= $4 AND p.updated_at < $5)
+	`
+
+	rows, err := r.db.Query(ctx, query, fileID, references, bankCode, from, to)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list reconciliation candidates")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var payments []*domain.ReconciliationPayment
+	for rows.Next() {
+		var p domain.ReconciliationPayment
+		if err := rows.Scan(&p.ID, &p.Reference, &p.Amount, &p.Currency, &p.AmountETB, &p.Status, &p.BankCode, &p.UpdatedAt, &p.MatchedFileID); err != nil {
+			r.logger.WithError(err).Error("Failed to scan reconciliation candidate")
+			return nil, domain.ErrDatabase
+		}
+		payments = append(payments, &p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *settlementRepository) SaveReconciliation(ctx context.Context, fileID uuid.UUID, lines []*domain.SettlementLine, reconciledAt time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, l := range lines {
+		batch.Queue(`
+			UPDATE settlement_lines SET payment_id = $2, match_status = $3, match_note = NULLIF($4, '')
+			WHERE id = $1
+		`, l.ID, l.PaymentID, l.MatchStatus, l.MatchNote)
+	}
+	batch.Queue("UPDATE settlement_files SET reconciled_at = $2 WHERE id = $1", fileID, reconciledAt)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		r.logger.WithError(err).Error("Failed to save reconciliation")
+		return domain.ErrDatabase
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *settlementRepository) UnmatchedPayments(ctx context.Context, bankCode string, from, to time.Time) ([]*domain.ReconciliationPayment, error) {
+	query := `
+		SELECT ` + reconciliationPaymentColumns + `
+		FROM payments p
+		WHERE p.bank_code = $1 AND p.status = 'SUCCESS' AND p.updated_at >= $2 AND p.updated_at < $3
+		  AND NOT EXISTS (
+			SELECT 1 FROM settlement_lines l WHERE l.payment_id = p.id AND l.match_status = 'MATCHED'
+		  )
+		ORDER BY p.updated_at
+	`
+
+	rows, err := r.db.Query(ctx, query, bankCode, from, to)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list unmatched payments")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var payments []*domain.ReconciliationPayment
+	for rows.Next() {
+		var p domain.ReconciliationPayment
+		if err := rows.Scan(&p.ID, &p.Reference, &p.Amount, &p.Currency, &p.AmountETB, &p.Status, &p.BankCode, &p.UpdatedAt); err != nil {
+			r.logger.WithError(err).Error("Failed to scan unmatched payment")
+			return nil, domain.ErrDatabase
+		}
+		payments = append(payments, &p)
+	}
+
+	return payments, rows.Err()
+}
+
+func scanSettlementFile(row pgx.Row) (*domain.SettlementFile, error) {
+	var file domain.SettlementFile
+	var parseErrors []byte
+	err := row.Scan(
+		&file.ID,
+		&file.BankCode,
+		&file.Format,
+		&file.FileName,
+		&file.Checksum,
+		&file.AccountNumber,
+		&file.Currency,
+		&file.OpeningBalance,
+		&file.ClosingBalance,
+		&file.LineCount,
+		&file.ErrorCount,
+		&parseErrors,
+		&file.Status,
+		&file.UploadedBy,
+		&file.ReconciledAt,
+		&file.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(parseErrors, &file.ParseErrors); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type TelegramChatRepository interface {
+	Create(ctx context.Context, chat *domain.TelegramChat) error
+	Link(ctx context.Context, linkCode string, chatID int64, chatTitle string) (*domain.TelegramChat, error)
+	// ListLinked returns one merchant's linked chats, or every chat when nil
+	ListLinked(ctx context.Context, merchantID *string) ([]*domain.TelegramChat, error)
+	// Delete removes a chat; with a merchant ID only that merchant's chat
+	Delete(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type telegramChatRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewTelegramChatRepository(db *pgxpool.Pool, logger *logrus.Logger) TelegramChatRepository {
+	return &telegramChatRepository{db: db, logger: logger}
+}
+
+func (r *telegramChatRepository) Create(ctx context.Context, chat *domain.TelegramChat) error {
+	query := `
+		INSERT INTO telegram_chats (id, merchant_id, label, link_code, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.Exec(ctx, query, chat.ID, chat.MerchantID, chat.Label, chat.LinkCode, chat.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create telegram chat link")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+// Link attaches a Telegram chat to an unused link code
+func (r *telegramChatRepository) Link(ctx context.Context, linkCode string, chatID int64, chatTitle string) (*domain.TelegramChat, error) {
+	query := `
+		UPDATE telegram_chats
+		SET chat_id = $1, chat_title = $2, linked_at = $3
+		WHERE link_code = $4 AND linked_at IS NULL
+		RETURNING id, merchant_id, label, created_at, linked_at
+	`
+
+	chat := domain.TelegramChat{
+		LinkCode:  linkCode,
+		ChatID:    chatID,
+		ChatTitle: chatTitle,
+	}
+	err := r.db.QueryRow(ctx, query, chatID, chatTitle, time.Now().UTC(), linkCode).Scan(
+		&chat.ID,
+		&chat.MerchantID,
+		&chat.Label,
+		&chat.CreatedAt,
+		&chat.LinkedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrTelegramLinkNotFound
+	}
+
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to link telegram chat")
+		return nil, domain.ErrDatabase
+	}
+
+	return &chat, nil
+}
+
+func (r *telegramChatRepository) ListLinked(ctx context.Context, merchantID *string) ([]*domain.TelegramChat, error) {
+	query := `
+		SELECT id, merchant_id, label, chat_id, COALESCE(chat_title, ''), linked_at, created_at
+		FROM telegram_chats
+		WHERE linked_at IS NOT NULL AND ($1::text IS NULL OR merchant_id = $1)
+		ORDER BY linked_at
+	`
+
+	rows, err := r.db.Query(ctx, query, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list telegram chats")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var chats []*domain.TelegramChat
+	for rows.Next() {
+		var chat domain.TelegramChat
+		err := rows.Scan(
+			&chat.ID,
+			&chat.MerchantID,
+			&chat.Label,
+			&chat.ChatID,
+			&chat.ChatTitle,
+			&chat.LinkedAt,
+			&chat.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, &chat)
+	}
+
+	return chats, rows.Err()
+}
+
+func (r *telegramChatRepository) Delete(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM telegram_chats WHERE id = $1 AND ($2::text IS NULL OR merchant_id = $2)", id, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete telegram chat")
+		return domain.ErrDatabase
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+
+	return nil
+}
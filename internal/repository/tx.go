@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// Transactor runs several repository calls in one database transaction.
+// Repositories called inside it join the caller's transaction, as a
+// savepoint where they would have opened their own, so a change and the
+// outbox message announcing it commit or roll back together.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewTransactor(db *pgxpool.Pool, logger *logrus.Logger) Transactor {
+	return &transactor{db: db, logger: logger}
+}
+
+type txKey struct{}
+
+// txState is the transaction carried on a context by InTx
+type txState struct {
+	tx          pgx.Tx
+	afterCommit []func(ctx context.Context)
+}
+
+func (t *transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		// Already inside one; the outermost call commits
+		return fn(ctx)
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		t.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	state := &txState{tx: tx}
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		t.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	for _, f := range state.afterCommit {
+		f(ctx)
+	}
+	return nil
+}
+
+// InTx reports whether ctx carries a transaction from Transactor.InTx
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// AfterCommit runs fn once the transaction on ctx has committed, and not
+// at all if it rolls back; without a transaction fn runs at once. It is for
+// side effects, such as publishing straight to RabbitMQ, that must not be
+// seen before the change they describe.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// begin opens a transaction, or a savepoint within the caller's
+// transaction when ctx carries one
+func begin(ctx context.Context, db *pgxpool.Pool) (pgx.Tx, error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx.Begin(ctx)
+	}
+	return db.Begin(ctx)
+}
+
+// querier is what pgxpool.Pool and pgx.Tx have in common
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn is the caller's transaction when ctx carries one, else the pool
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type CashVoucherRepository interface {
+	// Issue cancels the payment's active voucher, if any, and stores the new
+	// one. ErrVoucherCodeTaken means the code collided and a new one is needed.
+	Issue(ctx context.Context, voucher *domain.CashVoucher) error
+	// GetByCode also fills in the payment reference and customer name
+	GetByCode(ctx context.Context, code string) (*domain.CashVoucher, error)
+	// Redeem marks the voucher REDEEMED and its payment SUCCESS in one
+	// transaction, filling in the voucher's redemption fields.
+	Redeem(ctx context.Context, voucher *domain.CashVoucher, agent, agentReference, branch string, at time.Time) error
+}
+
+type cashVoucherRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewCashVoucherRepository(db *pgxpool.Pool, logger *logrus.Logger) CashVoucherRepository {
+	return &cashVoucherRepository{db: db, logger: logger}
+}
+
+func (r *cashVoucherRepository) Issue(ctx context.Context, v *domain.CashVoucher) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"UPDATE cash_vouchers SET status = $1 WHERE payment_id = $2 AND status = $3",
+		domain.VoucherCancelled, v.PaymentID, domain.VoucherActive,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to cancel previous cash voucher")
+		return domain.ErrDatabase
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO cash_vouchers (id, payment_id, code, amount, currency, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (code) DO NOTHING
+		RETURNING id
+	`, v.ID, v.PaymentID, v.Code, v.Amount, v.Currency, v.Status, v.ExpiresAt, v.CreatedAt).Scan(&v.ID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrVoucherCodeTaken
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create cash voucher")
+		return domain.ErrDatabase
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit cash voucher")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *cashVoucherRepository) GetByCode(ctx context.Context, code string) (*domain.CashVoucher, error) {
+	query := `
+		SELECT v.id, v.payment_id, v.code, v.amount, v.currency, v.status, v.expires_at, v.created_at,
+		       v.redeemed_at, COALESCE(v.redeemed_by, ''), COALESCE(v.agent_reference, ''), COALESCE(v.branch, ''),
+		       p.reference, COALESCE(p.customer_name, '')
+		FROM cash_vouchers v
+		JOIN payments p ON p.id = v.payment_id
+		WHERE v.code = $1
+	`
+
+	var v domain.CashVoucher
+	err := r.db.QueryRow(ctx, query, code).Scan(
+		&v.ID,
+		&v.PaymentID,
+		&v.Code,
+		&v.Amount,
+		&v.Currency,
+		&v.Status,
+		&v.ExpiresAt,
+		&v.CreatedAt,
+		&v.RedeemedAt,
+		&v.RedeemedBy,
+		&v.AgentReference,
+		&v.Branch,
+		&v.Reference,
+		&v.CustomerName,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrVoucherNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get cash voucher")
+		return nil, domain.ErrDatabase
+	}
+
+	return &v, nil
+}
+
+func (r *cashVoucherRepository) Redeem(ctx context.Context, v *domain.CashVoucher, agent, agentReference, branch string, at time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	if err := redeemVoucher(ctx, tx, r.logger, v, agent, agentReference, branch, at); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit cash voucher redemption")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+// redeemVoucher marks the voucher REDEEMED and settles its AWAITING_CASH
+// payment inside tx; callers add their own bookkeeping to the same tx
+func redeemVoucher(ctx context.Context, tx pgx.Tx, logger *logrus.Logger, v *domain.CashVoucher, agent, agentReference, branch string, at time.Time) error {
+	result, err := tx.Exec(ctx, `
+		UPDATE cash_vouchers
+		SET status = $1, redeemed_at = $2, redeemed_by = $3, agent_reference = $4, branch = NULLIF($5, '')
+		WHERE id = $6 AND status = $7 AND expires_at > $2
+	`, domain.VoucherRedeemed, at, agent, agentReference, branch, v.ID, domain.VoucherActive)
+	if err != nil {
+		logger.WithError(err).Error("Failed to redeem cash voucher")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrVoucherNotActive
+	}
+
+	result, err = tx.Exec(ctx,
+		"UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4",
+		domain.StatusSuccess, at, v.PaymentID, domain.StatusAwaitingCash,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Failed to settle cash payment")
+		return domain.ErrDatabase
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrVoucherPaymentClosed
+	}
+
+	err = recordAudit(ctx, tx, logger, domain.AuditPaymentStatusChanged, domain.AuditEntityPayment, v.PaymentID,
+		statusState(domain.StatusAwaitingCash), statusState(domain.StatusSuccess),
+	)
+	if err != nil {
+		return err
+	}
+
+	v.Status = domain.VoucherRedeemed
+	v.RedeemedAt = &at
+	v.RedeemedBy = agent
+	v.AgentReference = agentReference
+	v.Branch = branch
+
+	return nil
+}
@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookRepository persists merchant webhook endpoints and their
+// deliveries. A delivery is leased to one worker while it is being sent.
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error
+	// GetEndpoint includes the signing secret; ListEndpoints does not
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*domain.WebhookEndpoint, error)
+	// ListEndpoints returns a merchant's endpoints, or every endpoint for a
+	// nil merchantID
+	ListEndpoints(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookEndpoint, error)
+	// Deactivate stops new deliveries to the endpoint; pending ones still go
+	Deactivate(ctx context.Context, id uuid.UUID) error
+
+	// Enqueue adds a delivery for every active endpoint of the payment's
+	// merchant subscribed to the event and returns how many were added.
+	// Payments without a merchant go to endpoints without one.
+	Enqueue(ctx context.Context, event domain.NotificationEvent, paymentID uuid.UUID, merchantID *uuid.UUID, payload []byte, at time.Time) (int, error)
+	// ClaimDue leases pending deliveries whose next attempt is due
+	ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.WebhookDelivery, error)
+	// RecordAttempt stores the attempt and the delivery's new state, and
+	// releases the lease
+	RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error
+	// ClaimFailed leases a FAILED delivery for a manual replay; nil if it
+	// is not FAILED or is already being replayed
+	ClaimFailed(ctx context.Context, id uuid.UUID, now, lockedUntil time.Time) (*domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error)
+	// ListFailed returns FAILED deliveries across a merchant's endpoints, or
+	// all endpoints for a nil merchantID, newest first
+	ListFailed(ctx context.Context, merchantID *uuid.UUID, limit int) ([]*domain.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*domain.WebhookAttempt, error)
+}
+
+type webhookRepository struct {
+	db     *pgxpool.Pool
+	logger *logrus.Logger
+}
+
+func NewWebhookRepository(db *pgxpool.Pool, logger *logrus.Logger) WebhookRepository {
+	return &webhookRepository{db: db, logger: logger}
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO webhook_endpoints (id, url, description, events, secret, active, merchant_id, created_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+		`, endpoint.ID, endpoint.URL, endpoint.Description, eventStrings(endpoint.Events), endpoint.Secret, endpoint.Active, endpoint.MerchantID, endpoint.CreatedAt)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to create webhook endpoint")
+			return domain.ErrDatabase
+		}
+
+		// The signing secret never goes into the audit log
+		logged := *endpoint
+		logged.Secret = ""
+		return recordAudit(ctx, tx, r.logger, domain.AuditWebhookRegistered, domain.AuditEntityWebhook, endpoint.ID, nil, logged)
+	})
+}
+
+func (r *webhookRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*domain.WebhookEndpoint, error) {
+	var endpoint domain.WebhookEndpoint
+	var events []string
+	err := r.db.QueryRow(ctx, `
+		SELECT id, url, COALESCE(description, ''), events, active, secret, merchant_id, created_at
+		FROM webhook_endpoints WHERE id = $1
+	`, id).Scan(&endpoint.ID, &endpoint.URL, &endpoint.Description, &events, &endpoint.Active, &endpoint.Secret, &endpoint.MerchantID, &endpoint.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get webhook endpoint")
+		return nil, domain.ErrDatabase
+	}
+
+	endpoint.Events = notificationEvents(events)
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) ListEndpoints(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookEndpoint, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, url, COALESCE(description, ''), events, active, merchant_id, created_at
+		FROM webhook_endpoints
+		WHERE $1::uuid IS NULL OR merchant_id = $1
+		ORDER BY created_at
+	`, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list webhook endpoints")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var endpoints []*domain.WebhookEndpoint
+	for rows.Next() {
+		var endpoint domain.WebhookEndpoint
+		var events []string
+		if err := rows.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Description, &events, &endpoint.Active, &endpoint.MerchantID, &endpoint.CreatedAt); err != nil {
+			r.logger.WithError(err).Error("Failed to scan webhook endpoint")
+			return nil, domain.ErrDatabase
+		}
+		endpoint.Events = notificationEvents(events)
+		endpoints = append(endpoints, &endpoint)
+	}
+
+	return endpoints, rows.Err()
+}
+
+func (r *webhookRepository) Deactivate(ctx context.Context, id uuid.UUID) error {
+	return audited(ctx, r.db, r.logger, func(tx pgx.Tx) error {
+		var wasActive bool
+		err := tx.QueryRow(ctx, `
+			UPDATE webhook_endpoints e SET active = FALSE
+			FROM (SELECT id, active FROM webhook_endpoints WHERE id = $1 FOR UPDATE) old
+			WHERE e.id = old.id
+			RETURNING old.active
+		`, id).Scan(&wasActive)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrWebhookNotFound
+		}
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to deactivate webhook endpoint")
+			return domain.ErrDatabase
+		}
+
+		if !wasActive {
+			return nil
+		}
+		return recordAudit(ctx, tx, r.logger, domain.AuditWebhookDeleted, domain.AuditEntityWebhook, id,
+			map[string]any{"active": true}, map[string]any{"active": false})
+	})
+}
+
+func (r *webhookRepository) Enqueue(ctx context.Context, event domain.NotificationEvent, paymentID uuid.UUID, merchantID *uuid.UUID, payload []byte, at time.Time) (int, error) {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (endpoint_id, event, payment_id, payload, status, next_attempt_at, created_at)
+		SELECT id, $1, $2, $3, $4, $5, $5
+		FROM webhook_endpoints
+		WHERE active AND (cardinality(events) = 0 OR $1 = ANY(events))
+		  AND merchant_id IS NOT DISTINCT FROM $6::uuid
+	`, string(event), paymentID, payload, domain.WebhookPending, at, merchantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to enqueue webhook deliveries")
+		return 0, domain.ErrDatabase
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, event, payment_id, payload, status, attempts, next_attempt_at, COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, delivered_at`
+
+func scanWebhookDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.Event,
+		&delivery.PaymentID,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	delivery.Payload = payload
+	return &delivery, err
+}
+
+func (r *webhookRepository) ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries SET locked_until = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING'
+			  AND next_attempt_at <= $1
+			  AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	return r.queryDeliveries(ctx, query, now, lockedUntil, limit)
+}
+
+func (r *webhookRepository) ClaimFailed(ctx context.Context, id uuid.UUID, now, lockedUntil time.Time) (*domain.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(ctx, `
+		UPDATE webhook_deliveries SET locked_until = $3
+		WHERE id = $1 AND status = 'FAILED' AND (locked_until IS NULL OR locked_until < $2)
+		RETURNING `+webhookDeliveryColumns, id, now, lockedUntil))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim webhook delivery")
+		return nil, domain.ErrDatabase
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt *domain.WebhookAttempt) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return domain.ErrDatabase
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO webhook_attempts (id, delivery_id, attempt_number, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, ''), $6, $7)
+	`, attempt.ID, attempt.DeliveryID, attempt.AttemptNumber, attempt.StatusCode, attempt.Error, attempt.DurationMS, attempt.AttemptedAt)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to record webhook attempt")
+		return domain.ErrDatabase
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = NULLIF($4, 0),
+			last_error = NULLIF($5, ''), delivered_at = $6, locked_until = NULL
+		WHERE id = $7
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to update webhook delivery")
+		return domain.ErrDatabase
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return domain.ErrDatabase
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE endpoint_id = $1 ORDER BY created_at DESC LIMIT $2"
+	return r.queryDeliveries(ctx, query, endpointID, limit)
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.db.QueryRow(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get webhook delivery")
+		return nil, domain.ErrDatabase
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) ListFailed(ctx context.Context, merchantID *uuid.UUID, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE status = 'FAILED'
+		  AND ($2::uuid IS NULL OR endpoint_id IN (SELECT id FROM webhook_endpoints WHERE merchant_id = $2))
+		ORDER BY created_at DESC LIMIT $1`
+	return r.queryDeliveries(ctx, query, limit, merchantID)
+}
+
+func (r *webhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to query webhook deliveries")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to scan webhook delivery")
+			return nil, domain.ErrDatabase
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*domain.WebhookAttempt, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, delivery_id, attempt_number, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, attempted_at
+		FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempt_number
+	`, deliveryID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list webhook attempts")
+		return nil, domain.ErrDatabase
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookAttempt
+	for rows.Next() {
+		var attempt domain.WebhookAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.AttemptNumber, &attempt.StatusCode, &attempt.Error, &attempt.DurationMS, &attempt.AttemptedAt); err != nil {
+			r.logger.WithError(err).Error("Failed to scan webhook attempt")
+			return nil, domain.ErrDatabase
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+func eventStrings(events []domain.NotificationEvent) []string {
+	out := make([]string, len(events))
+	for i, event := range events {
+		out[i] = string(event)
+	}
+	return out
+}
+
+func notificationEvents(values []string) []domain.NotificationEvent {
+	out := make([]domain.NotificationEvent, len(values))
+	for i, value := range values {
+		out[i] = domain.NotificationEvent(value)
+	}
+	return out
+}
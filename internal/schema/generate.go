@@ -0,0 +1,117 @@
+// Package schema generates JSON Schema documents from the Go types the
+// gateway actually serializes, so published schemas cannot drift from the
+// payloads on the wire.
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	uuidType   = reflect.TypeOf(uuid.UUID{})
+	amountType = reflect.TypeOf(domain.Amount(0))
+)
+
+// Generate builds the schema for v, which must be a struct or pointer to one
+func Generate(id, title string, v interface{}) map[string]interface{} {
+	s := typeSchema(reflect.TypeOf(v))
+	s["$schema"] = draft
+	s["$id"] = id
+	s["title"] = title
+	return s
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case amountType:
+		// Held in minor units but written as a decimal, see domain.Amount
+		return map[string]interface{}{"type": "number", "multipleOf": 0.01}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a name are flattened, as encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := typeSchema(field.Type)
+			if props, ok := embedded["properties"].(map[string]interface{}); ok {
+				for k, v := range props {
+					properties[k] = v
+				}
+				if req, ok := embedded["required"].([]string); ok {
+					required = append(required, req...)
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
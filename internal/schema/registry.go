@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"fmt"
+
+	"payment-gateway/internal/messaging"
+)
+
+// Payload kinds
+const (
+	KindQueue   = "queue"   // RabbitMQ message bodies
+	KindWebhook = "webhook" // Merchant webhook event bodies
+)
+
+// Document is one published, versioned payload schema. A breaking change
+// to a payload gets a new version; old versions stay published.
+type Document struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Path        string `json:"path"`
+
+	sample interface{}
+}
+
+var documents = []Document{
+	{
+		Kind:        KindQueue,
+		Name:        "payment.created",
+		Version:     1,
+		Description: "Published when a payment is ready for processing; consumed by the worker",
+		sample:      messaging.PaymentMessage{},
+	},
+}
+
+// List returns every published schema and the path it is served at
+func List() []Document {
+	list := make([]Document, len(documents))
+	for i, doc := range documents {
+		doc.Path = doc.path()
+		list[i] = doc
+	}
+	return list
+}
+
+// Find returns the JSON Schema for a payload, with its $id under baseURL
+func Find(baseURL, kind, name string, version int) (map[string]interface{}, bool) {
+	for _, doc := range documents {
+		if doc.Kind == kind && doc.Name == name && doc.Version == version {
+			return Generate(baseURL+doc.path(), fmt.Sprintf("%s %s v%d", doc.Kind, doc.Name, doc.Version), doc.sample), true
+		}
+	}
+	return nil, false
+}
+
+func (d Document) path() string {
+	return fmt.Sprintf("/api/v1/schemas/%s/%s/v%d", d.Kind, d.Name, d.Version)
+}
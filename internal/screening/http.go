@@ -0,0 +1,98 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+type APIConfig struct {
+	Name     string // Recorded as the source of its hits
+	URL      string
+	APIKey   string
+	Timeout  time.Duration
+	MinScore float64
+}
+
+type apiScreener struct {
+	config APIConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// NewAPIScreener calls an external screening service:
+// POST {"name": ...} -> {"hits": [{"list": ..., "name": ..., "score": 0-1}]}
+func NewAPIScreener(config APIConfig, logger *logrus.Logger) Screener {
+	if config.Name == "" {
+		config.Name = "api"
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &apiScreener{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+func (a *apiScreener) Screen(ctx context.Context, name string) ([]domain.ScreeningHit, error) {
+	body, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.config.APIKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.logger.WithError(err).WithField("screener", a.config.Name).Warn("Screening request failed")
+		return nil, fmt.Errorf("screening: %s: %w", a.config.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("screening: %s returned status %d", a.config.Name, resp.StatusCode)
+	}
+
+	var result struct {
+		Hits []struct {
+			List  string  `json:"list"`
+			Name  string  `json:"name"`
+			Score float64 `json:"score"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("screening: %s returned invalid body: %w", a.config.Name, err)
+	}
+
+	var hits []domain.ScreeningHit
+	for _, h := range result.Hits {
+		if h.Score < a.config.MinScore {
+			continue
+		}
+		hits = append(hits, domain.ScreeningHit{
+			List:   h.List,
+			Name:   h.Name,
+			Score:  h.Score,
+			Source: a.config.Name,
+		})
+	}
+	return hits, nil
+}
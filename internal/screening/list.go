@@ -0,0 +1,101 @@
+package screening
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"payment-gateway/internal/domain"
+)
+
+type listEntry struct {
+	list  string
+	name  string
+	words []string
+}
+
+type listScreener struct {
+	entries  []listEntry
+	minScore float64
+}
+
+// LoadLists reads watch list files of one name per line, or "list,name"
+// rows naming the list an entry comes from; otherwise the list is the
+// file's name without extension. Blank lines and # comments are skipped.
+// Names scoring below minScore against an entry are not hits.
+func LoadLists(paths []string, minScore float64) (Screener, error) {
+	s := &listScreener{minScore: minScore}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		list := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		err = s.parse(f, list)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("screening: %s: %w", path, err)
+		}
+	}
+
+	if len(s.entries) == 0 {
+		return nil, fmt.Errorf("screening: no names found")
+	}
+	return s, nil
+}
+
+func (s *listScreener) parse(r io.Reader, list string) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := listEntry{list: list}
+		switch len(record) {
+		case 1:
+			entry.name = strings.TrimSpace(record[0])
+		default:
+			if l := strings.TrimSpace(record[0]); l != "" {
+				entry.list = strings.ToUpper(l)
+			}
+			entry.name = strings.TrimSpace(record[1])
+		}
+		entry.words = tokens(entry.name)
+		if len(entry.words) == 0 {
+			continue
+		}
+		s.entries = append(s.entries, entry)
+	}
+}
+
+func (s *listScreener) Screen(_ context.Context, name string) ([]domain.ScreeningHit, error) {
+	words := tokens(name)
+
+	var hits []domain.ScreeningHit
+	for _, entry := range s.entries {
+		score := matchScore(words, entry.words)
+		if score == 0 || score < s.minScore {
+			continue
+		}
+		hits = append(hits, domain.ScreeningHit{
+			List:   entry.list,
+			Name:   entry.name,
+			Score:  score,
+			Source: "local",
+		})
+	}
+	return hits, nil
+}
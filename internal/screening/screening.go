@@ -0,0 +1,75 @@
+// Package screening checks customer names against sanctions and watch
+// lists: local list files and an external screening API.
+package screening
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"payment-gateway/internal/domain"
+)
+
+// Screener returns the watch list entries a name matches; none means clear
+type Screener interface {
+	Screen(ctx context.Context, name string) ([]domain.ScreeningHit, error)
+}
+
+type multiScreener []Screener
+
+// Multi screens against every screener. Hits from the ones that answered
+// are returned alongside the error of any that did not.
+func Multi(screeners ...Screener) Screener {
+	return multiScreener(screeners)
+}
+
+func (m multiScreener) Screen(ctx context.Context, name string) ([]domain.ScreeningHit, error) {
+	var hits []domain.ScreeningHit
+	var errs []error
+	for _, s := range m {
+		found, err := s.Screen(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		hits = append(hits, found...)
+	}
+	return hits, errors.Join(errs...)
+}
+
+// tokens upper-cases a name and splits it into words of letters and
+// digits, so punctuation and spacing do not matter
+func tokens(name string) []string {
+	return strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchScore compares two names word by word, ignoring order. Names match
+// when one's words are all in the other and the shorter has at least two,
+// as when a customer gives two of the three names on a list; the score is
+// the share of the longer name's words in common.
+func matchScore(a, b []string) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return 0
+	}
+
+	words := make(map[string]int, len(b))
+	for _, w := range b {
+		words[w]++
+	}
+	for _, w := range a {
+		if words[w] == 0 {
+			return 0
+		}
+		words[w]--
+	}
+
+	if len(a) < 2 && len(a) != len(b) {
+		return 0
+	}
+	return float64(len(a)) / float64(len(b))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"payment-gateway/internal/bank"
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AccountService verifies destination accounts before money is sent to them
+type AccountService interface {
+	VerifyAccount(ctx context.Context, req domain.AccountVerificationRequest) (*domain.AccountVerification, error)
+}
+
+type accountService struct {
+	inquirers map[string]bank.NameInquirer // by bank/wallet code
+	logger    *logrus.Logger
+}
+
+func NewAccountService(inquirers map[string]bank.NameInquirer, logger *logrus.Logger) AccountService {
+	return &accountService{
+		inquirers: inquirers,
+		logger:    logger,
+	}
+}
+
+func (s *accountService) VerifyAccount(ctx context.Context, req domain.AccountVerificationRequest) (*domain.AccountVerification, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	inquirer, ok := s.inquirers[req.BankCode]
+	if !ok {
+		return nil, domain.ErrNameInquiryUnsupported
+	}
+
+	name, err := inquirer.LookupAccountName(ctx, req.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Account numbers are not logged in full
+	s.logger.WithFields(logrus.Fields{
+		"bank_code": req.BankCode,
+		"account":   maskAccount(req.AccountNumber),
+	}).Info("Account name inquiry succeeded")
+
+	return &domain.AccountVerification{
+		BankCode:      req.BankCode,
+		AccountNumber: req.AccountNumber,
+		AccountName:   name,
+	}, nil
+}
+
+func maskAccount(account string) string {
+	if len(account) <= 4 {
+		return "****"
+	}
+	return "****" + account[len(account)-4:]
+}
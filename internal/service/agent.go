@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// AgentService manages the gateway's own agent network: registration,
+// float top-ups, cash-in against AWAITING_CASH payments and the daily
+// settlement of what each agent collected.
+type AgentService interface {
+	Register(ctx context.Context, req domain.RegisterAgentRequest) (*domain.RegisteredAgent, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.Agent, error)
+	List(ctx context.Context) ([]*domain.Agent, error)
+	Update(ctx context.Context, id uuid.UUID, req domain.UpdateAgentRequest) (*domain.Agent, error)
+	TopUp(ctx context.Context, id uuid.UUID, req domain.TopUpFloatRequest, operator string) (*domain.AgentFloatEntry, error)
+	ListFloat(ctx context.Context, id uuid.UUID, page, limit int) ([]*domain.AgentFloatEntry, error)
+	// Authenticate resolves an agent API token
+	Authenticate(ctx context.Context, token string) (*domain.Agent, error)
+	// CashIn redeems the voucher against the agent's float; it is
+	// idempotent for the same agent reference
+	CashIn(ctx context.Context, agent *domain.Agent, code string, req domain.ConfirmVoucherRequest) (*domain.CashVoucher, error)
+	// SettleDay settles the Ethiopian business day containing day; agents
+	// already settled for that day are skipped
+	SettleDay(ctx context.Context, day time.Time) ([]*domain.AgentSettlement, error)
+	ListSettlements(ctx context.Context, businessDate string) ([]*domain.AgentSettlement, error)
+}
+
+type AgentSettings struct {
+	CommissionRate float64 // Share of each day's cash-in total credited to the agent
+}
+
+type agentService struct {
+	repo        repository.AgentRepository
+	voucherRepo repository.CashVoucherRepository
+	paymentRepo repository.PaymentRepository
+	notifier    NotificationService
+	settings    AgentSettings
+	logger      *logrus.Logger
+}
+
+func NewAgentService(repo repository.AgentRepository, voucherRepo repository.CashVoucherRepository, paymentRepo repository.PaymentRepository, notifier NotificationService, settings AgentSettings, logger *logrus.Logger) AgentService {
+	if settings.CommissionRate < 0 {
+		settings.CommissionRate = 0
+	}
+
+	return &agentService{
+		repo:        repo,
+		voucherRepo: voucherRepo,
+		paymentRepo: paymentRepo,
+		notifier:    notifier,
+		settings:    settings,
+		logger:      logger,
+	}
+}
+
+func (s *agentService) Register(ctx context.Context, req domain.RegisterAgentRequest) (*domain.RegisteredAgent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate agent token: %w", err)
+	}
+	token := "agt_" + hex.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	agent := &domain.Agent{
+		ID:         uuid.New(),
+		Code:       req.Code,
+		Name:       req.Name,
+		Phone:      req.Phone,
+		Region:     req.Region,
+		Status:     domain.AgentActive,
+		TxnLimit:   req.TxnLimit,
+		DailyLimit: req.DailyLimit,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.Create(ctx, agent, hashAgentToken(token)); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id":   agent.ID,
+		"agent_code": agent.Code,
+	}).Info("Agent registered")
+
+	return &domain.RegisteredAgent{Agent: *agent, Token: token}, nil
+}
+
+func (s *agentService) Get(ctx context.Context, id uuid.UUID) (*domain.Agent, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *agentService) List(ctx context.Context) ([]*domain.Agent, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *agentService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateAgentRequest) (*domain.Agent, error) {
+	agent, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Apply(agent); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if err := s.repo.Update(ctx, agent); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *agentService) TopUp(ctx context.Context, id uuid.UUID, req domain.TopUpFloatRequest, operator string) (*domain.AgentFloatEntry, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	entry, err := s.repo.TopUp(ctx, id, req.Amount, req.Reference, operator, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id":      id,
+		"amount":        req.Amount,
+		"balance_after": entry.BalanceAfter,
+		"operator":      operator,
+	}).Info("Agent float topped up")
+
+	return entry, nil
+}
+
+func (s *agentService) ListFloat(ctx context.Context, id uuid.UUID, page, limit int) ([]*domain.AgentFloatEntry, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.ListFloatEntries(ctx, id, limit, (page-1)*limit)
+}
+
+func (s *agentService) Authenticate(ctx context.Context, token string) (*domain.Agent, error) {
+	if token == "" {
+		return nil, domain.ErrAgentNotFound
+	}
+	return s.repo.GetByTokenHash(ctx, hashAgentToken(token))
+}
+
+func (s *agentService) CashIn(ctx context.Context, agent *domain.Agent, code string, req domain.ConfirmVoucherRequest) (*domain.CashVoucher, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	normalized, ok := domain.NormalizeVoucherCode(code)
+	if !ok {
+		return nil, fmt.Errorf("%w: voucher code is not valid, check for typing mistakes", domain.ErrInvalidInput)
+	}
+
+	voucher, err := s.voucherRepo.GetByCode(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"payment_id":      voucher.PaymentID,
+		"voucher_id":      voucher.ID,
+		"agent_code":      agent.Code,
+		"agent_reference": req.AgentReference,
+	})
+
+	if voucher.RedeemedFor(agent.Code, req.AgentReference) {
+		return voucher, nil
+	}
+
+	now := time.Now().UTC()
+	if err := voucher.CheckRedeemable(req.Amount, now); err != nil {
+		if err == domain.ErrVoucherAmount {
+			log.WithField("amount", req.Amount).Warn("Cash voucher amount mismatch")
+		}
+		return nil, err
+	}
+
+	dayStart, _ := businessDay(now)
+	entry, err := s.repo.CashIn(ctx, agent.ID, voucher, req.AgentReference, req.Branch, dayStart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithField("float_balance", entry.BalanceAfter).Info("Agent cash-in accepted; payment settled")
+
+	payment, err := s.paymentRepo.GetByID(ctx, voucher.PaymentID)
+	if err == nil {
+		err = s.notifier.NotifyPaymentStatus(ctx, payment)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to send payment notification")
+	}
+
+	return voucher, nil
+}
+
+func (s *agentService) SettleDay(ctx context.Context, day time.Time) ([]*domain.AgentSettlement, error) {
+	from, businessDate := businessDay(day)
+
+	settlements, err := s.repo.Settle(ctx, businessDate, from, from.AddDate(0, 0, 1), s.settings.CommissionRate, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	var total, commission domain.Amount
+	for _, st := range settlements {
+		total += st.CashInTotal
+		commission += st.Commission
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"business_date": businessDate,
+		"agents":        len(settlements),
+		"cash_in_total": total,
+		"commission":    commission,
+	}).Info("Agent settlement completed")
+
+	return settlements, nil
+}
+
+func (s *agentService) ListSettlements(ctx context.Context, businessDate string) ([]*domain.AgentSettlement, error) {
+	if _, err := time.Parse("2006-01-02", businessDate); err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", domain.ErrInvalidInput)
+	}
+
+	return s.repo.ListSettlements(ctx, businessDate)
+}
+
+// businessDay returns the start of the Ethiopian day containing t and its
+// date; agent limits and settlement follow the Ethiopian business day
+func businessDay(t time.Time) (time.Time, string) {
+	midnight := etime.StartOfDay(t)
+	return midnight, midnight.Format(etime.DateLayout)
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
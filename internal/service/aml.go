@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/screening"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// AMLSettings controls screening of customer names against sanctions and
+// watch lists when payments are created
+type AMLSettings struct {
+	Enabled  bool
+	Screener screening.Screener
+}
+
+// screen checks the customer name and returns any hits, with the reason
+// to hold the payment for review or "" to let it through. A name that
+// cannot be screened is held rather than processed unchecked.
+func (s *paymentService) screen(ctx context.Context, name string) ([]domain.ScreeningHit, string) {
+	if !s.aml.Enabled || s.aml.Screener == nil || strings.TrimSpace(name) == "" {
+		return nil, ""
+	}
+
+	hits, err := s.aml.Screener.Screen(ctx, name)
+	if err != nil {
+		s.logger.WithError(err).Error("AML screening failed, holding payment for review")
+		if len(hits) == 0 {
+			return nil, "Screening unavailable: " + err.Error()
+		}
+	}
+	if len(hits) == 0 {
+		return nil, ""
+	}
+
+	lists := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if !contains(lists, hit.List) {
+			lists = append(lists, hit.List)
+		}
+	}
+	return hits, "Customer name matches " + strings.Join(lists, ", ")
+}
+
+func (s *paymentService) ListReviews(ctx context.Context, status domain.PaymentReviewStatus, page, limit int) ([]*domain.PaymentReview, error) {
+	if status != "" && status != domain.ReviewOpen && status != domain.ReviewCleared && status != domain.ReviewRejected {
+		return nil, fmt.Errorf("%w: status must be OPEN, CLEARED or REJECTED", domain.ErrInvalidInput)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.reviews.List(ctx, status, limit, (page-1)*limit)
+}
+
+func (s *paymentService) GetReview(ctx context.Context, id uuid.UUID) (*domain.PaymentReview, error) {
+	return s.reviews.GetByPayment(ctx, id)
+}
+
+// ReviewPayment records a compliance decision. A cleared payment carries on
+// as it would have without the hit: queued for the bank, sent its OTP, or
+// left awaiting cash. A rejected one fails and the customer is told.
+func (s *paymentService) ReviewPayment(ctx context.Context, id uuid.UUID, req domain.ReviewDecisionRequest, operator string) (*domain.Payment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	review, err := s.reviews.GetByPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.ReviewOpen {
+		return nil, domain.ErrReviewAlreadyDecided
+	}
+
+	now := time.Now().UTC()
+	review.Status = req.Decision
+	review.DecisionNote = req.Note
+	review.DecidedBy = operator
+	review.DecidedAt = &now
+
+	status := domain.StatusFailed
+	if req.Decision == domain.ReviewCleared {
+		status = review.ReleaseStatus
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.reviews.Decide(ctx, review, status); err != nil {
+			return err
+		}
+		if status != domain.StatusPending {
+			return nil
+		}
+		if err := s.publisher.PublishPaymentCreated(ctx, id); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Error("Failed to publish payment message")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": id,
+		"review_id":  review.ID,
+		"decision":   review.Status,
+		"status":     status,
+		"operator":   operator,
+	}).Warn("AML review decided")
+
+	switch status {
+	case domain.StatusAwaitingOTP:
+		if err := s.issueOTP(ctx, payment, false); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to send payment OTP")
+		}
+		if err := s.reminders.Schedule(ctx, domain.ReminderSubjectPayment, id, id, nil); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to schedule payment reminders")
+		}
+	case domain.StatusFailed:
+		if err := s.notifier.NotifyPaymentStatus(ctx, payment); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to send payment notification")
+		}
+	}
+
+	return payment, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AnalyticsService answers merchant BI questions over successful payments.
+// Foreign currency volume is valued at its settled ETB amount, or at the
+// current rate while it has none.
+type AnalyticsService interface {
+	TopCustomers(ctx context.Context, r domain.AnalyticsRange, limit int) ([]*domain.TopCustomer, error)
+	RepeatRate(ctx context.Context, r domain.AnalyticsRange) (*domain.RepeatRate, error)
+	BankMix(ctx context.Context, r domain.AnalyticsRange, interval domain.AnalyticsInterval) ([]*domain.BankMixPoint, error)
+}
+
+type analyticsService struct {
+	repo   repository.AnalyticsRepository
+	fx     FXService
+	logger *logrus.Logger
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository, fx FXService, logger *logrus.Logger) AnalyticsService {
+	return &analyticsService{
+		repo:   repo,
+		fx:     fx,
+		logger: logger,
+	}
+}
+
+func (s *analyticsService) TopCustomers(ctx context.Context, r domain.AnalyticsRange, limit int) ([]*domain.TopCustomer, error) {
+	from, to, err := analyticsBounds(&r)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.TopCustomers(ctx, from, to, r.MerchantID, rates, limit)
+}
+
+func (s *analyticsService) RepeatRate(ctx context.Context, r domain.AnalyticsRange) (*domain.RepeatRate, error) {
+	from, to, err := analyticsBounds(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	customers, repeat, err := s.repo.RepeatCustomers(ctx, from, to, r.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := &domain.RepeatRate{
+		AnalyticsRange:  r,
+		Customers:       customers,
+		RepeatCustomers: repeat,
+	}
+	if customers > 0 {
+		rate.Rate = float64(repeat) / float64(customers)
+	}
+	return rate, nil
+}
+
+func (s *analyticsService) BankMix(ctx context.Context, r domain.AnalyticsRange, interval domain.AnalyticsInterval) ([]*domain.BankMixPoint, error) {
+	from, to, err := analyticsBounds(&r)
+	if err != nil {
+		return nil, err
+	}
+	if interval == "" {
+		interval = domain.IntervalDay
+	}
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("%w: interval must be day, week or month", domain.ErrInvalidInput)
+	}
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.BankMix(ctx, from, to, r.MerchantID, interval, rates)
+}
+
+// analyticsBounds validates the range and returns it as instants, from the
+// start of its first business day to the end of its last
+func analyticsBounds(r *domain.AnalyticsRange) (time.Time, time.Time, error) {
+	_, today := businessDay(time.Now())
+	if err := r.Validate(today); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	from, _ := etime.ParseDate(r.From)
+	to, _ := etime.ParseDate(r.To)
+	return from, to.AddDate(0, 0, 1), nil
+}
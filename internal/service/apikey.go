@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyService issues merchant API keys and resolves them on each request
+type APIKeyService interface {
+	Issue(ctx context.Context, merchantID uuid.UUID, req domain.IssueAPIKeyRequest, operator string) (*domain.IssuedAPIKey, error)
+	List(ctx context.Context, merchantID uuid.UUID) ([]*domain.APIKey, error)
+	// Rotate issues a replacement with the same name and scopes. The old
+	// key keeps working for the rotation grace period.
+	Rotate(ctx context.Context, merchantID, id uuid.UUID, rotatedBy string) (*domain.IssuedAPIKey, error)
+	// Revoke stops the key at once
+	Revoke(ctx context.Context, merchantID, id uuid.UUID) error
+	// Authenticate resolves a key to its usable record; ErrAPIKeyNotFound
+	// for unknown, revoked or expired keys
+	Authenticate(ctx context.Context, key string) (*domain.APIKey, error)
+	// CheckToken reports whether a merchant JWT's key is still usable:
+	// ErrAPIKeyNotFound once it is revoked or expired, ErrMerchantSuspended
+	// once its merchant is. Answers are cached for tokenCheckTTL.
+	CheckToken(ctx context.Context, merchantID, keyID uuid.UUID) error
+}
+
+// tokenCheckTTL bounds how long a revoked key's JWTs keep working
+const tokenCheckTTL = 30 * time.Second
+
+type tokenCheck struct {
+	err     error
+	checked time.Time
+}
+
+type APIKeySettings struct {
+	RotationGrace time.Duration // How long a rotated key keeps working
+}
+
+type apiKeyService struct {
+	repo      repository.APIKeyRepository
+	merchants repository.MerchantRepository
+	settings  APIKeySettings
+	logger    *logrus.Logger
+
+	checksMu sync.Mutex
+	checks   map[uuid.UUID]tokenCheck // By key ID
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, merchants repository.MerchantRepository, settings APIKeySettings, logger *logrus.Logger) APIKeyService {
+	if settings.RotationGrace <= 0 {
+		settings.RotationGrace = 24 * time.Hour
+	}
+
+	return &apiKeyService{
+		repo:      repo,
+		merchants: merchants,
+		settings:  settings,
+		logger:    logger,
+		checks:    make(map[uuid.UUID]tokenCheck),
+	}
+}
+
+func (s *apiKeyService) Issue(ctx context.Context, merchantID uuid.UUID, req domain.IssueAPIKeyRequest, operator string) (*domain.IssuedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if _, err := s.merchants.GetByID(ctx, merchantID); err != nil {
+		return nil, err
+	}
+
+	issued, hash, err := newAPIKey(merchantID, req.Name, req.Scopes, operator)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, &issued.APIKey, hash); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": merchantID,
+		"key_id":      issued.ID,
+		"scopes":      issued.Scopes,
+		"operator":    operator,
+	}).Info("API key issued")
+
+	return issued, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, merchantID uuid.UUID) ([]*domain.APIKey, error) {
+	if _, err := s.merchants.GetByID(ctx, merchantID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByMerchant(ctx, merchantID)
+}
+
+func (s *apiKeyService) Rotate(ctx context.Context, merchantID, id uuid.UUID, rotatedBy string) (*domain.IssuedAPIKey, error) {
+	old, err := s.repo.GetByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	issued, hash, err := newAPIKey(merchantID, old.Name, old.Scopes, rotatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rotate(ctx, old.ID, &issued.APIKey, hash, issued.CreatedAt.Add(s.settings.RotationGrace)); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": merchantID,
+		"old_key_id":  old.ID,
+		"key_id":      issued.ID,
+		"rotated_by":  rotatedBy,
+	}).Info("API key rotated")
+
+	return issued, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, merchantID, id uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, merchantID, id, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	s.checksMu.Lock()
+	delete(s.checks, id)
+	s.checksMu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": merchantID,
+		"key_id":      id,
+	}).Warn("API key revoked")
+
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*domain.APIKey, error) {
+	if key == "" {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	apiKey, err := s.repo.GetByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !apiKey.Usable(now) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	merchant, err := s.merchants.GetByID(ctx, apiKey.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status == domain.MerchantSuspended {
+		return nil, domain.ErrMerchantSuspended
+	}
+
+	// Best effort; a missed update only makes last_used_at stale
+	if err := s.repo.Touch(ctx, apiKey.ID, now); err != nil {
+		s.logger.WithError(err).WithField("key_id", apiKey.ID).Warn("Failed to record API key use")
+	}
+
+	return apiKey, nil
+}
+
+func (s *apiKeyService) CheckToken(ctx context.Context, merchantID, keyID uuid.UUID) error {
+	now := time.Now().UTC()
+	s.checksMu.Lock()
+	check, ok := s.checks[keyID]
+	s.checksMu.Unlock()
+	if ok && now.Sub(check.checked) < tokenCheckTTL {
+		return check.err
+	}
+
+	err := s.checkToken(ctx, merchantID, keyID, now)
+	if err != nil && err != domain.ErrAPIKeyNotFound && err != domain.ErrMerchantSuspended {
+		// Database trouble is not cached
+		return err
+	}
+
+	s.checksMu.Lock()
+	for id, c := range s.checks {
+		if now.Sub(c.checked) >= tokenCheckTTL {
+			delete(s.checks, id)
+		}
+	}
+	s.checks[keyID] = tokenCheck{err: err, checked: now}
+	s.checksMu.Unlock()
+	return err
+}
+
+func (s *apiKeyService) checkToken(ctx context.Context, merchantID, keyID uuid.UUID, now time.Time) error {
+	apiKey, err := s.repo.GetByID(ctx, merchantID, keyID)
+	if err != nil {
+		return err
+	}
+	if !apiKey.Usable(now) {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	merchant, err := s.merchants.GetByID(ctx, merchantID)
+	if err != nil {
+		if err == domain.ErrMerchantNotFound {
+			return domain.ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if merchant.Status == domain.MerchantSuspended {
+		return domain.ErrMerchantSuspended
+	}
+	return nil
+}
+
+// newAPIKey generates a key and returns it with the hash that is stored
+func newAPIKey(merchantID uuid.UUID, name string, scopes []domain.APIKeyScope, createdBy string) (*domain.IssuedAPIKey, string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := "sk_" + hex.EncodeToString(secret)
+
+	return &domain.IssuedAPIKey{
+		APIKey: domain.APIKey{
+			ID:         uuid.New(),
+			MerchantID: merchantID,
+			Name:       name,
+			Prefix:     key[:11],
+			CreatedBy:  createdBy,
+			CreatedAt:  time.Now().UTC(),
+			Scopes:     scopes,
+		},
+		Key: key,
+	}, hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/repository"
+	"payment-gateway/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// AttachmentService stores proof-of-payment documents. Contents live in
+// object storage and are only served through short-lived signed links.
+type AttachmentService interface {
+	Upload(ctx context.Context, paymentID uuid.UUID, kind domain.AttachmentKind, fileName string, data []byte, uploadedBy string) (*domain.Attachment, error)
+	// List returns a payment's attachments with fresh download links
+	List(ctx context.Context, paymentID uuid.UUID) ([]*domain.Attachment, error)
+	// Open verifies a download link and returns the attachment contents
+	Open(ctx context.Context, id uuid.UUID, expires, signature string) (*domain.Attachment, io.ReadCloser, error)
+}
+
+type AttachmentSettings struct {
+	BaseURL       string // Public base URL for download links
+	SigningSecret string
+	LinkTTL       time.Duration
+	MaxSize       int64
+	AllowedTypes  []string // Sniffed MIME types, e.g. application/pdf
+}
+
+type attachmentService struct {
+	repo        repository.AttachmentRepository
+	paymentRepo repository.PaymentRepository
+	store       storage.ObjectStore
+	events      messaging.EventPublisher
+	settings    AttachmentSettings
+	logger      *logrus.Logger
+}
+
+// NewAttachmentService returns a service whose calls fail with
+// ErrAttachmentsDisabled when store is nil or no signing secret is set.
+// Uploads are announced through events when it is not nil.
+func NewAttachmentService(repo repository.AttachmentRepository, paymentRepo repository.PaymentRepository, store storage.ObjectStore, events messaging.EventPublisher, settings AttachmentSettings, logger *logrus.Logger) AttachmentService {
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+	if settings.LinkTTL <= 0 {
+		settings.LinkTTL = 15 * time.Minute
+	}
+	if settings.MaxSize <= 0 {
+		settings.MaxSize = 10 << 20
+	}
+	if len(settings.AllowedTypes) == 0 {
+		settings.AllowedTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+	}
+
+	return &attachmentService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		store:       store,
+		events:      events,
+		settings:    settings,
+		logger:      logger,
+	}
+}
+
+func (s *attachmentService) enabled() bool {
+	return s.store != nil && s.settings.SigningSecret != ""
+}
+
+func (s *attachmentService) Upload(ctx context.Context, paymentID uuid.UUID, kind domain.AttachmentKind, fileName string, data []byte, uploadedBy string) (*domain.Attachment, error) {
+	if !s.enabled() {
+		return nil, domain.ErrAttachmentsDisabled
+	}
+
+	if kind == "" {
+		kind = domain.AttachmentOther
+	}
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("%w: unknown attachment kind %q", domain.ErrInvalidInput, kind)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", domain.ErrInvalidInput)
+	}
+	if int64(len(data)) > s.settings.MaxSize {
+		return nil, domain.ErrAttachmentTooLarge
+	}
+
+	// Trust the contents, not the client's Content-Type header
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !contains(s.settings.AllowedTypes, contentType) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAttachmentType, contentType)
+	}
+
+	if _, err := s.paymentRepo.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	attachment := &domain.Attachment{
+		ID:          uuid.New(),
+		PaymentID:   paymentID,
+		Kind:        kind,
+		FileName:    truncate(filepath.Base(fileName), 255),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(sum[:]),
+		UploadedBy:  uploadedBy,
+		CreatedAt:   time.Now().UTC(),
+	}
+	attachment.StorageKey = "attachments/" + paymentID.String() + "/" + attachment.ID.String()
+
+	if err := s.store.Put(ctx, attachment.StorageKey, contentType, data); err != nil {
+		s.logger.WithError(err).WithField("payment_id", paymentID).Error("Failed to store attachment")
+		return nil, err
+	}
+
+	// An object left behind by a failed insert is unreachable but harmless
+	if err := s.repo.Create(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id":    paymentID,
+		"attachment_id": attachment.ID,
+		"kind":          kind,
+		"uploaded_by":   uploadedBy,
+	}).Info("Payment attachment uploaded")
+
+	if s.events != nil {
+		event := &domain.AttachmentUploadedEvent{Attachment: attachment, Content: data}
+		if err := s.events.PublishEvent(ctx, messaging.EventAttachmentUploaded, event); err != nil {
+			s.logger.WithError(err).WithField("attachment_id", attachment.ID).Warn("Failed to announce attachment")
+		}
+	}
+
+	s.addLink(attachment, time.Now())
+	return attachment, nil
+}
+
+func (s *attachmentService) List(ctx context.Context, paymentID uuid.UUID) ([]*domain.Attachment, error) {
+	if !s.enabled() {
+		return nil, nil
+	}
+
+	attachments, err := s.repo.ListByPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, attachment := range attachments {
+		s.addLink(attachment, now)
+	}
+
+	return attachments, nil
+}
+
+func (s *attachmentService) Open(ctx context.Context, id uuid.UUID, expires, signature string) (*domain.Attachment, io.ReadCloser, error) {
+	if !s.enabled() {
+		return nil, nil, domain.ErrAttachmentsDisabled
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, domain.ErrAttachmentLinkInvalid
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(id, expiresAt)) {
+		return nil, nil, domain.ErrAttachmentLinkInvalid
+	}
+
+	attachment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		s.logger.WithError(err).WithField("attachment_id", id).Error("Failed to read attachment from storage")
+		return nil, nil, err
+	}
+
+	return attachment, body, nil
+}
+
+func (s *attachmentService) addLink(attachment *domain.Attachment, now time.Time) {
+	expiresAt := now.Add(s.settings.LinkTTL).UTC().Truncate(time.Second)
+	attachment.URL = fmt.Sprintf("%s/api/v1/attachments/%s?expires=%d&signature=%s",
+		s.settings.BaseURL, attachment.ID, expiresAt.Unix(), hex.EncodeToString(s.mac(attachment.ID, expiresAt.Unix())))
+	attachment.URLExpiresAt = &expiresAt
+}
+
+func (s *attachmentService) mac(id uuid.UUID, expires int64) []byte {
+	h := hmac.New(sha256.New, []byte(s.settings.SigningSecret))
+	h.Write([]byte("attachment:"))
+	h.Write(id[:])
+	h.Write([]byte(strconv.FormatInt(expires, 10)))
+	return h.Sum(nil)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AuditService reads the audit log kept for NBE audits
+type AuditService interface {
+	// ListEvents returns matching events, newest first, and the total count
+	ListEvents(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]*domain.AuditEvent, int, error)
+}
+
+type auditService struct {
+	repo   repository.AuditRepository
+	logger *logrus.Logger
+}
+
+func NewAuditService(repo repository.AuditRepository, logger *logrus.Logger) AuditService {
+	return &auditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *auditService) ListEvents(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]*domain.AuditEvent, int, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, err := s.repo.List(ctx, filter, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Client control actions
+const (
+	ClientActionBlock     = "block"
+	ClientActionChallenge = "challenge" // Require the customer's OTP before debiting
+)
+
+// ClientControlSettings screens create-payment requests by client IP.
+// Challenged requests are held for OTP confirmation; requests that cannot
+// be challenged (no customer phone, OTP disabled, cash payments) are blocked.
+type ClientControlSettings struct {
+	Enabled          bool
+	AllowedCountries []string // Requests resolved to other countries get CountryAction; unresolved IPs pass
+	CountryAction    string
+	BlockedIPs       []string // IPs or CIDRs
+	VelocityWindow   time.Duration
+	VelocityMax      int // Payments per IP per window; 0 disables
+	VelocityAction   string
+}
+
+// checkClient applies the client controls, switching req to OTP
+// confirmation when the request is challenged
+func (s *paymentService) checkClient(ctx context.Context, req *domain.CreatePaymentRequest) error {
+	client := req.Client
+	if !s.clients.Enabled || client.IP == "" {
+		return nil
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"client_ip":      client.IP,
+		"client_country": client.Country,
+	})
+
+	ip, err := netip.ParseAddr(client.IP)
+	if err == nil {
+		ip = ip.Unmap()
+		for _, prefix := range s.blockedIPs {
+			if prefix.Contains(ip) {
+				log.Warn("Payment blocked: client IP is blocklisted")
+				return fmt.Errorf("%w: IP address is blocked", domain.ErrClientBlocked)
+			}
+		}
+	}
+
+	if client.Country != "" && len(s.clients.AllowedCountries) > 0 && !contains(s.clients.AllowedCountries, client.Country) {
+		if err := s.challenge(req, s.clients.CountryAction, "requests from "+client.Country+" are not accepted"); err != nil {
+			log.Warn("Payment blocked: unexpected client country")
+			return err
+		}
+		log.Info("Payment challenged: unexpected client country")
+		return nil
+	}
+
+	if s.clients.VelocityMax > 0 {
+		count, err := s.repo.CountByClientIP(ctx, client.IP, time.Now().UTC().Add(-s.clients.VelocityWindow))
+		if err != nil {
+			return err
+		}
+		if count >= s.clients.VelocityMax {
+			log = log.WithField("payments_in_window", count)
+			if err := s.challenge(req, s.clients.VelocityAction, "too many payments from this IP address"); err != nil {
+				log.Warn("Payment blocked: client IP velocity exceeded")
+				return err
+			}
+			log.Info("Payment challenged: client IP velocity exceeded")
+		}
+	}
+
+	return nil
+}
+
+// challenge requires OTP confirmation on req, or returns ErrClientBlocked
+// when the action is block or the payment cannot take an OTP
+func (s *paymentService) challenge(req *domain.CreatePaymentRequest, action, reason string) error {
+	if action == ClientActionChallenge && s.otp.Enabled && req.CustomerPhone != "" && !req.PayByCash {
+		req.RequireOTP = true
+		return nil
+	}
+	return fmt.Errorf("%w: %s", domain.ErrClientBlocked, reason)
+}
+
+// parseBlockedIPs reads IPs and CIDRs, logging and skipping invalid entries
+func parseBlockedIPs(values []string, logger *logrus.Logger) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(value)
+			if ipErr != nil {
+				logger.WithField("value", value).Warn("Ignoring invalid blocked IP")
+				continue
+			}
+			ip = ip.Unmap()
+			prefix = netip.PrefixFrom(ip, ip.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
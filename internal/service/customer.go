@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// CustomerService registers KYC customer profiles for merchants and records
+// operators' verification of them
+type CustomerService interface {
+	Create(ctx context.Context, req domain.CreateCustomerRequest, merchantID *uuid.UUID) (*domain.Customer, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.Customer, error)
+	// List returns customers oldest first, e.g. the PENDING verification queue
+	List(ctx context.Context, status domain.CustomerVerificationStatus, merchantID *uuid.UUID, page, limit int) ([]*domain.Customer, error)
+	// Verify marks a customer VERIFIED or REJECTED after checking their
+	// documents. A decision may be revised, e.g. when a document expires.
+	Verify(ctx context.Context, id uuid.UUID, req domain.VerifyCustomerRequest, operator string) (*domain.Customer, error)
+	// ListPayments returns a page of the customer's payments with totals
+	// over all of them
+	ListPayments(ctx context.Context, id uuid.UUID, page, limit int) (*domain.CustomerPaymentHistory, error)
+}
+
+type customerService struct {
+	repo     repository.CustomerRepository
+	payments repository.PaymentRepository
+	fx       FXService
+	logger   *logrus.Logger
+}
+
+func NewCustomerService(repo repository.CustomerRepository, payments repository.PaymentRepository, fx FXService, logger *logrus.Logger) CustomerService {
+	return &customerService{
+		repo:     repo,
+		payments: payments,
+		fx:       fx,
+		logger:   logger,
+	}
+}
+
+func (s *customerService) Create(ctx context.Context, req domain.CreateCustomerRequest, merchantID *uuid.UUID) (*domain.Customer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	now := time.Now().UTC()
+	customer := &domain.Customer{
+		ID:                 uuid.New(),
+		FullName:           req.FullName,
+		NationalID:         req.NationalID,
+		PassportNumber:     req.PassportNumber,
+		Phone:              req.Phone,
+		Email:              req.Email,
+		VerificationStatus: domain.CustomerPending,
+		MerchantID:         merchantID,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.repo.Create(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"customer_id": customer.ID,
+		"merchant_id": merchantID,
+	}).Info("Customer registered")
+
+	return customer, nil
+}
+
+func (s *customerService) Get(ctx context.Context, id uuid.UUID) (*domain.Customer, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *customerService) List(ctx context.Context, status domain.CustomerVerificationStatus, merchantID *uuid.UUID, page, limit int) ([]*domain.Customer, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be PENDING, VERIFIED or REJECTED", domain.ErrInvalidInput)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.List(ctx, status, merchantID, limit, (page-1)*limit)
+}
+
+func (s *customerService) Verify(ctx context.Context, id uuid.UUID, req domain.VerifyCustomerRequest, operator string) (*domain.Customer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	customer, err := s.repo.Verify(ctx, id, req.Decision, req.Note, operator, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"customer_id": id,
+		"status":      customer.VerificationStatus,
+		"operator":    operator,
+	}).Info("Customer verification decided")
+
+	return customer, nil
+}
+
+func (s *customerService) ListPayments(ctx context.Context, id uuid.UUID, page, limit int) (*domain.CustomerPaymentHistory, error) {
+	customer, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.repo.PaymentSummary(ctx, id, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.payments.ListByCustomer(ctx, id, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &domain.CustomerPaymentHistory{
+		Customer: customer,
+		Summary:  summary,
+		Payments: make([]domain.PaymentResponse, len(payments)),
+		Total:    summary.Payments,
+		Page:     page,
+		Limit:    limit,
+		HasMore:  summary.Payments > page*limit,
+	}
+	for i, payment := range payments {
+		history.Payments[i] = payment.ToResponse()
+	}
+
+	return history, nil
+}
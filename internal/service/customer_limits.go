@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CustomerLimitSettings caps each customer's cumulative daily and monthly
+// volume. Customers identified only by phone get the Basic tier; a national
+// ID on the payment moves them to Verified.
+type CustomerLimitSettings struct {
+	Enabled  bool
+	Reject   bool // Reject payments over the limit instead of flagging them
+	Basic    domain.LimitTier
+	Verified domain.LimitTier
+}
+
+// checkCustomerLimits returns the flag to store on the payment, or
+// ErrCustomerLimitExceeded when limits are enforced by rejection. Foreign
+// currency volume counts at its settled ETB amount, or at today's rate while
+// it has none.
+func (s *paymentService) checkCustomerLimits(ctx context.Context, amount domain.Amount, currency domain.Currency, phone, nationalID string) (domain.LimitFlag, error) {
+	if !s.limits.Enabled || (phone == "" && nationalID == "") {
+		return "", nil
+	}
+
+	tier := s.limits.Basic
+	if nationalID != "" {
+		tier = s.limits.Verified
+	}
+
+	dayStart, _ := businessDay(time.Now())
+	monthStart := dayStart.AddDate(0, 0, 1-dayStart.Day())
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return "", err
+	}
+
+	usage, err := s.repo.CustomerVolume(ctx, phone, nationalID, dayStart, monthStart, rates)
+	if err != nil {
+		return "", err
+	}
+
+	amountETB := amount
+	if currency.IsForeign() {
+		amountETB = domain.ConvertToETB(amount, rates[currency])
+	}
+
+	flag := tier.Check(usage, amountETB)
+	if flag == "" {
+		return "", nil
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"customer_phone": phone,
+		"tier":           tier.Name,
+		"limit":          flag,
+		"amount_etb":     amountETB,
+		"day_etb":        usage.DayETB,
+		"month_etb":      usage.MonthETB,
+	})
+
+	if s.limits.Reject {
+		log.Info("Payment rejected over customer limit")
+		return "", fmt.Errorf("%w: %s limit of the %s tier", domain.ErrCustomerLimitExceeded, flag, tier.Name)
+	}
+
+	log.Warn("Payment flagged over customer limit")
+	return flag, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Failures shown on the dashboard
+const dashboardRecentFailures = 10
+
+// DashboardService builds the merchant portal home screen
+type DashboardService interface {
+	// Today summarizes the current Ethiopian business day, for one merchant
+	// or, with a nil merchantID, for all of them
+	Today(ctx context.Context, merchantID *uuid.UUID) (*domain.Dashboard, error)
+}
+
+type dashboardService struct {
+	paymentRepo    repository.PaymentRepository
+	settlementRepo repository.SettlementRepository
+	logger         *logrus.Logger
+}
+
+func NewDashboardService(paymentRepo repository.PaymentRepository, settlementRepo repository.SettlementRepository, logger *logrus.Logger) DashboardService {
+	return &dashboardService{
+		paymentRepo:    paymentRepo,
+		settlementRepo: settlementRepo,
+		logger:         logger,
+	}
+}
+
+func (s *dashboardService) Today(ctx context.Context, merchantID *uuid.UUID) (*domain.Dashboard, error) {
+	now := time.Now().UTC()
+	dayStart, businessDate := businessDay(now)
+
+	counts, err := s.paymentRepo.CountsBetween(ctx, dayStart, dayStart.AddDate(0, 0, 1), merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &domain.Dashboard{
+		BusinessDate:   businessDate,
+		Volume:         []domain.DashboardVolume{},
+		RecentFailures: []domain.PaymentResponse{},
+		GeneratedAt:    now,
+	}
+
+	// Volume is listed in the order of SupportedCurrencies
+	volumes := make(map[domain.Currency]*domain.DashboardVolume)
+	for _, c := range counts {
+		v, ok := volumes[c.Currency]
+		if !ok {
+			v = &domain.DashboardVolume{Currency: c.Currency}
+			volumes[c.Currency] = v
+		}
+		v.Count += c.Count
+		v.Amount += c.Amount
+
+		dashboard.TotalCount += c.Count
+		switch c.Status {
+		case domain.StatusSuccess:
+			v.SuccessAmount += c.Amount
+			dashboard.SuccessCount += c.Count
+		case domain.StatusFailed:
+			dashboard.FailedCount += c.Count
+		case domain.StatusCancelled, domain.StatusExpired:
+			// Never processed; neither pending nor a failure
+		default:
+			dashboard.PendingCount += c.Count
+		}
+	}
+	for _, currency := range domain.SupportedCurrencies {
+		if v, ok := volumes[currency]; ok {
+			dashboard.Volume = append(dashboard.Volume, *v)
+		}
+	}
+
+	if finished := dashboard.SuccessCount + dashboard.FailedCount; finished > 0 {
+		dashboard.SuccessRate = float64(dashboard.SuccessCount) / float64(finished)
+	}
+
+	failures, err := s.paymentRepo.ListRecentByStatus(ctx, domain.StatusFailed, dayStart, merchantID, dashboardRecentFailures)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range failures {
+		dashboard.RecentFailures = append(dashboard.RecentFailures, payment.ToResponse())
+	}
+
+	if dashboard.Settlement.StatementsToday, err = s.settlementRepo.CountSince(ctx, dayStart); err != nil {
+		return nil, err
+	}
+	files, err := s.settlementRepo.List(ctx, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		dashboard.Settlement.LastStatement = files[0]
+	}
+
+	return dashboard, nil
+}
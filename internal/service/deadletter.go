@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DeadLetterService is the operators' view of the dead letter queue: the
+// messages the worker gave up on after its retries
+type DeadLetterService interface {
+	List(ctx context.Context, limit int) ([]*domain.DeadLetter, error)
+	// Requeue sends dead letters back to the worker with their retries reset
+	Requeue(ctx context.Context, req domain.RequeueDeadLettersRequest, operator string) (int, error)
+	Purge(ctx context.Context, operator string) (int, error)
+}
+
+type deadLetterService struct {
+	queue  messaging.DeadLetters
+	logger *logrus.Logger
+}
+
+// NewDeadLetterService returns a service whose calls fail with
+// ErrDeadLettersUnavailable when queue is nil
+func NewDeadLetterService(queue messaging.DeadLetters, logger *logrus.Logger) DeadLetterService {
+	return &deadLetterService{
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+func (s *deadLetterService) List(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	if s.queue == nil {
+		return nil, domain.ErrDeadLettersUnavailable
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	return s.queue.ListDeadLetters(ctx, limit)
+}
+
+func (s *deadLetterService) Requeue(ctx context.Context, req domain.RequeueDeadLettersRequest, operator string) (int, error) {
+	if s.queue == nil {
+		return 0, domain.ErrDeadLettersUnavailable
+	}
+	if !req.All && len(req.MessageIDs) == 0 {
+		return 0, fmt.Errorf("%w: message_ids is required unless all is set", domain.ErrInvalidInput)
+	}
+
+	requeued, err := s.queue.RequeueDeadLetters(ctx, req.MessageIDs, req.All)
+	s.logger.WithFields(logrus.Fields{
+		"operator":  operator,
+		"requested": len(req.MessageIDs),
+		"all":       req.All,
+		"requeued":  requeued,
+	}).Info("Dead letters requeued")
+	return requeued, err
+}
+
+func (s *deadLetterService) Purge(ctx context.Context, operator string) (int, error) {
+	if s.queue == nil {
+		return 0, domain.ErrDeadLettersUnavailable
+	}
+
+	purged, err := s.queue.PurgeDeadLetters(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"operator": operator,
+		"purged":   purged,
+	}).Warn("Dead letter queue purged")
+	return purged, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// DisputeService records chargebacks against successful payments. Each
+// change is published as a dispute.* event so merchants can react; the
+// event is written in the same transaction as the change.
+type DisputeService interface {
+	OpenDispute(ctx context.Context, paymentID uuid.UUID, req domain.OpenDisputeRequest, operator string) (*domain.Dispute, error)
+	GetDispute(ctx context.Context, id uuid.UUID) (*domain.Dispute, error)
+	ListDisputes(ctx context.Context, paymentID uuid.UUID) ([]*domain.Dispute, error)
+	SubmitEvidence(ctx context.Context, id uuid.UUID, req domain.SubmitDisputeEvidenceRequest) (*domain.Dispute, error)
+	ResolveDispute(ctx context.Context, id uuid.UUID, req domain.ResolveDisputeRequest, operator string) (*domain.Dispute, error)
+}
+
+type disputeService struct {
+	repo      repository.DisputeRepository
+	payments  repository.PaymentRepository
+	tx        repository.Transactor
+	publisher messaging.PaymentPublisher
+	logger    *logrus.Logger
+}
+
+func NewDisputeService(repo repository.DisputeRepository, payments repository.PaymentRepository, tx repository.Transactor, publisher messaging.PaymentPublisher, logger *logrus.Logger) DisputeService {
+	return &disputeService{
+		repo:      repo,
+		payments:  payments,
+		tx:        tx,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+func (s *disputeService) OpenDispute(ctx context.Context, paymentID uuid.UUID, req domain.OpenDisputeRequest, operator string) (*domain.Dispute, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	payment, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusSuccess {
+		return nil, domain.ErrPaymentNotDisputable
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = payment.Amount
+	}
+	if amount > payment.Amount {
+		return nil, domain.ErrDisputeExceedsPayment
+	}
+
+	now := time.Now().UTC()
+	dispute := &domain.Dispute{
+		ID:            uuid.New(),
+		PaymentID:     payment.ID,
+		Amount:        amount,
+		Currency:      payment.Currency,
+		Reason:        req.Reason,
+		BankReference: req.BankReference,
+		Status:        domain.DisputeOpen,
+		OpenedBy:      operator,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, dispute); err != nil {
+			return err
+		}
+		return s.publish(ctx, messaging.EventDisputeOpened, dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"dispute_id": dispute.ID,
+		"amount":     dispute.Amount,
+		"operator":   operator,
+	}).Warn("Dispute opened")
+
+	return dispute, nil
+}
+
+func (s *disputeService) GetDispute(ctx context.Context, id uuid.UUID) (*domain.Dispute, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *disputeService) ListDisputes(ctx context.Context, paymentID uuid.UUID) ([]*domain.Dispute, error) {
+	if _, err := s.payments.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByPayment(ctx, paymentID)
+}
+
+// SubmitEvidence records the merchant's answer; it can be given once
+func (s *disputeService) SubmitEvidence(ctx context.Context, id uuid.UUID, req domain.SubmitDisputeEvidenceRequest) (*domain.Dispute, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	dispute, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		submitted, err := s.repo.SubmitEvidence(ctx, id, req.Evidence)
+		if err != nil {
+			return err
+		}
+		if !submitted {
+			return domain.ErrDisputeClosed
+		}
+		return s.publish(ctx, messaging.EventDisputeEvidenceSubmitted, dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithField("dispute_id", id).Info("Dispute evidence submitted")
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// ResolveDispute records the bank's decision. The bank takes a lost
+// dispute's money back itself; this only records the outcome.
+func (s *disputeService) ResolveDispute(ctx context.Context, id uuid.UUID, req domain.ResolveDisputeRequest, operator string) (*domain.Dispute, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	dispute, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	event := messaging.EventDisputeWon
+	if req.Outcome == domain.DisputeLost {
+		event = messaging.EventDisputeLost
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		resolved, err := s.repo.Resolve(ctx, id, req.Outcome, req.Resolution, operator, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if !resolved {
+			return domain.ErrDisputeClosed
+		}
+		return s.publish(ctx, event, dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"dispute_id": id,
+		"outcome":    req.Outcome,
+		"operator":   operator,
+	}).Info("Dispute resolved")
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// publish runs inside the change's transaction: through the outbox a
+// failure rolls the change back, straight to RabbitMQ it waits for the
+// commit
+func (s *disputeService) publish(ctx context.Context, event string, dispute *domain.Dispute) error {
+	if err := s.publisher.PublishDisputeEvent(ctx, event, dispute.PaymentID, dispute.ID); err != nil {
+		s.logger.WithError(err).WithField("dispute_id", dispute.ID).Error("Failed to publish dispute event")
+		return err
+	}
+	return nil
+}
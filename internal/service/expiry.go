@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExpiryService gives up on payments left PENDING, such as those whose
+// queue message was lost, so they do not wait forever.
+type ExpiryService interface {
+	// ExpireStale moves PENDING payments older than the TTL to EXPIRED and
+	// returns how many it swept
+	ExpireStale(ctx context.Context) (int, error)
+}
+
+type ExpirySettings struct {
+	TTL       time.Duration
+	BatchSize int
+}
+
+type expiryService struct {
+	repo      repository.PaymentRepository
+	tx        repository.Transactor
+	publisher messaging.PaymentPublisher
+	notifier  NotificationService
+	reminders ReminderService
+	settings  ExpirySettings
+	logger    *logrus.Logger
+}
+
+func NewExpiryService(repo repository.PaymentRepository, tx repository.Transactor, publisher messaging.PaymentPublisher, notifier NotificationService, reminders ReminderService, settings ExpirySettings, logger *logrus.Logger) ExpiryService {
+	if settings.TTL <= 0 {
+		settings.TTL = 24 * time.Hour
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 100
+	}
+
+	return &expiryService{
+		repo:      repo,
+		tx:        tx,
+		publisher: publisher,
+		notifier:  notifier,
+		reminders: reminders,
+		settings:  settings,
+		logger:    logger,
+	}
+}
+
+func (s *expiryService) ExpireStale(ctx context.Context) (int, error) {
+	payments, err := s.repo.ListStale(ctx, domain.StatusPending, time.Now().UTC().Add(-s.settings.TTL), s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+
+		log := s.logger.WithField("payment_id", payment.ID)
+
+		// The event commits with the status, so it is never sent for a
+		// payment the worker picked up in the meantime
+		var updated bool
+		err := s.tx.InTx(ctx, func(ctx context.Context) error {
+			var err error
+			updated, err = s.repo.TransitionStatus(ctx, payment.ID, domain.StatusPending, domain.StatusExpired)
+			if err != nil || !updated {
+				return err
+			}
+			return s.publisher.PublishPaymentExpired(ctx, payment.ID)
+		})
+		if err != nil {
+			log.WithError(err).Error("Failed to expire pending payment")
+			continue
+		}
+		if !updated {
+			continue
+		}
+		expired++
+
+		log.WithField("pending_since", payment.UpdatedAt).Info("Pending payment expired")
+
+		if err := s.reminders.Complete(ctx, domain.ReminderSubjectPayment, payment.ID); err != nil {
+			log.WithError(err).Warn("Failed to stop payment reminders")
+		}
+
+		payment.Status = domain.StatusExpired
+		if err := s.notifier.NotifyPaymentStatus(ctx, payment); err != nil {
+			log.WithError(err).Warn("Failed to send payment notification")
+		}
+	}
+
+	return expired, nil
+}
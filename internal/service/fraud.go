@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// FraudService manages the fraud rules operators configure and evaluates
+// them against payments before they are created and queued
+type FraudService interface {
+	CreateRule(ctx context.Context, req domain.FraudRuleRequest, operator string) (*domain.FraudRule, error)
+	GetRule(ctx context.Context, id uuid.UUID) (*domain.FraudRule, error)
+	ListRules(ctx context.Context) ([]*domain.FraudRule, error)
+	UpdateRule(ctx context.Context, id uuid.UUID, req domain.FraudRuleRequest) (*domain.FraudRule, error)
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	ListHits(ctx context.Context, ruleID *uuid.UUID, action domain.FraudAction, page, limit int) ([]*domain.FraudHit, error)
+	// Evaluate returns the hit of the first rule matching the payment when
+	// that rule flags or blocks it; nil means the payment is allowed
+	Evaluate(ctx context.Context, payment *domain.Payment) (*domain.FraudHit, error)
+	RecordHit(ctx context.Context, hit *domain.FraudHit) error
+}
+
+type FraudSettings struct {
+	Enabled bool
+}
+
+type fraudService struct {
+	repo     repository.FraudRepository
+	fx       FXService
+	settings FraudSettings
+	logger   *logrus.Logger
+}
+
+func NewFraudService(repo repository.FraudRepository, fx FXService, settings FraudSettings, logger *logrus.Logger) FraudService {
+	return &fraudService{
+		repo:     repo,
+		fx:       fx,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (s *fraudService) CreateRule(ctx context.Context, req domain.FraudRuleRequest, operator string) (*domain.FraudRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	now := time.Now().UTC()
+	rule := &domain.FraudRule{
+		ID:        uuid.New(),
+		Enabled:   true,
+		CreatedBy: operator,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyFraudRule(rule, req)
+
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"rule_id":  rule.ID,
+		"name":     rule.Name,
+		"kind":     rule.Kind,
+		"action":   rule.Action,
+		"operator": operator,
+	}).Info("Fraud rule created")
+
+	return rule, nil
+}
+
+func (s *fraudService) GetRule(ctx context.Context, id uuid.UUID) (*domain.FraudRule, error) {
+	return s.repo.GetRule(ctx, id)
+}
+
+func (s *fraudService) ListRules(ctx context.Context) ([]*domain.FraudRule, error) {
+	return s.repo.ListRules(ctx, false)
+}
+
+func (s *fraudService) UpdateRule(ctx context.Context, id uuid.UUID, req domain.FraudRuleRequest) (*domain.FraudRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	rule := &domain.FraudRule{
+		ID:        id,
+		Enabled:   true,
+		UpdatedAt: time.Now().UTC(),
+	}
+	applyFraudRule(rule, req)
+
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"rule_id": rule.ID,
+		"action":  rule.Action,
+		"enabled": rule.Enabled,
+	}).Info("Fraud rule updated")
+
+	return rule, nil
+}
+
+func (s *fraudService) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.WithField("rule_id", id).Info("Fraud rule deleted")
+	return nil
+}
+
+func (s *fraudService) ListHits(ctx context.Context, ruleID *uuid.UUID, action domain.FraudAction, page, limit int) ([]*domain.FraudHit, error) {
+	if action != "" && action != domain.FraudFlag && action != domain.FraudBlock {
+		return nil, fmt.Errorf("%w: action must be FLAG or BLOCK", domain.ErrInvalidInput)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.ListHits(ctx, ruleID, action, limit, (page-1)*limit)
+}
+
+func (s *fraudService) Evaluate(ctx context.Context, payment *domain.Payment) (*domain.FraudHit, error) {
+	if !s.settings.Enabled {
+		return nil, nil
+	}
+
+	rules, err := s.repo.ListRules(ctx, true)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	check := domain.FraudCheck{
+		AmountETB:      payment.Amount,
+		NewCustomer:    true,
+		RecentFailures: make(map[int]int),
+		Hour:           etime.In(payment.CreatedAt).Hour(),
+	}
+	if payment.Currency.IsForeign() {
+		check.AmountETB = payment.AmountETB
+		if check.AmountETB == 0 {
+			rate, err := s.fx.CurrentRate(ctx, payment.Currency)
+			if err != nil {
+				return nil, err
+			}
+			check.AmountETB = domain.ConvertToETB(payment.Amount, rate.Rate)
+		}
+	}
+
+	// History is only looked up for rules that need it. A customer with no
+	// phone or national ID has none, so is new and has no failures.
+	known := payment.CustomerPhone != "" || payment.CustomerNationalID != ""
+	historyChecked := !known
+	for _, rule := range rules {
+		switch rule.Kind {
+		case domain.FraudNewCustomerAmount:
+			if !historyChecked {
+				succeeded, err := s.repo.HasSucceeded(ctx, payment.CustomerPhone, payment.CustomerNationalID)
+				if err != nil {
+					return nil, err
+				}
+				check.NewCustomer = !succeeded
+				historyChecked = true
+			}
+		case domain.FraudRepeatedFailures:
+			if _, counted := check.RecentFailures[rule.WindowMinutes]; known && !counted {
+				since := payment.CreatedAt.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+				count, err := s.repo.CountFailures(ctx, payment.CustomerPhone, payment.CustomerNationalID, since)
+				if err != nil {
+					return nil, err
+				}
+				check.RecentFailures[rule.WindowMinutes] = count
+			}
+		}
+
+		if !rule.Matches(check) {
+			continue
+		}
+		if rule.Action == domain.FraudAllow {
+			return nil, nil
+		}
+		return &domain.FraudHit{
+			ID:            uuid.New(),
+			RuleID:        &rule.ID,
+			RuleName:      rule.Name,
+			Action:        rule.Action,
+			Reference:     payment.Reference,
+			Amount:        payment.Amount,
+			Currency:      payment.Currency,
+			AmountETB:     check.AmountETB,
+			CustomerPhone: payment.CustomerPhone,
+			MerchantID:    payment.MerchantID,
+			CreatedAt:     payment.CreatedAt,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *fraudService) RecordHit(ctx context.Context, hit *domain.FraudHit) error {
+	return s.repo.CreateHit(ctx, hit)
+}
+
+func applyFraudRule(rule *domain.FraudRule, req domain.FraudRuleRequest) {
+	rule.Name = req.Name
+	rule.Kind = req.Kind
+	rule.Action = req.Action
+	rule.Priority = req.Priority
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	rule.AmountETB = req.AmountETB
+	rule.Failures = req.Failures
+	rule.WindowMinutes = req.WindowMinutes
+	rule.StartHour = req.StartHour
+	rule.EndHour = req.EndHour
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/bank"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// FXService keeps the daily rate of each foreign currency into ETB and
+// issues quotes that lock a rate for one payment
+type FXService interface {
+	// CurrentRate is the latest rate treasury set, or the configured rate
+	CurrentRate(ctx context.Context, currency domain.Currency) (*domain.FXRate, error)
+	// CurrentRates returns the current rate of every accepted foreign currency
+	CurrentRates(ctx context.Context) ([]*domain.FXRate, error)
+	// RatesOn returns the rate of every accepted foreign currency in force at
+	// the end of an Ethiopian business day, YYYY-MM-DD
+	RatesOn(ctx context.Context, date string) ([]*domain.FXRate, error)
+	// Accepts reports whether payments may be made in the currency
+	Accepts(currency domain.Currency) bool
+	SetRate(ctx context.Context, req domain.SetFXRateRequest, operator string) (*domain.FXRate, error)
+	// SyncRates records the rate feed's latest rates and returns how many
+	// were new
+	SyncRates(ctx context.Context) (int, error)
+	// RateHistory lists every rate set, newest first
+	RateHistory(ctx context.Context, filter domain.FXRateFilter, page, limit int) ([]*domain.FXRate, error)
+	CreateQuote(ctx context.Context, req domain.FXQuoteRequest) (*domain.FXQuote, error)
+	GetQuote(ctx context.Context, id uuid.UUID) (*domain.FXQuote, error)
+	// Convert prices an amount in ETB at the rate a quote would lock now,
+	// without locking it
+	Convert(ctx context.Context, currency domain.Currency, amount domain.Amount) (*domain.FXConversion, error)
+	// SetFallbackRates replaces the configured rates, on a configuration
+	// reload. A currency given a rate of zero or less keeps its current one.
+	SetFallbackRates(rates map[domain.Currency]float64)
+}
+
+type FXSettings struct {
+	FallbackRates map[domain.Currency]float64 // Used until treasury sets a daily rate
+	QuoteTTL      time.Duration
+	SpreadBps     int               // Taken off the mid rate on quotes
+	Currencies    []domain.Currency // Foreign currencies payments may be made in; empty accepts all
+	Feed          bank.RateFeed     // Reference rates recorded by SyncRates; nil when rates are set by hand only
+}
+
+type fxService struct {
+	repo     repository.FXRepository
+	settings FXSettings
+	logger   *logrus.Logger
+
+	mu sync.RWMutex // Guards settings.FallbackRates, which a reload replaces
+}
+
+func NewFXService(repo repository.FXRepository, settings FXSettings, logger *logrus.Logger) FXService {
+	if settings.QuoteTTL <= 0 {
+		settings.QuoteTTL = 15 * time.Minute
+	}
+	if settings.SpreadBps < 0 {
+		settings.SpreadBps = 0
+	}
+
+	return &fxService{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (s *fxService) CurrentRate(ctx context.Context, currency domain.Currency) (*domain.FXRate, error) {
+	if !currency.IsForeign() {
+		return nil, fmt.Errorf("%w: %s is not a foreign currency", domain.ErrInvalidInput, currency)
+	}
+
+	rate, err := s.repo.LatestRate(ctx, domain.FXPair(currency))
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return &domain.FXRate{
+			Pair:     domain.FXPair(currency),
+			Currency: currency,
+			Rate:     s.fallbackRate(currency),
+			SetBy:    "config",
+		}, nil
+	}
+	return rate, nil
+}
+
+func (s *fxService) CurrentRates(ctx context.Context) ([]*domain.FXRate, error) {
+	var rates []*domain.FXRate
+	for _, currency := range domain.SupportedCurrencies {
+		if !currency.IsForeign() || !s.Accepts(currency) {
+			continue
+		}
+		rate, err := s.CurrentRate(ctx, currency)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (s *fxService) RatesOn(ctx context.Context, date string) ([]*domain.FXRate, error) {
+	if _, err := etime.ParseDate(date); err != nil {
+		return nil, fmt.Errorf("%w: date must be in YYYY-MM-DD format", domain.ErrInvalidInput)
+	}
+
+	var rates []*domain.FXRate
+	for _, currency := range domain.SupportedCurrencies {
+		if !currency.IsForeign() || !s.Accepts(currency) {
+			continue
+		}
+		rate, err := s.repo.RateOn(ctx, domain.FXPair(currency), date)
+		if err != nil {
+			return nil, err
+		}
+		if rate == nil {
+			rate = &domain.FXRate{
+				Pair:     domain.FXPair(currency),
+				Currency: currency,
+				Rate:     s.fallbackRate(currency),
+				SetBy:    "config",
+			}
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (s *fxService) SetFallbackRates(rates map[domain.Currency]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accepted := make(map[domain.Currency]float64, len(rates))
+	for currency, rate := range rates {
+		if rate <= 0 {
+			s.logger.WithFields(logrus.Fields{
+				"currency": currency,
+				"rate":     rate,
+			}).Warn("Ignoring a fallback exchange rate that is not positive; keeping the current one")
+			if current, ok := s.settings.FallbackRates[currency]; ok {
+				accepted[currency] = current
+			}
+			continue
+		}
+		accepted[currency] = rate
+	}
+	s.settings.FallbackRates = accepted
+}
+
+func (s *fxService) fallbackRate(currency domain.Currency) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings.FallbackRates[currency]
+}
+
+func (s *fxService) Accepts(currency domain.Currency) bool {
+	if !currency.IsForeign() {
+		return currency.IsValid()
+	}
+	if len(s.settings.Currencies) == 0 {
+		return true
+	}
+	return slices.Contains(s.settings.Currencies, currency)
+}
+
+func (s *fxService) SetRate(ctx context.Context, req domain.SetFXRateRequest, operator string) (*domain.FXRate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	now := time.Now().UTC()
+	_, businessDate := businessDay(now)
+	id := uuid.New()
+	rate := &domain.FXRate{
+		ID:            &id,
+		Pair:          domain.FXPair(req.Currency),
+		Currency:      req.Currency,
+		Rate:          req.Rate,
+		EffectiveDate: businessDate,
+		SetBy:         operator,
+		CreatedAt:     now,
+	}
+
+	if err := s.repo.AddRate(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"pair":     rate.Pair,
+		"rate":     rate.Rate,
+		"operator": operator,
+	}).Info("Daily FX rate set")
+
+	return rate, nil
+}
+
+// SyncRates takes each currency's feed rate once per effective date, and
+// never over a rate treasury set for that day or later
+func (s *fxService) SyncRates(ctx context.Context) (int, error) {
+	if s.settings.Feed == nil {
+		return 0, nil
+	}
+
+	sheet, err := s.settings.Feed.FetchRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	effectiveDate := sheet.Date
+	if effectiveDate == "" {
+		_, effectiveDate = businessDay(now)
+	}
+
+	added := 0
+	for _, currency := range domain.SupportedCurrencies {
+		mid, ok := sheet.Rates[currency]
+		if !ok || !currency.IsForeign() {
+			continue
+		}
+
+		latest, err := s.repo.LatestRate(ctx, domain.FXPair(currency))
+		if err != nil {
+			return added, err
+		}
+		// Dates are YYYY-MM-DD, so they compare as strings
+		if latest != nil && latest.EffectiveDate >= effectiveDate {
+			continue
+		}
+
+		id := uuid.New()
+		rate := &domain.FXRate{
+			ID:            &id,
+			Pair:          domain.FXPair(currency),
+			Currency:      currency,
+			Rate:          math.Round(mid*10000) / 10000,
+			EffectiveDate: effectiveDate,
+			SetBy:         sheet.Source,
+			CreatedAt:     now,
+		}
+		if err := s.repo.AddRate(ctx, rate); err != nil {
+			return added, err
+		}
+		added++
+
+		s.logger.WithFields(logrus.Fields{
+			"pair":           rate.Pair,
+			"rate":           rate.Rate,
+			"effective_date": rate.EffectiveDate,
+			"source":         sheet.Source,
+		}).Info("FX rate recorded from feed")
+	}
+
+	return added, nil
+}
+
+func (s *fxService) RateHistory(ctx context.Context, filter domain.FXRateFilter, page, limit int) ([]*domain.FXRate, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.ListRates(ctx, filter, limit, (page-1)*limit)
+}
+
+func (s *fxService) CreateQuote(ctx context.Context, req domain.FXQuoteRequest) (*domain.FXQuote, error) {
+	mid, rate, err := s.quoteRate(ctx, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	quote := &domain.FXQuote{
+		ID:        uuid.New(),
+		Pair:      mid.Pair,
+		Currency:  req.Currency,
+		Rate:      rate,
+		MidRate:   mid.Rate,
+		RateID:    mid.ID,
+		SpreadBps: s.settings.SpreadBps,
+		ExpiresAt: now.Add(s.settings.QuoteTTL),
+		CreatedAt: now,
+	}
+
+	if err := s.repo.CreateQuote(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+func (s *fxService) GetQuote(ctx context.Context, id uuid.UUID) (*domain.FXQuote, error) {
+	return s.repo.GetQuote(ctx, id)
+}
+
+func (s *fxService) Convert(ctx context.Context, currency domain.Currency, amount domain.Amount) (*domain.FXConversion, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be greater than zero", domain.ErrInvalidInput)
+	}
+
+	mid, rate, err := s.quoteRate(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.FXConversion{
+		Pair:      mid.Pair,
+		Currency:  currency,
+		Amount:    amount,
+		Rate:      rate,
+		MidRate:   mid.Rate,
+		RateID:    mid.ID,
+		SpreadBps: s.settings.SpreadBps,
+		AmountETB: domain.ConvertToETB(amount, rate),
+	}, nil
+}
+
+// quoteRate is the current mid rate of an accepted currency and the rate
+// after spread that a quote locks, to four places
+func (s *fxService) quoteRate(ctx context.Context, currency domain.Currency) (*domain.FXRate, float64, error) {
+	if !currency.IsForeign() {
+		return nil, 0, fmt.Errorf("%w: currency must be one of USD, EUR, GBP, AED or CNY", domain.ErrInvalidInput)
+	}
+	if !s.Accepts(currency) {
+		return nil, 0, fmt.Errorf("%w: %s is not accepted", domain.ErrInvalidInput, currency)
+	}
+
+	mid, err := s.CurrentRate(ctx, currency)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mid.Rate <= 0 {
+		return nil, 0, fmt.Errorf("no %s rate is configured", mid.Pair)
+	}
+
+	rate := math.Round(mid.Rate*(1-float64(s.settings.SpreadBps)/10000)*10000) / 10000
+	return mid, rate, nil
+}
+
+// rateMap keys the current rates by currency
+func rateMap(ctx context.Context, fx FXService) (map[domain.Currency]float64, error) {
+	current, err := fx.CurrentRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[domain.Currency]float64, len(current))
+	for _, rate := range current {
+		rates[rate.Currency] = rate.Rate
+	}
+	return rates, nil
+}
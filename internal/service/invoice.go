@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// InvoiceService bills customers with VAT invoices. Sending an invoice
+// gives it a payment link; it is marked paid once the successful payments
+// made through that link, or linked to it by the merchant, cover the total.
+type InvoiceService interface {
+	Create(ctx context.Context, req domain.CreateInvoiceRequest, merchantID *uuid.UUID) (*domain.Invoice, error)
+	// Get returns the invoice with its payment URL and linked payment IDs
+	Get(ctx context.Context, id uuid.UUID) (*domain.Invoice, error)
+	// Send creates the invoice's payment link on the first send and
+	// delivers the invoice to the customer; sending again resends it
+	Send(ctx context.Context, id uuid.UUID) (*domain.Invoice, error)
+	// LinkPayment counts a payment towards the invoice, e.g. one made by
+	// bank transfer with the invoice number as the reference
+	LinkPayment(ctx context.Context, id uuid.UUID, paymentID uuid.UUID) (*domain.Invoice, error)
+}
+
+type invoiceService struct {
+	repo        repository.InvoiceRepository
+	paymentRepo repository.PaymentRepository
+	links       PaymentLinkService
+	notifier    NotificationService
+	settler     *invoiceSettler
+	logger      *logrus.Logger
+}
+
+func NewInvoiceService(repo repository.InvoiceRepository, paymentRepo repository.PaymentRepository, linkRepo repository.PaymentLinkRepository, links PaymentLinkService, notifier NotificationService, logger *logrus.Logger) InvoiceService {
+	return &invoiceService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		links:       links,
+		notifier:    notifier,
+		settler:     &invoiceSettler{repo: repo, links: linkRepo, logger: logger},
+		logger:      logger,
+	}
+}
+
+func (s *invoiceService) Create(ctx context.Context, req domain.CreateInvoiceRequest, merchantID *uuid.UUID) (*domain.Invoice, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	invoice, err := domain.NewInvoice(req, merchantID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if err := s.repo.Create(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"invoice_id": invoice.ID,
+		"number":     invoice.Number,
+		"total":      invoice.Total,
+		"currency":   invoice.Currency,
+	}).Info("Invoice created")
+
+	return invoice, nil
+}
+
+func (s *invoiceService) Get(ctx context.Context, id uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.PaymentIDs, err = s.repo.ListPayments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.PaymentLinkID != nil {
+		link, err := s.links.Get(ctx, *invoice.PaymentLinkID)
+		if err != nil {
+			return nil, err
+		}
+		invoice.PaymentURL = link.URL
+	}
+	invoice.Overdue = invoice.IsOverdue(time.Now().UTC())
+
+	return invoice, nil
+}
+
+func (s *invoiceService) Send(ctx context.Context, id uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == domain.InvoicePaid {
+		return nil, domain.ErrInvoicePaid
+	}
+
+	if invoice.PaymentLinkID == nil {
+		// The link asks for what is still owed after payments the merchant
+		// linked before sending
+		paid, err := s.repo.PaidAmount(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if paid >= invoice.Total {
+			s.settler.settle(ctx, invoice)
+			return nil, domain.ErrInvoicePaid
+		}
+
+		link, err := s.links.Create(ctx, domain.CreatePaymentLinkRequest{
+			Amount:      invoice.Total - paid,
+			Currency:    invoice.Currency,
+			Description: "Invoice " + invoice.Number,
+			Language:    invoice.Language,
+		}, invoice.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		invoice.PaymentLinkID, invoice.PaymentURL = &link.ID, link.URL
+	}
+
+	if err := s.notifier.SendInvoice(ctx, invoice); err != nil && err != domain.ErrInvoiceNoRecipient {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := s.repo.MarkSent(ctx, id, *invoice.PaymentLinkID, now); err != nil {
+		return nil, err
+	}
+	invoice.Status, invoice.SentAt = domain.InvoiceSent, &now
+
+	s.logger.WithFields(logrus.Fields{
+		"invoice_id":      id,
+		"payment_link_id": invoice.PaymentLinkID,
+	}).Info("Invoice sent")
+
+	return invoice, nil
+}
+
+func (s *invoiceService) LinkPayment(ctx context.Context, id uuid.UUID, paymentID uuid.UUID) (*domain.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == domain.InvoicePaid {
+		return nil, domain.ErrInvoicePaid
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameMerchant(payment.MerchantID, invoice.MerchantID) {
+		return nil, domain.ErrPaymentNotFound // Another merchant's payments stay invisible
+	}
+	if payment.Currency != invoice.Currency {
+		return nil, domain.ErrInvoicePaymentMismatch
+	}
+
+	if err := s.repo.AddPayment(ctx, id, paymentID); err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"invoice_id": id,
+		"payment_id": paymentID,
+	}).Info("Payment linked to invoice")
+
+	// A payment that already succeeded may complete the invoice now
+	if payment.Status == domain.StatusSuccess {
+		s.settler.settle(ctx, invoice)
+	}
+
+	return s.Get(ctx, id)
+}
+
+func sameMerchant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// invoiceSettler marks invoices paid once their successful payments cover
+// the total, and closes their payment link
+type invoiceSettler struct {
+	repo   repository.InvoiceRepository
+	links  repository.PaymentLinkRepository
+	logger *logrus.Logger
+}
+
+// paymentSucceeded settles the unpaid invoices the payment is linked to.
+// Failures are logged: the payment itself has succeeded regardless.
+func (s *invoiceSettler) paymentSucceeded(ctx context.Context, paymentID uuid.UUID) {
+	invoices, err := s.repo.ListUnpaidByPayment(ctx, paymentID)
+	if err != nil {
+		s.logger.WithError(err).WithField("payment_id", paymentID).Warn("Failed to find invoices for payment")
+		return
+	}
+
+	for _, invoice := range invoices {
+		s.settle(ctx, invoice)
+	}
+}
+
+func (s *invoiceSettler) settle(ctx context.Context, invoice *domain.Invoice) {
+	logger := s.logger.WithField("invoice_id", invoice.ID)
+
+	paid, err := s.repo.PaidAmount(ctx, invoice.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to sum invoice payments")
+		return
+	}
+	if paid < invoice.Total {
+		return
+	}
+
+	marked, err := s.repo.MarkPaid(ctx, invoice.ID, time.Now().UTC())
+	if err != nil {
+		logger.WithError(err).Warn("Failed to mark invoice paid")
+		return
+	}
+	if !marked {
+		return
+	}
+	logger.WithField("paid", paid).Info("Invoice paid")
+
+	if invoice.PaymentLinkID != nil {
+		if err := s.links.Deactivate(ctx, *invoice.PaymentLinkID); err != nil {
+			logger.WithError(err).Warn("Failed to deactivate invoice payment link")
+		}
+	}
+}
+
+// invoicingNotifier settles invoices as their payments succeed. Every path
+// that finishes a payment (worker, re-query, overrides, vouchers, agents)
+// notifies through it, so it sees each success once the status is stored.
+type invoicingNotifier struct {
+	NotificationService
+	settler *invoiceSettler
+}
+
+func NewInvoicingNotifier(notifier NotificationService, invoices repository.InvoiceRepository, links repository.PaymentLinkRepository, logger *logrus.Logger) NotificationService {
+	return &invoicingNotifier{
+		NotificationService: notifier,
+		settler:             &invoiceSettler{repo: invoices, links: links, logger: logger},
+	}
+}
+
+func (n *invoicingNotifier) NotifyPaymentStatus(ctx context.Context, payment *domain.Payment) error {
+	if payment.Status == domain.StatusSuccess {
+		n.settler.paymentSucceeded(ctx, payment.ID)
+	}
+	return n.NotificationService.NotifyPaymentStatus(ctx, payment)
+}
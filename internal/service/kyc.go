@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// KYCSettings requires payments above an ETB amount to name a VERIFIED
+// customer. RequireVerifiedAbove 0 leaves customer_id optional.
+type KYCSettings struct {
+	RequireVerifiedAbove domain.Amount
+}
+
+// resolveCustomer looks up the customer a payment names, or else the
+// caller's customer with the payment's national ID or phone, and enforces
+// the KYC threshold. Foreign currency payments count at their quote's rate,
+// or today's rate without one. Callers may only name their own customers.
+func (s *paymentService) resolveCustomer(ctx context.Context, req *domain.CreatePaymentRequest, quote *domain.FXQuote) (*domain.Customer, error) {
+	var customer *domain.Customer
+	if req.CustomerID != "" {
+		var err error
+		customer, err = s.customers.GetByID(ctx, uuid.MustParse(req.CustomerID))
+		if errors.Is(err, domain.ErrCustomerNotFound) || (err == nil && !customerVisibleTo(customer, req.MerchantID)) {
+			return nil, fmt.Errorf("%w: customer_id does not name a registered customer", domain.ErrInvalidInput)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if req.CustomerPhone != "" || req.CustomerNationalID != "" {
+		phone := req.CustomerPhone
+		if phone != "" {
+			phone, _ = domain.NormalizePhone(phone)
+		}
+		var err error
+		customer, err = s.customers.Match(ctx, req.MerchantID, phone, req.CustomerNationalID)
+		if err != nil && !errors.Is(err, domain.ErrCustomerNotFound) {
+			return nil, err
+		}
+	}
+
+	if s.kyc.RequireVerifiedAbove <= 0 {
+		return customer, nil
+	}
+
+	amountETB := req.Amount
+	if quote != nil {
+		amountETB = domain.ConvertToETB(req.Amount, quote.Rate)
+	} else if req.Currency.IsForeign() {
+		rates, err := rateMap(ctx, s.fx)
+		if err != nil {
+			return nil, err
+		}
+		amountETB = domain.ConvertToETB(req.Amount, rates[req.Currency])
+	}
+	if amountETB <= s.kyc.RequireVerifiedAbove {
+		return customer, nil
+	}
+
+	threshold := domain.NewMoney(s.kyc.RequireVerifiedAbove, domain.CurrencyETB)
+	if customer == nil {
+		return nil, fmt.Errorf("%w: customer_id is required above %s", domain.ErrCustomerNotVerified, threshold)
+	}
+	if customer.VerificationStatus != domain.CustomerVerified {
+		return nil, fmt.Errorf("%w: customer is %s; payments above %s need a VERIFIED customer", domain.ErrCustomerNotVerified, customer.VerificationStatus, threshold)
+	}
+	return customer, nil
+}
+
+// customerVisibleTo reports whether the caller, a merchant or an operator
+// (nil), may use the customer
+func customerVisibleTo(customer *domain.Customer, merchantID *uuid.UUID) bool {
+	if merchantID == nil {
+		return true
+	}
+	return customer.MerchantID != nil && *customer.MerchantID == *merchantID
+}
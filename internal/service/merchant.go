@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// MerchantService onboards merchants: registration, settlement accounts
+// and their verification, and activation. A merchant can only be activated
+// once it has a verified primary settlement account.
+type MerchantService interface {
+	Register(ctx context.Context, req domain.RegisterMerchantRequest) (*domain.Merchant, error)
+	// Get returns the merchant with its settlement accounts
+	Get(ctx context.Context, id uuid.UUID) (*domain.Merchant, error)
+	List(ctx context.Context, status string) ([]*domain.Merchant, error)
+	Update(ctx context.Context, id uuid.UUID, req domain.UpdateMerchantRequest, operator string) (*domain.Merchant, error)
+	AddSettlementAccount(ctx context.Context, id uuid.UUID, req domain.SettlementAccountRequest) (*domain.SettlementAccount, error)
+	// UpdateSettlementAccount replaces the account's details; the account
+	// has to be verified again
+	UpdateSettlementAccount(ctx context.Context, id, accountID uuid.UUID, req domain.SettlementAccountRequest) (*domain.SettlementAccount, error)
+	// VerifySettlementAccount runs a name inquiry against the declared
+	// account name, or records an operator's manual decision. The first
+	// verified account becomes primary.
+	VerifySettlementAccount(ctx context.Context, id, accountID uuid.UUID, req domain.VerifySettlementAccountRequest, operator string) (*domain.SettlementAccount, error)
+	SetPrimarySettlementAccount(ctx context.Context, id, accountID uuid.UUID) (*domain.SettlementAccount, error)
+}
+
+type merchantService struct {
+	repo     repository.MerchantRepository
+	accounts AccountService
+	logger   *logrus.Logger
+}
+
+func NewMerchantService(repo repository.MerchantRepository, accounts AccountService, logger *logrus.Logger) MerchantService {
+	return &merchantService{
+		repo:     repo,
+		accounts: accounts,
+		logger:   logger,
+	}
+}
+
+func (s *merchantService) Register(ctx context.Context, req domain.RegisterMerchantRequest) (*domain.Merchant, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	now := time.Now().UTC()
+	merchant := &domain.Merchant{
+		ID:        uuid.New(),
+		LegalName: req.LegalName,
+		TradeName: req.TradeName,
+		TIN:       req.TIN,
+		Email:     req.Email,
+		Phone:     req.Phone,
+		Region:    req.Region,
+		Status:    domain.MerchantPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	accounts := make([]*domain.SettlementAccount, 0, len(req.SettlementAccounts))
+	for _, a := range req.SettlementAccounts {
+		accounts = append(accounts, newSettlementAccount(merchant.ID, a, now))
+	}
+
+	if err := s.repo.Create(ctx, merchant, accounts); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": merchant.ID,
+		"accounts":    len(accounts),
+	}).Info("Merchant registered")
+
+	merchant.SettlementAccounts = accounts
+	return merchant, nil
+}
+
+func (s *merchantService) Get(ctx context.Context, id uuid.UUID) (*domain.Merchant, error) {
+	merchant, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.SettlementAccounts, err = s.repo.ListAccounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+func (s *merchantService) List(ctx context.Context, status string) ([]*domain.Merchant, error) {
+	if status != "" && !domain.MerchantStatus(status).IsValid() {
+		return nil, fmt.Errorf("%w: status must be PENDING, ACTIVE or SUSPENDED", domain.ErrInvalidInput)
+	}
+
+	return s.repo.List(ctx, domain.MerchantStatus(status))
+}
+
+func (s *merchantService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateMerchantRequest, operator string) (*domain.Merchant, error) {
+	merchant, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := merchant.Status
+
+	if err := req.Apply(merchant); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if merchant.Status == domain.MerchantActive && previous != domain.MerchantActive {
+		if _, err := s.primaryAccount(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, merchant); err != nil {
+		return nil, err
+	}
+
+	if merchant.Status != previous {
+		s.logger.WithFields(logrus.Fields{
+			"merchant_id": id,
+			"from":        previous,
+			"to":          merchant.Status,
+			"reason":      merchant.StatusReason,
+			"operator":    operator,
+		}).Info("Merchant status changed")
+	}
+
+	return s.Get(ctx, id)
+}
+
+func (s *merchantService) AddSettlementAccount(ctx context.Context, id uuid.UUID, req domain.SettlementAccountRequest) (*domain.SettlementAccount, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	account := newSettlementAccount(id, req, time.Now().UTC())
+	if err := s.repo.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": id,
+		"account_id":  account.ID,
+		"bank_code":   account.BankCode,
+		"account":     maskAccount(account.AccountNumber),
+	}).Info("Settlement account added")
+
+	return account, nil
+}
+
+func (s *merchantService) UpdateSettlementAccount(ctx context.Context, id, accountID uuid.UUID, req domain.SettlementAccountRequest) (*domain.SettlementAccount, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	merchant, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repo.GetAccount(ctx, id, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An active merchant must always have a verified account to settle to
+	if account.Primary && merchant.Status == domain.MerchantActive {
+		return nil, domain.ErrSettlementAccountPrimary
+	}
+
+	if account.BankCode != req.BankCode || account.AccountNumber != req.AccountNumber {
+		existing, err := s.repo.ListAccounts(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range existing {
+			if other.ID != account.ID && other.BankCode == req.BankCode && other.AccountNumber == req.AccountNumber {
+				return nil, domain.ErrSettlementAccountExists
+			}
+		}
+	}
+
+	account.BankCode = req.BankCode
+	account.AccountNumber = req.AccountNumber
+	account.AccountName = req.AccountName
+	account.Status = domain.SettlementAccountPending
+	account.VerifiedName = ""
+	account.StatusNote = ""
+	account.VerifiedBy = ""
+	account.VerifiedAt = nil
+
+	if err := s.repo.UpdateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAccount(ctx, id, accountID)
+}
+
+func (s *merchantService) VerifySettlementAccount(ctx context.Context, id, accountID uuid.UUID, req domain.VerifySettlementAccountRequest, operator string) (*domain.SettlementAccount, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	account, err := s.repo.GetAccount(ctx, id, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status == domain.SettlementAccountVerified {
+		return account, nil
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"merchant_id": id,
+		"account_id":  accountID,
+		"bank_code":   account.BankCode,
+		"account":     maskAccount(account.AccountNumber),
+		"operator":    operator,
+	})
+
+	now := time.Now().UTC()
+	account.VerifiedBy = operator
+	account.VerifiedAt = &now
+	account.StatusNote = req.Note
+
+	if req.Manual {
+		account.Status = domain.SettlementAccountVerified
+		if req.Reject {
+			account.Status = domain.SettlementAccountRejected
+		}
+	} else {
+		result, err := s.accounts.VerifyAccount(ctx, domain.AccountVerificationRequest{
+			BankCode:      account.BankCode,
+			AccountNumber: account.AccountNumber,
+		})
+		switch {
+		case errors.Is(err, domain.ErrAccountNotFound):
+			account.Status = domain.SettlementAccountRejected
+			account.StatusNote = "Account not found at the bank"
+		case err != nil:
+			return nil, err
+		case domain.NamesMatch(account.AccountName, result.AccountName):
+			account.Status = domain.SettlementAccountVerified
+			account.VerifiedName = result.AccountName
+		default:
+			account.Status = domain.SettlementAccountRejected
+			account.VerifiedName = result.AccountName
+			account.StatusNote = "Account holder name does not match the declared name"
+		}
+	}
+
+	if err := s.repo.UpdateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"status": account.Status,
+		"manual": req.Manual,
+	}).Info("Settlement account verification recorded")
+
+	if account.Status == domain.SettlementAccountVerified {
+		if _, err := s.primaryAccount(ctx, id); err == domain.ErrMerchantNotActivatable {
+			if err := s.repo.SetPrimaryAccount(ctx, id, accountID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.repo.GetAccount(ctx, id, accountID)
+}
+
+func (s *merchantService) SetPrimarySettlementAccount(ctx context.Context, id, accountID uuid.UUID) (*domain.SettlementAccount, error) {
+	if _, err := s.repo.GetAccount(ctx, id, accountID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetPrimaryAccount(ctx, id, accountID); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"merchant_id": id,
+		"account_id":  accountID,
+	}).Info("Primary settlement account changed")
+
+	return s.repo.GetAccount(ctx, id, accountID)
+}
+
+// primaryAccount returns ErrMerchantNotActivatable when the merchant has
+// no verified primary account
+func (s *merchantService) primaryAccount(ctx context.Context, id uuid.UUID) (*domain.SettlementAccount, error) {
+	accounts, err := s.repo.ListAccounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range accounts {
+		if a.Primary && a.Status == domain.SettlementAccountVerified {
+			return a, nil
+		}
+	}
+	return nil, domain.ErrMerchantNotActivatable
+}
+
+func newSettlementAccount(merchantID uuid.UUID, req domain.SettlementAccountRequest, now time.Time) *domain.SettlementAccount {
+	return &domain.SettlementAccount{
+		ID:            uuid.New(),
+		MerchantID:    merchantID,
+		BankCode:      req.BankCode,
+		AccountNumber: req.AccountNumber,
+		AccountName:   req.AccountName,
+		Status:        domain.SettlementAccountPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
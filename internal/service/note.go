@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// NoteService manages ops comments on payments. Notes never change the
+// payment itself, so they stay out of the status override audit log.
+type NoteService interface {
+	AddNote(ctx context.Context, paymentID uuid.UUID, req domain.PaymentNoteRequest, author string) (*domain.PaymentNote, error)
+	ListNotes(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentNote, error)
+	// EditNote and DeleteNote are limited to the note's author
+	EditNote(ctx context.Context, paymentID, noteID uuid.UUID, req domain.PaymentNoteRequest, author string) (*domain.PaymentNote, error)
+	DeleteNote(ctx context.Context, paymentID, noteID uuid.UUID, author string) error
+}
+
+type noteService struct {
+	repo        repository.PaymentNoteRepository
+	paymentRepo repository.PaymentRepository
+	logger      *logrus.Logger
+}
+
+func NewNoteService(repo repository.PaymentNoteRepository, paymentRepo repository.PaymentRepository, logger *logrus.Logger) NoteService {
+	return &noteService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		logger:      logger,
+	}
+}
+
+func (s *noteService) AddNote(ctx context.Context, paymentID uuid.UUID, req domain.PaymentNoteRequest, author string) (*domain.PaymentNote, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if _, err := s.paymentRepo.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+
+	note := &domain.PaymentNote{
+		ID:        uuid.New(),
+		PaymentID: paymentID,
+		Author:    author,
+		Body:      req.Body,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": paymentID,
+		"note_id":    note.ID,
+		"author":     author,
+	}).Info("Payment note added")
+
+	return note, nil
+}
+
+func (s *noteService) ListNotes(ctx context.Context, paymentID uuid.UUID) ([]*domain.PaymentNote, error) {
+	if _, err := s.paymentRepo.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListByPayment(ctx, paymentID)
+}
+
+func (s *noteService) EditNote(ctx context.Context, paymentID, noteID uuid.UUID, req domain.PaymentNoteRequest, author string) (*domain.PaymentNote, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	note, err := s.authoredNote(ctx, paymentID, noteID, author)
+	if err != nil {
+		return nil, err
+	}
+
+	editedAt := time.Now().UTC()
+	if err := s.repo.UpdateBody(ctx, noteID, req.Body, editedAt); err != nil {
+		return nil, err
+	}
+
+	note.Body = req.Body
+	note.EditedAt = &editedAt
+	return note, nil
+}
+
+func (s *noteService) DeleteNote(ctx context.Context, paymentID, noteID uuid.UUID, author string) error {
+	if _, err := s.authoredNote(ctx, paymentID, noteID, author); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, noteID); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": paymentID,
+		"note_id":    noteID,
+		"author":     author,
+	}).Info("Payment note deleted")
+
+	return nil
+}
+
+// authoredNote loads a note of the payment and checks it belongs to author
+func (s *noteService) authoredNote(ctx context.Context, paymentID, noteID uuid.UUID, author string) (*domain.PaymentNote, error) {
+	note, err := s.repo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note.PaymentID != paymentID {
+		return nil, domain.ErrNoteNotFound
+	}
+	if note.Author != author {
+		return nil, domain.ErrNoteNotAuthor
+	}
+
+	return note, nil
+}
@@ -0,0 +1,881 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/notification"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationService interface {
+	NotifyPaymentStatus(ctx context.Context, payment *domain.Payment) error
+	SendPaymentOTP(ctx context.Context, payment *domain.Payment, code string, ttl time.Duration) error
+	SendPaymentReminder(ctx context.Context, payment *domain.Payment) error
+	NotifyRefund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) error
+	// SendInvoice emails and texts the invoice to its customer; it returns
+	// ErrInvoiceNoRecipient when no enabled channel reaches them
+	SendInvoice(ctx context.Context, invoice *domain.Invoice) error
+	SendDailySummary(ctx context.Context, day time.Time) error
+	HandleDeliveryReport(ctx context.Context, report domain.DeliveryReport) error
+	ListPaymentNotifications(ctx context.Context, paymentID uuid.UUID) ([]*domain.Notification, error)
+
+	CreateTelegramLink(ctx context.Context, label, merchantID string) (*domain.TelegramChat, string, error)
+	HandleTelegramUpdate(ctx context.Context, update notification.TelegramUpdate) error
+	// The merchant-scoped calls below act on one merchant's records when
+	// merchantID is set, and on any record when it is nil
+	ListTelegramChats(ctx context.Context, merchantID *string) ([]*domain.TelegramChat, error)
+	UnlinkTelegramChat(ctx context.Context, id uuid.UUID, merchantID *string) error
+
+	ListPreferences(ctx context.Context, recipientType domain.RecipientType, recipientID string) ([]*domain.NotificationPreference, error)
+	SavePreference(ctx context.Context, pref *domain.NotificationPreference) error
+	DeletePreference(ctx context.Context, id uuid.UUID, merchantID *string) error
+
+	RegisterPushDevice(ctx context.Context, device *domain.PushDevice) error
+	ListPushDevices(ctx context.Context, merchantID string) ([]*domain.PushDevice, error)
+	UnregisterPushDevice(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type NotificationSettings struct {
+	// Language for merchant-facing messages and customers without a preference
+	DefaultLanguage domain.Language
+
+	SMSEnabled      bool
+	NotifyOnSuccess bool
+	NotifyOnFailure bool
+
+	EmailEnabled     bool
+	SendReceipts     bool
+	RefundReceipts   bool
+	MerchantReceipts bool // Copy customer receipts and refund notices to the merchant
+	FailureAlerts    bool
+	DailySummary     bool
+	FromName         string
+	FromAddress      string
+	// Merchant addresses for payments without a merchant, or whose merchant
+	// has no email
+	MerchantEmails []string
+
+	TelegramEnabled     bool
+	TelegramBotUsername string
+
+	PushEnabled bool
+}
+
+type notificationService struct {
+	repo         repository.NotificationRepository
+	paymentRepo  repository.PaymentRepository
+	merchantRepo repository.MerchantRepository
+	telegramRepo repository.TelegramChatRepository
+	prefs        repository.NotificationPreferenceRepository
+	pushDevices  repository.PushDeviceRepository
+	templates    TemplateService
+	sms          notification.SMSSender
+	email        notification.EmailSender
+	telegram     notification.TelegramSender
+	push         notification.PushSender
+	webhooks     WebhookService
+	settings     NotificationSettings
+	logger       *logrus.Logger
+}
+
+func NewNotificationService(
+	repo repository.NotificationRepository,
+	paymentRepo repository.PaymentRepository,
+	merchantRepo repository.MerchantRepository,
+	telegramRepo repository.TelegramChatRepository,
+	prefs repository.NotificationPreferenceRepository,
+	pushDevices repository.PushDeviceRepository,
+	templates TemplateService,
+	sms notification.SMSSender,
+	email notification.EmailSender,
+	telegram notification.TelegramSender,
+	push notification.PushSender,
+	webhooks WebhookService,
+	settings NotificationSettings,
+	logger *logrus.Logger,
+) NotificationService {
+	if !settings.DefaultLanguage.IsValid() {
+		settings.DefaultLanguage = domain.LanguageAmharic
+	}
+
+	return &notificationService{
+		repo:         repo,
+		paymentRepo:  paymentRepo,
+		merchantRepo: merchantRepo,
+		telegramRepo: telegramRepo,
+		prefs:        prefs,
+		pushDevices:  pushDevices,
+		templates:    templates,
+		sms:          sms,
+		email:        email,
+		telegram:     telegram,
+		push:         push,
+		webhooks:     webhooks,
+		settings:     settings,
+		logger:       logger,
+	}
+}
+
+// statusEvent maps a terminal payment status to its notification event
+func statusEvent(status domain.PaymentStatus) (domain.NotificationEvent, bool) {
+	switch status {
+	case domain.StatusSuccess:
+		return domain.EventPaymentSucceeded, true
+	case domain.StatusFailed:
+		return domain.EventPaymentFailed, true
+	case domain.StatusExpired:
+		return domain.EventPaymentExpired, true
+	default:
+		return "", false
+	}
+}
+
+// NotifyPaymentStatus sends every enabled notification for a terminal payment.
+// Recipient preferences override the configured defaults; missing
+// recipients are skipped and delivery errors are joined and returned.
+func (s *notificationService) NotifyPaymentStatus(ctx context.Context, payment *domain.Payment) error {
+	event, ok := statusEvent(payment.Status)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+
+	// Merchant webhooks go first so a preference lookup failure cannot
+	// lose them; the worker sends them, this only queues them
+	if s.webhooks != nil {
+		if err := s.webhooks.Enqueue(ctx, event, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	customerPrefs, err := s.preferencesFor(ctx, domain.RecipientCustomer, customerKey(payment), event)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	merchantPrefs, err := s.merchantPreferences(ctx, payment, event)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	// SMS to the customer: their own choice, else the merchant's, else the
+	// gateway configuration
+	smsDefault := (event == domain.EventPaymentSucceeded && s.settings.NotifyOnSuccess) ||
+		(event == domain.EventPaymentFailed && s.settings.NotifyOnFailure)
+	if preferred(customerPrefs, domain.ChannelSMS, preferred(merchantPrefs, domain.ChannelSMS, smsDefault)) {
+		if err := s.notifySMS(ctx, event, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.settings.EmailEnabled && s.email != nil {
+		switch event {
+		case domain.EventPaymentSucceeded:
+			// Receipt to the customer, and a copy to the merchant
+			if preferred(customerPrefs, domain.ChannelEmail, s.settings.SendReceipts) && payment.CustomerEmail != "" {
+				if err := s.sendEmail(ctx, payment, event, payment.Language, []string{payment.CustomerEmail}, payment); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if preferred(merchantPrefs, domain.ChannelEmail, s.settings.MerchantReceipts) {
+				if to := s.merchantEmails(ctx, payment); len(to) > 0 {
+					if err := s.sendEmail(ctx, payment, event, s.settings.DefaultLanguage, to, payment); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		case domain.EventPaymentFailed:
+			// Alert to the merchant
+			if preferred(merchantPrefs, domain.ChannelEmail, s.settings.FailureAlerts) {
+				if to := s.merchantEmails(ctx, payment); len(to) > 0 {
+					if err := s.sendEmail(ctx, payment, event, s.settings.DefaultLanguage, to, payment); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		}
+	}
+
+	// Telegram to the merchant's linked chats
+	if preferred(merchantPrefs, domain.ChannelTelegram, true) {
+		if err := s.notifyTelegram(ctx, event, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// "Payment received" push to the merchant's app devices
+	if event == domain.EventPaymentSucceeded && preferred(merchantPrefs, domain.ChannelPush, true) {
+		if err := s.notifyPush(ctx, event, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// customerKey identifies a customer for preferences: phone first, then email
+func customerKey(payment *domain.Payment) string {
+	if payment.CustomerPhone != "" {
+		return payment.CustomerPhone
+	}
+	return payment.CustomerEmail
+}
+
+func (s *notificationService) preferencesFor(ctx context.Context, recipientType domain.RecipientType, recipientID string, event domain.NotificationEvent) (map[domain.NotificationChannel]bool, error) {
+	if recipientType == domain.RecipientCustomer && recipientID == "" {
+		return nil, nil
+	}
+
+	prefs, err := s.prefs.ForEvent(ctx, recipientType, recipientID, event)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load notification preferences")
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// merchantKey identifies the payment's merchant for preferences and
+// templates; empty is the gateway itself
+func merchantKey(payment *domain.Payment) string {
+	if payment.MerchantID == nil {
+		return ""
+	}
+	return payment.MerchantID.String()
+}
+
+// merchantPreferences overlays the payment merchant's own preferences on
+// the gateway-wide ones
+func (s *notificationService) merchantPreferences(ctx context.Context, payment *domain.Payment, event domain.NotificationEvent) (map[domain.NotificationChannel]bool, error) {
+	prefs, err := s.preferencesFor(ctx, domain.RecipientMerchant, "", event)
+	if err != nil || payment.MerchantID == nil {
+		return prefs, err
+	}
+
+	own, err := s.preferencesFor(ctx, domain.RecipientMerchant, merchantKey(payment), event)
+	if err != nil {
+		return nil, err
+	}
+	if len(own) == 0 {
+		return prefs, nil
+	}
+
+	merged := make(map[domain.NotificationChannel]bool, len(prefs)+len(own))
+	for channel, enabled := range prefs {
+		merged[channel] = enabled
+	}
+	for channel, enabled := range own {
+		merged[channel] = enabled
+	}
+	return merged, nil
+}
+
+// preferred applies an explicit preference if there is one, else the default
+func preferred(prefs map[domain.NotificationChannel]bool, channel domain.NotificationChannel, def bool) bool {
+	if enabled, ok := prefs[channel]; ok {
+		return enabled
+	}
+	return def
+}
+
+func (s *notificationService) notifySMS(ctx context.Context, event domain.NotificationEvent, payment *domain.Payment) error {
+	if !s.settings.SMSEnabled || s.sms == nil {
+		return nil
+	}
+
+	if payment.CustomerPhone == "" {
+		s.logger.WithField("payment_id", payment.ID).Debug("No customer phone, skipping SMS")
+		return nil
+	}
+
+	// Payments created before phone validation may hold numbers the SMS
+	// gateway would reject
+	phone, err := domain.NormalizePhone(payment.CustomerPhone)
+	if err != nil {
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Invalid customer phone, skipping SMS")
+		return nil
+	}
+
+	_, text, err := s.templates.Render(ctx, event, domain.ChannelSMS, payment.Language, merchantKey(payment), payment)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.record(ctx, payment.ID, domain.ChannelSMS, phone, text)
+	if err != nil {
+		return err
+	}
+
+	messageID, err := s.sms.SendSMS(ctx, n.Recipient, n.Message)
+	return s.finish(ctx, n, messageID, err)
+}
+
+// SendPaymentOTP texts the confirmation code to the customer. It ignores
+// preferences since the payment cannot proceed without it, and the stored
+// copy of the message has the code masked.
+func (s *notificationService) SendPaymentOTP(ctx context.Context, payment *domain.Payment, code string, ttl time.Duration) error {
+	if !s.settings.SMSEnabled || s.sms == nil {
+		return domain.ErrOTPUnavailable
+	}
+
+	data := struct {
+		*domain.Payment
+		Code    string
+		Minutes int
+	}{
+		Payment: payment,
+		Code:    code,
+		Minutes: int(ttl.Minutes()),
+	}
+
+	phone, err := domain.NormalizePhone(payment.CustomerPhone)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	_, text, err := s.templates.Render(ctx, domain.EventPaymentOTP, domain.ChannelSMS, payment.Language, "", data)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.record(ctx, payment.ID, domain.ChannelSMS, phone, strings.ReplaceAll(text, code, strings.Repeat("*", len(code))))
+	if err != nil {
+		return err
+	}
+
+	messageID, err := s.sms.SendSMS(ctx, phone, text)
+	return s.finish(ctx, n, messageID, err)
+}
+
+// SendPaymentReminder nudges the customer by SMS and the merchant's
+// Telegram chats about a payment that is still unpaid.
+func (s *notificationService) SendPaymentReminder(ctx context.Context, payment *domain.Payment) error {
+	customerPrefs, err := s.preferencesFor(ctx, domain.RecipientCustomer, customerKey(payment), domain.EventPaymentReminder)
+	if err != nil {
+		return err
+	}
+	merchantPrefs, err := s.merchantPreferences(ctx, payment, domain.EventPaymentReminder)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if preferred(customerPrefs, domain.ChannelSMS, preferred(merchantPrefs, domain.ChannelSMS, true)) {
+		if err := s.notifySMS(ctx, domain.EventPaymentReminder, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if preferred(merchantPrefs, domain.ChannelTelegram, true) {
+		if err := s.notifyTelegram(ctx, domain.EventPaymentReminder, payment); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NotifyRefund tells the customer by SMS and email that their refund was
+// sent, copying the email to the merchant when configured. The messages
+// use the refund's amount and currency rather than the payment's.
+func (s *notificationService) NotifyRefund(ctx context.Context, payment *domain.Payment, refund *domain.Refund) error {
+	customerPrefs, err := s.preferencesFor(ctx, domain.RecipientCustomer, customerKey(payment), domain.EventRefundSucceeded)
+	if err != nil {
+		return err
+	}
+	merchantPrefs, err := s.merchantPreferences(ctx, payment, domain.EventRefundSucceeded)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		*domain.Payment
+		Amount   domain.Amount
+		Currency domain.Currency
+		Reason   string
+	}{
+		Payment:  payment,
+		Amount:   refund.Amount,
+		Currency: refund.Currency,
+		Reason:   refund.Reason,
+	}
+
+	var errs []error
+	if preferred(customerPrefs, domain.ChannelSMS, preferred(merchantPrefs, domain.ChannelSMS, true)) {
+		if err := s.notifyRefundSMS(ctx, payment, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.settings.EmailEnabled && s.email != nil {
+		if preferred(customerPrefs, domain.ChannelEmail, s.settings.RefundReceipts) && payment.CustomerEmail != "" {
+			if err := s.sendEmail(ctx, payment, domain.EventRefundSucceeded, payment.Language, []string{payment.CustomerEmail}, data); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if preferred(merchantPrefs, domain.ChannelEmail, s.settings.MerchantReceipts) {
+			if to := s.merchantEmails(ctx, payment); len(to) > 0 {
+				if err := s.sendEmail(ctx, payment, domain.EventRefundSucceeded, s.settings.DefaultLanguage, to, data); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *notificationService) notifyRefundSMS(ctx context.Context, payment *domain.Payment, data interface{}) error {
+	if !s.settings.SMSEnabled || s.sms == nil || payment.CustomerPhone == "" {
+		return nil
+	}
+
+	phone, err := domain.NormalizePhone(payment.CustomerPhone)
+	if err != nil {
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Invalid customer phone, skipping SMS")
+		return nil
+	}
+
+	_, text, err := s.templates.Render(ctx, domain.EventRefundSucceeded, domain.ChannelSMS, payment.Language, merchantKey(payment), data)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.record(ctx, payment.ID, domain.ChannelSMS, phone, text)
+	if err != nil {
+		return err
+	}
+
+	messageID, err := s.sms.SendSMS(ctx, phone, text)
+	return s.finish(ctx, n, messageID, err)
+}
+
+// SendInvoice uses the merchant's invoice.sent templates when it has them.
+// Like the daily summary, invoice messages are not recorded per payment.
+func (s *notificationService) SendInvoice(ctx context.Context, invoice *domain.Invoice) error {
+	merchantID := ""
+	if invoice.MerchantID != nil {
+		merchantID = invoice.MerchantID.String()
+	}
+
+	sent := false
+	var errs []error
+
+	if s.settings.EmailEnabled && s.email != nil && invoice.CustomerEmail != "" {
+		sent = true
+		subject, body, err := s.templates.Render(ctx, domain.EventInvoiceSent, domain.ChannelEmail, invoice.Language, merchantID, invoice)
+		if err == nil {
+			_, err = s.email.SendEmail(ctx, notification.Email{
+				FromName:    s.settings.FromName,
+				FromAddress: s.settings.FromAddress,
+				To:          []string{invoice.CustomerEmail},
+				Subject:     subject,
+				HTML:        body,
+			})
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.settings.SMSEnabled && s.sms != nil && invoice.CustomerPhone != "" {
+		phone, err := domain.NormalizePhone(invoice.CustomerPhone)
+		if err == nil {
+			sent = true
+			var text string
+			_, text, err = s.templates.Render(ctx, domain.EventInvoiceSent, domain.ChannelSMS, invoice.Language, merchantID, invoice)
+			if err == nil {
+				_, err = s.sms.SendSMS(ctx, phone, text)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !sent && len(errs) == 0 {
+		return domain.ErrInvoiceNoRecipient
+	}
+	if err := errors.Join(errs...); err != nil {
+		s.logger.WithError(err).WithField("invoice_id", invoice.ID).Error("Failed to send invoice")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"invoice_id": invoice.ID,
+		"number":     invoice.Number,
+	}).Info("Invoice sent to customer")
+
+	return nil
+}
+
+// notifyTelegram posts the status to the gateway's chats and the paying
+// merchant's
+func (s *notificationService) notifyTelegram(ctx context.Context, event domain.NotificationEvent, payment *domain.Payment) error {
+	if !s.settings.TelegramEnabled || s.telegram == nil {
+		return nil
+	}
+
+	linked, err := s.telegramRepo.ListLinked(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var chats []*domain.TelegramChat
+	for _, chat := range linked {
+		if chat.MerchantID == "" || (payment.MerchantID != nil && chat.MerchantID == payment.MerchantID.String()) {
+			chats = append(chats, chat)
+		}
+	}
+	if len(chats) == 0 {
+		return nil
+	}
+
+	_, text, err := s.templates.Render(ctx, event, domain.ChannelTelegram, s.settings.DefaultLanguage, "", payment)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, chat := range chats {
+		n, err := s.record(ctx, payment.ID, domain.ChannelTelegram, strconv.FormatInt(chat.ChatID, 10), text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		messageID, err := s.telegram.SendMessage(ctx, chat.ChatID, n.Message)
+		if err := s.finish(ctx, n, messageID, err); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// notifyPush sends to every registered merchant device, forgetting devices
+// whose tokens FCM reports as no longer valid
+func (s *notificationService) notifyPush(ctx context.Context, event domain.NotificationEvent, payment *domain.Payment) error {
+	if !s.settings.PushEnabled || s.push == nil {
+		return nil
+	}
+
+	devices, err := s.pushDevices.ListByMerchant(ctx, "")
+	if err != nil || len(devices) == 0 {
+		return err
+	}
+
+	title, body, err := s.templates.Render(ctx, event, domain.ChannelPush, s.settings.DefaultLanguage, "", payment)
+	if err != nil {
+		return err
+	}
+
+	msg := notification.PushMessage{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"event":      string(event),
+			"payment_id": payment.ID.String(),
+			"reference":  payment.Reference,
+		},
+	}
+
+	var errs []error
+	for _, device := range devices {
+		n, err := s.record(ctx, payment.ID, domain.ChannelPush, "device:"+device.ID.String(), body)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		messageID, sendErr := s.push.SendPush(ctx, device.Token, msg)
+		if errors.Is(sendErr, notification.ErrPushTokenInvalid) {
+			if err := s.pushDevices.Delete(ctx, device.ID, nil); err != nil {
+				s.logger.WithError(err).WithField("device_id", device.ID).Warn("Failed to remove stale push device")
+			}
+		}
+		if err := s.finish(ctx, n, messageID, sendErr); err != nil && !errors.Is(err, notification.ErrPushTokenInvalid) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// merchantEmails is where merchant copies and alerts for a payment go: its
+// merchant's address, else the configured merchant addresses
+func (s *notificationService) merchantEmails(ctx context.Context, payment *domain.Payment) []string {
+	if payment.MerchantID != nil {
+		merchant, err := s.merchantRepo.GetByID(ctx, *payment.MerchantID)
+		if err != nil {
+			s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to get merchant email")
+		} else if merchant.Email != "" {
+			return []string{merchant.Email}
+		}
+	}
+	return s.settings.MerchantEmails
+}
+
+// sendEmail uses the payment merchant's templates when it has them
+func (s *notificationService) sendEmail(ctx context.Context, payment *domain.Payment, event domain.NotificationEvent, language domain.Language, to []string, data interface{}) error {
+	subject, body, err := s.templates.Render(ctx, event, domain.ChannelEmail, language, merchantKey(payment), data)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.record(ctx, payment.ID, domain.ChannelEmail, to[0], subject)
+	if err != nil {
+		return err
+	}
+
+	messageID, err := s.email.SendEmail(ctx, notification.Email{
+		FromName:    s.settings.FromName,
+		FromAddress: s.settings.FromAddress,
+		To:          to,
+		Subject:     subject,
+		HTML:        body,
+	})
+	return s.finish(ctx, n, messageID, err)
+}
+
+// SendDailySummary emails merchants the totals for the given (UTC) day
+func (s *notificationService) SendDailySummary(ctx context.Context, day time.Time) error {
+	if !s.settings.EmailEnabled || s.email == nil || len(s.settings.MerchantEmails) == 0 {
+		return nil
+	}
+
+	merchantPrefs, err := s.preferencesFor(ctx, domain.RecipientMerchant, "", domain.EventDailySummary)
+	if err != nil {
+		return err
+	}
+	if !preferred(merchantPrefs, domain.ChannelEmail, s.settings.DailySummary) {
+		return nil
+	}
+
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	totals, err := s.paymentRepo.Totals(ctx, domain.PaymentFilter{
+		CreatedFrom: from.Format(time.RFC3339),
+		CreatedTo:   from.AddDate(0, 0, 1).Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Date  string
+		Stats *PaymentStatistics
+	}{
+		Date:  from.Format("2006-01-02"),
+		Stats: summarize(totals),
+	}
+
+	subject, body, err := s.templates.Render(ctx, domain.EventDailySummary, domain.ChannelEmail, s.settings.DefaultLanguage, "", data)
+	if err != nil {
+		return err
+	}
+
+	messageID, err := s.email.SendEmail(ctx, notification.Email{
+		FromName:    s.settings.FromName,
+		FromAddress: s.settings.FromAddress,
+		To:          s.settings.MerchantEmails,
+		Subject:     subject,
+		HTML:        body,
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to send daily summary email")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"date":       data.Date,
+		"payments":   data.Stats.TotalPayments,
+		"message_id": messageID,
+	}).Info("Daily payment summary sent")
+
+	return nil
+}
+
+func (s *notificationService) record(ctx context.Context, paymentID uuid.UUID, channel domain.NotificationChannel, recipient, message string) (*domain.Notification, error) {
+	now := time.Now().UTC()
+	n := &domain.Notification{
+		ID:        uuid.New(),
+		PaymentID: paymentID,
+		Channel:   channel,
+		Recipient: recipient,
+		Message:   message,
+		Status:    domain.NotificationQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.Create(ctx, n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// finish records the outcome of a send attempt
+func (s *notificationService) finish(ctx context.Context, n *domain.Notification, messageID string, sendErr error) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"payment_id":      n.PaymentID,
+		"notification_id": n.ID,
+		"channel":         n.Channel,
+	})
+
+	if sendErr != nil {
+		logger.WithError(sendErr).Error("Failed to send notification")
+		if err := s.repo.UpdateStatus(ctx, n.ID, domain.NotificationFailed, "", sendErr.Error()); err != nil {
+			logger.WithError(err).Error("Failed to record notification failure")
+		}
+		return sendErr
+	}
+
+	if err := s.repo.UpdateStatus(ctx, n.ID, domain.NotificationSent, messageID, ""); err != nil {
+		return err
+	}
+
+	logger.WithField("message_id", messageID).Info("Payment notification sent")
+	return nil
+}
+
+func (s *notificationService) HandleDeliveryReport(ctx context.Context, report domain.DeliveryReport) error {
+	if report.MessageID == "" || !report.Status.IsValid() {
+		return domain.ErrInvalidInput
+	}
+
+	if err := s.repo.UpdateStatusByProviderID(ctx, report.MessageID, report.Status, report.Error); err != nil {
+		s.logger.WithError(err).WithField("message_id", report.MessageID).Warn("Failed to apply SMS delivery report")
+		return err
+	}
+
+	return nil
+}
+
+func (s *notificationService) ListPaymentNotifications(ctx context.Context, paymentID uuid.UUID) ([]*domain.Notification, error) {
+	return s.repo.ListByPayment(ctx, paymentID)
+}
+
+// CreateTelegramLink issues a one-time code and the t.me deep link that
+// links whichever chat opens it to the gateway bot.
+func (s *notificationService) CreateTelegramLink(ctx context.Context, label, merchantID string) (*domain.TelegramChat, string, error) {
+	if label == "" {
+		return nil, "", domain.ErrInvalidInput
+	}
+
+	code := make([]byte, 12)
+	if _, err := rand.Read(code); err != nil {
+		return nil, "", err
+	}
+
+	chat := &domain.TelegramChat{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		Label:      label,
+		LinkCode:   hex.EncodeToString(code),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := s.telegramRepo.Create(ctx, chat); err != nil {
+		return nil, "", err
+	}
+
+	deepLink := fmt.Sprintf("https://t.me/%s?start=%s", s.settings.TelegramBotUsername, chat.LinkCode)
+	return chat, deepLink, nil
+}
+
+// HandleTelegramUpdate processes bot updates; only "/start <code>" is acted on
+func (s *notificationService) HandleTelegramUpdate(ctx context.Context, update notification.TelegramUpdate) error {
+	if update.Message == nil {
+		return nil
+	}
+
+	fields := strings.Fields(update.Message.Text)
+	if len(fields) != 2 || fields[0] != "/start" {
+		return nil
+	}
+
+	chatTitle := update.Message.Chat.Title
+	if chatTitle == "" {
+		chatTitle = update.Message.Chat.FirstName
+	}
+
+	chat, err := s.telegramRepo.Link(ctx, fields[1], update.Message.Chat.ID, chatTitle)
+	if err != nil {
+		s.logger.WithError(err).WithField("chat_id", update.Message.Chat.ID).Warn("Telegram link attempt failed")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"chat_id": chat.ChatID,
+		"label":   chat.Label,
+	}).Info("Telegram chat linked")
+
+	if s.telegram != nil {
+		text := "✅ ይህ ቻት ከክፍያ መግቢያው ጋር ተገናኝቷል። This chat will now receive payment notifications (" + chat.Label + ")."
+		if _, err := s.telegram.SendMessage(ctx, chat.ChatID, htmltemplate.HTMLEscapeString(text)); err != nil {
+			s.logger.WithError(err).Warn("Failed to send Telegram link confirmation")
+		}
+	}
+
+	return nil
+}
+
+func (s *notificationService) ListTelegramChats(ctx context.Context, merchantID *string) ([]*domain.TelegramChat, error) {
+	return s.telegramRepo.ListLinked(ctx, merchantID)
+}
+
+func (s *notificationService) UnlinkTelegramChat(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	return s.telegramRepo.Delete(ctx, id, merchantID)
+}
+
+func (s *notificationService) ListPreferences(ctx context.Context, recipientType domain.RecipientType, recipientID string) ([]*domain.NotificationPreference, error) {
+	return s.prefs.List(ctx, recipientType, recipientID)
+}
+
+func (s *notificationService) SavePreference(ctx context.Context, pref *domain.NotificationPreference) error {
+	if err := pref.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if pref.ID == uuid.Nil {
+		pref.ID = uuid.New()
+	}
+
+	return s.prefs.Upsert(ctx, pref)
+}
+
+func (s *notificationService) DeletePreference(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	return s.prefs.Delete(ctx, id, merchantID)
+}
+
+func (s *notificationService) RegisterPushDevice(ctx context.Context, device *domain.PushDevice) error {
+	if err := device.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if device.ID == uuid.Nil {
+		device.ID = uuid.New()
+	}
+	device.CreatedAt = time.Now().UTC()
+
+	return s.pushDevices.Register(ctx, device)
+}
+
+func (s *notificationService) ListPushDevices(ctx context.Context, merchantID string) ([]*domain.PushDevice, error) {
+	return s.pushDevices.ListByMerchant(ctx, merchantID)
+}
+
+func (s *notificationService) UnregisterPushDevice(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	return s.pushDevices.Delete(ctx, id, merchantID)
+}
@@ -2,93 +2,424 @@ package service
 
 import (
 	"context"
-	"math/rand"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
 	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/provider"
 	"payment-gateway/internal/repository"
+	"payment-gateway/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PaymentService interface {
 	CreatePayment(ctx context.Context, req domain.CreatePaymentRequest) (*domain.Payment, error)
 	GetPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
 	GetPaymentByReference(ctx context.Context, reference string) (*domain.Payment, error)
-	ListPayments(ctx context.Context, page, limit int) ([]*domain.Payment, int, error)
+	// ListPayments returns a page of payments in the filter's sort order. A
+	// cursor from a previous page takes precedence over page.
+	ListPayments(ctx context.Context, filter domain.PaymentFilter, cursor string, page, limit int) (*domain.PaymentPage, error)
+	ListCustomerPayments(ctx context.Context, phone string, merchantID *uuid.UUID, page, limit int) ([]*domain.Payment, int, error)
+	// SearchPayments finds payments by part of their reference, customer
+	// name or description, best matches first. A non-nil merchantID limits
+	// it to that merchant's payments.
+	SearchPayments(ctx context.Context, query string, merchantID *uuid.UUID, page, limit int) (*domain.PaymentPage, error)
+	// ExportPayments returns an Excel workbook of the filtered payments with
+	// summaries by bank and currency, and its file name
+	ExportPayments(ctx context.Context, filter domain.PaymentFilter) ([]byte, string, error)
+	ConfirmOTP(ctx context.Context, id uuid.UUID, code string) (*domain.Payment, error)
+	ResendOTP(ctx context.Context, id uuid.UUID) error
+	RetryPayment(ctx context.Context, id uuid.UUID, req domain.RetryPaymentRequest) (*domain.Payment, error)
+	// CancelPayment withdraws a payment the worker has not picked up yet
+	CancelPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	ListAttempts(ctx context.Context, id uuid.UUID) ([]*domain.PaymentAttempt, error)
+	OverrideStatus(ctx context.Context, id uuid.UUID, req domain.OverrideStatusRequest, operator string) (*domain.Payment, error)
+	ListStatusOverrides(ctx context.Context, id uuid.UUID) ([]*domain.StatusOverride, error)
+	// ListReviews returns the AML review queue, oldest first
+	ListReviews(ctx context.Context, status domain.PaymentReviewStatus, page, limit int) ([]*domain.PaymentReview, error)
+	GetReview(ctx context.Context, id uuid.UUID) (*domain.PaymentReview, error)
+	// ReviewPayment clears an UNDER_REVIEW payment for processing or fails it
+	ReviewPayment(ctx context.Context, id uuid.UUID, req domain.ReviewDecisionRequest, operator string) (*domain.Payment, error)
+	// SetTags replaces the payment's merchant labels
+	SetTags(ctx context.Context, id uuid.UUID, req domain.SetTagsRequest) (*domain.Payment, error)
 	ProcessPayment(ctx context.Context, id uuid.UUID) error
-	GetStatistics(ctx context.Context) (*PaymentStatistics, error)
+	// SyncWithProvider asks the payment's provider for the outcome of a
+	// payment left PROCESSING at the provider, e.g. on a provider webhook
+	SyncWithProvider(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	GetStatistics(ctx context.Context, filter domain.PaymentFilter) (*PaymentStatistics, error)
+	// SetMaxETBAmount replaces the per-payment regulatory limit, on a
+	// configuration reload
+	SetMaxETBAmount(limit domain.Amount)
 }
 
 type paymentService struct {
-	repo      repository.PaymentRepository
-	publisher messaging.PaymentPublisher
-	logger    *logrus.Logger
+	repo       repository.PaymentRepository
+	otpRepo    repository.PaymentOTPRepository
+	attempts   repository.PaymentAttemptRepository
+	overrides  repository.StatusOverrideRepository
+	reviews    repository.PaymentReviewRepository
+	customers  repository.CustomerRepository
+	tx         repository.Transactor
+	publisher  messaging.PaymentPublisher
+	notifier   NotificationService
+	reminders  ReminderService
+	fx         FXService
+	fraud      FraudService
+	providers  *provider.Registry
+	otp        OTPSettings
+	references ReferenceSettings
+	limits     CustomerLimitSettings
+	clients    ClientControlSettings
+	aml        AMLSettings
+	velocity   VelocitySettings
+	kyc        KYCSettings
+	blockedIPs []netip.Prefix
+	logger     *logrus.Logger
+
+	maxETBAmount atomic.Int64 // Per-payment regulatory limit, until a reload replaces it; zero keeps the default
+}
+
+// OTPSettings controls the optional customer OTP confirmation step
+type OTPSettings struct {
+	Enabled     bool
+	CodeLength  int
+	TTL         time.Duration
+	MaxAttempts int
+	MaxSends    int
 }
 
+// ReferenceSettings controls server-generated payment references
+type ReferenceSettings struct {
+	Prefixes []string // Allowed prefixes; the first is the default
+}
+
+// Attempts before giving up on finding an unused reference
+const maxReferenceAttempts = 5
+
 // Ethiopian Payment Statistics
 type PaymentStatistics struct {
-	TotalPayments      int     `json:"total_payments"`
-	TotalAmountETB     float64 `json:"total_amount_etb"`
-	TotalAmountUSD     float64 `json:"total_amount_usd"`
-	SuccessfulPayments int     `json:"successful_payments"`
-	FailedPayments     int     `json:"failed_payments"`
-	PendingPayments    int     `json:"pending_payments"`
-	AverageAmountETB   float64 `json:"average_amount_etb"`
-	AverageAmountUSD   float64 `json:"average_amount_usd"`
+	TotalPayments      int           `json:"total_payments"`
+	TotalAmountETB     domain.Amount `json:"total_amount_etb"`
+	TotalAmountUSD     domain.Amount `json:"total_amount_usd"`
+	SuccessfulPayments int           `json:"successful_payments"`
+	FailedPayments     int           `json:"failed_payments"`
+	PendingPayments    int           `json:"pending_payments"`
+	AverageAmountETB   domain.Amount `json:"average_amount_etb"` // Rounded to the santim
+	AverageAmountUSD   domain.Amount `json:"average_amount_usd"`
+	// Totals of every currency, including EUR, GBP, AED and CNY
+	TotalsByCurrency map[domain.Currency]domain.Amount `json:"totals_by_currency"`
 }
 
-func NewPaymentService(repo repository.PaymentRepository, publisher messaging.PaymentPublisher, logger *logrus.Logger) PaymentService {
-	return &paymentService{
-		repo:      repo,
-		publisher: publisher,
-		logger:    logger,
+func NewPaymentService(repo repository.PaymentRepository, otpRepo repository.PaymentOTPRepository, attempts repository.PaymentAttemptRepository, overrides repository.StatusOverrideRepository, reviews repository.PaymentReviewRepository, customers repository.CustomerRepository, tx repository.Transactor, publisher messaging.PaymentPublisher, notifier NotificationService, reminders ReminderService, fx FXService, fraud FraudService, providers *provider.Registry, otp OTPSettings, references ReferenceSettings, limits CustomerLimitSettings, clients ClientControlSettings, aml AMLSettings, velocity VelocitySettings, kyc KYCSettings, maxETBAmount domain.Amount, logger *logrus.Logger) PaymentService {
+	if otp.CodeLength < 4 || otp.CodeLength > 10 {
+		otp.CodeLength = 6
+	}
+	if otp.TTL <= 0 {
+		otp.TTL = 5 * time.Minute
+	}
+	if otp.MaxAttempts <= 0 {
+		otp.MaxAttempts = 5
+	}
+	if otp.MaxSends <= 0 {
+		otp.MaxSends = 3
+	}
+	if len(references.Prefixes) == 0 {
+		references.Prefixes = []string{domain.DefaultReferencePrefix}
+	}
+	if clients.VelocityWindow <= 0 {
+		clients.VelocityWindow = 10 * time.Minute
+	}
+
+	s := &paymentService{
+		repo:       repo,
+		otpRepo:    otpRepo,
+		attempts:   attempts,
+		overrides:  overrides,
+		reviews:    reviews,
+		customers:  customers,
+		tx:         tx,
+		publisher:  publisher,
+		notifier:   notifier,
+		reminders:  reminders,
+		fx:         fx,
+		fraud:      fraud,
+		providers:  providers,
+		otp:        otp,
+		references: references,
+		limits:     limits,
+		clients:    clients,
+		aml:        aml,
+		velocity:   velocity,
+		kyc:        kyc,
+		blockedIPs: parseBlockedIPs(clients.BlockedIPs, logger),
+		logger:     logger,
 	}
+	s.maxETBAmount.Store(int64(maxETBAmount))
+	return s
+}
+
+func (s *paymentService) SetMaxETBAmount(limit domain.Amount) {
+	s.maxETBAmount.Store(int64(limit))
 }
 
 func (s *paymentService) CreatePayment(ctx context.Context, req domain.CreatePaymentRequest) (*domain.Payment, error) {
 	// Validate request
-	if err := req.Validate(); err != nil {
+	if err := req.Validate(domain.Amount(s.maxETBAmount.Load())); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if !s.fx.Accepts(req.Currency) {
+		return nil, fmt.Errorf("%w: %s payments are not accepted", domain.ErrInvalidInput, req.Currency)
+	}
+
+	reference := req.Reference
+	if reference == "" {
+		generated, err := s.generateReference(ctx, req.ReferencePrefix, req.BankCode)
+		if err != nil {
+			return nil, err
+		}
+		reference = generated
+	} else {
+		// Check if payment with same reference already exists
+		existing, err := s.repo.GetByReference(ctx, reference)
+		if err != nil && err != domain.ErrPaymentNotFound {
+			s.logger.WithError(err).Error("Failed to check existing payment")
+			return nil, err
+		}
+		if existing != nil {
+			return nil, domain.ErrPaymentAlreadyExists
+		}
+	}
+
+	if req.RequireOTP && !s.otp.Enabled {
+		return nil, domain.ErrOTPUnavailable
+	}
+
+	// Aggregator payments must never reach the simulated fallback
+	if req.PaymentMethod != domain.MethodBank && !s.providers.Registered(string(req.PaymentMethod)) {
+		return nil, domain.ErrMethodUnavailable
+	}
+
+	if err := s.checkClient(ctx, &req); err != nil {
 		return nil, err
 	}
 
-	// Check if payment with same reference already exists
-	existing, err := s.repo.GetByReference(ctx, req.Reference)
-	if err != nil && err != domain.ErrPaymentNotFound {
-		s.logger.WithError(err).Error("Failed to check existing payment")
+	// A quote locks the rate into ETB the payment settles at
+	var quote *domain.FXQuote
+	if req.FXQuoteID != "" {
+		var err error
+		quote, err = s.fx.GetQuote(ctx, uuid.MustParse(req.FXQuoteID))
+		if err != nil {
+			return nil, err
+		}
+		if err := quote.CheckUsable(req.Currency, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+
+	// The customer's profile fills in what the request leaves out, so limits
+	// and screening see the verified identity
+	customer, err := s.resolveCustomer(ctx, &req, quote)
+	if err != nil {
 		return nil, err
 	}
-	if existing != nil {
-		return nil, domain.ErrPaymentAlreadyExists
+	var customerID *uuid.UUID
+	if customer != nil {
+		customerID = &customer.ID
+		if req.CustomerName == "" {
+			req.CustomerName = customer.FullName
+		}
+		if req.CustomerPhone == "" {
+			req.CustomerPhone = customer.Phone
+		}
+		if req.CustomerNationalID == "" {
+			req.CustomerNationalID = customer.NationalID
+		}
+	}
+
+	hits, holdReason := s.screen(ctx, req.CustomerName)
+
+	// Store phones in one format so customer lookups match
+	customerPhone := req.CustomerPhone
+	if customerPhone != "" {
+		customerPhone, _ = domain.NormalizePhone(customerPhone)
+	}
+
+	// Payments needing payer consent are held until the OTP is confirmed
+	status := domain.StatusPending
+	if req.RequireOTP {
+		status = domain.StatusAwaitingOTP
+	}
+	// Cash payments never go to the bank; an agent settles them against a voucher
+	if req.PayByCash {
+		status = domain.StatusAwaitingCash
+	}
+	// Watch list hits wait for compliance; the review releases the payment
+	// into the status it would have had
+	var review *domain.PaymentReview
+	if holdReason != "" {
+		review = &domain.PaymentReview{
+			ID:            uuid.New(),
+			CustomerName:  req.CustomerName,
+			Hits:          hits,
+			Reason:        holdReason,
+			ReleaseStatus: status,
+			Status:        domain.ReviewOpen,
+		}
+		status = domain.StatusUnderReview
 	}
 
 	// Create payment
 	now := time.Now().UTC()
 	payment := &domain.Payment{
-		ID:           uuid.New(),
-		Amount:       req.Amount,
-		Currency:     req.Currency,
-		Reference:    req.Reference,
-		Status:       domain.StatusPending,
-		Description:  req.Description,
-		CustomerName: req.CustomerName,
-		BankCode:     req.BankCode,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:                 uuid.New(),
+		Amount:             req.Amount,
+		Currency:           req.Currency,
+		Reference:          reference,
+		Status:             status,
+		Description:        req.Description,
+		CustomerName:       req.CustomerName,
+		CustomerPhone:      customerPhone,
+		CustomerEmail:      req.CustomerEmail,
+		CustomerNationalID: req.CustomerNationalID,
+		CustomerID:         customerID,
+		Language:           req.Language,
+		BankCode:           req.BankCode,
+		PurposeCode:        req.PurposeCode,
+		MCC:                req.MCC,
+		ClientIP:           req.Client.IP,
+		ClientCountry:      req.Client.Country,
+		DeviceFingerprint:  req.Client.DeviceFingerprint,
+		Tags:               req.Tags,
+		PaymentMethod:      req.PaymentMethod,
+		MerchantID:         req.MerchantID,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
-	// Save to database
-	if err := s.repo.Create(ctx, payment); err != nil {
-		s.logger.WithError(err).Error("Failed to create payment")
+	if quote != nil {
+		payment.FXQuoteID = &quote.ID
+		payment.FXRate = quote.Rate
+		payment.AmountETB = domain.ConvertToETB(payment.Amount, quote.Rate)
+	}
+
+	// Fraud rules see the payment as it would be stored. Blocked payments
+	// are never created; flagged ones go ahead with the hit recorded.
+	fraudHit, err := s.fraud.Evaluate(ctx, payment)
+	if err != nil {
 		return nil, err
 	}
+	if fraudHit != nil && fraudHit.Action == domain.FraudBlock {
+		if err := s.fraud.RecordHit(ctx, fraudHit); err != nil {
+			s.logger.WithError(err).Warn("Failed to record fraud hit")
+		}
+		s.logger.WithFields(logrus.Fields{
+			"reference":  payment.Reference,
+			"rule_id":    fraudHit.RuleID,
+			"rule_name":  fraudHit.RuleName,
+			"amount_etb": fraudHit.AmountETB,
+		}).Warn("Payment blocked by fraud rule")
+		return nil, domain.ErrPaymentDeclined
+	}
+
+	// Payments for the worker are queued in the transaction that creates them
+	queue := payment.Status == domain.StatusPending && !payment.PaymentMethod.Redirects()
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		// The customer stays locked until the payment is written, so two
+		// payments at once cannot both fit under the same remaining limit
+		if (s.limits.Enabled || s.velocity.Enabled) && (customerPhone != "" || req.CustomerNationalID != "") {
+			if err := s.repo.LockCustomer(ctx, customerPhone, req.CustomerNationalID); err != nil {
+				return err
+			}
+		}
+		limitFlag, err := s.checkCustomerLimits(ctx, req.Amount, req.Currency, customerPhone, req.CustomerNationalID)
+		if err != nil {
+			return err
+		}
+		payment.LimitFlag = limitFlag
+		if err := s.checkVelocity(ctx, req.Amount, req.Currency, customerPhone, req.CustomerNationalID, req.BankCode); err != nil {
+			return err
+		}
+
+		if quote != nil {
+			err = s.repo.CreateWithQuote(ctx, payment, now)
+		} else {
+			err = s.repo.Create(ctx, payment)
+		}
+		if err != nil {
+			return err
+		}
+		if fraudHit != nil {
+			fraudHit.PaymentID = &payment.ID
+			if err := s.fraud.RecordHit(ctx, fraudHit); err != nil {
+				return err
+			}
+		}
+		if review != nil {
+			review.PaymentID = payment.ID
+			review.CreatedAt = now
+			return s.reviews.Create(ctx, review)
+		}
+		if !queue {
+			return nil
+		}
+		if err := s.publisher.PublishPaymentCreated(ctx, payment.ID); err != nil {
+			s.logger.WithError(err).Error("Failed to publish payment message")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrCustomerLimitExceeded) && !errors.Is(err, domain.ErrVelocityLimitExceeded) {
+			s.logger.WithError(err).Error("Failed to create payment")
+		}
+		return nil, err
+	}
+
+	if payment.Status == domain.StatusAwaitingOTP {
+		// The customer can ask for a resend, so a failed send does not fail creation
+		if err := s.issueOTP(ctx, payment, false); err != nil {
+			s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to send payment OTP")
+		}
+		if err := s.reminders.Schedule(ctx, domain.ReminderSubjectPayment, payment.ID, payment.ID, nil); err != nil {
+			s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to schedule payment reminders")
+		}
+	} else if payment.Status == domain.StatusPending && payment.PaymentMethod.Redirects() {
+		// The customer is redirected straight away, so the checkout is
+		// opened now rather than by the worker
+		if err := s.ProcessPayment(ctx, payment.ID); err != nil {
+			s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to open checkout, queueing payment")
+			if err := s.publisher.PublishPaymentCreated(ctx, payment.ID); err != nil {
+				s.logger.WithError(err).Error("Failed to publish payment message")
+			}
+		} else if opened, err := s.repo.GetByID(ctx, payment.ID); err == nil {
+			payment = opened
+		}
+	}
 
-	// Publish message for async processing
-	if err := s.publisher.PublishPaymentCreated(ctx, payment.ID); err != nil {
-		s.logger.WithError(err).Error("Failed to publish payment message")
-		// We still return success, as payment is created in database
+	if review != nil {
+		s.logger.WithFields(logrus.Fields{
+			"payment_id": payment.ID,
+			"review_id":  review.ID,
+			"hits":       len(review.Hits),
+			"reason":     review.Reason,
+		}).Warn("Payment held for AML review")
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -103,6 +434,43 @@ func (s *paymentService) CreatePayment(ctx context.Context, req domain.CreatePay
 	return payment, nil
 }
 
+// generateReference picks an unused reference. Collisions are unlikely with
+// 40 random bits per day, but the unique index would reject one anyway.
+func (s *paymentService) generateReference(ctx context.Context, prefix, bankCode string) (string, error) {
+	if prefix == "" {
+		prefix = s.references.Prefixes[0]
+	} else if !s.allowedPrefix(prefix) {
+		return "", fmt.Errorf("%w: reference_prefix must be one of %s", domain.ErrInvalidInput, strings.Join(s.references.Prefixes, ", "))
+	}
+
+	for attempt := 0; attempt < maxReferenceAttempts; attempt++ {
+		reference, err := domain.GenerateReference(prefix, bankCode, etime.Now())
+		if err != nil {
+			return "", err
+		}
+
+		_, err = s.repo.GetByReference(ctx, reference)
+		if err == domain.ErrPaymentNotFound {
+			return reference, nil
+		}
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to check generated reference")
+			return "", err
+		}
+	}
+
+	return "", domain.ErrReferenceGeneration
+}
+
+func (s *paymentService) allowedPrefix(prefix string) bool {
+	for _, p := range s.references.Prefixes {
+		if strings.EqualFold(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *paymentService) GetPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
 	payment, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -124,7 +492,10 @@ func (s *paymentService) GetPaymentByReference(ctx context.Context, reference st
 	return payment, nil
 }
 
-func (s *paymentService) ListPayments(ctx context.Context, page, limit int) ([]*domain.Payment, int, error) {
+func (s *paymentService) ListPayments(ctx context.Context, filter domain.PaymentFilter, cursor string, page, limit int) (*domain.PaymentPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
 	if page < 1 {
 		page = 1
 	}
@@ -132,124 +503,716 @@ func (s *paymentService) ListPayments(ctx context.Context, page, limit int) ([]*
 		limit = 20
 	}
 
+	var after *domain.PaymentCursor
+	if cursor != "" {
+		if !filter.Sort.Keyset() {
+			return nil, fmt.Errorf("%w: cursor applies to created_at sorts only; use page", domain.ErrInvalidInput)
+		}
+		var err error
+		if after, err = domain.ParsePaymentCursor(cursor); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+		}
+	}
+
 	offset := (page - 1) * limit
 
-	// Get paginated payments
-	payments, err := s.repo.List(ctx, limit, offset)
+	// One row past the page tells whether there is a next one
+	payments, err := s.repo.List(ctx, filter, after, limit+1, offset)
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to list payments")
+		return nil, err
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.PaymentPage{Payments: payments, Total: total}
+	if len(payments) > limit {
+		result.Payments = payments[:limit]
+		result.HasMore = true
+		if filter.Sort.Keyset() {
+			last := payments[limit-1]
+			result.NextCursor = domain.PaymentCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+		}
+	}
+
+	return result, nil
+}
+
+func (s *paymentService) SearchPayments(ctx context.Context, query string, merchantID *uuid.UUID, page, limit int) (*domain.PaymentPage, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	// Trigram matching needs three characters to narrow anything down
+	if n := utf8.RuneCountInString(query); n < 3 || n > 100 {
+		return nil, fmt.Errorf("%w: q must be 3 to 100 characters", domain.ErrInvalidInput)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	payments, err := s.repo.Search(ctx, query, merchantID, limit+1, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.PaymentPage{Payments: payments}
+	if len(payments) > limit {
+		result.Payments = payments[:limit]
+		result.HasMore = true
+	}
+	return result, nil
+}
+
+// ListCustomerPayments returns a customer's payment history by phone number,
+// accepting any of the common Ethiopian formats. A non-nil merchantID limits
+// it to that merchant's payments.
+func (s *paymentService) ListCustomerPayments(ctx context.Context, phone string, merchantID *uuid.UUID, page, limit int) ([]*domain.Payment, int, error) {
+	normalized, err := domain.NormalizePhone(phone)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	payments, err := s.repo.ListByCustomerPhone(ctx, normalized, merchantID, limit, (page-1)*limit)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list customer payments")
 		return nil, 0, err
 	}
 
-	// For demo purposes, return length as total
-	total := len(payments)
+	total, err := s.repo.CountByCustomerPhone(ctx, normalized, merchantID)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	return payments, total, nil
 }
 
+// ConfirmOTP checks the customer's code and, if it matches, releases the
+// payment for processing.
+func (s *paymentService) ConfirmOTP(ctx context.Context, id uuid.UUID, code string) (*domain.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == domain.StatusProcessing && payment.ProviderReference != "" {
+		return s.confirmProviderOTP(ctx, payment, code)
+	}
+	if payment.Status != domain.StatusAwaitingOTP {
+		return nil, domain.ErrOTPNotAwaited
+	}
+
+	otp, err := s.otpRepo.GetByPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if otp.VerifiedAt != nil {
+		return nil, domain.ErrOTPNotAwaited
+	}
+	if otp.IsExpired(time.Now().UTC()) {
+		return nil, domain.ErrOTPExpired
+	}
+
+	// Count the attempt before comparing so parallel guesses share the limit
+	allowed, err := s.otpRepo.UseAttempt(ctx, id, s.otp.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, domain.ErrOTPAttemptsExceeded
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashOTP(id, code)), []byte(otp.CodeHash)) != 1 {
+		s.logger.WithField("payment_id", id).Warn("Incorrect payment OTP")
+		return nil, domain.ErrOTPInvalid
+	}
+
+	if err := s.otpRepo.MarkVerified(ctx, id); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		released, err := s.repo.TransitionStatus(ctx, id, domain.StatusAwaitingOTP, domain.StatusPending)
+		if err != nil {
+			return err
+		}
+		if !released {
+			return domain.ErrOTPNotAwaited
+		}
+		if err := s.publisher.PublishPaymentCreated(ctx, payment.ID); err != nil {
+			s.logger.WithError(err).Error("Failed to publish payment message")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	payment.Status = domain.StatusPending
+
+	if err := s.reminders.Complete(ctx, domain.ReminderSubjectPayment, id); err != nil {
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to stop payment reminders")
+	}
+
+	s.logger.WithField("payment_id", id).Info("Payment OTP confirmed")
+
+	return payment, nil
+}
+
+// confirmProviderOTP passes the code to a provider that sent its own OTP,
+// such as Amole, and settles the payment on the answer
+func (s *paymentService) confirmProviderOTP(ctx context.Context, payment *domain.Payment, code string) (*domain.Payment, error) {
+	p, err := s.providers.Lookup(payment.ProviderCode())
+	if err != nil {
+		return nil, domain.ErrOTPNotAwaited
+	}
+	confirmer, ok := p.(provider.OTPConfirmer)
+	if !ok {
+		return nil, domain.ErrOTPNotAwaited
+	}
+
+	result, err := confirmer.ConfirmOTP(ctx, payment, code)
+	if err != nil {
+		if err != domain.ErrOTPExpired {
+			s.logger.WithError(err).WithField("payment_id", payment.ID).Error("Provider OTP confirmation failed")
+		}
+		return nil, err
+	}
+
+	status, reason := providerStatus(result)
+	if status == domain.StatusProcessing {
+		return payment, nil
+	}
+	if err := s.completeProcessing(ctx, payment, status, reason); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"status":     status,
+	}).Info("Provider OTP confirmed")
+
+	return s.repo.GetByID(ctx, payment.ID)
+}
+
+// ResendOTP issues a fresh code, up to the configured number of sends
+func (s *paymentService) ResendOTP(ctx context.Context, id uuid.UUID) error {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if payment.Status != domain.StatusAwaitingOTP {
+		return domain.ErrOTPNotAwaited
+	}
+
+	otp, err := s.otpRepo.GetByPayment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if otp.SendCount >= s.otp.MaxSends {
+		return domain.ErrOTPResendLimit
+	}
+
+	return s.issueOTP(ctx, payment, true)
+}
+
+// issueOTP generates a code, stores its hash and texts it to the customer
+func (s *paymentService) issueOTP(ctx context.Context, payment *domain.Payment, reissue bool) error {
+	code, err := generateOTP(s.otp.CodeLength)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.otp.TTL)
+
+	if reissue {
+		err = s.otpRepo.Reissue(ctx, payment.ID, hashOTP(payment.ID, code), expiresAt)
+	} else {
+		err = s.otpRepo.Create(ctx, &domain.PaymentOTP{
+			PaymentID: payment.ID,
+			CodeHash:  hashOTP(payment.ID, code),
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.SendPaymentOTP(ctx, payment, code, s.otp.TTL)
+}
+
+func generateOTP(length int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := crand.Int(crand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
+
+// hashOTP binds the code to its payment so equal codes hash differently
+func hashOTP(paymentID uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(paymentID.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// CancelPayment moves a PENDING payment to CANCELLED under the same row
+// lock the worker's claim contends for, so a payment is either cancelled
+// or processed, never both. The queued message is left in place; the
+// worker finds the payment no longer pending and skips it.
+func (s *paymentService) CancelPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		cancelled, err := s.repo.UpdateStatusIfPending(ctx, id, domain.StatusCancelled)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return domain.ErrPaymentNotPending
+		}
+		if err := s.publisher.PublishPaymentCancelled(ctx, id); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Error("Failed to publish payment cancelled message")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithField("payment_id", id).Info("Payment cancelled")
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// RetryPayment re-queues a FAILED payment, optionally on a different bank.
+// The failed attempt stays in the history; the retry gets its own record.
+func (s *paymentService) RetryPayment(ctx context.Context, id uuid.UUID, req domain.RetryPaymentRequest) (*domain.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != domain.StatusFailed {
+		return nil, domain.ErrPaymentNotRetryable
+	}
+
+	bankCode := strings.ToUpper(strings.TrimSpace(req.BankCode))
+	if len(bankCode) > 20 {
+		return nil, fmt.Errorf("%w: bank_code is too long", domain.ErrInvalidInput)
+	}
+	if bankCode != "" {
+		if bank, ok := domain.LookupBank(bankCode); ok {
+			if err := bank.ValidateReference(payment.Reference); err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+			}
+		}
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		requeued, err := s.repo.RequeueFailed(ctx, id, bankCode)
+		if err != nil {
+			return err
+		}
+		if !requeued {
+			// Someone else retried it first
+			return domain.ErrPaymentNotRetryable
+		}
+		if err := s.publisher.PublishPaymentCreated(ctx, id); err != nil {
+			s.logger.WithError(err).WithField("payment_id", id).Error("Failed to publish payment retry")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if bankCode != "" {
+		payment.BankCode = bankCode
+	}
+	payment.Status = domain.StatusPending
+
+	attempt := &domain.PaymentAttempt{
+		ID:        uuid.New(),
+		PaymentID: id,
+		BankCode:  payment.BankCode,
+		Trigger:   domain.AttemptTriggerRetry,
+		Status:    domain.AttemptQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.attempts.Queue(ctx, attempt); err != nil {
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to record retry attempt")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": id,
+		"bank_code":  payment.BankCode,
+		"attempt":    attempt.AttemptNumber,
+	}).Info("Failed payment re-queued")
+
+	return payment, nil
+}
+
+func (s *paymentService) ListAttempts(ctx context.Context, id uuid.UUID) ([]*domain.PaymentAttempt, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.attempts.ListByPayment(ctx, id)
+}
+
+// OverrideStatus lets an operator settle a payment by hand. The change goes
+// through the same notifications and reminder cleanup as automatic
+// processing, so downstream systems cannot tell the difference.
+func (s *paymentService) OverrideStatus(ctx context.Context, id uuid.UUID, req domain.OverrideStatusRequest, operator string) (*domain.Payment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	override := &domain.StatusOverride{
+		ID:        uuid.New(),
+		PaymentID: id,
+		ToStatus:  req.Status,
+		Reason:    req.Reason,
+		Operator:  operator,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.overrides.Apply(ctx, override); err != nil {
+		return nil, err
+	}
+
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id":  id,
+		"from_status": override.FromStatus,
+		"to_status":   override.ToStatus,
+		"operator":    operator,
+		"reason":      override.Reason,
+	}).Warn("Payment status overridden by operator")
+
+	if payment.Status == domain.StatusSuccess {
+		s.recordSettlementRate(ctx, payment)
+	}
+
+	if err := s.reminders.Complete(ctx, domain.ReminderSubjectPayment, id); err != nil {
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to stop payment reminders")
+	}
+
+	if err := s.notifier.NotifyPaymentStatus(ctx, payment); err != nil {
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Failed to send payment notification")
+	}
+
+	return payment, nil
+}
+
+func (s *paymentService) SetTags(ctx context.Context, id uuid.UUID, req domain.SetTagsRequest) (*domain.Payment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	if err := s.repo.SetTags(ctx, id, req.Tags); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *paymentService) ListStatusOverrides(ctx context.Context, id uuid.UUID) ([]*domain.StatusOverride, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.overrides.ListByPayment(ctx, id)
+}
+
+// recordSettlementRate fixes the ETB amount of an unquoted foreign currency
+// payment at the daily rate in force when it succeeded
+func (s *paymentService) recordSettlementRate(ctx context.Context, payment *domain.Payment) {
+	if !payment.Currency.IsForeign() || payment.FXRate > 0 {
+		return
+	}
+
+	rate, err := s.fx.CurrentRate(ctx, payment.Currency)
+	if err == nil {
+		payment.FXRate = rate.Rate
+		payment.AmountETB = domain.ConvertToETB(payment.Amount, rate.Rate)
+		err = s.repo.SetSettlementRate(ctx, payment.ID, payment.FXRate, payment.AmountETB)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to record payment settlement rate")
+	}
+}
+
 func (s *paymentService) ProcessPayment(ctx context.Context, id uuid.UUID) error {
 	s.logger.WithField("payment_id", id).Info("Starting payment processing")
 
-	// Idempotent processing - only process if pending
-	// Simulate external payment processing
-	time.Sleep(time.Millisecond * time.Duration(rand.Intn(500)+100))
-
-	// Simulate different payment processors based on bank code
-	var successRate float64
 	payment, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	// Different success rates for different Ethiopian banks
-	switch payment.BankCode {
-	case "CBE":
-		successRate = 0.95 // Commercial Bank of Ethiopia - high success rate
-	case "AWASH":
-		successRate = 0.90 // Awash Bank
-	case "DASHEN":
-		successRate = 0.88 // Dashen Bank
-	case "ABYSSINIA":
-		successRate = 0.92 // Bank of Abyssinia
-	default:
-		successRate = 0.85 // Default for other banks
+	// Idempotent processing - claim the payment only if pending. A payment
+	// left in PROCESSING (worker crash, bank timeout) is resolved by the
+	// status re-query job, never by sending it to the bank again.
+	claimed, err := s.repo.TransitionStatus(ctx, id, domain.StatusPending, domain.StatusProcessing)
+	if err != nil {
+		s.logger.WithError(err).WithField("payment_id", id).Error("Failed to claim payment for processing")
+		return err
+	}
+	if !claimed {
+		s.logger.WithField("payment_id", id).Info("Payment already processed, skipping")
+		return nil
 	}
 
-	// Random result based on success rate
-	var newStatus domain.PaymentStatus
-	if rand.Float64() < successRate {
-		newStatus = domain.StatusSuccess
+	newStatus, failureReason, err := s.sendToProvider(ctx, payment)
+	if errors.Is(err, errSendAgain) {
+		// Nothing is open or held at the provider, so it can safely go back
+		// to PENDING; the error sends the message round the retry queues
+		if _, perr := s.repo.TransitionStatus(context.WithoutCancel(ctx), id, domain.StatusProcessing, domain.StatusPending); perr != nil {
+			s.logger.WithError(perr).WithField("payment_id", id).Error("Failed to park payment for retry")
+			return perr
+		}
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Payment provider call failed, payment parked for retry")
+		return err
+	}
+	if err != nil {
+		// The provider may have moved or held the funds, so it is left in
+		// PROCESSING; the status re-query job or an operator resolves it
+		s.logger.WithError(err).WithField("payment_id", id).Warn("Payment provider call failed")
+		return nil
+	}
+	if newStatus == domain.StatusProcessing {
+		s.logger.WithField("payment_id", id).Info("Payment accepted by provider, outcome pending")
+		return nil
+	}
+	if newStatus == domain.StatusSuccess {
 		s.logger.WithField("payment_id", id).Info("Payment processing successful")
 	} else {
-		newStatus = domain.StatusFailed
 		s.logger.WithField("payment_id", id).Warn("Payment processing failed")
 	}
 
-	// Update status atomically if still pending
-	updated, err := s.repo.UpdateStatusIfPending(ctx, id, newStatus)
+	return s.completeProcessing(ctx, payment, newStatus, failureReason)
+}
+
+// completeProcessing moves the payment out of PROCESSING, records the
+// attempt and notifies the customer
+func (s *paymentService) completeProcessing(ctx context.Context, payment *domain.Payment, newStatus domain.PaymentStatus, failureReason string) error {
+	// Update status atomically if still processing
+	updated, err := s.repo.TransitionStatus(ctx, payment.ID, domain.StatusProcessing, newStatus)
 	if err != nil {
-		s.logger.WithError(err).WithField("payment_id", id).Error("Failed to update payment status")
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Error("Failed to update payment status")
 		return err
 	}
 
 	if !updated {
-		s.logger.WithField("payment_id", id).Info("Payment already processed, skipping")
-		return nil // Idempotent - no error if already processed
+		s.logger.WithField("payment_id", payment.ID).Info("Payment resolved elsewhere, skipping")
+		return nil // An operator override got there first
+	}
+	trace.SpanFromContext(ctx).AddEvent("payment status updated", trace.WithAttributes(
+		attribute.String("payment.id", payment.ID.String()),
+		attribute.String("payment.status", string(newStatus)),
+	))
+
+	if err := s.attempts.RecordResult(ctx, payment.ID, payment.BankCode, domain.AttemptStatus(newStatus), failureReason); err != nil {
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to record payment attempt")
+	}
+
+	if newStatus == domain.StatusSuccess {
+		s.recordSettlementRate(ctx, payment)
 	}
 
 	s.logger.WithFields(logrus.Fields{
-		"payment_id": id,
+		"payment_id": payment.ID,
 		"status":     newStatus,
 		"bank_code":  payment.BankCode,
 		"amount":     payment.Amount,
 		"currency":   payment.Currency,
 	}).Info("Ethiopian payment processed successfully")
 
+	// Notify the customer; a failed notification must not fail the payment
+	payment.Status = newStatus
+	if err := s.notifier.NotifyPaymentStatus(ctx, payment); err != nil {
+		s.logger.WithError(err).WithField("payment_id", payment.ID).Warn("Failed to send payment notification")
+	}
+
 	return nil
 }
 
-func (s *paymentService) GetStatistics(ctx context.Context) (*PaymentStatistics, error) {
-	// Get all payments (limited for demo)
-	payments, err := s.repo.List(ctx, 1000, 0)
+// errSendAgain marks a provider call refused or failed before it reached
+// the provider, so nothing can have been opened, held or moved
+var errSendAgain = errors.New("payment can be sent again")
+
+// rejectedByProvider reports whether the provider answered Authorize with
+// a refusal that says the payment was not taken: a status code that is
+// neither a server error nor a rate limit
+func rejectedByProvider(err error) bool {
+	var status interface{ StatusCode() int }
+	return errors.As(err, &status) && !provider.Retryable(err, true)
+}
+
+// sendToProvider authorizes the payment with its bank's provider, and
+// captures it when the provider holds funds first. PROCESSING means the
+// outcome is not known yet.
+func (s *paymentService) sendToProvider(ctx context.Context, payment *domain.Payment) (status domain.PaymentStatus, reason string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "provider "+payment.ProviderCode(), trace.WithAttributes(
+		attribute.String("payment.id", payment.ID.String()),
+		attribute.String("payment.bank_code", payment.BankCode),
+	))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.String("payment.status", string(status)))
+		span.End()
+	}()
+
+	p, err := s.providers.Lookup(payment.ProviderCode())
+	if err == provider.ErrNoProvider {
+		return domain.StatusFailed, "bank not supported", nil
+	}
+
+	result, err := p.Authorize(ctx, payment)
+	if errors.Is(err, provider.ErrCircuitOpen) || provider.Retryable(err, false) {
+		return "", "", fmt.Errorf("%w: %w", errSendAgain, err)
+	}
+	if rejectedByProvider(err) {
+		return domain.StatusFailed, "rejected by provider: " + err.Error(), nil
+	}
+	if err != nil {
+		// Single-message providers move the money in Authorize, so one
+		// that may have reached the provider is never sent again
+		return "", "", err
+	}
+
+	if result.Reference != "" || result.CheckoutURL != "" {
+		payment.ProviderReference, payment.CheckoutURL = result.Reference, result.CheckoutURL
+		if err := s.repo.SetProviderCheckout(ctx, payment.ID, result.Reference, result.CheckoutURL); err != nil {
+			return "", "", err
+		}
+	}
+
+	if result.Status == provider.Authorized {
+		if result, err = p.Capture(ctx, payment); err != nil {
+			// The funds are already held, so an open circuit here must not
+			// read as a payment that was never sent
+			return "", "", fmt.Errorf("capture: %v", err)
+		}
+	}
+
+	status, reason = providerStatus(result)
+	return status, reason, nil
+}
+
+func (s *paymentService) SyncWithProvider(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusProcessing || payment.ProviderReference == "" {
+		return payment, nil
+	}
+
+	p, err := s.providers.Lookup(payment.ProviderCode())
 	if err != nil {
 		return nil, err
 	}
 
+	result, err := p.Query(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	status, reason := providerStatus(result)
+	if status == domain.StatusProcessing {
+		return payment, nil
+	}
+
+	if err := s.completeProcessing(ctx, payment, status, reason); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// providerStatus maps a provider result onto the payment; PROCESSING means
+// the outcome is not known yet
+func providerStatus(result provider.Result) (domain.PaymentStatus, string) {
+	switch result.Status {
+	case provider.Captured:
+		return domain.StatusSuccess, ""
+	case provider.Declined:
+		return domain.StatusFailed, result.Reason
+	default:
+		return domain.StatusProcessing, ""
+	}
+}
+
+func (s *paymentService) GetStatistics(ctx context.Context, filter domain.PaymentFilter) (*PaymentStatistics, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	totals, err := s.repo.Totals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(totals), nil
+}
+
+// summarize aggregates counts and per-currency totals from payment totals
+// by currency and status
+func summarize(totals []domain.PaymentTotal) *PaymentStatistics {
 	stats := &PaymentStatistics{
-		TotalPayments: len(payments),
+		TotalsByCurrency: map[domain.Currency]domain.Amount{},
 	}
 
-	var totalETB, totalUSD float64
 	var etbCount, usdCount int
+	for _, t := range totals {
+		stats.TotalPayments += t.Count
 
-	for _, payment := range payments {
-		switch payment.Status {
+		switch t.Status {
 		case domain.StatusSuccess:
-			stats.SuccessfulPayments++
+			stats.SuccessfulPayments += t.Count
 		case domain.StatusFailed:
-			stats.FailedPayments++
-		case domain.StatusPending:
-			stats.PendingPayments++
+			stats.FailedPayments += t.Count
+		case domain.StatusPending, domain.StatusAwaitingOTP, domain.StatusProcessing, domain.StatusAwaitingCash:
+			stats.PendingPayments += t.Count
 		}
 
-		if payment.Currency == domain.CurrencyETB {
-			totalETB += payment.Amount
-			etbCount++
-		} else if payment.Currency == domain.CurrencyUSD {
-			totalUSD += payment.Amount
-			usdCount++
+		stats.TotalsByCurrency[t.Currency] += t.Amount
+
+		switch t.Currency {
+		case domain.CurrencyETB:
+			stats.TotalAmountETB += t.Amount
+			etbCount += t.Count
+		case domain.CurrencyUSD:
+			stats.TotalAmountUSD += t.Amount
+			usdCount += t.Count
 		}
 	}
 
-	stats.TotalAmountETB = totalETB
-	stats.TotalAmountUSD = totalUSD
-
 	if etbCount > 0 {
-		stats.AverageAmountETB = totalETB / float64(etbCount)
+		stats.AverageAmountETB = stats.TotalAmountETB.Div(float64(etbCount))
 	}
 	if usdCount > 0 {
-		stats.AverageAmountUSD = totalUSD / float64(usdCount)
+		stats.AverageAmountUSD = stats.TotalAmountUSD.Div(float64(usdCount))
 	}
 
-	return stats, nil
+	return stats
 }
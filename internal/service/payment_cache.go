@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"payment-gateway/internal/cache"
+	"payment-gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// CacheSettings bounds how stale cached reads may be. Writes made through
+// this process invalidate at once; writes from the worker show up within
+// the TTL.
+type CacheSettings struct {
+	PaymentTTL    time.Duration // Payments in SUCCESS or FAILED
+	StatisticsTTL time.Duration
+	MaxEntries    int
+}
+
+// cachedPaymentService serves terminal payments and statistics from memory
+// for clients polling the API
+type cachedPaymentService struct {
+	PaymentService
+	payments   *cache.TTL[*domain.Payment]
+	statistics *cache.TTL[*PaymentStatistics]
+	logger     *logrus.Logger
+}
+
+func NewCachedPaymentService(inner PaymentService, settings CacheSettings, logger *logrus.Logger) PaymentService {
+	if settings.PaymentTTL <= 0 {
+		settings.PaymentTTL = 30 * time.Second
+	}
+	if settings.StatisticsTTL <= 0 {
+		settings.StatisticsTTL = 10 * time.Second
+	}
+
+	return &cachedPaymentService{
+		PaymentService: inner,
+		payments:       cache.NewTTL[*domain.Payment](settings.PaymentTTL, settings.MaxEntries),
+		statistics:     cache.NewTTL[*PaymentStatistics](settings.StatisticsTTL, settings.MaxEntries),
+		logger:         logger,
+	}
+}
+
+func (s *cachedPaymentService) GetPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	if payment, ok := s.payments.Get("id:" + id.String()); ok {
+		return copyPayment(payment), nil
+	}
+
+	payment, err := s.PaymentService.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.remember(payment)
+	return payment, nil
+}
+
+func (s *cachedPaymentService) GetPaymentByReference(ctx context.Context, reference string) (*domain.Payment, error) {
+	if payment, ok := s.payments.Get("ref:" + reference); ok {
+		return copyPayment(payment), nil
+	}
+
+	payment, err := s.PaymentService.GetPaymentByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	s.remember(payment)
+	return payment, nil
+}
+
+func (s *cachedPaymentService) GetStatistics(ctx context.Context, filter domain.PaymentFilter) (*PaymentStatistics, error) {
+	key, err := statisticsKey(filter)
+	if err != nil {
+		return nil, err
+	}
+	if stats, ok := s.statistics.Get(key); ok {
+		return copyStatistics(stats), nil
+	}
+
+	stats, err := s.PaymentService.GetStatistics(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.statistics.Set(key, copyStatistics(stats))
+	return stats, nil
+}
+
+// statisticsKey covers every filter field, the merchant included, so one
+// merchant never gets another's cached statistics
+func statisticsKey(filter domain.PaymentFilter) (string, error) {
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to build statistics cache key: %w", err)
+	}
+	return string(key), nil
+}
+
+func (s *cachedPaymentService) CreatePayment(ctx context.Context, req domain.CreatePaymentRequest) (*domain.Payment, error) {
+	payment, err := s.PaymentService.CreatePayment(ctx, req)
+	if err == nil {
+		s.statistics.Clear()
+	}
+	return payment, err
+}
+
+func (s *cachedPaymentService) ConfirmOTP(ctx context.Context, id uuid.UUID, code string) (*domain.Payment, error) {
+	payment, err := s.PaymentService.ConfirmOTP(ctx, id, code)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) RetryPayment(ctx context.Context, id uuid.UUID, req domain.RetryPaymentRequest) (*domain.Payment, error) {
+	payment, err := s.PaymentService.RetryPayment(ctx, id, req)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) CancelPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	payment, err := s.PaymentService.CancelPayment(ctx, id)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) OverrideStatus(ctx context.Context, id uuid.UUID, req domain.OverrideStatusRequest, operator string) (*domain.Payment, error) {
+	payment, err := s.PaymentService.OverrideStatus(ctx, id, req, operator)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) ReviewPayment(ctx context.Context, id uuid.UUID, req domain.ReviewDecisionRequest, operator string) (*domain.Payment, error) {
+	payment, err := s.PaymentService.ReviewPayment(ctx, id, req, operator)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) SetTags(ctx context.Context, id uuid.UUID, req domain.SetTagsRequest) (*domain.Payment, error) {
+	payment, err := s.PaymentService.SetTags(ctx, id, req)
+	s.forget(id)
+	return payment, err
+}
+
+func (s *cachedPaymentService) ProcessPayment(ctx context.Context, id uuid.UUID) error {
+	err := s.PaymentService.ProcessPayment(ctx, id)
+	s.forget(id)
+	return err
+}
+
+func (s *cachedPaymentService) SyncWithProvider(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	payment, err := s.PaymentService.SyncWithProvider(ctx, id)
+	s.forget(id)
+	return payment, err
+}
+
+// remember caches payments that have reached a terminal status; others
+// are still changing and always read through
+func (s *cachedPaymentService) remember(payment *domain.Payment) {
+	if !payment.Status.IsTerminal() {
+		return
+	}
+	cached := copyPayment(payment)
+	s.payments.Set("id:"+payment.ID.String(), cached)
+	s.payments.Set("ref:"+payment.Reference, cached)
+}
+
+// forget drops the payment and all statistics after a write
+func (s *cachedPaymentService) forget(id uuid.UUID) {
+	if payment, ok := s.payments.Get("id:" + id.String()); ok {
+		s.payments.Delete("ref:" + payment.Reference)
+	}
+	s.payments.Delete("id:" + id.String())
+	s.statistics.Clear()
+}
+
+// copyPayment keeps callers from mutating a cached payment, including
+// its tags and pointer fields
+func copyPayment(payment *domain.Payment) *domain.Payment {
+	p := *payment
+	p.Tags = slices.Clone(payment.Tags)
+	if payment.FXQuoteID != nil {
+		id := *payment.FXQuoteID
+		p.FXQuoteID = &id
+	}
+	if payment.MerchantID != nil {
+		id := *payment.MerchantID
+		p.MerchantID = &id
+	}
+	return &p
+}
+
+func copyStatistics(stats *PaymentStatistics) *PaymentStatistics {
+	c := *stats
+	c.TotalsByCurrency = maps.Clone(stats.TotalsByCurrency)
+	return &c
+}
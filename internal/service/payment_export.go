@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/xlsx"
+)
+
+// maxExportPayments bounds one export; narrow the filter, e.g. by
+// created_from and created_to, for more
+const maxExportPayments = 50000
+
+// exportPageSize is how many payments an export reads at a time
+const exportPageSize = 1000
+
+// exportTotals sums one bank's or currency's payments
+type exportTotals struct {
+	payments    int
+	successful  int
+	failed      int // FAILED, CANCELLED and EXPIRED
+	paid        domain.Amount
+	paidETB     domain.Amount
+	refunded    domain.Amount
+	refundedETB domain.Amount // At the rate of the refunded payment
+}
+
+func (t *exportTotals) add(payment *domain.Payment, etb domain.Amount) {
+	t.payments++
+	switch payment.Status {
+	case domain.StatusSuccess:
+		t.successful++
+		t.paid += payment.Amount
+		t.paidETB += etb
+		t.refunded += payment.RefundedAmount
+		if payment.Amount > 0 {
+			t.refundedETB += payment.RefundedAmount.Mul(etb.Float64() / payment.Amount.Float64())
+		}
+	case domain.StatusFailed, domain.StatusCancelled, domain.StatusExpired:
+		t.failed++
+	}
+}
+
+func (t *exportTotals) successRate() xlsx.Percent {
+	if t.payments == 0 {
+		return 0
+	}
+	return xlsx.Percent(float64(t.successful) / float64(t.payments))
+}
+
+// ExportPayments writes the filtered payments to an Excel workbook with a
+// detail sheet and summaries by bank and by currency. Payments are listed
+// oldest first unless the filter sorts them otherwise; times are in
+// Ethiopian time.
+func (s *paymentService) ExportPayments(ctx context.Context, filter domain.PaymentFilter) ([]byte, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	if filter.Sort == "" {
+		filter.Sort = domain.SortOldest
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if total > maxExportPayments {
+		return nil, "", fmt.Errorf("%w: %d payments match; an export holds at most %d, narrow created_from and created_to", domain.ErrInvalidInput, total, maxExportPayments)
+	}
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	book := xlsx.New()
+	detail := book.AddSheet("Payments")
+	detail.SetWidths(18, 16, 30, 14, 14, 9, 14, 14, 10, 28, 15, 40, 10, 38)
+	detail.AddHeader("Created", "Created (EC)", "Reference", "Status", "Amount", "Currency", "Amount (ETB)", "Refunded",
+		"Bank", "Customer", "Customer phone", "Description", "Purpose", "Payment ID")
+
+	byBank := map[string]*exportTotals{}
+	byCurrency := map[domain.Currency]*exportTotals{}
+
+	var cursor *domain.PaymentCursor
+	offset := 0
+	for {
+		page, err := s.repo.List(ctx, filter, cursor, exportPageSize, offset)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, payment := range page {
+			etb := payment.Amount
+			if payment.Currency != domain.CurrencyETB {
+				etb = payment.AmountETB
+				if etb == 0 {
+					etb = domain.ConvertToETB(payment.Amount, rates[payment.Currency])
+				}
+			}
+
+			created := etime.In(payment.CreatedAt)
+			detail.AddRow(created, domain.ToEthiopianDate(created).Numeric(), payment.Reference, string(payment.Status),
+				xlsx.Decimal(payment.Amount.String()), string(payment.Currency), xlsx.Decimal(etb.String()), xlsx.Decimal(payment.RefundedAmount.String()),
+				payment.BankCode, payment.CustomerName, payment.CustomerPhone, payment.Description, payment.PurposeCode, payment.ID.String())
+
+			if byBank[payment.BankCode] == nil {
+				byBank[payment.BankCode] = &exportTotals{}
+			}
+			byBank[payment.BankCode].add(payment, etb)
+			if byCurrency[payment.Currency] == nil {
+				byCurrency[payment.Currency] = &exportTotals{}
+			}
+			byCurrency[payment.Currency].add(payment, etb)
+		}
+
+		if len(page) < exportPageSize {
+			break
+		}
+		if filter.Sort.Keyset() {
+			last := page[len(page)-1]
+			cursor = &domain.PaymentCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		} else {
+			offset += len(page)
+		}
+	}
+
+	banks := book.AddSheet("By bank")
+	banks.SetWidths(10, 34, 11, 11, 11, 13, 18, 18)
+	banks.AddHeader("Bank", "Name", "Payments", "Successful", "Failed", "Success rate", "Paid (ETB)", "Refunded (ETB)")
+	codes := make([]string, 0, len(byBank))
+	for code := range byBank {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		t := byBank[code]
+		name := ""
+		if bank, ok := domain.LookupBank(code); ok {
+			name = bank.Name
+		}
+		banks.AddRow(code, name, t.payments, t.successful, t.failed, t.successRate(), xlsx.Decimal(t.paidETB.String()), xlsx.Decimal(t.refundedETB.String()))
+	}
+
+	currencies := book.AddSheet("By currency")
+	currencies.SetWidths(10, 11, 11, 11, 13, 18, 18, 18)
+	currencies.AddHeader("Currency", "Payments", "Successful", "Failed", "Success rate", "Paid", "Refunded", "Paid (ETB)")
+	codes = codes[:0]
+	for currency := range byCurrency {
+		codes = append(codes, string(currency))
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		t := byCurrency[domain.Currency(code)]
+		currencies.AddRow(code, t.payments, t.successful, t.failed, t.successRate(), xlsx.Decimal(t.paid.String()), xlsx.Decimal(t.refunded.String()), xlsx.Decimal(t.paidETB.String()))
+	}
+
+	content, err := book.Bytes()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, fmt.Sprintf("payments-%s.xlsx", etime.Now().Format("20060102")), nil
+}
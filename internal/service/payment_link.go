@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// PaymentLinkService issues hosted payment links. The customer opens the
+// link, picks a bank or wallet on the checkout page and a payment is
+// created for the link's amount, owned by the link's merchant.
+type PaymentLinkService interface {
+	Create(ctx context.Context, req domain.CreatePaymentLinkRequest, merchantID *uuid.UUID) (*domain.PaymentLink, error)
+	// Get returns the link with the IDs of the payments made through it
+	Get(ctx context.Context, id uuid.UUID) (*domain.PaymentLink, error)
+	Deactivate(ctx context.Context, id uuid.UUID) error
+
+	// Resolve returns the link behind a code; a link that can no longer be
+	// paid is returned with the reason as the error
+	Resolve(ctx context.Context, code string) (*domain.PaymentLink, error)
+	// Options are the banks and wallets the checkout page offers for link
+	Options(link *domain.PaymentLink) []domain.CheckoutOption
+	// Checkout creates the payment for a submitted checkout page
+	Checkout(ctx context.Context, code string, form domain.PaymentLinkCheckout) (*domain.Payment, error)
+}
+
+type PaymentLinkSettings struct {
+	BaseURL string // Public base URL, e.g. https://pay.example.et
+}
+
+type paymentLinkService struct {
+	repo      repository.PaymentLinkRepository
+	payments  PaymentService
+	providers *provider.Registry
+	settings  PaymentLinkSettings
+	logger    *logrus.Logger
+}
+
+func NewPaymentLinkService(repo repository.PaymentLinkRepository, payments PaymentService, providers *provider.Registry, settings PaymentLinkSettings, logger *logrus.Logger) PaymentLinkService {
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+
+	return &paymentLinkService{
+		repo:      repo,
+		payments:  payments,
+		providers: providers,
+		settings:  settings,
+		logger:    logger,
+	}
+}
+
+// maxLinkCodeAttempts bounds retries on the rare code collision
+const maxLinkCodeAttempts = 5
+
+func (s *paymentLinkService) Create(ctx context.Context, req domain.CreatePaymentLinkRequest, merchantID *uuid.UUID) (*domain.PaymentLink, error) {
+	now := time.Now().UTC()
+	if err := req.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	link := &domain.PaymentLink{
+		ID:          uuid.New(),
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Language:    req.Language,
+		OneTime:     req.OneTime,
+		ExpiresAt:   req.ExpiresAt,
+		Active:      true,
+		MerchantID:  merchantID,
+		CreatedAt:   now,
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt == maxLinkCodeAttempts {
+			return nil, errors.New("could not generate a unique payment link code")
+		}
+
+		code, err := domain.GeneratePaymentLinkCode()
+		if err != nil {
+			return nil, err
+		}
+		link.Code = code
+
+		created, err := s.repo.Create(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			break
+		}
+	}
+	link.URL = s.url(link)
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_link_id": link.ID,
+		"amount":          link.Amount,
+		"currency":        link.Currency,
+		"one_time":        link.OneTime,
+	}).Info("Payment link created")
+
+	return link, nil
+}
+
+func (s *paymentLinkService) Get(ctx context.Context, id uuid.UUID) (*domain.PaymentLink, error) {
+	link, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	link.PaymentIDs, err = s.repo.ListPayments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	link.URL = s.url(link)
+
+	return link, nil
+}
+
+func (s *paymentLinkService) Deactivate(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Deactivate(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.WithField("payment_link_id", id).Info("Payment link deactivated")
+	return nil
+}
+
+func (s *paymentLinkService) Resolve(ctx context.Context, code string) (*domain.PaymentLink, error) {
+	if len(code) != domain.PaymentLinkCodeLength {
+		return nil, domain.ErrPaymentLinkNotFound
+	}
+
+	link, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	link.URL = s.url(link)
+
+	return link, link.CheckUsable(time.Now().UTC())
+}
+
+func (s *paymentLinkService) Options(link *domain.PaymentLink) []domain.CheckoutOption {
+	var options []domain.CheckoutOption
+	for _, bank := range domain.Banks() {
+		options = append(options, domain.CheckoutOption{Value: string(bank.Code), Name: bank.Name})
+	}
+
+	// Wallets and cards pay on the provider's page, in ETB only
+	if link.Currency == domain.CurrencyETB {
+		for _, wallet := range []struct {
+			method domain.PaymentMethod
+			name   string
+		}{
+			{domain.MethodArifPay, "ArifPay"},
+			{domain.MethodSantimPay, "SantimPay"},
+			{domain.MethodCard, "Debit card (EthSwitch)"},
+		} {
+			if s.providers.Registered(string(wallet.method)) {
+				options = append(options, domain.CheckoutOption{Value: string(wallet.method), Name: wallet.name})
+			}
+		}
+	}
+
+	return options
+}
+
+func (s *paymentLinkService) Checkout(ctx context.Context, code string, form domain.PaymentLinkCheckout) (*domain.Payment, error) {
+	link, err := s.Resolve(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	req := domain.CreatePaymentRequest{
+		Amount:        link.Amount,
+		Currency:      link.Currency,
+		Description:   link.Description,
+		CustomerName:  strings.TrimSpace(form.CustomerName),
+		CustomerPhone: strings.TrimSpace(form.CustomerPhone),
+		Language:      form.Language,
+		Client:        form.Client,
+		MerchantID:    link.MerchantID,
+	}
+	if !req.Language.IsValid() {
+		req.Language = link.Language
+	}
+
+	offered := false
+	for _, option := range s.Options(link) {
+		if option.Value == form.Option {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return nil, fmt.Errorf("%w: choose a bank or wallet", domain.ErrInvalidInput)
+	}
+	if method := domain.PaymentMethod(form.Option); method.Redirects() {
+		req.PaymentMethod = method
+	} else {
+		req.BankCode = form.Option
+	}
+
+	// The use is counted first so two customers cannot both pay a one-time
+	// link, and given back if the payment is not created
+	claimed, err := s.repo.Claim(ctx, link.ID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		if link.OneTime {
+			return nil, domain.ErrPaymentLinkUsed
+		}
+		return nil, domain.ErrPaymentLinkInactive
+	}
+
+	payment, err := s.payments.CreatePayment(ctx, req)
+	if err != nil {
+		if releaseErr := s.repo.Release(ctx, link.ID); releaseErr != nil {
+			s.logger.WithError(releaseErr).WithField("payment_link_id", link.ID).Warn("Failed to release payment link use")
+		}
+		return nil, err
+	}
+
+	if err := s.repo.AddPayment(ctx, link.ID, payment.ID); err != nil {
+		s.logger.WithError(err).WithField("payment_link_id", link.ID).Warn("Failed to record payment link payment")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_link_id": link.ID,
+		"payment_id":      payment.ID,
+	}).Info("Payment created from payment link")
+
+	return payment, nil
+}
+
+func (s *paymentLinkService) url(link *domain.PaymentLink) string {
+	return s.settings.BaseURL + "/pay/" + link.Code
+}
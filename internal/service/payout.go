@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/repository"
+	"payment-gateway/pkg/webhook"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// BulkPayoutService turns an uploaded payouts CSV into a job the worker
+// works through in the background.
+type BulkPayoutService interface {
+	// Upload stores the job for the merchant whose key uploaded it, if any
+	Upload(ctx context.Context, fileName string, data []byte, callbackURL string, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error)
+	// GetJob and ListRows only find a merchant's own jobs; nil finds any
+	GetJob(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error)
+	ListRows(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) ([]*domain.Payout, error)
+	// ProcessNext runs one queued job to completion; false if there was none
+	ProcessNext(ctx context.Context) (bool, error)
+	// DeliverCompletion posts a finished job's results to its callback URL
+	DeliverCompletion(ctx context.Context, completion *domain.BulkPayoutCompletion) error
+	// SetMaxETBAmount replaces the per-row regulatory limit, on a
+	// configuration reload
+	SetMaxETBAmount(limit domain.Amount)
+}
+
+type BulkPayoutSettings struct {
+	MaxRows        int
+	MaxETBAmount   domain.Amount // Per-row regulatory limit
+	Lease          time.Duration // How long a worker owns a job before another may resume it
+	WebhookTimeout time.Duration
+}
+
+type bulkPayoutService struct {
+	repo         repository.BulkPayoutRepository
+	events       messaging.EventPublisher
+	client       *http.Client
+	settings     BulkPayoutSettings
+	maxETBAmount atomic.Int64 // settings.MaxETBAmount, until a reload replaces it
+	logger       *logrus.Logger
+}
+
+// NewBulkPayoutService queues completion callbacks through events; with nil
+// events they are posted as soon as a job finishes.
+func NewBulkPayoutService(repo repository.BulkPayoutRepository, events messaging.EventPublisher, settings BulkPayoutSettings, logger *logrus.Logger) BulkPayoutService {
+	if settings.MaxRows <= 0 {
+		settings.MaxRows = 5000
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = 10 * time.Minute
+	}
+	if settings.WebhookTimeout <= 0 {
+		settings.WebhookTimeout = 10 * time.Second
+	}
+
+	s := &bulkPayoutService{
+		repo:     repo,
+		events:   events,
+		client:   &http.Client{Timeout: settings.WebhookTimeout},
+		settings: settings,
+		logger:   logger,
+	}
+	s.maxETBAmount.Store(int64(settings.MaxETBAmount))
+	return s
+}
+
+func (s *bulkPayoutService) SetMaxETBAmount(limit domain.Amount) {
+	s.maxETBAmount.Store(int64(limit))
+}
+
+// Header aliases for the payouts CSV, lower-cased
+var payoutColumns = map[string][]string{
+	"bank_code": {"bank_code", "bank", "bank code", "wallet"},
+	"account":   {"account", "account_number", "account number", "phone"},
+	"amount":    {"amount"},
+	"currency":  {"currency"},
+	"reason":    {"reason", "description", "narration"},
+}
+
+func (s *bulkPayoutService) Upload(ctx context.Context, fileName string, data []byte, callbackURL string, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error) {
+	if callbackURL != "" {
+		u, err := url.Parse(callbackURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("%w: callback_url must be an absolute http(s) URL", domain.ErrInvalidInput)
+		}
+	}
+
+	now := time.Now().UTC()
+	job := &domain.BulkPayoutJob{
+		ID:          uuid.New(),
+		FileName:    fileName,
+		Status:      domain.BulkJobQueued,
+		MerchantID:  merchantID,
+		CallbackURL: callbackURL,
+		CreatedAt:   now,
+	}
+	if callbackURL != "" {
+		secret := make([]byte, 24)
+		if _, err := crand.Read(secret); err != nil {
+			return nil, err
+		}
+		job.CallbackSecret = "whsec_" + hex.EncodeToString(secret)
+	}
+
+	payouts, err := s.parseRows(data, job.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	job.TotalRows = len(payouts)
+
+	if err := s.repo.Create(ctx, job, payouts); err != nil {
+		return nil, err
+	}
+
+	for _, p := range payouts {
+		if p.Status == domain.PayoutRejected {
+			job.RejectedRows++
+		} else {
+			job.PendingRows++
+			job.TotalAmount += p.Amount
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"rows":     job.TotalRows,
+		"rejected": job.RejectedRows,
+	}).Info("Bulk payout file accepted")
+
+	return job, nil
+}
+
+func (s *bulkPayoutService) parseRows(data []byte, jobID uuid.UUID, now time.Time) ([]*domain.Payout, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrBulkFileInvalid, err)
+	}
+
+	columns := make(map[string]int)
+	for i, cell := range header {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		for role, aliases := range payoutColumns {
+			if _, seen := columns[role]; !seen && contains(aliases, name) {
+				columns[role] = i
+			}
+		}
+	}
+	for _, required := range []string{"bank_code", "account", "amount", "reason"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", domain.ErrBulkFileInvalid, required)
+		}
+	}
+
+	var payouts []*domain.Payout
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rowNumber, _ := reader.FieldPos(0)
+		if err == nil && isBlank(record) {
+			continue
+		}
+		if len(payouts) >= s.settings.MaxRows {
+			return nil, fmt.Errorf("%w: file has more than %d rows", domain.ErrInvalidInput, s.settings.MaxRows)
+		}
+
+		payout := &domain.Payout{
+			ID:        uuid.New(),
+			JobID:     &jobID,
+			RowNumber: rowNumber,
+			Currency:  domain.CurrencyETB,
+			Status:    domain.PayoutPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		payouts = append(payouts, payout)
+
+		if err != nil {
+			reject(payout, err)
+			continue
+		}
+
+		cell := func(role string) string {
+			i, ok := columns[role]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		payout.BankCode = cell("bank_code")
+		payout.AccountNumber = cell("account")
+		payout.Reason = cell("reason")
+		if currency := cell("currency"); currency != "" {
+			payout.Currency = domain.Currency(strings.ToUpper(currency))
+		}
+
+		amount, err := domain.ParseAmount(strings.ReplaceAll(cell("amount"), ",", ""))
+		if err != nil {
+			reject(payout, fmt.Errorf("invalid amount %q", cell("amount")))
+			continue
+		}
+		payout.Amount = amount
+
+		if err := payout.Validate(domain.Amount(s.maxETBAmount.Load())); err != nil {
+			reject(payout, err)
+		}
+	}
+
+	if len(payouts) == 0 {
+		return nil, fmt.Errorf("%w: file has no rows", domain.ErrBulkFileInvalid)
+	}
+
+	return payouts, nil
+}
+
+// reject keeps the row (truncated to fit) so the outcome report covers every line
+func reject(p *domain.Payout, err error) {
+	p.Status = domain.PayoutRejected
+	p.FailureReason = err.Error()
+	p.BankCode = truncate(p.BankCode, 20)
+	p.AccountNumber = truncate(p.AccountNumber, 50)
+	p.Reason = truncate(p.Reason, 140)
+	if !p.Currency.IsValid() {
+		p.Currency = domain.CurrencyETB
+	}
+	if p.Amount < 0 || p.Amount >= 1e15 { // Beyond DECIMAL(15,2)
+		p.Amount = 0
+	}
+}
+
+func (s *bulkPayoutService) GetJob(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.BulkPayoutJob, error) {
+	return s.repo.GetByID(ctx, id, merchantID)
+}
+
+func (s *bulkPayoutService) ListRows(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) ([]*domain.Payout, error) {
+	if _, err := s.repo.GetByID(ctx, id, merchantID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListPayouts(ctx, id)
+}
+
+func (s *bulkPayoutService) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := s.repo.ClaimNext(ctx, time.Now().UTC(), s.settings.Lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	log := s.logger.WithField("job_id", job.ID)
+	log.Info("Processing bulk payout job")
+
+	// Rows finished before a crash are not sent again
+	payouts, err := s.repo.ListPendingPayouts(ctx, job.ID)
+	if err != nil {
+		return true, err
+	}
+
+	for _, payout := range payouts {
+		if ctx.Err() != nil {
+			return true, ctx.Err() // Lease expiry hands the rest to the next run
+		}
+
+		status, reason := s.sendPayout(payout)
+		if err := s.repo.FinishPayout(ctx, payout.ID, status, reason); err != nil {
+			return true, err
+		}
+	}
+
+	if err := s.repo.Complete(ctx, job.ID, time.Now().UTC()); err != nil {
+		return true, err
+	}
+
+	// GetByID leaves the secret out; keep the one from the claim
+	secret := job.CallbackSecret
+	job, err = s.repo.GetByID(ctx, job.ID, nil)
+	if err != nil {
+		return true, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"succeeded": job.SucceededRows,
+		"failed":    job.FailedRows,
+		"rejected":  job.RejectedRows,
+	}).Info("Bulk payout job completed")
+
+	if job.CallbackURL == "" {
+		return true, nil
+	}
+
+	// Every row goes with the callback, so large jobs are queued through
+	// the claim check rather than held by this loop
+	rows, err := s.repo.ListPayouts(ctx, job.ID)
+	if err != nil {
+		return true, err
+	}
+	completion := &domain.BulkPayoutCompletion{Job: job, Payouts: rows}
+
+	webhookStatus := "queued"
+	if s.events == nil {
+		webhookStatus = s.notifyCompletion(ctx, completion, secret)
+	} else if err := s.events.PublishEvent(ctx, messaging.MessageBulkPayoutCompleted, completion); err != nil {
+		webhookStatus = "failed: not queued"
+	}
+	if err := s.repo.SetWebhookStatus(ctx, job.ID, webhookStatus); err != nil {
+		log.WithError(err).Warn("Failed to record bulk payout webhook status")
+	}
+
+	return true, nil
+}
+
+func (s *bulkPayoutService) DeliverCompletion(ctx context.Context, completion *domain.BulkPayoutCompletion) error {
+	if completion.Job == nil || completion.Job.CallbackURL == "" {
+		return nil
+	}
+
+	secret, err := s.repo.CallbackSecret(ctx, completion.Job.ID)
+	if err != nil {
+		return err
+	}
+
+	// A merchant that is down gets the outcome in the job record, not a redelivery
+	webhookStatus := s.notifyCompletion(ctx, completion, secret)
+	return s.repo.SetWebhookStatus(ctx, completion.Job.ID, webhookStatus)
+}
+
+// sendPayout simulates the bank transfer; payouts do not go through the
+// payment providers yet
+func (s *bulkPayoutService) sendPayout(payout *domain.Payout) (domain.PayoutStatus, string) {
+	time.Sleep(time.Millisecond * time.Duration(rand.Intn(200)+50))
+
+	if rand.Float64() < 0.95 {
+		return domain.PayoutSuccess, ""
+	}
+	return domain.PayoutFailed, "declined by bank"
+}
+
+// notifyCompletion posts the job summary and its rows to the merchant's
+// callback URL, signed with the job's secret, and returns a short outcome
+// for the job record
+func (s *bulkPayoutService) notifyCompletion(ctx context.Context, completion *domain.BulkPayoutCompletion, secret string) string {
+	const event = "bulk_payout.completed"
+	job := completion.Job
+	body, err := json.Marshal(map[string]interface{}{
+		"event":   event,
+		"job":     job,
+		"payouts": completion.Payouts,
+	})
+	if err != nil {
+		return "failed: " + err.Error()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return "failed: " + err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" { // Jobs uploaded before callbacks were signed have none
+		webhook.SetHeaders(req.Header, secret, job.ID.String(), event, time.Now().UTC(), body)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", job.ID).Warn("Bulk payout completion webhook failed")
+		return "failed: unreachable"
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Sprintf("failed: HTTP %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("delivered: HTTP %d", resp.StatusCode)
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// POSService records card purchases from registered POS terminals as
+// payments. The gateway answers as the acquiring host, but no issuer has
+// authorized the card: purchases within the terminal's merchant and the
+// regulatory limit get an approval code and a PENDING payment, which
+// stays PENDING until the acquirer's settlement confirms it.
+type POSService interface {
+	// Authorize returns the ISO 8583 response code and, when approved, sets
+	// txn.ApprovalCode and txn.PaymentID. A retry with the same terminal and
+	// RRN gets the original answer.
+	Authorize(ctx context.Context, txn *domain.CardTransaction) (string, error)
+	// SetMaxETBAmount replaces the regulatory limit, on a configuration
+	// reload
+	SetMaxETBAmount(limit domain.Amount)
+}
+
+type POSSettings struct {
+	Terminals    map[string]string // Terminal ID -> merchant ID it is registered to
+	MaxETBAmount domain.Amount
+}
+
+type posService struct {
+	repo         repository.POSRepository
+	settings     POSSettings
+	maxETBAmount atomic.Int64 // settings.MaxETBAmount, until a reload replaces it
+	logger       *logrus.Logger
+}
+
+func NewPOSService(repo repository.POSRepository, settings POSSettings, logger *logrus.Logger) POSService {
+	s := &posService{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+	s.maxETBAmount.Store(int64(settings.MaxETBAmount))
+	return s
+}
+
+func (s *posService) SetMaxETBAmount(limit domain.Amount) {
+	s.maxETBAmount.Store(int64(limit))
+}
+
+func (s *posService) Authorize(ctx context.Context, txn *domain.CardTransaction) (string, error) {
+	log := s.logger.WithFields(logrus.Fields{
+		"terminal_id": txn.TerminalID,
+		"merchant_id": txn.MerchantID,
+		"stan":        txn.STAN,
+		"rrn":         txn.RRN,
+	})
+
+	merchantID, ok := s.settings.Terminals[txn.TerminalID]
+	if !ok {
+		log.Warn("Card transaction from unregistered terminal")
+		return domain.POSNotPermitted, nil
+	}
+	if merchantID != txn.MerchantID {
+		log.Warn("Card transaction merchant does not match terminal registration")
+		return domain.POSInvalidMerchant, nil
+	}
+
+	if txn.Amount <= 0 {
+		return domain.POSInvalidAmount, nil
+	}
+	if limit := domain.Amount(s.maxETBAmount.Load()); txn.Currency == domain.CurrencyETB && limit > 0 && txn.Amount > limit {
+		return domain.POSExceedsLimit, nil
+	}
+
+	if txn.MCC != "" {
+		mcc, err := domain.ValidateMCC(txn.MCC)
+		if err != nil {
+			mcc = ""
+		}
+		txn.MCC = mcc
+	}
+	if domain.RequiresClassification(txn.Currency, txn.Amount) && txn.MCC == "" {
+		log.WithField("amount", txn.Amount).Warn("Card transaction needs an MCC for NBE reporting")
+		return domain.POSInvalidTransaction, nil
+	}
+
+	code, err := approvalCode()
+	if err != nil {
+		return domain.POSSystemError, err
+	}
+
+	now := time.Now().UTC()
+	payment := &domain.Payment{
+		ID:          uuid.New(),
+		Amount:      txn.Amount,
+		Currency:    txn.Currency,
+		Reference:   "POS" + txn.TerminalID + txn.RRN,
+		Status:      domain.StatusPending,
+		Description: fmt.Sprintf("Card %s at %s", txn.MaskedPAN, strings.TrimSpace(txn.AcceptorName)),
+		MCC:         txn.MCC,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if txn.MCC != "" {
+		payment.PurposeCode = "GDDS"
+	}
+
+	txn.ID = uuid.New()
+	txn.PaymentID = payment.ID
+	txn.ApprovalCode = code
+	txn.ResponseCode = domain.POSApproved
+	txn.CreatedAt = now
+
+	err = s.repo.Create(ctx, payment, txn)
+	if err == domain.ErrCardTxnDuplicate || err == domain.ErrPaymentAlreadyExists {
+		return s.repeat(ctx, txn)
+	}
+	if err != nil {
+		return domain.POSSystemError, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"payment_id":    payment.ID,
+		"amount":        payment.Amount,
+		"currency":      payment.Currency,
+		"approval_code": code,
+	}).Info("Card purchase approved, pending settlement")
+
+	return domain.POSApproved, nil
+}
+
+// repeat answers a retried request with the original result, or a
+// duplicate decline when the RRN was reused for a different purchase
+func (s *posService) repeat(ctx context.Context, txn *domain.CardTransaction) (string, error) {
+	original, err := s.repo.GetByTerminalRRN(ctx, txn.TerminalID, txn.RRN)
+	if err != nil {
+		return domain.POSSystemError, err
+	}
+
+	if original.STAN != txn.STAN || original.Amount != txn.Amount || original.Currency != txn.Currency {
+		return domain.POSDuplicate, nil
+	}
+
+	txn.ID = original.ID
+	txn.PaymentID = original.PaymentID
+	txn.ApprovalCode = original.ApprovalCode
+	txn.CreatedAt = original.CreatedAt
+	return original.ResponseCode, nil
+}
+
+// approvalCode returns a random 6-digit authorization code
+func approvalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate approval code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
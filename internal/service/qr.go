@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/qrcode"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// QRCodeService renders printable QR codes that Ethiopian bank and wallet
+// apps scan to pay a payment, or a payment link, by bank transfer
+type QRCodeService interface {
+	// ForPayment renders the code of a pending ETB payment
+	ForPayment(ctx context.Context, paymentID uuid.UUID, format string, scale int) ([]byte, error)
+	// ForPaymentLink renders the code of a payment link that can still be
+	// paid; reusable links get a static code
+	ForPaymentLink(ctx context.Context, linkID uuid.UUID, format string, scale int) ([]byte, error)
+}
+
+type qrCodeService struct {
+	paymentRepo repository.PaymentRepository
+	linkRepo    repository.PaymentLinkRepository
+	merchant    domain.QRMerchant
+	logger      *logrus.Logger
+}
+
+func NewQRCodeService(paymentRepo repository.PaymentRepository, linkRepo repository.PaymentLinkRepository, merchant domain.QRMerchant, logger *logrus.Logger) QRCodeService {
+	return &qrCodeService{
+		paymentRepo: paymentRepo,
+		linkRepo:    linkRepo,
+		merchant:    merchant,
+		logger:      logger,
+	}
+}
+
+func (s *qrCodeService) ForPayment(ctx context.Context, paymentID uuid.UUID, format string, scale int) ([]byte, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusPending {
+		return nil, domain.ErrQRNotAvailable
+	}
+
+	payload, err := domain.EMVQRPayload(s.merchant, domain.QRPayment{
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Reference: payment.Reference,
+		MCC:       payment.MCC,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renderQR(payload, format, scale)
+}
+
+func (s *qrCodeService) ForPaymentLink(ctx context.Context, linkID uuid.UUID, format string, scale int) ([]byte, error) {
+	link, err := s.linkRepo.GetByID(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if err := link.CheckUsable(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	payload, err := domain.EMVQRPayload(s.merchant, domain.QRPayment{
+		Amount:    link.Amount,
+		Currency:  link.Currency,
+		Reference: link.Code,
+		Reusable:  !link.OneTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renderQR(payload, format, scale)
+}
+
+func renderQR(payload, format string, scale int) ([]byte, error) {
+	code, err := qrcode.Encode([]byte(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case domain.QRFormatPNG:
+		return code.PNG(scale)
+	case domain.QRFormatSVG:
+		return code.SVG(scale), nil
+	default:
+		return nil, fmt.Errorf("%w: format must be png or svg", domain.ErrInvalidInput)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/pdf"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReceiptService issues signed public receipt URLs that can be shared with
+// the payer. A URL is only valid while its nonce is the payment's current
+// one and the link is not revoked.
+type ReceiptService interface {
+	CreateLink(ctx context.Context, paymentID uuid.UUID) (*domain.ReceiptLink, error)
+	RevokeLink(ctx context.Context, paymentID uuid.UUID) error
+	// Resolve verifies a receipt token and returns its payment
+	Resolve(ctx context.Context, token string) (*domain.Payment, error)
+	// PDF renders a bilingual Amharic/English receipt for a successful
+	// payment
+	PDF(ctx context.Context, paymentID uuid.UUID) ([]byte, error)
+}
+
+type ReceiptSettings struct {
+	BaseURL       string // Public base URL, e.g. https://pay.example.et
+	SigningSecret string
+	PDFFont       *pdf.Font // Covers Ge'ez script; PDF receipts are disabled while nil
+}
+
+type receiptService struct {
+	repo         repository.ReceiptLinkRepository
+	paymentRepo  repository.PaymentRepository
+	merchantRepo repository.MerchantRepository
+	settings     ReceiptSettings
+	logger       *logrus.Logger
+}
+
+func NewReceiptService(repo repository.ReceiptLinkRepository, paymentRepo repository.PaymentRepository, merchantRepo repository.MerchantRepository, settings ReceiptSettings, logger *logrus.Logger) ReceiptService {
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+
+	return &receiptService{
+		repo:         repo,
+		paymentRepo:  paymentRepo,
+		merchantRepo: merchantRepo,
+		settings:     settings,
+		logger:       logger,
+	}
+}
+
+func (s *receiptService) CreateLink(ctx context.Context, paymentID uuid.UUID) (*domain.ReceiptLink, error) {
+	if s.settings.SigningSecret == "" {
+		return nil, domain.ErrReceiptLinksDisabled
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusSuccess {
+		return nil, domain.ErrReceiptNotAvailable
+	}
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	link := &domain.ReceiptLink{
+		PaymentID: paymentID,
+		Nonce:     hex.EncodeToString(nonce),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Upsert(ctx, link); err != nil {
+		return nil, err
+	}
+
+	link.URL = s.settings.BaseURL + "/receipts/" + s.sign(paymentID, link.Nonce)
+
+	s.logger.WithField("payment_id", paymentID).Info("Receipt link issued")
+
+	return link, nil
+}
+
+func (s *receiptService) RevokeLink(ctx context.Context, paymentID uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, paymentID); err != nil {
+		return err
+	}
+
+	s.logger.WithField("payment_id", paymentID).Info("Receipt link revoked")
+	return nil
+}
+
+func (s *receiptService) Resolve(ctx context.Context, token string) (*domain.Payment, error) {
+	if s.settings.SigningSecret == "" {
+		return nil, domain.ErrReceiptLinksDisabled
+	}
+
+	paymentID, nonce, ok := s.verify(token)
+	if !ok {
+		return nil, domain.ErrReceiptLinkNotFound
+	}
+
+	link, err := s.repo.GetByPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if link.IsRevoked() || !hmac.Equal([]byte(link.Nonce), []byte(nonce)) {
+		return nil, domain.ErrReceiptLinkNotFound
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusSuccess {
+		return nil, domain.ErrReceiptNotAvailable
+	}
+
+	return payment, nil
+}
+
+// Tokens are base64url(payment ID bytes + nonce) "." base64url(HMAC-SHA256)
+func (s *receiptService) sign(paymentID uuid.UUID, nonce string) string {
+	payload := append(paymentID[:], []byte(nonce)...)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+func (s *receiptService) verify(token string) (uuid.UUID, string, bool) {
+	encodedPayload, encodedMAC, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) <= 16 {
+		return uuid.Nil, "", false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return uuid.Nil, "", false
+	}
+
+	paymentID, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+
+	return paymentID, string(payload[16:]), true
+}
+
+func (s *receiptService) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, []byte(s.settings.SigningSecret))
+	h.Write([]byte("receipt:"))
+	h.Write(payload)
+	return h.Sum(nil)
+}
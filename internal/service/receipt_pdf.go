@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/pdf"
+
+	"github.com/google/uuid"
+)
+
+// Receipt PDFs are bilingual: every label is printed in Amharic and English
+// so one file serves the payer and the merchant's bookkeeping alike
+var receiptPDFLabels = struct {
+	Brand, Title, Paid, Reference, Payer, Bank, Description, EthiopianDate, Date, PaymentID, Footer [2]string
+}{
+	Brand:         [2]string{"የኢትዮጵያ ክፍያ መግቢያ", "Ethiopian Payment Gateway"},
+	Title:         [2]string{"የክፍያ ደረሰኝ", "Payment Receipt"},
+	Paid:          [2]string{"ተከፍሏል", "Paid"},
+	Reference:     [2]string{"ማጣቀሻ", "Reference"},
+	Payer:         [2]string{"ከፋይ", "Payer"},
+	Bank:          [2]string{"ባንክ", "Bank"},
+	Description:   [2]string{"መግለጫ", "Description"},
+	EthiopianDate: [2]string{"ቀን (ኢትዮጵያ)", "Date (Ethiopian)"},
+	Date:          [2]string{"ቀን (ግሪጎሪያን)", "Date (Gregorian)"},
+	PaymentID:     [2]string{"የክፍያ መለያ", "Payment ID"},
+	Footer:        [2]string{"ይህ ደረሰኝ በኢትዮጵያ ክፍያ መግቢያ የተሰጠ ነው።", "This receipt was issued by the Ethiopian Payment Gateway."},
+}
+
+var (
+	receiptBrandColor = [3]uint8{0x14, 0x7d, 0x64}
+	receiptInkColor   = [3]uint8{0x1f, 0x29, 0x33}
+	receiptMutedColor = [3]uint8{0x61, 0x6e, 0x7c}
+	receiptRuleColor  = [3]uint8{0xe4, 0xe7, 0xeb}
+	receiptWhite      = [3]uint8{0xff, 0xff, 0xff}
+)
+
+func (s *receiptService) PDF(ctx context.Context, paymentID uuid.UUID) ([]byte, error) {
+	if s.settings.PDFFont == nil {
+		return nil, domain.ErrReceiptPDFDisabled
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusSuccess {
+		return nil, domain.ErrReceiptNotAvailable
+	}
+
+	// The merchant's trade name heads the receipt; a missing merchant is not
+	// worth failing the download over
+	brand := ""
+	if payment.MerchantID != nil {
+		merchant, err := s.merchantRepo.GetByID(ctx, *payment.MerchantID)
+		if err != nil {
+			s.logger.WithError(err).WithField("payment_id", paymentID).Warn("Failed to get merchant for receipt")
+		} else if brand = merchant.TradeName; brand == "" {
+			brand = merchant.LegalName
+		}
+	}
+
+	return renderReceiptPDF(payment, brand, s.settings.PDFFont)
+}
+
+func renderReceiptPDF(payment *domain.Payment, brand string, font *pdf.Font) ([]byte, error) {
+	const (
+		margin = 56.0
+		labelX = margin
+		valueX = 230.0
+	)
+	l := receiptPDFLabels
+
+	doc := pdf.New(pdf.A4Width, pdf.A4Height, font)
+	page := doc.AddPage()
+	width := pdf.A4Width - 2*margin
+	valueWidth := pdf.A4Width - margin - valueX
+
+	// Header band
+	page.Rect(0, 0, pdf.A4Width, 110, receiptBrandColor)
+	if brand == "" {
+		page.Text(margin, 48, l.Brand[0], pdf.Style{Size: 20, Color: receiptWhite})
+		page.Text(margin, 70, l.Brand[1], pdf.Style{Size: 12, Color: receiptWhite})
+	} else {
+		page.Text(margin, 50, brand, pdf.Style{Size: 20, Bold: true, Color: receiptWhite})
+	}
+	page.Text(margin, 94, l.Title[0]+"  ·  "+l.Title[1], pdf.Style{Size: 12, Color: receiptWhite})
+
+	// Amount and status
+	y := 165.0
+	amount := fmt.Sprintf("%s %.2f %s", payment.Currency.GetSymbol(), payment.Amount, payment.Currency)
+	page.Text(margin, y, amount, pdf.Style{Size: 26, Bold: true, Color: receiptInkColor})
+	paid := l.Paid[0] + " / " + l.Paid[1]
+	paidStyle := pdf.Style{Size: 13, Bold: true, Color: receiptBrandColor}
+	page.Text(margin+width-doc.TextWidth(paid, paidStyle), y, paid, paidStyle)
+	y += 22
+
+	paidAt := etime.In(payment.UpdatedAt)
+	ethiopian := domain.ToEthiopianDate(paidAt)
+
+	rows := []struct {
+		label  [2]string
+		values []string
+	}{
+		{l.Reference, []string{payment.Reference}},
+		{l.Payer, []string{payment.CustomerName}},
+		{l.Bank, receiptBankNames(payment.BankCode)},
+		{l.Description, []string{payment.Description}},
+		{l.EthiopianDate, []string{ethiopian.FormatGeez(), ethiopian.Format(domain.LanguageEnglish), ethiopian.Numeric()}},
+		{l.Date, []string{paidAt.Format("2006-01-02 15:04 MST")}},
+		{l.PaymentID, []string{payment.ID.String()}},
+	}
+
+	labelStyle := pdf.Style{Size: 10, Color: receiptMutedColor}
+	valueStyle := pdf.Style{Size: 11, Color: receiptInkColor}
+	for _, row := range rows {
+		var lines []string
+		for _, value := range row.values {
+			if value != "" {
+				lines = append(lines, wrapPDFText(doc, value, valueStyle, valueWidth)...)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		page.Line(margin, y, margin+width, y, 0.75, receiptRuleColor)
+		y += 20
+		page.Text(labelX, y, row.label[0], labelStyle)
+		page.Text(labelX, y+14, row.label[1], labelStyle)
+		for i, line := range lines {
+			page.Text(valueX, y+float64(i)*16, line, valueStyle)
+		}
+		y += max(14, float64(len(lines)-1)*16) + 12
+	}
+	page.Line(margin, y, margin+width, y, 0.75, receiptRuleColor)
+
+	footerStyle := pdf.Style{Size: 9, Color: receiptMutedColor}
+	page.Text(margin, pdf.A4Height-margin-14, l.Footer[0], footerStyle)
+	page.Text(margin, pdf.A4Height-margin, l.Footer[1], footerStyle)
+
+	return doc.Bytes()
+}
+
+// receiptBankNames is the payment's bank in Amharic and English, or its
+// code for banks outside the registry
+func receiptBankNames(code string) []string {
+	if code == "" {
+		return nil
+	}
+	bank, ok := domain.LookupBank(code)
+	if !ok {
+		return []string{code}
+	}
+	return []string{bank.NameAm, bank.Name}
+}
+
+// wrapPDFText breaks text at spaces into lines no wider than width
+func wrapPDFText(doc *pdf.Document, text string, style pdf.Style, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && doc.TextWidth(candidate, style) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReconciliationService matches the credit lines of uploaded bank statements
+// to payments, by reference and otherwise by amount and date, and reports
+// what did not match
+type ReconciliationService interface {
+	// ReconcilePending reconciles files not yet reconciled and returns how
+	// many it did
+	ReconcilePending(ctx context.Context) (int, error)
+	// Reconcile matches a file's lines again, e.g. after payment statuses
+	// were corrected
+	Reconcile(ctx context.Context, fileID uuid.UUID) (*domain.ReconciliationReport, error)
+	Report(ctx context.Context, fileID uuid.UUID) (*domain.ReconciliationReport, error)
+}
+
+type ReconciliationSettings struct {
+	// Days a bank may take to book a payment; also bounds matching by
+	// amount when a line has no reference
+	BookingDays int
+	BatchSize   int
+}
+
+type reconciliationService struct {
+	repo     repository.SettlementRepository
+	settings ReconciliationSettings
+	logger   *logrus.Logger
+}
+
+func NewReconciliationService(repo repository.SettlementRepository, settings ReconciliationSettings, logger *logrus.Logger) ReconciliationService {
+	if settings.BookingDays <= 0 {
+		settings.BookingDays = 3
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 10
+	}
+
+	return &reconciliationService{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (s *reconciliationService) ReconcilePending(ctx context.Context) (int, error) {
+	files, err := s.repo.ListUnreconciled(ctx, s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	reconciled := 0
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return reconciled, ctx.Err()
+		}
+		if err := s.reconcile(ctx, file); err != nil {
+			s.logger.WithError(err).WithField("file_id", file.ID).Error("Failed to reconcile settlement file")
+			continue
+		}
+		reconciled++
+	}
+
+	return reconciled, nil
+}
+
+func (s *reconciliationService) Reconcile(ctx context.Context, fileID uuid.UUID) (*domain.ReconciliationReport, error) {
+	file, err := s.repo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reconcile(ctx, file); err != nil {
+		return nil, err
+	}
+
+	return s.Report(ctx, fileID)
+}
+
+func (s *reconciliationService) reconcile(ctx context.Context, file *domain.SettlementFile) error {
+	lines, err := s.repo.ListLines(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+
+	var references []string
+	for _, line := range lines {
+		if line.Direction == "CREDIT" {
+			references = append(references, lineReferences(line)...)
+		}
+	}
+
+	var candidates []*domain.ReconciliationPayment
+	if from, to, ok := statementPeriod(lines); ok {
+		from = from.AddDate(0, 0, -s.settings.BookingDays)
+		candidates, err = s.repo.ReconciliationCandidates(ctx, file.ID, file.BankCode, references, from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	matchStatementLines(file, lines, candidates, s.settings.BookingDays)
+
+	now := time.Now().UTC()
+	if err := s.repo.SaveReconciliation(ctx, file.ID, lines, now); err != nil {
+		return err
+	}
+
+	summary := summarizeReconciliation(lines)
+	s.logger.WithFields(logrus.Fields{
+		"file_id":         file.ID,
+		"bank_code":       file.BankCode,
+		"matched":         summary.Matched,
+		"amount_mismatch": summary.AmountMismatch,
+		"status_mismatch": summary.StatusMismatch,
+		"duplicate":       summary.Duplicate,
+		"unmatched":       summary.Unmatched,
+	}).Info("Settlement file reconciled")
+
+	return nil
+}
+
+func (s *reconciliationService) Report(ctx context.Context, fileID uuid.UUID) (*domain.ReconciliationReport, error) {
+	file, err := s.repo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.ReconciledAt == nil {
+		return nil, domain.ErrSettlementNotReconciled
+	}
+
+	lines, err := s.repo.ListLines(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ReconciliationReport{
+		File:       file,
+		Summary:    summarizeReconciliation(lines),
+		Mismatches: []*domain.SettlementLine{},
+		Missing:    []*domain.ReconciliationPayment{},
+	}
+	for _, line := range lines {
+		if line.MatchStatus != domain.MatchMatched && line.MatchStatus != domain.MatchIgnored {
+			report.Mismatches = append(report.Mismatches, line)
+		}
+	}
+
+	// Payments the bank should have booked within the statement's days
+	from, to, ok := statementPeriod(lines)
+	if !ok {
+		return report, nil
+	}
+	report.From = from.Format(etime.DateLayout)
+	report.To = to.AddDate(0, 0, -1).Format(etime.DateLayout)
+
+	missing, err := s.repo.UnmatchedPayments(ctx, file.BankCode, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range missing {
+		currency := statementCurrency(file, nil)
+		if !settlesIn(payment, currency) {
+			continue
+		}
+		report.Missing = append(report.Missing, payment)
+		report.Summary.MissingPayments++
+		report.Summary.MissingAmount += paymentAmountIn(payment, currency)
+	}
+
+	return report, nil
+}
+
+// statementPeriod is midnight in Ethiopia on the first value date through
+// the midnight after the last
+func statementPeriod(lines []*domain.SettlementLine) (time.Time, time.Time, bool) {
+	var first, last string
+	for _, line := range lines {
+		date := line.ValueDate.Format(etime.DateLayout)
+		if first == "" || date < first {
+			first = date
+		}
+		if date > last {
+			last = date
+		}
+	}
+	if first == "" {
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, _ := etime.ParseDate(first)
+	to, _ := etime.ParseDate(last)
+	return from, to.AddDate(0, 0, 1), true
+}
+
+// matchStatementLines sets the outcome of every line. Lines are matched by
+// reference first, so a payment named on one line is not taken by another
+// line matching only on amount.
+func matchStatementLines(file *domain.SettlementFile, lines []*domain.SettlementLine, candidates []*domain.ReconciliationPayment, bookingDays int) {
+	byReference := make(map[string]*domain.ReconciliationPayment, len(candidates))
+	for _, payment := range candidates {
+		byReference[strings.ToUpper(payment.Reference)] = payment
+	}
+	claimed := make(map[uuid.UUID]int) // Payment ID to the line number that matched it
+
+	var unreferenced []*domain.SettlementLine
+	for _, line := range lines {
+		line.PaymentID, line.MatchStatus, line.MatchNote = nil, "", ""
+		if line.Direction != "CREDIT" {
+			line.MatchStatus = domain.MatchIgnored
+			continue
+		}
+
+		var payment *domain.ReconciliationPayment
+		for _, ref := range lineReferences(line) {
+			if payment = byReference[strings.ToUpper(ref)]; payment != nil {
+				break
+			}
+		}
+		if payment == nil {
+			unreferenced = append(unreferenced, line)
+			continue
+		}
+
+		line.PaymentID = &payment.ID
+		currency := statementCurrency(file, line)
+		matchedOn, taken := claimed[payment.ID]
+		switch {
+		case payment.MatchedFileID != nil:
+			line.MatchStatus = domain.MatchDuplicate
+			line.MatchNote = fmt.Sprintf("Payment %s was already matched on statement %s", payment.Reference, payment.MatchedFileID)
+		case taken:
+			line.MatchStatus = domain.MatchDuplicate
+			line.MatchNote = fmt.Sprintf("Payment %s was already matched on line %d", payment.Reference, matchedOn)
+		case paymentAmountIn(payment, currency) != line.Amount:
+			line.MatchStatus = domain.MatchAmountMismatch
+			line.MatchNote = fmt.Sprintf("Statement shows %s %s, payment %s is %s", line.Amount, currency, payment.Reference, domain.NewMoney(payment.Amount, payment.Currency))
+		case payment.Status != domain.StatusSuccess:
+			line.MatchStatus = domain.MatchStatusMismatch
+			line.MatchNote = fmt.Sprintf("Money received for payment %s, which is %s", payment.Reference, payment.Status)
+		default:
+			line.MatchStatus = domain.MatchMatched
+			claimed[payment.ID] = line.LineNumber
+		}
+	}
+
+	// Lines without a known reference match a successful payment at the
+	// bank only when exactly one of that amount was made in the days before
+	for _, line := range unreferenced {
+		currency := statementCurrency(file, line)
+		valueDate := line.ValueDate.Format(etime.DateLayout)
+		earliest := line.ValueDate.AddDate(0, 0, -bookingDays).Format(etime.DateLayout)
+
+		var found []*domain.ReconciliationPayment
+		for _, payment := range candidates {
+			paid := etime.Date(payment.UpdatedAt)
+			_, taken := claimed[payment.ID]
+			if payment.Status != domain.StatusSuccess || payment.BankCode != file.BankCode ||
+				payment.MatchedFileID != nil || taken || !settlesIn(payment, currency) ||
+				paid < earliest || paid > valueDate || paymentAmountIn(payment, currency) != line.Amount {
+				continue
+			}
+			found = append(found, payment)
+		}
+
+		line.MatchStatus = domain.MatchUnmatched
+		switch len(found) {
+		case 0:
+			line.MatchNote = "No payment found for the reference or amount"
+		case 1:
+			line.PaymentID = &found[0].ID
+			line.MatchStatus = domain.MatchMatched
+			line.MatchNote = "Matched by amount and date; the line names no payment reference"
+			claimed[found[0].ID] = line.LineNumber
+		default:
+			line.MatchNote = fmt.Sprintf("%d payments of %s %s around the value date; match by reference", len(found), line.Amount, currency)
+		}
+	}
+}
+
+// lineReferences are the words of a line that may be a payment reference:
+// the reference field, then anything in the narrative shaped like one
+func lineReferences(line *domain.SettlementLine) []string {
+	var refs []string
+	if ref := strings.TrimSpace(line.Reference); ref != "" {
+		refs = append(refs, ref, strings.ToUpper(ref))
+	}
+	for _, word := range strings.FieldsFunc(line.Description, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == '/' || r == ':'
+	}) {
+		if len(word) >= 8 && strings.Contains(word, "-") {
+			refs = append(refs, word, strings.ToUpper(word))
+		}
+	}
+	return refs
+}
+
+// statementCurrency is the line's currency, else the statement's, else ETB
+func statementCurrency(file *domain.SettlementFile, line *domain.SettlementLine) string {
+	if line != nil && line.Currency != "" {
+		return strings.ToUpper(line.Currency)
+	}
+	if file.Currency != "" {
+		return strings.ToUpper(file.Currency)
+	}
+	return string(domain.CurrencyETB)
+}
+
+// settlesIn reports whether the payment reaches an account in currency
+func settlesIn(payment *domain.ReconciliationPayment, currency string) bool {
+	return string(payment.Currency) == currency || (currency == string(domain.CurrencyETB) && payment.AmountETB > 0)
+}
+
+// paymentAmountIn is what the bank should have booked for the payment: its
+// amount, or the ETB amount of a foreign currency payment on an ETB account
+func paymentAmountIn(payment *domain.ReconciliationPayment, currency string) domain.Amount {
+	if string(payment.Currency) != currency && currency == string(domain.CurrencyETB) && payment.AmountETB > 0 {
+		return payment.AmountETB
+	}
+	return payment.Amount
+}
+
+func summarizeReconciliation(lines []*domain.SettlementLine) domain.ReconciliationSummary {
+	summary := domain.ReconciliationSummary{Lines: len(lines)}
+	for _, line := range lines {
+		if line.Direction == "CREDIT" {
+			summary.Credits += line.Amount
+		}
+		switch line.MatchStatus {
+		case domain.MatchMatched:
+			summary.Matched++
+			summary.MatchedAmount += line.Amount
+		case domain.MatchAmountMismatch:
+			summary.AmountMismatch++
+		case domain.MatchStatusMismatch:
+			summary.StatusMismatch++
+		case domain.MatchDuplicate:
+			summary.Duplicate++
+		case domain.MatchUnmatched:
+			summary.Unmatched++
+		case domain.MatchIgnored:
+			summary.Ignored++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/provider"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// sagaRefund is the saga kind that carries a refund to the provider
+const sagaRefund = "refund"
+
+// RefundService returns successful payments' money. Refunds are queued
+// like payments; the worker runs each one as a saga so a provider outage
+// is retried with backoff instead of failing the refund.
+type RefundService interface {
+	CreateRefund(ctx context.Context, paymentID uuid.UUID, req domain.CreateRefundRequest) (*domain.Refund, error)
+	GetRefund(ctx context.Context, id uuid.UUID) (*domain.Refund, error)
+	ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]*domain.Refund, error)
+	// ProcessRefund is called by the worker for each queued refund
+	ProcessRefund(ctx context.Context, id uuid.UUID) error
+}
+
+type refundService struct {
+	repo      repository.RefundRepository
+	payments  repository.PaymentRepository
+	tx        repository.Transactor
+	publisher messaging.PaymentPublisher
+	providers *provider.Registry
+	notifier  NotificationService
+	sagas     SagaRunner
+	logger    *logrus.Logger
+}
+
+func NewRefundService(repo repository.RefundRepository, payments repository.PaymentRepository, tx repository.Transactor, publisher messaging.PaymentPublisher, providers *provider.Registry, notifier NotificationService, sagas SagaRunner, logger *logrus.Logger) RefundService {
+	s := &refundService{
+		repo:      repo,
+		payments:  payments,
+		tx:        tx,
+		publisher: publisher,
+		providers: providers,
+		notifier:  notifier,
+		sagas:     sagas,
+		logger:    logger,
+	}
+
+	sagas.Register(SagaDefinition{
+		Kind: sagaRefund,
+		Steps: []SagaStep{
+			{Name: "send_to_provider", Do: s.sendToProvider, Compensate: s.abandon},
+			{Name: "record_outcome", Do: s.recordOutcome},
+			{Name: "notify_customer", Do: s.notifyCustomer},
+		},
+	})
+
+	return s
+}
+
+func (s *refundService) CreateRefund(ctx context.Context, paymentID uuid.UUID, req domain.CreateRefundRequest) (*domain.Refund, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	payment, err := s.payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusSuccess {
+		return nil, domain.ErrPaymentNotRefundable
+	}
+
+	// Refuse up front rather than queue a refund that can only fail
+	p, err := s.providers.Lookup(payment.ProviderCode())
+	if err != nil {
+		return nil, domain.ErrRefundUnsupported
+	}
+	if _, ok := p.(provider.Refunder); !ok {
+		return nil, domain.ErrRefundUnsupported
+	}
+
+	// No amount refunds the rest; the repository rejects an amount over it
+	// even when another refund is created at the same time
+	amount := req.Amount
+	if amount == 0 {
+		amount = payment.Amount - payment.RefundedAmount
+	}
+	if amount <= 0 || amount > payment.Amount {
+		return nil, domain.ErrRefundExceedsPayment
+	}
+
+	now := time.Now().UTC()
+	refund := &domain.Refund{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Currency:  payment.Currency,
+		Reason:    req.Reason,
+		Status:    domain.RefundPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, refund); err != nil {
+			return err
+		}
+		if err := s.publisher.PublishRefundRequested(ctx, payment.ID, refund.ID); err != nil {
+			s.logger.WithError(err).WithField("refund_id", refund.ID).Error("Failed to publish refund message")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"refund_id":  refund.ID,
+		"amount":     refund.Amount,
+		"currency":   refund.Currency,
+	}).Info("Refund requested")
+
+	return refund, nil
+}
+
+func (s *refundService) GetRefund(ctx context.Context, id uuid.UUID) (*domain.Refund, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *refundService) ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]*domain.Refund, error) {
+	if _, err := s.payments.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByPayment(ctx, paymentID)
+}
+
+// ProcessRefund claims a queued refund and starts its saga. A redelivered
+// message finds the refund already claimed and does nothing; the saga job
+// resumes a saga interrupted by a crash.
+func (s *refundService) ProcessRefund(ctx context.Context, id uuid.UUID) error {
+	claimed, err := s.repo.TransitionStatus(ctx, id, domain.RefundPending, domain.RefundProcessing, "")
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		s.logger.WithField("refund_id", id).Info("Refund already processed, skipping")
+		return nil
+	}
+
+	run, err := s.sagas.Start(ctx, sagaRefund, map[string]string{"refund_id": id.String()})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"refund_id": id,
+		"saga_id":   run.ID,
+		"status":    run.Status,
+	}).Info("Refund saga started")
+
+	return nil
+}
+
+// load reads the saga's refund and its payment
+func (s *refundService) load(ctx context.Context, data map[string]string) (*domain.Refund, *domain.Payment, error) {
+	id, err := uuid.Parse(data["refund_id"])
+	if err != nil {
+		return nil, nil, err
+	}
+	refund, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	payment, err := s.payments.GetByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return refund, payment, nil
+}
+
+// sendToProvider asks the provider to return the money. The answer is
+// kept in the saga data, so a retried step never sends the refund twice
+// once an answer was saved.
+func (s *refundService) sendToProvider(ctx context.Context, data map[string]string) error {
+	if data["outcome"] != "" {
+		return nil
+	}
+
+	refund, payment, err := s.load(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	p, err := s.providers.Lookup(payment.ProviderCode())
+	if err != nil {
+		return err
+	}
+	refunder, ok := p.(provider.Refunder)
+	if !ok {
+		data["outcome"], data["reason"] = string(domain.RefundFailed), domain.ErrRefundUnsupported.Error()
+		return nil
+	}
+
+	result, err := refunder.Refund(ctx, payment, refund)
+	if err != nil {
+		return err
+	}
+
+	if result.Reference != "" {
+		if err := s.repo.SetProviderReference(ctx, refund.ID, result.Reference); err != nil {
+			s.logger.WithError(err).WithField("refund_id", refund.ID).Warn("Failed to save refund provider reference")
+		}
+	}
+
+	switch result.Status {
+	case provider.Captured:
+		data["outcome"] = string(domain.RefundSucceeded)
+	case provider.Declined:
+		data["outcome"], data["reason"] = string(domain.RefundFailed), result.Reason
+	default:
+		// The provider finishes it later; there is no refund status query
+		// yet, so an operator confirms it
+		data["outcome"] = string(domain.RefundProcessing)
+	}
+	return nil
+}
+
+// abandon fails the refund when the provider could not be reached. Money
+// already sent cannot be taken back, so that case is left to an operator.
+func (s *refundService) abandon(ctx context.Context, data map[string]string) error {
+	if data["outcome"] == string(domain.RefundSucceeded) {
+		return errors.New("refund was sent to the customer and cannot be undone")
+	}
+
+	id, err := uuid.Parse(data["refund_id"])
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.TransitionStatus(ctx, id, domain.RefundProcessing, domain.RefundFailed, "provider could not be reached; confirm with the provider before refunding again")
+	return err
+}
+
+func (s *refundService) recordOutcome(ctx context.Context, data map[string]string) error {
+	id, err := uuid.Parse(data["refund_id"])
+	if err != nil {
+		return err
+	}
+
+	outcome := domain.RefundStatus(data["outcome"])
+	if !outcome.IsTerminal() {
+		s.logger.WithField("refund_id", id).Warn("Refund pending at the provider, needs an operator to confirm")
+		return nil
+	}
+
+	if _, err := s.repo.TransitionStatus(ctx, id, domain.RefundProcessing, outcome, data["reason"]); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"refund_id": id,
+		"status":    outcome,
+	}).Info("Refund processed")
+	return nil
+}
+
+// notifyCustomer never fails the saga; the refund has already happened
+func (s *refundService) notifyCustomer(ctx context.Context, data map[string]string) error {
+	if data["outcome"] != string(domain.RefundSucceeded) {
+		return nil
+	}
+
+	refund, payment, err := s.load(ctx, data)
+	if err == nil {
+		err = s.notifier.NotifyRefund(ctx, payment, refund)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("refund_id", data["refund_id"]).Warn("Failed to send refund notification")
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/etime"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RegulatoryService produces the periodic transaction reports the National
+// Bank of Ethiopia requires of payment operators: volumes per bank,
+// currency and business day as CSV
+type RegulatoryService interface {
+	Generate(ctx context.Context, req domain.GenerateRegulatoryReportRequest, operator string) (*domain.RegulatoryReport, error)
+	// GenerateDaily reports the business day containing day, unless it was
+	// already reported; it returns nil then
+	GenerateDaily(ctx context.Context, day time.Time) (*domain.RegulatoryReport, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.RegulatoryReport, error)
+	List(ctx context.Context, page, limit int) ([]*domain.RegulatoryReport, error)
+	// Download returns the report's CSV as generated and its file name
+	Download(ctx context.Context, id uuid.UUID) ([]byte, string, error)
+}
+
+type RegulatorySettings struct {
+	InstitutionCode string // Licence number NBE issued the operator
+}
+
+type regulatoryService struct {
+	repo     repository.RegulatoryRepository
+	fx       FXService
+	settings RegulatorySettings
+	logger   *logrus.Logger
+}
+
+func NewRegulatoryService(repo repository.RegulatoryRepository, fx FXService, settings RegulatorySettings, logger *logrus.Logger) RegulatoryService {
+	return &regulatoryService{
+		repo:     repo,
+		fx:       fx,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// Column layout of the NBE payment operator transaction return
+var regulatoryCSVHeader = []string{
+	"INSTITUTION_CODE", "REPORTING_DATE", "TRANSACTION_DATE", "BANK", "CURRENCY",
+	"SUCCESS_COUNT", "SUCCESS_AMOUNT", "SUCCESS_AMOUNT_ETB", "FAILED_COUNT", "REFUND_COUNT", "REFUND_AMOUNT",
+}
+
+func (s *regulatoryService) Generate(ctx context.Context, req domain.GenerateRegulatoryReportRequest, operator string) (*domain.RegulatoryReport, error) {
+	_, today := businessDay(time.Now())
+	if err := req.Validate(today); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	return s.generate(ctx, req.From, req.To, operator)
+}
+
+func (s *regulatoryService) GenerateDaily(ctx context.Context, day time.Time) (*domain.RegulatoryReport, error) {
+	_, date := businessDay(day)
+
+	exists, err := s.repo.ExistsForPeriod(ctx, date, date)
+	if err != nil || exists {
+		return nil, err
+	}
+
+	return s.generate(ctx, date, date, "scheduler")
+}
+
+func (s *regulatoryService) generate(ctx context.Context, from, to, generatedBy string) (*domain.RegulatoryReport, error) {
+	start, _ := etime.ParseDate(from)
+	end, _ := etime.ParseDate(to)
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.Aggregate(ctx, start, end.AddDate(0, 0, 1), rates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	content, err := writeRegulatoryCSV(s.settings.InstitutionCode, etime.Date(now), rows)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+
+	report := &domain.RegulatoryReport{
+		ID:              uuid.New(),
+		From:            from,
+		To:              to,
+		InstitutionCode: s.settings.InstitutionCode,
+		RowCount:        len(rows),
+		Checksum:        hex.EncodeToString(sum[:]),
+		GeneratedBy:     generatedBy,
+		CreatedAt:       now,
+	}
+	if err := s.repo.Create(ctx, report, content); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"report_id":    report.ID,
+		"from":         from,
+		"to":           to,
+		"rows":         report.RowCount,
+		"generated_by": generatedBy,
+	}).Info("Regulatory report generated")
+
+	return report, nil
+}
+
+func (s *regulatoryService) Get(ctx context.Context, id uuid.UUID) (*domain.RegulatoryReport, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *regulatoryService) List(ctx context.Context, page, limit int) ([]*domain.RegulatoryReport, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.List(ctx, limit, (page-1)*limit)
+}
+
+func (s *regulatoryService) Download(ctx context.Context, id uuid.UUID) ([]byte, string, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := s.repo.Content(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := fmt.Sprintf("nbe-transactions-%s-%s", report.InstitutionCode, report.From)
+	if report.To != report.From {
+		name += "_" + report.To
+	}
+	return content, name + ".csv", nil
+}
+
+// writeRegulatoryCSV renders the return: one line per row, then a TOTAL
+// line. Amounts in mixed currencies are only totalled in ETB.
+func writeRegulatoryCSV(institution, reportingDate string, rows []*domain.RegulatoryRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+
+	if err := w.Write(regulatoryCSVHeader); err != nil {
+		return nil, err
+	}
+
+	var successCount, failedCount, refundCount int
+	var successETB domain.Amount
+	for _, row := range rows {
+		successCount += row.SuccessCount
+		failedCount += row.FailedCount
+		refundCount += row.RefundCount
+		successETB += row.SuccessVolumeETB
+
+		err := w.Write([]string{
+			institution,
+			reportingDate,
+			row.Date,
+			row.Bank,
+			string(row.Currency),
+			strconv.Itoa(row.SuccessCount),
+			row.SuccessVolume.String(),
+			row.SuccessVolumeETB.String(),
+			strconv.Itoa(row.FailedCount),
+			strconv.Itoa(row.RefundCount),
+			row.RefundVolume.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := w.Write([]string{
+		institution,
+		reportingDate,
+		"TOTAL",
+		"",
+		"",
+		strconv.Itoa(successCount),
+		"",
+		successETB.String(),
+		strconv.Itoa(failedCount),
+		strconv.Itoa(refundCount),
+		"",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
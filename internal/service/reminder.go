@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReminderService chases customers who have not completed a payment with
+// SMS/Telegram reminders on a configurable schedule (e.g. after 1h, 24h).
+type ReminderService interface {
+	Schedule(ctx context.Context, subjectType domain.ReminderSubject, subjectID, paymentID uuid.UUID, expiresAt *time.Time) error
+	// Complete stops reminders for a subject that has been paid
+	Complete(ctx context.Context, subjectType domain.ReminderSubject, subjectID uuid.UUID) error
+	// SendDue sends every reminder that is due and returns how many were sent
+	SendDue(ctx context.Context) (int, error)
+}
+
+type ReminderSettings struct {
+	Enabled bool
+	// Offsets from scheduling time; one reminder is sent per offset
+	Schedule []time.Duration
+}
+
+// How long a claimed reminder stays hidden from other workers; a failed
+// send is retried after this.
+const reminderLease = 10 * time.Minute
+
+const reminderBatchSize = 100
+
+type reminderService struct {
+	repo        repository.ReminderRepository
+	paymentRepo repository.PaymentRepository
+	notifier    NotificationService
+	settings    ReminderSettings
+	logger      *logrus.Logger
+}
+
+func NewReminderService(repo repository.ReminderRepository, paymentRepo repository.PaymentRepository, notifier NotificationService, settings ReminderSettings, logger *logrus.Logger) ReminderService {
+	if len(settings.Schedule) == 0 {
+		settings.Schedule = []time.Duration{time.Hour, 24 * time.Hour}
+	}
+
+	return &reminderService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		notifier:    notifier,
+		settings:    settings,
+		logger:      logger,
+	}
+}
+
+func (s *reminderService) Schedule(ctx context.Context, subjectType domain.ReminderSubject, subjectID, paymentID uuid.UUID, expiresAt *time.Time) error {
+	if !s.settings.Enabled {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	return s.repo.Create(ctx, &domain.PaymentReminder{
+		ID:          uuid.New(),
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		PaymentID:   paymentID,
+		NextAt:      now.Add(s.settings.Schedule[0]),
+		ExpiresAt:   expiresAt,
+		Status:      domain.ReminderActive,
+		CreatedAt:   now,
+	})
+}
+
+func (s *reminderService) Complete(ctx context.Context, subjectType domain.ReminderSubject, subjectID uuid.UUID) error {
+	return s.repo.FinishBySubject(ctx, subjectType, subjectID, domain.ReminderCompleted)
+}
+
+func (s *reminderService) SendDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	reminders, err := s.repo.ClaimDue(ctx, now, reminderLease, reminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		logger := s.logger.WithFields(logrus.Fields{
+			"reminder_id":  reminder.ID,
+			"subject_type": reminder.SubjectType,
+			"subject_id":   reminder.SubjectID,
+		})
+
+		if reminder.ExpiresAt != nil && now.After(*reminder.ExpiresAt) {
+			if err := s.repo.Finish(ctx, reminder.ID, domain.ReminderExpired); err != nil {
+				logger.WithError(err).Error("Failed to expire reminder")
+			}
+			continue
+		}
+
+		payment, err := s.paymentRepo.GetByID(ctx, reminder.PaymentID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to load payment for reminder")
+			continue
+		}
+
+		if !awaitingCustomer(reminder.SubjectType, payment) {
+			if err := s.repo.Finish(ctx, reminder.ID, domain.ReminderCompleted); err != nil {
+				logger.WithError(err).Error("Failed to complete reminder")
+			}
+			continue
+		}
+
+		// On failure the lease from ClaimDue delays the retry
+		if err := s.notifier.SendPaymentReminder(ctx, payment); err != nil {
+			logger.WithError(err).Warn("Failed to send payment reminder")
+			continue
+		}
+		sent++
+
+		step := reminder.Step + 1
+		if step >= len(s.settings.Schedule) {
+			err = s.repo.Finish(ctx, reminder.ID, domain.ReminderCompleted)
+		} else {
+			err = s.repo.Advance(ctx, reminder.ID, step, reminder.CreatedAt.Add(s.settings.Schedule[step]))
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to update reminder schedule")
+		}
+	}
+
+	return sent, nil
+}
+
+// awaitingCustomer reports whether the subject is still unpaid
+func awaitingCustomer(subjectType domain.ReminderSubject, payment *domain.Payment) bool {
+	switch subjectType {
+	case domain.ReminderSubjectPayment:
+		return payment.Status == domain.StatusAwaitingOTP
+	default:
+		return false
+	}
+}
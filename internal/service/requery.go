@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-gateway/internal/bank"
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/provider"
+	"payment-gateway/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequeryService resolves payments stuck in PROCESSING by asking the bank
+// for the real outcome instead of sending the payment again.
+type RequeryService interface {
+	ResolveStuck(ctx context.Context) (int, error)
+}
+
+type RequerySettings struct {
+	StaleAfter time.Duration
+	BatchSize  int
+}
+
+type requeryService struct {
+	repo      repository.PaymentRepository
+	attempts  repository.PaymentAttemptRepository
+	notifier  NotificationService
+	inquirers map[string]bank.StatusInquirer // by bank code
+	providers *provider.Registry
+	settings  RequerySettings
+	logger    *logrus.Logger
+}
+
+func NewRequeryService(repo repository.PaymentRepository, attempts repository.PaymentAttemptRepository, notifier NotificationService, inquirers map[string]bank.StatusInquirer, providers *provider.Registry, settings RequerySettings, logger *logrus.Logger) RequeryService {
+	if settings.StaleAfter <= 0 {
+		settings.StaleAfter = 5 * time.Minute
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 50
+	}
+
+	return &requeryService{
+		repo:      repo,
+		attempts:  attempts,
+		notifier:  notifier,
+		inquirers: inquirers,
+		providers: providers,
+		settings:  settings,
+		logger:    logger,
+	}
+}
+
+// ResolveStuck returns how many payments reached a terminal status
+func (s *requeryService) ResolveStuck(ctx context.Context) (int, error) {
+	payments, err := s.repo.ListStale(ctx, domain.StatusProcessing, time.Now().UTC().Add(-s.settings.StaleAfter), s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return resolved, ctx.Err()
+		}
+
+		log := s.logger.WithFields(logrus.Fields{
+			"payment_id": payment.ID,
+			"bank_code":  payment.BankCode,
+		})
+
+		status, reason, err := s.queryStatus(ctx, payment)
+		if err == errNoStatusInquiry {
+			// Needs an operator override; logged every run so it is not missed
+			log.Warn("Payment stuck in PROCESSING and bank has no status inquiry")
+			continue
+		}
+		if err != nil {
+			log.WithError(err).Warn("Bank status inquiry failed, will retry")
+			continue
+		}
+		if !status.IsTerminal() {
+			log.Debug("Bank still processing payment")
+			continue
+		}
+
+		updated, err := s.repo.TransitionStatus(ctx, payment.ID, domain.StatusProcessing, status)
+		if err != nil {
+			log.WithError(err).Error("Failed to update re-queried payment")
+			continue
+		}
+		if !updated {
+			continue
+		}
+		resolved++
+
+		if err := s.attempts.RecordResult(ctx, payment.ID, payment.BankCode, domain.AttemptStatus(status), reason); err != nil {
+			log.WithError(err).Warn("Failed to record payment attempt")
+		}
+
+		log.WithField("status", status).Info("Stuck payment resolved by bank status inquiry")
+
+		payment.Status = status
+		if err := s.notifier.NotifyPaymentStatus(ctx, payment); err != nil {
+			log.WithError(err).Warn("Failed to send payment notification")
+		}
+	}
+
+	return resolved, nil
+}
+
+var errNoStatusInquiry = errors.New("no status inquiry for this bank")
+
+// queryStatus asks the bank's status inquiry, or for payments opened at a
+// payment provider (hosted checkouts), the provider
+func (s *requeryService) queryStatus(ctx context.Context, payment *domain.Payment) (domain.PaymentStatus, string, error) {
+	if inquirer, ok := s.inquirers[payment.ProviderCode()]; ok {
+		return inquirer.QueryStatus(ctx, payment)
+	}
+
+	if payment.ProviderReference == "" {
+		return "", "", errNoStatusInquiry
+	}
+	p, err := s.providers.Lookup(payment.ProviderCode())
+	if err != nil {
+		return "", "", errNoStatusInquiry
+	}
+
+	result, err := p.Query(ctx, payment)
+	if err != nil {
+		return "", "", err
+	}
+	status, reason := providerStatus(result)
+	return status, reason, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SagaStep is one step of a multi-step operation. Do and Compensate may run
+// more than once (after a crash or a retry) and must be idempotent. A step
+// whose Do gave up is compensated too, so Compensate must also cope with a
+// Do that never took effect. Compensate may be nil for steps with nothing
+// to undo, such as notifications.
+type SagaStep struct {
+	Name       string
+	Do         func(ctx context.Context, data map[string]string) error
+	Compensate func(ctx context.Context, data map[string]string) error
+}
+
+// SagaDefinition is a named sequence of steps
+type SagaDefinition struct {
+	Kind  string
+	Steps []SagaStep
+}
+
+// SagaRunner executes sagas and resumes those interrupted by a crash or
+// waiting to retry a failed step. Steps are retried with backoff; once a
+// step runs out of attempts, the steps done so far are compensated in
+// reverse order.
+type SagaRunner interface {
+	Register(def SagaDefinition)
+	// Start persists a new run and executes it as far as it gets; the
+	// returned run's status tells whether it completed
+	Start(ctx context.Context, kind string, data map[string]string) (*domain.SagaRun, error)
+	// ResumeDue continues runs whose next attempt is due
+	ResumeDue(ctx context.Context) (int, error)
+}
+
+type SagaSettings struct {
+	MaxAttempts int           // Per step, for both Do and Compensate
+	Backoff     time.Duration // Multiplied by the attempt number
+	Lease       time.Duration // How long a run is reserved for the process executing it
+	BatchSize   int
+}
+
+type sagaRunner struct {
+	repo     repository.SagaRepository
+	settings SagaSettings
+	logger   *logrus.Logger
+
+	mu          sync.RWMutex
+	definitions map[string]SagaDefinition
+}
+
+func NewSagaRunner(repo repository.SagaRepository, settings SagaSettings, logger *logrus.Logger) SagaRunner {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 5
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = 30 * time.Second
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = 5 * time.Minute
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 50
+	}
+
+	return &sagaRunner{
+		repo:        repo,
+		settings:    settings,
+		logger:      logger,
+		definitions: make(map[string]SagaDefinition),
+	}
+}
+
+func (r *sagaRunner) Register(def SagaDefinition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definitions[def.Kind] = def
+}
+
+func (r *sagaRunner) definition(kind string) (SagaDefinition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.definitions[kind]
+	return def, ok
+}
+
+func (r *sagaRunner) Start(ctx context.Context, kind string, data map[string]string) (*domain.SagaRun, error) {
+	def, ok := r.definition(kind)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnknownSaga, kind)
+	}
+	if data == nil {
+		data = map[string]string{}
+	}
+
+	now := time.Now().UTC()
+	run := &domain.SagaRun{
+		ID:            uuid.New(),
+		Kind:          kind,
+		Status:        domain.SagaRunning,
+		Data:          data,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := r.repo.Create(ctx, run, now.Add(r.settings.Lease)); err != nil {
+		return nil, err
+	}
+
+	r.execute(ctx, def, run)
+	return run, nil
+}
+
+func (r *sagaRunner) ResumeDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	runs, err := r.repo.ClaimDue(ctx, now, now.Add(r.settings.Lease), r.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, run := range runs {
+		def, ok := r.definition(run.Kind)
+		if !ok {
+			// Leave it leased; a process that knows the kind picks it up
+			// when the lease lapses
+			r.logger.WithFields(logrus.Fields{"saga_id": run.ID, "kind": run.Kind}).Warn("No saga registered for kind, skipping")
+			continue
+		}
+		r.execute(ctx, def, run)
+	}
+
+	return len(runs), nil
+}
+
+// execute advances the run until it finishes or a step has to wait for a
+// retry. Progress is saved after every step; if saving fails the lease
+// lapses and the run is resumed from the last saved step.
+func (r *sagaRunner) execute(ctx context.Context, def SagaDefinition, run *domain.SagaRun) {
+	log := r.logger.WithFields(logrus.Fields{"saga_id": run.ID, "kind": run.Kind})
+
+	for {
+		switch run.Status {
+		case domain.SagaRunning:
+			if run.Step >= len(def.Steps) {
+				run.Status = domain.SagaCompleted
+				r.save(ctx, run, false)
+				log.Info("Saga completed")
+				return
+			}
+
+			step := def.Steps[run.Step]
+			if err := step.Do(ctx, run.Data); err != nil {
+				run.Attempts++
+				run.LastError = step.Name + ": " + err.Error()
+				if run.Attempts < r.settings.MaxAttempts {
+					r.retryLater(ctx, run)
+					log.WithError(err).WithField("step", step.Name).Warn("Saga step failed, will retry")
+					return
+				}
+
+				log.WithError(err).WithField("step", step.Name).Error("Saga step gave up, compensating")
+				run.Status = domain.SagaCompensating
+				run.Step++ // The failed step may have partly taken effect
+				run.Attempts = 0
+				if !r.save(ctx, run, true) {
+					return
+				}
+				continue
+			}
+
+			run.Step++
+			run.Attempts = 0
+			if !r.save(ctx, run, true) {
+				return
+			}
+
+		case domain.SagaCompensating:
+			if run.Step <= 0 {
+				run.Status = domain.SagaCompensated
+				r.save(ctx, run, false)
+				log.Info("Saga compensated")
+				return
+			}
+
+			step := def.Steps[run.Step-1]
+			if step.Compensate != nil {
+				if err := step.Compensate(ctx, run.Data); err != nil {
+					run.Attempts++
+					run.LastError = step.Name + " compensation: " + err.Error()
+					if run.Attempts < r.settings.MaxAttempts {
+						r.retryLater(ctx, run)
+						log.WithError(err).WithField("step", step.Name).Warn("Saga compensation failed, will retry")
+						return
+					}
+
+					run.Status = domain.SagaFailed
+					r.save(ctx, run, false)
+					log.WithError(err).WithField("step", step.Name).Error("Saga compensation gave up; manual action required")
+					return
+				}
+			}
+
+			run.Step--
+			run.Attempts = 0
+			if !r.save(ctx, run, true) {
+				return
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+func (r *sagaRunner) retryLater(ctx context.Context, run *domain.SagaRun) {
+	run.NextAttemptAt = time.Now().UTC().Add(r.settings.Backoff * time.Duration(run.Attempts))
+	r.save(ctx, run, false)
+}
+
+// save records progress, keeping the lease while the run continues
+func (r *sagaRunner) save(ctx context.Context, run *domain.SagaRun, keepLease bool) bool {
+	now := time.Now().UTC()
+	run.UpdatedAt = now
+
+	var lockedUntil time.Time
+	if keepLease {
+		lockedUntil = now.Add(r.settings.Lease)
+	}
+
+	return r.repo.Save(ctx, run, lockedUntil) == nil
+}
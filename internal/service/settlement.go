@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+	"payment-gateway/internal/settlement"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SettlementService stores bank statements for reconciliation
+type SettlementService interface {
+	// Upload parses a statement; format may be empty to detect it
+	Upload(ctx context.Context, bankCode, format, fileName string, data []byte, operator string) (*domain.SettlementFile, error)
+	GetFile(ctx context.Context, id uuid.UUID) (*domain.SettlementFile, error)
+	ListFiles(ctx context.Context, page, limit int) ([]*domain.SettlementFile, error)
+	ListLines(ctx context.Context, id uuid.UUID) ([]*domain.SettlementLine, error)
+}
+
+type settlementService struct {
+	repo   repository.SettlementRepository
+	logger *logrus.Logger
+}
+
+func NewSettlementService(repo repository.SettlementRepository, logger *logrus.Logger) SettlementService {
+	return &settlementService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *settlementService) Upload(ctx context.Context, bankCode, format, fileName string, data []byte, operator string) (*domain.SettlementFile, error) {
+	bankCode = strings.ToUpper(strings.TrimSpace(bankCode))
+	if bankCode == "" {
+		return nil, fmt.Errorf("%w: bank_code is required", domain.ErrInvalidInput)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", domain.ErrInvalidInput)
+	}
+
+	statementFormat := settlement.Format(strings.ToUpper(format))
+	if statementFormat != "" && !statementFormat.IsValid() {
+		return nil, fmt.Errorf("%w: format must be MT940, CAMT053 or CSV", domain.ErrInvalidInput)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	if _, err := s.repo.GetByChecksum(ctx, checksum); err == nil {
+		return nil, domain.ErrSettlementFileDuplicate
+	} else if err != domain.ErrSettlementFileNotFound {
+		return nil, err
+	}
+
+	stmt, err := settlement.Parse(data, statementFormat, bankCode)
+	if err != nil {
+		if errors.Is(err, settlement.ErrUnrecognized) {
+			return nil, fmt.Errorf("%w: %v", domain.ErrSettlementFileInvalid, err)
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	file := &domain.SettlementFile{
+		ID:             uuid.New(),
+		BankCode:       bankCode,
+		Format:         string(stmt.Format),
+		FileName:       fileName,
+		Checksum:       checksum,
+		AccountNumber:  stmt.AccountNumber,
+		Currency:       stmt.Currency,
+		OpeningBalance: stmt.OpeningBalance,
+		ClosingBalance: stmt.ClosingBalance,
+		LineCount:      len(stmt.Lines),
+		ErrorCount:     len(stmt.Errors),
+		ParseErrors:    make([]domain.SettlementParseError, 0, len(stmt.Errors)),
+		Status:         domain.SettlementParsed,
+		UploadedBy:     operator,
+		CreatedAt:      time.Now().UTC(),
+	}
+	for _, e := range stmt.Errors {
+		file.ParseErrors = append(file.ParseErrors, domain.SettlementParseError{Line: e.Line, Message: e.Message})
+	}
+	if file.ErrorCount > 0 {
+		file.Status = domain.SettlementParsedWithErrors
+	}
+
+	lines := make([]*domain.SettlementLine, 0, len(stmt.Lines))
+	for _, l := range stmt.Lines {
+		lines = append(lines, &domain.SettlementLine{
+			ID:          uuid.New(),
+			FileID:      file.ID,
+			LineNumber:  l.Number,
+			ValueDate:   l.ValueDate,
+			Direction:   string(l.Direction),
+			Amount:      l.Amount,
+			Currency:    l.Currency,
+			Reference:   truncate(l.Reference, 140),
+			BankRef:     truncate(l.BankRef, 100),
+			Description: l.Description,
+		})
+	}
+
+	if err := s.repo.Create(ctx, file, lines); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"file_id":   file.ID,
+		"bank_code": bankCode,
+		"format":    file.Format,
+		"lines":     file.LineCount,
+		"errors":    file.ErrorCount,
+		"operator":  operator,
+	}).Info("Settlement file uploaded")
+
+	return file, nil
+}
+
+func (s *settlementService) GetFile(ctx context.Context, id uuid.UUID) (*domain.SettlementFile, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *settlementService) ListFiles(ctx context.Context, page, limit int) ([]*domain.SettlementFile, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	return s.repo.List(ctx, limit, (page-1)*limit)
+}
+
+func (s *settlementService) ListLines(ctx context.Context, id uuid.UUID) ([]*domain.SettlementLine, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListLines(ctx, id)
+}
+
+// truncate shortens s to at most n characters to fit its column
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// TemplateService renders notification content stored as data in
+// notification_templates, so wording can change without a deploy.
+type TemplateService interface {
+	Render(ctx context.Context, event domain.NotificationEvent, channel domain.NotificationChannel, language domain.Language, merchantID string, data interface{}) (subject, body string, err error)
+	ListTemplates(ctx context.Context, merchantID string) ([]*domain.NotificationTemplate, error)
+	SaveTemplate(ctx context.Context, tmpl *domain.NotificationTemplate) error
+	// DeleteTemplate only removes a merchant's own override when merchantID is set
+	DeleteTemplate(ctx context.Context, id uuid.UUID, merchantID *string) error
+}
+
+type templateService struct {
+	repo            repository.NotificationTemplateRepository
+	defaultLanguage domain.Language
+	logger          *logrus.Logger
+}
+
+func NewTemplateService(repo repository.NotificationTemplateRepository, defaultLanguage domain.Language, logger *logrus.Logger) TemplateService {
+	if !defaultLanguage.IsValid() {
+		defaultLanguage = domain.LanguageAmharic
+	}
+
+	return &templateService{
+		repo:            repo,
+		defaultLanguage: defaultLanguage,
+		logger:          logger,
+	}
+}
+
+// Render falls back from the requested language to the default language,
+// and from a merchant override to the gateway default.
+func (s *templateService) Render(ctx context.Context, event domain.NotificationEvent, channel domain.NotificationChannel, language domain.Language, merchantID string, data interface{}) (string, string, error) {
+	if !language.IsValid() {
+		language = s.defaultLanguage
+	}
+
+	tmpl, err := s.repo.Find(ctx, event, channel, language, merchantID)
+	if err == domain.ErrTemplateNotFound && language != s.defaultLanguage {
+		tmpl, err = s.repo.Find(ctx, event, channel, s.defaultLanguage, merchantID)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"event":    event,
+			"channel":  channel,
+			"language": language,
+		}).Error("No notification template available")
+		return "", "", err
+	}
+
+	subject, err := executeTemplate(channel, "subject", tmpl.Subject, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	body, err := executeTemplate(channel, "body", tmpl.Body, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	return subject, body, nil
+}
+
+func (s *templateService) ListTemplates(ctx context.Context, merchantID string) ([]*domain.NotificationTemplate, error) {
+	return s.repo.List(ctx, merchantID)
+}
+
+func (s *templateService) SaveTemplate(ctx context.Context, tmpl *domain.NotificationTemplate) error {
+	if err := tmpl.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	// Reject templates that would fail at send time
+	if err := parseTemplate(tmpl.Channel, "subject", tmpl.Subject); err != nil {
+		return fmt.Errorf("%w: subject: %v", domain.ErrInvalidInput, err)
+	}
+	if err := parseTemplate(tmpl.Channel, "body", tmpl.Body); err != nil {
+		return fmt.Errorf("%w: body: %v", domain.ErrInvalidInput, err)
+	}
+
+	if tmpl.ID == uuid.Nil {
+		tmpl.ID = uuid.New()
+	}
+
+	return s.repo.Upsert(ctx, tmpl)
+}
+
+func (s *templateService) DeleteTemplate(ctx context.Context, id uuid.UUID, merchantID *string) error {
+	return s.repo.Delete(ctx, id, merchantID)
+}
+
+// Email and Telegram bodies are HTML and get contextual escaping; SMS is plain text
+func usesHTML(channel domain.NotificationChannel) bool {
+	return channel == domain.ChannelEmail || channel == domain.ChannelTelegram
+}
+
+func parseTemplate(channel domain.NotificationChannel, name, text string) error {
+	if usesHTML(channel) && name == "body" {
+		_, err := htmltemplate.New(name).Parse(text)
+		return err
+	}
+	_, err := template.New(name).Parse(text)
+	return err
+}
+
+func executeTemplate(channel domain.NotificationChannel, name, text string, data interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	var out bytes.Buffer
+	if usesHTML(channel) && name == "body" {
+		tmpl, err := htmltemplate.New(name).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", err
+		}
+		return out.String(), nil
+	}
+
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VelocitySettings caps how often and how much each customer may pay within
+// sliding windows. Customer limits cover all of a customer's payments; bank
+// limits, keyed by bank code, only those to that bank, as several partner
+// banks require.
+type VelocitySettings struct {
+	Enabled  bool
+	Customer []domain.VelocityLimit
+	Banks    map[string][]domain.VelocityLimit
+}
+
+// checkVelocity returns ErrVelocityLimitExceeded when the payment would take
+// the customer over a limit. It runs under the customer lock, so concurrent
+// payments cannot both squeeze under one limit.
+func (s *paymentService) checkVelocity(ctx context.Context, amount domain.Amount, currency domain.Currency, phone, nationalID, bankCode string) error {
+	if !s.velocity.Enabled || (phone == "" && nationalID == "") {
+		return nil
+	}
+
+	bankCode = strings.ToUpper(bankCode)
+	bankLimits := s.velocity.Banks[bankCode]
+	if len(s.velocity.Customer) == 0 && len(bankLimits) == 0 {
+		return nil
+	}
+
+	rates, err := rateMap(ctx, s.fx)
+	if err != nil {
+		return err
+	}
+	amountETB := amount
+	if currency.IsForeign() {
+		amountETB = domain.ConvertToETB(amount, rates[currency])
+	}
+
+	now := time.Now()
+	check := func(limits []domain.VelocityLimit, bank string) error {
+		for _, limit := range limits {
+			if limit.Window <= 0 {
+				continue
+			}
+			usage, err := s.repo.CustomerVelocity(ctx, phone, nationalID, bank, now.Add(-limit.Window), rates)
+			if err != nil {
+				return err
+			}
+			if err := limit.Check(usage, amountETB); err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"customer_phone": phone,
+					"bank_code":      bank,
+					"window":         limit.Window,
+					"payments":       usage.Payments,
+					"amount_etb":     usage.AmountETB,
+				}).Info("Payment rejected over velocity limit")
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := check(s.velocity.Customer, ""); err != nil {
+		return err
+	}
+	return check(bankLimits, bankCode)
+}
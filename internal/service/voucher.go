@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const maxVoucherCodeAttempts = 5
+
+// CashVoucherService lets customers pay AWAITING_CASH payments in cash at
+// partner branches and agents. The agent system looks the voucher up,
+// takes the cash and confirms, which settles the payment.
+type CashVoucherService interface {
+	// IssueVoucher replaces any earlier voucher for the payment
+	IssueVoucher(ctx context.Context, paymentID uuid.UUID) (*domain.CashVoucher, error)
+	LookupVoucher(ctx context.Context, code string) (*domain.CashVoucher, error)
+	// ConfirmVoucher is idempotent for the same agent and agent reference
+	ConfirmVoucher(ctx context.Context, code string, req domain.ConfirmVoucherRequest, agent string) (*domain.CashVoucher, error)
+}
+
+type CashVoucherSettings struct {
+	Enabled bool
+	TTL     time.Duration
+}
+
+type cashVoucherService struct {
+	repo        repository.CashVoucherRepository
+	paymentRepo repository.PaymentRepository
+	notifier    NotificationService
+	settings    CashVoucherSettings
+	logger      *logrus.Logger
+}
+
+func NewCashVoucherService(repo repository.CashVoucherRepository, paymentRepo repository.PaymentRepository, notifier NotificationService, settings CashVoucherSettings, logger *logrus.Logger) CashVoucherService {
+	if settings.TTL <= 0 {
+		settings.TTL = 48 * time.Hour
+	}
+
+	return &cashVoucherService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		notifier:    notifier,
+		settings:    settings,
+		logger:      logger,
+	}
+}
+
+func (s *cashVoucherService) IssueVoucher(ctx context.Context, paymentID uuid.UUID) (*domain.CashVoucher, error) {
+	if !s.settings.Enabled {
+		return nil, domain.ErrCashPaymentsDisabled
+	}
+
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusAwaitingCash {
+		return nil, domain.ErrVoucherNotAllowed
+	}
+
+	now := time.Now().UTC()
+	voucher := &domain.CashVoucher{
+		ID:           uuid.New(),
+		PaymentID:    paymentID,
+		Amount:       payment.Amount,
+		Currency:     payment.Currency,
+		Status:       domain.VoucherActive,
+		ExpiresAt:    now.Add(s.settings.TTL),
+		CreatedAt:    now,
+		Reference:    payment.Reference,
+		CustomerName: payment.CustomerName,
+	}
+
+	for attempt := 0; ; attempt++ {
+		voucher.Code, err = domain.GenerateVoucherCode()
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.Issue(ctx, voucher)
+		if err != domain.ErrVoucherCodeTaken || attempt == maxVoucherCodeAttempts-1 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"payment_id": paymentID,
+		"voucher_id": voucher.ID,
+		"expires_at": voucher.ExpiresAt,
+	}).Info("Cash voucher issued")
+
+	return voucher, nil
+}
+
+func (s *cashVoucherService) LookupVoucher(ctx context.Context, code string) (*domain.CashVoucher, error) {
+	normalized, ok := domain.NormalizeVoucherCode(code)
+	if !ok {
+		return nil, fmt.Errorf("%w: voucher code is not valid, check for typing mistakes", domain.ErrInvalidInput)
+	}
+
+	return s.repo.GetByCode(ctx, normalized)
+}
+
+func (s *cashVoucherService) ConfirmVoucher(ctx context.Context, code string, req domain.ConfirmVoucherRequest, agent string) (*domain.CashVoucher, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	voucher, err := s.LookupVoucher(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"payment_id":      voucher.PaymentID,
+		"voucher_id":      voucher.ID,
+		"agent":           agent,
+		"agent_reference": req.AgentReference,
+	})
+
+	// The agent system retries on timeouts; repeat the earlier answer
+	if voucher.RedeemedFor(agent, req.AgentReference) {
+		return voucher, nil
+	}
+
+	now := time.Now().UTC()
+	if err := voucher.CheckRedeemable(req.Amount, now); err != nil {
+		if err == domain.ErrVoucherAmount {
+			log.WithField("amount", req.Amount).Warn("Cash voucher amount mismatch")
+		}
+		return nil, err
+	}
+
+	if err := s.repo.Redeem(ctx, voucher, agent, req.AgentReference, req.Branch, now); err != nil {
+		return nil, err
+	}
+
+	log.Info("Cash voucher redeemed; payment settled")
+
+	// Notify the customer; a failed notification must not undo the payment
+	payment, err := s.paymentRepo.GetByID(ctx, voucher.PaymentID)
+	if err == nil {
+		err = s.notifier.NotifyPaymentStatus(ctx, payment)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to send payment notification")
+	}
+
+	return voucher, nil
+}
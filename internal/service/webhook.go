@@ -0,0 +1,337 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/repository"
+	"payment-gateway/pkg/webhook"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookService pushes payment events to merchant endpoints so they do
+// not have to poll. Events are queued in the database when a payment
+// finishes; the worker sends them and retries failures with backoff.
+//
+// merchantID scopes the management calls to one merchant's endpoints;
+// another merchant's endpoint or delivery reads as not found. A nil
+// merchantID is an operator and sees every endpoint.
+type WebhookService interface {
+	// RegisterEndpoint returns the endpoint with its signing secret, which
+	// is not shown again
+	RegisterEndpoint(ctx context.Context, req domain.RegisterWebhookRequest, merchantID *uuid.UUID) (*domain.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) error
+	ListDeliveries(ctx context.Context, endpointID uuid.UUID, merchantID *uuid.UUID) ([]*domain.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID, merchantID *uuid.UUID) ([]*domain.WebhookAttempt, error)
+	// ListFailed returns the latest deliveries that ran out of attempts
+	ListFailed(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookDelivery, error)
+	// ReplayDelivery sends a FAILED delivery once more, now, and returns
+	// its new state
+	ReplayDelivery(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.WebhookDelivery, error)
+
+	// Enqueue queues the event for every subscribed endpoint
+	Enqueue(ctx context.Context, event domain.NotificationEvent, payment *domain.Payment) error
+	// DispatchDue sends the deliveries that are due and returns how many
+	// were attempted
+	DispatchDue(ctx context.Context) (int, error)
+}
+
+// WebhookSettings controls delivery retries
+type WebhookSettings struct {
+	MaxAttempts int             // Before a delivery is marked FAILED
+	Schedule    []time.Duration // Wait before each retry; the last one repeats
+	Timeout     time.Duration   // Per HTTP request
+	Lease       time.Duration
+	BatchSize   int
+}
+
+type webhookService struct {
+	repo     repository.WebhookRepository
+	client   *http.Client
+	settings WebhookSettings
+	logger   *logrus.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, settings WebhookSettings, logger *logrus.Logger) WebhookService {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 8
+	}
+	if len(settings.Schedule) == 0 {
+		settings.Schedule = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = 2 * time.Minute
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 50
+	}
+
+	return &webhookService{
+		repo:     repo,
+		client:   &http.Client{Timeout: settings.Timeout},
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (s *webhookService) RegisterEndpoint(ctx context.Context, req domain.RegisterWebhookRequest, merchantID *uuid.UUID) (*domain.WebhookEndpoint, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	endpoint := &domain.WebhookEndpoint{
+		ID:          uuid.New(),
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		Active:      true,
+		Secret:      "whsec_" + hex.EncodeToString(secret),
+		MerchantID:  merchantID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if endpoint.Events == nil {
+		endpoint.Events = []domain.NotificationEvent{}
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"endpoint_id": endpoint.ID,
+		"url":         endpoint.URL,
+	}).Info("Webhook endpoint registered")
+
+	return endpoint, nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookEndpoint, error) {
+	return s.repo.ListEndpoints(ctx, merchantID)
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) error {
+	if _, err := s.endpoint(ctx, id, merchantID); err != nil {
+		return err
+	}
+	return s.repo.Deactivate(ctx, id)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, endpointID uuid.UUID, merchantID *uuid.UUID) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.endpoint(ctx, endpointID, merchantID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, endpointID, 100)
+}
+
+func (s *webhookService) ListAttempts(ctx context.Context, deliveryID uuid.UUID, merchantID *uuid.UUID) ([]*domain.WebhookAttempt, error) {
+	if merchantID != nil {
+		if _, _, err := s.delivery(ctx, deliveryID, merchantID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListAttempts(ctx, deliveryID)
+}
+
+func (s *webhookService) ListFailed(ctx context.Context, merchantID *uuid.UUID) ([]*domain.WebhookDelivery, error) {
+	return s.repo.ListFailed(ctx, merchantID, 100)
+}
+
+func (s *webhookService) ReplayDelivery(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, endpoint, err := s.delivery(ctx, id, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Active {
+		return nil, domain.ErrWebhookEndpointInactive
+	}
+
+	now := time.Now().UTC()
+	delivery, err = s.repo.ClaimFailed(ctx, id, now, now.Add(s.settings.Lease))
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, domain.ErrWebhookNotReplayable
+	}
+
+	s.logger.WithField("delivery_id", id).Info("Replaying failed webhook delivery")
+
+	// Already past max attempts, so a failure leaves it FAILED
+	s.deliver(ctx, endpoint, delivery)
+	return delivery, nil
+}
+
+// endpoint loads an endpoint the caller may manage
+func (s *webhookService) endpoint(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.OwnedBy(merchantID) {
+		return nil, domain.ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// delivery loads a delivery and its endpoint, if the caller may manage it
+func (s *webhookService) delivery(ctx context.Context, id uuid.UUID, merchantID *uuid.UUID) (*domain.WebhookDelivery, *domain.WebhookEndpoint, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpoint, err := s.repo.GetEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !endpoint.OwnedBy(merchantID) {
+		return nil, nil, domain.ErrWebhookDeliveryNotFound
+	}
+	return delivery, endpoint, nil
+}
+
+func (s *webhookService) Enqueue(ctx context.Context, event domain.NotificationEvent, payment *domain.Payment) error {
+	now := time.Now().UTC()
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":      event,
+		"created_at": now,
+		"data":       payment.ToResponse(),
+	})
+	if err != nil {
+		return err
+	}
+
+	queued, err := s.repo.Enqueue(ctx, event, payment.ID, payment.MerchantID, payload, now)
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"payment_id": payment.ID,
+			"event":      event,
+			"endpoints":  queued,
+		}).Debug("Webhook deliveries queued")
+	}
+
+	return nil
+}
+
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	deliveries, err := s.repo.ClaimDue(ctx, now, now.Add(s.settings.Lease), s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := map[uuid.UUID]*domain.WebhookEndpoint{}
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			endpoint, err = s.repo.GetEndpoint(ctx, delivery.EndpointID)
+			if err != nil {
+				// Leave it leased; it is retried when the lease lapses
+				s.logger.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to load webhook endpoint")
+				continue
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		s.deliver(ctx, endpoint, delivery)
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt and schedules the next one on failure. Any
+// 2xx response counts as delivered.
+func (s *webhookService) deliver(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery) {
+	log := s.logger.WithFields(logrus.Fields{
+		"delivery_id": delivery.ID,
+		"endpoint_id": endpoint.ID,
+		"event":       delivery.Event,
+	})
+
+	started := time.Now().UTC()
+	statusCode, sendErr := s.send(ctx, endpoint, delivery, started)
+
+	delivery.Attempts++
+	attempt := &domain.WebhookAttempt{
+		ID:            uuid.New(),
+		DeliveryID:    delivery.ID,
+		AttemptNumber: delivery.Attempts,
+		StatusCode:    statusCode,
+		DurationMS:    time.Since(started).Milliseconds(),
+		AttemptedAt:   started,
+	}
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.WebhookDelivered
+		delivery.DeliveredAt = &started
+	case delivery.Attempts >= s.settings.MaxAttempts:
+		delivery.Status = domain.WebhookFailed
+		delivery.LastError = sendErr.Error()
+		attempt.Error = sendErr.Error()
+		log.WithError(sendErr).Warn("Webhook delivery failed, giving up")
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = started.Add(s.backoff(delivery.Attempts))
+		attempt.Error = sendErr.Error()
+		log.WithError(sendErr).Info("Webhook delivery failed, will retry")
+	}
+
+	if err := s.repo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		// The lease lapses and the delivery is sent again; receivers
+		// de-duplicate on webhook.HeaderID
+		log.WithError(err).Error("Failed to record webhook attempt")
+	}
+}
+
+// backoff is the wait after the given failed attempt
+func (s *webhookService) backoff(attempt int) time.Duration {
+	if attempt > len(s.settings.Schedule) {
+		attempt = len(s.settings.Schedule)
+	}
+	return s.settings.Schedule[attempt-1]
+}
+
+// send posts the payload signed with the endpoint secret
+func (s *webhookService) send(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery, at time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	webhook.SetHeaders(req.Header, endpoint.Secret, delivery.ID.String(), string(delivery.Event), at, delivery.Payload)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("unreachable: %w", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
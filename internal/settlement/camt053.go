@@ -0,0 +1,212 @@
+package settlement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Only the parts of camt.053 (BankToCustomerStatement) used for
+// reconciliation. Tags carry no namespace, so any camt.053 version matches.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Account struct {
+		IBAN     string `xml:"Id>IBAN"`
+		Other    string `xml:"Id>Othr>Id"`
+		Currency string `xml:"Ccy"`
+	} `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtEntry struct {
+	Amount         camtAmount `xml:"Amt"`
+	Indicator      string     `xml:"CdtDbtInd"`
+	Reversal       bool       `xml:"RvslInd"`
+	Status         camtStatus `xml:"Sts"`
+	BookingDate    camtDate   `xml:"BookgDt"`
+	ValueDate      camtDate   `xml:"ValDt"`
+	ServicerRef    string     `xml:"AcctSvcrRef"`
+	AdditionalInfo string     `xml:"AddtlNtryInf"`
+	Transactions   []camtTx   `xml:"NtryDtls>TxDtls"`
+}
+
+// Sts is plain text up to camt.053.001.06 and <Sts><Cd> from .07 on
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtTx struct {
+	EndToEndID   string   `xml:"Refs>EndToEndId"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	Structured   string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+func parseCAMT053(data []byte) (*Statement, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnrecognized, err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("%w: no BkToCstmrStmt/Stmt element", ErrUnrecognized)
+	}
+
+	lineNumbers := entryLineNumbers(data)
+	stmt := &Statement{Format: FormatCAMT053}
+	entryIndex := 0
+
+	for _, s := range doc.Statements {
+		if stmt.AccountNumber == "" {
+			stmt.AccountNumber = firstNonEmpty(s.Account.IBAN, s.Account.Other)
+			stmt.Currency = s.Account.Currency
+		}
+
+		for _, b := range s.Balances {
+			amount, err := parseAmount(b.Amount.Value, false)
+			if err != nil {
+				stmt.addError(0, "balance %s: %v", b.Type, err)
+				continue
+			}
+			if b.Indicator == "DBIT" {
+				amount = -amount
+			}
+			if stmt.Currency == "" {
+				stmt.Currency = b.Amount.Currency
+			}
+
+			switch b.Type {
+			case "OPBD", "PRCD":
+				if stmt.OpeningBalance == nil {
+					stmt.OpeningBalance = &amount
+				}
+			case "CLBD":
+				stmt.ClosingBalance = &amount
+			}
+		}
+
+		for _, e := range s.Entries {
+			lineNo := 0
+			if entryIndex < len(lineNumbers) {
+				lineNo = lineNumbers[entryIndex]
+			}
+			entryIndex++
+
+			// Pending and informational entries are not settled money
+			status := strings.TrimSpace(firstNonEmpty(e.Status.Code, e.Status.Text))
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			line, err := camtLine(e)
+			if err != nil {
+				stmt.addError(lineNo, "%v", err)
+				continue
+			}
+			line.Number = lineNo
+			stmt.Lines = append(stmt.Lines, line)
+		}
+	}
+
+	return stmt, nil
+}
+
+func camtLine(e camtEntry) (Line, error) {
+	amount, err := parseAmount(e.Amount.Value, false)
+	if err != nil {
+		return Line{}, err
+	}
+
+	direction := Credit
+	switch e.Indicator {
+	case "CRDT":
+	case "DBIT":
+		direction = Debit
+	default:
+		return Line{}, fmt.Errorf("invalid CdtDbtInd %q", e.Indicator)
+	}
+
+	date := firstNonEmpty(e.ValueDate.Date, e.ValueDate.DateTime, e.BookingDate.Date, e.BookingDate.DateTime)
+	valueDate, err := parseCAMTDate(date)
+	if err != nil {
+		return Line{}, err
+	}
+
+	line := Line{
+		ValueDate:   valueDate,
+		Direction:   direction,
+		Amount:      amount,
+		Currency:    e.Amount.Currency,
+		BankRef:     strings.TrimSpace(e.ServicerRef),
+		Description: strings.TrimSpace(e.AdditionalInfo),
+	}
+
+	if len(e.Transactions) > 0 {
+		tx := e.Transactions[0]
+		line.Reference = strings.TrimSpace(firstNonEmpty(tx.Structured, tx.EndToEndID))
+		if line.Reference == "NOTPROVIDED" {
+			line.Reference = ""
+		}
+		if remittance := strings.TrimSpace(strings.Join(tx.Unstructured, " ")); remittance != "" {
+			line.Description = remittance
+		}
+	}
+
+	return line, nil
+}
+
+func parseCAMTDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// entryLineNumbers finds the source line of each <Ntry> for error reports
+func entryLineNumbers(data []byte) []int {
+	var numbers []int
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return numbers
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "Ntry" {
+			numbers = append(numbers, bytes.Count(data[:offset], []byte("\n"))+1)
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package settlement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvProfile describes one bank's CSV export. Columns are found by header
+// name, since banks add and reorder columns between system upgrades.
+type csvProfile struct {
+	dateLayouts []string
+	currency    string
+}
+
+var csvProfiles = map[string]csvProfile{
+	"CBE": {
+		dateLayouts: []string{"02/01/2006", "2006-01-02", "02-Jan-2006"},
+		currency:    "ETB",
+	},
+	"AWASH": {
+		dateLayouts: []string{"02-Jan-2006", "02-Jan-06", "02/01/2006", "2006-01-02"},
+		currency:    "ETB",
+	},
+}
+
+var defaultCSVProfile = csvProfile{
+	dateLayouts: []string{"2006-01-02", "02/01/2006", "02-Jan-2006", "02-01-2006"},
+	currency:    "ETB",
+}
+
+// Header aliases, lower-cased; the first matching column wins
+var csvColumns = map[string][]string{
+	"date":        {"value date", "valuedate", "transaction date", "txn date", "date", "posting date"},
+	"reference":   {"reference", "reference no", "reference no.", "ref no", "ref", "customer reference", "cheque/ref no"},
+	"bank_ref":    {"transaction id", "transaction reference", "txn id", "ft number", "ft reference"},
+	"description": {"description", "narration", "narrative", "particulars", "remarks", "details"},
+	"debit":       {"debit", "debit amount", "withdrawal", "withdrawals", "dr"},
+	"credit":      {"credit", "credit amount", "deposit", "deposits", "cr"},
+	"amount":      {"amount", "transaction amount"},
+	"type":        {"dr/cr", "cr/dr", "type", "debit/credit"},
+	"currency":    {"currency", "ccy"},
+}
+
+func parseCSV(data []byte, bankCode string) (*Statement, error) {
+	profile, ok := csvProfiles[strings.ToUpper(bankCode)]
+	if !ok {
+		profile = defaultCSVProfile
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1 // Preamble rows (account, period) have fewer columns
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	stmt := &Statement{Format: FormatCSV, Currency: profile.currency}
+	var columns map[string]int
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		lineNo, _ := reader.FieldPos(0)
+		if err != nil {
+			stmt.addError(lineNo, "%v", err)
+			continue
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		// Skip the preamble until the header row
+		if columns == nil {
+			columns = csvHeader(record)
+			if columns == nil && stmt.AccountNumber == "" {
+				stmt.AccountNumber = csvPreambleAccount(record)
+			}
+			continue
+		}
+
+		line, err := csvLine(record, columns, profile)
+		if err != nil {
+			stmt.addError(lineNo, "%v", err)
+			continue
+		}
+		if line == nil {
+			continue // Totals or balance rows
+		}
+		line.Number = lineNo
+		stmt.Lines = append(stmt.Lines, *line)
+	}
+
+	if columns == nil {
+		return nil, fmt.Errorf("%w: no header row with date and amount columns", ErrUnrecognized)
+	}
+
+	return stmt, nil
+}
+
+// csvHeader maps column roles to indexes, or nil if record is not a header
+func csvHeader(record []string) map[string]int {
+	columns := make(map[string]int)
+	for role, aliases := range csvColumns {
+		for i, cell := range record {
+			name := strings.ToLower(strings.TrimSpace(cell))
+			if contains(aliases, name) {
+				if _, seen := columns[role]; !seen {
+					columns[role] = i
+				}
+			}
+		}
+	}
+
+	_, hasDate := columns["date"]
+	_, hasAmount := columns["amount"]
+	_, hasDebit := columns["debit"]
+	_, hasCredit := columns["credit"]
+	if !hasDate || !(hasAmount || (hasDebit && hasCredit)) {
+		return nil
+	}
+	return columns
+}
+
+func csvLine(record []string, columns map[string]int, profile csvProfile) (*Line, error) {
+	cell := func(role string) string {
+		i, ok := columns[role]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rawDate := cell("date")
+	if rawDate == "" {
+		return nil, nil
+	}
+	valueDate, err := parseCSVDate(rawDate, profile.dateLayouts)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &Line{
+		ValueDate:   valueDate,
+		Currency:    profile.currency,
+		Reference:   cell("reference"),
+		BankRef:     cell("bank_ref"),
+		Description: cell("description"),
+	}
+	if currency := cell("currency"); currency != "" {
+		line.Currency = strings.ToUpper(currency)
+	}
+
+	if _, ok := columns["amount"]; ok && cell("amount") != "" {
+		amount, err := parseAmount(cell("amount"), false)
+		if err != nil {
+			return nil, err
+		}
+		line.Direction = Credit
+		kind := strings.ToUpper(cell("type"))
+		if amount < 0 || strings.HasPrefix(kind, "D") {
+			line.Direction = Debit
+		}
+		if amount < 0 {
+			amount = -amount
+		}
+		line.Amount = amount
+		return line, nil
+	}
+
+	debit, credit := cell("debit"), cell("credit")
+	switch {
+	case credit != "" && credit != "0" && credit != "0.00":
+		amount, err := parseAmount(credit, false)
+		if err != nil {
+			return nil, err
+		}
+		line.Direction, line.Amount = Credit, amount
+	case debit != "" && debit != "0" && debit != "0.00":
+		amount, err := parseAmount(debit, false)
+		if err != nil {
+			return nil, err
+		}
+		line.Direction, line.Amount = Debit, amount
+	default:
+		return nil, errors.New("row has neither a debit nor a credit amount")
+	}
+
+	return line, nil
+}
+
+func parseCSVDate(value string, layouts []string) (time.Time, error) {
+	// Some exports append a time of day
+	if i := strings.IndexByte(value, ' '); i > 0 {
+		value = value[:i]
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// csvPreambleAccount picks up "Account Number,1000123456789" style rows
+func csvPreambleAccount(record []string) string {
+	if len(record) < 2 {
+		return ""
+	}
+	label := strings.ToLower(strings.TrimSpace(record[0]))
+	if strings.Contains(label, "account") && (strings.Contains(label, "no") || strings.Contains(label, "number")) {
+		return strings.TrimSpace(record[1])
+	}
+	return ""
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package settlement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+)
+
+// :61: value date YYMMDD, optional entry date MMDD, mark (C, D, RC, RD),
+// optional funds code, amount with decimal comma, transaction type,
+// then customer reference and optional //bank reference
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d[\d,]*)([NSF][A-Z0-9]{3})(.*)$`)
+
+// :60F:/:62F: mark, date, currency, amount
+var mt940Balance = regexp.MustCompile(`^(C|D)(\d{6})([A-Z]{3})(\d[\d,]*)$`)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+func parseMT940(data []byte) (*Statement, error) {
+	fields := splitMT940(data)
+	if len(fields) == 0 {
+		return nil, ErrUnrecognized
+	}
+
+	stmt := &Statement{Format: FormatMT940}
+	var current *Line
+
+	for _, f := range fields {
+		switch f.tag {
+		case "25":
+			// Account, optionally BIC/account
+			account := f.value
+			if i := strings.LastIndex(account, "/"); i >= 0 {
+				account = account[i+1:]
+			}
+			stmt.AccountNumber = strings.TrimSpace(account)
+
+		case "60F", "60M":
+			if stmt.OpeningBalance != nil {
+				continue // Intermediate balance of a multi-page statement
+			}
+			currency, balance, err := parseMT940Balance(f.value)
+			if err != nil {
+				stmt.addError(f.line, "opening balance: %v", err)
+				continue
+			}
+			stmt.Currency = currency
+			stmt.OpeningBalance = &balance
+
+		case "62F", "62M":
+			_, balance, err := parseMT940Balance(f.value)
+			if err != nil {
+				stmt.addError(f.line, "closing balance: %v", err)
+				continue
+			}
+			stmt.ClosingBalance = &balance
+
+		case "61":
+			current = nil
+			line, err := parseMT940Line(f.value, stmt.Currency)
+			if err != nil {
+				stmt.addError(f.line, "%v", err)
+				continue
+			}
+			line.Number = f.line
+			stmt.Lines = append(stmt.Lines, line)
+			current = &stmt.Lines[len(stmt.Lines)-1]
+
+		case "86":
+			// Information to account owner, belongs to the preceding :61:
+			if current != nil {
+				current.Description = strings.Join(strings.Fields(f.value), " ")
+			}
+		}
+	}
+
+	if stmt.Currency == "" && len(stmt.Lines) > 0 {
+		stmt.addError(0, "statement has no opening balance (:60F:), line currency unknown")
+	}
+
+	return stmt, nil
+}
+
+// splitMT940 groups the file into tags, joining continuation lines and
+// skipping SWIFT block headers and trailers
+func splitMT940(data []byte) []mt940Field {
+	var fields []mt940Field
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(text)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "-"):
+			continue
+		case strings.HasPrefix(trimmed, ":"):
+			end := strings.Index(trimmed[1:], ":")
+			if end < 0 {
+				continue
+			}
+			fields = append(fields, mt940Field{
+				tag:   trimmed[1 : end+1],
+				value: trimmed[end+2:],
+				line:  lineNo,
+			})
+		case len(fields) > 0:
+			last := &fields[len(fields)-1]
+			last.value += "\n" + trimmed
+		}
+	}
+
+	return fields
+}
+
+func parseMT940Balance(value string) (string, domain.Amount, error) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return "", 0, fmt.Errorf("invalid balance %q", value)
+	}
+
+	amount, err := parseAmount(m[4], true)
+	if err != nil {
+		return "", 0, err
+	}
+	if m[1] == "D" {
+		amount = -amount
+	}
+	return m[3], amount, nil
+}
+
+func parseMT940Line(value, currency string) (Line, error) {
+	// Supplementary details may follow on the next line
+	first, supplementary, _ := strings.Cut(value, "\n")
+
+	m := mt940Line.FindStringSubmatch(strings.TrimSpace(first))
+	if m == nil {
+		return Line{}, fmt.Errorf("invalid :61: statement line %q", first)
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid value date %q", m[1])
+	}
+
+	amount, err := parseAmount(m[5], true)
+	if err != nil {
+		return Line{}, err
+	}
+
+	// Reversals flip the direction of the original booking
+	direction := Credit
+	if m[3] == "D" || m[3] == "RC" {
+		direction = Debit
+	}
+
+	reference, bankRef, _ := strings.Cut(m[7], "//")
+	reference = strings.TrimSpace(reference)
+	if reference == "NONREF" {
+		reference = ""
+	}
+
+	return Line{
+		ValueDate:   valueDate,
+		Direction:   direction,
+		Amount:      amount,
+		Currency:    currency,
+		Reference:   reference,
+		BankRef:     strings.TrimSpace(bankRef),
+		Description: strings.TrimSpace(supplementary),
+	}, nil
+}
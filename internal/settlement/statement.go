@@ -0,0 +1,132 @@
+// Package settlement parses the statement files Ethiopian banks deliver
+// (SWIFT MT940, ISO 20022 camt.053 and bank-specific CSV exports) into a
+// common form for reconciliation.
+package settlement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"payment-gateway/internal/domain"
+)
+
+type Format string
+
+const (
+	FormatMT940   Format = "MT940"
+	FormatCAMT053 Format = "CAMT053"
+	FormatCSV     Format = "CSV"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatMT940 || f == FormatCAMT053 || f == FormatCSV
+}
+
+type Direction string
+
+const (
+	Credit Direction = "CREDIT" // Money into the gateway's account
+	Debit  Direction = "DEBIT"
+)
+
+// Line is one booked transaction on a statement
+type Line struct {
+	Number      int           `json:"line_number"` // Position in the source file, for error reports
+	ValueDate   time.Time     `json:"value_date"`
+	Direction   Direction     `json:"direction"`
+	Amount      domain.Amount `json:"amount"`
+	Currency    string        `json:"currency"`
+	Reference   string        `json:"reference,omitempty"` // Customer/payment reference
+	BankRef     string        `json:"bank_ref,omitempty"`  // The bank's own transaction ID
+	Description string        `json:"description,omitempty"`
+}
+
+// ParseError reports a line that could not be read. Parsing continues past
+// it, so one bad row does not block the rest of the file.
+type ParseError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type Statement struct {
+	Format         Format         `json:"format"`
+	AccountNumber  string         `json:"account_number,omitempty"`
+	Currency       string         `json:"currency,omitempty"`
+	OpeningBalance *domain.Amount `json:"opening_balance,omitempty"`
+	ClosingBalance *domain.Amount `json:"closing_balance,omitempty"`
+	Lines          []Line         `json:"lines"`
+	Errors         []ParseError   `json:"errors"`
+}
+
+func (s *Statement) addError(line int, format string, args ...interface{}) {
+	s.Errors = append(s.Errors, ParseError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// ErrUnrecognized is returned when a file is not in the expected format at all
+var ErrUnrecognized = errors.New("file is not a recognized statement")
+
+// Parse reads a statement in the given format; an empty format is detected
+// from the content. bankCode selects the CSV column profile.
+func Parse(data []byte, format Format, bankCode string) (*Statement, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+
+	switch format {
+	case FormatMT940:
+		return parseMT940(data)
+	case FormatCAMT053:
+		return parseCAMT053(data)
+	case FormatCSV:
+		return parseCSV(data, bankCode)
+	default:
+		return nil, fmt.Errorf("unsupported statement format %q", format)
+	}
+}
+
+// Detect guesses the format from the first bytes of a file
+func Detect(data []byte) Format {
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatCAMT053
+	case bytes.Contains(head, []byte(":20:")) || bytes.Contains(head, []byte(":61:")):
+		return FormatMT940
+	default:
+		return FormatCSV
+	}
+}
+
+// parseAmount accepts "1,500.00", "1500,00" (SWIFT) and "1 500.00"
+func parseAmount(raw string, decimalComma bool) (domain.Amount, error) {
+	s := strings.TrimSpace(raw)
+	s = strings.ReplaceAll(s, " ", "")
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return 0, errors.New("amount is empty")
+	}
+
+	amount, err := domain.ParseAmount(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return amount, nil
+}
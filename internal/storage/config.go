@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"payment-gateway/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FromConfig opens the store cfg names: an S3 bucket for "s3", otherwise
+// a local directory
+func FromConfig(cfg config.StorageConfig, logger *logrus.Logger) (ObjectStore, error) {
+	switch cfg.Driver {
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:     cfg.S3.Endpoint,
+			Region:       cfg.S3.Region,
+			Bucket:       cfg.S3.Bucket,
+			AccessKey:    cfg.S3.AccessKey,
+			SecretKey:    cfg.S3.SecretKey,
+			UsePathStyle: cfg.S3.UsePathStyle,
+		}, logger)
+	default:
+		return NewLocalStore(cfg.LocalDir)
+	}
+}
@@ -0,0 +1,73 @@
+// Package tracing wires OpenTelemetry so a payment can be followed from
+// the API request through RabbitMQ to the worker that processes it.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "payment-gateway"
+
+type Config struct {
+	Enabled     bool
+	ServiceName string  // e.g. payment-gateway-api
+	Endpoint    string  // OTLP/HTTP collector host:port; empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    // Plain HTTP to the collector
+	SampleRatio float64 // Share of new traces kept; traces started upstream follow the caller
+}
+
+// Setup installs the W3C trace context propagator and, when enabled, a
+// tracer provider exporting over OTLP/HTTP. Context is propagated even
+// while disabled, so a traced caller's spans still join up downstream.
+// The returned function flushes pending spans on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracehttp.Option{}
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		cfg.SampleRatio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the gateway's tracer; a no-op until Setup enables tracing
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// TraceID returns the trace ID of ctx's span for log correlation, or ""
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AgentSettlementJob is the daily settlement run for the agent network. Once
+// a day at the configured time (UTC "HH:MM") it settles the Ethiopian
+// business day that ended before the run.
+type AgentSettlementJob struct {
+	agentService service.AgentService
+	logger       *logrus.Logger
+	at           string
+}
+
+func NewAgentSettlementJob(agentService service.AgentService, logger *logrus.Logger, at string) *AgentSettlementJob {
+	if at == "" {
+		at = "21:30" // 00:30 EAT
+	}
+
+	return &AgentSettlementJob{
+		agentService: agentService,
+		logger:       logger,
+		at:           at,
+	}
+}
+
+func (j *AgentSettlementJob) Run(ctx context.Context) {
+	for {
+		next, err := nextRun(time.Now().UTC(), j.at)
+		if err != nil {
+			j.logger.WithError(err).WithField("at", j.at).Error("Invalid agent settlement time, job disabled")
+			return
+		}
+
+		j.logger.WithField("next_run", next.Format(time.RFC3339)).Debug("Agent settlement scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := j.agentService.SettleDay(ctx, next.AddDate(0, 0, -1)); err != nil {
+			j.logger.WithError(err).Error("Agent settlement job failed")
+		}
+	}
+}
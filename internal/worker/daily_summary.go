@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DailySummaryJob emails merchants the previous day's payment summary
+// once a day at the configured time (UTC "HH:MM").
+type DailySummaryJob struct {
+	notificationService service.NotificationService
+	logger              *logrus.Logger
+	at                  string
+}
+
+func NewDailySummaryJob(notificationService service.NotificationService, logger *logrus.Logger, at string) *DailySummaryJob {
+	if at == "" {
+		at = "05:00" // 08:00 EAT
+	}
+
+	return &DailySummaryJob{
+		notificationService: notificationService,
+		logger:              logger,
+		at:                  at,
+	}
+}
+
+func (j *DailySummaryJob) Run(ctx context.Context) {
+	for {
+		next, err := nextRun(time.Now().UTC(), j.at)
+		if err != nil {
+			j.logger.WithError(err).WithField("at", j.at).Error("Invalid daily summary time, job disabled")
+			return
+		}
+
+		j.logger.WithField("next_run", next.Format(time.RFC3339)).Debug("Daily summary scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		yesterday := next.AddDate(0, 0, -1)
+		if err := j.notificationService.SendDailySummary(ctx, yesterday); err != nil {
+			j.logger.WithError(err).Error("Daily summary job failed")
+		}
+	}
+}
+
+// nextRun returns the next occurrence of the "HH:MM" wall-clock time after now
+func nextRun(now time.Time, at string) (time.Time, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next, nil
+}
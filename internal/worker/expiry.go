@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExpiryJob periodically expires payments left PENDING past their TTL
+type ExpiryJob struct {
+	expiryService service.ExpiryService
+	logger        *logrus.Logger
+	interval      time.Duration
+}
+
+func NewExpiryJob(expiryService service.ExpiryService, logger *logrus.Logger, interval time.Duration) *ExpiryJob {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	return &ExpiryJob{
+		expiryService: expiryService,
+		logger:        logger,
+		interval:      interval,
+	}
+}
+
+func (j *ExpiryJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := j.expiryService.ExpireStale(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Payment expiry job failed")
+			continue
+		}
+		if expired > 0 {
+			j.logger.WithField("expired", expired).Info("Pending payments swept to EXPIRED")
+		}
+	}
+}
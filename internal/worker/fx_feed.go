@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FXFeedJob polls the reference rate feed. The day's rates are recorded
+// the first time the feed publishes them; later polls find nothing new.
+type FXFeedJob struct {
+	fxService service.FXService
+	logger    *logrus.Logger
+	interval  time.Duration
+}
+
+func NewFXFeedJob(fxService service.FXService, logger *logrus.Logger, interval time.Duration) *FXFeedJob {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &FXFeedJob{
+		fxService: fxService,
+		logger:    logger,
+		interval:  interval,
+	}
+}
+
+func (j *FXFeedJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		// Poll at start-up too, so a restarted worker does not wait an
+		// interval for the day's rates
+		added, err := j.fxService.SyncRates(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("FX rate feed job failed")
+		} else if added > 0 {
+			j.logger.WithField("rates", added).Info("FX rates recorded from feed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/messaging"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OutboxRelayJob publishes messages waiting in the outbox
+type OutboxRelayJob struct {
+	relay    *messaging.OutboxRelay
+	logger   *logrus.Logger
+	interval time.Duration
+}
+
+func NewOutboxRelayJob(relay *messaging.OutboxRelay, logger *logrus.Logger, interval time.Duration) *OutboxRelayJob {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &OutboxRelayJob{
+		relay:    relay,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+func (j *OutboxRelayJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Drain a backlog without waiting a tick between full batches
+		for {
+			sent, err := j.relay.RelayDue(ctx)
+			if err != nil {
+				j.logger.WithError(err).Error("Outbox relay failed")
+				break
+			}
+			if sent > 0 {
+				j.logger.WithField("sent", sent).Debug("Outbox messages published")
+			}
+			if sent == 0 || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/domain"
+	"payment-gateway/internal/messaging"
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BulkPayoutJob drains queued bulk payout files
+type BulkPayoutJob struct {
+	payoutService service.BulkPayoutService
+	logger        *logrus.Logger
+	interval      time.Duration
+}
+
+func NewBulkPayoutJob(payoutService service.BulkPayoutService, logger *logrus.Logger, interval time.Duration) *BulkPayoutJob {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	return &BulkPayoutJob{
+		payoutService: payoutService,
+		logger:        logger,
+		interval:      interval,
+	}
+}
+
+func (j *BulkPayoutJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Keep going while there is work so a backlog doesn't wait a tick per job
+		for ctx.Err() == nil {
+			processed, err := j.payoutService.ProcessNext(ctx)
+			if err != nil {
+				j.logger.WithError(err).Error("Bulk payout job failed")
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+	}
+}
+
+// BulkPayoutCompletionHandler posts the results of a finished job, queued
+// by ProcessNext, to the merchant's callback URL
+func BulkPayoutCompletionHandler(payoutService service.BulkPayoutService, claims *messaging.ClaimCheck) MessageHandler {
+	return func(ctx context.Context, delivery messaging.Delivery) error {
+		var completion domain.BulkPayoutCompletion
+		if err := claims.OpenDelivery(ctx, delivery, &completion); err != nil {
+			return err
+		}
+		return payoutService.DeliverCompletion(ctx, &completion)
+	}
+}
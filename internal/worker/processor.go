@@ -3,57 +3,163 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"payment-gateway/internal/domain"
 	"payment-gateway/internal/messaging"
 	"payment-gateway/internal/service"
+	"payment-gateway/internal/tracing"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// MessageHandler processes a message the processor has no built-in handling for
+type MessageHandler func(ctx context.Context, delivery messaging.Delivery) error
+
 type PaymentProcessor struct {
 	paymentService service.PaymentService
-	rabbitMQ       *messaging.RabbitMQClient
+	refundService  service.RefundService
+	handlers       map[string]MessageHandler
+	broker         messaging.Broker
 	logger         *logrus.Logger
 	workerCount    int
+	workers        sync.WaitGroup
+	consuming      atomic.Int32 // Workers still taking deliveries
+
+	// Set by Start, so SetConcurrency can add and stop workers
+	mu         sync.Mutex
+	ctx        context.Context
+	deliveries <-chan messaging.Delivery
+	stops      []context.CancelFunc // One per running worker, newest last
+	nextID     int
 }
 
 func NewPaymentProcessor(
 	paymentService service.PaymentService,
-	rabbitMQ *messaging.RabbitMQClient,
+	refundService service.RefundService,
+	broker messaging.Broker,
 	logger *logrus.Logger,
 	workerCount int,
 ) *PaymentProcessor {
 	return &PaymentProcessor{
 		paymentService: paymentService,
-		rabbitMQ:       rabbitMQ,
+		refundService:  refundService,
+		handlers:       make(map[string]MessageHandler),
+		broker:         broker,
 		logger:         logger,
 		workerCount:    workerCount,
 	}
 }
 
+// Handle routes messages with routingKey to h; call it before Start
+func (p *PaymentProcessor) Handle(routingKey string, h MessageHandler) {
+	p.handlers[routingKey] = h
+}
+
 func (p *PaymentProcessor) Start(ctx context.Context) error {
-	deliveries, err := p.rabbitMQ.Consume()
+	deliveries, err := p.broker.Consume()
 	if err != nil {
 		return err
 	}
 
 	// Start multiple workers for concurrency
-	for i := 0; i < p.workerCount; i++ {
-		go p.worker(ctx, deliveries, i)
-	}
+	p.mu.Lock()
+	p.ctx, p.deliveries = ctx, deliveries
+	p.scale(p.workerCount)
+	p.mu.Unlock()
 
 	p.logger.WithFields(logrus.Fields{
 		"worker_count": p.workerCount,
-		"queue":        p.rabbitMQ.Config.QueueName,
 	}).Info("Ethiopian Payment Processor started with workers")
 
 	return nil
 }
 
-func (p *PaymentProcessor) worker(ctx context.Context, deliveries <-chan amqp.Delivery, workerID int) {
+// SetConcurrency runs n workers from now on, for a configuration reload.
+// Workers stopped to scale down finish the message they are processing.
+func (p *PaymentProcessor) SetConcurrency(n int) {
+	if n <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n == p.workerCount {
+		return
+	}
+	from := p.workerCount
+	p.workerCount = n
+	if p.deliveries == nil || p.ctx.Err() != nil {
+		return // Not started, or shutting down
+	}
+	p.scale(n)
+
+	p.logger.WithFields(logrus.Fields{
+		"from": from,
+		"to":   n,
+	}).Info("Payment worker count changed")
+}
+
+// scale starts or stops workers until n are running; p.mu must be held
+func (p *PaymentProcessor) scale(n int) {
+	for len(p.stops) < n {
+		ctx, stop := context.WithCancel(p.ctx)
+		id := p.nextID
+		p.nextID++
+		p.stops = append(p.stops, stop)
+
+		p.workers.Add(1)
+		p.consuming.Add(1)
+		go func() {
+			defer p.workers.Done()
+			defer p.consuming.Add(-1)
+			p.worker(ctx, p.deliveries, id)
+		}()
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		p.stops[last]()
+		p.stops = p.stops[:last]
+	}
+}
+
+// Check fails unless workers are taking messages from a connected broker,
+// for the worker's readiness probe
+func (p *PaymentProcessor) Check(ctx context.Context) error {
+	if p.consuming.Load() == 0 {
+		return errors.New("no worker is consuming")
+	}
+	return p.broker.Ping(ctx)
+}
+
+// Shutdown waits, once the context given to Start is cancelled, for the
+// workers to settle the messages they are processing, or until ctx is done.
+// Messages the broker delivered but no worker took are redelivered after
+// the broker is closed.
+func (p *PaymentProcessor) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *PaymentProcessor) worker(ctx context.Context, deliveries <-chan messaging.Delivery, workerID int) {
 	logger := p.logger.WithFields(logrus.Fields{
 		"worker_id": workerID,
 		"component": "payment_worker",
@@ -68,35 +174,79 @@ func (p *PaymentProcessor) worker(ctx context.Context, deliveries <-chan amqp.De
 			return
 		case delivery, ok := <-deliveries:
 			if !ok {
-				logger.Warn("Delivery channel closed")
+				logger.Info("Broker closed")
 				return
 			}
 
-			// Process message with retry logic
-			if err := p.processMessageWithRetry(ctx, delivery); err != nil {
-				logger.WithError(err).Error("Failed to process message after retries")
-
-				// Don't requeue, send to DLQ
-				delivery.Nack(false, false)
-			} else {
+			// A message in hand is finished even once shutdown begins, so
+			// it is acked or nacked rather than abandoned mid-payment
+			err := p.processMessage(context.WithoutCancel(ctx), delivery)
+			if err == nil {
 				// Acknowledge successful processing
-				delivery.Ack(false)
+				delivery.Ack()
+				continue
 			}
+
+			log := logger.WithError(err).WithFields(logrus.Fields{
+				"message_id": delivery.MessageID,
+				"attempts":   delivery.Attempts() + 1,
+			})
+			if !errors.Is(err, errUnprocessable) {
+				// Wait out the next retry tier, then try again
+				retryErr := delivery.Retry()
+				if retryErr == nil {
+					log.Warn("Failed to process message, will retry")
+					continue
+				}
+				if !errors.Is(retryErr, messaging.ErrRetriesExhausted) {
+					log = log.WithField("retry_error", retryErr)
+				}
+			}
+
+			// Out of retries, or never going to work: send to DLQ
+			log.Error("Failed to process message, sending to DLQ")
+			delivery.Nack(err.Error())
 		}
 	}
 }
 
-func (p *PaymentProcessor) processMessageWithRetry(ctx context.Context, delivery amqp.Delivery) error {
+// errUnprocessable marks a message that no retry can fix
+var errUnprocessable = errors.New("unprocessable message")
+
+func (p *PaymentProcessor) processMessage(ctx context.Context, delivery messaging.Delivery) (err error) {
+	// Continue the trace of the request that published the message
+	ctx, span := tracing.Tracer().Start(tracing.ExtractAMQP(ctx, delivery.Headers), "process "+delivery.RoutingKey,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", p.broker.System()),
+			attribute.String("messaging.message.id", delivery.MessageID),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if h, ok := p.handlers[delivery.RoutingKey]; ok {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		return h(ctx, delivery)
+	}
+
 	var msg messaging.PaymentMessage
 	if err := json.Unmarshal(delivery.Body, &msg); err != nil {
 		p.logger.WithError(err).Error("Failed to unmarshal message")
-		return err
+		return fmt.Errorf("%w: %v", errUnprocessable, err)
 	}
+	span.SetAttributes(attribute.String("payment.id", msg.PaymentID.String()))
 
 	logger := p.logger.WithFields(logrus.Fields{
 		"payment_id": msg.PaymentID,
-		"message_id": delivery.MessageId,
+		"message_id": delivery.MessageID,
 		"timestamp":  msg.Timestamp.Format(time.RFC3339),
+		"trace_id":   tracing.TraceID(ctx),
 	})
 
 	logger.Info("Processing Ethiopian payment message")
@@ -105,15 +255,19 @@ func (p *PaymentProcessor) processMessageWithRetry(ctx context.Context, delivery
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Check retry count from headers
-	retryCount := 0
-	if retryHeader, ok := delivery.Headers["retry_count"].(int32); ok {
-		retryCount = int(retryHeader)
+	if msg.Type == messaging.MessagePaymentCancelled {
+		// The payment's own message finds it CANCELLED and skips it
+		logger.Info("Payment cancelled, nothing to process")
+		return nil
 	}
 
-	if retryCount > 3 {
-		logger.Warn("Max retries exceeded, sending to DLQ")
-		return domain.ErrPaymentNotPending
+	if msg.Type == messaging.MessageRefundRequested {
+		if err := p.refundService.ProcessRefund(ctx, msg.RefundID); err != nil {
+			logger.WithError(err).WithField("refund_id", msg.RefundID).Error("Failed to process refund")
+			return err
+		}
+		logger.WithField("refund_id", msg.RefundID).Info("Refund processed")
+		return nil
 	}
 
 	// Process the payment
@@ -126,11 +280,6 @@ func (p *PaymentProcessor) processMessageWithRetry(ctx context.Context, delivery
 
 		// For other errors, log and retry
 		logger.WithError(err).Error("Failed to process payment")
-
-		// Increment retry count
-		delivery.Headers["retry_count"] = retryCount + 1
-
-		// Reject with requeue (for retry)
 		return err
 	}
 
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReconciliationJob matches newly uploaded bank statements to payments
+type ReconciliationJob struct {
+	reconciliationService service.ReconciliationService
+	logger                *logrus.Logger
+	interval              time.Duration
+}
+
+func NewReconciliationJob(reconciliationService service.ReconciliationService, logger *logrus.Logger, interval time.Duration) *ReconciliationJob {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &ReconciliationJob{
+		reconciliationService: reconciliationService,
+		logger:                logger,
+		interval:              interval,
+	}
+}
+
+func (j *ReconciliationJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reconciled, err := j.reconciliationService.ReconcilePending(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Settlement reconciliation job failed")
+			continue
+		}
+		if reconciled > 0 {
+			j.logger.WithField("files", reconciled).Info("Settlement files reconciled")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RegulatoryReportJob generates the NBE transaction report of the
+// Ethiopian business day that ended before each run, once a day at the
+// configured time (UTC "HH:MM"). A day already reported is skipped.
+type RegulatoryReportJob struct {
+	regulatoryService service.RegulatoryService
+	logger            *logrus.Logger
+	at                string
+}
+
+func NewRegulatoryReportJob(regulatoryService service.RegulatoryService, logger *logrus.Logger, at string) *RegulatoryReportJob {
+	if at == "" {
+		at = "22:00" // 01:00 EAT
+	}
+
+	return &RegulatoryReportJob{
+		regulatoryService: regulatoryService,
+		logger:            logger,
+		at:                at,
+	}
+}
+
+func (j *RegulatoryReportJob) Run(ctx context.Context) {
+	for {
+		next, err := nextRun(time.Now().UTC(), j.at)
+		if err != nil {
+			j.logger.WithError(err).WithField("at", j.at).Error("Invalid regulatory report time, job disabled")
+			return
+		}
+
+		j.logger.WithField("next_run", next.Format(time.RFC3339)).Debug("Regulatory report scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := j.regulatoryService.GenerateDaily(ctx, next.AddDate(0, 0, -1)); err != nil {
+			j.logger.WithError(err).Error("Regulatory report job failed")
+		}
+	}
+}
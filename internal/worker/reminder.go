@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReminderJob polls for due payment reminders and sends them
+type ReminderJob struct {
+	reminderService service.ReminderService
+	logger          *logrus.Logger
+	interval        time.Duration
+}
+
+func NewReminderJob(reminderService service.ReminderService, logger *logrus.Logger, interval time.Duration) *ReminderJob {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &ReminderJob{
+		reminderService: reminderService,
+		logger:          logger,
+		interval:        interval,
+	}
+}
+
+func (j *ReminderJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent, err := j.reminderService.SendDue(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Payment reminder job failed")
+			continue
+		}
+		if sent > 0 {
+			j.logger.WithField("sent", sent).Info("Payment reminders sent")
+		}
+	}
+}
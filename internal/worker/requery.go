@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequeryJob periodically resolves payments stuck in PROCESSING
+type RequeryJob struct {
+	requeryService service.RequeryService
+	logger         *logrus.Logger
+	interval       time.Duration
+}
+
+func NewRequeryJob(requeryService service.RequeryService, logger *logrus.Logger, interval time.Duration) *RequeryJob {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &RequeryJob{
+		requeryService: requeryService,
+		logger:         logger,
+		interval:       interval,
+	}
+}
+
+func (j *RequeryJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		resolved, err := j.requeryService.ResolveStuck(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Payment status re-query job failed")
+			continue
+		}
+		if resolved > 0 {
+			j.logger.WithField("resolved", resolved).Info("Stuck payments resolved")
+		}
+	}
+}
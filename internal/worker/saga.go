@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SagaJob periodically resumes sagas that are due a retry or were left
+// running by a crashed process
+type SagaJob struct {
+	runner   service.SagaRunner
+	logger   *logrus.Logger
+	interval time.Duration
+}
+
+func NewSagaJob(runner service.SagaRunner, logger *logrus.Logger, interval time.Duration) *SagaJob {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	return &SagaJob{
+		runner:   runner,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+func (j *SagaJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		resumed, err := j.runner.ResumeDue(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Saga resume job failed")
+			continue
+		}
+		if resumed > 0 {
+			j.logger.WithField("resumed", resumed).Info("Sagas resumed")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"payment-gateway/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookJob sends merchant webhook deliveries that are due
+type WebhookJob struct {
+	webhooks service.WebhookService
+	logger   *logrus.Logger
+	interval time.Duration
+}
+
+func NewWebhookJob(webhooks service.WebhookService, logger *logrus.Logger, interval time.Duration) *WebhookJob {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	return &WebhookJob{
+		webhooks: webhooks,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+func (j *WebhookJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent, err := j.webhooks.DispatchDue(ctx)
+		if err != nil {
+			j.logger.WithError(err).Error("Webhook dispatch job failed")
+			continue
+		}
+		if sent > 0 {
+			j.logger.WithField("attempted", sent).Debug("Webhook deliveries attempted")
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Package xlsx writes Office Open XML spreadsheets (.xlsx) with plain
+// sheets of text, numbers, money and dates: enough for exports that open
+// in Excel, LibreOffice and Google Sheets without a spreadsheet library.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decimal is a number written exactly as given, e.g. an amount's "1250.50",
+// and shown with thousands separators and two decimal places
+type Decimal string
+
+// Percent is a fraction shown as a percentage, e.g. 0.25 as 25.0%
+type Percent float64
+
+// Cell styles, indexes into cellXfs in styles.xml
+const (
+	styleDefault = iota
+	styleHeader
+	styleDecimal
+	styleDateTime
+	stylePercent
+)
+
+// Sheet names may not contain these and are at most 31 characters
+const invalidSheetChars = `[]:*?/\`
+
+type Workbook struct {
+	sheets []*Sheet
+}
+
+func New() *Workbook {
+	return &Workbook{}
+}
+
+// Sheet is a grid filled row by row from the top
+type Sheet struct {
+	name   string
+	widths []float64
+	rows   [][]any
+	header bool // First row is a header: bold, and frozen when scrolling
+}
+
+// AddSheet appends a sheet; names are trimmed to what Excel accepts
+func (w *Workbook) AddSheet(name string) *Sheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidSheetChars, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	s := &Sheet{name: name}
+	w.sheets = append(w.sheets, s)
+	return s
+}
+
+// SetWidths sets column widths in characters, from the first column
+func (s *Sheet) SetWidths(widths ...float64) {
+	s.widths = widths
+}
+
+// AddHeader adds a bold row that stays in view while scrolling. It must be
+// the first row.
+func (s *Sheet) AddHeader(titles ...string) {
+	row := make([]any, len(titles))
+	for i, title := range titles {
+		row[i] = title
+	}
+	s.rows = append(s.rows, row)
+	s.header = len(s.rows) == 1
+}
+
+// AddRow adds a row of cells: string, int, int64, float64, Decimal, Percent,
+// time.Time, or nil for an empty cell
+func (s *Sheet) AddRow(cells ...any) {
+	s.rows = append(s.rows, cells)
+}
+
+// Write writes the workbook as an .xlsx file
+func (w *Workbook) Write(out io.Writer) error {
+	if len(w.sheets) == 0 {
+		w.AddSheet("Sheet1")
+	}
+
+	z := zip.NewWriter(out)
+	files := []part{
+		{"[Content_Types].xml", w.writeContentTypes},
+		{"_rels/.rels", writeString(rootRels)},
+		{"xl/workbook.xml", w.writeWorkbook},
+		{"xl/_rels/workbook.xml.rels", w.writeWorkbookRels},
+		{"xl/styles.xml", writeString(styles)},
+	}
+	for i, sheet := range w.sheets {
+		files = append(files, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.write})
+	}
+
+	for _, file := range files {
+		f, err := z.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.write(f); err != nil {
+			return fmt.Errorf("xlsx: write %s: %w", file.name, err)
+		}
+	}
+	return z.Close()
+}
+
+// Bytes returns the workbook as an .xlsx file
+func (w *Workbook) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// part is a file inside the .xlsx zip
+type part struct {
+	name  string
+	write func(io.Writer) error
+}
+
+func writeString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+func (w *Workbook) writeContentTypes(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (w *Workbook) writeWorkbook(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(sheet.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (w *Workbook) writeWorkbookRels(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func (s *Sheet) write(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if s.header {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			style := styleDefault
+			if r == 0 && s.header {
+				style = styleHeader
+			}
+			if err := writeCell(&b, cellRef(c, r), value, style); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func writeCell(b *strings.Builder, ref string, value any, style int) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(v))
+	case int:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+	case int64:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+	case float64:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+	case Decimal:
+		if _, err := strconv.ParseFloat(string(v), 64); err != nil {
+			return fmt.Errorf("cell %s: %q is not a number", ref, v)
+		}
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDecimal, v)
+	case Percent:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, stylePercent, strconv.FormatFloat(float64(v), 'f', -1, 64))
+	case time.Time:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDateTime, strconv.FormatFloat(serial(v), 'f', -1, 64))
+	default:
+		return fmt.Errorf("cell %s: unsupported type %T", ref, value)
+	}
+	return nil
+}
+
+// serial is t's wall clock as an Excel date: days since 1899-12-30, so the
+// sheet shows the time in t's location
+func serial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Round(time.Second).Seconds() / 86400
+}
+
+// cellRef is the A1 reference of a zero-based column and row
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		// Control characters other than tab and newlines are not valid XML
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			continue
+		}
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// Cell formats in the order of the style constants. Format 4 is #,##0.00
+// and 22 m/d/yyyy h:mm, built into every spreadsheet application; 164 is
+// a custom percentage with one decimal place.
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="0.0%"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,31 @@
+-- SMS notifications for Ethiopian payments
+
+-- Customer phone number used for SMS notifications (e.g. 0911234567)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS customer_phone VARCHAR(20);
+
+-- Create notifications table
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('SMS')),
+    recipient VARCHAR(100) NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED'
+        CHECK (status IN ('QUEUED', 'SENT', 'DELIVERED', 'FAILED')),
+    provider_message_id VARCHAR(100),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_payment_id ON notifications(payment_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_provider_message_id ON notifications(provider_message_id);
+
+DROP TRIGGER IF EXISTS update_notifications_updated_at ON notifications;
+CREATE TRIGGER update_notifications_updated_at
+    BEFORE UPDATE ON notifications
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notifications IS 'Customer notifications (SMS) sent for payment status changes';
+COMMENT ON COLUMN notifications.provider_message_id IS 'Message ID returned by the Ethio Telecom SMS gateway, used to match delivery reports';
@@ -0,0 +1,9 @@
+-- Email receipts and merchant alerts
+
+-- Customer email used for payment receipts
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS customer_email VARCHAR(254);
+
+-- Allow EMAIL notifications alongside SMS
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('SMS', 'EMAIL'));
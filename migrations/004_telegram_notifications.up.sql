@@ -0,0 +1,21 @@
+-- Telegram bot notifications for merchants
+
+CREATE TABLE IF NOT EXISTS telegram_chats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    label VARCHAR(100) NOT NULL,
+    link_code VARCHAR(32) NOT NULL UNIQUE,
+    chat_id BIGINT UNIQUE,
+    chat_title VARCHAR(255),
+    linked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_chats_linked ON telegram_chats(linked_at) WHERE linked_at IS NOT NULL;
+
+-- Allow TELEGRAM notifications
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM'));
+
+COMMENT ON TABLE telegram_chats IS 'Merchant Telegram chats receiving payment notifications';
+COMMENT ON COLUMN telegram_chats.link_code IS 'One-time code sent to the bot as /start <code> to link a chat';
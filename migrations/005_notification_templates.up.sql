@@ -0,0 +1,136 @@
+-- Notification templates (per event, channel, language and merchant)
+
+-- Preferred notification language for the customer (am / en)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS language VARCHAR(2);
+
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM')),
+    language VARCHAR(2) NOT NULL CHECK (language IN ('am', 'en')),
+    merchant_id VARCHAR(64) NOT NULL DEFAULT '',
+    subject VARCHAR(255),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (event_type, channel, language, merchant_id)
+);
+
+DROP TRIGGER IF EXISTS update_notification_templates_updated_at ON notification_templates;
+CREATE TRIGGER update_notification_templates_updated_at
+    BEFORE UPDATE ON notification_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notification_templates IS 'Go text/html templates for notifications; merchant_id = '''' is the gateway default';
+
+-- Default templates
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    -- SMS
+    ('payment.succeeded', 'SMS', 'am', NULL,
+     $tpl$ክፍያዎ ተሳክቷል። {{printf "%.2f" .Amount}} {{.Currency}} (ማጣቀሻ፡ {{.Reference}})። እናመሰግናለን!$tpl$),
+    ('payment.succeeded', 'SMS', 'en', NULL,
+     $tpl$Your payment of {{printf "%.2f" .Amount}} {{.Currency}} was successful. Ref: {{.Reference}}. Thank you!$tpl$),
+    ('payment.failed', 'SMS', 'am', NULL,
+     $tpl$ክፍያዎ አልተሳካም። {{printf "%.2f" .Amount}} {{.Currency}} (ማጣቀሻ፡ {{.Reference}})። እባክዎ እንደገና ይሞክሩ።$tpl$),
+    ('payment.failed', 'SMS', 'en', NULL,
+     $tpl$Your payment of {{printf "%.2f" .Amount}} {{.Currency}} failed. Ref: {{.Reference}}. Please try again.$tpl$),
+
+    -- Email (customer receipt on success, merchant alert on failure)
+    ('payment.succeeded', 'EMAIL', 'am', $tpl$የክፍያ ደረሰኝ {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>የክፍያ ደረሰኝ</h2>
+<p>ውድ {{if .CustomerName}}{{.CustomerName}}{{else}}ደንበኛችን{{end}}፣ ክፍያዎ በተሳካ ሁኔታ ተከናውኗል።</p>
+<table cellpadding="6">
+<tr><td><b>ማጣቀሻ</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>መጠን</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+{{if .BankCode}}<tr><td><b>ባንክ</b></td><td>{{.BankCode}}</td></tr>{{end}}
+{{if .Description}}<tr><td><b>መግለጫ</b></td><td>{{.Description}}</td></tr>{{end}}
+<tr><td><b>ቀን</b></td><td>{{.CreatedAt.Format "2006-01-02 15:04"}} UTC</td></tr>
+</table>
+</body></html>$tpl$),
+    ('payment.succeeded', 'EMAIL', 'en', $tpl$Payment receipt {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>Payment Receipt</h2>
+<p>Dear {{if .CustomerName}}{{.CustomerName}}{{else}}customer{{end}}, your payment was successful.</p>
+<table cellpadding="6">
+<tr><td><b>Reference</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>Amount</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+{{if .BankCode}}<tr><td><b>Bank</b></td><td>{{.BankCode}}</td></tr>{{end}}
+{{if .Description}}<tr><td><b>Description</b></td><td>{{.Description}}</td></tr>{{end}}
+<tr><td><b>Date</b></td><td>{{.CreatedAt.Format "2006-01-02 15:04"}} UTC</td></tr>
+</table>
+</body></html>$tpl$),
+    ('payment.failed', 'EMAIL', 'am', $tpl$ያልተሳካ ክፍያ፡ {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>ያልተሳካ ክፍያ</h2>
+<p>የሚከተለው ክፍያ አልተሳካም፤ ደንበኛውን ማነጋገር ሊያስፈልግ ይችላል።</p>
+<table cellpadding="6">
+<tr><td><b>መለያ</b></td><td>{{.ID}}</td></tr>
+<tr><td><b>ማጣቀሻ</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>መጠን</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+<tr><td><b>ባንክ</b></td><td>{{.BankCode}}</td></tr>
+<tr><td><b>ደንበኛ</b></td><td>{{.CustomerName}} {{.CustomerPhone}}</td></tr>
+</table>
+</body></html>$tpl$),
+    ('payment.failed', 'EMAIL', 'en', $tpl$Payment failed: {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>Payment Failed</h2>
+<p>A payment could not be completed and may need follow-up with the customer.</p>
+<table cellpadding="6">
+<tr><td><b>Payment ID</b></td><td>{{.ID}}</td></tr>
+<tr><td><b>Reference</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>Amount</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+<tr><td><b>Bank</b></td><td>{{.BankCode}}</td></tr>
+<tr><td><b>Customer</b></td><td>{{.CustomerName}} {{.CustomerPhone}}</td></tr>
+</table>
+</body></html>$tpl$),
+    ('daily_summary', 'EMAIL', 'am', $tpl$የዕለት ክፍያ ማጠቃለያ {{.Date}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>የዕለት ክፍያ ማጠቃለያ — {{.Date}}</h2>
+<table cellpadding="6">
+<tr><td><b>ጠቅላላ ክፍያዎች</b></td><td>{{.Stats.TotalPayments}}</td></tr>
+<tr><td><b>የተሳኩ</b></td><td>{{.Stats.SuccessfulPayments}}</td></tr>
+<tr><td><b>ያልተሳኩ</b></td><td>{{.Stats.FailedPayments}}</td></tr>
+<tr><td><b>በመጠባበቅ ላይ</b></td><td>{{.Stats.PendingPayments}}</td></tr>
+<tr><td><b>ጠቅላላ ብር</b></td><td>{{printf "%.2f" .Stats.TotalAmountETB}} ብር</td></tr>
+<tr><td><b>ጠቅላላ ዶላር</b></td><td>{{printf "%.2f" .Stats.TotalAmountUSD}} $</td></tr>
+</table>
+</body></html>$tpl$),
+    ('daily_summary', 'EMAIL', 'en', $tpl$Daily payment summary {{.Date}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>Daily Payment Summary — {{.Date}}</h2>
+<table cellpadding="6">
+<tr><td><b>Total payments</b></td><td>{{.Stats.TotalPayments}}</td></tr>
+<tr><td><b>Successful</b></td><td>{{.Stats.SuccessfulPayments}}</td></tr>
+<tr><td><b>Failed</b></td><td>{{.Stats.FailedPayments}}</td></tr>
+<tr><td><b>Pending</b></td><td>{{.Stats.PendingPayments}}</td></tr>
+<tr><td><b>Total ETB</b></td><td>{{printf "%.2f" .Stats.TotalAmountETB}} Br</td></tr>
+<tr><td><b>Total USD</b></td><td>{{printf "%.2f" .Stats.TotalAmountUSD}} $</td></tr>
+</table>
+</body></html>$tpl$),
+
+    -- Telegram (HTML subset)
+    ('payment.succeeded', 'TELEGRAM', 'am', NULL,
+     $tpl$✅ <b>ክፍያ ተቀብለዋል</b>
+ማጣቀሻ፡ <code>{{.Reference}}</code>
+መጠን፡ {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+ባንክ፡ {{.BankCode}}{{end}}{{if .CustomerName}}
+ደንበኛ፡ {{.CustomerName}}{{end}}$tpl$),
+    ('payment.succeeded', 'TELEGRAM', 'en', NULL,
+     $tpl$✅ <b>Payment received</b>
+Reference: <code>{{.Reference}}</code>
+Amount: {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+Bank: {{.BankCode}}{{end}}{{if .CustomerName}}
+Customer: {{.CustomerName}}{{end}}$tpl$),
+    ('payment.failed', 'TELEGRAM', 'am', NULL,
+     $tpl$❌ <b>ክፍያ አልተሳካም</b>
+ማጣቀሻ፡ <code>{{.Reference}}</code>
+መጠን፡ {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+ባንክ፡ {{.BankCode}}{{end}}$tpl$),
+    ('payment.failed', 'TELEGRAM', 'en', NULL,
+     $tpl$❌ <b>Payment failed</b>
+Reference: <code>{{.Reference}}</code>
+Amount: {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+Bank: {{.BankCode}}{{end}}$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
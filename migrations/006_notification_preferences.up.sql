@@ -0,0 +1,22 @@
+-- Per-recipient notification preferences (event x channel)
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recipient_type VARCHAR(20) NOT NULL CHECK (recipient_type IN ('CUSTOMER', 'MERCHANT')),
+    recipient_id VARCHAR(254) NOT NULL DEFAULT '',
+    event_type VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM', 'WEBHOOK')),
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (recipient_type, recipient_id, event_type, channel)
+);
+
+DROP TRIGGER IF EXISTS update_notification_preferences_updated_at ON notification_preferences;
+CREATE TRIGGER update_notification_preferences_updated_at
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notification_preferences IS 'Opt-in/opt-out per recipient, event and channel; absent rows fall back to gateway config';
+COMMENT ON COLUMN notification_preferences.recipient_id IS 'Customer phone (or email) or merchant ID; empty merchant ID is the gateway itself';
@@ -0,0 +1,13 @@
+-- Customer lookup by phone number
+
+-- Bring phones stored before normalization into E.164 (+2519XXXXXXXX)
+UPDATE payments
+SET customer_phone = '+251' || RIGHT(regexp_replace(customer_phone, '\D', '', 'g'), 9)
+WHERE customer_phone IS NOT NULL
+  AND customer_phone NOT LIKE '+251%'
+  AND regexp_replace(customer_phone, '\D', '', 'g') ~ '^(00251|251|0)?[79][0-9]{8}$';
+
+CREATE INDEX IF NOT EXISTS idx_payments_customer_phone ON payments(customer_phone, created_at DESC)
+    WHERE customer_phone IS NOT NULL;
+
+COMMENT ON COLUMN payments.customer_phone IS 'Customer mobile number in E.164 format (+2519XXXXXXXX)';
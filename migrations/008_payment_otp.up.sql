@@ -0,0 +1,32 @@
+-- Optional customer OTP confirmation before a payment is processed
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP'));
+
+CREATE TABLE IF NOT EXISTS payment_otps (
+    payment_id UUID PRIMARY KEY REFERENCES payments(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    send_count INTEGER NOT NULL DEFAULT 1,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_payment_otps_updated_at ON payment_otps;
+CREATE TRIGGER update_payment_otps_updated_at
+    BEFORE UPDATE ON payment_otps
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE payment_otps IS 'One-time codes customers confirm before an AWAITING_OTP payment is processed';
+COMMENT ON COLUMN payment_otps.code_hash IS 'SHA-256 of payment ID and code; the code itself is never stored';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('payment.otp', 'SMS', 'am', NULL,
+     $tpl$የማረጋገጫ ኮድዎ {{.Code}} ነው። ለ{{printf "%.2f" .Amount}} {{.Currency}} ክፍያ (ማጣቀሻ፡ {{.Reference}})። ለ{{.Minutes}} ደቂቃ ያገለግላል። ለማንም አያጋሩ።$tpl$),
+    ('payment.otp', 'SMS', 'en', NULL,
+     $tpl$Your confirmation code is {{.Code}} for a payment of {{printf "%.2f" .Amount}} {{.Currency}} (Ref: {{.Reference}}). Valid for {{.Minutes}} minutes. Do not share it.$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
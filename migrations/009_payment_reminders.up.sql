@@ -0,0 +1,43 @@
+-- Reminder schedules for payments the customer has not completed yet
+
+CREATE TABLE IF NOT EXISTS payment_reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_type VARCHAR(20) NOT NULL CHECK (subject_type IN ('PAYMENT')),
+    subject_id UUID NOT NULL,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL DEFAULT 0,
+    next_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE'
+        CHECK (status IN ('ACTIVE', 'COMPLETED', 'EXPIRED', 'CANCELLED')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (subject_type, subject_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_reminders_due ON payment_reminders(next_at)
+    WHERE status = 'ACTIVE';
+
+DROP TRIGGER IF EXISTS update_payment_reminders_updated_at ON payment_reminders;
+CREATE TRIGGER update_payment_reminders_updated_at
+    BEFORE UPDATE ON payment_reminders
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE payment_reminders IS 'SMS/Telegram reminder schedule per unpaid subject; offsets come from reminders.schedule in config';
+COMMENT ON COLUMN payment_reminders.step IS 'Number of reminders already sent';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('payment.reminder', 'SMS', 'am', NULL,
+     $tpl$ማስታወሻ፡ የ{{printf "%.2f" .Amount}} {{.Currency}} ክፍያ (ማጣቀሻ፡ {{.Reference}}) ገና አልተጠናቀቀም። እባክዎ ክፍያውን ያጠናቅቁ።$tpl$),
+    ('payment.reminder', 'SMS', 'en', NULL,
+     $tpl$Reminder: your payment of {{printf "%.2f" .Amount}} {{.Currency}} (Ref: {{.Reference}}) is not complete yet. Please complete it.$tpl$),
+    ('payment.reminder', 'TELEGRAM', 'am', NULL,
+     $tpl$⏰ <b>ያልተከፈለ ክፍያ</b>
+{{printf "%.2f" .Amount}} {{.Currency}} — {{.Reference}}{{if .CustomerName}}
+ደንበኛ፡ {{.CustomerName}}{{end}}$tpl$),
+    ('payment.reminder', 'TELEGRAM', 'en', NULL,
+     $tpl$⏰ <b>Payment still unpaid</b>
+{{printf "%.2f" .Amount}} {{.Currency}} — {{.Reference}}{{if .CustomerName}}
+Customer: {{.CustomerName}}{{end}}$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
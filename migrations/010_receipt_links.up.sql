@@ -0,0 +1,18 @@
+-- Shareable public receipt links for successful payments
+
+CREATE TABLE IF NOT EXISTS receipt_links (
+    payment_id UUID PRIMARY KEY REFERENCES payments(id) ON DELETE CASCADE,
+    nonce VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_receipt_links_updated_at ON receipt_links;
+CREATE TRIGGER update_receipt_links_updated_at
+    BEFORE UPDATE ON receipt_links
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE receipt_links IS 'One active public receipt link per payment; the URL is signed over payment ID and nonce';
+COMMENT ON COLUMN receipt_links.nonce IS 'Rotated when a new link is issued, which invalidates the previous URL';
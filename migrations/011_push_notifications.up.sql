@@ -0,0 +1,35 @@
+-- FCM push notifications for merchant mobile/web apps
+
+CREATE TABLE IF NOT EXISTS push_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id VARCHAR(100) NOT NULL DEFAULT '',
+    user_id VARCHAR(100) NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    platform VARCHAR(10) NOT NULL CHECK (platform IN ('android', 'ios', 'web')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_merchant ON push_devices(merchant_id, user_id);
+
+-- Allow PUSH notifications, templates and preferences
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM', 'PUSH'));
+
+ALTER TABLE notification_templates DROP CONSTRAINT IF EXISTS notification_templates_channel_check;
+ALTER TABLE notification_templates ADD CONSTRAINT notification_templates_channel_check
+    CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM', 'PUSH'));
+
+ALTER TABLE notification_preferences DROP CONSTRAINT IF EXISTS notification_preferences_channel_check;
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_channel_check
+    CHECK (channel IN ('SMS', 'EMAIL', 'TELEGRAM', 'WEBHOOK', 'PUSH'));
+
+COMMENT ON TABLE push_devices IS 'Merchant app installations registered for FCM pushes; merchant_id = '''' is the gateway operators';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('payment.succeeded', 'PUSH', 'am', $tpl$ክፍያ ተቀብለዋል$tpl$,
+     $tpl${{printf "%.2f" .Amount}} {{.Currency}} — {{.Reference}}{{if .CustomerName}} ({{.CustomerName}}){{end}}$tpl$),
+    ('payment.succeeded', 'PUSH', 'en', $tpl$Payment received$tpl$,
+     $tpl${{printf "%.2f" .Amount}} {{.Currency}} — {{.Reference}}{{if .CustomerName}} ({{.CustomerName}}){{end}}$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
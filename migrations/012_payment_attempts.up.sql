@@ -0,0 +1,28 @@
+-- Processing attempt history, so manual retries keep earlier failures
+
+CREATE TABLE IF NOT EXISTS payment_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    attempt_number INTEGER NOT NULL,
+    bank_code VARCHAR(20),
+    trigger VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    failure_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT payment_attempts_number_unique UNIQUE (payment_id, attempt_number),
+    CONSTRAINT payment_attempts_trigger_check CHECK (trigger IN ('AUTO', 'MANUAL_RETRY')),
+    CONSTRAINT payment_attempts_status_check CHECK (status IN ('QUEUED', 'SUCCESS', 'FAILED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_payment ON payment_attempts(payment_id, attempt_number);
+
+-- Payments processed before this migration get their single attempt recorded
+INSERT INTO payment_attempts (payment_id, attempt_number, bank_code, trigger, status, created_at, finished_at)
+SELECT p.id, 1, p.bank_code, 'AUTO', p.status, p.created_at, p.updated_at
+FROM payments p
+WHERE p.status IN ('SUCCESS', 'FAILED')
+  AND NOT EXISTS (SELECT 1 FROM payment_attempts a WHERE a.payment_id = p.id);
+
+COMMENT ON TABLE payment_attempts IS 'One row per processing attempt; finished rows are never modified';
+COMMENT ON COLUMN payment_attempts.trigger IS 'AUTO for normal processing, MANUAL_RETRY for POST /payments/:id/retry';
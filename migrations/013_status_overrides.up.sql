@@ -0,0 +1,17 @@
+-- Audit trail for manual payment status changes by operators
+
+CREATE TABLE IF NOT EXISTS payment_status_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    operator VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT payment_status_overrides_reason_check CHECK (length(trim(reason)) >= 10)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_status_overrides_payment ON payment_status_overrides(payment_id, created_at);
+
+COMMENT ON TABLE payment_status_overrides IS 'Append-only log of operator status overrides';
+COMMENT ON COLUMN payment_status_overrides.operator IS 'Operator name from the admin token that made the change';
@@ -0,0 +1,8 @@
+-- PROCESSING: sent to the bank, result not yet known. Payments stuck here
+-- are resolved by the status re-query job.
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP', 'PROCESSING'));
+
+CREATE INDEX IF NOT EXISTS idx_payments_processing ON payments(updated_at) WHERE status = 'PROCESSING';
@@ -0,0 +1,51 @@
+-- Uploaded bank statements (MT940, camt.053, bank CSV) and their parsed lines
+
+CREATE TABLE IF NOT EXISTS settlement_files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bank_code VARCHAR(20) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL UNIQUE,
+    account_number VARCHAR(50),
+    currency VARCHAR(3),
+    opening_balance DECIMAL(15,2),
+    closing_balance DECIMAL(15,2),
+    line_count INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    parse_errors JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL,
+    uploaded_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT settlement_files_format_check CHECK (format IN ('MT940', 'CAMT053', 'CSV')),
+    CONSTRAINT settlement_files_status_check CHECK (status IN ('PARSED', 'PARSED_WITH_ERRORS'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_files_bank ON settlement_files(bank_code, created_at DESC);
+
+DROP TRIGGER IF EXISTS update_settlement_files_updated_at ON settlement_files;
+CREATE TRIGGER update_settlement_files_updated_at
+    BEFORE UPDATE ON settlement_files
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS settlement_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES settlement_files(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    value_date DATE NOT NULL,
+    direction VARCHAR(6) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount >= 0),
+    currency VARCHAR(3),
+    reference VARCHAR(140),
+    bank_ref VARCHAR(100),
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT settlement_lines_direction_check CHECK (direction IN ('CREDIT', 'DEBIT'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_file ON settlement_lines(file_id, line_number);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_reference ON settlement_lines(reference);
+
+COMMENT ON TABLE settlement_files IS 'Bank statement uploads; parse_errors lists lines that were skipped';
+COMMENT ON TABLE settlement_lines IS 'Booked transactions from statements, input to reconciliation';
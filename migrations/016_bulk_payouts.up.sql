@@ -0,0 +1,52 @@
+-- Payouts and bulk payout jobs (CSV uploads processed by the worker)
+
+CREATE TABLE IF NOT EXISTS bulk_payout_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED',
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    callback_url TEXT,
+    webhook_status VARCHAR(100),
+    claimed_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT bulk_payout_jobs_status_check CHECK (status IN ('QUEUED', 'PROCESSING', 'COMPLETED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_payout_jobs_open ON bulk_payout_jobs(created_at) WHERE status <> 'COMPLETED';
+
+DROP TRIGGER IF EXISTS update_bulk_payout_jobs_updated_at ON bulk_payout_jobs;
+CREATE TRIGGER update_bulk_payout_jobs_updated_at
+    BEFORE UPDATE ON bulk_payout_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID REFERENCES bulk_payout_jobs(id) ON DELETE CASCADE,
+    row_number INTEGER,
+    bank_code VARCHAR(20) NOT NULL,
+    account_number VARCHAR(50) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(140) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    failure_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT payouts_status_check CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'REJECTED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_job ON payouts(job_id, row_number);
+CREATE INDEX IF NOT EXISTS idx_payouts_pending ON payouts(job_id) WHERE status = 'PENDING';
+
+DROP TRIGGER IF EXISTS update_payouts_updated_at ON payouts;
+CREATE TRIGGER update_payouts_updated_at
+    BEFORE UPDATE ON payouts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE bulk_payout_jobs IS 'Uploaded payout CSVs; counts are derived from payouts';
+COMMENT ON COLUMN bulk_payout_jobs.claimed_until IS 'Worker lease; an expired lease lets another worker resume the job';
+COMMENT ON COLUMN payouts.status IS 'REJECTED rows failed validation at upload and were never sent';
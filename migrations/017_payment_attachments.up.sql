@@ -0,0 +1,20 @@
+-- Proof-of-payment documents (transfer slips, approvals) kept in object storage
+
+CREATE TABLE IF NOT EXISTS payment_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    kind VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    checksum VARCHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    uploaded_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT payment_attachments_kind_check CHECK (kind IN ('TRANSFER_SLIP', 'APPROVAL', 'OTHER'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_attachments_payment ON payment_attachments(payment_id, created_at);
+
+COMMENT ON TABLE payment_attachments IS 'Immutable proof-of-payment documents for audits and disputes';
+COMMENT ON COLUMN payment_attachments.checksum IS 'SHA-256 of the stored object, hex';
@@ -0,0 +1,18 @@
+-- Purpose-of-payment (ISO 20022) and merchant category (ISO 18245) codes
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS purpose_code VARCHAR(4);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS mcc VARCHAR(4);
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_purpose_code_check;
+ALTER TABLE payments ADD CONSTRAINT payments_purpose_code_check CHECK (purpose_code ~ '^[A-Z]{4}$');
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_mcc_check;
+ALTER TABLE payments ADD CONSTRAINT payments_mcc_check CHECK (mcc ~ '^[0-9]{4}$');
+
+CREATE INDEX IF NOT EXISTS idx_payments_purpose_code ON payments(purpose_code, created_at DESC)
+    WHERE purpose_code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_mcc ON payments(mcc, created_at DESC)
+    WHERE mcc IS NOT NULL;
+
+COMMENT ON COLUMN payments.purpose_code IS 'ISO 20022 purpose code; required for FX and high-value payments';
+COMMENT ON COLUMN payments.mcc IS 'ISO 18245 merchant category code; required for FX and high-value payments';
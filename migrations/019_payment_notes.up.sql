@@ -0,0 +1,15 @@
+-- Internal investigation notes on payments (separate from the audit log)
+
+CREATE TABLE IF NOT EXISTS payment_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    author VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL CHECK (length(body) BETWEEN 1 AND 2000),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_notes_payment ON payment_notes(payment_id, created_at);
+
+COMMENT ON TABLE payment_notes IS 'Ops comments on payments; editable by their author, not part of the audit trail';
+COMMENT ON COLUMN payment_notes.author IS 'Operator name from the admin token';
@@ -0,0 +1,33 @@
+-- Cash payments at partner bank branches and agents against a voucher code.
+-- AWAITING_CASH: held until an agent confirms the cash was received.
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP', 'PROCESSING', 'AWAITING_CASH'));
+
+CREATE TABLE IF NOT EXISTS cash_vouchers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    code VARCHAR(10) NOT NULL UNIQUE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    redeemed_at TIMESTAMP WITH TIME ZONE,
+    redeemed_by VARCHAR(100),
+    agent_reference VARCHAR(64),
+    branch VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT cash_vouchers_status_check CHECK (status IN ('ACTIVE', 'REDEEMED', 'CANCELLED'))
+);
+
+-- At most one live voucher per payment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cash_vouchers_active_payment ON cash_vouchers(payment_id)
+    WHERE status = 'ACTIVE';
+
+-- An agent transaction can only pay one voucher
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cash_vouchers_agent_reference ON cash_vouchers(redeemed_by, agent_reference)
+    WHERE agent_reference IS NOT NULL;
+
+COMMENT ON COLUMN cash_vouchers.code IS '9 random digits plus a Luhn check digit';
+COMMENT ON COLUMN cash_vouchers.redeemed_by IS 'Agent name from the agent token that confirmed the cash';
@@ -0,0 +1,56 @@
+-- Agent network: registered cash-in agents, their float ledger and the
+-- per-day lines of the settlement run
+
+CREATE TABLE IF NOT EXISTS agents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    region VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    float_balance DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (float_balance >= 0),
+    txn_limit DECIMAL(15,2) NOT NULL CHECK (txn_limit > 0),
+    daily_limit DECIMAL(15,2) NOT NULL CHECK (daily_limit > 0),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT agents_status_check CHECK (status IN ('ACTIVE', 'SUSPENDED'))
+);
+
+DROP TRIGGER IF EXISTS update_agents_updated_at ON agents;
+CREATE TRIGGER update_agents_updated_at
+    BEFORE UPDATE ON agents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS agent_float_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES agents(id),
+    kind VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    reference VARCHAR(64),
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT agent_float_entries_kind_check CHECK (kind IN ('TOPUP', 'CASH_IN', 'COMMISSION'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_float_entries_agent ON agent_float_entries(agent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_float_entries_cash_in ON agent_float_entries(created_at)
+    WHERE kind = 'CASH_IN';
+
+CREATE TABLE IF NOT EXISTS agent_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES agents(id),
+    business_date DATE NOT NULL,
+    cash_in_count INTEGER NOT NULL,
+    cash_in_total DECIMAL(15,2) NOT NULL,
+    commission DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (agent_id, business_date)
+);
+
+COMMENT ON TABLE agent_float_entries IS 'Append-only float ledger; amount is signed (cash-ins are negative)';
+COMMENT ON COLUMN agents.token_hash IS 'SHA-256 of the agent API token, hex; the token itself is never stored';
+COMMENT ON COLUMN agent_settlements.business_date IS 'Ethiopian business day (EAT) the cash-ins belong to';
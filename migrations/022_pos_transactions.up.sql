@@ -0,0 +1,22 @@
+-- Card payments received from POS terminals over ISO 8583
+
+CREATE TABLE IF NOT EXISTS pos_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    terminal_id VARCHAR(8) NOT NULL,
+    merchant_id VARCHAR(15) NOT NULL,
+    stan VARCHAR(6) NOT NULL,
+    rrn VARCHAR(12) NOT NULL,
+    masked_pan VARCHAR(19) NOT NULL,
+    entry_mode VARCHAR(3),
+    acceptor_name VARCHAR(40),
+    approval_code VARCHAR(6) NOT NULL,
+    response_code VARCHAR(2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (terminal_id, rrn)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pos_transactions_payment ON pos_transactions(payment_id);
+
+COMMENT ON COLUMN pos_transactions.masked_pan IS 'First six and last four digits only';
+COMMENT ON COLUMN pos_transactions.rrn IS 'Retrieval reference number (ISO 8583 field 37); terminals retry with the same RRN';
@@ -0,0 +1,18 @@
+-- Per-customer cumulative limits: national ID as a second customer key and
+-- a flag on payments accepted over a limit
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS customer_national_id VARCHAR(16);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS limit_flag VARCHAR(20);
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_limit_flag_check;
+ALTER TABLE payments ADD CONSTRAINT payments_limit_flag_check
+    CHECK (limit_flag IS NULL OR limit_flag IN ('DAILY_LIMIT', 'MONTHLY_LIMIT'));
+
+CREATE INDEX IF NOT EXISTS idx_payments_customer_phone_created ON payments(customer_phone, created_at);
+CREATE INDEX IF NOT EXISTS idx_payments_customer_national_id_created ON payments(customer_national_id, created_at)
+    WHERE customer_national_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_limit_flag ON payments(created_at DESC)
+    WHERE limit_flag IS NOT NULL;
+
+COMMENT ON COLUMN payments.customer_national_id IS 'Fayda FIN (12 digits) or FAN (16 digits)';
+COMMENT ON COLUMN payments.limit_flag IS 'Cumulative customer limit the payment exceeded when accepted in flag mode';
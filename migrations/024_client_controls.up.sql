@@ -0,0 +1,11 @@
+-- Client IP, country and device fingerprint on payments for geo and
+-- velocity controls
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS client_ip VARCHAR(45);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS client_country CHAR(2);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS device_fingerprint VARCHAR(128);
+
+CREATE INDEX IF NOT EXISTS idx_payments_client_ip_created ON payments(client_ip, created_at)
+    WHERE client_ip IS NOT NULL;
+
+COMMENT ON COLUMN payments.client_country IS 'ISO 3166 alpha-2 resolved from the client IP when the payment was created';
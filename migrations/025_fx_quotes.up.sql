@@ -0,0 +1,31 @@
+-- Daily USD/ETB rates, locked-rate quotes and the rate each USD payment
+-- settled at
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pair VARCHAR(7) NOT NULL DEFAULT 'USD/ETB',
+    rate DECIMAL(12,4) NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL,
+    set_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_pair_created ON fx_rates(pair, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pair VARCHAR(7) NOT NULL DEFAULT 'USD/ETB',
+    rate DECIMAL(12,4) NOT NULL,
+    mid_rate DECIMAL(12,4) NOT NULL,
+    spread_bps INTEGER NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    payment_id UUID UNIQUE REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fx_quote_id UUID REFERENCES fx_quotes(id);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fx_rate DECIMAL(12,4);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_etb DECIMAL(15,2);
+
+COMMENT ON TABLE fx_rates IS 'Daily USD/ETB mid rates; the latest row is current';
+COMMENT ON COLUMN payments.fx_rate IS 'Quoted rate, or the daily rate when an unquoted USD payment succeeded';
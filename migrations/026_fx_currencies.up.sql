@@ -0,0 +1,5 @@
+-- EUR, GBP, AED and CNY settle through the same rates and quotes as USD;
+-- pairs are named <currency>/ETB
+
+COMMENT ON TABLE fx_rates IS 'Daily mid rates into ETB per pair; the latest row per pair is current';
+COMMENT ON COLUMN payments.fx_rate IS 'Quoted rate, or the daily rate when an unquoted foreign currency payment succeeded';
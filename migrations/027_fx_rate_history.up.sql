@@ -0,0 +1,8 @@
+-- Rate history lookups by effective date, and the history entry each quote
+-- was priced from
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_effective_date ON fx_rates(effective_date);
+
+ALTER TABLE fx_quotes ADD COLUMN IF NOT EXISTS rate_id UUID REFERENCES fx_rates(id);
+
+COMMENT ON COLUMN fx_quotes.rate_id IS 'fx_rates entry of the mid rate; NULL when priced from the configured fallback';
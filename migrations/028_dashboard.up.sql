@@ -0,0 +1,5 @@
+-- Dashboard: today's aggregates come from idx_payments_created_at; recent
+-- failures are read by when they failed
+
+CREATE INDEX IF NOT EXISTS idx_payments_failed_updated ON payments(updated_at DESC) WHERE status = 'FAILED';
+CREATE INDEX IF NOT EXISTS idx_settlement_files_created_at ON settlement_files(created_at DESC);
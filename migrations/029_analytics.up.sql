@@ -0,0 +1,3 @@
+-- Analytics read successful payments by creation time
+
+CREATE INDEX IF NOT EXISTS idx_payments_success_created ON payments(created_at) WHERE status = 'SUCCESS';
@@ -0,0 +1,5 @@
+-- Free-form merchant labels on payments; the GIN index serves tags @> filters
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_payments_tags ON payments USING GIN (tags);
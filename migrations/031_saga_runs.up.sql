@@ -0,0 +1,22 @@
+-- Multi-step operations (e.g. refunds) run as sagas: progress is saved after
+-- every step so a crashed run resumes, and failed runs are compensated
+
+CREATE TABLE IF NOT EXISTS saga_runs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    step INTEGER NOT NULL DEFAULT 0,
+    data JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT saga_runs_status_check CHECK (status IN ('RUNNING', 'COMPENSATING', 'COMPLETED', 'COMPENSATED', 'FAILED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_saga_runs_due ON saga_runs(next_attempt_at) WHERE status IN ('RUNNING', 'COMPENSATING');
+CREATE INDEX IF NOT EXISTS idx_saga_runs_failed ON saga_runs(updated_at) WHERE status = 'FAILED';
+
+COMMENT ON COLUMN saga_runs.step IS 'Running: next step to do. Compensating: steps before it remain to undo';
@@ -0,0 +1,56 @@
+-- Merchant onboarding: registered merchants and the bank accounts they are
+-- settled to
+
+CREATE TABLE IF NOT EXISTS merchants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    legal_name VARCHAR(150) NOT NULL,
+    trade_name VARCHAR(150),
+    tin VARCHAR(10) NOT NULL UNIQUE,
+    email VARCHAR(254) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    region VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    status_reason VARCHAR(500),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT merchants_status_check CHECK (status IN ('PENDING', 'ACTIVE', 'SUSPENDED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchants_status ON merchants(status);
+
+DROP TRIGGER IF EXISTS update_merchants_updated_at ON merchants;
+CREATE TRIGGER update_merchants_updated_at
+    BEFORE UPDATE ON merchants
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS merchant_settlement_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    bank_code VARCHAR(20) NOT NULL,
+    account_number VARCHAR(20) NOT NULL,
+    account_name VARCHAR(150) NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    verified_name VARCHAR(150),
+    status_note VARCHAR(500),
+    verified_by VARCHAR(100),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (merchant_id, bank_code, account_number),
+    CONSTRAINT merchant_settlement_accounts_status_check CHECK (status IN ('PENDING', 'VERIFIED', 'REJECTED')),
+    CONSTRAINT merchant_settlement_accounts_primary_check CHECK (NOT is_primary OR status = 'VERIFIED')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merchant_settlement_accounts_primary
+    ON merchant_settlement_accounts(merchant_id) WHERE is_primary;
+
+DROP TRIGGER IF EXISTS update_merchant_settlement_accounts_updated_at ON merchant_settlement_accounts;
+CREATE TRIGGER update_merchant_settlement_accounts_updated_at
+    BEFORE UPDATE ON merchant_settlement_accounts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN merchants.tin IS 'Ethiopian Taxpayer Identification Number (10 digits)';
+COMMENT ON COLUMN merchant_settlement_accounts.verified_name IS 'Account holder name returned by the bank name inquiry';
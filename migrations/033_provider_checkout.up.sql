@@ -0,0 +1,8 @@
+-- Payments routed through an aggregator (e.g. Chapa hosted checkout) carry
+-- the provider's transaction reference and the page the customer pays on
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_reference VARCHAR(100);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS checkout_url TEXT;
+
+COMMENT ON COLUMN payments.provider_reference IS 'Transaction reference sent to the payment provider; changes on each retry';
+COMMENT ON COLUMN payments.checkout_url IS 'Hosted checkout page for payments awaiting the customer at the provider';
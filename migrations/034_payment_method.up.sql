@@ -0,0 +1,6 @@
+-- How the customer pays: BANK payments go to the provider for their
+-- bank_code, aggregator methods (ARIFPAY) to the aggregator's checkout
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20) NOT NULL DEFAULT 'BANK';
+
+COMMENT ON COLUMN payments.payment_method IS 'BANK or an aggregator such as ARIFPAY; selects the payment provider';
@@ -0,0 +1,33 @@
+-- Refunds of successful payments, processed by the worker like payments
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY,
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(200) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'PROCESSING', 'SUCCEEDED', 'FAILED')),
+    failure_reason TEXT,
+    provider_reference VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One live refund per payment; a failed one may be tried again
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_payment_live ON refunds(payment_id) WHERE status <> 'FAILED';
+CREATE INDEX IF NOT EXISTS idx_refunds_payment ON refunds(payment_id, created_at);
+
+DROP TRIGGER IF EXISTS update_refunds_updated_at ON refunds;
+CREATE TRIGGER update_refunds_updated_at
+    BEFORE UPDATE ON refunds
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN refunds.provider_reference IS 'Refund reference from the payment provider, when it gives one';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('refund.succeeded', 'SMS', 'am', NULL,
+     $tpl$የ{{printf "%.2f" .Amount}} {{.Currency}} ተመላሽ ገንዘብ (ማጣቀሻ፡ {{.Reference}}) ተልኳል።$tpl$),
+    ('refund.succeeded', 'SMS', 'en', NULL,
+     $tpl$Your refund of {{printf "%.2f" .Amount}} {{.Currency}} (Ref: {{.Reference}}) has been sent.$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
@@ -0,0 +1,18 @@
+-- Several partial refunds per payment. refunded_amount counts refunds that
+-- have not failed, so concurrent refunds cannot exceed the payment.
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_refunded_amount_check;
+ALTER TABLE payments ADD CONSTRAINT payments_refunded_amount_check
+    CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+
+DROP INDEX IF EXISTS idx_refunds_payment_live;
+
+UPDATE payments p SET refunded_amount = r.total
+FROM (
+    SELECT payment_id, SUM(amount) AS total FROM refunds WHERE status <> 'FAILED' GROUP BY payment_id
+) r
+WHERE p.id = r.payment_id;
+
+COMMENT ON COLUMN payments.refunded_amount IS 'Sum of refunds that are pending, processing or succeeded';
@@ -0,0 +1,5 @@
+-- CANCELLED: withdrawn by the merchant while still PENDING
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP', 'PROCESSING', 'AWAITING_CASH', 'CANCELLED'));
@@ -0,0 +1,30 @@
+-- Chargeback disputes raised by customers' banks against successful payments
+
+CREATE TABLE IF NOT EXISTS disputes (
+    id UUID PRIMARY KEY,
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(200) NOT NULL,
+    bank_reference VARCHAR(100),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('OPEN', 'EVIDENCE_SUBMITTED', 'WON', 'LOST')),
+    evidence TEXT,
+    resolution VARCHAR(500),
+    opened_by VARCHAR(100) NOT NULL,
+    resolved_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- One undecided dispute per payment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_disputes_payment_open ON disputes(payment_id) WHERE status IN ('OPEN', 'EVIDENCE_SUBMITTED');
+CREATE INDEX IF NOT EXISTS idx_disputes_payment ON disputes(payment_id, created_at);
+
+DROP TRIGGER IF EXISTS update_disputes_updated_at ON disputes;
+CREATE TRIGGER update_disputes_updated_at
+    BEFORE UPDATE ON disputes
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN disputes.opened_by IS 'Operator who recorded the bank''s claim';
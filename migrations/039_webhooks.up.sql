@@ -0,0 +1,46 @@
+-- Merchant webhooks: endpoints, one delivery per event and endpoint, and
+-- every HTTP attempt of a delivery
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY,
+    url VARCHAR(500) NOT NULL,
+    description VARCHAR(200),
+    events TEXT[] NOT NULL DEFAULT '{}',
+    secret VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id),
+    event VARCHAR(50) NOT NULL,
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_status_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id),
+    attempt_number INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    duration_ms BIGINT NOT NULL,
+    attempted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT webhook_attempts_number_unique UNIQUE (delivery_id, attempt_number)
+);
+
+COMMENT ON COLUMN webhook_endpoints.events IS 'Subscribed events; empty means every event';
+COMMENT ON COLUMN webhook_endpoints.secret IS 'HMAC-SHA256 key for the X-Webhook-Signature header';
+COMMENT ON COLUMN webhook_deliveries.locked_until IS 'Lease held by the worker sending it';
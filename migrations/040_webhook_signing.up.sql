@@ -0,0 +1,7 @@
+-- Outbound webhooks are signed in the X-Signature header. Bulk payout
+-- completion callbacks get their own key, returned once on upload.
+
+ALTER TABLE bulk_payout_jobs ADD COLUMN IF NOT EXISTS callback_secret VARCHAR(100);
+
+COMMENT ON COLUMN bulk_payout_jobs.callback_secret IS 'HMAC-SHA256 key for the completion callback''s X-Signature header';
+COMMENT ON COLUMN webhook_endpoints.secret IS 'HMAC-SHA256 key for the X-Signature header';
@@ -0,0 +1,4 @@
+-- FAILED webhook deliveries are the dead-letter list operators inspect
+-- and replay
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_failed ON webhook_deliveries(created_at DESC) WHERE status = 'FAILED';
@@ -0,0 +1,20 @@
+-- Merchant API keys. Only the SHA-256 of a key is stored; a rotated key
+-- keeps working until expires_at so the merchant can deploy the new one.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_merchant ON api_keys(merchant_id, created_at);
+
+COMMENT ON TABLE api_keys IS 'Keys merchants send as "Authorization: Bearer <key>" on the merchant API';
+COMMENT ON COLUMN api_keys.expires_at IS 'Set on rotation; the old key works until then';
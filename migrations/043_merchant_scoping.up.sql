@@ -0,0 +1,10 @@
+-- Payments and webhook endpoints belong to the merchant whose API key
+-- created them. Rows from before merchants had keys stay unowned.
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS merchant_id UUID REFERENCES merchants(id);
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS merchant_id UUID REFERENCES merchants(id);
+
+CREATE INDEX IF NOT EXISTS idx_payments_merchant ON payments(merchant_id, created_at DESC) WHERE merchant_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_merchant ON webhook_endpoints(merchant_id);
+
+COMMENT ON COLUMN payments.merchant_id IS 'Merchant whose API key created the payment; NULL before merchant scoping';
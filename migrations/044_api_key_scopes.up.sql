@@ -0,0 +1,6 @@
+-- API key scopes. Existing keys keep no scopes, which leaves them
+-- unrestricted.
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN api_keys.scopes IS 'payments:create, payments:read, refunds:create, statistics:read; empty means unrestricted';
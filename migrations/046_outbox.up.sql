@@ -0,0 +1,20 @@
+-- Transactional outbox. The API writes queue messages here and
+-- cmd/outbox-relay publishes them to RabbitMQ, marking each one sent once
+-- the broker confirms it.
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    routing_key VARCHAR(100) NOT NULL,
+    body JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent ON outbox(next_attempt_at) WHERE sent_at IS NULL;
+
+COMMENT ON COLUMN outbox.headers IS 'Trace context (traceparent, tracestate) to publish with the message';
@@ -0,0 +1,12 @@
+-- Bulk payout jobs and Telegram chats belong to the merchant whose API key
+-- created them. Telegram chats use the same string merchant ID as push
+-- devices and templates; '' is the gateway's own operators.
+
+ALTER TABLE bulk_payout_jobs ADD COLUMN IF NOT EXISTS merchant_id UUID REFERENCES merchants(id);
+ALTER TABLE telegram_chats ADD COLUMN IF NOT EXISTS merchant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_bulk_payout_jobs_merchant ON bulk_payout_jobs(merchant_id) WHERE merchant_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_telegram_chats_merchant ON telegram_chats(merchant_id);
+
+COMMENT ON COLUMN bulk_payout_jobs.merchant_id IS 'Merchant whose API key uploaded the file; NULL for operators';
+COMMENT ON COLUMN telegram_chats.merchant_id IS 'Merchant whose chat this is; '''' is the gateway operators, who hear about every payment';
@@ -0,0 +1,5 @@
+-- The audit log also records back-office changes: merchant activation and
+-- suspension, API keys, agent float top-ups, disputes, FX rates and webhook
+-- endpoints
+
+COMMENT ON COLUMN audit_events.entity_type IS 'payment, refund, merchant, api_key, agent, dispute, fx_rate or webhook';
@@ -0,0 +1,25 @@
+-- EXPIRED: left PENDING past payment_expiry.ttl and given up on by the worker
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP', 'PROCESSING', 'AWAITING_CASH', 'CANCELLED', 'EXPIRED'));
+
+-- The expiry job's sweep, oldest first
+CREATE INDEX IF NOT EXISTS idx_payments_pending ON payments(updated_at) WHERE status = 'PENDING';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('payment.expired', 'SMS', 'am', NULL,
+     $tpl$የ{{printf "%.2f" .Amount}} {{.Currency}} ክፍያዎ (ማጣቀሻ፡ {{.Reference}}) ሳይጠናቀቅ ጊዜው አልፏል። እባክዎ አዲስ ክፍያ ይጀምሩ።$tpl$),
+    ('payment.expired', 'SMS', 'en', NULL,
+     $tpl$Your payment of {{printf "%.2f" .Amount}} {{.Currency}} (Ref: {{.Reference}}) expired before it was completed. Please start a new payment.$tpl$),
+    ('payment.expired', 'TELEGRAM', 'am', NULL,
+     $tpl$⌛ <b>ክፍያው ጊዜው አልፏል</b>
+ማጣቀሻ፡ <code>{{.Reference}}</code>
+መጠን፡ {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+ባንክ፡ {{.BankCode}}{{end}}$tpl$),
+    ('payment.expired', 'TELEGRAM', 'en', NULL,
+     $tpl$⌛ <b>Payment expired</b>
+Reference: <code>{{.Reference}}</code>
+Amount: {{printf "%.2f" .Amount}} {{.Currency}}{{if .BankCode}}
+Bank: {{.BankCode}}{{end}}$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
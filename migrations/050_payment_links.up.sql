@@ -0,0 +1,27 @@
+-- Hosted payment links: a short URL to a checkout page that creates the payment
+
+CREATE TABLE IF NOT EXISTS payment_links (
+    id UUID PRIMARY KEY,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description VARCHAR(255),
+    language VARCHAR(2),
+    one_time BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    uses INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    merchant_id UUID REFERENCES merchants(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_links_merchant ON payment_links(merchant_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS payment_link_payments (
+    link_id UUID NOT NULL REFERENCES payment_links(id),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (link_id, payment_id)
+);
+
+COMMENT ON COLUMN payment_links.uses IS 'Payments created from the link; a one_time link takes one';
@@ -0,0 +1,95 @@
+-- Invoices with VAT line items, paid through their payment link or linked payments
+
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY,
+    number VARCHAR(20) NOT NULL UNIQUE,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('DRAFT', 'SENT', 'PAID')),
+    customer_name VARCHAR(100) NOT NULL,
+    customer_email VARCHAR(255),
+    customer_phone VARCHAR(20),
+    language VARCHAR(2),
+    currency VARCHAR(3) NOT NULL,
+    subtotal DECIMAL(15,2) NOT NULL,
+    vat_amount DECIMAL(15,2) NOT NULL,
+    total DECIMAL(15,2) NOT NULL CHECK (total > 0),
+    due_date DATE NOT NULL,
+    notes TEXT,
+    payment_link_id UUID REFERENCES payment_links(id),
+    merchant_id UUID REFERENCES merchants(id),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_merchant ON invoices(merchant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_payment_link ON invoices(payment_link_id) WHERE payment_link_id IS NOT NULL;
+
+DROP TRIGGER IF EXISTS update_invoices_updated_at ON invoices;
+CREATE TRIGGER update_invoices_updated_at
+    BEFORE UPDATE ON invoices
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS invoice_items (
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    position INTEGER NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    quantity DECIMAL(15,3) NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(15,2) NOT NULL,
+    vat_rate DECIMAL(5,2) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    vat_amount DECIMAL(15,2) NOT NULL,
+    PRIMARY KEY (invoice_id, position)
+);
+
+-- Payments linked by the merchant; payments made through the invoice's
+-- payment link are found through payment_link_payments
+CREATE TABLE IF NOT EXISTS invoice_payments (
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (invoice_id, payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_payments_payment ON invoice_payments(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_link_payments_payment ON payment_link_payments(payment_id);
+
+COMMENT ON COLUMN invoices.status IS 'PAID once successful linked payments cover the total';
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('invoice.sent', 'SMS', 'am', NULL,
+     $tpl$የክፍያ ጥያቄ {{.Number}}፡ {{printf "%.2f" .Total}} {{.Currency}} እስከ {{.DueDate}} ይክፈሉ። {{.PaymentURL}}$tpl$),
+    ('invoice.sent', 'SMS', 'en', NULL,
+     $tpl$Invoice {{.Number}}: {{printf "%.2f" .Total}} {{.Currency}} due {{.DueDate}}. Pay: {{.PaymentURL}}$tpl$),
+    ('invoice.sent', 'EMAIL', 'am', $tpl$የክፍያ ጥያቄ {{.Number}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>የክፍያ ጥያቄ {{.Number}}</h2>
+<p>ውድ {{.CustomerName}}፣ እባክዎ ይህን የክፍያ ጥያቄ እስከ {{.DueDate}} ይክፈሉ።</p>
+<table cellpadding="6">
+<tr><td><b>መግለጫ</b></td><td><b>ብዛት</b></td><td><b>የአንዱ ዋጋ</b></td><td><b>ተ.እ.ታ %</b></td><td><b>መጠን</b></td></tr>
+{{range .Items}}<tr><td>{{.Description}}</td><td>{{.Quantity}}</td><td>{{printf "%.2f" .UnitPrice}}</td><td>{{.VATRate}}</td><td>{{printf "%.2f" .Amount}}</td></tr>
+{{end}}<tr><td colspan="4"><b>ንዑስ ድምር</b></td><td>{{printf "%.2f" .Subtotal}}</td></tr>
+<tr><td colspan="4"><b>ተ.እ.ታ</b></td><td>{{printf "%.2f" .VATAmount}}</td></tr>
+<tr><td colspan="4"><b>ጠቅላላ</b></td><td><b>{{printf "%.2f" .Total}} {{.Currency}}</b></td></tr>
+</table>
+{{if .Notes}}<p>{{.Notes}}</p>{{end}}
+<p><a href="{{.PaymentURL}}">አሁን ይክፈሉ</a></p>
+</body></html>$tpl$),
+    ('invoice.sent', 'EMAIL', 'en', $tpl$Invoice {{.Number}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>Invoice {{.Number}}</h2>
+<p>Dear {{.CustomerName}}, please pay this invoice by {{.DueDate}}.</p>
+<table cellpadding="6">
+<tr><td><b>Description</b></td><td><b>Qty</b></td><td><b>Unit price</b></td><td><b>VAT %</b></td><td><b>Amount</b></td></tr>
+{{range .Items}}<tr><td>{{.Description}}</td><td>{{.Quantity}}</td><td>{{printf "%.2f" .UnitPrice}}</td><td>{{.VATRate}}</td><td>{{printf "%.2f" .Amount}}</td></tr>
+{{end}}<tr><td colspan="4"><b>Subtotal</b></td><td>{{printf "%.2f" .Subtotal}}</td></tr>
+<tr><td colspan="4"><b>VAT</b></td><td>{{printf "%.2f" .VATAmount}}</td></tr>
+<tr><td colspan="4"><b>Total</b></td><td><b>{{printf "%.2f" .Total}} {{.Currency}}</b></td></tr>
+</table>
+{{if .Notes}}<p>{{.Notes}}</p>{{end}}
+<p><a href="{{.PaymentURL}}">Pay now</a></p>
+</body></html>$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
@@ -0,0 +1,26 @@
+-- Refund notices by email, to the customer and as a copy to the merchant
+
+INSERT INTO notification_templates (event_type, channel, language, subject, body) VALUES
+    ('refund.succeeded', 'EMAIL', 'am', $tpl$ተመላሽ ገንዘብ ተልኳል፡ {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>ተመላሽ ገንዘብ ተልኳል</h2>
+<p>ለ{{if .CustomerName}}{{.CustomerName}}{{else}}ደንበኛው{{end}} የ{{printf "%.2f" .Amount}} {{.Currency}} ተመላሽ ገንዘብ ተልኳል። ወደ ሂሳቡ ለመድረስ ጥቂት የሥራ ቀናት ሊወስድ ይችላል።</p>
+<table cellpadding="6">
+<tr><td><b>ማጣቀሻ</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>ተመላሽ መጠን</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+{{if .BankCode}}<tr><td><b>ባንክ</b></td><td>{{.BankCode}}</td></tr>{{end}}
+{{if .Reason}}<tr><td><b>ምክንያት</b></td><td>{{.Reason}}</td></tr>{{end}}
+</table>
+</body></html>$tpl$),
+    ('refund.succeeded', 'EMAIL', 'en', $tpl$Refund sent: {{.Reference}}$tpl$,
+     $tpl$<html><body style="font-family: Arial, sans-serif">
+<h2>Refund Sent</h2>
+<p>A refund of {{printf "%.2f" .Amount}} {{.Currency}} has been sent to {{if .CustomerName}}{{.CustomerName}}{{else}}the customer{{end}}. It may take a few business days to reach the account.</p>
+<table cellpadding="6">
+<tr><td><b>Reference</b></td><td>{{.Reference}}</td></tr>
+<tr><td><b>Refunded</b></td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+{{if .BankCode}}<tr><td><b>Bank</b></td><td>{{.BankCode}}</td></tr>{{end}}
+{{if .Reason}}<tr><td><b>Reason</b></td><td>{{.Reason}}</td></tr>{{end}}
+</table>
+</body></html>$tpl$)
+ON CONFLICT (event_type, channel, language, merchant_id) DO NOTHING;
@@ -0,0 +1,20 @@
+-- Reconciliation of bank statement lines against payments. A file is
+-- waiting for the worker until reconciled_at is set.
+
+ALTER TABLE settlement_files ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_settlement_files_unreconciled ON settlement_files(created_at) WHERE reconciled_at IS NULL;
+
+ALTER TABLE settlement_lines ADD COLUMN IF NOT EXISTS payment_id UUID REFERENCES payments(id);
+ALTER TABLE settlement_lines ADD COLUMN IF NOT EXISTS match_status VARCHAR(20);
+ALTER TABLE settlement_lines ADD COLUMN IF NOT EXISTS match_note TEXT;
+
+ALTER TABLE settlement_lines DROP CONSTRAINT IF EXISTS settlement_lines_match_status_check;
+ALTER TABLE settlement_lines ADD CONSTRAINT settlement_lines_match_status_check
+    CHECK (match_status IN ('MATCHED', 'AMOUNT_MISMATCH', 'STATUS_MISMATCH', 'DUPLICATE', 'UNMATCHED', 'IGNORED'));
+
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_payment ON settlement_lines(payment_id) WHERE payment_id IS NOT NULL;
+
+COMMENT ON COLUMN settlement_files.reconciled_at IS 'Last reconciliation run; NULL until the worker has matched the lines';
+COMMENT ON COLUMN settlement_lines.payment_id IS 'Payment the line was matched to, also set on mismatches found by reference';
+COMMENT ON COLUMN settlement_lines.match_status IS 'NULL until reconciled';
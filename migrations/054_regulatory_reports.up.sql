@@ -0,0 +1,21 @@
+-- NBE transaction reports: payment volumes per bank, currency and Ethiopian
+-- business day, kept exactly as generated
+
+CREATE TABLE IF NOT EXISTS regulatory_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    period_from DATE NOT NULL,
+    period_to DATE NOT NULL,
+    institution_code VARCHAR(50) NOT NULL,
+    row_count INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    generated_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT regulatory_reports_period_check CHECK (period_to >= period_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_regulatory_reports_period ON regulatory_reports(period_from, period_to, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_regulatory_reports_created ON regulatory_reports(created_at DESC);
+
+COMMENT ON TABLE regulatory_reports IS 'Generated NBE reports; a period may be generated again after corrections, the newest is current';
+COMMENT ON COLUMN regulatory_reports.content IS 'The CSV as downloaded; checksum is its SHA-256';
@@ -0,0 +1,27 @@
+-- AML / sanctions screening of the customer name at payment creation. A
+-- payment whose name is on a watch list is held UNDER_REVIEW with a
+-- payment_reviews row until a compliance officer clears or rejects it.
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('PENDING', 'SUCCESS', 'FAILED', 'AWAITING_OTP', 'PROCESSING', 'AWAITING_CASH', 'CANCELLED', 'EXPIRED', 'UNDER_REVIEW'));
+
+CREATE TABLE IF NOT EXISTS payment_reviews (
+    id UUID PRIMARY KEY,
+    payment_id UUID NOT NULL UNIQUE REFERENCES payments(id),
+    customer_name VARCHAR(255) NOT NULL,
+    hits JSONB NOT NULL DEFAULT '[]',
+    reason TEXT NOT NULL,
+    release_status VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'CLEARED', 'REJECTED')),
+    decision_note TEXT,
+    decided_by VARCHAR(100),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- The review queue, oldest first
+CREATE INDEX IF NOT EXISTS idx_payment_reviews_open ON payment_reviews(created_at) WHERE status = 'OPEN';
+
+COMMENT ON COLUMN payment_reviews.hits IS 'Watch list entries the name matched: [{"list", "name", "score", "source"}]';
+COMMENT ON COLUMN payment_reviews.release_status IS 'Status the payment takes when cleared: PENDING, AWAITING_OTP or AWAITING_CASH';
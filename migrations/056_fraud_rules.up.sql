@@ -0,0 +1,43 @@
+-- Fraud rules engine. Enabled rules are tried in priority order before a
+-- payment is created; the first that matches allows, flags or blocks it.
+
+CREATE TABLE IF NOT EXISTS fraud_rules (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('AMOUNT_THRESHOLD', 'NEW_CUSTOMER_AMOUNT', 'REPEATED_FAILURES', 'UNUSUAL_HOUR')),
+    action VARCHAR(10) NOT NULL CHECK (action IN ('ALLOW', 'FLAG', 'BLOCK')),
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    amount_etb DECIMAL(15,2) NOT NULL DEFAULT 0,
+    failures INTEGER NOT NULL DEFAULT 0,
+    window_minutes INTEGER NOT NULL DEFAULT 0,
+    start_hour SMALLINT NOT NULL DEFAULT 0 CHECK (start_hour BETWEEN 0 AND 23),
+    end_hour SMALLINT NOT NULL DEFAULT 0 CHECK (end_hour BETWEEN 0 AND 23),
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_rules_enabled ON fraud_rules(priority, created_at) WHERE enabled;
+
+-- FLAG and BLOCK decisions; blocked payments are never created
+CREATE TABLE IF NOT EXISTS fraud_hits (
+    id UUID PRIMARY KEY,
+    rule_id UUID REFERENCES fraud_rules(id) ON DELETE SET NULL,
+    rule_name VARCHAR(100) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    reference VARCHAR(100),
+    amount DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount_etb DECIMAL(15,2) NOT NULL,
+    customer_phone VARCHAR(20),
+    merchant_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_hits_created ON fraud_hits(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_hits_rule ON fraud_hits(rule_id, created_at DESC);
+
+COMMENT ON COLUMN fraud_rules.amount_etb IS 'Threshold; for UNUSUAL_HOUR an optional minimum';
+COMMENT ON COLUMN fraud_rules.start_hour IS 'UNUSUAL_HOUR window in Ethiopian time, end exclusive; start > end spans midnight';
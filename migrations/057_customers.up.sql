@@ -0,0 +1,38 @@
+-- KYC customer profiles. Merchants register customers with their identity
+-- documents; operators verify them. Payments may name the customer they are
+-- for, which large payments must when KYC is enforced.
+
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY,
+    full_name VARCHAR(255) NOT NULL,
+    national_id VARCHAR(16),
+    passport_number VARCHAR(20),
+    phone VARCHAR(20) NOT NULL,
+    email VARCHAR(254),
+    verification_status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (verification_status IN ('PENDING', 'VERIFIED', 'REJECTED')),
+    verification_note TEXT,
+    verified_by VARCHAR(100),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    merchant_id UUID REFERENCES merchants(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (national_id IS NOT NULL OR passport_number IS NOT NULL)
+);
+
+-- One profile per document and phone for each merchant; customers registered
+-- without a merchant share the gateway's own namespace
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_national_id
+    ON customers(COALESCE(merchant_id, '00000000-0000-0000-0000-000000000000'), national_id) WHERE national_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_passport
+    ON customers(COALESCE(merchant_id, '00000000-0000-0000-0000-000000000000'), passport_number) WHERE passport_number IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone
+    ON customers(COALESCE(merchant_id, '00000000-0000-0000-0000-000000000000'), phone);
+
+-- The verification queue, oldest first
+CREATE INDEX IF NOT EXISTS idx_customers_status ON customers(verification_status, created_at);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id);
+CREATE INDEX IF NOT EXISTS idx_payments_customer_id ON payments(customer_id) WHERE customer_id IS NOT NULL;
+
+COMMENT ON COLUMN customers.national_id IS 'Fayda FIN (12 digits) or FAN (16 digits)';
+COMMENT ON COLUMN payments.customer_id IS 'KYC profile the payment is for; required above kyc.require_verified_above';
@@ -0,0 +1,9 @@
+-- Payment listings page by (created_at, id) so that payments created while
+-- a client pages through are neither skipped nor repeated. The id breaks
+-- ties between payments created in the same microsecond.
+
+CREATE INDEX IF NOT EXISTS idx_payments_created_id ON payments(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_created_id ON payments(merchant_id, created_at DESC, id DESC) WHERE merchant_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_payments_created_at;
+DROP INDEX IF EXISTS idx_payments_merchant;
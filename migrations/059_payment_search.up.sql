@@ -0,0 +1,18 @@
+-- Support staff search payments by a partial reference, customer name or
+-- description. Trigram indexes serve substring matches such as part of a
+-- reference; the 'simple' text search vector matches whole words in any
+-- script, Amharic names included, without English stemming.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        to_tsvector('simple', reference || ' ' || COALESCE(customer_name, '') || ' ' || COALESCE(description, ''))
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_payments_search_vector ON payments USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_payments_reference_trgm ON payments USING GIN (reference gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_payments_customer_name_trgm ON payments USING GIN (customer_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_payments_description_trgm ON payments USING GIN (description gin_trgm_ops);
+
+COMMENT ON COLUMN payments.search_vector IS 'Words of the reference, customer name and description for GET /payments/search';
@@ -0,0 +1,16 @@
+-- Each migration records its version here so the gateway's readiness
+-- probe (/readyz) can tell when the database is behind the code. New
+-- migrations end with their own
+--   INSERT INTO schema_migrations (version) VALUES (<n>) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- This one and every migration before it, applied before the table existed
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 60)
+ON CONFLICT DO NOTHING;
+
+COMMENT ON TABLE schema_migrations IS 'Applied migrations/*.up.sql versions, checked by /readyz';
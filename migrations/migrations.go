@@ -0,0 +1,43 @@
+// Package migrations holds the SQL migrations, applied in file name order
+// by make migrate. Each records its version in schema_migrations, so a
+// running gateway can tell whether the database is behind its code.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.up.sql
+var files embed.FS
+
+// Latest is the version of the newest migration
+func Latest() int {
+	entries, _ := files.ReadDir(".")
+	latest := 0
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		if version, err := strconv.Atoi(prefix); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// Check fails while the database has not had every migration applied
+func Check(ctx context.Context, db *pgxpool.Pool) error {
+	var applied int
+	err := db.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	if latest := Latest(); applied < latest {
+		return fmt.Errorf("database is at migration %d, the code needs %d", applied, latest)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package gatewayclient is the Go client for the Ethiopian Payment Gateway
+// API. It depends on nothing inside the gateway, so merchants can import it
+// from outside this module.
+package gatewayclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	apiKey     string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client (30s timeout)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithAPIKey authenticates every request with the merchant's API key
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// New creates a client for the gateway at baseURL, e.g. "https://pay.example.et"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + "/api/v1",
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  "payment-gateway-go-client/1.0",
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// APIError is returned for any non-2xx response
+type APIError struct {
+	StatusCode int
+	Code       string // Machine-readable reason, when the gateway gives one
+	Message    string
+	Details    string
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("gateway returned %d: %s (%s)", e.StatusCode, e.Message, e.Details)
+	}
+	return fmt.Sprintf("gateway returned %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the gateway
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsVelocityLimited reports whether the gateway rejected a payment over a
+// customer velocity limit; it may be retried once the window has passed
+func IsVelocityLimited(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Code == "VELOCITY_LIMIT_EXCEEDED"
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errBody); err == nil {
+			apiErr.Message = errBody.Error
+			apiErr.Details = errBody.Details
+			apiErr.Code = errBody.Code
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RotateAPIKey replaces the client's API key. The old key keeps working
+// for the gateway's grace period; create a new Client with the returned Key.
+func (c *Client) RotateAPIKey(ctx context.Context) (*IssuedAPIKey, error) {
+	var out IssuedAPIKey
+	if err := c.do(ctx, http.MethodPost, "/api-keys/rotate", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,82 @@
+package gatewayclient
+
+import "context"
+
+// PaymentIterator walks every page of a payment listing:
+//
+//	it := client.Payments(gatewayclient.ListOptions{Limit: 100})
+//	for it.Next(ctx) {
+//		p := it.Payment()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type PaymentIterator struct {
+	fetch func(ctx context.Context, opts ListOptions) (*PaymentList, error)
+	opts  ListOptions
+	page  []Payment
+	index int
+	done  bool
+	err   error
+}
+
+// Payments iterates over all payments, starting at opts.Page
+func (c *Client) Payments(opts ListOptions) *PaymentIterator {
+	return newPaymentIterator(c.ListPayments, opts)
+}
+
+// CustomerPayments iterates over all payments of a customer phone number
+func (c *Client) CustomerPayments(phone string, opts ListOptions) *PaymentIterator {
+	return newPaymentIterator(func(ctx context.Context, opts ListOptions) (*PaymentList, error) {
+		return c.ListCustomerPayments(ctx, phone, opts)
+	}, opts)
+}
+
+func newPaymentIterator(fetch func(ctx context.Context, opts ListOptions) (*PaymentList, error), opts ListOptions) *PaymentIterator {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.Limit < 1 {
+		opts.Limit = 20
+	}
+
+	return &PaymentIterator{fetch: fetch, opts: opts, index: -1}
+}
+
+// Next advances to the next payment, fetching the next page when needed
+func (it *PaymentIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	if it.index < len(it.page) {
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	resp, err := it.fetch(ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = resp.Payments
+	it.index = 0
+	it.opts.Page++
+	// Listings that return a cursor are followed by it, so payments created
+	// meanwhile do not shift the pages
+	it.opts.Cursor = resp.NextCursor
+	// A short page is the last one, whatever has_more says
+	it.done = !resp.HasMore || len(resp.Payments) < it.opts.Limit
+
+	return len(it.page) > 0
+}
+
+func (it *PaymentIterator) Payment() Payment {
+	return it.page[it.index]
+}
+
+func (it *PaymentIterator) Err() error {
+	return it.err
+}
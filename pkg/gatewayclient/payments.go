@@ -0,0 +1,271 @@
+package gatewayclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (c *Client) CreatePayment(ctx context.Context, req CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	var out CreatePaymentResponse
+	if err := c.do(ctx, http.MethodPost, "/payments", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetPayment(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	var out Payment
+	if err := c.do(ctx, http.MethodGet, "/payments/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetPaymentByReference(ctx context.Context, reference string) (*Payment, error) {
+	var out Payment
+	query := url.Values{"reference": {reference}}
+	if err := c.do(ctx, http.MethodGet, "/payments/by-reference", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchPayments finds payments by part of a reference, customer name or
+// description; only opts.Page and opts.Limit apply
+func (c *Client) SearchPayments(ctx context.Context, q string, opts ListOptions) (*PaymentList, error) {
+	query := url.Values{"q": {q}}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var out PaymentList
+	if err := c.do(ctx, http.MethodGet, "/payments/search", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOptions selects a page; zero values use the gateway defaults (page 1, 20 items)
+type ListOptions struct {
+	Page  int
+	Limit int
+
+	// Filters for ListPayments; ignored by other lists
+	Cursor       string // NextCursor of the previous page; takes precedence over Page
+	PurposeCode  string
+	MCC          string
+	LimitFlagged bool
+	Tags         []string // Payments carrying all of these tags
+	Status       PaymentStatus
+	Currency     Currency
+	BankCode     string
+	MinAmount    float64 // Inclusive; 0 for no bound
+	MaxAmount    float64
+	CreatedFrom  time.Time // Inclusive
+	CreatedTo    time.Time // Exclusive
+	Sort         string    // -created_at (default), created_at, -amount or amount
+}
+
+func (o ListOptions) values() url.Values {
+	query := url.Values{}
+	if o.Page > 0 {
+		query.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+	if o.PurposeCode != "" {
+		query.Set("purpose_code", o.PurposeCode)
+	}
+	if o.MCC != "" {
+		query.Set("mcc", o.MCC)
+	}
+	if o.LimitFlagged {
+		query.Set("limit_flagged", "true")
+	}
+	if len(o.Tags) > 0 {
+		query.Set("tags", strings.Join(o.Tags, ","))
+	}
+	if o.Status != "" {
+		query.Set("status", string(o.Status))
+	}
+	if o.Currency != "" {
+		query.Set("currency", string(o.Currency))
+	}
+	if o.BankCode != "" {
+		query.Set("bank_code", o.BankCode)
+	}
+	if o.MinAmount > 0 {
+		query.Set("min_amount", strconv.FormatFloat(o.MinAmount, 'f', 2, 64))
+	}
+	if o.MaxAmount > 0 {
+		query.Set("max_amount", strconv.FormatFloat(o.MaxAmount, 'f', 2, 64))
+	}
+	if !o.CreatedFrom.IsZero() {
+		query.Set("created_from", o.CreatedFrom.Format(time.RFC3339))
+	}
+	if !o.CreatedTo.IsZero() {
+		query.Set("created_to", o.CreatedTo.Format(time.RFC3339))
+	}
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	return query
+}
+
+func (c *Client) ListPayments(ctx context.Context, opts ListOptions) (*PaymentList, error) {
+	var out PaymentList
+	if err := c.do(ctx, http.MethodGet, "/payments", opts.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListCustomerPayments looks up payments by customer phone in any Ethiopian format
+func (c *Client) ListCustomerPayments(ctx context.Context, phone string, opts ListOptions) (*PaymentList, error) {
+	var out PaymentList
+	if err := c.do(ctx, http.MethodGet, "/customers/"+url.PathEscape(phone)+"/payments", opts.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetTags replaces a payment's tags; an empty list clears them
+func (c *Client) SetTags(ctx context.Context, id uuid.UUID, tags []string) (*Payment, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	var out Payment
+	if err := c.do(ctx, http.MethodPut, "/payments/"+id.String()+"/tags", nil, setTagsRequest{Tags: tags}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmOTP submits the customer's code for a payment created with RequireOTP
+func (c *Client) ConfirmOTP(ctx context.Context, id uuid.UUID, code string) (*Payment, error) {
+	var out Payment
+	if err := c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/confirm-otp", nil, confirmOTPRequest{Code: code}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) ResendOTP(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/resend-otp", nil, nil, nil)
+}
+
+// RetryPayment re-queues a FAILED payment; bankCode may be empty to keep the original bank.
+func (c *Client) RetryPayment(ctx context.Context, id uuid.UUID, bankCode string) (*Payment, error) {
+	var out Payment
+	if err := c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/retry", nil, retryPaymentRequest{BankCode: bankCode}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelPayment withdraws a payment that is still PENDING
+func (c *Client) CancelPayment(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	var out Payment
+	if err := c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/cancel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPaymentAttempts returns every processing attempt for a payment, oldest first.
+func (c *Client) ListPaymentAttempts(ctx context.Context, id uuid.UUID) ([]*PaymentAttempt, error) {
+	var out []*PaymentAttempt
+	if err := c.do(ctx, http.MethodGet, "/payments/"+id.String()+"/attempts", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IssueCashVoucher returns a voucher code for a payment created with PayByCash;
+// any previous code stops working
+func (c *Client) IssueCashVoucher(ctx context.Context, id uuid.UUID) (*CashVoucher, error) {
+	var out CashVoucher
+	if err := c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/cash-voucher", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateReceiptLink issues a shareable receipt URL; any previous URL stops working
+func (c *Client) CreateReceiptLink(ctx context.Context, id uuid.UUID) (*ReceiptLink, error) {
+	var out ReceiptLink
+	if err := c.do(ctx, http.MethodPost, "/payments/"+id.String()+"/receipt-link", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) RevokeReceiptLink(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/payments/"+id.String()+"/receipt-link", nil, nil, nil)
+}
+
+// CreateRefund queues a refund of a successful payment; a zero req.Amount
+// refunds whatever has not been refunded yet. Poll GetRefund until it is
+// SUCCEEDED or FAILED.
+func (c *Client) CreateRefund(ctx context.Context, paymentID uuid.UUID, req CreateRefundRequest) (*Refund, error) {
+	var out Refund
+	if err := c.do(ctx, http.MethodPost, "/payments/"+paymentID.String()+"/refunds", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRefunds returns a payment's refunds, oldest first
+func (c *Client) ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error) {
+	var out []*Refund
+	if err := c.do(ctx, http.MethodGet, "/payments/"+paymentID.String()+"/refunds", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetRefund(ctx context.Context, id uuid.UUID) (*Refund, error) {
+	var out Refund
+	if err := c.do(ctx, http.MethodGet, "/refunds/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDisputes returns a payment's chargeback disputes, oldest first
+func (c *Client) ListDisputes(ctx context.Context, paymentID uuid.UUID) ([]*Dispute, error) {
+	var out []*Dispute
+	if err := c.do(ctx, http.MethodGet, "/payments/"+paymentID.String()+"/disputes", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetDispute(ctx context.Context, id uuid.UUID) (*Dispute, error) {
+	var out Dispute
+	if err := c.do(ctx, http.MethodGet, "/disputes/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitDisputeEvidence answers an OPEN dispute; evidence can be submitted once
+func (c *Client) SubmitDisputeEvidence(ctx context.Context, id uuid.UUID, evidence string) (*Dispute, error) {
+	var out Dispute
+	if err := c.do(ctx, http.MethodPost, "/disputes/"+id.String()+"/evidence", nil, submitDisputeEvidenceRequest{Evidence: evidence}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
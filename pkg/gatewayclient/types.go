@@ -0,0 +1,285 @@
+package gatewayclient
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Currency string
+
+const (
+	CurrencyETB Currency = "ETB" // Ethiopian Birr
+	CurrencyUSD Currency = "USD" // US Dollar
+	CurrencyEUR Currency = "EUR" // Euro
+	CurrencyGBP Currency = "GBP" // Pound Sterling
+	CurrencyAED Currency = "AED" // UAE Dirham
+	CurrencyCNY Currency = "CNY" // Chinese Yuan
+)
+
+type PaymentStatus string
+
+const (
+	StatusPending      PaymentStatus = "PENDING"
+	StatusSuccess      PaymentStatus = "SUCCESS"
+	StatusFailed       PaymentStatus = "FAILED"
+	StatusAwaitingOTP  PaymentStatus = "AWAITING_OTP"  // Held until the customer confirms the OTP
+	StatusProcessing   PaymentStatus = "PROCESSING"    // Sent to the bank, result not yet known
+	StatusAwaitingCash PaymentStatus = "AWAITING_CASH" // Held until cash is paid against a voucher
+	StatusCancelled    PaymentStatus = "CANCELLED"     // Withdrawn by the merchant before it was processed
+	StatusExpired      PaymentStatus = "EXPIRED"       // Never processed before the gateway's expiry window
+	StatusUnderReview  PaymentStatus = "UNDER_REVIEW"  // Held for a compliance review before processing
+)
+
+type PaymentMethod string
+
+const (
+	MethodBank      PaymentMethod = "BANK"
+	MethodArifPay   PaymentMethod = "ARIFPAY"   // ArifPay hosted checkout
+	MethodSantimPay PaymentMethod = "SANTIMPAY" // SantimPay hosted checkout
+	MethodCard      PaymentMethod = "CARD"      // Domestic debit card through EthSwitch
+)
+
+// Event is the kind of a webhook request
+type Event string
+
+const (
+	EventPaymentSucceeded Event = "payment.succeeded"
+	EventPaymentFailed    Event = "payment.failed"
+	EventRefundSucceeded  Event = "refund.succeeded"
+	EventPaymentExpired   Event = "payment.expired"
+)
+
+type CreatePaymentRequest struct {
+	Amount             float64       `json:"amount"`
+	Currency           Currency      `json:"currency"`
+	Reference          string        `json:"reference,omitempty"`        // Generated by the gateway when empty
+	ReferencePrefix    string        `json:"reference_prefix,omitempty"` // One of the configured prefixes; generated references only
+	Description        string        `json:"description,omitempty"`
+	CustomerName       string        `json:"customer_name,omitempty"`
+	CustomerPhone      string        `json:"customer_phone,omitempty"`
+	CustomerEmail      string        `json:"customer_email,omitempty"`
+	CustomerNationalID string        `json:"customer_national_id,omitempty"` // Fayda FIN or FAN
+	CustomerID         string        `json:"customer_id,omitempty"`          // Registered KYC customer; required above the gateway's KYC threshold
+	Language           string        `json:"language,omitempty"`             // am or en
+	BankCode           string        `json:"bank_code,omitempty"`
+	RequireOTP         bool          `json:"require_otp,omitempty"`  // Customer must confirm an SMS OTP before debiting
+	PayByCash          bool          `json:"pay_by_cash,omitempty"`  // Customer pays cash at a branch/agent against a voucher
+	PurposeCode        string        `json:"purpose_code,omitempty"` // Required for FX and high-value payments
+	MCC                string        `json:"mcc,omitempty"`          // Required for FX and high-value payments
+	FXQuoteID          string        `json:"fx_quote_id,omitempty"`  // From POST /fx/quotes; locks the rate to ETB
+	Tags               []string      `json:"tags,omitempty"`
+	PaymentMethod      PaymentMethod `json:"payment_method,omitempty"` // BANK when empty
+}
+
+type CreatePaymentResponse struct {
+	Message       string        `json:"message"`
+	PaymentID     uuid.UUID     `json:"payment_id"`
+	Status        PaymentStatus `json:"status"`
+	Reference     string        `json:"reference"`
+	CheckoutURL   string        `json:"checkout_url,omitempty"` // Send the customer here for redirect payment methods
+	CreatedAt     string        `json:"created_at"`
+	EthiopianTime string        `json:"ethiopian_time"`
+}
+
+type Payment struct {
+	ID                 uuid.UUID     `json:"id"`
+	Amount             float64       `json:"amount"`
+	Currency           Currency      `json:"currency"`
+	CurrencySymbol     string        `json:"currency_symbol"`
+	Reference          string        `json:"reference"`
+	Status             PaymentStatus `json:"status"`
+	Description        string        `json:"description,omitempty"`
+	CustomerName       string        `json:"customer_name,omitempty"`
+	CustomerPhone      string        `json:"customer_phone,omitempty"`
+	CustomerEmail      string        `json:"customer_email,omitempty"`
+	CustomerNationalID string        `json:"customer_national_id,omitempty"`
+	CustomerID         *uuid.UUID    `json:"customer_id,omitempty"`
+	Language           string        `json:"language,omitempty"`
+	BankCode           string        `json:"bank_code,omitempty"`
+	PurposeCode        string        `json:"purpose_code,omitempty"`
+	MCC                string        `json:"mcc,omitempty"`
+	LimitFlag          string        `json:"limit_flag,omitempty"`
+	ClientIP           string        `json:"client_ip,omitempty"`
+	ClientCountry      string        `json:"client_country,omitempty"`
+	DeviceFingerprint  string        `json:"device_fingerprint,omitempty"`
+	FXQuoteID          *uuid.UUID    `json:"fx_quote_id,omitempty"`
+	FXRate             float64       `json:"fx_rate,omitempty"`
+	AmountETB          float64       `json:"amount_etb,omitempty"`
+	Tags               []string      `json:"tags,omitempty"`
+	ProviderReference  string        `json:"provider_reference,omitempty"`
+	CheckoutURL        string        `json:"checkout_url,omitempty"` // Send the customer here while the payment is PROCESSING
+	PaymentMethod      PaymentMethod `json:"payment_method"`
+	RefundedAmount     float64       `json:"refunded_amount,omitempty"`
+	MerchantID         *uuid.UUID    `json:"merchant_id,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+	CreatedAtET        string        `json:"created_at_et"` // Ethiopian time
+	// Ethiopian calendar date
+	CreatedAtEthiopian     string `json:"created_at_ethiopian"`      // e.g. "15-04-2016 EC"
+	CreatedAtEthiopianGeez string `json:"created_at_ethiopian_geez"` // e.g. "ታኅሣሥ ፲፭ ቀን ፳፻፲፮ ዓ.ም."
+
+	// Proof-of-payment documents; only set on single-payment lookups
+	Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	ID           uuid.UUID  `json:"id"`
+	PaymentID    uuid.UUID  `json:"payment_id"`
+	Kind         string     `json:"kind"`
+	FileName     string     `json:"file_name"`
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	Checksum     string     `json:"checksum"` // SHA-256 of the contents, hex
+	UploadedBy   string     `json:"uploaded_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	URL          string     `json:"url,omitempty"` // Short-lived signed download link
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+type PaymentList struct {
+	Payments   []Payment `json:"payments"`
+	Total      int       `json:"total"`
+	TotalPages int       `json:"total_pages"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type PaymentAttempt struct {
+	ID            uuid.UUID  `json:"id"`
+	PaymentID     uuid.UUID  `json:"payment_id"`
+	AttemptNumber int        `json:"attempt_number"`
+	BankCode      string     `json:"bank_code,omitempty"`
+	Trigger       string     `json:"trigger"`
+	Status        string     `json:"status"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+type CashVoucher struct {
+	ID             uuid.UUID  `json:"id"`
+	PaymentID      uuid.UUID  `json:"payment_id"`
+	Code           string     `json:"code"`
+	Amount         float64    `json:"amount"`
+	Currency       Currency   `json:"currency"`
+	Status         string     `json:"status"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RedeemedAt     *time.Time `json:"redeemed_at,omitempty"`
+	RedeemedBy     string     `json:"redeemed_by,omitempty"`
+	AgentReference string     `json:"agent_reference,omitempty"`
+	Branch         string     `json:"branch,omitempty"`
+	Reference      string     `json:"reference,omitempty"`
+	CustomerName   string     `json:"customer_name,omitempty"`
+}
+
+type ReceiptLink struct {
+	PaymentID uuid.UUID  `json:"payment_id"`
+	URL       string     `json:"url,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateRefundRequest struct {
+	Amount float64 `json:"amount,omitempty"` // Omit to refund whatever has not been refunded yet
+	Reason string  `json:"reason"`
+}
+
+type Refund struct {
+	ID                uuid.UUID `json:"id"`
+	PaymentID         uuid.UUID `json:"payment_id"`
+	Amount            float64   `json:"amount"`
+	Currency          Currency  `json:"currency"`
+	Reason            string    `json:"reason"`
+	Status            string    `json:"status"` // PENDING, SUCCESS or FAILED
+	FailureReason     string    `json:"failure_reason,omitempty"`
+	ProviderReference string    `json:"provider_reference,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type Dispute struct {
+	ID            uuid.UUID  `json:"id"`
+	PaymentID     uuid.UUID  `json:"payment_id"`
+	Amount        float64    `json:"amount"`
+	Currency      Currency   `json:"currency"`
+	Reason        string     `json:"reason"`
+	BankReference string     `json:"bank_reference,omitempty"` // The bank's case number
+	Status        string     `json:"status"`
+	Evidence      string     `json:"evidence,omitempty"`
+	Resolution    string     `json:"resolution,omitempty"`
+	OpenedBy      string     `json:"opened_by"`
+	ResolvedBy    string     `json:"resolved_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
+
+type RegisterWebhookRequest struct {
+	URL         string  `json:"url"`
+	Description string  `json:"description,omitempty"`
+	Events      []Event `json:"events,omitempty"` // Omit for every event
+}
+
+type WebhookEndpoint struct {
+	ID          uuid.UUID  `json:"id"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Events      []Event    `json:"events"` // Empty means every event
+	Active      bool       `json:"active"`
+	Secret      string     `json:"secret,omitempty"` // Only returned when the endpoint is registered
+	MerchantID  *uuid.UUID `json:"merchant_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	EndpointID     uuid.UUID       `json:"endpoint_id"`
+	Event          Event           `json:"event"`
+	PaymentID      uuid.UUID       `json:"payment_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// IssuedAPIKey is the only time a key is returned in full
+type IssuedAPIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	MerchantID uuid.UUID  `json:"merchant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Key        string     `json:"key"`
+}
+
+type errorResponse struct {
+	Error   string `json:"error"`
+	Code    string `json:"code,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+type setTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type confirmOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type retryPaymentRequest struct {
+	BankCode string `json:"bank_code,omitempty"`
+}
+
+type submitDisputeEvidenceRequest struct {
+	Evidence string `json:"evidence"`
+}
@@ -0,0 +1,95 @@
+package gatewayclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"payment-gateway/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// RegisterWebhook adds an endpoint for payment events. Keep the returned
+// Secret: it is needed to verify requests and is not shown again.
+func (c *Client) RegisterWebhook(ctx context.Context, req RegisterWebhookRequest) (*WebhookEndpoint, error) {
+	var out WebhookEndpoint
+	if err := c.do(ctx, http.MethodPost, "/webhooks", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*WebhookEndpoint, error) {
+	var out []*WebhookEndpoint
+	if err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+id.String(), nil, nil, nil)
+}
+
+// ListWebhookDeliveries returns an endpoint's latest deliveries, newest first
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id uuid.UUID) ([]*WebhookDelivery, error) {
+	var out []*WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/webhooks/"+id.String()+"/deliveries", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListFailedWebhookDeliveries returns deliveries that ran out of attempts
+func (c *Client) ListFailedWebhookDeliveries(ctx context.Context) ([]*WebhookDelivery, error) {
+	var out []*WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/webhooks/deliveries/failed", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReplayWebhookDelivery sends a failed delivery once more
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	if err := c.do(ctx, http.MethodPost, "/webhooks/deliveries/"+id.String()+"/replay", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WebhookEvent is the body of a webhook request
+type WebhookEvent struct {
+	ID        string    `json:"-"` // webhook.HeaderID; the same on every retry
+	Event     Event     `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      Payment   `json:"data"`
+}
+
+// ErrInvalidWebhookSignature is returned by VerifyWebhook for a request the
+// gateway did not sign, or signed longer ago than the tolerance
+var ErrInvalidWebhookSignature = webhook.ErrInvalidSignature
+
+// VerifyWebhook checks a webhook request against the endpoint secret and
+// decodes it. tolerance bounds the request's age; zero allows 5 minutes.
+// Use webhook.Verify directly for bulk payout callbacks.
+func VerifyWebhook(r *http.Request, secret string, tolerance time.Duration) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := webhook.Verify(secret, r.Header, body, tolerance); err != nil {
+		return nil, err
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	event.ID = r.Header.Get(webhook.HeaderID)
+	return &event, nil
+}
@@ -0,0 +1,7 @@
+// Package paymentpb is the gRPC client and server code of the payment
+// service, generated from proto/payment/v1/payment.proto. Internal
+// services dial the API's grpc.listen_addr and call
+// NewPaymentServiceClient.
+package paymentpb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=payment-gateway/pkg/paymentpb --go-grpc_out=. --go-grpc_opt=module=payment-gateway/pkg/paymentpb payment/v1/payment.proto